@@ -4,6 +4,9 @@ package constraint
 
 import (
 	"math/big"
+	"net/mail"
+	"net/netip"
+	"net/url"
 )
 
 // SPDX-License-Identifier: Apache-2.0
@@ -43,6 +46,11 @@ type Numeric interface {
 	IntegerAndFloat | *big.Int | *big.Float | *big.Rat
 }
 
+// Net describes the net types that conv can convert to and from a string
+type Net interface {
+	netip.Addr | netip.Prefix | *url.URL | *mail.Address
+}
+
 // Ordered is equivalent to golang.org/x/exp/constraints#Ordered
 type Ordered interface {
 	Signed | UnsignedInteger | ~string
@@ -0,0 +1,227 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/bantling/micro/funcs"
+)
+
+const (
+	errBaseRangeMsg    = "The base %d is not in the range 2 thru 62"
+	errBaseAlphabetMsg = "A base encoding alphabet must have at least 2 distinct ASCII characters, not %q"
+
+	// base62Alphabet is the same alphabet big.Int uses for base 62
+	base62Alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+	// base58Alphabet is the bitcoin alphabet, which excludes 0, O, I, and l to avoid confusing them
+	base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+)
+
+var (
+	// Base62 encodes integers with digits, lowercase letters, then uppercase letters, the same as BigIntToStringBase(62)
+	Base62 = NewBaseEncoding(base62Alphabet)
+
+	// Base58 encodes integers with the bitcoin alphabet, which has no characters that look alike
+	Base58 = NewBaseEncoding(base58Alphabet)
+)
+
+// StringToBigIntBase converts a string of digits in the given base to a *big.Int, with an optional leading sign.
+// For bases up to 36, letters are case insensitive. For bases 37 thru 62, lowercase letters are 10 thru 35, and
+// uppercase letters are 36 thru 61.
+//
+// Returns an error if the base is not in the range 2 thru 62, or the string is not a valid integer in the base.
+func StringToBigIntBase(ival string, base int, oval **big.Int) error {
+	if (base < 2) || (base > 62) {
+		return fmt.Errorf(errBaseRangeMsg, base)
+	}
+
+	var (
+		inter = big.NewInt(0)
+		ok    bool
+	)
+
+	if _, ok = inter.SetString(ival, base); !ok {
+		return fmt.Errorf(errMsg, ival, ival, "*big.Int")
+	}
+
+	*oval = inter
+	return nil
+}
+
+// MustStringToBigIntBase is a Must version of StringToBigIntBase
+func MustStringToBigIntBase(ival string, base int, oval **big.Int) {
+	funcs.Must(StringToBigIntBase(ival, base, oval))
+}
+
+// BigIntToStringBase converts a *big.Int to a string of digits in the given base, using lowercase letters for digits
+// 10 thru 35, and uppercase letters for digits 36 thru 61.
+//
+// Returns an error if the base is not in the range 2 thru 62.
+func BigIntToStringBase(val *big.Int, base int) (string, error) {
+	if (base < 2) || (base > 62) {
+		return "", fmt.Errorf(errBaseRangeMsg, base)
+	}
+
+	return val.Text(base), nil
+}
+
+// MustBigIntToStringBase is a Must version of BigIntToStringBase
+func MustBigIntToStringBase(val *big.Int, base int) string {
+	return funcs.MustValue(BigIntToStringBase(val, base))
+}
+
+// BaseEncoding is a reversible encoding of integers as strings of characters from an alphabet, where the first
+// character is the digit 0, the second is 1, and so on. It is useful for compact identifiers in public APIs, such as
+// URLs. Unlike encodings of byte strings, such as bitcoin base58, an integer has no leading zero digits, so 0 is
+// encoded as the first character alone.
+//
+// Negative integers have a leading minus sign, so the alphabet cannot contain one.
+type BaseEncoding struct {
+	alphabet string
+	digits   [256]int16
+}
+
+// NewBaseEncoding constructs a BaseEncoding of the given alphabet.
+// Panics if the alphabet has less than 2 characters, or has duplicate, minus sign, or non-ASCII characters.
+func NewBaseEncoding(alphabet string) *BaseEncoding {
+	enc := &BaseEncoding{alphabet: alphabet}
+	for i := range enc.digits {
+		enc.digits[i] = -1
+	}
+
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if (c >= 0x80) || (c == '-') || (enc.digits[c] >= 0) {
+			panic(fmt.Errorf(errBaseAlphabetMsg, alphabet))
+		}
+
+		enc.digits[c] = int16(i)
+	}
+
+	if len(alphabet) < 2 {
+		panic(fmt.Errorf(errBaseAlphabetMsg, alphabet))
+	}
+
+	return enc
+}
+
+// Base returns the number of characters in the alphabet
+func (enc *BaseEncoding) Base() int {
+	return len(enc.alphabet)
+}
+
+// EncodeUint64 encodes a uint64
+func (enc *BaseEncoding) EncodeUint64(val uint64) string {
+	var (
+		base = uint64(len(enc.alphabet))
+		buf  [64]byte
+		i    = len(buf)
+	)
+
+	for {
+		i--
+		buf[i] = enc.alphabet[val%base]
+		if val /= base; val == 0 {
+			break
+		}
+	}
+
+	return string(buf[i:])
+}
+
+// DecodeUint64 decodes a uint64.
+// Returns an error if the string is empty, has characters that are not in the alphabet, or overflows a uint64.
+func (enc *BaseEncoding) DecodeUint64(str string) (uint64, error) {
+	var (
+		base = uint64(len(enc.alphabet))
+		val  uint64
+	)
+
+	if str == "" {
+		return 0, fmt.Errorf(errMsg, str, str, "uint64")
+	}
+
+	for i := 0; i < len(str); i++ {
+		digit := enc.digits[str[i]]
+		if (digit < 0) || (val > (1<<64-1-uint64(digit))/base) {
+			return 0, fmt.Errorf(errMsg, str, str, "uint64")
+		}
+
+		val = val*base + uint64(digit)
+	}
+
+	return val, nil
+}
+
+// MustDecodeUint64 is a Must version of DecodeUint64
+func (enc *BaseEncoding) MustDecodeUint64(str string) uint64 {
+	return funcs.MustValue(enc.DecodeUint64(str))
+}
+
+// EncodeBigInt encodes a *big.Int
+func (enc *BaseEncoding) EncodeBigInt(val *big.Int) string {
+	if val.IsUint64() {
+		return enc.EncodeUint64(val.Uint64())
+	}
+
+	var (
+		base   = big.NewInt(int64(len(enc.alphabet)))
+		quo    = new(big.Int).Abs(val)
+		rem    = new(big.Int)
+		digits []byte
+	)
+
+	for quo.Sign() > 0 {
+		quo.QuoRem(quo, base, rem)
+		digits = append(digits, enc.alphabet[rem.Int64()])
+	}
+
+	if val.Sign() < 0 {
+		digits = append(digits, '-')
+	}
+
+	// Digits were generated least significant first
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i]
+	}
+
+	return string(digits)
+}
+
+// DecodeBigInt decodes a *big.Int, with an optional leading minus sign.
+// Returns an error if there are no digits, or there are characters that are not in the alphabet.
+func (enc *BaseEncoding) DecodeBigInt(str string) (*big.Int, error) {
+	var (
+		base   = big.NewInt(int64(len(enc.alphabet)))
+		val    = big.NewInt(0)
+		digits = strings.TrimPrefix(str, "-")
+	)
+
+	if digits == "" {
+		return nil, fmt.Errorf(errMsg, str, str, "*big.Int")
+	}
+
+	for i := 0; i < len(digits); i++ {
+		digit := enc.digits[digits[i]]
+		if digit < 0 {
+			return nil, fmt.Errorf(errMsg, str, str, "*big.Int")
+		}
+
+		val.Add(val.Mul(val, base), big.NewInt(int64(digit)))
+	}
+
+	if len(digits) < len(str) {
+		val.Neg(val)
+	}
+
+	return val, nil
+}
+
+// MustDecodeBigInt is a Must version of DecodeBigInt
+func (enc *BaseEncoding) MustDecodeBigInt(str string) *big.Int {
+	return funcs.MustValue(enc.DecodeBigInt(str))
+}
@@ -0,0 +1,140 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/tuple"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringToBigIntBase_(t *testing.T) {
+	var o *big.Int
+	assert.Nil(t, StringToBigIntBase("-ff", 16, &o))
+	assert.Equal(t, big.NewInt(-255), o)
+
+	assert.Nil(t, StringToBigIntBase("1010", 2, &o))
+	assert.Equal(t, big.NewInt(10), o)
+
+	assert.Nil(t, StringToBigIntBase("Z", 62, &o))
+	assert.Equal(t, big.NewInt(61), o)
+
+	// Invalid strings and bases do not modify the target
+	assert.Equal(t, fmt.Errorf(errMsg, "12", "12", "*big.Int"), StringToBigIntBase("12", 2, &o))
+	assert.Equal(t, fmt.Errorf(errBaseRangeMsg, 1), StringToBigIntBase("0", 1, &o))
+	assert.Equal(t, fmt.Errorf(errBaseRangeMsg, 63), StringToBigIntBase("0", 63, &o))
+	assert.Equal(t, big.NewInt(61), o)
+
+	MustStringToBigIntBase("10", 36, &o)
+	assert.Equal(t, big.NewInt(36), o)
+
+	funcs.TryTo(
+		func() {
+			MustStringToBigIntBase("g", 16, &o)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errMsg, "g", "g", "*big.Int"), e)
+		},
+	)
+}
+
+func TestBigIntToStringBase_(t *testing.T) {
+	assert.Equal(t, tuple.Of2("-ff", error(nil)), tuple.Of2(BigIntToStringBase(big.NewInt(-255), 16)))
+	assert.Equal(t, tuple.Of2("Z", error(nil)), tuple.Of2(BigIntToStringBase(big.NewInt(61), 62)))
+	assert.Equal(t, tuple.Of2("", fmt.Errorf(errBaseRangeMsg, 0)), tuple.Of2(BigIntToStringBase(big.NewInt(1), 0)))
+
+	assert.Equal(t, "1010", MustBigIntToStringBase(big.NewInt(10), 2))
+
+	funcs.TryTo(
+		func() {
+			MustBigIntToStringBase(big.NewInt(1), 63)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errBaseRangeMsg, 63), e)
+		},
+	)
+}
+
+func TestBaseEncoding_(t *testing.T) {
+	assert.Equal(t, 62, Base62.Base())
+	assert.Equal(t, 58, Base58.Base())
+
+	// uint64
+	for _, val := range []uint64{0, 1, 57, 58, 61, 62, 1_000_000, math.MaxUint64} {
+		assert.Equal(t, tuple.Of2(val, error(nil)), tuple.Of2(Base62.DecodeUint64(Base62.EncodeUint64(val))))
+		assert.Equal(t, tuple.Of2(val, error(nil)), tuple.Of2(Base58.DecodeUint64(Base58.EncodeUint64(val))))
+	}
+
+	assert.Equal(t, "0", Base62.EncodeUint64(0))
+	assert.Equal(t, "1", Base58.EncodeUint64(0))
+	assert.Equal(t, "21", Base58.EncodeUint64(58))
+	assert.Equal(t, "lYGhA16ahyf", Base62.EncodeUint64(math.MaxUint64))
+
+	// Base62 is the same as big.Int base 62
+	assert.Equal(t, MustBigIntToStringBase(new(big.Int).SetUint64(math.MaxUint64), 62), Base62.EncodeUint64(math.MaxUint64))
+
+	assert.Equal(t, tuple.Of2(uint64(0), fmt.Errorf(errMsg, "", "", "uint64")), tuple.Of2(Base62.DecodeUint64("")))
+	assert.Equal(t, tuple.Of2(uint64(0), fmt.Errorf(errMsg, "0O", "0O", "uint64")), tuple.Of2(Base58.DecodeUint64("0O")))
+	assert.Equal(t, tuple.Of2(uint64(0), fmt.Errorf(errMsg, "lYGhA16ahyg", "lYGhA16ahyg", "uint64")), tuple.Of2(Base62.DecodeUint64("lYGhA16ahyg")))
+
+	assert.Equal(t, uint64(58), Base58.MustDecodeUint64("21"))
+
+	funcs.TryTo(
+		func() {
+			Base58.MustDecodeUint64("l")
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errMsg, "l", "l", "uint64"), e)
+		},
+	)
+
+	// *big.Int
+	huge := new(big.Int).Lsh(big.NewInt(1), 200)
+	for _, val := range []*big.Int{big.NewInt(0), big.NewInt(-1), big.NewInt(123456789), huge, new(big.Int).Neg(huge)} {
+		assert.Equal(t, tuple.Of2(val, error(nil)), tuple.Of2(Base62.DecodeBigInt(Base62.EncodeBigInt(val))))
+		assert.Equal(t, tuple.Of2(val, error(nil)), tuple.Of2(Base58.DecodeBigInt(Base58.EncodeBigInt(val))))
+		assert.Equal(t, MustBigIntToStringBase(val, 62), Base62.EncodeBigInt(val))
+	}
+
+	assert.Equal(t, "-21", Base58.EncodeBigInt(big.NewInt(-58)))
+
+	for _, str := range []string{"", "-", "--1", "a+b"} {
+		assert.Equal(t, tuple.Of2((*big.Int)(nil), fmt.Errorf(errMsg, str, str, "*big.Int")), tuple.Of2(Base62.DecodeBigInt(str)))
+	}
+
+	assert.Equal(t, big.NewInt(-58), Base58.MustDecodeBigInt("-21"))
+
+	funcs.TryTo(
+		func() {
+			Base58.MustDecodeBigInt("0")
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errMsg, "0", "0", "*big.Int"), e)
+		},
+	)
+
+	// Custom alphabets
+	bin := NewBaseEncoding("ab")
+	assert.Equal(t, "baba", bin.EncodeUint64(10))
+
+	for _, alphabet := range []string{"", "a", "aba", "a-", "aé"} {
+		funcs.TryTo(
+			func() {
+				NewBaseEncoding(alphabet)
+				assert.Fail(t, "Must die")
+			},
+			func(e any) {
+				assert.Equal(t, fmt.Errorf(errBaseAlphabetMsg, alphabet), e)
+			},
+		)
+	}
+}
@@ -0,0 +1,32 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bantling/micro/funcs"
+)
+
+// BoolToString converts a bool to "true" or "false"
+func BoolToString(ival bool) string {
+	return strconv.FormatBool(ival)
+}
+
+// StringToBool converts a string to a bool
+// Accepts the same values as strconv.ParseBool: 1, t, T, TRUE, true, True, 0, f, F, FALSE, false, False.
+// Returns an error for any other string.
+func StringToBool(ival string, oval *bool) error {
+	var err error
+	if *oval, err = strconv.ParseBool(ival); err != nil {
+		return fmt.Errorf(errMsg, ival, ival, "bool")
+	}
+
+	return nil
+}
+
+// MustStringToBool is a Must version of StringToBool
+func MustStringToBool(ival string, oval *bool) {
+	funcs.Must(StringToBool(ival, oval))
+}
@@ -0,0 +1,44 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoolToString_(t *testing.T) {
+	assert.Equal(t, "true", BoolToString(true))
+	assert.Equal(t, "false", BoolToString(false))
+}
+
+func TestStringToBool_(t *testing.T) {
+	var b bool
+	for _, str := range []string{"1", "t", "T", "TRUE", "true", "True"} {
+		assert.Nil(t, StringToBool(str, &b))
+		assert.True(t, b)
+	}
+
+	for _, str := range []string{"0", "f", "F", "FALSE", "false", "False"} {
+		assert.Nil(t, StringToBool(str, &b))
+		assert.False(t, b)
+	}
+
+	assert.Equal(t, fmt.Errorf("The string value of yes cannot be converted to bool"), StringToBool("yes", &b))
+
+	MustStringToBool("true", &b)
+	assert.True(t, b)
+
+	funcs.TryTo(
+		func() {
+			MustStringToBool("", &b)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf("The string value of  cannot be converted to bool"), e)
+		},
+	)
+}
@@ -0,0 +1,17 @@
+// Package main is the convgen command, which generates direct conversion functions for pairs of types, and registers
+// them with conv.RegisterDirect, so that conv.To calls them without reflection or map dispatch.
+//
+// Usage:
+//
+//	convgen [-o file] [-package name] from:to ...
+//
+// Each argument is a pair of types separated by a colon, where each type is one of: int, int8, int16, int32, int64,
+// uint, uint8, uint16, uint32, uint64, float32, float64, or string. Eg, int32:string generates Int32ToString.
+//
+// The output file defaults to conv_generated.go, and the package defaults to the GOPACKAGE environment variable that
+// go generate provides, so a typical use is:
+//
+//	//go:generate go run github.com/bantling/micro/conv/cmd/convgen int:string string:int64 float64:int32
+//
+// SPDX-License-Identifier: Apache-2.0
+package main
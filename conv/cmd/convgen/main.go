@@ -0,0 +1,203 @@
+package main
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bantling/micro/funcs"
+)
+
+// Error constants
+var (
+	errNoPairs          = fmt.Errorf("At least one from:to type pair is required")
+	errNoPackage        = fmt.Errorf("A package name is required, either with -package or from the GOPACKAGE environment variable")
+	errInvalidPairMsg   = "The type pair %q must be of the form from:to"
+	errUnsupportedMsg   = "The type %q of pair %q is not supported"
+	errSameTypesMsg     = "The type pair %q has the same types, no conversion is needed"
+	errDuplicatePairMsg = "The type pair %q is repeated"
+)
+
+// category is a category of types that are converted the same way
+type category uint
+
+const (
+	signed category = iota
+	unsigned
+	float
+	str
+)
+
+var (
+	// categories maps each supported type to its category
+	categories = map[string]category{
+		"int":     signed,
+		"int8":    signed,
+		"int16":   signed,
+		"int32":   signed,
+		"int64":   signed,
+		"uint":    unsigned,
+		"uint8":   unsigned,
+		"uint16":  unsigned,
+		"uint32":  unsigned,
+		"uint64":  unsigned,
+		"float32": float,
+		"float64": float,
+		"string":  str,
+	}
+
+	// bodies maps a pair of categories to a format string for the body of a conversion function.
+	// The format argument is the target type, where a conversion needs an intermediate value.
+	bodies = map[category]map[category]string{
+		signed: {
+			signed:   "return conv.IntToInt(ival, oval)",
+			unsigned: "return conv.IntToUint(ival, oval)",
+			float:    "return conv.IntToFloat(ival, oval)",
+			str:      "*oval = conv.IntToString(ival)\nreturn nil",
+		},
+		unsigned: {
+			signed:   "return conv.UintToInt(ival, oval)",
+			unsigned: "return conv.UintToUint(ival, oval)",
+			float:    "return conv.IntToFloat(ival, oval)",
+			str:      "*oval = conv.UintToString(ival)\nreturn nil",
+		},
+		float: {
+			signed:   "return conv.FloatToInt(ival, oval)",
+			unsigned: "return conv.FloatToUint(ival, oval)",
+			float:    "return conv.FloatToFloat(ival, oval)",
+			str:      "*oval = conv.FloatToString(ival)\nreturn nil",
+		},
+		str: {
+			signed:   "var inter int64\nif err := conv.StringToInt64(ival, &inter); err != nil {\nreturn err\n}\nreturn conv.IntToInt(inter, oval)",
+			unsigned: "var inter uint64\nif err := conv.StringToUint64(ival, &inter); err != nil {\nreturn err\n}\nreturn conv.UintToUint(inter, oval)",
+			float:    "return conv.StringTo%s(ival, oval)",
+		},
+	}
+)
+
+// pair is a from and to type
+type pair struct {
+	from, to string
+}
+
+// article returns a type name preceded by a or an
+func article(typ string) string {
+	return funcs.Ternary(strings.HasPrefix(typ, "i"), "an ", "a ") + typ
+}
+
+// funcName returns the name of the conversion function for a pair, eg Int32ToString
+func (p pair) funcName() string {
+	return strings.Title(p.from) + "To" + strings.Title(p.to)
+}
+
+// parsePairs parses from:to arguments
+func parsePairs(args []string) ([]pair, error) {
+	if len(args) == 0 {
+		return nil, errNoPairs
+	}
+
+	var (
+		pairs []pair
+		seen  = map[pair]bool{}
+	)
+
+	for _, arg := range args {
+		types := strings.Split(arg, ":")
+		if len(types) != 2 {
+			return nil, fmt.Errorf(errInvalidPairMsg, arg)
+		}
+
+		p := pair{strings.TrimSpace(types[0]), strings.TrimSpace(types[1])}
+		for _, typ := range types {
+			if _, haveIt := categories[strings.TrimSpace(typ)]; !haveIt {
+				return nil, fmt.Errorf(errUnsupportedMsg, typ, arg)
+			}
+		}
+
+		if p.from == p.to {
+			return nil, fmt.Errorf(errSameTypesMsg, arg)
+		}
+
+		if seen[p] {
+			return nil, fmt.Errorf(errDuplicatePairMsg, arg)
+		}
+
+		pairs, seen[p] = append(pairs, p), true
+	}
+
+	return pairs, nil
+}
+
+// generate generates the source for the given package and pairs
+func generate(pkg string, pairs []pair) ([]byte, error) {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "// Code generated by convgen; DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	fmt.Fprintf(&buf, "import \"github.com/bantling/micro/conv\"\n\n")
+
+	fmt.Fprintf(&buf, "func init() {\n")
+	for _, p := range pairs {
+		fmt.Fprintf(&buf, "conv.RegisterDirect(%s)\n", p.funcName())
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	for _, p := range pairs {
+		body := bodies[categories[p.from]][categories[p.to]]
+		if (p.from == "string") && ((p.to == "int64") || (p.to == "uint64")) {
+			// No intermediate value is needed
+			body = "return conv.StringTo%s(ival, oval)"
+		}
+
+		if strings.Contains(body, "%s") {
+			body = fmt.Sprintf(body, strings.Title(p.to))
+		}
+
+		fmt.Fprintf(&buf, "\n// %s converts %s to %s, the same way as conv.To\n", p.funcName(), article(p.from), article(p.to))
+		fmt.Fprintf(&buf, "func %s(ival %s, oval *%s) error {\n%s\n}\n", p.funcName(), p.from, p.to, body)
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// run parses the command line arguments, and writes the generated source
+func run(args []string, getenv func(string) string, stderr io.Writer) error {
+	var (
+		flags  = flag.NewFlagSet("convgen", flag.ContinueOnError)
+		output = flags.String("o", "conv_generated.go", "the output file")
+		pkg    = flags.String("package", getenv("GOPACKAGE"), "the package name, defaults to $GOPACKAGE")
+	)
+	flags.SetOutput(stderr)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *pkg == "" {
+		return errNoPackage
+	}
+
+	pairs, err := parsePairs(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	src, err := generate(*pkg, pairs)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(*output, src, 0644)
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Getenv, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "convgen:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bantling/micro/tuple"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePairs_(t *testing.T) {
+	assert.Equal(
+		t,
+		tuple.Of2([]pair{{"int", "string"}, {"string", "float32"}}, error(nil)),
+		tuple.Of2(parsePairs([]string{"int:string", " string : float32 "})),
+	)
+
+	assert.Equal(t, tuple.Of2([]pair(nil), errNoPairs), tuple.Of2(parsePairs(nil)))
+	assert.Equal(t, tuple.Of2([]pair(nil), fmt.Errorf(errInvalidPairMsg, "int")), tuple.Of2(parsePairs([]string{"int"})))
+	assert.Equal(t, tuple.Of2([]pair(nil), fmt.Errorf(errInvalidPairMsg, "a:b:c")), tuple.Of2(parsePairs([]string{"a:b:c"})))
+	assert.Equal(t, tuple.Of2([]pair(nil), fmt.Errorf(errUnsupportedMsg, "bool", "bool:int")), tuple.Of2(parsePairs([]string{"bool:int"})))
+	assert.Equal(t, tuple.Of2([]pair(nil), fmt.Errorf(errUnsupportedMsg, "rune", "int:rune")), tuple.Of2(parsePairs([]string{"int:rune"})))
+	assert.Equal(t, tuple.Of2([]pair(nil), fmt.Errorf(errSameTypesMsg, "int:int")), tuple.Of2(parsePairs([]string{"int:int"})))
+	assert.Equal(
+		t,
+		tuple.Of2([]pair(nil), fmt.Errorf(errDuplicatePairMsg, "int:uint")),
+		tuple.Of2(parsePairs([]string{"int:uint", "int:uint"})),
+	)
+}
+
+func TestGenerate_(t *testing.T) {
+	src, err := generate("foo", []pair{{"int32", "string"}, {"string", "uint8"}, {"string", "float64"}, {"float32", "int"}})
+	assert.Nil(t, err)
+
+	assert.Equal(t, `// Code generated by convgen; DO NOT EDIT.
+
+package foo
+
+import "github.com/bantling/micro/conv"
+
+func init() {
+	conv.RegisterDirect(Int32ToString)
+	conv.RegisterDirect(StringToUint8)
+	conv.RegisterDirect(StringToFloat64)
+	conv.RegisterDirect(Float32ToInt)
+}
+
+// Int32ToString converts an int32 to a string, the same way as conv.To
+func Int32ToString(ival int32, oval *string) error {
+	*oval = conv.IntToString(ival)
+	return nil
+}
+
+// StringToUint8 converts a string to a uint8, the same way as conv.To
+func StringToUint8(ival string, oval *uint8) error {
+	var inter uint64
+	if err := conv.StringToUint64(ival, &inter); err != nil {
+		return err
+	}
+	return conv.UintToUint(inter, oval)
+}
+
+// StringToFloat64 converts a string to a float64, the same way as conv.To
+func StringToFloat64(ival string, oval *float64) error {
+	return conv.StringToFloat64(ival, oval)
+}
+
+// Float32ToInt converts a float32 to an int, the same way as conv.To
+func Float32ToInt(ival float32, oval *int) error {
+	return conv.FloatToInt(ival, oval)
+}
+`, string(src))
+
+	// Every supported pair generates a body
+	var all []pair
+	for from := range categories {
+		for to := range categories {
+			if from != to {
+				all = append(all, pair{from, to})
+			}
+		}
+	}
+
+	src, err = generate("foo", all)
+	assert.Nil(t, err)
+	assert.Equal(t, len(all), strings.Count(string(src), "conv.RegisterDirect("))
+	assert.Equal(t, 1, strings.Count(string(src), "conv.StringToInt64(ival, oval)"))
+	assert.Equal(t, 1, strings.Count(string(src), "conv.StringToUint64(ival, oval)"))
+}
+
+func TestRun_(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		output = filepath.Join(dir, "gen.go")
+		getenv = func(string) string { return "envpkg" }
+		stderr strings.Builder
+	)
+
+	// Package from environment
+	assert.Nil(t, run([]string{"-o", output, "int:string"}, getenv, &stderr))
+	src, _ := os.ReadFile(output)
+	assert.True(t, strings.Contains(string(src), "package envpkg\n"))
+	assert.True(t, strings.Contains(string(src), "func IntToString("))
+
+	// Package flag overrides environment
+	assert.Nil(t, run([]string{"-o", output, "-package", "flagpkg", "int:string"}, getenv, &stderr))
+	src, _ = os.ReadFile(output)
+	assert.True(t, strings.Contains(string(src), "package flagpkg\n"))
+
+	// Errors
+	noenv := func(string) string { return "" }
+	assert.Equal(t, errNoPackage, run([]string{"-o", output, "int:string"}, noenv, &stderr))
+	assert.Equal(t, errNoPairs, run([]string{"-o", output}, getenv, &stderr))
+	assert.NotNil(t, run([]string{"-bad"}, getenv, &stderr))
+	assert.True(t, strings.Contains(stderr.String(), "-bad"))
+	assert.NotNil(t, run([]string{"-o", filepath.Join(dir, "missing", "gen.go"), "int:string"}, getenv, &stderr))
+}
@@ -0,0 +1,163 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	goreflect "reflect"
+	"strings"
+	"sync"
+
+	"github.com/bantling/micro/constraint"
+	"github.com/bantling/micro/funcs"
+)
+
+const (
+	warnTrimmedMsg      = "leading or trailing whitespace was trimmed"
+	warnPlusMsg         = "a leading plus sign was removed"
+	warnLeadingZerosMsg = "leading zeros were removed"
+)
+
+// Warning is a non-fatal problem with a value that was converted anyway, eg whitespace was trimmed from a string
+type Warning struct {
+	// Location is the location of the value when the warning occurred, as set by ConversionContext.At
+	Location string
+
+	// Value is the input value before it was cleaned up
+	Value string
+
+	// Type is the type the value was converted to
+	Type string
+
+	// Message describes the problem
+	Message string
+}
+
+// String is the Stringer interface, eg `row 3: " 5" converted to int: leading or trailing whitespace was trimmed`
+func (w Warning) String() string {
+	return fmt.Sprintf("%s%q converted to %s: %s", funcs.Ternary(w.Location == "", "", w.Location+": "), w.Value, w.Type, w.Message)
+}
+
+// ConversionContext accumulates warnings about strings that are cleaned up before they are converted to a number by
+// ToWithContext and ReflectToWithContext, so that data import tooling can report data quality issues without
+// failing. A string has leading and trailing whitespace trimmed, a leading plus sign removed, and leading zeros before
+// the first digit removed, and each of these produces a warning.
+//
+// Typically, each goroutine converting data has its own context, which is inspected after a batch of conversions. A
+// context can be shared by goroutines, in which case Location is not meaningful.
+//
+// The zero value is ready to use.
+type ConversionContext struct {
+	mu       sync.Mutex
+	location string
+	warnings []Warning
+}
+
+// NewConversionContext constructs a ConversionContext
+func NewConversionContext() *ConversionContext {
+	return &ConversionContext{}
+}
+
+// At sets the location recorded in subsequent warnings, such as a row number and column name, and returns the context
+func (c *ConversionContext) At(location string) *ConversionContext {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.location = location
+	return c
+}
+
+// Warn records a warning about a value converted to the given type, for conversions outside this package that share a
+// context
+func (c *ConversionContext) Warn(value, typ, msg string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.warnings = append(c.warnings, Warning{Location: c.location, Value: value, Type: typ, Message: msg})
+}
+
+// Warnings returns a copy of the warnings recorded so far, in the order they occurred
+func (c *ConversionContext) Warnings() []Warning {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Warning(nil), c.warnings...)
+}
+
+// Reset removes all warnings and the location
+func (c *ConversionContext) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.location, c.warnings = "", nil
+}
+
+// clean cleans up a string that is to be converted to a number of the given type, recording a warning for each change
+func (c *ConversionContext) clean(str string, typ string) string {
+	res := strings.TrimSpace(str)
+	if res != str {
+		c.Warn(str, typ, warnTrimmedMsg)
+	}
+
+	sign := ""
+	if strings.HasPrefix(res, "+") {
+		res = res[1:]
+		c.Warn(str, typ, warnPlusMsg)
+	} else if strings.HasPrefix(res, "-") {
+		sign, res = "-", res[1:]
+	}
+
+	// Remove zeros that are followed by another digit, so that 0 and 0.5 are unchanged
+	if i := strings.IndexFunc(res, func(r rune) bool { return r != '0' }); (i != 0) && (len(res) > 1) {
+		if i < 0 {
+			i = len(res) - 1
+		} else if (res[i] < '0') || (res[i] > '9') {
+			i--
+		}
+
+		if i > 0 {
+			res = res[i:]
+			c.Warn(str, typ, warnLeadingZerosMsg)
+		}
+	}
+
+	return sign + res
+}
+
+// ToWithContext is a version of To that cleans up a string before converting it to a number, recording warnings in the
+// context as described in ConversionContext. Any other conversion is the same as To.
+func ToWithContext[I, O constraint.Numeric | string](ctx *ConversionContext, i I, o *O) error {
+	var (
+		ityp = goreflect.TypeOf((*I)(nil)).Elem()
+		otyp = goreflect.TypeOf((*O)(nil)).Elem()
+	)
+
+	if (ityp.Kind() == goreflect.String) && (otyp.Kind() != goreflect.String) {
+		return To(ctx.clean(goreflect.ValueOf(i).String(), otyp.String()), o)
+	}
+
+	return To(i, o)
+}
+
+// MustToWithContext is a Must version of ToWithContext
+func MustToWithContext[I, O constraint.Numeric | string](ctx *ConversionContext, i I, o *O) {
+	funcs.Must(ToWithContext(ctx, i, o))
+}
+
+// ReflectToWithContext is a version of ReflectTo that cleans up a string before converting it to a number, recording
+// warnings in the context as described in ConversionContext. Any other conversion is the same as ReflectTo.
+func ReflectToWithContext(ctx *ConversionContext, i, o goreflect.Value) error {
+	if i.IsValid() && (i.Type() == goreflect.TypeOf("")) && o.IsValid() && (o.Kind() == goreflect.Pointer) {
+		// Strings and byte or rune slices accept any string as is
+		if otyp := o.Type().Elem(); (otyp.Kind() != goreflect.String) && (otyp.Kind() != goreflect.Slice) {
+			return ReflectTo(goreflect.ValueOf(ctx.clean(i.String(), otyp.String())), o)
+		}
+	}
+
+	return ReflectTo(i, o)
+}
+
+// MustReflectToWithContext is a Must version of ReflectToWithContext
+func MustReflectToWithContext(ctx *ConversionContext, i, o goreflect.Value) {
+	funcs.Must(ReflectToWithContext(ctx, i, o))
+}
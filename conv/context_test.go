@@ -0,0 +1,143 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	goreflect "reflect"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConversionContext_(t *testing.T) {
+	ctx := NewConversionContext()
+	assert.Equal(t, []Warning(nil), ctx.Warnings())
+
+	ctx.At("row 1").Warn("x", "int", "a problem")
+	assert.Equal(t, []Warning{{Location: "row 1", Value: "x", Type: "int", Message: "a problem"}}, ctx.Warnings())
+	assert.Equal(t, `row 1: "x" converted to int: a problem`, ctx.Warnings()[0].String())
+	assert.Equal(t, `"x" converted to int: a problem`, Warning{Value: "x", Type: "int", Message: "a problem"}.String())
+
+	// Warnings returns a copy
+	ctx.Warnings()[0].Value = "y"
+	assert.Equal(t, "x", ctx.Warnings()[0].Value)
+
+	ctx.Reset()
+	assert.Equal(t, []Warning(nil), ctx.Warnings())
+	ctx.Warn("x", "int", "a problem")
+	assert.Equal(t, []Warning{{Value: "x", Type: "int", Message: "a problem"}}, ctx.Warnings())
+
+	// Zero value
+	var zv ConversionContext
+	zv.Warn("x", "int", "a problem")
+	assert.Equal(t, 1, len(zv.Warnings()))
+}
+
+func TestToWithContext_(t *testing.T) {
+	var (
+		ctx = NewConversionContext()
+		i   int
+		f   float64
+		s   string
+	)
+
+	// No changes, no warnings
+	for _, str := range []string{"0", "10", "-5"} {
+		assert.Nil(t, ToWithContext(ctx, str, &i))
+	}
+	assert.Nil(t, ToWithContext(ctx, "0.5", &f))
+	assert.Equal(t, 0.5, f)
+	assert.Equal(t, []Warning(nil), ctx.Warnings())
+
+	// Each change is a warning
+	assert.Nil(t, ToWithContext(ctx.At("row 2"), " +007\t", &i))
+	assert.Equal(t, 7, i)
+	assert.Equal(
+		t,
+		[]Warning{
+			{Location: "row 2", Value: " +007\t", Type: "int", Message: warnTrimmedMsg},
+			{Location: "row 2", Value: " +007\t", Type: "int", Message: warnPlusMsg},
+			{Location: "row 2", Value: " +007\t", Type: "int", Message: warnLeadingZerosMsg},
+		},
+		ctx.Warnings(),
+	)
+
+	ctx.Reset()
+	assert.Nil(t, ToWithContext(ctx, "-000", &i))
+	assert.Equal(t, 0, i)
+	assert.Nil(t, ToWithContext(ctx, "-00.25", &f))
+	assert.Equal(t, -0.25, f)
+	assert.Equal(
+		t,
+		[]Warning{
+			{Value: "-000", Type: "int", Message: warnLeadingZerosMsg},
+			{Value: "-00.25", Type: "float64", Message: warnLeadingZerosMsg},
+		},
+		ctx.Warnings(),
+	)
+
+	// Strings are not cleaned up
+	ctx.Reset()
+	assert.Nil(t, ToWithContext(ctx, " +01", &s))
+	assert.Equal(t, " +01", s)
+	assert.Nil(t, ToWithContext(ctx, 5, &s))
+	assert.Equal(t, "5", s)
+	assert.Equal(t, []Warning(nil), ctx.Warnings())
+
+	// Errors are still errors
+	assert.Equal(t, fmt.Errorf("The string value of abc cannot be converted to int64"), ToWithContext(ctx, " abc", &i))
+	assert.Equal(t, []Warning{{Value: " abc", Type: "int", Message: warnTrimmedMsg}}, ctx.Warnings())
+
+	MustToWithContext(ctx, "+1", &i)
+	assert.Equal(t, 1, i)
+
+	funcs.TryTo(
+		func() {
+			MustToWithContext(ctx, "abc", &i)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf("The string value of abc cannot be converted to int64"), e)
+		},
+	)
+}
+
+func TestReflectToWithContext_(t *testing.T) {
+	var (
+		ctx = NewConversionContext()
+		i   int
+		b   []byte
+	)
+
+	assert.Nil(t, ReflectToWithContext(ctx, goreflect.ValueOf(" 01"), goreflect.ValueOf(&i)))
+	assert.Equal(t, 1, i)
+	assert.Equal(
+		t,
+		[]Warning{
+			{Value: " 01", Type: "int", Message: warnTrimmedMsg},
+			{Value: " 01", Type: "int", Message: warnLeadingZerosMsg},
+		},
+		ctx.Warnings(),
+	)
+
+	// Slices are not cleaned up
+	ctx.Reset()
+	assert.Nil(t, ReflectToWithContext(ctx, goreflect.ValueOf(" 01"), goreflect.ValueOf(&b)))
+	assert.Equal(t, []byte(" 01"), b)
+	assert.Equal(t, []Warning(nil), ctx.Warnings())
+
+	MustReflectToWithContext(ctx, goreflect.ValueOf("+2"), goreflect.ValueOf(&i))
+	assert.Equal(t, 2, i)
+
+	funcs.TryTo(
+		func() {
+			MustReflectToWithContext(ctx, goreflect.ValueOf("x"), goreflect.ValueOf(&i))
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf("The string value of x cannot be converted to int64"), e)
+		},
+	)
+}
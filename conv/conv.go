@@ -3,10 +3,13 @@ package conv
 // SPDX-License-Identifier: Apache-2.0
 
 import (
+	"encoding"
 	"fmt"
 	"math"
 	"math/big"
 	goreflect "reflect"
+	"sync"
+	"time"
 
 	"github.com/bantling/micro/constraint"
 	"github.com/bantling/micro/funcs"
@@ -945,7 +948,16 @@ var (
 			return StringToBigRat(t.(string), u.(**big.Rat))
 		},
 
+		// ==== To bool
+		"stringbool": func(t any, u any) error {
+			return StringToBool(t.(string), u.(*bool))
+		},
+
 		// ==== To string
+		"boolstring": func(t any, u any) error {
+			*(u.(*string)) = BoolToString(t.(bool))
+			return nil
+		},
 		"intstring": func(t any, u any) error {
 			*(u.(*string)) = IntToString(t.(int))
 			return nil
@@ -1009,18 +1021,131 @@ var (
 	}
 )
 
+// toKind describes how To handles a pair of types
+type toKind uint
+
+const (
+	toCopy toKind = iota
+	toDirect
+	toBase
+	toReflection
+	toRegistered
+)
+
+// toKey is a pair of input and output types for To
+type toKey struct {
+	in, out goreflect.Type
+}
+
+// toEntry describes how To handles a toKey
+type toEntry struct {
+	kind toKind
+	fn   func(any, any) error
+	// direct is a func(I, *O) error registered by RegisterDirect
+	direct any
+}
+
+var (
+	// toCache maps toKey to toEntry, so that To only has to examine each pair of types once
+	toCache sync.Map
+)
+
+// newToEntry determines how To handles a pair of types:
+// - the same types are copied, or copied into a new pointer by toReflect for big types
+// - subtypes of the same base type are copied by toReflect
+// - different base types are converted by calling the function in convertFromTo directly
+// - subtypes of different base types are converted to their base types, then converted as base types
+func newToEntry(key toKey) toEntry {
+	var (
+		ibase = reflect.ValueToBaseType(goreflect.Zero(key.in)).Type()
+		obase = reflect.ValueToBaseType(goreflect.New(key.out)).Type().Elem()
+		fn    = lookupConvertFromTo(ibase.String(), obase.String())
+	)
+
+	switch {
+	case key.in == key.out:
+		return toEntry{kind: funcs.Ternary(reflect.IsBigPtr(key.in) || (key.in.Kind() == goreflect.Slice), toReflection, toCopy)}
+
+	case (ibase == obase) || (fn == nil):
+		// toReflect copies the same base types, and returns an error if there is no function
+		return toEntry{kind: toReflection}
+
+	case (ibase != key.in) || (obase != key.out):
+		return toEntry{kind: toBase, fn: fn}
+	}
+
+	return toEntry{kind: toDirect, fn: fn}
+}
+
 // To converts any Numeric type or string to any Numeric type or string
 // If the types are the same, a copy by value is performed, unless they are big types.
 // For big type copies, a new pointer is constructed with a copy of the input value.
 // This allows the big copy to be modified without affecting the original big value.
 //
+// A []byte is treated like a string, except that a copy of a []byte is a new slice.
+// Types with no registered conversion fall back on the []byte and text marshaling rules of ReflectTo.
+//
+// A string converts to an integer type as a decimal number only, so To rejects Go integer literal syntax such as 0x1F
+// or 1_000. To has no way to pass an option per call, so the literal syntax is opt-in through LiteralToInt64 and
+// LiteralToUint64 instead of being on by default with a global opt-out.
+//
 // Note that subtypes are handled automatically by the generic constraints.
-func To[I, O constraint.Numeric | string](i I, o *O) error {
+func To[I, O constraint.Numeric | string | []byte](i I, o *O) error {
+	if !observing() {
+		return to(i, o)
+	}
+
+	start := time.Now()
+	err := to(i, o)
+	observe(goreflect.TypeOf((*I)(nil)).Elem(), goreflect.TypeOf((*O)(nil)).Elem(), start, err)
+	return err
+}
+
+// to is the implementation of To, without notifying the Observer
+func to[I, O constraint.Numeric | string | []byte](i I, o *O) error {
 	// Target cannot be nil
 	if o == nil {
 		return fmt.Errorf(errONonNilMsg, o)
 	}
 
+	// Convert between integers, floats, and strings without the cache, unless the pair of types has been overridden
+	key := toKey{goreflect.TypeOf((*I)(nil)).Elem(), goreflect.TypeOf((*O)(nil)).Elem()}
+	if useToFast(key) {
+		if done, err := toFast(i, o); done {
+			return err
+		}
+	}
+
+	// Get the cached handling of the type pair without reflecting on the values, or determine and cache it
+	entry, _ := toCache.Load(key)
+
+	if entry == nil {
+		entry, _ = toCache.LoadOrStore(key, newToEntry(key))
+	}
+
+	switch e := entry.(toEntry); e.kind {
+	case toCopy:
+		*any(o).(*I) = i
+		return nil
+
+	case toDirect:
+		return e.fn(i, o)
+
+	case toRegistered:
+		return e.direct.(func(I, *O) error)(i, o)
+
+	case toBase:
+		return e.fn(
+			reflect.ValueToBaseType(goreflect.ValueOf(i)).Interface(),
+			reflect.ValueToBaseType(goreflect.ValueOf(o)).Interface(),
+		)
+	}
+
+	return toReflect(i, o)
+}
+
+// toReflect is the reflection based implementation of To, used for copies of big types, []byte, and subtypes
+func toReflect[I, O constraint.Numeric | string | []byte](i I, o *O) error {
 	// Get reflection info on i and o
 	// If i and/or o is a subtype, convert it to the base type, so we can find a conversion function
 	var (
@@ -1047,8 +1172,15 @@ func To[I, O constraint.Numeric | string](i I, o *O) error {
 				// Copy the value
 				oval.Elem().Elem().Set(ival.Elem())
 			}
+		} else if ityp.Kind() == goreflect.Slice {
+			// A []byte is copied into a new slice, unless it is nil
+			if ival.IsNil() {
+				oval.Elem().Set(ival)
+			} else {
+				oval.Elem().Set(goreflect.AppendSlice(goreflect.MakeSlice(otyp, 0, ival.Len()), ival))
+			}
 		} else {
-			// All non-big types are value types, just copy the value
+			// All other types are value types, just copy the value
 			oval.Elem().Set(ival)
 		}
 
@@ -1057,64 +1189,159 @@ func To[I, O constraint.Numeric | string](i I, o *O) error {
 
 	// Construct a string of the input and output types (eg "int8int" means int8 -> int)
 	// Use the string as an index into the convertFromTo map
-	return convertFromTo[ityp.String()+otyp.String()](any(ival.Interface()), any(oval.Interface()))
+	if fn := lookupConvertFromTo(ityp.String(), otyp.String()); fn != nil {
+		return fn(any(ival.Interface()), any(oval.Interface()))
+	}
+
+	// Fall back to []byte as a string
+	return reflectToText(goreflect.ValueOf(i), goreflect.ValueOf(o))
+}
+
+// RegisterDirect registers a function that To calls to convert type I to type O, without any reflection or boxing of
+// the values into interfaces. Registering a function for the same pair of types again replaces the function.
+//
+// RegisterDirect is meant for functions generated by the convgen command (see conv/cmd/convgen), which are registered
+// in an init function, but any function with the same semantics as To can be registered. Registering a function for two
+// numeric types or a numeric type and a string turns off the faster paths To uses for that pair of types only.
+func RegisterDirect[I, O constraint.Numeric | string](fn func(I, *O) error) {
+	key := toKey{goreflect.TypeOf((*I)(nil)).Elem(), goreflect.TypeOf((*O)(nil)).Elem()}
+	overrideToFast(key)
+	toCache.Store(key, toEntry{kind: toRegistered, direct: fn})
 }
 
 // MustTo is a Must version of To
-func MustTo[I, O constraint.Numeric | string](i I, o *O) {
+func MustTo[I, O constraint.Numeric | string | []byte](i I, o *O) {
 	funcs.Must(To(i, o))
 }
 
 // AnyTo is a version of To that accepts input values of type any
 // The output value must still satisfy constraint.Numeric | string
+//
+// In addition to the input types accepted by To, the input may be:
+// - a bool, which can only be converted to a string
+// - a []byte, which is converted to a string, then converted as a string
+// - any type with a conversion registered by Register, such as math.Decimal
+// - an encoding.TextMarshaler, whose text is converted as a string
+//
+// Named types whose underlying type is one of the above are converted as the underlying type.
 func AnyTo[O constraint.Numeric | string](i any, o *O) error {
+	if !observing() {
+		return anyTo(i, o)
+	}
+
+	start := time.Now()
+	err := anyTo(i, o)
+	observe(goreflect.TypeOf(i), goreflect.TypeOf((*O)(nil)).Elem(), start, err)
+	return err
+}
+
+// anyTo is the implementation of AnyTo, without notifying the Observer
+func anyTo[O constraint.Numeric | string](i any, o *O) error {
 	var (
 		iv   = goreflect.ValueOf(i)
-		ival = reflect.ValueToBaseType(iv).Interface()
+		ival any
 	)
 
+	// A nil interface has no kind or type
+	if !iv.IsValid() {
+		return fmt.Errorf(errAnyToInvalidIMsg, "nil")
+	}
+
+	ival = reflect.ValueToBaseType(iv).Interface()
+
 	switch iv.Kind() {
+	case goreflect.Bool:
+		// Target cannot be nil
+		if o == nil {
+			return fmt.Errorf(errONonNilMsg, o)
+		}
+
+		// Only registered conversions from bool are possible
+		oval := reflect.ValueToBaseType(goreflect.ValueOf(o))
+		if convFn := lookupConvertFromTo("bool", oval.Type().Elem().String()); convFn != nil {
+			return convFn(ival, oval.Interface())
+		}
+
+		return fmt.Errorf(errReflectToLookupMsg, iv.Type(), goreflect.TypeOf(o).Elem())
+	case goreflect.Slice:
+		if iv.Type().Elem().Kind() == goreflect.Uint8 {
+			return to(string(iv.Bytes()), o)
+		}
 	case goreflect.Int:
-		return To(ival.(int), o)
+		return to(ival.(int), o)
 	case goreflect.Int8:
-		return To(ival.(int8), o)
+		return to(ival.(int8), o)
 	case goreflect.Int16:
-		return To(ival.(int16), o)
+		return to(ival.(int16), o)
 	case goreflect.Int32:
-		return To(ival.(int32), o)
+		return to(ival.(int32), o)
 	case goreflect.Int64:
-		return To(ival.(int64), o)
+		return to(ival.(int64), o)
 	case goreflect.Uint:
-		return To(ival.(uint), o)
+		return to(ival.(uint), o)
 	case goreflect.Uint8:
-		return To(ival.(uint8), o)
+		return to(ival.(uint8), o)
 	case goreflect.Uint16:
-		return To(ival.(uint16), o)
+		return to(ival.(uint16), o)
 	case goreflect.Uint32:
-		return To(ival.(uint32), o)
+		return to(ival.(uint32), o)
 	case goreflect.Uint64:
-		return To(ival.(uint64), o)
+		return to(ival.(uint64), o)
 	case goreflect.Float32:
-		return To(ival.(float32), o)
+		return to(ival.(float32), o)
 	case goreflect.Float64:
-		return To(ival.(float64), o)
+		return to(ival.(float64), o)
 	case goreflect.String:
-		return To(ival.(string), o)
+		return to(ival.(string), o)
 	case goreflect.Ptr:
 		if bi, isa := i.(*big.Int); isa {
-			return To(bi, o)
+			return to(bi, o)
 		} else if bf, isa := i.(*big.Float); isa {
-			return To(bf, o)
+			return to(bf, o)
 		} else if br, isa := i.(*big.Rat); isa {
-			return To(br, o)
+			return to(br, o)
+		}
+	}
+
+	// Fall back to registered conversions, then text marshaling
+	if o != nil {
+		oval := reflect.ValueToBaseType(goreflect.ValueOf(o))
+		if convFn := lookupConvertFromTo(iv.Type().String(), oval.Type().Elem().String()); convFn != nil {
+			return convFn(i, oval.Interface())
 		}
 	}
 
+	if tm, isa := i.(encoding.TextMarshaler); isa {
+		text, err := tm.MarshalText()
+		if err != nil {
+			return err
+		}
+
+		return to(string(text), o)
+	}
+
 	return fmt.Errorf(errAnyToInvalidIMsg, iv.Type())
 }
 
+// MustAnyTo is a Must version of AnyTo
+func MustAnyTo[O constraint.Numeric | string](i any, o *O) {
+	funcs.Must(AnyTo(i, o))
+}
+
 // ToBigOps is the BigOps version of To
 func ToBigOps[I constraint.Numeric | string, O constraint.BigOps[O]](i I, o *O) error {
+	if !observing() {
+		return toBigOps(i, o)
+	}
+
+	start := time.Now()
+	err := toBigOps(i, o)
+	observe(goreflect.TypeOf((*I)(nil)).Elem(), goreflect.TypeOf((*O)(nil)).Elem(), start, err)
+	return err
+}
+
+// toBigOps is the implementation of ToBigOps, without notifying the Observer
+func toBigOps[I constraint.Numeric | string, O constraint.BigOps[O]](i I, o *O) error {
 	// Target cannot be nil
 	if o == nil {
 		return fmt.Errorf(errONonNilMsg, o)
@@ -1147,7 +1374,11 @@ func ToBigOps[I constraint.Numeric | string, O constraint.BigOps[O]](i I, o *O)
 	}
 
 	// Types differ, lookup conversion using types and execute it, returning result
-	return convertFromTo[ityp.String()+otyp.String()](ival.Interface(), oval.Interface())
+	if fn := lookupConvertFromTo(ityp.String(), otyp.String()); fn != nil {
+		return fn(ival.Interface(), oval.Interface())
+	}
+
+	return fmt.Errorf(errReflectToLookupMsg, ityp, otyp)
 }
 
 // MustToBigOps is a Must version of ToBigOps
@@ -1158,7 +1389,24 @@ func MustToBigOps[I constraint.Numeric | string, O constraint.BigOps[O]](i I, o
 // ReflectTo uses reflection objects to convert from source to target.
 // This function is useful for reflection algorithms that need to do conversions.
 // The tgt must wrap a pointer.
+//
+// If there is no registered conversion, then types that implement encoding.TextMarshaler or encoding.TextUnmarshaler
+// are converted via their text, so that types like UUIDs and enums do not need registering. Otherwise, values of the
+// same base type are copied, a []byte source is converted like a string, and a []byte target is set to the bytes of
+// the source converted to a string.
 func ReflectTo(i, o goreflect.Value) error {
+	if !observing() {
+		return reflectTo(i, o)
+	}
+
+	start := time.Now()
+	err := reflectTo(i, o)
+	observe(reflectInType(i), reflectOutType(o), start, err)
+	return err
+}
+
+// reflectTo is the implementation of ReflectTo, without notifying the Observer
+func reflectTo(i, o goreflect.Value) error {
 	// Die if i is invalid
 	if !i.IsValid() {
 		return errReflectToInvalidSrc
@@ -1193,9 +1441,10 @@ func ReflectTo(i, o goreflect.Value) error {
 	ob := reflect.ValueToBaseType(o)
 
 	// Locate a conversion function in convertFromTo map
-	convFn := convertFromTo[(ityp.String() + ob.Type().Elem().String())]
+	convFn := lookupConvertFromTo(ityp.String(), ob.Type().Elem().String())
 	if convFn == nil {
-		return fmt.Errorf(errReflectToLookupMsg, ityp, otyp.Elem())
+		// Fall back to text marshaling and []byte
+		return reflectToText(i, o)
 	}
 
 	return convFn(i.Interface(), ob.Interface())
@@ -1130,6 +1130,81 @@ func TestTo_(t *testing.T) {
 	}
 }
 
+func TestToCache_(t *testing.T) {
+	type foo int
+	type bar int
+	type baz string
+
+	entryOf := func(in, out any) toEntry {
+		return newToEntry(toKey{goreflect.TypeOf(in), goreflect.TypeOf(out)})
+	}
+
+	assert.Equal(t, toCopy, entryOf(0, 0).kind)
+	assert.Equal(t, toCopy, entryOf(foo(0), foo(0)).kind)
+	assert.Equal(t, toReflection, entryOf(big.NewInt(0), big.NewInt(0)).kind)
+	assert.Equal(t, toReflection, entryOf(foo(0), bar(0)).kind)
+	assert.Equal(t, toReflection, entryOf(foo(0), 0).kind)
+	assert.Equal(t, toDirect, entryOf(0, "").kind)
+	assert.Equal(t, toBase, entryOf(foo(0), "").kind)
+	assert.Equal(t, toBase, entryOf(0, baz("")).kind)
+
+	// Repeated conversions use the cached entry
+	var s string
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, To(foo(i), &s))
+		assert.Equal(t, fmt.Sprint(i), s)
+	}
+
+	entry, haveIt := toCache.Load(toKey{goreflect.TypeOf(foo(0)), goreflect.TypeOf("")})
+	assert.True(t, haveIt)
+	assert.Equal(t, toBase, entry.(toEntry).kind)
+
+	// Copy does not allocate
+	var i int
+	assert.Equal(t, float64(0), testing.AllocsPerRun(100, func() { To(1, &i) }))
+}
+
+func TestRegisterDirect_(t *testing.T) {
+	type foo int
+	type bar float32
+
+	// Before registration, subtypes are converted by reflection
+	var b bar
+	assert.Nil(t, To(foo(2), &b))
+	assert.Equal(t, bar(2), b)
+
+	// Registered function is called directly, and registering again replaces it
+	calls := 0
+	RegisterDirect(func(i foo, o *bar) error {
+		calls++
+		*o = bar(i * 10)
+		return nil
+	})
+	assert.Nil(t, To(foo(2), &b))
+	assert.Equal(t, bar(20), b)
+	assert.Equal(t, 1, calls)
+
+	anErr := fmt.Errorf("An err")
+	RegisterDirect(func(foo, *bar) error { return anErr })
+	assert.Equal(t, anErr, To(foo(2), &b))
+	assert.Equal(t, 1, calls)
+}
+
+// BenchmarkTo_ and BenchmarkToReflect_ compare a hot conversion loop using the cache and using only reflection
+func BenchmarkTo_(b *testing.B) {
+	var f float64
+	for n := 0; n < b.N; n++ {
+		To(n, &f)
+	}
+}
+
+func BenchmarkToReflect_(b *testing.B) {
+	var f float64
+	for n := 0; n < b.N; n++ {
+		toReflect(n, &f)
+	}
+}
+
 func TestAnyTo_(t *testing.T) {
 	var o int
 
@@ -1197,12 +1272,66 @@ func TestAnyTo_(t *testing.T) {
 	assert.Nil(t, AnyTo(big.NewRat(16, 1), &o))
 	assert.Equal(t, 16, o)
 
+	// named string
+	type str string
+	assert.Nil(t, AnyTo(str("17"), &o))
+	assert.Equal(t, 17, o)
+
+	// []byte
+	assert.Nil(t, AnyTo([]byte("18"), &o))
+	assert.Equal(t, 18, o)
+
+	// named []byte
+	type bytes []byte
+	assert.Nil(t, AnyTo(bytes("19"), &o))
+	assert.Equal(t, 19, o)
+
+	var s string
+	assert.Nil(t, AnyTo([]byte("foo"), &s))
+	assert.Equal(t, "foo", s)
+
+	assert.Equal(t, fmt.Errorf("The string value of bar cannot be converted to int64"), AnyTo([]byte("bar"), &o))
+
+	// bool to string
+	assert.Nil(t, AnyTo(true, &s))
+	assert.Equal(t, "true", s)
+
+	// named bool to string
+	type bln bool
+	assert.Nil(t, AnyTo(bln(false), &s))
+	assert.Equal(t, "false", s)
+
+	// bool to a number is not possible
+	assert.Equal(t, fmt.Errorf("There is no conversion function from bool to int"), AnyTo(true, &o))
+
+	// bool to nil target
+	assert.Equal(t, fmt.Errorf("The target value of type *string cannot be nil"), AnyTo(true, (*string)(nil)))
+
 	// Any other value is an error
 	assert.Equal(
 		t,
 		fmt.Errorf("AnyTo cannot convert the input type struct { Name string }"),
 		AnyTo(struct{ Name string }{Name: "Foo"}, &o),
 	)
+
+	assert.Equal(t, fmt.Errorf("AnyTo cannot convert the input type []int"), AnyTo([]int{1}, &o))
+	assert.Equal(t, fmt.Errorf("AnyTo cannot convert the input type nil"), AnyTo(nil, &o))
+}
+
+func TestMustAnyTo_(t *testing.T) {
+	var o int
+	MustAnyTo("1", &o)
+	assert.Equal(t, 1, o)
+
+	funcs.TryTo(
+		func() {
+			MustAnyTo(true, &o)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf("There is no conversion function from bool to int"), e)
+		},
+	)
 }
 
 func TestToBigOps_(t *testing.T) {
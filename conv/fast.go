@@ -0,0 +1,188 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/bantling/micro/constraint"
+)
+
+var (
+	// toFastOverrides is the number of pairs of types in toFastOverridden
+	toFastOverrides int32
+
+	// toFastOverridden contains the toKey of each pair of the primitive types toFast handles whose conversion has been
+	// registered, which To must not use the fast paths for, as they would bypass the registered function
+	toFastOverridden sync.Map
+)
+
+// overrideToFast turns off the To fast paths for a pair of types, if both types are exactly one of the primitive types
+// toFast handles, as opposed to a subtype of one. The fast paths for other pairs of types are unaffected.
+func overrideToFast(key toKey) {
+	if toFastTypes[key.in.String()] && toFastTypes[key.out.String()] {
+		if _, loaded := toFastOverridden.LoadOrStore(key, true); !loaded {
+			atomic.AddInt32(&toFastOverrides, 1)
+		}
+	}
+}
+
+// useToFast returns true if To can use the fast paths for a pair of types, which is true unless overrideToFast turned
+// them off for the pair. Nothing is looked up unless some pair has been overridden.
+func useToFast(key toKey) bool {
+	if atomic.LoadInt32(&toFastOverrides) == 0 {
+		return true
+	}
+
+	_, overridden := toFastOverridden.Load(key)
+	return !overridden
+}
+
+// toFastTypes are the names of the types toFast handles
+var toFastTypes = map[string]bool{
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true, "string": true,
+}
+
+// toFast converts between integers, floats, and from them to strings, using type switches on pointers rather than the
+// cache and the convertFromTo map, so that the values are never boxed into interfaces. The conversions are the same
+// functions the map uses, so the results and errors are the same.
+//
+// Returns false if the types are not handled, in which case To continues as usual.
+func toFast[I, O constraint.Numeric | string | []byte](i I, o *O) (bool, error) {
+	switch ip := any(&i).(type) {
+	case *int:
+		return toFastSigned(*ip, o)
+	case *int8:
+		return toFastSigned(*ip, o)
+	case *int16:
+		return toFastSigned(*ip, o)
+	case *int32:
+		return toFastSigned(*ip, o)
+	case *int64:
+		return toFastSigned(*ip, o)
+	case *uint:
+		return toFastUnsigned(*ip, o)
+	case *uint8:
+		return toFastUnsigned(*ip, o)
+	case *uint16:
+		return toFastUnsigned(*ip, o)
+	case *uint32:
+		return toFastUnsigned(*ip, o)
+	case *uint64:
+		return toFastUnsigned(*ip, o)
+	case *float32:
+		return toFastFloat(*ip, o)
+	case *float64:
+		return toFastFloat(*ip, o)
+	}
+
+	return false, nil
+}
+
+// toFastSigned converts a signed integer for toFast
+func toFastSigned[S constraint.SignedInteger, O constraint.Numeric | string | []byte](ival S, o *O) (bool, error) {
+	switch op := any(o).(type) {
+	case *int:
+		return true, IntToInt(ival, op)
+	case *int8:
+		return true, IntToInt(ival, op)
+	case *int16:
+		return true, IntToInt(ival, op)
+	case *int32:
+		return true, IntToInt(ival, op)
+	case *int64:
+		return true, IntToInt(ival, op)
+	case *uint:
+		return true, IntToUint(ival, op)
+	case *uint8:
+		return true, IntToUint(ival, op)
+	case *uint16:
+		return true, IntToUint(ival, op)
+	case *uint32:
+		return true, IntToUint(ival, op)
+	case *uint64:
+		return true, IntToUint(ival, op)
+	case *float32:
+		return true, IntToFloat(ival, op)
+	case *float64:
+		return true, IntToFloat(ival, op)
+	case *string:
+		*op = IntToString(ival)
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// toFastUnsigned converts an unsigned integer for toFast
+func toFastUnsigned[U constraint.UnsignedInteger, O constraint.Numeric | string | []byte](ival U, o *O) (bool, error) {
+	switch op := any(o).(type) {
+	case *int:
+		return true, UintToInt(ival, op)
+	case *int8:
+		return true, UintToInt(ival, op)
+	case *int16:
+		return true, UintToInt(ival, op)
+	case *int32:
+		return true, UintToInt(ival, op)
+	case *int64:
+		return true, UintToInt(ival, op)
+	case *uint:
+		return true, UintToUint(ival, op)
+	case *uint8:
+		return true, UintToUint(ival, op)
+	case *uint16:
+		return true, UintToUint(ival, op)
+	case *uint32:
+		return true, UintToUint(ival, op)
+	case *uint64:
+		return true, UintToUint(ival, op)
+	case *float32:
+		return true, IntToFloat(ival, op)
+	case *float64:
+		return true, IntToFloat(ival, op)
+	case *string:
+		*op = UintToString(ival)
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// toFastFloat converts a float for toFast
+func toFastFloat[F constraint.Float, O constraint.Numeric | string | []byte](ival F, o *O) (bool, error) {
+	switch op := any(o).(type) {
+	case *int:
+		return true, FloatToInt(ival, op)
+	case *int8:
+		return true, FloatToInt(ival, op)
+	case *int16:
+		return true, FloatToInt(ival, op)
+	case *int32:
+		return true, FloatToInt(ival, op)
+	case *int64:
+		return true, FloatToInt(ival, op)
+	case *uint:
+		return true, FloatToUint(ival, op)
+	case *uint8:
+		return true, FloatToUint(ival, op)
+	case *uint16:
+		return true, FloatToUint(ival, op)
+	case *uint32:
+		return true, FloatToUint(ival, op)
+	case *uint64:
+		return true, FloatToUint(ival, op)
+	case *float32:
+		return true, FloatToFloat(ival, op)
+	case *float64:
+		return true, FloatToFloat(ival, op)
+	case *string:
+		*op = FloatToString(ival)
+		return true, nil
+	}
+
+	return false, nil
+}
@@ -0,0 +1,173 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	goreflect "reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bantling/micro/constraint"
+	"github.com/bantling/micro/tuple"
+	"github.com/stretchr/testify/assert"
+)
+
+// withoutToFast turns off the To fast paths for I to O, returning a func that turns them back on, unless they were
+// already turned off
+func withoutToFast[I, O any]() func() {
+	key := toKey{goreflect.TypeOf((*I)(nil)).Elem(), goreflect.TypeOf((*O)(nil)).Elem()}
+	if _, overridden := toFastOverridden.Load(key); overridden {
+		return func() {}
+	}
+
+	overrideToFast(key)
+	return func() {
+		toFastOverridden.Delete(key)
+		atomic.AddInt32(&toFastOverrides, -1)
+	}
+}
+
+// checkFast checks that toFast handles I to O, with the same result and error as the convertFromTo map
+func checkFast[I, O constraint.Numeric | string](t *testing.T, i I) {
+	var (
+		fast, slow O
+		done, err  = toFast(i, &fast)
+	)
+
+	assert.True(t, done)
+	assert.Equal(t, toReflect(i, &slow), err)
+	// Compare the printed values, as NaN is not equal to itself
+	assert.Equal(t, fmt.Sprint(slow), fmt.Sprint(fast))
+}
+
+// checkFastTargets checks that toFast converts I to every type it handles
+func checkFastTargets[I constraint.Numeric](t *testing.T, vals ...I) {
+	for _, i := range vals {
+		checkFast[I, int](t, i)
+		checkFast[I, int8](t, i)
+		checkFast[I, int16](t, i)
+		checkFast[I, int32](t, i)
+		checkFast[I, int64](t, i)
+		checkFast[I, uint](t, i)
+		checkFast[I, uint8](t, i)
+		checkFast[I, uint16](t, i)
+		checkFast[I, uint32](t, i)
+		checkFast[I, uint64](t, i)
+		checkFast[I, float32](t, i)
+		checkFast[I, float64](t, i)
+		checkFast[I, string](t, i)
+	}
+}
+
+func TestToFast_(t *testing.T) {
+	checkFastTargets[int](t, 0, -1, math.MaxInt8+1, math.MinInt32, math.MaxInt)
+	checkFastTargets[int8](t, 0, -1, math.MaxInt8)
+	checkFastTargets[int16](t, -1, math.MaxInt16)
+	checkFastTargets[int32](t, -1, math.MaxInt32)
+	checkFastTargets[int64](t, -1, math.MinInt64, 1<<53+1)
+	checkFastTargets[uint](t, 0, math.MaxUint)
+	checkFastTargets[uint8](t, 0, math.MaxUint8)
+	checkFastTargets[uint16](t, math.MaxUint16)
+	checkFastTargets[uint32](t, math.MaxUint32)
+	checkFastTargets[uint64](t, math.MaxUint64, 1<<24+1)
+	checkFastTargets[float32](t, 0, -1.5, 3, float32(math.Inf(1)), float32(math.NaN()), math.MaxFloat32)
+	checkFastTargets[float64](t, 0, -1.5, 256, 1e300, math.Inf(-1), math.SmallestNonzeroFloat64, math.MaxInt64)
+
+	// Strings, big numbers, and subtypes are not handled
+	type myInt int
+	var (
+		i   int
+		mi  myInt
+		str string
+	)
+
+	assert.Equal(t, tuple.Of2(false, error(nil)), tuple.Of2(toFast("1", &i)))
+	assert.Equal(t, tuple.Of2(false, error(nil)), tuple.Of2(toFast(myInt(1), &i)))
+	assert.Equal(t, tuple.Of2(false, error(nil)), tuple.Of2(toFast(1, &mi)))
+	assert.Equal(t, tuple.Of2(false, error(nil)), tuple.Of2(toFast(big.NewInt(1), &str)))
+
+	// Overriding a built in numeric conversion turns off the fast paths for that pair only, other registrations do not
+	var (
+		intStr    = toKey{goreflect.TypeOf(0), goreflect.TypeOf("")}
+		myIntInt  = toKey{goreflect.TypeOf(mi), goreflect.TypeOf(0)}
+		int8Int16 = toKey{goreflect.TypeOf(int8(0)), goreflect.TypeOf(int16(0))}
+	)
+
+	overrideToFast(myIntInt)
+	assert.True(t, useToFast(myIntInt))
+
+	defer withoutToFast[int, string]()()
+	assert.False(t, useToFast(intStr))
+	assert.True(t, useToFast(int8Int16))
+}
+
+func TestRegisterDirectPrimitive_(t *testing.T) {
+	// A generated conversion between two primitive types is used for that pair, but not for other pairs
+	defer func() {
+		key := toKey{goreflect.TypeOf(int8(0)), goreflect.TypeOf("")}
+		toCache.Delete(key)
+		toFastOverridden.Delete(key)
+		atomic.AddInt32(&toFastOverrides, -1)
+	}()
+
+	RegisterDirect(func(i int8, o *string) error { *o = "direct"; return nil })
+
+	var (
+		str string
+		i16 int16
+	)
+
+	assert.Nil(t, To(int8(1), &str))
+	assert.Equal(t, "direct", str)
+
+	assert.True(t, useToFast(toKey{goreflect.TypeOf(int8(0)), goreflect.TypeOf(int16(0))}))
+	assert.Nil(t, To(int8(1), &i16))
+	assert.Equal(t, int16(1), i16)
+	assert.Equal(t, float64(0), testing.AllocsPerRun(100, func() { To(int8(1), &i16) }))
+}
+
+func TestToFastAllocs_(t *testing.T) {
+	var (
+		i32 = int32(5)
+		i64 int64
+		f   float64
+	)
+
+	assert.Equal(t, float64(0), testing.AllocsPerRun(100, func() { To(i32, &i64) }))
+	assert.Equal(t, float64(0), testing.AllocsPerRun(100, func() { To(2.5, &f) }))
+}
+
+func BenchmarkToFastInt32Int64_(b *testing.B) {
+	var o int64
+	for n := 0; n < b.N; n++ {
+		To(int32(n), &o)
+	}
+}
+
+func BenchmarkToCacheInt32Int64_(b *testing.B) {
+	defer withoutToFast[int32, int64]()()
+
+	var o int64
+	for n := 0; n < b.N; n++ {
+		To(int32(n), &o)
+	}
+}
+
+func BenchmarkToFastFloat64Int_(b *testing.B) {
+	var o int
+	for n := 0; n < b.N; n++ {
+		To(float64(n), &o)
+	}
+}
+
+func BenchmarkToCacheFloat64Int_(b *testing.B) {
+	defer withoutToFast[float64, int]()()
+
+	var o int
+	for n := 0; n < b.N; n++ {
+		To(float64(n), &o)
+	}
+}
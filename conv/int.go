@@ -8,6 +8,7 @@ import (
 	"math/big"
 	goreflect "reflect"
 	"strconv"
+	"strings"
 
 	"github.com/bantling/micro/constraint"
 	"github.com/bantling/micro/funcs"
@@ -221,6 +222,37 @@ func MustBigRatToInt64(ival *big.Rat, oval *int64) {
 	funcs.Must(BigRatToInt64(ival, oval))
 }
 
+// intLiteral returns the string and base to parse a Go integer literal with
+func intLiteral(ival string) (string, int) {
+	// A prefix after an optional sign is parsed as a Go literal, which includes underscores
+	digits := ival
+	if (digits != "") && ((digits[0] == '-') || (digits[0] == '+')) {
+		digits = digits[1:]
+	}
+
+	if (len(digits) > 2) && (digits[0] == '0') && strings.ContainsRune("xXoObB", rune(digits[1])) {
+		return ival, 0
+	}
+
+	// Otherwise, only remove underscores that are between two digits, any others remain and are invalid
+	if strings.Contains(digits, "_") {
+		for i := 0; i < len(digits); i++ {
+			if (digits[i] == '_') && ((i == 0) || (i == len(digits)-1) || !isDigit(digits[i-1]) || !isDigit(digits[i+1])) {
+				return ival, 10
+			}
+		}
+
+		return strings.ReplaceAll(ival, "_", ""), 10
+	}
+
+	return ival, 10
+}
+
+// isDigit returns true if c is a decimal digit
+func isDigit(c byte) bool {
+	return (c >= '0') && (c <= '9')
+}
+
 // StringToInt64 converts a string to an int64
 // Returns an error if the string cannot be represented as an int64
 func StringToInt64(ival string, oval *int64) error {
@@ -238,6 +270,29 @@ func MustStringToInt64(ival string, oval *int64) {
 	funcs.Must(StringToInt64(ival, oval))
 }
 
+// LiteralToInt64 is the same as StringToInt64, except that it also accepts Go integer literal syntax: a 0x, 0o, or 0b
+// prefix for hex, octal, or binary digits, and _ separators between digits, eg 0xFF_FF or 1_000_000. A leading zero
+// without a letter is still decimal, not octal, eg 010 is 10.
+// Returns an error if the string cannot be represented as an int64
+func LiteralToInt64(ival string, oval *int64) error {
+	var (
+		str, base = intLiteral(ival)
+		err       error
+	)
+
+	*oval, err = strconv.ParseInt(str, base, 64)
+	if err != nil {
+		return fmt.Errorf(errMsg, ival, ival, "int64")
+	}
+
+	return nil
+}
+
+// MustLiteralToInt64 is a Must version of LiteralToInt64
+func MustLiteralToInt64(ival string, oval *int64) {
+	funcs.Must(LiteralToInt64(ival, oval))
+}
+
 // ==== ToUint64
 
 // BigIntToUint64 converts a *big.Int to a uint64
@@ -303,3 +358,24 @@ func StringToUint64(ival string, oval *uint64) error {
 func MustStringToUint64(ival string, oval *uint64) {
 	funcs.Must(StringToUint64(ival, oval))
 }
+
+// LiteralToUint64 is the same as StringToUint64, except that it also accepts Go integer literal syntax, see
+// LiteralToInt64
+// Returns an error if the string cannot be represented as a uint64
+func LiteralToUint64(ival string, oval *uint64) error {
+	var (
+		str, base = intLiteral(ival)
+		err       error
+	)
+
+	if *oval, err = strconv.ParseUint(str, base, 64); err != nil {
+		return fmt.Errorf(errMsg, ival, ival, "uint64")
+	}
+
+	return nil
+}
+
+// MustLiteralToUint64 is a Must version of LiteralToUint64
+func MustLiteralToUint64(ival string, oval *uint64) {
+	funcs.Must(LiteralToUint64(ival, oval))
+}
@@ -3,6 +3,7 @@ package conv
 // SPDX-License-Identifier: Apache-2.0
 
 import (
+	"fmt"
 	"math"
 	"math/big"
 	"testing"
@@ -270,6 +271,62 @@ func TestBigRatToInt64_(t *testing.T) {
 	)
 }
 
+func TestLiteralToInt64_(t *testing.T) {
+	var (
+		i int64
+		u uint64
+	)
+
+	// Go literal syntax
+	for str, val := range map[string]int64{
+		"0x1F":      31,
+		"-0XfF":     -255,
+		"+0o17":     15,
+		"0b1010":    10,
+		"0x_ff_ff":  0xffff,
+		"1_000_000": 1_000_000,
+		"-1_0":      -10,
+		"010":       10,
+		"0_10":      10,
+	} {
+		assert.Nil(t, LiteralToInt64(str, &i), str)
+		assert.Equal(t, val, i, str)
+
+		// Only the literal versions accept the syntax
+		if str != "010" {
+			assert.Equal(t, fmt.Errorf(errMsg, str, str, "int64"), StringToInt64(str, &i), str)
+		}
+	}
+
+	for _, str := range []string{"0x", "_1", "1_", "1__0", "0b2", "0x1__F", "1_0x1"} {
+		assert.Equal(t, fmt.Errorf(errMsg, str, str, "int64"), LiteralToInt64(str, &i), str)
+	}
+
+	assert.Nil(t, LiteralToUint64("0xFFFF_FFFF_FFFF_FFFF", &u))
+	assert.Equal(t, uint64(math.MaxUint64), u)
+	assert.Equal(t, fmt.Errorf(errMsg, "-0x1", "-0x1", "uint64"), LiteralToUint64("-0x1", &u))
+	assert.Equal(t, fmt.Errorf(errMsg, "1_000", "1_000", "uint64"), StringToUint64("1_000", &u))
+
+	// To is decimal only
+	var i8 int8
+	assert.Equal(t, fmt.Errorf(errMsg, "-0x80", "-0x80", "int64"), To("-0x80", &i8))
+
+	MustLiteralToInt64("1_0", &i)
+	assert.Equal(t, int64(10), i)
+	MustLiteralToUint64("0b11", &u)
+	assert.Equal(t, uint64(3), u)
+
+	funcs.TryTo(
+		func() {
+			MustLiteralToUint64("0x", &u)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errMsg, "0x", "0x", "uint64"), e)
+		},
+	)
+}
+
 func TestStringToInt64_(t *testing.T) {
 	var o int64
 	assert.Nil(t, StringToInt64("1", &o))
@@ -0,0 +1,88 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+
+	"github.com/bantling/micro/constraint"
+	"github.com/bantling/micro/funcs"
+)
+
+var (
+	errToMapKeyMsg          = "The map key %v cannot be converted: %w"
+	errToMapValueMsg        = "The map value of key %v cannot be converted: %w"
+	errToMapDuplicateKeyMsg = "The map keys %v and %v both convert to the key %v"
+)
+
+// toMap converts the keys of i with To and the values with valFn, into a new map
+func toMap[KI, KO constraint.Numeric | string, VI any, VO constraint.Numeric | string](
+	i map[KI]VI,
+	o *map[KO]VO,
+	valFn func(VI, *VO) error,
+) error {
+	if o == nil {
+		return fmt.Errorf(errONonNilMsg, o)
+	}
+
+	if i == nil {
+		*o = nil
+		return nil
+	}
+
+	var (
+		res      = make(map[KO]VO, len(i))
+		fromKeys = make(map[KO]KI, len(i))
+	)
+
+	for ki, vi := range i {
+		var (
+			ko KO
+			vo VO
+		)
+
+		if err := To(ki, &ko); err != nil {
+			return fmt.Errorf(errToMapKeyMsg, ki, err)
+		}
+
+		if prev, haveIt := fromKeys[ko]; haveIt {
+			return fmt.Errorf(errToMapDuplicateKeyMsg, prev, ki, ko)
+		}
+		fromKeys[ko] = ki
+
+		if err := valFn(vi, &vo); err != nil {
+			return fmt.Errorf(errToMapValueMsg, ki, err)
+		}
+
+		res[ko] = vo
+	}
+
+	*o = res
+	return nil
+}
+
+// ToMap converts a map[KI]VI to a map[KO]VO, converting each key and value with To.
+// A nil input produces a nil output.
+//
+// If a key or value cannot be converted, the error contains the input key, and wraps the error returned by To.
+// If two keys convert to the same key, such as the strings 1 and 01 converted to ints, an error occurs.
+// As map iteration order is random, the offending key is the first one found.
+// The target is only modified if all keys and values are converted.
+func ToMap[KI, KO, VI, VO constraint.Numeric | string](i map[KI]VI, o *map[KO]VO) error {
+	return toMap(i, o, To[VI, VO])
+}
+
+// MustToMap is a Must version of ToMap
+func MustToMap[KI, KO, VI, VO constraint.Numeric | string](i map[KI]VI, o *map[KO]VO) {
+	funcs.Must(ToMap(i, o))
+}
+
+// AnyToMap is like ToMap, except the values are converted with AnyTo, such as a map[string]any decoded from JSON.
+func AnyToMap[KI, KO, VO constraint.Numeric | string](i map[KI]any, o *map[KO]VO) error {
+	return toMap(i, o, AnyTo[VO])
+}
+
+// MustAnyToMap is a Must version of AnyToMap
+func MustAnyToMap[KI, KO, VO constraint.Numeric | string](i map[KI]any, o *map[KO]VO) {
+	funcs.Must(AnyToMap(i, o))
+}
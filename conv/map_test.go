@@ -0,0 +1,107 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToMap_(t *testing.T) {
+	{
+		var o map[int]float64
+		assert.Nil(t, ToMap(map[string]string{"1": "1.5", "2": "2"}, &o))
+		assert.Equal(t, map[int]float64{1: 1.5, 2: 2}, o)
+
+		// Nil and empty
+		assert.Nil(t, ToMap(map[string]string(nil), &o))
+		assert.Nil(t, o)
+		assert.Nil(t, ToMap(map[string]string{}, &o))
+		assert.Equal(t, map[int]float64{}, o)
+	}
+
+	{
+		// Failing key and value, target is not modified
+		o := map[int8]int8{1: 1}
+		err := ToMap(map[int]int{300: 1}, &o)
+		assert.Equal(t, fmt.Errorf(errToMapKeyMsg, 300, fmt.Errorf(errMsg, 300, "300", "int8")), err)
+		assert.Equal(t, fmt.Errorf(errMsg, 300, "300", "int8"), errors.Unwrap(err))
+
+		err = ToMap(map[int]int{1: 300}, &o)
+		assert.Equal(t, "The map value of key 1 cannot be converted: The int value of 300 cannot be converted to int8", err.Error())
+		assert.Equal(t, map[int8]int8{1: 1}, o)
+	}
+
+	{
+		// Duplicate keys
+		var o map[int]int
+		err := ToMap(map[string]int{"1": 1, "01": 2}, &o)
+		assert.Contains(
+			t,
+			[]string{"The map keys 1 and 01 both convert to the key 1", "The map keys 01 and 1 both convert to the key 1"},
+			err.Error(),
+		)
+		assert.Nil(t, o)
+	}
+
+	assert.Equal(t, fmt.Errorf(errONonNilMsg, (*map[int]int)(nil)), ToMap(map[int]int{}, (*map[int]int)(nil)))
+
+	{
+		var o map[string]string
+		MustToMap(map[int]int{1: 2}, &o)
+		assert.Equal(t, map[string]string{"1": "2"}, o)
+
+		funcs.TryTo(
+			func() {
+				var o map[uint]int
+				MustToMap(map[int]int{-1: 2}, &o)
+				assert.Fail(t, "Must die")
+			},
+			func(e any) {
+				assert.Equal(t, fmt.Errorf(errToMapKeyMsg, -1, fmt.Errorf(errMsg, -1, "-1", "uint")), e)
+			},
+		)
+	}
+}
+
+func TestAnyToMap_(t *testing.T) {
+	{
+		// Decoded JSON
+		var o map[string]int
+		assert.Nil(t, AnyToMap(map[string]any{"a": float64(1), "b": "2", "c": []byte("3")}, &o))
+		assert.Equal(t, map[string]int{"a": 1, "b": 2, "c": 3}, o)
+
+		assert.Nil(t, AnyToMap(map[string]any(nil), &o))
+		assert.Nil(t, o)
+	}
+
+	{
+		var o map[string]int
+		assert.Equal(
+			t,
+			fmt.Errorf(errToMapValueMsg, "a", fmt.Errorf(errAnyToInvalidIMsg, "[]interface {}")),
+			AnyToMap(map[string]any{"a": []any{}}, &o),
+		)
+		assert.Nil(t, o)
+	}
+
+	{
+		var o map[int]string
+		MustAnyToMap(map[string]any{"1": true}, &o)
+		assert.Equal(t, map[int]string{1: "true"}, o)
+
+		funcs.TryTo(
+			func() {
+				MustAnyToMap(map[string]any{"1": nil}, &o)
+				assert.Fail(t, "Must die")
+			},
+			func(e any) {
+				assert.Equal(t, fmt.Errorf(errToMapValueMsg, "1", fmt.Errorf(errAnyToInvalidIMsg, "nil")), e)
+			},
+		)
+	}
+}
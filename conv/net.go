@@ -0,0 +1,166 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	goreflect "reflect"
+
+	"github.com/bantling/micro/constraint"
+	"github.com/bantling/micro/funcs"
+)
+
+// Register conversions between the net types and string, so they are available to ReflectTo.
+//
+// The net types do not satisfy the constraints of To, so To cannot convert to or from them.
+// Use NetToString and StringToNet instead, or the functions for each type below.
+func init() {
+	convertFromTo["netip.Addrstring"] = func(t any, u any) error {
+		*(u.(*string)) = NetipAddrToString(t.(netip.Addr))
+		return nil
+	}
+	convertFromTo["stringnetip.Addr"] = func(t any, u any) error {
+		return StringToNetipAddr(t.(string), u.(*netip.Addr))
+	}
+	convertFromTo["netip.Prefixstring"] = func(t any, u any) error {
+		*(u.(*string)) = NetipPrefixToString(t.(netip.Prefix))
+		return nil
+	}
+	convertFromTo["stringnetip.Prefix"] = func(t any, u any) error {
+		return StringToNetipPrefix(t.(string), u.(*netip.Prefix))
+	}
+	convertFromTo["*url.URLstring"] = func(t any, u any) error {
+		*(u.(*string)) = URLToString(t.(*url.URL))
+		return nil
+	}
+	convertFromTo["string*url.URL"] = func(t any, u any) error {
+		return StringToURL(t.(string), u.(**url.URL))
+	}
+	convertFromTo["*mail.Addressstring"] = func(t any, u any) error {
+		*(u.(*string)) = MailAddressToString(t.(*mail.Address))
+		return nil
+	}
+	convertFromTo["string*mail.Address"] = func(t any, u any) error {
+		return StringToMailAddress(t.(string), u.(**mail.Address))
+	}
+}
+
+// NetToString converts any Net type to a string with ReflectTo, using the conversions registered above
+func NetToString[I constraint.Net](ival I, oval *string) error {
+	return ReflectTo(goreflect.ValueOf(ival), goreflect.ValueOf(oval))
+}
+
+// MustNetToString is a Must version of NetToString
+func MustNetToString[I constraint.Net](ival I, oval *string) {
+	funcs.Must(NetToString(ival, oval))
+}
+
+// StringToNet converts a string to any Net type with ReflectTo, using the conversions registered above
+// Returns an error if the string is not valid for the type
+func StringToNet[O constraint.Net](ival string, oval *O) error {
+	return ReflectTo(goreflect.ValueOf(ival), goreflect.ValueOf(oval))
+}
+
+// MustStringToNet is a Must version of StringToNet
+func MustStringToNet[O constraint.Net](ival string, oval *O) {
+	funcs.Must(StringToNet(ival, oval))
+}
+
+// ==== netip.Addr
+
+// NetipAddrToString converts a netip.Addr to a string.
+// The zero Addr is converted to "invalid IP".
+func NetipAddrToString(ival netip.Addr) string {
+	return ival.String()
+}
+
+// StringToNetipAddr converts a string to a netip.Addr
+// Returns an error if the string is not a valid IPv4 or IPv6 address
+func StringToNetipAddr(ival string, oval *netip.Addr) error {
+	var err error
+	if *oval, err = netip.ParseAddr(ival); err != nil {
+		return fmt.Errorf(errMsg, ival, ival, "netip.Addr")
+	}
+
+	return nil
+}
+
+// MustStringToNetipAddr is a Must version of StringToNetipAddr
+func MustStringToNetipAddr(ival string, oval *netip.Addr) {
+	funcs.Must(StringToNetipAddr(ival, oval))
+}
+
+// ==== netip.Prefix
+
+// NetipPrefixToString converts a netip.Prefix to a string.
+// The zero Prefix is converted to "invalid Prefix".
+func NetipPrefixToString(ival netip.Prefix) string {
+	return ival.String()
+}
+
+// StringToNetipPrefix converts a string in CIDR notation (eg "192.168.0.0/16") to a netip.Prefix
+// Returns an error if the string is not a valid prefix
+func StringToNetipPrefix(ival string, oval *netip.Prefix) error {
+	var err error
+	if *oval, err = netip.ParsePrefix(ival); err != nil {
+		return fmt.Errorf(errMsg, ival, ival, "netip.Prefix")
+	}
+
+	return nil
+}
+
+// MustStringToNetipPrefix is a Must version of StringToNetipPrefix
+func MustStringToNetipPrefix(ival string, oval *netip.Prefix) {
+	funcs.Must(StringToNetipPrefix(ival, oval))
+}
+
+// ==== *url.URL
+
+// URLToString converts a non-nil *url.URL to a string
+func URLToString(ival *url.URL) string {
+	return ival.String()
+}
+
+// StringToURL converts a string to a *url.URL
+// Returns an error if the string cannot be parsed as a url
+func StringToURL(ival string, oval **url.URL) error {
+	var err error
+	if *oval, err = url.Parse(ival); err != nil {
+		return fmt.Errorf(errMsg, ival, ival, "*url.URL")
+	}
+
+	return nil
+}
+
+// MustStringToURL is a Must version of StringToURL
+func MustStringToURL(ival string, oval **url.URL) {
+	funcs.Must(StringToURL(ival, oval))
+}
+
+// ==== *mail.Address
+
+// MailAddressToString converts a non-nil *mail.Address to a string.
+// The result is formatted per RFC 5322, eg "Joe Smith" <joe@example.com>, or <joe@example.com> if there is no name.
+func MailAddressToString(ival *mail.Address) string {
+	return ival.String()
+}
+
+// StringToMailAddress converts a string to a *mail.Address
+// Returns an error if the string is not a single valid RFC 5322 address, such as joe@example.com or
+// Joe Smith <joe@example.com>
+func StringToMailAddress(ival string, oval **mail.Address) error {
+	var err error
+	if *oval, err = mail.ParseAddress(ival); err != nil {
+		return fmt.Errorf(errMsg, ival, ival, "*mail.Address")
+	}
+
+	return nil
+}
+
+// MustStringToMailAddress is a Must version of StringToMailAddress
+func MustStringToMailAddress(ival string, oval **mail.Address) {
+	funcs.Must(StringToMailAddress(ival, oval))
+}
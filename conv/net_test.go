@@ -0,0 +1,195 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	goreflect "reflect"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetipAddr_(t *testing.T) {
+	var addr netip.Addr
+	assert.Nil(t, StringToNetipAddr("192.168.1.2", &addr))
+	assert.Equal(t, netip.MustParseAddr("192.168.1.2"), addr)
+	assert.Equal(t, "192.168.1.2", NetipAddrToString(addr))
+
+	MustStringToNetipAddr("::1", &addr)
+	assert.Equal(t, netip.IPv6Loopback(), addr)
+	assert.Equal(t, "::1", NetipAddrToString(addr))
+
+	assert.Equal(t, "invalid IP", NetipAddrToString(netip.Addr{}))
+
+	assert.Equal(t, fmt.Errorf("The string value of 1.2.3 cannot be converted to netip.Addr"), StringToNetipAddr("1.2.3", &addr))
+
+	funcs.TryTo(
+		func() {
+			MustStringToNetipAddr("", &addr)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf("The string value of  cannot be converted to netip.Addr"), e)
+		},
+	)
+}
+
+func TestNetipPrefix_(t *testing.T) {
+	var prefix netip.Prefix
+	assert.Nil(t, StringToNetipPrefix("10.0.0.0/8", &prefix))
+	assert.Equal(t, netip.MustParsePrefix("10.0.0.0/8"), prefix)
+	assert.Equal(t, "10.0.0.0/8", NetipPrefixToString(prefix))
+
+	MustStringToNetipPrefix("fd00::/8", &prefix)
+	assert.Equal(t, "fd00::/8", NetipPrefixToString(prefix))
+
+	assert.Equal(t, "invalid Prefix", NetipPrefixToString(netip.Prefix{}))
+
+	assert.Equal(t, fmt.Errorf("The string value of 10.0.0.0 cannot be converted to netip.Prefix"), StringToNetipPrefix("10.0.0.0", &prefix))
+
+	funcs.TryTo(
+		func() {
+			MustStringToNetipPrefix("10.0.0.0/33", &prefix)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf("The string value of 10.0.0.0/33 cannot be converted to netip.Prefix"), e)
+		},
+	)
+}
+
+func TestURL_(t *testing.T) {
+	var u *url.URL
+	assert.Nil(t, StringToURL("https://example.com/a?b=c", &u))
+	assert.Equal(t, "example.com", u.Host)
+	assert.Equal(t, "https://example.com/a?b=c", URLToString(u))
+
+	MustStringToURL("/relative", &u)
+	assert.Equal(t, "/relative", URLToString(u))
+
+	assert.Equal(t, fmt.Errorf("The string value of :foo cannot be converted to *url.URL"), StringToURL(":foo", &u))
+
+	funcs.TryTo(
+		func() {
+			MustStringToURL("http://[::1", &u)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf("The string value of http://[::1 cannot be converted to *url.URL"), e)
+		},
+	)
+}
+
+func TestMailAddress_(t *testing.T) {
+	var addr *mail.Address
+	assert.Nil(t, StringToMailAddress("Joe Smith <joe@example.com>", &addr))
+	assert.Equal(t, &mail.Address{Name: "Joe Smith", Address: "joe@example.com"}, addr)
+	assert.Equal(t, `"Joe Smith" <joe@example.com>`, MailAddressToString(addr))
+
+	MustStringToMailAddress("joe@example.com", &addr)
+	assert.Equal(t, "<joe@example.com>", MailAddressToString(addr))
+
+	assert.Equal(t, fmt.Errorf("The string value of joe cannot be converted to *mail.Address"), StringToMailAddress("joe", &addr))
+
+	funcs.TryTo(
+		func() {
+			MustStringToMailAddress("", &addr)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf("The string value of  cannot be converted to *mail.Address"), e)
+		},
+	)
+}
+
+func TestNetReflectTo_(t *testing.T) {
+	{
+		var addr netip.Addr
+		assert.Nil(t, ReflectTo(goreflect.ValueOf("127.0.0.1"), goreflect.ValueOf(&addr)))
+		assert.Equal(t, netip.MustParseAddr("127.0.0.1"), addr)
+
+		var str string
+		assert.Nil(t, ReflectTo(goreflect.ValueOf(addr), goreflect.ValueOf(&str)))
+		assert.Equal(t, "127.0.0.1", str)
+
+		assert.Equal(t, fmt.Errorf("The string value of foo cannot be converted to netip.Addr"), ReflectTo(goreflect.ValueOf("foo"), goreflect.ValueOf(&addr)))
+	}
+
+	{
+		var prefix netip.Prefix
+		assert.Nil(t, ReflectTo(goreflect.ValueOf("127.0.0.0/8"), goreflect.ValueOf(&prefix)))
+		assert.Equal(t, netip.MustParsePrefix("127.0.0.0/8"), prefix)
+
+		var str string
+		assert.Nil(t, ReflectTo(goreflect.ValueOf(prefix), goreflect.ValueOf(&str)))
+		assert.Equal(t, "127.0.0.0/8", str)
+	}
+
+	{
+		var u *url.URL
+		assert.Nil(t, ReflectTo(goreflect.ValueOf("http://example.com"), goreflect.ValueOf(&u)))
+		assert.Equal(t, "example.com", u.Host)
+
+		var str string
+		assert.Nil(t, ReflectTo(goreflect.ValueOf(u), goreflect.ValueOf(&str)))
+		assert.Equal(t, "http://example.com", str)
+	}
+
+	{
+		var addr *mail.Address
+		assert.Nil(t, ReflectTo(goreflect.ValueOf("a@b.c"), goreflect.ValueOf(&addr)))
+		assert.Equal(t, &mail.Address{Address: "a@b.c"}, addr)
+
+		var str string
+		assert.Nil(t, ReflectTo(goreflect.ValueOf(addr), goreflect.ValueOf(&str)))
+		assert.Equal(t, "<a@b.c>", str)
+	}
+}
+
+func TestNetToStringAndStringToNet_(t *testing.T) {
+	var str string
+	assert.Nil(t, NetToString(netip.MustParseAddr("10.1.2.3"), &str))
+	assert.Equal(t, "10.1.2.3", str)
+
+	assert.Nil(t, NetToString(netip.MustParsePrefix("10.0.0.0/8"), &str))
+	assert.Equal(t, "10.0.0.0/8", str)
+
+	MustNetToString(&url.URL{Scheme: "https", Host: "example.com"}, &str)
+	assert.Equal(t, "https://example.com", str)
+
+	MustNetToString(&mail.Address{Name: "Joe", Address: "joe@example.com"}, &str)
+	assert.Equal(t, `"Joe" <joe@example.com>`, str)
+
+	var addr netip.Addr
+	assert.Nil(t, StringToNet("::1", &addr))
+	assert.Equal(t, netip.IPv6Loopback(), addr)
+
+	var prefix netip.Prefix
+	MustStringToNet("fd00::/8", &prefix)
+	assert.Equal(t, netip.MustParsePrefix("fd00::/8"), prefix)
+
+	var u *url.URL
+	assert.Nil(t, StringToNet("http://example.com/a", &u))
+	assert.Equal(t, "/a", u.Path)
+
+	var maddr *mail.Address
+	MustStringToNet("a@b.c", &maddr)
+	assert.Equal(t, &mail.Address{Address: "a@b.c"}, maddr)
+
+	assert.Equal(t, fmt.Errorf("The string value of 1.2.3 cannot be converted to netip.Addr"), StringToNet("1.2.3", &addr))
+
+	funcs.TryTo(
+		func() {
+			MustStringToNet("foo", &maddr)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf("The string value of foo cannot be converted to *mail.Address"), e)
+		},
+	)
+}
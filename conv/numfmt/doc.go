@@ -0,0 +1,5 @@
+// Package numfmt formats and parses numbers using the conventions of a locale, such as the decimal separator, grouping
+// separators, and currency symbol
+//
+// SPDX-License-Identifier: Apache-2.0
+package numfmt
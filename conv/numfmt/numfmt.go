@@ -0,0 +1,383 @@
+package numfmt
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	gomath "math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/bantling/micro/constraint"
+	"github.com/bantling/micro/conv"
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/math"
+)
+
+const (
+	errUnknownLocaleMsg = "The locale %s is not supported"
+	errParseMsg         = "The string %q is not a valid number in the locale %s"
+
+	// spaces are a space, no-break space, and narrow no-break space, which are interchangeable when parsing
+	spaces = " \u00a0\u202f"
+)
+
+// Locale describes the conventions of a locale for formatting and parsing numbers
+type Locale struct {
+	// Tag is the BCP 47 language tag, eg en-US
+	Tag string
+
+	// DecimalSeparator separates the integer and fractional digits
+	DecimalSeparator string
+
+	// GroupSeparator separates groups of integer digits, if it is non-empty
+	GroupSeparator string
+
+	// GroupSize is the number of digits in the rightmost group, there is no grouping if it is 0
+	GroupSize uint
+
+	// SecondaryGroupSize is the number of digits in the other groups, if it is 0 then GroupSize is used.
+	// Eg, en-IN has a GroupSize of 3 and a SecondaryGroupSize of 2, so that 1234567 is 12,34,567.
+	SecondaryGroupSize uint
+
+	// CurrencySymbol is the symbol for amounts of the local currency
+	CurrencySymbol string
+
+	// CurrencyAfter places the currency symbol after the amount rather than before it
+	CurrencyAfter bool
+
+	// CurrencySpace separates the currency symbol and the amount with a no-break space
+	CurrencySpace bool
+}
+
+var (
+	EnUS = Locale{Tag: "en-US", DecimalSeparator: ".", GroupSeparator: ",", GroupSize: 3, CurrencySymbol: "$"}
+	EnGB = Locale{Tag: "en-GB", DecimalSeparator: ".", GroupSeparator: ",", GroupSize: 3, CurrencySymbol: "£"}
+	EnIN = Locale{Tag: "en-IN", DecimalSeparator: ".", GroupSeparator: ",", GroupSize: 3, SecondaryGroupSize: 2, CurrencySymbol: "₹"}
+	DeDE = Locale{Tag: "de-DE", DecimalSeparator: ",", GroupSeparator: ".", GroupSize: 3, CurrencySymbol: "€", CurrencyAfter: true, CurrencySpace: true}
+	DeCH = Locale{Tag: "de-CH", DecimalSeparator: ".", GroupSeparator: "’", GroupSize: 3, CurrencySymbol: "CHF", CurrencySpace: true}
+	EsES = Locale{Tag: "es-ES", DecimalSeparator: ",", GroupSeparator: ".", GroupSize: 3, CurrencySymbol: "€", CurrencyAfter: true, CurrencySpace: true}
+	FrFR = Locale{Tag: "fr-FR", DecimalSeparator: ",", GroupSeparator: "\u202f", GroupSize: 3, CurrencySymbol: "€", CurrencyAfter: true, CurrencySpace: true}
+	ItIT = Locale{Tag: "it-IT", DecimalSeparator: ",", GroupSeparator: ".", GroupSize: 3, CurrencySymbol: "€", CurrencyAfter: true, CurrencySpace: true}
+	JaJP = Locale{Tag: "ja-JP", DecimalSeparator: ".", GroupSeparator: ",", GroupSize: 3, CurrencySymbol: "¥"}
+	PtBR = Locale{Tag: "pt-BR", DecimalSeparator: ",", GroupSeparator: ".", GroupSize: 3, CurrencySymbol: "R$", CurrencySpace: true}
+
+	locales = map[string]Locale{}
+)
+
+func init() {
+	for _, l := range []Locale{EnUS, EnGB, EnIN, DeDE, DeCH, EsES, FrFR, ItIT, JaJP, PtBR} {
+		locales[strings.ToLower(l.Tag)] = l
+	}
+}
+
+// Lookup returns the predefined locale for a language tag, ignoring case and accepting _ in place of -, eg en_us.
+// Returns an error if there is no such locale.
+func Lookup(tag string) (Locale, error) {
+	if l, haveIt := locales[strings.ToLower(strings.ReplaceAll(tag, "_", "-"))]; haveIt {
+		return l, nil
+	}
+
+	return Locale{}, fmt.Errorf(errUnknownLocaleMsg, tag)
+}
+
+// MustLookup is a must version of Lookup
+func MustLookup(tag string) Locale {
+	return funcs.MustValue(Lookup(tag))
+}
+
+// groupSizes returns the size of the rightmost group and the other groups, which are 0 if there is no grouping
+func (l Locale) groupSizes() (int, int) {
+	if (l.GroupSeparator == "") || (l.GroupSize == 0) {
+		return 0, 0
+	}
+
+	return int(l.GroupSize), int(funcs.Ternary(l.SecondaryGroupSize == 0, l.GroupSize, l.SecondaryGroupSize))
+}
+
+// localize converts a number formatted as [-]digits[.digits] to the conventions of the locale
+func (l Locale) localize(str string) string {
+	var (
+		sign      string
+		intDigits = str
+		frac      string
+	)
+
+	if strings.HasPrefix(intDigits, "-") {
+		sign, intDigits = "-", intDigits[1:]
+	}
+
+	if i := strings.IndexByte(intDigits, '.'); i >= 0 {
+		intDigits, frac = intDigits[:i], l.DecimalSeparator+intDigits[i+1:]
+	}
+
+	// Group from the right, the first group has the primary size and the rest have the secondary size
+	if size, secondary := l.groupSizes(); (size > 0) && (len(intDigits) > size) {
+		groups := []string{intDigits[len(intDigits)-size:]}
+		for rest := intDigits[:len(intDigits)-size]; rest != ""; {
+			n := funcs.Ternary(len(rest) > secondary, secondary, len(rest))
+			groups = append([]string{rest[len(rest)-n:]}, groups...)
+			rest = rest[:len(rest)-n]
+		}
+
+		intDigits = strings.Join(groups, l.GroupSeparator)
+	}
+
+	return sign + intDigits + frac
+}
+
+// canonical converts a number in the conventions of the locale to [-]digits[.digits], removing a currency symbol if
+// currency is true. Returns false if the string is not a valid number in the locale.
+func (l Locale) canonical(str string, currency bool) (string, bool) {
+	res := strings.TrimSpace(strings.Trim(str, spaces))
+
+	// The sign may come before or after a leading currency symbol
+	sign := ""
+	if strings.HasPrefix(res, "-") || strings.HasPrefix(res, "+") {
+		sign, res = strings.TrimPrefix(res[:1], "+"), res[1:]
+	}
+
+	if currency && (l.CurrencySymbol != "") {
+		trimmed := funcs.Ternary(l.CurrencyAfter, strings.TrimSuffix(res, l.CurrencySymbol), strings.TrimPrefix(res, l.CurrencySymbol))
+		if trimmed == res {
+			return "", false
+		}
+
+		res = strings.Trim(trimmed, spaces)
+		if (sign == "") && (strings.HasPrefix(res, "-") || strings.HasPrefix(res, "+")) {
+			sign, res = strings.TrimPrefix(res[:1], "+"), res[1:]
+		}
+	}
+
+	// Split integer and fractional digits
+	intDigits, frac := res, ""
+	if i := strings.Index(res, l.DecimalSeparator); i >= 0 {
+		intDigits, frac = res[:i], res[i+len(l.DecimalSeparator):]
+		if (frac == "") || !allDigits(frac) {
+			return "", false
+		}
+
+		frac = "." + frac
+	}
+
+	// Any space is accepted for a locale that groups with a space
+	groupSep := l.GroupSeparator
+	if (groupSep != "") && strings.Contains(spaces, groupSep) {
+		for _, r := range spaces {
+			intDigits = strings.ReplaceAll(intDigits, string(r), groupSep)
+		}
+	}
+
+	// Groups must have the sizes of the locale, except that the leftmost group may be shorter
+	groups := []string{intDigits}
+	if size, secondary := l.groupSizes(); (size > 0) && strings.Contains(intDigits, groupSep) {
+		groups = strings.Split(intDigits, groupSep)
+		for i, group := range groups {
+			want := funcs.Ternary(i == len(groups)-1, size, secondary)
+			if (len(group) > want) || ((i > 0) && (len(group) != want)) || (group == "") {
+				return "", false
+			}
+		}
+	}
+
+	intDigits = strings.Join(groups, "")
+	if (intDigits == "") || !allDigits(intDigits) {
+		return "", false
+	}
+
+	return sign + intDigits + frac, true
+}
+
+// allDigits returns true if str only contains decimal digits
+func allDigits(str string) bool {
+	for i := 0; i < len(str); i++ {
+		if (str[i] < '0') || (str[i] > '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// currency adds the currency symbol to a localized amount
+func (l Locale) currency(str string) string {
+	space := funcs.Ternary(l.CurrencySpace, "\u00a0", "")
+	if l.CurrencyAfter {
+		return str + space + l.CurrencySymbol
+	}
+
+	// The sign comes before the symbol, eg -$1.50
+	if strings.HasPrefix(str, "-") {
+		return "-" + l.CurrencySymbol + space + str[1:]
+	}
+
+	return l.CurrencySymbol + space + str
+}
+
+// ==== Format
+
+// FormatInt formats any signed or unsigned int type, eg 1234567 is 1,234,567 in en-US and 1.234.567 in de-DE
+func FormatInt[T constraint.Integer](l Locale, val T) string {
+	var str string
+	conv.MustTo(val, &str)
+	return l.localize(str)
+}
+
+// FormatFloat formats a float64 with the given number of digits after the decimal separator, or the smallest number
+// of digits necessary to represent the value exactly if digits is negative, as in strconv.FormatFloat.
+// NaN and infinities are formatted as NaN, +Inf, and -Inf.
+func FormatFloat(l Locale, val float64, digits int) string {
+	str := strconv.FormatFloat(val, 'f', digits, 64)
+	if gomath.IsInf(val, 0) || gomath.IsNaN(val) {
+		return str
+	}
+
+	return l.localize(str)
+}
+
+// FormatDecimal formats a Decimal with all of its digits after the decimal separator, eg 1234.50 is 1.234,50 in de-DE
+func FormatDecimal(l Locale, d math.Decimal) string {
+	return l.localize(d.String())
+}
+
+// FormatBigInt formats a *big.Int
+func FormatBigInt(l Locale, val *big.Int) string {
+	return l.localize(val.String())
+}
+
+// FormatBigFloat formats a *big.Float with the given number of digits after the decimal separator, as in FormatFloat.
+// Infinities are formatted as +Inf and -Inf.
+func FormatBigFloat(l Locale, val *big.Float, digits int) string {
+	if val.IsInf() {
+		return val.Text('f', digits)
+	}
+
+	return l.localize(val.Text('f', digits))
+}
+
+// FormatCurrency formats a Decimal like FormatDecimal with the currency symbol of the locale, eg -1234.5 is -$1,234.5
+// in en-US and -1.234,5 € in de-DE. A space between the symbol and amount is a no-break space.
+func FormatCurrency(l Locale, d math.Decimal) string {
+	return l.currency(FormatDecimal(l, d))
+}
+
+// ==== Parse
+
+// ParseInt parses a string in the conventions of the locale into any signed or unsigned int type.
+// Leading and trailing spaces and a leading + or - sign are allowed, and the digits may be grouped, but any groups
+// must be the sizes of the locale, eg 1,234 and 1234 are valid in en-US, but 12,34 is not.
+//
+// Returns an error if the string is not a valid integer in the locale, or the value does not fit in T.
+func ParseInt[T constraint.Integer](l Locale, str string) (T, error) {
+	var res T
+	canon, ok := l.canonical(str, false)
+	if !ok || strings.Contains(canon, ".") {
+		return res, fmt.Errorf(errParseMsg, str, l.Tag)
+	}
+
+	if err := conv.To(canon, &res); err != nil {
+		var zv T
+		return zv, err
+	}
+
+	return res, nil
+}
+
+// MustParseInt is a must version of ParseInt
+func MustParseInt[T constraint.Integer](l Locale, str string) T {
+	return funcs.MustValue(ParseInt[T](l, str))
+}
+
+// ParseFloat parses a string in the conventions of the locale into a float64, the same way as ParseInt, except that
+// there may be fractional digits.
+func ParseFloat(l Locale, str string) (float64, error) {
+	canon, ok := l.canonical(str, false)
+	if !ok {
+		return 0, fmt.Errorf(errParseMsg, str, l.Tag)
+	}
+
+	var res float64
+	if err := conv.To(canon, &res); err != nil {
+		return 0, err
+	}
+
+	return res, nil
+}
+
+// MustParseFloat is a must version of ParseFloat
+func MustParseFloat(l Locale, str string) float64 {
+	return funcs.MustValue(ParseFloat(l, str))
+}
+
+// ParseDecimal parses a string in the conventions of the locale into a Decimal, the same way as ParseFloat.
+// The scale is the number of fractional digits.
+func ParseDecimal(l Locale, str string) (math.Decimal, error) {
+	canon, ok := l.canonical(str, false)
+	if !ok {
+		return math.Decimal{}, fmt.Errorf(errParseMsg, str, l.Tag)
+	}
+
+	return math.StringToDecimal(canon)
+}
+
+// MustParseDecimal is a must version of ParseDecimal
+func MustParseDecimal(l Locale, str string) math.Decimal {
+	return funcs.MustValue(ParseDecimal(l, str))
+}
+
+// ParseBigInt parses a string in the conventions of the locale into a *big.Int, the same way as ParseInt
+func ParseBigInt(l Locale, str string) (*big.Int, error) {
+	canon, ok := l.canonical(str, false)
+	if !ok || strings.Contains(canon, ".") {
+		return nil, fmt.Errorf(errParseMsg, str, l.Tag)
+	}
+
+	var res *big.Int
+	if err := conv.StringToBigInt(canon, &res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// MustParseBigInt is a must version of ParseBigInt
+func MustParseBigInt(l Locale, str string) *big.Int {
+	return funcs.MustValue(ParseBigInt(l, str))
+}
+
+// ParseBigFloat parses a string in the conventions of the locale into a *big.Float, the same way as ParseFloat
+func ParseBigFloat(l Locale, str string) (*big.Float, error) {
+	canon, ok := l.canonical(str, false)
+	if !ok {
+		return nil, fmt.Errorf(errParseMsg, str, l.Tag)
+	}
+
+	var res *big.Float
+	if err := conv.StringToBigFloat(canon, &res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// MustParseBigFloat is a must version of ParseBigFloat
+func MustParseBigFloat(l Locale, str string) *big.Float {
+	return funcs.MustValue(ParseBigFloat(l, str))
+}
+
+// ParseCurrency parses an amount with the currency symbol of the locale into a Decimal, the same way as ParseDecimal.
+// The symbol must be present, in the position of the locale, and any space between it and the amount is optional.
+func ParseCurrency(l Locale, str string) (math.Decimal, error) {
+	canon, ok := l.canonical(str, true)
+	if !ok {
+		return math.Decimal{}, fmt.Errorf(errParseMsg, str, l.Tag)
+	}
+
+	return math.StringToDecimal(canon)
+}
+
+// MustParseCurrency is a must version of ParseCurrency
+func MustParseCurrency(l Locale, str string) math.Decimal {
+	return funcs.MustValue(ParseCurrency(l, str))
+}
@@ -0,0 +1,235 @@
+package numfmt
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	gomath "math"
+	"math/big"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/math"
+	"github.com/bantling/micro/tuple"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup_(t *testing.T) {
+	assert.Equal(t, tuple.Of2(DeDE, error(nil)), tuple.Of2(Lookup("de-DE")))
+	assert.Equal(t, tuple.Of2(EnIN, error(nil)), tuple.Of2(Lookup("en_in")))
+	assert.Equal(t, tuple.Of2(Locale{}, fmt.Errorf(errUnknownLocaleMsg, "xx-YY")), tuple.Of2(Lookup("xx-YY")))
+
+	assert.Equal(t, FrFR, MustLookup("FR-fr"))
+
+	funcs.TryTo(
+		func() {
+			MustLookup("")
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errUnknownLocaleMsg, ""), e)
+		},
+	)
+}
+
+func TestFormat_(t *testing.T) {
+	// Ints
+	assert.Equal(t, "0", FormatInt(EnUS, 0))
+	assert.Equal(t, "999", FormatInt(EnUS, 999))
+	assert.Equal(t, "1,000", FormatInt(EnUS, 1000))
+	assert.Equal(t, "-1,234,567", FormatInt(EnUS, -1234567))
+	assert.Equal(t, "1.234.567", FormatInt(DeDE, uint32(1234567)))
+	assert.Equal(t, "1\u202f234\u202f567", FormatInt(FrFR, 1234567))
+	assert.Equal(t, "1’234’567", FormatInt(DeCH, 1234567))
+	assert.Equal(t, "12,34,567", FormatInt(EnIN, 1234567))
+	assert.Equal(t, "1,23,45,678", FormatInt(EnIN, 12345678))
+	assert.Equal(t, "-9,223,372,036,854,775,808", FormatInt(EnUS, int64(gomath.MinInt64)))
+
+	// No grouping
+	assert.Equal(t, "1234567,5", FormatFloat(Locale{DecimalSeparator: ","}, 1234567.5, -1))
+
+	// Floats
+	assert.Equal(t, "1,234.5", FormatFloat(EnUS, 1234.5, -1))
+	assert.Equal(t, "-1.234,50", FormatFloat(DeDE, -1234.5, 2))
+	assert.Equal(t, "0,1", FormatFloat(ItIT, 0.1, -1))
+	assert.Equal(t, "NaN", FormatFloat(DeDE, gomath.NaN(), 2))
+	assert.Equal(t, "-Inf", FormatFloat(DeDE, gomath.Inf(-1), 2))
+
+	// Decimals keep their scale
+	assert.Equal(t, "1.234,50", FormatDecimal(DeDE, math.MustStringToDecimal("1234.50")))
+	assert.Equal(t, "-0.05", FormatDecimal(EnGB, math.MustStringToDecimal("-0.05")))
+	assert.Equal(t, "12,34,567.89", FormatDecimal(EnIN, math.MustStringToDecimal("1234567.89")))
+
+	// Big types
+	assert.Equal(t, "1,000,000,000,000,000,000,000", FormatBigInt(EnUS, new(big.Int).Exp(big.NewInt(10), big.NewInt(21), nil)))
+	assert.Equal(t, "1.234,125", FormatBigFloat(EsES, big.NewFloat(1234.125), -1))
+	assert.Equal(t, "1.234,13", FormatBigFloat(EsES, big.NewFloat(1234.13), 2))
+	assert.Equal(t, "+Inf", FormatBigFloat(EsES, new(big.Float).SetInf(false), 2))
+
+	// Currency
+	var (
+		amt = math.MustStringToDecimal("1234.50")
+		neg = math.MustStringToDecimal("-1234.50")
+	)
+
+	assert.Equal(t, "$1,234.50", FormatCurrency(EnUS, amt))
+	assert.Equal(t, "-$1,234.50", FormatCurrency(EnUS, neg))
+	assert.Equal(t, "£1,234.50", FormatCurrency(EnGB, amt))
+	assert.Equal(t, "1.234,50\u00a0€", FormatCurrency(DeDE, amt))
+	assert.Equal(t, "-1\u202f234,50\u00a0€", FormatCurrency(FrFR, neg))
+	assert.Equal(t, "CHF\u00a01’234.50", FormatCurrency(DeCH, amt))
+	assert.Equal(t, "-R$\u00a01.234,50", FormatCurrency(PtBR, neg))
+	assert.Equal(t, "¥1,234", FormatCurrency(JaJP, math.MustDecimal(1234, 0)))
+	assert.Equal(t, "₹12,34,567.00", FormatCurrency(EnIN, math.MustStringToDecimal("1234567.00")))
+}
+
+func TestParseInt_(t *testing.T) {
+	assert.Equal(t, tuple.Of2(1234567, error(nil)), tuple.Of2(ParseInt[int](EnUS, "1,234,567")))
+	assert.Equal(t, tuple.Of2(1234567, error(nil)), tuple.Of2(ParseInt[int](EnUS, " 1234567 ")))
+	assert.Equal(t, tuple.Of2(-1234, error(nil)), tuple.Of2(ParseInt[int](DeDE, "-1.234")))
+	assert.Equal(t, tuple.Of2(1234, error(nil)), tuple.Of2(ParseInt[int](DeDE, "+1234")))
+	assert.Equal(t, tuple.Of2(1234567, error(nil)), tuple.Of2(ParseInt[int](EnIN, "12,34,567")))
+
+	// Any space groups digits for a locale that groups with a space
+	for _, str := range []string{"1 234 567", "1\u00a0234\u00a0567", "1\u202f234\u202f567"} {
+		assert.Equal(t, tuple.Of2(uint32(1234567), error(nil)), tuple.Of2(ParseInt[uint32](FrFR, str)))
+	}
+
+	// Invalid groups, fractions, and other characters
+	for _, str := range []string{"", "-", "12,34", "1,2345", ",123", "123,", "1,,234", "1.5", "0x10", "1_000", "$1"} {
+		assert.Equal(t, tuple.Of2(0, fmt.Errorf(errParseMsg, str, "en-US")), tuple.Of2(ParseInt[int](EnUS, str)), str)
+	}
+
+	// en-IN groups differ from en-US
+	assert.Equal(t, tuple.Of2(0, fmt.Errorf(errParseMsg, "1,234,567", "en-IN")), tuple.Of2(ParseInt[int](EnIN, "1,234,567")))
+
+	// Out of range
+	_, err := ParseInt[int8](EnUS, "1,000")
+	assert.Equal(t, fmt.Errorf("The int64 value of 1000 cannot be converted to int8"), err)
+
+	assert.Equal(t, 1000, MustParseInt[int](EnGB, "1,000"))
+
+	funcs.TryTo(
+		func() {
+			MustParseInt[int](EnUS, "1.000")
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errParseMsg, "1.000", "en-US"), e)
+		},
+	)
+}
+
+func TestParseFloat_(t *testing.T) {
+	assert.Equal(t, tuple.Of2(1234.5, error(nil)), tuple.Of2(ParseFloat(EnUS, "1,234.5")))
+	assert.Equal(t, tuple.Of2(-1234.5, error(nil)), tuple.Of2(ParseFloat(DeDE, "-1.234,5")))
+	assert.Equal(t, tuple.Of2(1.234, error(nil)), tuple.Of2(ParseFloat(EnUS, "1.234")))
+	assert.Equal(t, tuple.Of2(1234.0, error(nil)), tuple.Of2(ParseFloat(DeDE, "1.234")))
+
+	for _, str := range []string{"1,", "1,2,3", "1,5e3", ",5.3"} {
+		assert.Equal(t, tuple.Of2(0.0, fmt.Errorf(errParseMsg, str, "de-DE")), tuple.Of2(ParseFloat(DeDE, str)), str)
+	}
+
+	assert.Equal(t, 0.5, MustParseFloat(DeDE, "0,5"))
+
+	funcs.TryTo(
+		func() {
+			MustParseFloat(DeDE, "0.5")
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errParseMsg, "0.5", "de-DE"), e)
+		},
+	)
+}
+
+func TestParseDecimal_(t *testing.T) {
+	assert.Equal(t, tuple.Of2(math.MustStringToDecimal("1234.50"), error(nil)), tuple.Of2(ParseDecimal(DeDE, "1.234,50")))
+	assert.Equal(t, tuple.Of2(math.MustStringToDecimal("-0.05"), error(nil)), tuple.Of2(ParseDecimal(EnUS, "-0.05")))
+	assert.Equal(t, tuple.Of2(math.Decimal{}, fmt.Errorf(errParseMsg, "1,5", "en-US")), tuple.Of2(ParseDecimal(EnUS, "1,5")))
+
+	// Round trip
+	d := math.MustStringToDecimal("-1234567.125")
+	for _, l := range []Locale{EnUS, EnGB, EnIN, DeDE, DeCH, EsES, FrFR, ItIT, JaJP, PtBR} {
+		assert.Equal(t, d, MustParseDecimal(l, FormatDecimal(l, d)), l.Tag)
+		assert.Equal(t, d, MustParseCurrency(l, FormatCurrency(l, d)), l.Tag)
+	}
+
+	funcs.TryTo(
+		func() {
+			MustParseDecimal(EnUS, "x")
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errParseMsg, "x", "en-US"), e)
+		},
+	)
+}
+
+func TestParseBig_(t *testing.T) {
+	huge := new(big.Int).Exp(big.NewInt(10), big.NewInt(21), nil)
+	assert.Equal(t, tuple.Of2(huge, error(nil)), tuple.Of2(ParseBigInt(EnUS, "1,000,000,000,000,000,000,000")))
+	assert.Equal(t, tuple.Of2((*big.Int)(nil), fmt.Errorf(errParseMsg, "1.5", "en-US")), tuple.Of2(ParseBigInt(EnUS, "1.5")))
+	assert.Equal(t, huge, MustParseBigInt(DeDE, "1.000.000.000.000.000.000.000"))
+
+	bf, err := ParseBigFloat(FrFR, "1 234,125")
+	assert.Nil(t, err)
+	assert.Equal(t, "1234.125", bf.Text('f', -1))
+
+	assert.Equal(t, tuple.Of2((*big.Float)(nil), fmt.Errorf(errParseMsg, "1.234,125", "fr-FR")), tuple.Of2(ParseBigFloat(FrFR, "1.234,125")))
+	assert.Equal(t, "-0.5", MustParseBigFloat(EnUS, "-0.5").Text('f', -1))
+
+	funcs.TryTo(
+		func() {
+			MustParseBigInt(EnUS, "")
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errParseMsg, "", "en-US"), e)
+		},
+	)
+
+	funcs.TryTo(
+		func() {
+			MustParseBigFloat(EnUS, "")
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errParseMsg, "", "en-US"), e)
+		},
+	)
+}
+
+func TestParseCurrency_(t *testing.T) {
+	amt := math.MustStringToDecimal("1234.50")
+	for l, str := range map[Locale]string{
+		EnUS: "$1,234.50",
+		EnGB: " £1234.50 ",
+		DeDE: "1.234,50 €",
+		FrFR: "1 234,50€",
+		PtBR: "R$ 1.234,50",
+	} {
+		assert.Equal(t, tuple.Of2(amt, error(nil)), tuple.Of2(ParseCurrency(l, str)), str)
+	}
+
+	// The sign can be before or after a leading symbol
+	neg := math.MustStringToDecimal("-1.5")
+	assert.Equal(t, tuple.Of2(neg, error(nil)), tuple.Of2(ParseCurrency(EnUS, "-$1.5")))
+	assert.Equal(t, tuple.Of2(neg, error(nil)), tuple.Of2(ParseCurrency(EnUS, "$-1.5")))
+	assert.Equal(t, tuple.Of2(neg, error(nil)), tuple.Of2(ParseCurrency(DeDE, "-1,5 €")))
+
+	// The symbol is required, in the right place
+	for _, str := range []string{"1.5", "1.5$", "€1.5", "-$-1.5", "$"} {
+		assert.Equal(t, tuple.Of2(math.Decimal{}, fmt.Errorf(errParseMsg, str, "en-US")), tuple.Of2(ParseCurrency(EnUS, str)), str)
+	}
+
+	funcs.TryTo(
+		func() {
+			MustParseCurrency(DeDE, "€1")
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errParseMsg, "€1", "de-DE"), e)
+		},
+	)
+}
@@ -0,0 +1,169 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	goreflect "reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Observer is notified of each conversion made by To, AnyTo, ToBigOps, and ReflectTo, with the source and target
+// types, how long the conversion took, and the error if it failed. A type is nil if the source is a nil interface, or
+// the target is not a valid pointer.
+//
+// Conversions made of other conversions, such as ToSlice converting each element with To, have each of those
+// conversions observed. An Observer is called by any goroutine that converts a value, so it must be safe for concurrent
+// use, and should be fast, as it is called for every conversion.
+type Observer func(in, out goreflect.Type, elapsed time.Duration, err error)
+
+// observerHolder holds an Observer, as an atomic.Value cannot hold a nil func
+type observerHolder struct {
+	obs Observer
+}
+
+var (
+	// observerOn is 1 if there is an Observer, so that conversions only have to check an int when there is none
+	observerOn int32
+
+	// observer holds the observerHolder of the Observer
+	observer atomic.Value
+)
+
+// SetObserver sets the Observer of all conversions, replacing any previous Observer, or removes it if obs is nil.
+// Returns the previous Observer, or nil if there was none, so that it can be restored later.
+//
+// Eg, a data import service can count the conversions that fail by pair of types:
+//
+//	stats := conv.NewConversionStats()
+//	conv.SetObserver(stats.Observe)
+func SetObserver(obs Observer) Observer {
+	var prev observerHolder
+	if h := observer.Swap(observerHolder{obs}); h != nil {
+		prev = h.(observerHolder)
+	}
+
+	if obs == nil {
+		atomic.StoreInt32(&observerOn, 0)
+	} else {
+		atomic.StoreInt32(&observerOn, 1)
+	}
+
+	return prev.obs
+}
+
+// observing returns true if there is an Observer
+func observing() bool {
+	return atomic.LoadInt32(&observerOn) != 0
+}
+
+// observe notifies the Observer, if any, of a conversion that started at the given time
+func observe(in, out goreflect.Type, start time.Time, err error) {
+	if h, _ := observer.Load().(observerHolder); h.obs != nil {
+		h.obs(in, out, time.Since(start), err)
+	}
+}
+
+// reflectInType returns the source type of a ReflectTo conversion, or nil if the source is invalid
+func reflectInType(i goreflect.Value) goreflect.Type {
+	if !i.IsValid() {
+		return nil
+	}
+
+	return i.Type()
+}
+
+// reflectOutType returns the target type of a ReflectTo conversion, or nil if the target is not a valid pointer
+func reflectOutType(o goreflect.Value) goreflect.Type {
+	if !o.IsValid() || (o.Kind() != goreflect.Pointer) {
+		return nil
+	}
+
+	return o.Type().Elem()
+}
+
+// typeName returns the name of a type for a ConversionPair, or "nil" for a nil type
+func typeName(typ goreflect.Type) string {
+	if typ == nil {
+		return "nil"
+	}
+
+	return typ.String()
+}
+
+// ConversionPair is a pair of source and target type names, as returned by reflect.Type.String
+type ConversionPair struct {
+	In  string
+	Out string
+}
+
+// ConversionStat is the statistics of the conversions of a ConversionPair
+type ConversionStat struct {
+	// Count is the number of conversions, including failures
+	Count uint64
+
+	// Failures is the number of conversions that returned an error
+	Failures uint64
+
+	// Elapsed is the total time of all conversions
+	Elapsed time.Duration
+}
+
+// ConversionStats accumulates a ConversionStat for each ConversionPair reported to its Observe method, which can be
+// passed to SetObserver. It is safe for concurrent use.
+//
+// The zero value is ready to use.
+type ConversionStats struct {
+	mu    sync.Mutex
+	stats map[ConversionPair]ConversionStat
+}
+
+// NewConversionStats constructs a ConversionStats
+func NewConversionStats() *ConversionStats {
+	return &ConversionStats{}
+}
+
+// Observe is an Observer that records a conversion
+func (s *ConversionStats) Observe(in, out goreflect.Type, elapsed time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stats == nil {
+		s.stats = map[ConversionPair]ConversionStat{}
+	}
+
+	var (
+		pair = ConversionPair{In: typeName(in), Out: typeName(out)}
+		stat = s.stats[pair]
+	)
+
+	stat.Count++
+	if err != nil {
+		stat.Failures++
+	}
+	stat.Elapsed += elapsed
+
+	s.stats[pair] = stat
+}
+
+// Stats returns a copy of the statistics recorded so far
+func (s *ConversionStats) Stats() map[ConversionPair]ConversionStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := make(map[ConversionPair]ConversionStat, len(s.stats))
+	for pair, stat := range s.stats {
+		res[pair] = stat
+	}
+
+	return res
+}
+
+// Reset removes all statistics
+func (s *ConversionStats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats = nil
+}
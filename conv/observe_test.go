@@ -0,0 +1,102 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"math/big"
+	goreflect "reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countsOf returns the stats without the elapsed times, which vary
+func countsOf(s *ConversionStats) map[ConversionPair]ConversionStat {
+	res := s.Stats()
+	for pair, stat := range res {
+		stat.Elapsed = 0
+		res[pair] = stat
+	}
+
+	return res
+}
+
+func TestSetObserver_(t *testing.T) {
+	stats := NewConversionStats()
+	assert.Nil(t, SetObserver(stats.Observe))
+	defer SetObserver(nil)
+
+	var (
+		i  int
+		s  string
+		bi *big.Int
+	)
+
+	assert.Nil(t, To("1", &i))
+	assert.NotNil(t, To("x", &i))
+	assert.Nil(t, To(1, &s))
+
+	// AnyTo and the text fallback of ReflectTo are observed once
+	assert.Nil(t, AnyTo(int8(5), &i))
+	assert.NotNil(t, AnyTo(nil, &i))
+	assert.Nil(t, ReflectTo(goreflect.ValueOf([]byte("a")), goreflect.ValueOf(&s)))
+	assert.NotNil(t, ReflectTo(goreflect.Value{}, goreflect.Value{}))
+	assert.Nil(t, ToBigOps("2", &bi))
+
+	// Each element is observed
+	var slc []int
+	assert.Nil(t, ToSlice([]string{"1", "2"}, &slc))
+
+	assert.Equal(
+		t,
+		map[ConversionPair]ConversionStat{
+			{"string", "int"}:      {Count: 4, Failures: 1},
+			{"int", "string"}:      {Count: 1},
+			{"int8", "int"}:        {Count: 1},
+			{"nil", "int"}:         {Count: 1, Failures: 1},
+			{"[]uint8", "string"}:  {Count: 1},
+			{"nil", "nil"}:         {Count: 1, Failures: 1},
+			{"string", "*big.Int"}: {Count: 1},
+		},
+		countsOf(stats),
+	)
+
+	// Removing the observer returns it
+	stats.Reset()
+	assert.NotNil(t, SetObserver(nil))
+	assert.Nil(t, To("1", &i))
+	assert.Equal(t, map[ConversionPair]ConversionStat{}, stats.Stats())
+	assert.Nil(t, SetObserver(nil))
+}
+
+func TestConversionStats_(t *testing.T) {
+	var (
+		stats ConversionStats
+		wg    sync.WaitGroup
+		ityp  = goreflect.TypeOf("")
+		otyp  = goreflect.TypeOf(0)
+	)
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				stats.Observe(ityp, otyp, time.Millisecond, nil)
+				stats.Observe(ityp, nil, time.Millisecond, errReflectToInvalidTgt)
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(
+		t,
+		map[ConversionPair]ConversionStat{
+			{"string", "int"}: {Count: 400, Elapsed: 400 * time.Millisecond},
+			{"string", "nil"}: {Count: 400, Failures: 400, Elapsed: 400 * time.Millisecond},
+		},
+		stats.Stats(),
+	)
+}
@@ -0,0 +1,112 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	goreflect "reflect"
+	"sync"
+
+	"github.com/bantling/micro/funcs"
+)
+
+var (
+	errRegisterNilMsg = "%s requires non-nil types and function"
+
+	// convertFromToMu guards convertFromTo after package initialization
+	convertFromToMu sync.RWMutex
+)
+
+// lookupConvertFromTo returns the conversion function registered for the from and to type strings, or nil
+func lookupConvertFromTo(from, to string) func(any, any) error {
+	convertFromToMu.RLock()
+	defer convertFromToMu.RUnlock()
+
+	return convertFromTo[from+to]
+}
+
+// clearToCache removes the cached handling of all type pairs, except those registered by RegisterDirect for any other
+// pair than the one given, so that To picks up a change to convertFromTo
+func clearToCache(key toKey) {
+	toCache.Range(func(k, v any) bool {
+		if (v.(toEntry).kind != toRegistered) || (k.(toKey) == key) {
+			toCache.Delete(k)
+		}
+
+		return true
+	})
+}
+
+// Register registers a function that converts a value of type from to a pointer to type to, for use by To, AnyTo,
+// ReflectTo, and anything else built on them. It is the means by which other packages plug their types into conv,
+// typically in an init function. Eg, for some type UUID:
+//
+//	conv.Register(reflect.TypeOf(UUID{}), reflect.TypeOf(""), func(i, o any) error {
+//	  *(o.(*string)) = i.(UUID).String()
+//	  return nil
+//	})
+//
+// Registering a function for a pair of types that already has one replaces it, including the built in conversions and
+// those registered by RegisterDirect. Replacing a built in conversion between two numeric types or a numeric type and a
+// string turns off the faster paths To uses for them.
+//
+// Types are identified by their String method, so two types of the same name in different packages with the same
+// package name cannot both be registered.
+//
+// Register is safe for concurrent use, but conversions in progress may still use a replaced function.
+// Panics if either type or the function is nil.
+func Register(from, to goreflect.Type, fn func(any, any) error) {
+	if (from == nil) || (to == nil) || (fn == nil) {
+		panic(fmt.Errorf(errRegisterNilMsg, "Register"))
+	}
+
+	convertFromToMu.Lock()
+	defer convertFromToMu.Unlock()
+
+	convertFromTo[from.String()+to.String()] = fn
+	overrideToFast(toKey{from, to})
+	clearToCache(toKey{from, to})
+}
+
+// Unregister removes the function registered for a pair of types, returning true if there was one.
+// After it is removed, converting the pair of types is an error.
+//
+// Unregister is safe for concurrent use.
+// Panics if either type is nil.
+func Unregister(from, to goreflect.Type) bool {
+	if (from == nil) || (to == nil) {
+		panic(fmt.Errorf(errRegisterNilMsg, "Unregister"))
+	}
+
+	convertFromToMu.Lock()
+	defer convertFromToMu.Unlock()
+
+	key := from.String() + to.String()
+	_, haveIt := convertFromTo[key]
+	delete(convertFromTo, key)
+	overrideToFast(toKey{from, to})
+	clearToCache(toKey{from, to})
+
+	return haveIt
+}
+
+// LookupConversion returns the function registered for a pair of types, which converts a value of type from to a
+// pointer to type to. Returns an error if there is no such function.
+//
+// LookupConversion is safe for concurrent use.
+func LookupConversion(from, to goreflect.Type) (func(any, any) error, error) {
+	if (from == nil) || (to == nil) {
+		return nil, fmt.Errorf(errReflectToLookupMsg, from, to)
+	}
+
+	if fn := lookupConvertFromTo(from.String(), to.String()); fn != nil {
+		return fn, nil
+	}
+
+	return nil, fmt.Errorf(errReflectToLookupMsg, from, to)
+}
+
+// MustLookupConversion is a Must version of LookupConversion
+func MustLookupConversion(from, to goreflect.Type) func(any, any) error {
+	return funcs.MustValue(LookupConversion(from, to))
+}
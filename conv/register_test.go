@@ -0,0 +1,123 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	goreflect "reflect"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/stretchr/testify/assert"
+)
+
+type registerID struct {
+	id int
+}
+
+func TestRegister_(t *testing.T) {
+	var (
+		idTyp  = goreflect.TypeOf(registerID{})
+		strTyp = goreflect.TypeOf("")
+		intTyp = goreflect.TypeOf(0)
+		s      string
+	)
+
+	// Custom type
+	assert.Equal(t, fmt.Errorf(errReflectToLookupMsg, idTyp, strTyp), ReflectTo(goreflect.ValueOf(registerID{5}), goreflect.ValueOf(&s)))
+
+	Register(idTyp, strTyp, func(i, o any) error {
+		*(o.(*string)) = fmt.Sprintf("id-%d", i.(registerID).id)
+		return nil
+	})
+	defer Unregister(idTyp, strTyp)
+
+	assert.Nil(t, ReflectTo(goreflect.ValueOf(registerID{5}), goreflect.ValueOf(&s)))
+	assert.Equal(t, "id-5", s)
+	assert.NotNil(t, MustLookupConversion(idTyp, strTyp))
+
+	// AnyTo uses registered conversions for types it does not otherwise handle
+	assert.Nil(t, AnyTo(registerID{6}, &s))
+	assert.Equal(t, "id-6", s)
+
+	// Override a built in conversion, after To has cached it
+	orig := MustLookupConversion(intTyp, strTyp)
+	assert.Nil(t, To(1, &s))
+	assert.Equal(t, "1", s)
+
+	Register(intTyp, strTyp, func(i, o any) error {
+		*(o.(*string)) = fmt.Sprintf("#%d", i.(int))
+		return nil
+	})
+	assert.Nil(t, To(1, &s))
+	assert.Equal(t, "#1", s)
+
+	// Unregister, To returns an error
+	assert.True(t, Unregister(intTyp, strTyp))
+	assert.False(t, Unregister(intTyp, strTyp))
+	assert.Equal(t, fmt.Errorf(errReflectToLookupMsg, intTyp, strTyp), To(1, &s))
+	_, err := LookupConversion(intTyp, strTyp)
+	assert.Equal(t, fmt.Errorf(errReflectToLookupMsg, intTyp, strTyp), err)
+
+	Register(intTyp, strTyp, orig)
+	assert.Nil(t, To(2, &s))
+	assert.Equal(t, "2", s)
+
+	// Override a RegisterDirect conversion, other RegisterDirect conversions are unaffected
+	type regFoo int
+	type regBar float64
+	var (
+		fooTyp = goreflect.TypeOf(regFoo(0))
+		barTyp = goreflect.TypeOf(regBar(0))
+		b      regBar
+	)
+
+	RegisterDirect(func(i regFoo, o *regBar) error { *o = -1; return nil })
+	RegisterDirect(func(i regFoo, o *string) error { *o = "direct"; return nil })
+	assert.Nil(t, To(regFoo(1), &b))
+	assert.Equal(t, regBar(-1), b)
+
+	// To converts subtypes as their base types, so only ReflectTo could use this function
+	Register(fooTyp, barTyp, func(i, o any) error { return nil })
+	defer Unregister(fooTyp, barTyp)
+
+	// regFoo and regBar no longer have a direct function, so they are converted as their base types
+	assert.Nil(t, To(regFoo(1), &b))
+	assert.Equal(t, regBar(1), b)
+	assert.Nil(t, To(regFoo(1), &s))
+	assert.Equal(t, "direct", s)
+
+	// Nil arguments
+	for _, fn := range []func(){
+		func() { Register(nil, strTyp, orig) },
+		func() { Register(intTyp, nil, orig) },
+		func() { Register(intTyp, strTyp, nil) },
+	} {
+		funcs.TryTo(
+			func() {
+				fn()
+				assert.Fail(t, "Must die")
+			},
+			func(e any) { assert.Equal(t, fmt.Errorf(errRegisterNilMsg, "Register"), e) },
+		)
+	}
+
+	funcs.TryTo(
+		func() {
+			Unregister(nil, strTyp)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf(errRegisterNilMsg, "Unregister"), e) },
+	)
+
+	_, err = LookupConversion(nil, strTyp)
+	assert.Equal(t, fmt.Errorf(errReflectToLookupMsg, nil, strTyp), err)
+
+	funcs.TryTo(
+		func() {
+			MustLookupConversion(strTyp, idTyp)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf(errReflectToLookupMsg, strTyp, idTyp), e) },
+	)
+}
@@ -0,0 +1,81 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+
+	"github.com/bantling/micro/constraint"
+	"github.com/bantling/micro/funcs"
+)
+
+var (
+	errToSliceMsg = "The element at index %s cannot be converted: %w"
+)
+
+// toSlice converts i into a new slice, formatting the index of a failing element after the given prefix
+func toSlice[I, O constraint.Numeric | string](prefix string, i []I, o *[]O) error {
+	if i == nil {
+		*o = nil
+		return nil
+	}
+
+	res := make([]O, len(i))
+	for idx, ival := range i {
+		if err := To(ival, &res[idx]); err != nil {
+			return fmt.Errorf(errToSliceMsg, fmt.Sprintf("%s[%d]", prefix, idx), err)
+		}
+	}
+
+	*o = res
+	return nil
+}
+
+// ToSlice converts a []I to a []O, converting each element with To.
+// A nil input produces a nil output, and an empty input produces an empty output.
+//
+// If an element cannot be converted, the error contains the index of the element, and wraps the error returned by To.
+// The target is only modified if all elements are converted.
+func ToSlice[I, O constraint.Numeric | string](i []I, o *[]O) error {
+	if o == nil {
+		return fmt.Errorf(errONonNilMsg, o)
+	}
+
+	return toSlice("", i, o)
+}
+
+// MustToSlice is a Must version of ToSlice
+func MustToSlice[I, O constraint.Numeric | string](i []I, o *[]O) {
+	funcs.Must(ToSlice(i, o))
+}
+
+// ToSlice2 converts a [][]I to a [][]O, converting each inner slice with ToSlice.
+// Nil inner slices produce nil inner slices.
+//
+// If an element cannot be converted, the error contains both indexes of the element, eg [1][2].
+// The target is only modified if all elements are converted.
+func ToSlice2[I, O constraint.Numeric | string](i [][]I, o *[][]O) error {
+	if o == nil {
+		return fmt.Errorf(errONonNilMsg, o)
+	}
+
+	if i == nil {
+		*o = nil
+		return nil
+	}
+
+	res := make([][]O, len(i))
+	for idx, ival := range i {
+		if err := toSlice(fmt.Sprintf("[%d]", idx), ival, &res[idx]); err != nil {
+			return err
+		}
+	}
+
+	*o = res
+	return nil
+}
+
+// MustToSlice2 is a Must version of ToSlice2
+func MustToSlice2[I, O constraint.Numeric | string](i [][]I, o *[][]O) {
+	funcs.Must(ToSlice2(i, o))
+}
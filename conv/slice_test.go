@@ -0,0 +1,105 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToSlice_(t *testing.T) {
+	{
+		var o []int
+		assert.Nil(t, ToSlice([]string{"1", "2", "3"}, &o))
+		assert.Equal(t, []int{1, 2, 3}, o)
+
+		// Nil and empty
+		assert.Nil(t, ToSlice([]string(nil), &o))
+		assert.Nil(t, o)
+		assert.Nil(t, ToSlice([]string{}, &o))
+		assert.Equal(t, []int{}, o)
+	}
+
+	{
+		// Subtypes and big types
+		type myInt int
+		var o []*big.Int
+		assert.Nil(t, ToSlice([]myInt{1, 2}, &o))
+		assert.Equal(t, []*big.Int{big.NewInt(1), big.NewInt(2)}, o)
+
+		var s []string
+		assert.Nil(t, ToSlice(o, &s))
+		assert.Equal(t, []string{"1", "2"}, s)
+	}
+
+	{
+		// Failing index, target is not modified
+		o := []int8{5}
+		err := ToSlice([]int{1, 2, 300}, &o)
+		assert.Equal(t, fmt.Errorf(errToSliceMsg, "[2]", fmt.Errorf(errMsg, 300, "300", "int8")), err)
+		assert.Equal(t, "The element at index [2] cannot be converted: The int value of 300 cannot be converted to int8", err.Error())
+		assert.Equal(t, fmt.Errorf(errMsg, 300, "300", "int8"), errors.Unwrap(err))
+		assert.Equal(t, []int8{5}, o)
+	}
+
+	assert.Equal(t, fmt.Errorf(errONonNilMsg, (*[]int)(nil)), ToSlice([]int{1}, (*[]int)(nil)))
+
+	{
+		var o []uint
+		MustToSlice([]int{1}, &o)
+		assert.Equal(t, []uint{1}, o)
+
+		funcs.TryTo(
+			func() {
+				MustToSlice([]int{1, -1}, &o)
+				assert.Fail(t, "Must die")
+			},
+			func(e any) {
+				assert.Equal(t, fmt.Errorf(errToSliceMsg, "[1]", fmt.Errorf(errMsg, -1, "-1", "uint")), e)
+			},
+		)
+	}
+}
+
+func TestToSlice2_(t *testing.T) {
+	{
+		var o [][]float64
+		assert.Nil(t, ToSlice2([][]int{{1, 2}, nil, {}, {3}}, &o))
+		assert.Equal(t, [][]float64{{1, 2}, nil, {}, {3}}, o)
+
+		assert.Nil(t, ToSlice2([][]int(nil), &o))
+		assert.Nil(t, o)
+	}
+
+	{
+		// Failing indexes, target is not modified
+		o := [][]int{{5}}
+		err := ToSlice2([][]string{{"1"}, {"2", "x"}}, &o)
+		assert.Equal(t, "The element at index [1][1] cannot be converted: The string value of x cannot be converted to int64", err.Error())
+		assert.Equal(t, [][]int{{5}}, o)
+	}
+
+	assert.Equal(t, fmt.Errorf(errONonNilMsg, (*[][]int)(nil)), ToSlice2([][]int{{1}}, (*[][]int)(nil)))
+
+	{
+		var o [][]string
+		MustToSlice2([][]int{{1}}, &o)
+		assert.Equal(t, [][]string{{"1"}}, o)
+
+		funcs.TryTo(
+			func() {
+				var i [][]int
+				MustToSlice2([][]string{{"y"}}, &i)
+				assert.Fail(t, "Must die")
+			},
+			func(e any) {
+				assert.Equal(t, "The element at index [0][0] cannot be converted: The string value of y cannot be converted to int64", e.(error).Error())
+			},
+		)
+	}
+}
@@ -3,8 +3,10 @@ package conv
 // SPDX-License-Identifier: Apache-2.0
 
 import (
+	"fmt"
 	"math/big"
 	"strconv"
+	"strings"
 
 	"github.com/bantling/micro/constraint"
 	"github.com/bantling/micro/funcs"
@@ -20,6 +22,90 @@ func UintToString[T constraint.UnsignedInteger](val T) string {
 	return strconv.FormatUint(uint64(val), 10)
 }
 
+var (
+	errIntFormatBaseMsg   = "The base %d is not in the range 2 thru 36"
+	errIntFormatPrefixMsg = "A prefix is only allowed for base 2, 8, or 16, not %d"
+
+	// intFormatPrefixes are the Go literal prefixes of each base
+	intFormatPrefixes = map[int]string{2: "0b", 8: "0o", 16: "0x"}
+)
+
+// IntFormat contains the options for IntToStringBase. The zero value formats the same as IntToString.
+type IntFormat struct {
+	// Base is the base of the digits, 2 thru 36, or 0 for base 10
+	Base int
+
+	// Prefix adds a 0b, 0o, or 0x prefix for base 2, 8, or 16, so that the result is a Go literal
+	Prefix bool
+
+	// GroupSize separates each group of GroupSize digits, counting from the right, if it is non-zero (eg, 3 for 1_000)
+	GroupSize uint
+
+	// Separator separates the groups, if it is empty then "_" is used, which LiteralToInt64 and LiteralToUint64 accept
+	Separator string
+
+	// Upper uses uppercase letters for digits over 9
+	Upper bool
+}
+
+// IntToStringBase converts any signed or unsigned int type into a string as described by the format. Eg, formatting
+// 0xFFFF_FFFF with base 16, prefix, and group size 4 is 0xffff_ffff.
+//
+// Returns an error if the base is not 0 or 2 thru 36, or there is a prefix for a base other than 2, 8, or 16.
+func IntToStringBase[T constraint.Integer](val T, format IntFormat) (string, error) {
+	base := funcs.Ternary(format.Base == 0, 10, format.Base)
+	if (base < 2) || (base > 36) {
+		return "", fmt.Errorf(errIntFormatBaseMsg, format.Base)
+	}
+
+	prefix := ""
+	if format.Prefix {
+		var haveIt bool
+		if prefix, haveIt = intFormatPrefixes[base]; !haveIt {
+			return "", fmt.Errorf(errIntFormatPrefixMsg, base)
+		}
+	}
+
+	// A negative value has a minus sign before any prefix, as in Go
+	var sign, digits string
+	if val < 0 {
+		sign, digits = "-", strconv.FormatUint(uint64(-int64(val)), base)
+	} else {
+		digits = strconv.FormatUint(uint64(val), base)
+	}
+
+	if format.Upper {
+		digits = strings.ToUpper(digits)
+	}
+
+	if size := int(format.GroupSize); (size > 0) && (len(digits) > size) {
+		var (
+			sep     = funcs.Ternary(format.Separator == "", "_", format.Separator)
+			grouped strings.Builder
+			first   = len(digits) % size
+		)
+
+		if first == 0 {
+			first = size
+		}
+
+		grouped.WriteString(digits[:first])
+		for i := first; i < len(digits); i += size {
+			grouped.WriteString(sep)
+			grouped.WriteString(digits[i : i+size])
+		}
+
+		digits = grouped.String()
+	}
+
+	return sign + prefix + digits, nil
+}
+
+// MustIntToStringBase is a Must version of IntToStringBase
+func MustIntToStringBase[T constraint.Integer](val T, format IntFormat) string {
+	return funcs.MustValue(IntToStringBase(val, format))
+}
+
 // FloatToString converts any float type into a string
 func FloatToString[T constraint.Float](val T) string {
 	_, is32 := any(val).(float32)
@@ -3,10 +3,13 @@ package conv
 // SPDX-License-Identifier: Apache-2.0
 
 import (
+	"fmt"
 	"math"
 	"math/big"
 	"testing"
 
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/tuple"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -26,6 +29,43 @@ func TestUintToString_(t *testing.T) {
 	assert.Equal(t, UintToString(uint(5)), "5")
 }
 
+func TestIntToStringBase_(t *testing.T) {
+	assert.Equal(t, tuple.Of2("-123", error(nil)), tuple.Of2(IntToStringBase(-123, IntFormat{})))
+	assert.Equal(t, tuple.Of2("0xffff_ffff", error(nil)), tuple.Of2(IntToStringBase(uint32(math.MaxUint32), IntFormat{Base: 16, Prefix: true, GroupSize: 4})))
+	assert.Equal(t, tuple.Of2("-0x80", error(nil)), tuple.Of2(IntToStringBase(int8(math.MinInt8), IntFormat{Base: 16, Prefix: true})))
+	assert.Equal(t, tuple.Of2("-0x8000000000000000", error(nil)), tuple.Of2(IntToStringBase(int64(math.MinInt64), IntFormat{Base: 16, Prefix: true})))
+	assert.Equal(t, tuple.Of2("0b1_0100", error(nil)), tuple.Of2(IntToStringBase(20, IntFormat{Base: 2, Prefix: true, GroupSize: 4})))
+	assert.Equal(t, tuple.Of2("0o17", error(nil)), tuple.Of2(IntToStringBase(15, IntFormat{Base: 8, Prefix: true})))
+	assert.Equal(t, tuple.Of2("1,234,567", error(nil)), tuple.Of2(IntToStringBase(1234567, IntFormat{GroupSize: 3, Separator: ","})))
+	assert.Equal(t, tuple.Of2("123", error(nil)), tuple.Of2(IntToStringBase(123, IntFormat{GroupSize: 3})))
+	assert.Equal(t, tuple.Of2("ZZ", error(nil)), tuple.Of2(IntToStringBase(1295, IntFormat{Base: 36, Upper: true})))
+
+	assert.Equal(t, tuple.Of2("", fmt.Errorf(errIntFormatBaseMsg, 1)), tuple.Of2(IntToStringBase(0, IntFormat{Base: 1})))
+	assert.Equal(t, tuple.Of2("", fmt.Errorf(errIntFormatBaseMsg, 37)), tuple.Of2(IntToStringBase(0, IntFormat{Base: 37})))
+	assert.Equal(t, tuple.Of2("", fmt.Errorf(errIntFormatPrefixMsg, 10)), tuple.Of2(IntToStringBase(0, IntFormat{Prefix: true})))
+
+	// Literals with the default separator parse back
+	var (
+		format = IntFormat{Base: 16, Prefix: true, GroupSize: 2}
+		str    = MustIntToStringBase(-0x123456, format)
+		i      int64
+	)
+
+	assert.Equal(t, "-0x12_34_56", str)
+	assert.Nil(t, LiteralToInt64(str, &i))
+	assert.Equal(t, int64(-0x123456), i)
+
+	funcs.TryTo(
+		func() {
+			MustIntToStringBase(1, IntFormat{Base: 3, Prefix: true})
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errIntFormatPrefixMsg, 3), e)
+		},
+	)
+}
+
 func TestFloatToString_(t *testing.T) {
 	assert.Equal(t, "1.25", FloatToString(float32(1.25)))
 	assert.Equal(t, "1.25", FloatToString(float64(1.25)))
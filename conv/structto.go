@@ -0,0 +1,158 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	goreflect "reflect"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/reflect"
+)
+
+const (
+	// structToTag is the tag that renames a field for StructTo, or skips it when the value is -
+	structToTag = "conv"
+)
+
+var (
+	errStructToSrcMsg   = "StructTo source must be a struct or a non-nil pointer to a struct, not %T"
+	errStructToDstMsg   = "StructTo target must be a non-nil pointer to a struct, not %T"
+	errStructToFieldMsg = "The field %s.%s cannot be converted to the field %s.%s: %w"
+)
+
+// structToField is an exported field of a struct, and the name StructTo matches it by
+type structToField struct {
+	name  string
+	index int
+}
+
+// structToFields returns the exported fields of a struct type that are not skipped, in order of declaration
+func structToFields(typ goreflect.Type) []structToField {
+	var fields []structToField
+
+	for i, n := 0, typ.NumField(); i < n; i++ {
+		fld := typ.Field(i)
+		if !fld.IsExported() {
+			continue
+		}
+
+		name := fld.Name
+		if tag := fld.Tag.Get(structToTag); tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+
+		fields = append(fields, structToField{name, i})
+	}
+
+	return fields
+}
+
+// structTo converts the matching fields of src into dst, which must be settable
+func structTo(src, dst goreflect.Value) error {
+	var (
+		styp, dtyp = src.Type(), dst.Type()
+		srcFields  = map[string]int{}
+	)
+
+	for _, fld := range structToFields(styp) {
+		srcFields[fld.name] = fld.index
+	}
+
+	for _, fld := range structToFields(dtyp) {
+		si, haveIt := srcFields[fld.name]
+		if !haveIt {
+			continue
+		}
+
+		if err := structToValue(src.Field(si), dst.Field(fld.index)); err != nil {
+			return fmt.Errorf(errStructToFieldMsg, styp, styp.Field(si).Name, dtyp, dtyp.Field(fld.index).Name, err)
+		}
+	}
+
+	return nil
+}
+
+// structToValue converts a src field into a dst field
+func structToValue(src, dst goreflect.Value) error {
+	var (
+		styp, dtyp = src.Type(), dst.Type()
+	)
+
+	switch {
+	case styp.AssignableTo(dtyp):
+		dst.Set(src)
+		return nil
+
+	case (styp.Kind() == dtyp.Kind()) && ((reflect.TypeToBaseType(styp) != nil) || (reflect.TypeToBaseType(dtyp) != nil)):
+		// A primitive and a subtype of it, or two subtypes of the same primitive
+		dst.Set(src.Convert(dtyp))
+		return nil
+
+	case (styp.Kind() == goreflect.Struct) && (dtyp.Kind() == goreflect.Struct):
+		return structTo(src, dst)
+
+	case (styp.Kind() == goreflect.Pointer) && (styp.Elem().Kind() == goreflect.Struct) &&
+		(dtyp.Kind() == goreflect.Pointer) && (dtyp.Elem().Kind() == goreflect.Struct):
+		if src.IsNil() {
+			dst.Set(goreflect.Zero(dtyp))
+			return nil
+		}
+
+		val := goreflect.New(dtyp.Elem())
+		if err := structTo(src.Elem(), val.Elem()); err != nil {
+			return err
+		}
+
+		dst.Set(val)
+		return nil
+	}
+
+	return ReflectTo(reflect.ValueToBaseType(src), dst.Addr())
+}
+
+// StructTo copies the exported fields of src into the exported fields of dst that have the same name.
+// The src may be a struct or a pointer to a struct, and dst must be a pointer to a struct.
+//
+// Fields of the same type are assigned, so pointers, slices, and maps are shared.
+// Fields of different types are converted with ReflectTo, eg int32 to int64, or string to *big.Rat.
+// Fields that are structs or pointers to structs of different types are converted recursively.
+//
+// A field tagged with conv:"name" on either side is matched by the given name, and a field tagged conv:"-" is skipped.
+// Fields that do not match anything are left unchanged.
+//
+// If a field cannot be converted, the error contains the src and dst field names, and wraps the conversion error.
+// The dst is only modified if all fields are converted.
+func StructTo(src, dst any) error {
+	sv := goreflect.ValueOf(src)
+	if (sv.Kind() == goreflect.Pointer) && !sv.IsNil() {
+		sv = sv.Elem()
+	}
+
+	if sv.Kind() != goreflect.Struct {
+		return fmt.Errorf(errStructToSrcMsg, src)
+	}
+
+	dv := goreflect.ValueOf(dst)
+	if (dv.Kind() != goreflect.Pointer) || dv.IsNil() || (dv.Elem().Kind() != goreflect.Struct) {
+		return fmt.Errorf(errStructToDstMsg, dst)
+	}
+
+	// Convert into a copy, so that dst is unmodified if an error occurs
+	res := goreflect.New(dv.Type().Elem()).Elem()
+	res.Set(dv.Elem())
+
+	if err := structTo(sv, res); err != nil {
+		return err
+	}
+
+	dv.Elem().Set(res)
+	return nil
+}
+
+// MustStructTo is a Must version of StructTo
+func MustStructTo(src, dst any) {
+	funcs.Must(StructTo(src, dst))
+}
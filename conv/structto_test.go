@@ -0,0 +1,173 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/stretchr/testify/assert"
+)
+
+type structToAddressDTO struct {
+	City string
+}
+
+type structToDTO struct {
+	ID       int32
+	Name     string
+	Price    string
+	Alias    string `conv:"Nickname"`
+	Secret   string `conv:"-"`
+	Tags     []string
+	Address  structToAddressDTO
+	Billing  *structToAddressDTO
+	Shipping *structToAddressDTO
+	Extra    int
+	hidden   int
+}
+
+type structToAddress struct {
+	City string
+	Zip  string
+}
+
+type structToName string
+
+type structToDomain struct {
+	ID       int64
+	Name     structToName
+	Price    *big.Rat
+	Nickname string
+	Secret   string
+	Tags     []string
+	Address  structToAddress
+	Billing  *structToAddress
+	Shipping *structToAddress
+	Other    int
+	hidden   int
+}
+
+func TestStructTo_(t *testing.T) {
+	{
+		src := structToDTO{
+			ID:      1,
+			Name:    "Widget",
+			Price:   "1.25",
+			Alias:   "W",
+			Secret:  "s",
+			Tags:    []string{"a"},
+			Address: structToAddressDTO{City: "Toronto"},
+			Billing: &structToAddressDTO{City: "Ottawa"},
+			Extra:   5,
+			hidden:  6,
+		}
+		dst := structToDomain{Secret: "t", Address: structToAddress{Zip: "M1M"}, Shipping: &structToAddress{}, Other: 7}
+
+		assert.Nil(t, StructTo(src, &dst))
+		assert.Equal(
+			t,
+			structToDomain{
+				ID:       1,
+				Name:     "Widget",
+				Price:    big.NewRat(5, 4),
+				Nickname: "W",
+				Secret:   "t",
+				Tags:     []string{"a"},
+				Address:  structToAddress{City: "Toronto", Zip: "M1M"},
+				Billing:  &structToAddress{City: "Ottawa"},
+				Other:    7,
+			},
+			dst,
+		)
+
+		// Same types are shared
+		src.Tags[0] = "b"
+		assert.Equal(t, []string{"b"}, dst.Tags)
+
+		// Pointer source, and back the other way
+		var back structToDTO
+		assert.Nil(t, StructTo(&dst, &back))
+		assert.Equal(
+			t,
+			structToDTO{
+				ID:      1,
+				Name:    "Widget",
+				Price:   "5/4",
+				Alias:   "W",
+				Tags:    []string{"b"},
+				Address: structToAddressDTO{City: "Toronto"},
+				Billing: &structToAddressDTO{City: "Ottawa"},
+			},
+			back,
+		)
+	}
+
+	{
+		// Failing field, dst is unmodified
+		dst := structToDomain{ID: 2}
+		err := StructTo(structToDTO{ID: 1, Price: "x"}, &dst)
+		assert.Equal(
+			t,
+			"The field conv.structToDTO.Price cannot be converted to the field conv.structToDomain.Price: "+
+				`The string value of x cannot be converted to *big.Rat`,
+			err.Error(),
+		)
+		assert.NotNil(t, errors.Unwrap(err))
+		assert.Equal(t, structToDomain{ID: 2}, dst)
+
+		// Nested field
+		type inner struct{ N string }
+		type outer struct{ In *inner }
+		type innerInt struct{ N int }
+		type outerInt struct{ In *innerInt }
+
+		var o outerInt
+		assert.Equal(
+			t,
+			"The field conv.outer.In cannot be converted to the field conv.outerInt.In: "+
+				"The field conv.inner.N cannot be converted to the field conv.innerInt.N: "+
+				"The string value of a cannot be converted to int64",
+			StructTo(outer{&inner{"a"}}, &o).Error(),
+		)
+
+		// No conversion
+		type fn struct{ ID func() }
+		assert.Equal(
+			t,
+			"The field conv.fn.ID cannot be converted to the field conv.structToDomain.ID: "+
+				"There is no conversion function from func() to int64",
+			StructTo(fn{}, &dst).Error(),
+		)
+	}
+
+	{
+		// Invalid src and dst
+		var dst structToDomain
+		assert.Equal(t, fmt.Errorf(errStructToSrcMsg, nil), StructTo(nil, &dst))
+		assert.Equal(t, fmt.Errorf(errStructToSrcMsg, 1), StructTo(1, &dst))
+		assert.Equal(t, fmt.Errorf(errStructToSrcMsg, (*structToDTO)(nil)), StructTo((*structToDTO)(nil), &dst))
+		assert.Equal(t, fmt.Errorf(errStructToDstMsg, dst), StructTo(structToDTO{}, dst))
+		assert.Equal(t, fmt.Errorf(errStructToDstMsg, (*structToDomain)(nil)), StructTo(structToDTO{}, (*structToDomain)(nil)))
+		assert.Equal(t, fmt.Errorf(errStructToDstMsg, new(int)), StructTo(structToDTO{}, new(int)))
+	}
+
+	{
+		var dst structToDomain
+		MustStructTo(structToDTO{ID: 3, Price: "0"}, &dst)
+		assert.Equal(t, int64(3), dst.ID)
+
+		funcs.TryTo(
+			func() {
+				MustStructTo(1, &dst)
+				assert.Fail(t, "Must die")
+			},
+			func(e any) {
+				assert.Equal(t, fmt.Errorf(errStructToSrcMsg, 1), e)
+			},
+		)
+	}
+}
@@ -0,0 +1,90 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"encoding"
+	"fmt"
+	goreflect "reflect"
+
+	"github.com/bantling/micro/reflect"
+)
+
+var (
+	textMarshalerType   = goreflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = goreflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	bytesType           = goreflect.TypeOf([]byte(nil))
+)
+
+// reflectToText is the fallback of ReflectTo when there is no registered conversion, in order of preference:
+// - a source that is an encoding.TextMarshaler is converted as the string of its text
+// - a target that is an encoding.TextUnmarshaler is unmarshaled from the source converted to a string
+// - a source with the same base type as the target is copied, eg a string into a string
+// - a source that is a []byte (or a subtype of it) is converted as a string
+// - a target that is a []byte (or a subtype of it) is set to the bytes of the source converted to a string
+//
+// Returns an error if none of the above apply.
+func reflectToText(i, o goreflect.Value) error {
+	var (
+		ityp = i.Type()
+		otyp = o.Type().Elem()
+	)
+
+	switch {
+	case ityp.Implements(textMarshalerType):
+		text, err := i.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+
+		return reflectTo(goreflect.ValueOf(string(text)), o)
+
+	case o.Type().Implements(textUnmarshalerType):
+		if str, haveIt, err := reflectToString(i); haveIt {
+			if err != nil {
+				return err
+			}
+
+			return o.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str))
+		}
+
+	case !reflect.IsBigPtr(ityp) && (reflect.ValueToBaseType(i).Type() == reflect.ValueToBaseType(o).Type().Elem()):
+		o.Elem().Set(i.Convert(otyp))
+		return nil
+
+	case ityp.ConvertibleTo(bytesType) && (ityp.Kind() == goreflect.Slice):
+		return reflectTo(goreflect.ValueOf(string(i.Convert(bytesType).Bytes())), o)
+
+	case otyp.ConvertibleTo(bytesType) && (otyp.Kind() == goreflect.Slice):
+		if str, haveIt, err := reflectToString(i); haveIt {
+			if err != nil {
+				return err
+			}
+
+			o.Elem().Set(goreflect.ValueOf([]byte(str)).Convert(otyp))
+			return nil
+		}
+	}
+
+	return fmt.Errorf(errReflectToLookupMsg, ityp, otyp)
+}
+
+// reflectToString converts a source to a string for reflectToText, returning false if there is no conversion
+func reflectToString(i goreflect.Value) (string, bool, error) {
+	if i.Kind() == goreflect.String {
+		return i.String(), true, nil
+	}
+
+	if (i.Kind() == goreflect.Slice) && i.Type().ConvertibleTo(bytesType) {
+		return string(i.Convert(bytesType).Bytes()), true, nil
+	}
+
+	ib := reflect.ValueToBaseType(i)
+	if convFn := lookupConvertFromTo(ib.Type().String(), "string"); convFn != nil {
+		var str string
+		err := convFn(ib.Interface(), &str)
+		return str, true, err
+	}
+
+	return "", false, nil
+}
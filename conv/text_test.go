@@ -0,0 +1,125 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"net"
+	goreflect "reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// textID is marshaled as id-N
+type textID struct {
+	n int
+}
+
+func (t textID) MarshalText() ([]byte, error) {
+	if t.n < 0 {
+		return nil, fmt.Errorf("negative id")
+	}
+
+	return []byte(fmt.Sprintf("id-%d", t.n)), nil
+}
+
+func (t *textID) UnmarshalText(text []byte) error {
+	if !strings.HasPrefix(string(text), "id-") {
+		return fmt.Errorf("invalid id %s", text)
+	}
+
+	return To(string(text[3:]), &t.n)
+}
+
+// textCode is only unmarshaled
+type textCode string
+
+func (t *textCode) UnmarshalText(text []byte) error {
+	*t = textCode(strings.ToUpper(string(text)))
+	return nil
+}
+
+func TestToBytes_(t *testing.T) {
+	var (
+		i int
+		s string
+		b []byte
+	)
+
+	assert.Nil(t, To([]byte("12"), &i))
+	assert.Equal(t, 12, i)
+	assert.Nil(t, To([]byte("ab"), &s))
+	assert.Equal(t, "ab", s)
+
+	assert.Nil(t, To(5, &b))
+	assert.Equal(t, []byte("5"), b)
+	assert.Nil(t, To("xyz", &b))
+	assert.Equal(t, []byte("xyz"), b)
+
+	// Copies are new slices
+	src := []byte("abc")
+	assert.Nil(t, To(src, &b))
+	src[0] = 'z'
+	assert.Equal(t, []byte("abc"), b)
+
+	assert.Nil(t, To([]byte(nil), &b))
+	assert.Nil(t, b)
+
+	assert.Equal(t, fmt.Errorf(errMsg, "x", "x", "int64"), To([]byte("x"), &i))
+}
+
+func TestReflectToText_(t *testing.T) {
+	var (
+		s    string
+		i    int
+		id   textID
+		code textCode
+		b    []byte
+	)
+
+	// Marshaler source
+	assert.Nil(t, ReflectTo(goreflect.ValueOf(textID{5}), goreflect.ValueOf(&s)))
+	assert.Equal(t, "id-5", s)
+	assert.Nil(t, ReflectTo(goreflect.ValueOf(textID{5}), goreflect.ValueOf(&b)))
+	assert.Equal(t, []byte("id-5"), b)
+	assert.Equal(t, fmt.Errorf("negative id"), ReflectTo(goreflect.ValueOf(textID{-1}), goreflect.ValueOf(&s)))
+	assert.Equal(t, fmt.Errorf(errMsg, "id-5", "id-5", "int64"), ReflectTo(goreflect.ValueOf(textID{5}), goreflect.ValueOf(&i)))
+
+	// A marshaler that is also a []byte uses its text
+	assert.Nil(t, ReflectTo(goreflect.ValueOf(net.ParseIP("1.2.3.4")), goreflect.ValueOf(&s)))
+	assert.Equal(t, "1.2.3.4", s)
+
+	// []byte source
+	assert.Nil(t, ReflectTo(goreflect.ValueOf([]byte("7")), goreflect.ValueOf(&i)))
+	assert.Equal(t, 7, i)
+
+	// Unmarshaler target
+	assert.Nil(t, ReflectTo(goreflect.ValueOf("id-8"), goreflect.ValueOf(&id)))
+	assert.Equal(t, textID{8}, id)
+	assert.Nil(t, ReflectTo(goreflect.ValueOf([]byte("id-9")), goreflect.ValueOf(&id)))
+	assert.Equal(t, textID{9}, id)
+	assert.Equal(t, fmt.Errorf("invalid id 7"), ReflectTo(goreflect.ValueOf(7), goreflect.ValueOf(&id)))
+
+	// Numbers are converted to a string first, and a string subtype target is unmarshaled rather than copied
+	assert.Nil(t, ReflectTo(goreflect.ValueOf(uint8(3)), goreflect.ValueOf(&code)))
+	assert.Equal(t, textCode("3"), code)
+	assert.Nil(t, ReflectTo(goreflect.ValueOf(textID{1}), goreflect.ValueOf(&code)))
+	assert.Equal(t, textCode("ID-1"), code)
+
+	// []byte target
+	assert.Nil(t, ReflectTo(goreflect.ValueOf(1.5), goreflect.ValueOf(&b)))
+	assert.Equal(t, []byte("1.5"), b)
+
+	// No conversion
+	type noText struct{}
+	assert.Equal(t, fmt.Errorf(errReflectToLookupMsg, goreflect.TypeOf(noText{}), goreflect.TypeOf("")), ReflectTo(goreflect.ValueOf(noText{}), goreflect.ValueOf(&s)))
+	assert.Equal(t, fmt.Errorf(errReflectToLookupMsg, goreflect.TypeOf(noText{}), goreflect.TypeOf(id)), ReflectTo(goreflect.ValueOf(noText{}), goreflect.ValueOf(&id)))
+	assert.Equal(t, fmt.Errorf(errReflectToLookupMsg, goreflect.TypeOf(noText{}), goreflect.TypeOf(b)), ReflectTo(goreflect.ValueOf(noText{}), goreflect.ValueOf(&b)))
+
+	// AnyTo
+	assert.Nil(t, AnyTo(textID{6}, &s))
+	assert.Equal(t, "id-6", s)
+	assert.Equal(t, fmt.Errorf("negative id"), AnyTo(textID{-1}, &s))
+}
@@ -0,0 +1,56 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"github.com/bantling/micro/constraint"
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/union"
+)
+
+// TryTo is a version of To that returns the converted value, rather than writing it through a pointer, so that it can
+// be used in expressions and functional pipelines. Eg, stream.MapError(conv.TryTo[string, int]).
+//
+// Returns the zero value and an error if the value cannot be converted.
+func TryTo[I, O constraint.Numeric | string | []byte](i I) (O, error) {
+	var o O
+	if err := To(i, &o); err != nil {
+		var zv O
+		return zv, err
+	}
+
+	return o, nil
+}
+
+// MustTryTo is a Must version of TryTo
+func MustTryTo[I, O constraint.Numeric | string | []byte](i I) O {
+	return funcs.MustValue(TryTo[I, O](i))
+}
+
+// ToResult is a version of TryTo that returns a union.Result of the converted value or the error
+func ToResult[I, O constraint.Numeric | string | []byte](i I) union.Result[O] {
+	return union.OfResultError(TryTo[I, O](i))
+}
+
+// AnyTryTo is a version of AnyTo that returns the converted value, rather than writing it through a pointer.
+//
+// Returns the zero value and an error if the value cannot be converted.
+func AnyTryTo[O constraint.Numeric | string](i any) (O, error) {
+	var o O
+	if err := AnyTo(i, &o); err != nil {
+		var zv O
+		return zv, err
+	}
+
+	return o, nil
+}
+
+// MustAnyTryTo is a Must version of AnyTryTo
+func MustAnyTryTo[O constraint.Numeric | string](i any) O {
+	return funcs.MustValue(AnyTryTo[O](i))
+}
+
+// AnyToResult is a version of AnyTryTo that returns a union.Result of the converted value or the error
+func AnyToResult[O constraint.Numeric | string](i any) union.Result[O] {
+	return union.OfResultError(AnyTryTo[O](i))
+}
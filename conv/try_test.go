@@ -0,0 +1,61 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/tuple"
+	"github.com/bantling/micro/union"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTryTo_(t *testing.T) {
+	assert.Equal(t, tuple.Of2(5, error(nil)), tuple.Of2(TryTo[string, int]("5")))
+	assert.Equal(t, tuple.Of2("1.5", error(nil)), tuple.Of2(TryTo[float64, string](1.5)))
+	assert.Equal(t, tuple.Of2(big.NewInt(7), error(nil)), tuple.Of2(TryTo[int, *big.Int](7)))
+	assert.Equal(t, tuple.Of2(int8(0), fmt.Errorf(errMsg, 128, "128", "int8")), tuple.Of2(TryTo[int, int8](128)))
+	assert.Equal(t, tuple.Of2(0, fmt.Errorf("The string value of a cannot be converted to int64")), tuple.Of2(TryTo[string, int]("a")))
+
+	// Usable in expressions
+	assert.Equal(t, 6, MustTryTo[string, int]("2")*3)
+
+	funcs.TryTo(
+		func() {
+			MustTryTo[int, uint](-1)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errMsg, -1, "-1", "uint"), e)
+		},
+	)
+}
+
+func TestToResult_(t *testing.T) {
+	assert.Equal(t, union.OfResult(uint8(3)), ToResult[string, uint8]("3"))
+	assert.Equal(t, union.OfError[uint8](fmt.Errorf(errMsg, 256, "256", "uint8")), ToResult[int, uint8](256))
+}
+
+func TestAnyTryTo_(t *testing.T) {
+	assert.Equal(t, tuple.Of2(int64(5), error(nil)), tuple.Of2(AnyTryTo[int64](uint8(5))))
+	assert.Equal(t, tuple.Of2("true", error(nil)), tuple.Of2(AnyTryTo[string](true)))
+	assert.Equal(t, tuple.Of2(0, fmt.Errorf(errAnyToInvalidIMsg, "nil")), tuple.Of2(AnyTryTo[int](nil)))
+
+	assert.Equal(t, 2.5, MustAnyTryTo[float64]("2.5"))
+
+	funcs.TryTo(
+		func() {
+			MustAnyTryTo[int](struct{}{})
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errAnyToInvalidIMsg, "struct {}"), e)
+		},
+	)
+
+	assert.Equal(t, union.OfResult(3), AnyToResult[int]("3"))
+	assert.Equal(t, union.OfError[int](fmt.Errorf(errAnyToInvalidIMsg, "nil")), AnyToResult[int](nil))
+}
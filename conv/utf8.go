@@ -0,0 +1,229 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/bantling/micro/funcs"
+)
+
+var (
+	errInvalidUTF8Msg = "The %s value is not valid UTF-8: the byte at offset %d is invalid"
+	errInvalidRuneMsg = "The []rune value is not valid UTF-8: the rune %U at index %d is not a valid code point"
+)
+
+// UTF8Policy is how conversions between string, []byte, and []rune handle invalid UTF-8
+type UTF8Policy uint
+
+const (
+	UTF8Strict  UTF8Policy = iota // UTF8Strict returns an error for invalid UTF-8
+	UTF8Replace                   // UTF8Replace replaces each invalid byte or rune with utf8.RuneError (U+FFFD)
+)
+
+// Register conversions between []rune and string or []byte, so they are available to ReflectTo.
+// The registered conversions use the UTF8Replace policy, like the Go conversions between these types, and as string and
+// []byte are converted on every other path: a []byte may be a binary payload rather than text. Use the conversion
+// functions below with UTF8Strict to reject invalid UTF-8.
+func init() {
+	convertFromTo["string[]int32"] = func(t any, u any) error {
+		return StringToRunes(t.(string), u.(*[]rune), UTF8Replace)
+	}
+	convertFromTo["[]int32string"] = func(t any, u any) error {
+		return RunesToString(t.([]rune), u.(*string), UTF8Replace)
+	}
+	convertFromTo["[]uint8[]int32"] = func(t any, u any) error {
+		return BytesToRunes(t.([]byte), u.(*[]rune), UTF8Replace)
+	}
+	convertFromTo["[]int32[]uint8"] = func(t any, u any) error {
+		return RunesToBytes(t.([]rune), u.(*[]byte), UTF8Replace)
+	}
+}
+
+// ==== Validation
+
+// InvalidUTF8Offsets returns the byte offset of every invalid byte in a string, which is empty if the string is valid
+func InvalidUTF8Offsets(ival string) []int {
+	var offsets []int
+
+	for i := 0; i < len(ival); {
+		// An invalid byte decodes as utf8.RuneError of size 1, a valid U+FFFD has size 3
+		r, size := utf8.DecodeRuneInString(ival[i:])
+		if (r == utf8.RuneError) && (size == 1) {
+			offsets = append(offsets, i)
+		}
+
+		i += size
+	}
+
+	return offsets
+}
+
+// ValidateUTF8 returns an error containing the offset of the first invalid byte, if the string is not valid UTF-8
+func ValidateUTF8(ival string) error {
+	return validateUTF8(ival, "string")
+}
+
+// validateUTF8 validates ival, describing it as the given type in the error
+func validateUTF8(ival string, typ string) error {
+	if utf8.ValidString(ival) {
+		return nil
+	}
+
+	return fmt.Errorf(errInvalidUTF8Msg, typ, InvalidUTF8Offsets(ival)[0])
+}
+
+// RepairUTF8 returns a copy of a string with each invalid byte replaced by utf8.RuneError.
+// Unlike strings.ToValidUTF8, each invalid byte is replaced, rather than each run of invalid bytes, so the result has
+// the same number of runes as ranging over the string.
+func RepairUTF8(ival string) string {
+	if utf8.ValidString(ival) {
+		return ival
+	}
+
+	return string([]rune(ival))
+}
+
+// RuneCount converts a string to the number of code points it contains, for length checks that count characters rather
+// than bytes. The optional policy defaults to UTF8Strict, and UTF8Replace counts each invalid byte as one code point.
+func RuneCount(ival string, oval *int, policy ...UTF8Policy) error {
+	if funcs.SliceIndex(policy, 0, UTF8Strict) == UTF8Strict {
+		if err := validateUTF8(ival, "string"); err != nil {
+			return err
+		}
+	}
+
+	*oval = utf8.RuneCountInString(ival)
+	return nil
+}
+
+// MustRuneCount is a Must version of RuneCount
+func MustRuneCount(ival string, oval *int, policy ...UTF8Policy) {
+	funcs.Must(RuneCount(ival, oval, policy...))
+}
+
+// ==== string <-> []byte
+
+// StringToBytes converts a string to a []byte.
+// The optional policy defaults to UTF8Strict, which returns an error if the string is not valid UTF-8.
+func StringToBytes(ival string, oval *[]byte, policy ...UTF8Policy) error {
+	if funcs.SliceIndex(policy, 0, UTF8Strict) == UTF8Strict {
+		if err := validateUTF8(ival, "string"); err != nil {
+			return err
+		}
+	} else {
+		ival = RepairUTF8(ival)
+	}
+
+	*oval = []byte(ival)
+	return nil
+}
+
+// MustStringToBytes is a Must version of StringToBytes
+func MustStringToBytes(ival string, oval *[]byte, policy ...UTF8Policy) {
+	funcs.Must(StringToBytes(ival, oval, policy...))
+}
+
+// BytesToString converts a []byte to a string.
+// The optional policy defaults to UTF8Strict, which returns an error if the bytes are not valid UTF-8.
+func BytesToString(ival []byte, oval *string, policy ...UTF8Policy) error {
+	str := string(ival)
+
+	if funcs.SliceIndex(policy, 0, UTF8Strict) == UTF8Strict {
+		if err := validateUTF8(str, "[]byte"); err != nil {
+			return err
+		}
+	} else {
+		str = RepairUTF8(str)
+	}
+
+	*oval = str
+	return nil
+}
+
+// MustBytesToString is a Must version of BytesToString
+func MustBytesToString(ival []byte, oval *string, policy ...UTF8Policy) {
+	funcs.Must(BytesToString(ival, oval, policy...))
+}
+
+// ==== string <-> []rune
+
+// StringToRunes converts a string to a []rune.
+// The optional policy defaults to UTF8Strict, which returns an error if the string is not valid UTF-8.
+func StringToRunes(ival string, oval *[]rune, policy ...UTF8Policy) error {
+	if funcs.SliceIndex(policy, 0, UTF8Strict) == UTF8Strict {
+		if err := validateUTF8(ival, "string"); err != nil {
+			return err
+		}
+	}
+
+	// Converting to []rune already replaces each invalid byte with utf8.RuneError
+	*oval = []rune(ival)
+	return nil
+}
+
+// MustStringToRunes is a Must version of StringToRunes
+func MustStringToRunes(ival string, oval *[]rune, policy ...UTF8Policy) {
+	funcs.Must(StringToRunes(ival, oval, policy...))
+}
+
+// RunesToString converts a []rune to a string.
+// The optional policy defaults to UTF8Strict, which returns an error if any rune is a surrogate or out of range.
+func RunesToString(ival []rune, oval *string, policy ...UTF8Policy) error {
+	if funcs.SliceIndex(policy, 0, UTF8Strict) == UTF8Strict {
+		for i, r := range ival {
+			if !utf8.ValidRune(r) {
+				return fmt.Errorf(errInvalidRuneMsg, r, i)
+			}
+		}
+	}
+
+	// Converting to string already replaces each invalid rune with utf8.RuneError
+	*oval = string(ival)
+	return nil
+}
+
+// MustRunesToString is a Must version of RunesToString
+func MustRunesToString(ival []rune, oval *string, policy ...UTF8Policy) {
+	funcs.Must(RunesToString(ival, oval, policy...))
+}
+
+// ==== []byte <-> []rune
+
+// BytesToRunes converts a []byte to a []rune.
+// The optional policy defaults to UTF8Strict, which returns an error if the bytes are not valid UTF-8.
+func BytesToRunes(ival []byte, oval *[]rune, policy ...UTF8Policy) error {
+	str := string(ival)
+
+	if funcs.SliceIndex(policy, 0, UTF8Strict) == UTF8Strict {
+		if err := validateUTF8(str, "[]byte"); err != nil {
+			return err
+		}
+	}
+
+	*oval = []rune(str)
+	return nil
+}
+
+// MustBytesToRunes is a Must version of BytesToRunes
+func MustBytesToRunes(ival []byte, oval *[]rune, policy ...UTF8Policy) {
+	funcs.Must(BytesToRunes(ival, oval, policy...))
+}
+
+// RunesToBytes converts a []rune to a []byte.
+// The optional policy defaults to UTF8Strict, which returns an error if any rune is a surrogate or out of range.
+func RunesToBytes(ival []rune, oval *[]byte, policy ...UTF8Policy) error {
+	var str string
+	if err := RunesToString(ival, &str, policy...); err != nil {
+		return err
+	}
+
+	*oval = []byte(str)
+	return nil
+}
+
+// MustRunesToBytes is a Must version of RunesToBytes
+func MustRunesToBytes(ival []rune, oval *[]byte, policy ...UTF8Policy) {
+	funcs.Must(RunesToBytes(ival, oval, policy...))
+}
@@ -0,0 +1,221 @@
+package conv
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	goreflect "reflect"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUTF8Validation_(t *testing.T) {
+	// A valid U+FFFD is not an invalid byte
+	assert.Nil(t, InvalidUTF8Offsets(""))
+	assert.Nil(t, InvalidUTF8Offsets("a�é"))
+	assert.Equal(t, []int{1, 4, 5}, InvalidUTF8Offsets("a\xFFé\xE2\x82"))
+
+	assert.Nil(t, ValidateUTF8("日本"))
+	assert.Equal(t, fmt.Errorf(errInvalidUTF8Msg, "string", 6), ValidateUTF8("日本\x80"))
+
+	// Each invalid byte is replaced
+	assert.Equal(t, "abc", RepairUTF8("abc"))
+	assert.Equal(t, "a�é��", RepairUTF8("a\xFFé\xE2\x82"))
+
+	// Length in code points
+	var n int
+	assert.Nil(t, RuneCount("日本語", &n))
+	assert.Equal(t, 3, n)
+
+	assert.Nil(t, RuneCount("a\xE2\x82", &n, UTF8Replace))
+	assert.Equal(t, 3, n)
+
+	n = 0
+	assert.Equal(t, fmt.Errorf(errInvalidUTF8Msg, "string", 1), RuneCount("a\xE2\x82", &n))
+	assert.Equal(t, 0, n)
+
+	MustRuneCount("ab", &n)
+	assert.Equal(t, 2, n)
+
+	funcs.TryTo(
+		func() {
+			MustRuneCount("\xFF", &n)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errInvalidUTF8Msg, "string", 0), e)
+		},
+	)
+}
+
+func TestStringBytes_(t *testing.T) {
+	var b []byte
+	assert.Nil(t, StringToBytes("é", &b))
+	assert.Equal(t, []byte{0xC3, 0xA9}, b)
+
+	assert.Nil(t, StringToBytes("a\xFF", &b, UTF8Replace))
+	assert.Equal(t, []byte("a�"), b)
+
+	b = nil
+	assert.Equal(t, fmt.Errorf(errInvalidUTF8Msg, "string", 1), StringToBytes("a\xFF", &b))
+	assert.Nil(t, b)
+
+	MustStringToBytes("a", &b)
+	assert.Equal(t, []byte("a"), b)
+
+	var s string
+	assert.Nil(t, BytesToString([]byte("é"), &s))
+	assert.Equal(t, "é", s)
+
+	assert.Nil(t, BytesToString([]byte{'a', 0xC3}, &s, UTF8Replace))
+	assert.Equal(t, "a�", s)
+
+	s = ""
+	assert.Equal(t, fmt.Errorf(errInvalidUTF8Msg, "[]byte", 1), BytesToString([]byte{'a', 0xC3}, &s))
+	assert.Equal(t, "", s)
+
+	MustBytesToString([]byte("b"), &s)
+	assert.Equal(t, "b", s)
+
+	funcs.TryTo(
+		func() {
+			MustBytesToString([]byte{0xFF}, &s)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errInvalidUTF8Msg, "[]byte", 0), e)
+		},
+	)
+}
+
+func TestStringRunes_(t *testing.T) {
+	var r []rune
+	assert.Nil(t, StringToRunes("日本", &r))
+	assert.Equal(t, []rune{'日', '本'}, r)
+
+	assert.Nil(t, StringToRunes("日\xFF", &r, UTF8Replace))
+	assert.Equal(t, []rune{'日', '�'}, r)
+
+	assert.Equal(t, fmt.Errorf(errInvalidUTF8Msg, "string", 3), StringToRunes("日\xFF", &r))
+
+	MustStringToRunes("a", &r)
+	assert.Equal(t, []rune{'a'}, r)
+
+	var s string
+	assert.Nil(t, RunesToString([]rune{'日', '本'}, &s))
+	assert.Equal(t, "日本", s)
+
+	// Surrogates and out of range runes are invalid
+	assert.Nil(t, RunesToString([]rune{'a', 0xD800, 0x110000}, &s, UTF8Replace))
+	assert.Equal(t, "a��", s)
+
+	assert.Equal(t, fmt.Errorf(errInvalidRuneMsg, rune(0xD800), 1), RunesToString([]rune{'a', 0xD800}, &s))
+	assert.Equal(t, fmt.Errorf(errInvalidRuneMsg, rune(-1), 0), RunesToString([]rune{-1}, &s))
+
+	MustRunesToString([]rune{'b'}, &s)
+	assert.Equal(t, "b", s)
+
+	funcs.TryTo(
+		func() {
+			MustRunesToString([]rune{0x110000}, &s)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errInvalidRuneMsg, rune(0x110000), 0), e)
+		},
+	)
+}
+
+func TestBytesRunes_(t *testing.T) {
+	var r []rune
+	assert.Nil(t, BytesToRunes([]byte("é"), &r))
+	assert.Equal(t, []rune{'é'}, r)
+
+	assert.Nil(t, BytesToRunes([]byte{0xFF, 'a'}, &r, UTF8Replace))
+	assert.Equal(t, []rune{'�', 'a'}, r)
+
+	assert.Equal(t, fmt.Errorf(errInvalidUTF8Msg, "[]byte", 0), BytesToRunes([]byte{0xFF}, &r))
+
+	MustBytesToRunes([]byte("a"), &r)
+	assert.Equal(t, []rune{'a'}, r)
+
+	var b []byte
+	assert.Nil(t, RunesToBytes([]rune{'é'}, &b))
+	assert.Equal(t, []byte{0xC3, 0xA9}, b)
+
+	assert.Nil(t, RunesToBytes([]rune{0xDFFF}, &b, UTF8Replace))
+	assert.Equal(t, []byte("�"), b)
+
+	b = nil
+	assert.Equal(t, fmt.Errorf(errInvalidRuneMsg, rune(0xDFFF), 0), RunesToBytes([]rune{0xDFFF}, &b))
+	assert.Nil(t, b)
+
+	MustRunesToBytes([]rune{'b'}, &b)
+	assert.Equal(t, []byte("b"), b)
+
+	funcs.TryTo(
+		func() {
+			MustBytesToRunes([]byte{0x80}, &r)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errInvalidUTF8Msg, "[]byte", 0), e)
+		},
+	)
+}
+
+func TestUTF8ReflectTo_(t *testing.T) {
+	var (
+		s string
+		b []byte
+		r []rune
+	)
+
+	assert.Nil(t, ReflectTo(goreflect.ValueOf("é"), goreflect.ValueOf(&b)))
+	assert.Equal(t, []byte("é"), b)
+
+	assert.Nil(t, ReflectTo(goreflect.ValueOf(b), goreflect.ValueOf(&r)))
+	assert.Equal(t, []rune{'é'}, r)
+
+	assert.Nil(t, ReflectTo(goreflect.ValueOf(r), goreflect.ValueOf(&b)))
+	assert.Equal(t, []byte("é"), b)
+
+	assert.Nil(t, ReflectTo(goreflect.ValueOf(r), goreflect.ValueOf(&s)))
+	assert.Equal(t, "é", s)
+
+	assert.Nil(t, ReflectTo(goreflect.ValueOf("ab"), goreflect.ValueOf(&r)))
+	assert.Equal(t, []rune{'a', 'b'}, r)
+
+	assert.Nil(t, ReflectTo(goreflect.ValueOf([]byte("cd")), goreflect.ValueOf(&s)))
+	assert.Equal(t, "cd", s)
+
+	// Registered conversions do not reject invalid UTF-8: bytes are copied as is, and invalid runes are replaced
+	assert.Nil(t, ReflectTo(goreflect.ValueOf("\xFF"), goreflect.ValueOf(&b)))
+	assert.Equal(t, []byte{0xFF}, b)
+
+	assert.Nil(t, ReflectTo(goreflect.ValueOf([]byte{0xFF}), goreflect.ValueOf(&s)))
+	assert.Equal(t, "\xFF", s)
+
+	assert.Nil(t, To([]byte{0xFF}, &s))
+	assert.Equal(t, "\xFF", s)
+
+	assert.Equal(t, "\xFE", MustTryTo[[]byte, string]([]byte{0xFE}))
+
+	s = ""
+	assert.Nil(t, AnyTo([]byte{0xFD}, &s))
+	assert.Equal(t, "\xFD", s)
+
+	assert.Nil(t, ReflectTo(goreflect.ValueOf([]byte{'a', 0xFF}), goreflect.ValueOf(&r)))
+	assert.Equal(t, []rune{'a', utf8.RuneError}, r)
+
+	assert.Nil(t, ReflectTo(goreflect.ValueOf([]rune{-1}), goreflect.ValueOf(&s)))
+	assert.Equal(t, "\uFFFD", s)
+
+	// A named []byte type is the same as []byte
+	type payload []byte
+	assert.Nil(t, ReflectTo(goreflect.ValueOf(payload{0xFF}), goreflect.ValueOf(&s)))
+	assert.Equal(t, "\xFF", s)
+}
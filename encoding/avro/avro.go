@@ -0,0 +1,527 @@
+package avro
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	gojson "encoding/json"
+	"fmt"
+	"io"
+	gomath "math"
+	"math/big"
+	goreflect "reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/iter"
+	"github.com/bantling/micro/math"
+)
+
+// Error constants
+var (
+	errUnsupportedTypeMsg  = "The type %s cannot be written as Avro"
+	errUnnamedRecordMsg    = "The struct type %s cannot be written as an Avro record, as it has no name"
+	errDuplicateNameMsg    = "The Avro record name %s is used by both %s and %s"
+	errInvalidTagMsg       = "The avro tag %q of field %s.%s is not valid"
+	errUintOverflowMsg     = "The value %d of type %s is too large for an Avro long"
+	errDecimalScaleMsg     = "The decimal value %s has more than %d decimal places"
+	errDecimalPrecisionMsg = "The decimal value %s has more than %d digits when scaled to %d decimal places"
+)
+
+const (
+	// defaultBlockSize is the default number of values written in each block of a file
+	defaultBlockSize = 1000
+
+	// defaultDecimalPrecision is the default precision of a Decimal, which is the maximum number of digits it can have
+	defaultDecimalPrecision = 18
+
+	// daySeconds is the number of seconds in a day
+	daySeconds = 24 * 60 * 60
+)
+
+var (
+	// magic is the first four bytes of an object container file
+	magic = []byte{'O', 'b', 'j', 1}
+
+	decimalType = goreflect.TypeOf(math.Decimal{})
+	timeType    = goreflect.TypeOf(time.Time{})
+
+	// newSync generates the sync marker of a file, it is a var so that tests can write predictable files
+	newSync = func() (sync [16]byte, err error) {
+		_, err = io.ReadFull(rand.Reader, sync[:])
+		return
+	}
+)
+
+// encoder appends the Avro binary encoding of a value to a buffer
+type encoder func(buf *bytes.Buffer, val goreflect.Value) error
+
+// fieldOpts are the options of a struct field given in an avro tag
+type fieldOpts struct {
+	name      string
+	skip      bool
+	date      bool
+	scale     uint
+	precision uint
+}
+
+// parseTag parses an avro tag of the form `avro:"name,date,scale=2,precision=10"`, where every part is optional.
+// The name - skips the field.
+func parseTag(typ goreflect.Type, fld goreflect.StructField) (opts fieldOpts, err error) {
+	opts.name, opts.precision = fld.Name, defaultDecimalPrecision
+
+	tag, haveIt := fld.Tag.Lookup("avro")
+	if !haveIt {
+		return
+	}
+
+	parts := strings.Split(tag, ",")
+	if parts[0] == "-" {
+		opts.skip = true
+		return
+	}
+
+	if parts[0] != "" {
+		opts.name = parts[0]
+	}
+
+	for _, part := range parts[1:] {
+		var (
+			kv  = strings.SplitN(part, "=", 2)
+			n   uint64
+			nok error
+		)
+
+		if len(kv) == 2 {
+			n, nok = strconv.ParseUint(kv[1], 10, 32)
+		}
+
+		switch {
+		case (len(kv) == 1) && (kv[0] == "date"):
+			opts.date = true
+		case (len(kv) == 2) && (kv[0] == "scale") && (nok == nil):
+			opts.scale = uint(n)
+		case (len(kv) == 2) && (kv[0] == "precision") && (nok == nil) && (n > 0):
+			opts.precision = uint(n)
+		default:
+			return opts, fmt.Errorf(errInvalidTagMsg, tag, typ, fld.Name)
+		}
+	}
+
+	if opts.scale > opts.precision {
+		return opts, fmt.Errorf(errInvalidTagMsg, tag, typ, fld.Name)
+	}
+
+	return
+}
+
+// compiler derives an Avro schema and an encoder from a type.
+// Each record type is fully described the first time it occurs, and referred to by name after that, which allows for
+// recursive types.
+type compiler struct {
+	names   map[string]goreflect.Type
+	records map[goreflect.Type]encoder
+}
+
+// compile returns the schema and encoder of a type, using the options of the field that has the type, if any
+func (c *compiler) compile(typ goreflect.Type, opts fieldOpts) (any, encoder, error) {
+	switch typ {
+	case decimalType:
+		return map[string]any{"type": "bytes", "logicalType": "decimal", "precision": opts.precision, "scale": opts.scale},
+			func(buf *bytes.Buffer, val goreflect.Value) error {
+				return writeDecimal(buf, val.Interface().(math.Decimal), opts.scale, opts.precision)
+			},
+			nil
+
+	case timeType:
+		if opts.date {
+			return map[string]any{"type": "int", "logicalType": "date"},
+				func(buf *bytes.Buffer, val goreflect.Value) error {
+					// The date in the location of the time, which is midnight UTC of the same date, an exact number of days
+					y, m, d := val.Interface().(time.Time).Date()
+					writeLong(buf, time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix()/daySeconds)
+					return nil
+				},
+				nil
+		}
+
+		return map[string]any{"type": "long", "logicalType": "timestamp-millis"},
+			func(buf *bytes.Buffer, val goreflect.Value) error {
+				writeLong(buf, val.Interface().(time.Time).UnixMilli())
+				return nil
+			},
+			nil
+	}
+
+	switch typ.Kind() {
+	case goreflect.Bool:
+		return "boolean", func(buf *bytes.Buffer, val goreflect.Value) error {
+			buf.WriteByte(funcs.Ternary[byte](val.Bool(), 1, 0))
+			return nil
+		}, nil
+
+	case goreflect.Int8, goreflect.Int16, goreflect.Int32, goreflect.Int, goreflect.Int64:
+		return funcs.Ternary(typ.Size() < 8, "int", "long"), func(buf *bytes.Buffer, val goreflect.Value) error {
+			writeLong(buf, val.Int())
+			return nil
+		}, nil
+
+	case goreflect.Uint8, goreflect.Uint16, goreflect.Uint32, goreflect.Uint, goreflect.Uint64:
+		return funcs.Ternary(typ.Size() < 4, "int", "long"), func(buf *bytes.Buffer, val goreflect.Value) error {
+			u := val.Uint()
+			if u > gomath.MaxInt64 {
+				return fmt.Errorf(errUintOverflowMsg, u, typ)
+			}
+
+			writeLong(buf, int64(u))
+			return nil
+		}, nil
+
+	case goreflect.Float32:
+		return "float", func(buf *bytes.Buffer, val goreflect.Value) error {
+			var b [4]byte
+			binary.LittleEndian.PutUint32(b[:], gomath.Float32bits(float32(val.Float())))
+			buf.Write(b[:])
+			return nil
+		}, nil
+
+	case goreflect.Float64:
+		return "double", func(buf *bytes.Buffer, val goreflect.Value) error {
+			var b [8]byte
+			binary.LittleEndian.PutUint64(b[:], gomath.Float64bits(val.Float()))
+			buf.Write(b[:])
+			return nil
+		}, nil
+
+	case goreflect.String:
+		return "string", func(buf *bytes.Buffer, val goreflect.Value) error {
+			writeBytes(buf, []byte(val.String()))
+			return nil
+		}, nil
+
+	case goreflect.Slice, goreflect.Array:
+		if (typ.Kind() == goreflect.Slice) && (typ.Elem().Kind() == goreflect.Uint8) {
+			return "bytes", func(buf *bytes.Buffer, val goreflect.Value) error {
+				writeBytes(buf, val.Bytes())
+				return nil
+			}, nil
+		}
+
+		schema, enc, err := c.compile(typ.Elem(), opts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return map[string]any{"type": "array", "items": schema}, func(buf *bytes.Buffer, val goreflect.Value) error {
+			// All elements are written as one block, followed by an empty block
+			if n := val.Len(); n > 0 {
+				writeLong(buf, int64(n))
+				for i := 0; i < n; i++ {
+					if err := enc(buf, val.Index(i)); err != nil {
+						return err
+					}
+				}
+			}
+
+			writeLong(buf, 0)
+			return nil
+		}, nil
+
+	case goreflect.Map:
+		if typ.Key().Kind() != goreflect.String {
+			break
+		}
+
+		schema, enc, err := c.compile(typ.Elem(), opts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return map[string]any{"type": "map", "values": schema}, func(buf *bytes.Buffer, val goreflect.Value) error {
+			if n := val.Len(); n > 0 {
+				writeLong(buf, int64(n))
+				for mi := val.MapRange(); mi.Next(); {
+					writeBytes(buf, []byte(mi.Key().String()))
+					if err := enc(buf, mi.Value()); err != nil {
+						return err
+					}
+				}
+			}
+
+			writeLong(buf, 0)
+			return nil
+		}, nil
+
+	case goreflect.Pointer:
+		schema, enc, err := c.compile(typ.Elem(), opts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// A pointer is a union of null and the type pointed to, where null is branch 0
+		return []any{"null", schema}, func(buf *bytes.Buffer, val goreflect.Value) error {
+			if val.IsNil() {
+				writeLong(buf, 0)
+				return nil
+			}
+
+			writeLong(buf, 1)
+			return enc(buf, val.Elem())
+		}, nil
+
+	case goreflect.Struct:
+		return c.compileRecord(typ)
+	}
+
+	return nil, nil, fmt.Errorf(errUnsupportedTypeMsg, typ)
+}
+
+// compileRecord returns the schema and encoder of a struct, which is a record of the exported fields
+func (c *compiler) compileRecord(typ goreflect.Type) (any, encoder, error) {
+	name := typ.Name()
+	if name == "" {
+		return nil, nil, fmt.Errorf(errUnnamedRecordMsg, typ)
+	}
+
+	type field struct {
+		index int
+		enc   encoder
+	}
+
+	var fields []field
+
+	// The encoder refers to the fields by closure, so a recursive reference can be made before the fields are compiled
+	enc := func(buf *bytes.Buffer, val goreflect.Value) error {
+		for _, f := range fields {
+			if err := f.enc(buf, val.Field(f.index)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if other, haveIt := c.names[name]; haveIt {
+		if other != typ {
+			return nil, nil, fmt.Errorf(errDuplicateNameMsg, name, other, typ)
+		}
+
+		// Refer to the schema by name
+		return name, c.records[typ], nil
+	}
+
+	c.names[name], c.records[typ] = typ, enc
+
+	var schemaFields []any
+	for i, n := 0, typ.NumField(); i < n; i++ {
+		fld := typ.Field(i)
+		if !fld.IsExported() {
+			continue
+		}
+
+		opts, err := parseTag(typ, fld)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if opts.skip {
+			continue
+		}
+
+		schema, fenc, err := c.compile(fld.Type, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		fields = append(fields, field{i, fenc})
+		schemaFields = append(schemaFields, map[string]any{"name": opts.name, "type": schema})
+	}
+
+	return map[string]any{"type": "record", "name": name, "fields": funcs.Ternary(schemaFields == nil, []any{}, schemaFields)}, enc, nil
+}
+
+// compileType returns the schema as JSON and the encoder of T
+func compileType[T any]() (string, encoder, error) {
+	var (
+		typ              = goreflect.TypeOf((*T)(nil)).Elem()
+		c                = &compiler{names: map[string]goreflect.Type{}, records: map[goreflect.Type]encoder{}}
+		schema, enc, err = c.compile(typ, fieldOpts{precision: defaultDecimalPrecision})
+	)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	str, err := gojson.Marshal(schema)
+	return string(str), enc, err
+}
+
+// writeLong appends a zig zag varint
+func writeLong(buf *bytes.Buffer, n int64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutUvarint(b[:], uint64((n<<1)^(n>>63)))])
+}
+
+// writeBytes appends a length followed by the bytes
+func writeBytes(buf *bytes.Buffer, b []byte) {
+	writeLong(buf, int64(len(b)))
+	buf.Write(b)
+}
+
+// writeDecimal appends the unscaled value of a decimal at the given scale as big endian two's complement bytes
+func writeDecimal(buf *bytes.Buffer, d math.Decimal, scale, precision uint) error {
+	if d.Scale() > scale {
+		return fmt.Errorf(errDecimalScaleMsg, d, scale)
+	}
+
+	// The digits of the string are the unscaled value at the scale of the decimal, add trailing zeros for the given scale
+	digits := strings.TrimLeft(strings.Replace(strings.TrimPrefix(d.String(), "-"), ".", "", 1), "0")
+	if digits != "" {
+		digits += strings.Repeat("0", int(scale-d.Scale()))
+	}
+
+	if uint(len(digits)) > precision {
+		return fmt.Errorf(errDecimalPrecisionMsg, d, precision, scale)
+	}
+
+	var (
+		val, _ = new(big.Int).SetString("0"+digits, 10)
+		b      []byte
+	)
+
+	if d.Sign() >= 0 {
+		// Add a leading zero byte if the high bit is set, so it is not negative
+		if b = val.Bytes(); (len(b) == 0) || (b[0]&0x80 != 0) {
+			b = append([]byte{0}, b...)
+		}
+	} else {
+		// The two's complement of -n is the inverse of n - 1, add a leading 0xFF byte if the high bit is not set
+		b = val.Sub(val, big.NewInt(1)).Bytes()
+		for i := range b {
+			b[i] = ^b[i]
+		}
+
+		if (len(b) == 0) || (b[0]&0x80 == 0) {
+			b = append([]byte{0xFF}, b...)
+		}
+	}
+
+	writeBytes(buf, b)
+	return nil
+}
+
+// Schema returns the Avro schema of T as JSON:
+//   - bool is boolean
+//   - int8, int16, int32, uint8 and uint16 are int
+//   - int, int64, uint, uint32 and uint64 are long, where unsigned values that are too large are an error
+//   - float32 is float, and float64 is double
+//   - string is string, and []byte is bytes
+//   - math.Decimal is bytes with logical type decimal, with a default precision of 18 and scale of 0
+//   - time.Time is long with logical type timestamp-millis, or int with logical type date
+//   - slices and arrays are arrays, and maps with string keys are maps
+//   - a pointer is a union of null and the type pointed to
+//   - a named struct is a record of the exported fields, in the order they are declared
+//
+// Struct fields may have a tag of the form `avro:"name,date,scale=2,precision=10"`, where every part is optional:
+//   - name is the field name, which defaults to the Go field name, and - skips the field
+//   - date writes a time.Time as a date
+//   - scale and precision are for a math.Decimal
+//
+// Any other type is an error.
+func Schema[T any]() (string, error) {
+	schema, _, err := compileType[T]()
+	return schema, err
+}
+
+// MustSchema is a must version of Schema
+func MustSchema[T any]() string {
+	return funcs.MustValue(Schema[T]())
+}
+
+// Write writes the values of an iter to an Avro object container file, using the schema of T as described in Schema.
+// The values are written in blocks of blockSize values (default 1000), without compression.
+//
+// Writing stops at the first error returned by the iter, the writer, or a value that cannot be encoded, such as a
+// Decimal with more decimal places than the field scale. In that case, the values of the block being built are not
+// written, so that the file is valid up to the last complete block.
+func Write[T any](src iter.Iter[T], dst io.Writer, blockSize ...uint) error {
+	schema, enc, err := compileType[T]()
+	if err != nil {
+		return err
+	}
+
+	sync, err := newSync()
+	if err != nil {
+		return err
+	}
+
+	// Header: magic, metadata map with the schema and codec, sync marker
+	var (
+		buf   bytes.Buffer
+		block bytes.Buffer
+		size  = funcs.SliceIndex(blockSize, 0, defaultBlockSize)
+		count int64
+	)
+
+	if size == 0 {
+		size = defaultBlockSize
+	}
+
+	buf.Write(magic)
+	writeLong(&buf, 2)
+	writeBytes(&buf, []byte("avro.codec"))
+	writeBytes(&buf, []byte("null"))
+	writeBytes(&buf, []byte("avro.schema"))
+	writeBytes(&buf, []byte(schema))
+	writeLong(&buf, 0)
+	buf.Write(sync[:])
+
+	// flush writes the buffer, after adding the pending block, if any
+	flush := func() error {
+		if count > 0 {
+			writeLong(&buf, count)
+			writeBytes(&buf, block.Bytes())
+			buf.Write(sync[:])
+			block.Reset()
+			count = 0
+		}
+
+		_, err := dst.Write(buf.Bytes())
+		buf.Reset()
+		return err
+	}
+
+	if err = flush(); err != nil {
+		return err
+	}
+
+	for {
+		val, err := src.Next()
+		if err != nil {
+			if err == iter.EOI {
+				return flush()
+			}
+
+			return err
+		}
+
+		if err = enc(&block, goreflect.ValueOf(&val).Elem()); err != nil {
+			return err
+		}
+
+		if count++; count == int64(size) {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// MustWrite is a must version of Write
+func MustWrite[T any](src iter.Iter[T], dst io.Writer, blockSize ...uint) {
+	funcs.Must(Write(src, dst, blockSize...))
+}
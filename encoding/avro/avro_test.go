@@ -0,0 +1,426 @@
+package avro
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	goreflect "reflect"
+	"testing"
+	"time"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/io"
+	"github.com/bantling/micro/iter"
+	"github.com/bantling/micro/math"
+	"github.com/bantling/micro/tuple"
+	"github.com/stretchr/testify/assert"
+)
+
+type avroCustomer struct {
+	ID       int64
+	Name     string       `avro:"name"`
+	Balance  math.Decimal `avro:"balance,scale=2,precision=10"`
+	Born     time.Time    `avro:"born,date"`
+	Created  time.Time
+	Tags     []string
+	Attrs    map[string]int32
+	Manager  *avroCustomer
+	Secret   string `avro:"-"`
+	internal int
+}
+
+type avroTypes struct {
+	B   bool
+	I8  int8
+	I16 int16
+	I32 int32
+	I64 int64
+	U8  uint8
+	U32 uint32
+	F32 float32
+	F64 float64
+	Bs  []byte
+	Arr [2]uint16
+}
+
+type avroEmpty struct{}
+
+// readLong reads a zig zag varint
+func readLong(r *bytes.Reader) int64 {
+	u := funcs.MustValue(binary.ReadUvarint(r))
+	return int64(u>>1) ^ -int64(u&1)
+}
+
+// readBytes reads a length followed by the bytes
+func readBytes(r *bytes.Reader) []byte {
+	b := make([]byte, readLong(r))
+	r.Read(b)
+	return b
+}
+
+// readFile reads an object container file, returning the metadata and the blocks, after verifying the sync markers
+func readFile(t *testing.T, file []byte) (map[string]string, []tuple.Two[int64, []byte]) {
+	var (
+		r      = bytes.NewReader(file)
+		hdr    = make([]byte, 4)
+		meta   = map[string]string{}
+		sync   = make([]byte, 16)
+		blocks []tuple.Two[int64, []byte]
+	)
+
+	r.Read(hdr)
+	assert.Equal(t, magic, hdr)
+
+	for n := readLong(r); n > 0; n = readLong(r) {
+		for ; n > 0; n-- {
+			k := string(readBytes(r))
+			meta[k] = string(readBytes(r))
+		}
+	}
+
+	r.Read(sync)
+	for r.Len() > 0 {
+		n, data, blockSync := readLong(r), readBytes(r), make([]byte, 16)
+		r.Read(blockSync)
+		assert.Equal(t, sync, blockSync)
+		blocks = append(blocks, tuple.Of2(n, data))
+	}
+
+	return meta, blocks
+}
+
+func TestWriteLong_(t *testing.T) {
+	for _, ts := range []tuple.Two[int64, []byte]{
+		tuple.Of2(int64(0), []byte{0}),
+		tuple.Of2(int64(-1), []byte{1}),
+		tuple.Of2(int64(1), []byte{2}),
+		tuple.Of2(int64(-64), []byte{0x7f}),
+		tuple.Of2(int64(64), []byte{0x80, 0x01}),
+		tuple.Of2(int64(-8193), []byte{0x81, 0x80, 0x01}),
+	} {
+		var buf bytes.Buffer
+		writeLong(&buf, ts.T)
+		assert.Equal(t, ts.U, buf.Bytes(), ts.T)
+		assert.Equal(t, ts.T, readLong(bytes.NewReader(buf.Bytes())))
+	}
+}
+
+func TestWriteDecimal_(t *testing.T) {
+	for _, ts := range []tuple.Three[string, uint, []byte]{
+		tuple.Of3("0", uint(0), []byte{0}),
+		tuple.Of3("0.00", uint(3), []byte{0}),
+		tuple.Of3("1.23", uint(2), []byte{0x7b}),
+		tuple.Of3("-1.23", uint(2), []byte{0x85}),
+		tuple.Of3("1.28", uint(2), []byte{0x00, 0x80}),
+		tuple.Of3("-1.28", uint(2), []byte{0x80}),
+		tuple.Of3("-1.29", uint(2), []byte{0xff, 0x7f}),
+		tuple.Of3("-1", uint(0), []byte{0xff}),
+		tuple.Of3("1.5", uint(3), []byte{0x05, 0xdc}),
+		tuple.Of3("-256", uint(0), []byte{0xff, 0x00}),
+		tuple.Of3("999999999999999999", uint(0), []byte{0x0d, 0xe0, 0xb6, 0xb3, 0xa7, 0x63, 0xff, 0xff}),
+	} {
+		var buf bytes.Buffer
+		assert.Nil(t, writeDecimal(&buf, math.MustStringToDecimal(ts.T), ts.U, 18), ts.T)
+		assert.Equal(t, ts.V, readBytes(bytes.NewReader(buf.Bytes())), ts.T)
+	}
+
+	// 18 digits at a scale of 2 is 20 digits, which needs a precision of 20
+	var buf bytes.Buffer
+	assert.Equal(
+		t,
+		fmt.Errorf(errDecimalPrecisionMsg, "999999999999999999", 18, 2),
+		writeDecimal(&buf, math.MustStringToDecimal("999999999999999999"), 2, 18),
+	)
+	assert.Nil(t, writeDecimal(&buf, math.MustStringToDecimal("999999999999999999"), 2, 20))
+
+	assert.Equal(t, fmt.Errorf(errDecimalScaleMsg, "1.234", 2), writeDecimal(&buf, math.MustStringToDecimal("1.234"), 2, 18))
+}
+
+func TestSchema_(t *testing.T) {
+	assert.Equal(
+		t,
+		`{"fields":[`+
+			`{"name":"ID","type":"long"},`+
+			`{"name":"name","type":"string"},`+
+			`{"name":"balance","type":{"logicalType":"decimal","precision":10,"scale":2,"type":"bytes"}},`+
+			`{"name":"born","type":{"logicalType":"date","type":"int"}},`+
+			`{"name":"Created","type":{"logicalType":"timestamp-millis","type":"long"}},`+
+			`{"name":"Tags","type":{"items":"string","type":"array"}},`+
+			`{"name":"Attrs","type":{"type":"map","values":"int"}},`+
+			`{"name":"Manager","type":["null","avroCustomer"]}`+
+			`],"name":"avroCustomer","type":"record"}`,
+		MustSchema[avroCustomer](),
+	)
+
+	assert.Equal(
+		t,
+		`{"fields":[`+
+			`{"name":"B","type":"boolean"},`+
+			`{"name":"I8","type":"int"},`+
+			`{"name":"I16","type":"int"},`+
+			`{"name":"I32","type":"int"},`+
+			`{"name":"I64","type":"long"},`+
+			`{"name":"U8","type":"int"},`+
+			`{"name":"U32","type":"long"},`+
+			`{"name":"F32","type":"float"},`+
+			`{"name":"F64","type":"double"},`+
+			`{"name":"Bs","type":"bytes"},`+
+			`{"name":"Arr","type":{"items":"int","type":"array"}}`+
+			`],"name":"avroTypes","type":"record"}`,
+		MustSchema[avroTypes](),
+	)
+
+	assert.Equal(t, `{"fields":[],"name":"avroEmpty","type":"record"}`, MustSchema[avroEmpty]())
+	assert.Equal(t, `["null",{"logicalType":"decimal","precision":18,"scale":0,"type":"bytes"}]`, MustSchema[*math.Decimal]())
+	assert.Equal(t, `{"items":"string","type":"array"}`, MustSchema[[]string]())
+
+	// Errors
+	// A local type of the same name as a package type
+	type dupeA struct{ A avroEmpty }
+	type avroEmpty struct{ A int }
+	type dupe struct {
+		A dupeA
+		B avroEmpty
+	}
+
+	type anon struct{ A struct{} }
+
+	type tags struct {
+		A int `avro:",scale=x"`
+	}
+
+	type precision struct {
+		A math.Decimal `avro:",scale=5,precision=4"`
+	}
+
+	type unknown struct {
+		A int `avro:",bogus"`
+	}
+
+	assert.Equal(t, tuple.Of2("", fmt.Errorf(errUnsupportedTypeMsg, "chan int")), tuple.Of2(Schema[chan int]()))
+	assert.Equal(t, tuple.Of2("", fmt.Errorf(errUnsupportedTypeMsg, "map[int]string")), tuple.Of2(Schema[map[int]string]()))
+	assert.Equal(t, tuple.Of2("", fmt.Errorf(errUnsupportedTypeMsg, "interface {}")), tuple.Of2(Schema[any]()))
+	assert.Equal(t, tuple.Of2("", fmt.Errorf(errUnnamedRecordMsg, "struct {}")), tuple.Of2(Schema[anon]()))
+	assert.Equal(
+		t,
+		tuple.Of2("", fmt.Errorf(errDuplicateNameMsg, "avroEmpty", "avro.avroEmpty", "avro.avroEmpty")),
+		tuple.Of2(Schema[dupe]()),
+	)
+	assert.Equal(t, tuple.Of2("", fmt.Errorf(errInvalidTagMsg, ",scale=x", "avro.tags", "A")), tuple.Of2(Schema[tags]()))
+	assert.Equal(
+		t,
+		tuple.Of2("", fmt.Errorf(errInvalidTagMsg, ",scale=5,precision=4", "avro.precision", "A")),
+		tuple.Of2(Schema[precision]()),
+	)
+	assert.Equal(t, tuple.Of2("", fmt.Errorf(errInvalidTagMsg, ",bogus", "avro.unknown", "A")), tuple.Of2(Schema[unknown]()))
+
+	funcs.TryTo(
+		func() {
+			MustSchema[any]()
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errUnsupportedTypeMsg, "interface {}"), e)
+		},
+	)
+}
+
+func TestWrite_(t *testing.T) {
+	// Predictable sync marker
+	defer func(orig func() ([16]byte, error)) { newSync = orig }(newSync)
+	newSync = func() (sync [16]byte, err error) {
+		for i := range sync {
+			sync[i] = byte(i)
+		}
+		return
+	}
+
+	var (
+		born    = time.Date(1969, 12, 31, 12, 0, 0, 0, time.UTC)
+		created = time.Date(2020, 1, 2, 3, 4, 5, 6_000_000, time.UTC)
+		mgr     = avroCustomer{
+			ID:      1,
+			Name:    "Boss",
+			Balance: math.MustStringToDecimal("-2.5"),
+			Born:    time.Unix(0, 0).UTC(),
+			Created: created,
+		}
+		emp = avroCustomer{
+			ID:      2,
+			Name:    "Emp",
+			Balance: math.MustStringToDecimal("1.28"),
+			Born:    born,
+			Created: created,
+			Tags:    []string{"a", "bc"},
+			Attrs:   map[string]int32{"x": -1},
+			Manager: &mgr,
+			Secret:  "hidden",
+		}
+		buf bytes.Buffer
+	)
+
+	assert.Nil(t, Write(iter.Of(mgr, emp, mgr), &buf, 2))
+
+	meta, blocks := readFile(t, buf.Bytes())
+	assert.Equal(t, map[string]string{"avro.codec": "null", "avro.schema": MustSchema[avroCustomer]()}, meta)
+
+	var (
+		createdMillis = created.UnixMilli()
+		longBytes     = func(vals ...int64) []byte {
+			var b bytes.Buffer
+			for _, v := range vals {
+				writeLong(&b, v)
+			}
+			return b.Bytes()
+		}
+		mgrBytes = bytes.Join([][]byte{
+			longBytes(1),                    // ID
+			append(longBytes(4), "Boss"...), // name
+			longBytes(2), {0xff, 0x06},      // balance -250
+			longBytes(0),             // born
+			longBytes(createdMillis), // created
+			longBytes(0),             // no tags
+			longBytes(0),             // no attrs
+			longBytes(0),             // no manager
+		}, nil)
+		empBytes = bytes.Join([][]byte{
+			longBytes(2),                   // ID
+			append(longBytes(3), "Emp"...), // name
+			longBytes(2), {0x00, 0x80},     // balance 128
+			longBytes(-1),                        // born the day before the epoch
+			longBytes(createdMillis),             // created
+			longBytes(2, 1), {'a'}, longBytes(2), // tags
+			{'b', 'c'}, longBytes(0), // tags end
+			longBytes(1, 1), {'x'}, longBytes(-1), // attrs
+			longBytes(0),           // attrs end
+			longBytes(1), mgrBytes, // manager
+		}, nil)
+	)
+
+	assert.Equal(
+		t,
+		[]tuple.Two[int64, []byte]{
+			tuple.Of2(int64(2), append(append([]byte{}, mgrBytes...), empBytes...)),
+			tuple.Of2(int64(1), mgrBytes),
+		},
+		blocks,
+	)
+
+	// Empty iter is a header only, and a zero block size is the default
+	buf.Reset()
+	assert.Nil(t, Write(iter.OfEmpty[avroTypes](), &buf, 0))
+	meta, blocks = readFile(t, buf.Bytes())
+	assert.Equal(t, map[string]string{"avro.codec": "null", "avro.schema": MustSchema[avroTypes]()}, meta)
+	assert.Nil(t, blocks)
+
+	// Primitives
+	buf.Reset()
+	assert.Nil(t, Write(iter.Of(avroTypes{true, -1, 2, -3, 4, 5, 6, 1.5, -0.25, []byte{9, 8}, [2]uint16{7, 300}}), &buf))
+	_, blocks = readFile(t, buf.Bytes())
+	assert.Equal(
+		t,
+		[]tuple.Two[int64, []byte]{
+			tuple.Of2(int64(1), bytes.Join([][]byte{
+				{1},
+				longBytes(-1, 2, -3, 4, 5, 6),
+				{0x00, 0x00, 0xc0, 0x3f}, // 1.5
+				{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xd0, 0xbf}, // -0.25
+				longBytes(2), {9, 8},
+				longBytes(2, 7, 300, 0),
+			}, nil)),
+		},
+		blocks,
+	)
+}
+
+func TestWriteDate_(t *testing.T) {
+	var (
+		c      = &compiler{names: map[string]goreflect.Type{}, records: map[goreflect.Type]encoder{}}
+		_, enc = funcs.MustValue2(c.compile(timeType, fieldOpts{date: true}))
+		days   = func(tm time.Time) []byte {
+			var buf bytes.Buffer
+			assert.Nil(t, enc(&buf, goreflect.ValueOf(tm)))
+			return buf.Bytes()
+		}
+		long = func(v int64) []byte {
+			var buf bytes.Buffer
+			writeLong(&buf, v)
+			return buf.Bytes()
+		}
+	)
+
+	// 2026-01-01 is 20454 days after the epoch, in any location
+	assert.Equal(t, long(20454), days(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, long(20454), days(time.Date(2026, 1, 1, 23, 59, 59, 0, time.UTC)))
+	assert.Equal(t, long(20454), days(time.Date(2026, 1, 1, 0, 0, 0, 0, time.FixedZone("JST", 9*60*60))))
+	assert.Equal(t, long(20454), days(time.Date(2026, 1, 1, 23, 0, 0, 0, time.FixedZone("PST", -8*60*60))))
+
+	// Before the epoch
+	assert.Equal(t, long(-1), days(time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, long(-1), days(time.Date(1969, 12, 31, 23, 0, 0, 0, time.FixedZone("PST", -8*60*60))))
+	assert.Equal(t, long(0), days(time.Date(1970, 1, 1, 0, 0, 0, 0, time.FixedZone("JST", 9*60*60))))
+
+	if tokyo, err := time.LoadLocation("Asia/Tokyo"); err == nil {
+		assert.Equal(t, long(20454), days(time.Date(2026, 1, 1, 0, 0, 0, 0, tokyo)))
+	}
+}
+
+func TestWriteErrors_(t *testing.T) {
+	anErr := fmt.Errorf("An err")
+
+	// Schema error
+	var buf bytes.Buffer
+	assert.Equal(t, fmt.Errorf(errUnsupportedTypeMsg, "interface {}"), Write(iter.Of[any](1), &buf))
+	assert.Zero(t, buf.Len())
+
+	// Sync error
+	defer func(orig func() ([16]byte, error)) { newSync = orig }(newSync)
+	newSync = func() ([16]byte, error) { return [16]byte{}, anErr }
+	assert.Equal(t, anErr, Write(iter.Of(1), &buf))
+	newSync = func() (sync [16]byte, err error) { return }
+
+	// Writer errors for the header and a block
+	assert.Equal(t, anErr, Write(iter.Of(1), io.NewErrorWriter(0, anErr)))
+	w := io.NewErrorWriter(len(magic)+44+16, anErr)
+	assert.Equal(t, anErr, Write(iter.Of(1), w, 1))
+	assert.Equal(t, len(magic)+44+16, len(w.Output()))
+
+	// Iter error, the first block is written
+	buf.Reset()
+	assert.Equal(t, anErr, Write(iter.OfScript(iter.ValueStep(1), iter.ValueStep(2), iter.ValueStep(3), iter.ErrorStep[int](anErr)), &buf, 2))
+	_, blocks := readFile(t, buf.Bytes())
+	assert.Equal(t, []tuple.Two[int64, []byte]{tuple.Of2(int64(2), []byte{2, 4})}, blocks)
+
+	// Encoding errors
+	type decimal struct {
+		D math.Decimal `avro:",scale=1"`
+	}
+	assert.Equal(
+		t,
+		fmt.Errorf(errDecimalScaleMsg, "1.25", 1),
+		Write(iter.Of(decimal{math.MustStringToDecimal("1.25")}), &buf),
+	)
+	assert.Equal(
+		t,
+		fmt.Errorf(errUintOverflowMsg, uint64(1<<63), goreflect.TypeOf(uint64(0))),
+		Write(iter.Of([]uint64{1, 1 << 63}), &buf),
+	)
+	half := math.MustStringToDecimal("0.5")
+	assert.Equal(
+		t,
+		fmt.Errorf(errDecimalScaleMsg, "0.5", 0),
+		Write(iter.Of(map[string]*math.Decimal{"a": &half}), &buf),
+	)
+
+	funcs.TryTo(
+		func() {
+			MustWrite(iter.Of(1), io.NewErrorWriter(0, anErr))
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, anErr, e)
+		},
+	)
+}
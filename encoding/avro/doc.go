@@ -0,0 +1,4 @@
+// Package avro writes iters of Go values as Avro object container files
+//
+// SPDX-License-Identifier: Apache-2.0
+package avro
@@ -0,0 +1,174 @@
+package json
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/union"
+)
+
+// ObjectBuilder builds a Value of type Object one key at a time, using fluent method calls.
+// Values are converted the same way as MapToValue.
+// The first conversion error is retained and returned by Build, further calls are ignored after an error occurs.
+type ObjectBuilder struct {
+	mp  map[string]Value
+	err error
+}
+
+// ArrayBuilder builds a Value of type Array one element at a time, using fluent method calls.
+// Values are converted the same way as SliceToValue.
+// The first conversion error is retained and returned by Build, further calls are ignored after an error occurs.
+type ArrayBuilder struct {
+	slc []Value
+	err error
+}
+
+// builderToValue converts a value for a builder, where the value may already be a Value
+func builderToValue(val any) (Value, error) {
+	if jv, isa := val.(Value); isa {
+		return jv, nil
+	}
+
+	return toValue(val)
+}
+
+// ==== ObjectBuilder
+
+// NewObjectBuilder constructs an ObjectBuilder for an empty object
+func NewObjectBuilder() *ObjectBuilder {
+	return &ObjectBuilder{mp: map[string]Value{}}
+}
+
+// Set sets a key to a value, which may be a Value, or any type accepted by MapToValue.
+// If the key already exists, it is replaced.
+func (b *ObjectBuilder) Set(key string, val any) *ObjectBuilder {
+	if b.err == nil {
+		var jv Value
+		if jv, b.err = builderToValue(val); b.err == nil {
+			b.mp[key] = jv
+		}
+	}
+
+	return b
+}
+
+// SetObject sets a key to a sub object, that is populated by the given function
+func (b *ObjectBuilder) SetObject(key string, fn func(*ObjectBuilder)) *ObjectBuilder {
+	if b.err == nil {
+		sub := NewObjectBuilder()
+		fn(sub)
+
+		var jv Value
+		if jv, b.err = sub.Build(); b.err == nil {
+			b.mp[key] = jv
+		}
+	}
+
+	return b
+}
+
+// SetArray sets a key to a sub array, that is populated by the given function
+func (b *ObjectBuilder) SetArray(key string, fn func(*ArrayBuilder)) *ObjectBuilder {
+	if b.err == nil {
+		sub := NewArrayBuilder()
+		fn(sub)
+
+		var jv Value
+		if jv, b.err = sub.Build(); b.err == nil {
+			b.mp[key] = jv
+		}
+	}
+
+	return b
+}
+
+// Build returns the object built so far, or the first error that occurred
+func (b *ObjectBuilder) Build() (Value, error) {
+	if b.err != nil {
+		return invalidValue, b.err
+	}
+
+	// Copy the map so that further calls do not modify the result
+	mp := make(map[string]Value, len(b.mp))
+	for k, v := range b.mp {
+		mp[k] = v
+	}
+
+	return Value{typ: Object, val: union.Of4T[map[string]Value, []Value, string, bool](mp)}, nil
+}
+
+// MustBuild is a must version of Build
+func (b *ObjectBuilder) MustBuild() Value {
+	return funcs.MustValue(b.Build())
+}
+
+// ==== ArrayBuilder
+
+// NewArrayBuilder constructs an ArrayBuilder for an empty array
+func NewArrayBuilder() *ArrayBuilder {
+	return &ArrayBuilder{slc: []Value{}}
+}
+
+// Add appends values, which may be Values, or any types accepted by SliceToValue
+func (b *ArrayBuilder) Add(vals ...any) *ArrayBuilder {
+	for _, val := range vals {
+		if b.err != nil {
+			break
+		}
+
+		var jv Value
+		if jv, b.err = builderToValue(val); b.err == nil {
+			b.slc = append(b.slc, jv)
+		}
+	}
+
+	return b
+}
+
+// AddObject appends a sub object, that is populated by the given function
+func (b *ArrayBuilder) AddObject(fn func(*ObjectBuilder)) *ArrayBuilder {
+	if b.err == nil {
+		sub := NewObjectBuilder()
+		fn(sub)
+
+		var jv Value
+		if jv, b.err = sub.Build(); b.err == nil {
+			b.slc = append(b.slc, jv)
+		}
+	}
+
+	return b
+}
+
+// AddArray appends a sub array, that is populated by the given function
+func (b *ArrayBuilder) AddArray(fn func(*ArrayBuilder)) *ArrayBuilder {
+	if b.err == nil {
+		sub := NewArrayBuilder()
+		fn(sub)
+
+		var jv Value
+		if jv, b.err = sub.Build(); b.err == nil {
+			b.slc = append(b.slc, jv)
+		}
+	}
+
+	return b
+}
+
+// Build returns the array built so far, or the first error that occurred
+func (b *ArrayBuilder) Build() (Value, error) {
+	if b.err != nil {
+		return invalidValue, b.err
+	}
+
+	// Copy the slice so that further calls do not modify the result
+	slc := make([]Value, len(b.slc))
+	copy(slc, b.slc)
+
+	return Value{typ: Array, val: union.Of4U[map[string]Value, []Value, string, bool](slc)}, nil
+}
+
+// MustBuild is a must version of Build
+func (b *ArrayBuilder) MustBuild() Value {
+	return funcs.MustValue(b.Build())
+}
@@ -0,0 +1,122 @@
+package json
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObjectBuilder_(t *testing.T) {
+	jv := NewObjectBuilder().
+		Set("str", "foo").
+		Set("int", 1).
+		Set("big", big.NewInt(2)).
+		Set("bln", true).
+		Set("nul", nil).
+		Set("val", StringToValue("bar")).
+		SetObject("obj", func(b *ObjectBuilder) { b.Set("sub", 3) }).
+		SetArray("arr", func(b *ArrayBuilder) { b.Add(4, "5") }).
+		MustBuild()
+
+	assert.Equal(
+		t,
+		map[string]any{
+			"str": "foo",
+			"int": NumberString("1"),
+			"big": NumberString("2"),
+			"bln": true,
+			"nul": nil,
+			"val": "bar",
+			"obj": map[string]any{"sub": NumberString("3")},
+			"arr": []any{NumberString("4"), "5"},
+		},
+		jv.ToMap(),
+	)
+
+	// Empty
+	assert.Equal(t, MustToValue(map[string]any{}), NewObjectBuilder().MustBuild())
+
+	// Further builder calls do not modify a built value
+	b := NewObjectBuilder().Set("a", 1)
+	jv = b.MustBuild()
+	b.Set("b", 2)
+	assert.Equal(t, 1, len(jv.AsMap()))
+	assert.Equal(t, 2, len(b.MustBuild().AsMap()))
+
+	// First error is retained
+	_, err := NewObjectBuilder().Set("ns", NumberString("foo")).Set("ok", 1).Build()
+	assert.Equal(t, errNotNumber, err)
+
+	_, err = NewObjectBuilder().Set("st", struct{}{}).Build()
+	assert.NotNil(t, err)
+
+	_, err = NewObjectBuilder().SetObject("obj", func(b *ObjectBuilder) { b.Set("ns", NumberString("")) }).Build()
+	assert.Equal(t, errNotNumber, err)
+
+	_, err = NewObjectBuilder().SetArray("arr", func(b *ArrayBuilder) { b.Add(NumberString("")) }).Build()
+	assert.Equal(t, errNotNumber, err)
+
+	funcs.TryTo(
+		func() {
+			NewObjectBuilder().Set("ns", NumberString("")).MustBuild()
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, errNotNumber, e) },
+	)
+}
+
+func TestArrayBuilder_(t *testing.T) {
+	jv := NewArrayBuilder().
+		Add("foo", 1, big.NewFloat(2.5)).
+		Add(false, nil, StringToValue("bar")).
+		AddObject(func(b *ObjectBuilder) { b.Set("sub", 3) }).
+		AddArray(func(b *ArrayBuilder) { b.Add(4) }).
+		MustBuild()
+
+	assert.Equal(
+		t,
+		[]any{
+			"foo",
+			NumberString("1"),
+			NumberString("2.5"),
+			false,
+			nil,
+			"bar",
+			map[string]any{"sub": NumberString("3")},
+			[]any{NumberString("4")},
+		},
+		jv.ToSlice(),
+	)
+
+	// Empty
+	assert.Equal(t, MustToValue([]any{}), NewArrayBuilder().MustBuild())
+
+	// Further builder calls do not modify a built value
+	b := NewArrayBuilder().Add(1)
+	jv = b.MustBuild()
+	b.Add(2)
+	assert.Equal(t, 1, len(jv.AsSlice()))
+	assert.Equal(t, 2, len(b.MustBuild().AsSlice()))
+
+	// First error is retained
+	_, err := NewArrayBuilder().Add(1, NumberString(""), 2).Build()
+	assert.Equal(t, errNotNumber, err)
+
+	_, err = NewArrayBuilder().AddObject(func(b *ObjectBuilder) { b.Set("ns", NumberString("")) }).Add(1).Build()
+	assert.Equal(t, errNotNumber, err)
+
+	_, err = NewArrayBuilder().AddArray(func(b *ArrayBuilder) { b.Add(NumberString("")) }).Build()
+	assert.Equal(t, errNotNumber, err)
+
+	funcs.TryTo(
+		func() {
+			NewArrayBuilder().Add(NumberString("")).MustBuild()
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, errNotNumber, e) },
+	)
+}
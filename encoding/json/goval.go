@@ -0,0 +1,229 @@
+package json
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"encoding/base64"
+	gojson "encoding/json"
+	"fmt"
+	"math/big"
+	goreflect "reflect"
+
+	"github.com/bantling/micro/conv"
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/union"
+)
+
+// Error constants
+var (
+	errInvalidFromGoValueMsg = "A value of type %T is not a valid type to convert to a Value. Acceptable types are maps with string keys, slices, arrays, strings, numbers, encoding/json.Number, NumberString, *big.Int, *big.Float, *big.Rat, bools, pointers to any of these, Value, and nil"
+)
+
+var (
+	bigIntType   = goreflect.TypeOf((*big.Int)(nil))
+	bigFloatType = goreflect.TypeOf((*big.Float)(nil))
+	bigRatType   = goreflect.TypeOf((*big.Rat)(nil))
+)
+
+// FromGo converts a tree of native Go values into a Value, such as a map[string]any decoded by encoding/json or some
+// third party library. The conversion is more lenient than ToValue, following the rules of encoding/json.Marshal for
+// the types listed below:
+// - a Value is used as is
+// - a map with string keys becomes an Object, where each map value is converted recursively
+// - a slice of any kind of byte becomes a String of the standard base64 encoding of the bytes
+// - any other slice, or an array, becomes an Array, where each element is converted recursively
+// - a nil map, nil slice, nil pointer, or nil becomes a Null
+// - a non-nil pointer is converted by converting the value it points to, except *big.Int, *big.Float, and *big.Rat
+// - any kind of string becomes a String, except NumberString and encoding/json.Number, which become a Number
+// - any kind of integer or float, and *big.Int, *big.Float, and *big.Rat become a Number
+// - any kind of bool becomes a Boolean
+//
+// Unlike encoding/json.Marshal, structs, maps with non-string keys, and types that only implement json.Marshaler or
+// encoding.TextMarshaler are not supported. Any other type results in (Invalid Value, error)
+func FromGo(val any) (res Value, err error) {
+	// Fast paths for the types encoding/json decodes into
+	switch v := val.(type) {
+	case nil:
+		return NullValue, nil
+	case Value:
+		return v, nil
+	case map[string]any:
+		if v == nil {
+			return NullValue, nil
+		}
+
+		mp := make(map[string]Value, len(v))
+		for k, e := range v {
+			if mp[k], err = FromGo(e); err != nil {
+				return invalidValue, err
+			}
+		}
+
+		return Value{typ: Object, val: union.Of4T[map[string]Value, []Value, string, bool](mp)}, nil
+	case []any:
+		if v == nil {
+			return NullValue, nil
+		}
+
+		slc := make([]Value, len(v))
+		for i, e := range v {
+			if slc[i], err = FromGo(e); err != nil {
+				return invalidValue, err
+			}
+		}
+
+		return Value{typ: Array, val: union.Of4U[map[string]Value, []Value, string, bool](slc)}, nil
+	case []byte:
+		if v == nil {
+			return NullValue, nil
+		}
+
+		return StringToValue(base64.StdEncoding.EncodeToString(v)), nil
+	case string:
+		return StringToValue(v), nil
+	case NumberString:
+		return numberToValue(v)
+	case gojson.Number:
+		return numberToValue(NumberString(v))
+	case bool:
+		return BoolToValue(v), nil
+	}
+
+	// Use reflection for any other types
+	rv := goreflect.ValueOf(val)
+
+	switch typ := rv.Type(); typ.Kind() {
+	case goreflect.Map:
+		if typ.Key().Kind() != goreflect.String {
+			break
+		}
+
+		if rv.IsNil() {
+			return NullValue, nil
+		}
+
+		mp := make(map[string]Value, rv.Len())
+		for mi := rv.MapRange(); mi.Next(); {
+			if mp[mi.Key().String()], err = FromGo(mi.Value().Interface()); err != nil {
+				return invalidValue, err
+			}
+		}
+
+		return Value{typ: Object, val: union.Of4T[map[string]Value, []Value, string, bool](mp)}, nil
+
+	case goreflect.Slice, goreflect.Array:
+		if (typ.Kind() == goreflect.Slice) && rv.IsNil() {
+			return NullValue, nil
+		}
+
+		if (typ.Kind() == goreflect.Slice) && (typ.Elem().Kind() == goreflect.Uint8) {
+			return StringToValue(base64.StdEncoding.EncodeToString(rv.Bytes())), nil
+		}
+
+		slc := make([]Value, rv.Len())
+		for i := range slc {
+			if slc[i], err = FromGo(rv.Index(i).Interface()); err != nil {
+				return invalidValue, err
+			}
+		}
+
+		return Value{typ: Array, val: union.Of4U[map[string]Value, []Value, string, bool](slc)}, nil
+
+	case goreflect.String:
+		return StringToValue(rv.String()), nil
+
+	case goreflect.Bool:
+		return BoolToValue(rv.Bool()), nil
+
+	case goreflect.Int, goreflect.Int8, goreflect.Int16, goreflect.Int32, goreflect.Int64:
+		return numberToValue(rv.Int())
+
+	case goreflect.Uint, goreflect.Uint8, goreflect.Uint16, goreflect.Uint32, goreflect.Uint64:
+		return numberToValue(rv.Uint())
+
+	case goreflect.Float32:
+		return numberToValue(float32(rv.Float()))
+
+	case goreflect.Float64:
+		return numberToValue(rv.Float())
+
+	case goreflect.Pointer:
+		if rv.IsNil() {
+			return NullValue, nil
+		}
+
+		if (typ == bigIntType) || (typ == bigFloatType) || (typ == bigRatType) {
+			return numberToValue(val)
+		}
+
+		return FromGo(rv.Elem().Interface())
+	}
+
+	return invalidValue, fmt.Errorf(errInvalidFromGoValueMsg, val)
+}
+
+// MustFromGo is a must version of FromGo
+func MustFromGo(val any) Value {
+	return funcs.MustValue(FromGo(val))
+}
+
+// ToGo converts a Value into a tree of native Go values, the same as encoding/json.Unmarshal into an any:
+// Object  = map[string]any
+// Array   = []any
+// String  = string
+// Number  = float64
+// Boolean = bool
+// Null    = nil
+//
+// Numbers are converted with conv.StringToFloat64, so a number that cannot be represented as a float64 results in
+// (nil, error). Use ToAny to convert numbers to NumberString instead.
+//
+// An Invalid Value results in (nil, nil).
+func ToGo(jv Value) (res any, err error) {
+	switch jv.typ {
+	case Object:
+		src := jv.val.T()
+		mp := make(map[string]any, len(src))
+
+		for k, v := range src {
+			if mp[k], err = ToGo(v); err != nil {
+				return nil, err
+			}
+		}
+
+		return mp, nil
+
+	case Array:
+		src := jv.val.U()
+		slc := make([]any, len(src))
+
+		for i, v := range src {
+			if slc[i], err = ToGo(v); err != nil {
+				return nil, err
+			}
+		}
+
+		return slc, nil
+
+	case String:
+		return jv.val.V(), nil
+
+	case Number:
+		var f float64
+		if err = conv.StringToFloat64(jv.val.V(), &f); err != nil {
+			return nil, err
+		}
+
+		return f, nil
+
+	case Boolean:
+		return jv.val.W(), nil
+	}
+
+	return nil, nil
+}
+
+// MustToGo is a must version of ToGo
+func MustToGo(jv Value) any {
+	return funcs.MustValue(ToGo(jv))
+}
@@ -0,0 +1,177 @@
+package json
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	gojson "encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/tuple"
+	"github.com/stretchr/testify/assert"
+)
+
+type fromGoString string
+
+type fromGoInt int16
+
+type fromGoBool bool
+
+type fromGoBytes []byte
+
+func TestFromGo_(t *testing.T) {
+	// Tree decoded by encoding/json
+	var goVal any
+	assert.Nil(t, gojson.Unmarshal([]byte(`{"str": "a", "num": 1.5, "bool": true, "null": null, "arr": [1, {"b": false}]}`), &goVal))
+
+	assert.Equal(
+		t,
+		MustMapToValue(map[string]Value{
+			"str":  StringToValue("a"),
+			"num":  MustNumberToValue(NumberString("1.5")),
+			"bool": TrueValue,
+			"null": NullValue,
+			"arr": MustSliceToValue([]Value{
+				MustNumberToValue(NumberString("1")),
+				MustMapToValue(map[string]Value{"b": FalseValue}),
+			}),
+		}),
+		MustFromGo(goVal),
+	)
+
+	// Tree decoded by encoding/json using Number
+	dec := gojson.NewDecoder(strings.NewReader(`[12345678901234567890]`))
+	dec.UseNumber()
+	assert.Nil(t, dec.Decode(&goVal))
+	assert.Equal(t, MustSliceToValue([]Value{MustNumberToValue(NumberString("12345678901234567890"))}), MustFromGo(goVal))
+
+	// Leaf types
+	assert.Equal(t, NullValue, MustFromGo(nil))
+	assert.Equal(t, TrueValue, MustFromGo(TrueValue))
+	assert.Equal(t, StringToValue("a"), MustFromGo("a"))
+	assert.Equal(t, StringToValue("a"), MustFromGo(fromGoString("a")))
+	assert.Equal(t, MustNumberToValue(NumberString("12")), MustFromGo(NumberString("12")))
+	assert.Equal(t, MustNumberToValue(NumberString("12345678901234567890")), MustFromGo(gojson.Number("12345678901234567890")))
+	assert.Equal(t, MustNumberToValue(NumberString("-3")), MustFromGo(fromGoInt(-3)))
+	assert.Equal(t, MustNumberToValue(NumberString("3")), MustFromGo(uint8(3)))
+	assert.Equal(t, MustNumberToValue(NumberString("1.25")), MustFromGo(float32(1.25)))
+	assert.Equal(t, MustNumberToValue(NumberString("5")), MustFromGo(big.NewInt(5)))
+	assert.Equal(t, TrueValue, MustFromGo(fromGoBool(true)))
+
+	// Pointers
+	str := "p"
+	assert.Equal(t, StringToValue("p"), MustFromGo(&str))
+	assert.Equal(t, NullValue, MustFromGo((*string)(nil)))
+	assert.Equal(t, NullValue, MustFromGo((*big.Int)(nil)))
+
+	// Typed maps, slices, and arrays
+	assert.Equal(
+		t,
+		MustMapToValue(map[string]Value{"a": MustSliceToValue([]Value{StringToValue("x"), StringToValue("y")})}),
+		MustFromGo(map[string][]string{"a": {"x", "y"}}),
+	)
+	assert.Equal(
+		t,
+		MustSliceToValue([]Value{MustNumberToValue(NumberString("1")), MustNumberToValue(NumberString("2"))}),
+		MustFromGo([2]int{1, 2}),
+	)
+	assert.Equal(t, MustMapToValue(map[string]Value{}), MustFromGo(map[fromGoString]int{}))
+
+	// Byte slices are base64 strings like encoding/json.Marshal, byte arrays are arrays
+	assert.Equal(t, StringToValue("aGk="), MustFromGo([]byte("hi")))
+	assert.Equal(t, StringToValue(""), MustFromGo([]byte{}))
+	assert.Equal(t, StringToValue("aGk="), MustFromGo(fromGoBytes("hi")))
+	assert.Equal(
+		t,
+		MustMapToValue(map[string]Value{"b": StringToValue("AQI=")}),
+		MustFromGo(map[string]any{"b": []byte{1, 2}}),
+	)
+	assert.Equal(
+		t,
+		MustSliceToValue([]Value{MustNumberToValue(NumberString("1")), MustNumberToValue(NumberString("2"))}),
+		MustFromGo([2]byte{1, 2}),
+	)
+
+	for _, val := range []any{[]byte("hi"), fromGoBytes{0xff}, [2]byte{1, 2}} {
+		var marshalled any
+		b, _ := gojson.Marshal(val)
+		assert.Nil(t, gojson.Unmarshal(b, &marshalled))
+		assert.Equal(t, MustFromGo(marshalled), MustFromGo(val))
+	}
+
+	// nil maps and slices
+	assert.Equal(t, NullValue, MustFromGo(map[string]any(nil)))
+	assert.Equal(t, NullValue, MustFromGo([]any(nil)))
+	assert.Equal(t, NullValue, MustFromGo(map[string]int(nil)))
+	assert.Equal(t, NullValue, MustFromGo([]int(nil)))
+	assert.Equal(t, NullValue, MustFromGo([]byte(nil)))
+	assert.Equal(t, NullValue, MustFromGo(fromGoBytes(nil)))
+
+	// Invalid types, including nested ones
+	for _, val := range []any{struct{}{}, map[int]any{}, []any{1, struct{}{}}, map[string]any{"a": make(chan int)}, NumberString("a")} {
+		res, err := FromGo(val)
+		assert.Equal(t, invalidValue, res)
+		assert.NotNil(t, err)
+	}
+
+	assert.Equal(t, tuple.Of2(invalidValue, fmt.Errorf(errInvalidFromGoValueMsg, struct{}{})), tuple.Of2(FromGo(struct{}{})))
+
+	funcs.TryTo(
+		func() {
+			MustFromGo(map[int]any{})
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf(errInvalidFromGoValueMsg, map[int]any{}), e) },
+	)
+}
+
+func TestToGo_(t *testing.T) {
+	jv := MustMapToValue(map[string]Value{
+		"str":  StringToValue("a"),
+		"num":  MustNumberToValue(NumberString("1.5")),
+		"bool": TrueValue,
+		"null": NullValue,
+		"arr":  MustSliceToValue([]Value{MustNumberToValue(NumberString("1")), MustMapToValue(map[string]Value{})}),
+	})
+
+	expected := map[string]any{
+		"str":  "a",
+		"num":  1.5,
+		"bool": true,
+		"null": nil,
+		"arr":  []any{float64(1), map[string]any{}},
+	}
+	assert.Equal(t, expected, MustToGo(jv))
+
+	// Same result as encoding/json
+	var goVal any
+	assert.Nil(t, gojson.Unmarshal([]byte(`{"str": "a", "num": 1.5, "bool": true, "null": null, "arr": [1, {}]}`), &goVal))
+	assert.Equal(t, goVal, MustToGo(jv))
+
+	// Round trip
+	assert.Equal(t, jv, MustFromGo(MustToGo(jv)))
+
+	// Invalid Value
+	assert.Equal(t, tuple.Of2[any, error](nil, nil), tuple.Of2(ToGo(invalidValue)))
+
+	// Number that is not a float64
+	bigNum := MustSliceToValue([]Value{MustNumberToValue(NumberString("12345678901234567"))})
+	assert.Equal(
+		t,
+		tuple.Of2[any, error](nil, fmt.Errorf("The string value of 12345678901234567 cannot be converted to float64")),
+		tuple.Of2(ToGo(bigNum)),
+	)
+
+	funcs.TryTo(
+		func() {
+			MustToGo(bigNum)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf("The string value of 12345678901234567 cannot be converted to float64"), e)
+		},
+	)
+}
@@ -0,0 +1,200 @@
+package json
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	gojson "encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/union"
+)
+
+const (
+	// Redacted is the string that Redact replaces values with
+	Redacted = "******"
+
+	errIllegalHookPathMsg = "The hook path %s is not a valid path, it must consist of a series of object keys and indexes, such as .users[*].password"
+)
+
+var (
+	// regexHookPathParts is like regexPathParts, except that an index may be *, to match any index
+	regexHookPathParts = regexp.MustCompile(`(?:\.[^.\[\]]+|\[(?:[0-9]+|\*)\])`)
+)
+
+// Hook transforms a Value that is being encoded, such as to redact or hash it when logging or exporting personal data.
+// The path is the path of the value in the document being encoded, in the form accepted by ParsePath, eg
+// .users[3].password.
+//
+// A hook replaces the whole value, so hooks do not apply to any value inside a value that a hook has replaced.
+type Hook func(path string, jv Value) (Value, error)
+
+// Hooks are the hooks that ApplyHooks applies to a document
+type Hooks struct {
+	// Paths maps paths to the hook applied to the value at that path. A path is in the form accepted by ParsePath, except
+	// that .* matches any object key and [*] matches any array index, eg .users[*].password.
+	// If more than one path matches a value, the most specific path is applied: the paths are compared part by part, and
+	// the first path with an exact key or index where the other has a wildcard wins, eg .users[0].* over .users[*].name.
+	Paths map[string]Hook
+
+	// Keys maps object keys to the hook applied to the value of that key in any object, at any depth, eg password.
+	// If a path and a key both match a value, only the path hook is applied.
+	Keys map[string]Hook
+}
+
+// Redact is a Hook that replaces any value with the String Redacted, except for null, which is left as is
+func Redact(_ string, jv Value) (Value, error) {
+	if jv.IsNull() {
+		return jv, nil
+	}
+
+	return StringToValue(Redacted), nil
+}
+
+// Hash returns a Hook that replaces any value with a String of the hex SHA-256 hash of the salt followed by the value,
+// except for null, which is left as is. Equal values have equal hashes, so that hashed values can still be correlated.
+//
+// A String is hashed as its characters, without quotes, and a Number or Boolean is hashed as its text. An Object or
+// Array is hashed as its encoding/json encoding, which has sorted keys.
+func Hash(salt string) Hook {
+	return func(_ string, jv Value) (Value, error) {
+		var text string
+
+		switch jv.Type() {
+		case Null:
+			return jv, nil
+		case Object, Array:
+			data, err := gojson.Marshal(jv.ToAny())
+			if err != nil {
+				return invalidValue, err
+			}
+
+			text = string(data)
+		default:
+			text = jv.AsString()
+		}
+
+		sum := sha256.Sum256([]byte(salt + text))
+		return StringToValue(hex.EncodeToString(sum[:])), nil
+	}
+}
+
+// hookPath is a path of Hooks.Paths, split into parts such as .users, [*], and .password
+type hookPath struct {
+	parts []string
+	hook  Hook
+}
+
+// isWildcard returns true if a part of a hook path is .* or [*]
+func isWildcard(part string) bool {
+	return (part == ".*") || (part == "[*]")
+}
+
+// moreSpecific returns true if the hook path hp is more specific than o, where the first part that is an exact key or
+// index in one and a wildcard in the other decides. Paths that do not differ that way are ordered by their parts.
+func (hp hookPath) moreSpecific(o hookPath) bool {
+	for i := 0; (i < len(hp.parts)) && (i < len(o.parts)); i++ {
+		if hw, ow := isWildcard(hp.parts[i]), isWildcard(o.parts[i]); hw != ow {
+			return ow
+		}
+	}
+
+	for i := 0; (i < len(hp.parts)) && (i < len(o.parts)); i++ {
+		if hp.parts[i] != o.parts[i] {
+			return hp.parts[i] < o.parts[i]
+		}
+	}
+
+	return len(hp.parts) < len(o.parts)
+}
+
+// matches returns true if the parts of the path of a value match the hook path
+func (hp hookPath) matches(parts []string) bool {
+	if len(parts) != len(hp.parts) {
+		return false
+	}
+
+	for i, part := range hp.parts {
+		if (part != parts[i]) && !((part == ".*") && (parts[i][0] == '.')) && !((part == "[*]") && (parts[i][0] == '[')) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ApplyHooks returns a copy of a Value, where each value matched by hooks is replaced by the result of the hook.
+// Eg, redacting passwords before logging a document:
+//
+//	json.ApplyHooks(doc, json.Hooks{Keys: map[string]json.Hook{"password": json.Redact}})
+//
+// The original Value is not modified. Returns an error if a path is not valid, or a hook returns an error.
+func ApplyHooks(jv Value, hooks Hooks) (Value, error) {
+	paths := make([]hookPath, 0, len(hooks.Paths))
+	for p, hook := range hooks.Paths {
+		if (p == "") || (len(regexHookPathParts.ReplaceAllLiteralString(p, "")) > 0) {
+			return invalidValue, fmt.Errorf(errIllegalHookPathMsg, p)
+		}
+
+		paths = append(paths, hookPath{parts: regexHookPathParts.FindAllString(p, -1), hook: hook})
+	}
+
+	// Map iteration order is random, so sort the paths to apply the most specific path that matches
+	sort.Slice(paths, func(i, j int) bool { return paths[i].moreSpecific(paths[j]) })
+
+	return applyHooks(jv, nil, "", paths, hooks.Keys)
+}
+
+// MustApplyHooks is a must version of ApplyHooks
+func MustApplyHooks(jv Value, hooks Hooks) Value {
+	return funcs.MustValue(ApplyHooks(jv, hooks))
+}
+
+// applyHooks applies hooks to a value at the path of the given parts, where key is the object key of the value, if any
+func applyHooks(jv Value, parts []string, key string, paths []hookPath, keys map[string]Hook) (Value, error) {
+	path := ""
+	for _, part := range parts {
+		path += part
+	}
+
+	for _, hp := range paths {
+		if hp.matches(parts) {
+			return hp.hook(path, jv)
+		}
+	}
+
+	if hook, haveIt := keys[key]; haveIt && (len(parts) > 0) && (parts[len(parts)-1][0] == '.') {
+		return hook(path, jv)
+	}
+
+	switch jv.Type() {
+	case Object:
+		mp := make(map[string]Value, len(jv.AsMap()))
+		for k, v := range jv.AsMap() {
+			var err error
+			if mp[k], err = applyHooks(v, append(parts[:len(parts):len(parts)], "."+k), k, paths, keys); err != nil {
+				return invalidValue, err
+			}
+		}
+
+		return Value{typ: Object, val: union.Of4T[map[string]Value, []Value, string, bool](mp)}, nil
+
+	case Array:
+		slc := make([]Value, len(jv.AsSlice()))
+		for i, v := range jv.AsSlice() {
+			var err error
+			if slc[i], err = applyHooks(v, append(parts[:len(parts):len(parts)], "["+strconv.Itoa(i)+"]"), "", paths, keys); err != nil {
+				return invalidValue, err
+			}
+		}
+
+		return Value{typ: Array, val: union.Of4U[map[string]Value, []Value, string, bool](slc)}, nil
+	}
+
+	return jv, nil
+}
@@ -0,0 +1,166 @@
+package json
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/tuple"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactHash_(t *testing.T) {
+	assert.Equal(t, tuple.Of2(StringToValue(Redacted), error(nil)), tuple.Of2(Redact("", StringToValue("secret"))))
+	assert.Equal(t, tuple.Of2(StringToValue(Redacted), error(nil)), tuple.Of2(Redact("", MustToValue(map[string]any{"a": 1}))))
+	assert.Equal(t, tuple.Of2(NullValue, error(nil)), tuple.Of2(Redact("", NullValue)))
+
+	hash := func(str string) Value {
+		sum := sha256.Sum256([]byte(str))
+		return StringToValue(hex.EncodeToString(sum[:]))
+	}
+
+	h := Hash("salt")
+	assert.Equal(t, tuple.Of2(hash("saltsecret"), error(nil)), tuple.Of2(h("", StringToValue("secret"))))
+	assert.Equal(t, tuple.Of2(hash("salt12"), error(nil)), tuple.Of2(h("", MustNumberToValue(12))))
+	assert.Equal(t, tuple.Of2(hash("salttrue"), error(nil)), tuple.Of2(h("", TrueValue)))
+	assert.Equal(t, tuple.Of2(hash(`salt{"a":"x","b":"y"}`), error(nil)), tuple.Of2(h("", MustToValue(map[string]any{"b": "y", "a": "x"}))))
+	assert.Equal(t, tuple.Of2(hash(`salt["x"]`), error(nil)), tuple.Of2(h("", MustToValue([]any{"x"}))))
+	assert.Equal(t, tuple.Of2(NullValue, error(nil)), tuple.Of2(h("", NullValue)))
+
+	// Different salts have different hashes
+	assert.NotEqual(t, funcs.MustValue(h("", StringToValue("secret"))), funcs.MustValue(Hash("other")("", StringToValue("secret"))))
+}
+
+func TestApplyHooks_(t *testing.T) {
+	var (
+		doc = MustToValue(map[string]any{
+			"name":     "Jane",
+			"password": "p1",
+			"users": []any{
+				map[string]any{"name": "a", "password": "p2", "ssn": "111"},
+				map[string]any{"name": "b", "password": nil, "ssn": "222"},
+			},
+			"tags": []any{"password"},
+		})
+		paths []string
+		upper = func(path string, jv Value) (Value, error) {
+			paths = append(paths, path)
+			return StringToValue("X" + jv.AsString()), nil
+		}
+	)
+
+	// Keys match at any depth, but not array elements that happen to have the same string value
+	assert.Equal(
+		t,
+		MustToValue(map[string]any{
+			"name":     "Jane",
+			"password": Redacted,
+			"users": []any{
+				map[string]any{"name": "a", "password": Redacted, "ssn": "111"},
+				map[string]any{"name": "b", "password": nil, "ssn": "222"},
+			},
+			"tags": []any{"password"},
+		}),
+		MustApplyHooks(doc, Hooks{Keys: map[string]Hook{"password": Redact}}),
+	)
+
+	// Paths with wildcards take precedence over keys, and receive the path of the value
+	assert.Equal(
+		t,
+		MustToValue(map[string]any{
+			"name":     "Jane",
+			"password": Redacted,
+			"users": []any{
+				map[string]any{"name": "Xa", "password": Redacted, "ssn": "X111"},
+				map[string]any{"name": "b", "password": nil, "ssn": "X222"},
+			},
+			"tags": []any{"Xpassword"},
+		}),
+		MustApplyHooks(doc, Hooks{
+			Paths: map[string]Hook{".users[*].ssn": upper, ".users[0].name": upper, ".tags[*]": upper},
+			Keys:  map[string]Hook{"password": Redact, "ssn": Redact},
+		}),
+	)
+	assert.ElementsMatch(t, []string{".users[0].name", ".users[0].ssn", ".users[1].ssn", ".tags[0]"}, paths)
+
+	// A replaced value is not descended into
+	assert.Equal(
+		t,
+		MustToValue(map[string]any{"name": "Jane", "password": Redacted, "users": Redacted, "tags": []any{"password"}}),
+		MustApplyHooks(doc, Hooks{Paths: map[string]Hook{".users": Redact}, Keys: map[string]Hook{"password": Redact}}),
+	)
+
+	// An object key wildcard
+	assert.Equal(
+		t,
+		MustToValue(map[string]any{
+			"name":     "Jane",
+			"password": "p1",
+			"users": []any{
+				map[string]any{"name": "a", "password": "p2", "ssn": Redacted},
+				map[string]any{"name": "b", "password": nil, "ssn": Redacted},
+			},
+			"tags": []any{"password"},
+		}),
+		MustApplyHooks(doc, Hooks{Paths: map[string]Hook{".*[*].ssn": Redact}}),
+	)
+
+	// The most specific of overlapping paths is applied, regardless of map order
+	tag := func(tg string) Hook {
+		return func(string, Value) (Value, error) { return StringToValue(tg), nil }
+	}
+
+	for i := 0; i < 20; i++ {
+		assert.Equal(
+			t,
+			MustToValue(map[string]any{
+				"name":     "Jane",
+				"password": "p1",
+				"users": []any{
+					map[string]any{"name": "exact", "password": "index", "ssn": "index"},
+					map[string]any{"name": "key", "password": "any", "ssn": "any"},
+				},
+				"tags": []any{"password"},
+			}),
+			MustApplyHooks(doc, Hooks{Paths: map[string]Hook{
+				".users[0].name": tag("exact"),
+				".users[0].*":    tag("index"),
+				".users[*].name": tag("key"),
+				".*[*].*":        tag("any"),
+			}}),
+		)
+	}
+
+	// The original is unchanged
+	assert.Equal(t, StringToValue("p1"), doc.AsMap()["password"])
+
+	// No hooks copies the value
+	assert.Equal(t, doc, MustApplyHooks(doc, Hooks{}))
+	assert.Equal(t, StringToValue("a"), MustApplyHooks(StringToValue("a"), Hooks{Keys: map[string]Hook{"a": Redact}}))
+
+	// Errors
+	err := fmt.Errorf("fail")
+	assert.Equal(
+		t,
+		tuple.Of2(invalidValue, err),
+		tuple.Of2(ApplyHooks(doc, Hooks{Paths: map[string]Hook{".users[1].name": func(string, Value) (Value, error) { return invalidValue, err }}})),
+	)
+
+	for _, p := range []string{"", "users", ".users[x]", ".users[]", ".users.[0]"} {
+		assert.Equal(t, tuple.Of2(invalidValue, fmt.Errorf(errIllegalHookPathMsg, p)), tuple.Of2(ApplyHooks(doc, Hooks{Paths: map[string]Hook{p: Redact}})))
+	}
+
+	funcs.TryTo(
+		func() {
+			MustApplyHooks(doc, Hooks{Paths: map[string]Hook{"users": Redact}})
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errIllegalHookPathMsg, "users"), e)
+		},
+	)
+}
@@ -0,0 +1,404 @@
+package parse
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/bantling/micro/encoding/json"
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/iter"
+)
+
+// Error constants
+var (
+	errLazyIncompleteMsg      = "The JSON value at offset %d is incomplete"
+	errLazyMismatchMsg        = "The JSON value at offset %d has a mismatched %c"
+	errLazyValueMsg           = "The JSON value at offset %d must be an object, array, string, number, boolean, or null"
+	errLazyTrailingMsg        = "The JSON value %s cannot be followed by %s"
+	errLazyNotObject          = fmt.Errorf("The LazyValue is not an object")
+	errLazyNotArray           = fmt.Errorf("The LazyValue is not an array")
+	errLazyIndexOutOfRangeMsg = "The LazyValue array index %d is out of range, the array has %d elements"
+)
+
+// LazyValue is a JSON value that retains the raw text of the value, and only parses as much of it as needed:
+// - An object only splits its raw text into keys and raw values when a key is first accessed
+// - An array only splits its raw text into raw elements when an element is first accessed
+// - Any value is only fully parsed into a json.Value when Value is called
+//
+// When only a few fields of a large document are needed, most of the document is only scanned for matching braces,
+// brackets, and quotes, without allocating any json.Values.
+//
+// The cost is that errors in a subtree are only detected when the subtree is accessed, except for mismatched braces,
+// brackets, and quotes, which are detected by ParseLazy.
+//
+// A LazyValue caches the results of accessing it, so it is not safe for concurrent use.
+type LazyValue struct {
+	raw    string
+	offset int
+	typ    json.Type
+	split  bool
+	keys   []string
+	object map[string]*LazyValue
+	array  []*LazyValue
+	value  *json.Value
+}
+
+// isWhitespace is true if the byte is JSON whitespace
+func isWhitespace(b byte) bool {
+	return (b == ' ') || (b == '\t') || (b == '\n') || (b == '\r')
+}
+
+// skipWhitespace returns the position of the first non-whitespace byte at or after pos
+func skipWhitespace(src string, pos int) int {
+	for (pos < len(src)) && isWhitespace(src[pos]) {
+		pos++
+	}
+
+	return pos
+}
+
+// skipString returns the position after the closing quote of the string that begins at pos.
+// Escapes are skipped over without validating them, so an escaped quote does not end the string.
+func skipString(src string, pos int) (int, error) {
+	for i := pos + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+
+	return 0, fmt.Errorf(errLazyIncompleteMsg, pos)
+}
+
+// skipValue returns the position after the value that begins at pos, which must not be whitespace.
+// Objects and arrays are skipped by matching braces and brackets, ignoring any inside strings.
+// Scalars are skipped by reading up to the next comma, closing brace, closing bracket, or whitespace.
+// The skipped text is not otherwise validated.
+func skipValue(src string, pos int) (int, error) {
+	if pos == len(src) {
+		return 0, fmt.Errorf(errLazyValueMsg, pos)
+	}
+
+	switch src[pos] {
+	case '"':
+		return skipString(src, pos)
+
+	case '{', '[':
+		closers := []byte{}
+
+		for i := pos; i < len(src); i++ {
+			switch c := src[i]; c {
+			case '"':
+				end, err := skipString(src, i)
+				if err != nil {
+					return 0, err
+				}
+				i = end - 1
+
+			case '{':
+				closers = append(closers, '}')
+
+			case '[':
+				closers = append(closers, ']')
+
+			case '}', ']':
+				if closers[len(closers)-1] != c {
+					return 0, fmt.Errorf(errLazyMismatchMsg, i, c)
+				}
+
+				if closers = closers[:len(closers)-1]; len(closers) == 0 {
+					return i + 1, nil
+				}
+			}
+		}
+
+		return 0, fmt.Errorf(errLazyIncompleteMsg, pos)
+
+	case ',', ':', '}', ']':
+		return 0, fmt.Errorf(errLazyValueMsg, pos)
+	}
+
+	end := pos
+	for (end < len(src)) && (!isWhitespace(src[end])) && (src[end] != ',') && (src[end] != '}') && (src[end] != ']') {
+		end++
+	}
+
+	return end, nil
+}
+
+// newLazyValue constructs a LazyValue for the raw text of a value, which must be the result of skipValue.
+// The type of a scalar is only a guess based on the first byte, Value verifies the guess.
+func newLazyValue(src string, pos, end int) *LazyValue {
+	lv := &LazyValue{raw: src[pos:end], offset: pos}
+
+	switch lv.raw[0] {
+	case '{':
+		lv.typ = json.Object
+	case '[':
+		lv.typ = json.Array
+	case '"':
+		lv.typ = json.String
+	case 't', 'f':
+		lv.typ = json.Boolean
+	case 'n':
+		lv.typ = json.Null
+	default:
+		lv.typ = json.Number
+	}
+
+	return lv
+}
+
+// ParseLazy reads a JSON document, and returns it as a LazyValue.
+// The document is only scanned to verify that braces, brackets, and quotes are matched.
+// The result is (LazyValue, nil) if the document passes the scan, else it is (nil, error).
+func ParseLazy(src io.Reader) (*LazyValue, error) {
+	var (
+		data []byte
+		err  error
+		end  int
+	)
+
+	if data, err = io.ReadAll(src); err != nil {
+		return nil, err
+	}
+
+	doc := string(data)
+
+	// First non-whitespace must be a { or [
+	pos := skipWhitespace(doc, 0)
+	if pos == len(doc) {
+		return nil, errEmptyDocument
+	}
+
+	if (doc[pos] != '{') && (doc[pos] != '[') {
+		return nil, errObjectOrArrayRequired
+	}
+
+	if end, err = skipValue(doc, pos); err != nil {
+		return nil, err
+	}
+
+	return newLazyValue(doc, pos, end), nil
+}
+
+// MustParseLazy is a must version of ParseLazy
+func MustParseLazy(src io.Reader) *LazyValue {
+	return funcs.MustValue(ParseLazy(src))
+}
+
+// Type returns the type of the value
+func (lv *LazyValue) Type() json.Type {
+	return lv.typ
+}
+
+// Raw returns the raw text of the value
+func (lv *LazyValue) Raw() string {
+	return lv.raw
+}
+
+// splitObject splits the raw text of an object into keys and raw values, only decoding keys
+func (lv *LazyValue) splitObject() error {
+	var (
+		raw    = lv.raw
+		pos    = skipWhitespace(raw, 1)
+		keys   = []string{}
+		object = map[string]*LazyValue{}
+	)
+
+	if raw[pos] != '}' {
+		for {
+			// Must have a string key
+			if raw[pos] != '"' {
+				return errObjectRequiresKeyOrBrace
+			}
+
+			end, err := skipString(raw, pos)
+			if err != nil {
+				return err
+			}
+
+			var keyTok token
+			if keyTok, err = lexString(iter.OfStringAsRunes(raw[pos:end])); err != nil {
+				return err
+			}
+
+			key := keyTok.value
+			if _, haveIt := object[key]; haveIt {
+				return fmt.Errorf(errObjectDuplicateKeyMsg, key)
+			}
+
+			// Expect colon separator
+			if pos = skipWhitespace(raw, end); raw[pos] != ':' {
+				return fmt.Errorf(errObjectKeyRequiresColonMsg, key)
+			}
+
+			// Expect value for key
+			pos = skipWhitespace(raw, pos+1)
+			if end, err = skipValue(raw, pos); err != nil {
+				return fmt.Errorf(errObjectKeyRequiresValueMsg, key)
+			}
+
+			value := newLazyValue(raw, pos, end)
+			value.offset += lv.offset
+			keys, object[key] = append(keys, key), value
+
+			// Expect a comma or closing brace
+			pos = skipWhitespace(raw, end)
+			if raw[pos] == '}' {
+				break
+			}
+
+			if raw[pos] != ',' {
+				return fmt.Errorf(errObjectKeyValueRequiresCommaOrBraceMsg, key)
+			}
+
+			pos = skipWhitespace(raw, pos+1)
+		}
+	}
+
+	lv.split, lv.keys, lv.object = true, keys, object
+	return nil
+}
+
+// splitArray splits the raw text of an array into raw elements
+func (lv *LazyValue) splitArray() error {
+	var (
+		raw   = lv.raw
+		pos   = skipWhitespace(raw, 1)
+		array = []*LazyValue{}
+	)
+
+	if raw[pos] != ']' {
+		for {
+			end, err := skipValue(raw, pos)
+			if err != nil {
+				return funcs.Ternary(len(array) == 0, errArrayRequiresValueOrBracket, errArrayRequiresValue)
+			}
+
+			value := newLazyValue(raw, pos, end)
+			value.offset += lv.offset
+			array = append(array, value)
+
+			// Expect a comma or closing bracket
+			pos = skipWhitespace(raw, end)
+			if raw[pos] == ']' {
+				break
+			}
+
+			if raw[pos] != ',' {
+				return errArrayRequiresCommaOrBracket
+			}
+
+			pos = skipWhitespace(raw, pos+1)
+		}
+	}
+
+	lv.split, lv.array = true, array
+	return nil
+}
+
+// Keys returns the keys of an object, in the order they occur in the document.
+// Returns an error if the value is not a valid object.
+func (lv *LazyValue) Keys() ([]string, error) {
+	if lv.typ != json.Object {
+		return nil, errLazyNotObject
+	}
+
+	if !lv.split {
+		if err := lv.splitObject(); err != nil {
+			return nil, err
+		}
+	}
+
+	return lv.keys, nil
+}
+
+// Get returns the value of an object key, and true if the key exists.
+// Only the raw text of the object is split, the key value is not parsed.
+// Returns an error if the value is not a valid object.
+func (lv *LazyValue) Get(key string) (*LazyValue, bool, error) {
+	if _, err := lv.Keys(); err != nil {
+		return nil, false, err
+	}
+
+	value, haveIt := lv.object[key]
+	return value, haveIt, nil
+}
+
+// Len returns the number of elements of an array.
+// Returns an error if the value is not a valid array.
+func (lv *LazyValue) Len() (int, error) {
+	if lv.typ != json.Array {
+		return 0, errLazyNotArray
+	}
+
+	if !lv.split {
+		if err := lv.splitArray(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(lv.array), nil
+}
+
+// Index returns the array element at the given index.
+// Only the raw text of the array is split, the element is not parsed.
+// Returns an error if the value is not a valid array, or the index is out of range.
+func (lv *LazyValue) Index(index int) (*LazyValue, error) {
+	n, err := lv.Len()
+	if err != nil {
+		return nil, err
+	}
+
+	if (index < 0) || (index >= n) {
+		return nil, fmt.Errorf(errLazyIndexOutOfRangeMsg, index, n)
+	}
+
+	return lv.array[index], nil
+}
+
+// Value fully parses the raw text into a json.Value, the same way as Parse.
+// Returns an error if the raw text is not a valid JSON value.
+func (lv *LazyValue) Value() (json.Value, error) {
+	if lv.value != nil {
+		return *lv.value, nil
+	}
+
+	var (
+		it    = lexer(iter.OfStringAsRunes(lv.raw))
+		value json.Value
+		zv    json.Value
+		err   error
+	)
+
+	if value, err = parseValue(it); err != nil {
+		return zv, err
+	}
+
+	if value.Type() == json.Invalid {
+		return zv, fmt.Errorf(errLazyValueMsg, lv.offset)
+	}
+
+	// A scalar like 1"a" lexes as a number followed by a string
+	var tok token
+	if tok, err = it.Next(); err != iter.EOI {
+		if err != nil {
+			return zv, err
+		}
+
+		return zv, fmt.Errorf(errLazyTrailingMsg, lv.raw, tok.value)
+	}
+
+	lv.value = &value
+	return value, nil
+}
+
+// MustValue is a must version of Value
+func (lv *LazyValue) MustValue() json.Value {
+	return funcs.MustValue(lv.Value())
+}
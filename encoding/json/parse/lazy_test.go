@@ -0,0 +1,205 @@
+package parse
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bantling/micro/encoding/json"
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/io"
+	"github.com/bantling/micro/tuple"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipValue_(t *testing.T) {
+	for _, src := range []string{`"a\"b"`, `{}`, `{"a": [1, {"b": "}"}]}`, `[[], "]", {}]`, `123`, `true`} {
+		assert.Equal(t, tuple.Of2(len(src), error(nil)), tuple.Of2(skipValue(src+" ", 0)))
+	}
+
+	// Scalars end at a delimiter
+	assert.Equal(t, tuple.Of2(3, error(nil)), tuple.Of2(skipValue(`1.5,`, 0)))
+	assert.Equal(t, tuple.Of2(4, error(nil)), tuple.Of2(skipValue(`null}`, 0)))
+
+	// Errors
+	assert.Equal(t, tuple.Of2(0, fmt.Errorf(errLazyValueMsg, 0)), tuple.Of2(skipValue(``, 0)))
+	assert.Equal(t, tuple.Of2(0, fmt.Errorf(errLazyValueMsg, 0)), tuple.Of2(skipValue(`,`, 0)))
+	assert.Equal(t, tuple.Of2(0, fmt.Errorf(errLazyIncompleteMsg, 0)), tuple.Of2(skipValue(`"a`, 0)))
+	assert.Equal(t, tuple.Of2(0, fmt.Errorf(errLazyIncompleteMsg, 0)), tuple.Of2(skipValue(`{"a": [1]`, 0)))
+	assert.Equal(t, tuple.Of2(0, fmt.Errorf(errLazyIncompleteMsg, 6)), tuple.Of2(skipValue(`{"a": "b}`, 0)))
+	assert.Equal(t, tuple.Of2(0, fmt.Errorf(errLazyMismatchMsg, 8, '}')), tuple.Of2(skipValue(`{"a": [1}]`, 0)))
+}
+
+func TestParseLazy_(t *testing.T) {
+	doc := MustParseLazy(strings.NewReader(` {"str": "ab", "num": 1.5, "arr": [true, null, {"k": "v"}], "obj": {}} `))
+	assert.Equal(t, json.Object, doc.Type())
+	assert.Equal(t, `{"str": "ab", "num": 1.5, "arr": [true, null, {"k": "v"}], "obj": {}}`, doc.Raw())
+	assert.Equal(t, tuple.Of2([]string{"str", "num", "arr", "obj"}, error(nil)), tuple.Of2(doc.Keys()))
+
+	// Object keys
+	str, haveIt, err := doc.Get("str")
+	assert.Equal(t, tuple.Of2(true, error(nil)), tuple.Of2(haveIt, err))
+	assert.Equal(t, json.String, str.Type())
+	assert.Equal(t, `"ab"`, str.Raw())
+	assert.Equal(t, json.StringToValue("ab"), str.MustValue())
+
+	num, _, _ := doc.Get("num")
+	assert.Equal(t, json.Number, num.Type())
+	assert.Equal(t, json.MustNumberToValue(json.NumberString("1.5")), num.MustValue())
+
+	_, haveIt, err = doc.Get("missing")
+	assert.Equal(t, tuple.Of2(false, error(nil)), tuple.Of2(haveIt, err))
+
+	// Array elements
+	arr, _, _ := doc.Get("arr")
+	assert.Equal(t, json.Array, arr.Type())
+	assert.Equal(t, tuple.Of2(3, error(nil)), tuple.Of2(arr.Len()))
+
+	elem, err := arr.Index(0)
+	assert.Nil(t, err)
+	assert.Equal(t, json.Boolean, elem.Type())
+	assert.Equal(t, json.TrueValue, elem.MustValue())
+
+	elem, _ = arr.Index(1)
+	assert.Equal(t, json.Null, elem.Type())
+	assert.Equal(t, json.NullValue, elem.MustValue())
+
+	elem, _ = arr.Index(2)
+	k, _, _ := elem.Get("k")
+	assert.Equal(t, json.StringToValue("v"), k.MustValue())
+
+	assert.Equal(t, tuple.Of2[*LazyValue, error](nil, fmt.Errorf(errLazyIndexOutOfRangeMsg, 3, 3)), tuple.Of2(arr.Index(3)))
+	assert.Equal(t, tuple.Of2[*LazyValue, error](nil, fmt.Errorf(errLazyIndexOutOfRangeMsg, -1, 3)), tuple.Of2(arr.Index(-1)))
+
+	// Empty object and array
+	obj, _, _ := doc.Get("obj")
+	assert.Equal(t, tuple.Of2([]string{}, error(nil)), tuple.Of2(obj.Keys()))
+	assert.Equal(t, tuple.Of2(0, error(nil)), tuple.Of2(MustParseLazy(strings.NewReader(`[ ]`)).Len()))
+
+	// Whole document, cached after first call
+	expected := json.MustMapToValue(map[string]any{
+		"str": "ab",
+		"num": json.NumberString("1.5"),
+		"arr": []any{true, nil, map[string]any{"k": "v"}},
+		"obj": map[string]any{},
+	})
+	assert.Equal(t, expected, doc.MustValue())
+	assert.Equal(t, expected, doc.MustValue())
+
+	// Wrong types
+	assert.Equal(t, tuple.Of2([]string(nil), errLazyNotObject), tuple.Of2(arr.Keys()))
+	assert.Equal(t, tuple.Of2(0, errLazyNotArray), tuple.Of2(doc.Len()))
+
+	_, _, err = arr.Get("a")
+	assert.Equal(t, errLazyNotObject, err)
+
+	_, err = doc.Index(0)
+	assert.Equal(t, errLazyNotArray, err)
+}
+
+func TestParseLazyErrors_(t *testing.T) {
+	anErr := fmt.Errorf("An err")
+
+	// Document errors
+	assert.Equal(t, tuple.Of2[*LazyValue, error](nil, errEmptyDocument), tuple.Of2(ParseLazy(strings.NewReader(` `))))
+	assert.Equal(t, tuple.Of2[*LazyValue, error](nil, anErr), tuple.Of2(ParseLazy(io.NewErrorReader([]byte(`{`), anErr))))
+	assert.Equal(t, tuple.Of2[*LazyValue, error](nil, errObjectOrArrayRequired), tuple.Of2(ParseLazy(strings.NewReader(`"a"`))))
+	assert.Equal(t, tuple.Of2[*LazyValue, error](nil, fmt.Errorf(errLazyIncompleteMsg, 0)), tuple.Of2(ParseLazy(strings.NewReader(`{"a": 1`))))
+
+	funcs.TryTo(
+		func() {
+			MustParseLazy(strings.NewReader(``))
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, errEmptyDocument, e) },
+	)
+
+	// Object errors are only detected on access
+	keysErr := func(src string) error {
+		_, err := MustParseLazy(strings.NewReader(src)).Keys()
+		return err
+	}
+
+	assert.Equal(t, errObjectRequiresKeyOrBrace, keysErr(`{1: 2}`))
+	assert.Equal(t, fmt.Errorf(errIllegalStringEscapeMsg, `\x`), keysErr(`{"\x": 2}`))
+	assert.Equal(t, fmt.Errorf(errObjectDuplicateKeyMsg, "a"), keysErr(`{"a": 1, "a": 2}`))
+	assert.Equal(t, fmt.Errorf(errObjectKeyRequiresColonMsg, "a"), keysErr(`{"a" 1}`))
+	assert.Equal(t, fmt.Errorf(errObjectKeyRequiresValueMsg, "a"), keysErr(`{"a": }`))
+	assert.Equal(t, fmt.Errorf(errObjectKeyValueRequiresCommaOrBraceMsg, "a"), keysErr(`{"a": 1 "b": 2}`))
+	assert.Equal(t, errObjectRequiresKeyOrBrace, keysErr(`{"a": 1, }`))
+
+	// Array errors are only detected on access
+	lenErr := func(src string) error {
+		_, err := MustParseLazy(strings.NewReader(src)).Len()
+		return err
+	}
+
+	assert.Equal(t, errArrayRequiresValueOrBracket, lenErr(`[,]`))
+	assert.Equal(t, errArrayRequiresValue, lenErr(`[1, ]`))
+	assert.Equal(t, errArrayRequiresCommaOrBracket, lenErr(`[1 2]`))
+
+	// Value errors are only detected on access
+	doc := MustParseLazy(strings.NewReader(`[tru, 1x, "a` + "\x01" + `", {"a": 1 2}, 1"a"]`))
+
+	valueErr := func(i int) error {
+		elem, err := doc.Index(i)
+		assert.Nil(t, err)
+
+		_, err = elem.Value()
+		return err
+	}
+
+	assert.Equal(t, fmt.Errorf(errInvalidBooleanNullMsg, "tru"), valueErr(0))
+	assert.NotNil(t, valueErr(1))
+	assert.Equal(t, fmt.Errorf(errControlCharInStringMsg, 1), valueErr(2))
+	assert.NotNil(t, valueErr(3))
+	assert.Equal(t, fmt.Errorf(errLazyTrailingMsg, `1"a"`, "a"), valueErr(4))
+
+	funcs.TryTo(
+		func() {
+			elem, _ := doc.Index(0)
+			elem.MustValue()
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf(errInvalidBooleanNullMsg, "tru"), e) },
+	)
+}
+
+func BenchmarkParse_(b *testing.B) {
+	doc := benchDoc()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		val := MustParse(strings.NewReader(doc))
+		_ = val.AsMap()["id"]
+	}
+}
+
+func BenchmarkParseLazy_(b *testing.B) {
+	doc := benchDoc()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		val, _, _ := MustParseLazy(strings.NewReader(doc)).Get("id")
+		_ = val.MustValue()
+	}
+}
+
+// benchDoc returns a document with a large array of records, where the interesting "id" key comes last
+func benchDoc() string {
+	var sb strings.Builder
+	sb.WriteString(`{"records": [`)
+
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+
+		fmt.Fprintf(&sb, `{"name": "record %d", "tags": ["a", "b", "c"], "value": %d.5, "active": true}`, i, i)
+	}
+
+	sb.WriteString(`], "id": 17}`)
+	return sb.String()
+}
@@ -253,12 +253,14 @@ func parseArray(it iter.Iter[token]) iter.Iter[json.Value] {
 // Useful for cases like writing JSON data to a database, where the JSON input could contain a large number of records,
 // and it is preferable to store each record one at a time, or perhaps in batches of some fixed maximum size.
 func Iterate(src io.Reader) iter.Iter[json.Value] {
+	// Reader > iter[rune] > iter[token]
+	return iterate(lexer(iter.OfReaderAsRunes(src)))
+}
+
+// iterate is the implementation of Iterate for an iter of tokens
+func iterate(it iter.Iter[token]) iter.Iter[json.Value] {
 	// First lexical element must be a { or [
-	var (
-		// Reader > iter[rune] > iter[token]
-		it            = lexer(iter.OfReaderAsRunes(src))
-		firstTok, err = it.Next()
-	)
+	firstTok, err := it.Next()
 
 	// Die if empty
 	if err != nil {
@@ -0,0 +1,388 @@
+package parse
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bantling/micro/encoding/json"
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/iter"
+)
+
+// Error constants
+var (
+	errViolationMsg        = "JSON violation at offset %d, path %s: %s"
+	errMaxBytesMsg         = "the document exceeds the maximum of %d bytes"
+	errMaxDepthMsg         = "the nesting exceeds the maximum depth of %d"
+	errMaxStringLengthMsg  = "the string length of %d exceeds the maximum of %d characters"
+	errMaxNumberLengthMsg  = "the number %s exceeds the maximum of %d characters"
+	errMaxArrayElementsMsg = "the array exceeds the maximum of %d elements"
+	errMaxObjectKeysMsg    = "the object exceeds the maximum of %d keys"
+)
+
+// Limits are size limits on a JSON document, that are checked as the document is read, before it is materialized into
+// json.Values. A zero field means there is no limit.
+type Limits struct {
+	// MaxBytes is the maximum size of the document in bytes
+	MaxBytes uint
+	// MaxDepth is the maximum nesting of objects and arrays, where the top level object or array is depth 1
+	MaxDepth uint
+	// MaxStringLength is the maximum number of characters in a string, including object keys, after decoding escapes
+	MaxStringLength uint
+	// MaxNumberLength is the maximum number of characters in a number
+	MaxNumberLength uint
+	// MaxArrayElements is the maximum number of elements in any one array
+	MaxArrayElements uint
+	// MaxObjectKeys is the maximum number of keys in any one object
+	MaxObjectKeys uint
+}
+
+// Violation describes a JSON value that violates a Limit, at the byte offset of the start of the value, and a path to
+// the value in the same form as json.Search (eg, .records[3].name). The path of the top level value is empty.
+type Violation struct {
+	Offset int
+	Path   string
+	Msg    string
+}
+
+// Error is the error interface
+func (v Violation) Error() string {
+	return fmt.Sprintf(errViolationMsg, v.Offset, v.Path, v.Msg)
+}
+
+// offsetIter is an iter of runes that tracks the byte offset of the next rune
+type offsetIter struct {
+	iter.Iter[rune]
+	offset int
+}
+
+// Next returns the next rune, advancing the offset
+func (oi *offsetIter) Next() (rune, error) {
+	r, err := oi.Iter.Next()
+	if err == nil {
+		oi.offset += utf8.RuneLen(r)
+	}
+
+	return r, err
+}
+
+// Unread unreads a rune, moving the offset back
+func (oi *offsetIter) Unread(r rune) {
+	oi.offset -= utf8.RuneLen(r)
+	oi.Iter.Unread(r)
+}
+
+// validateState is the state of a validateFrame, which is what token it expects next
+type validateState uint
+
+const (
+	sFirst        validateState = iota // value, key, or close after the opening bracket or brace
+	sKey                               // key after a comma in an object
+	sColon                             // colon after a key
+	sValue                             // value after a colon, or after a comma in an array
+	sCommaOrClose                      // comma or close after a value
+)
+
+// validateFrame is an object or array being validated
+type validateFrame struct {
+	object bool
+	state  validateState
+	count  uint
+	key    string
+	keys   map[string]bool
+}
+
+// validator checks the grammar of a stream of tokens the same way the parser does, and checks Limits.
+// Unlike the parser, it only keeps a stack of the objects and arrays it is in, rather than the values.
+type validator struct {
+	limits     Limits
+	max        uint
+	stack      []*validateFrame
+	started    bool
+	done       bool
+	violations []Violation
+}
+
+// path returns the path of the current value
+func (v *validator) path() string {
+	var sb strings.Builder
+	for _, f := range v.stack {
+		if f.count == 0 {
+			break
+		}
+
+		if f.object {
+			sb.WriteString(".")
+			sb.WriteString(f.key)
+		} else {
+			fmt.Fprintf(&sb, "[%d]", f.count-1)
+		}
+	}
+
+	return sb.String()
+}
+
+// violate records a violation, and returns true if the maximum number of violations is reached
+func (v *validator) violate(offset int, msg string, args ...any) bool {
+	v.violations = append(v.violations, Violation{offset, v.path(), fmt.Sprintf(msg, args...)})
+	return (v.max > 0) && (uint(len(v.violations)) >= v.max)
+}
+
+// syntaxErr returns the same error the parser returns for an unexpected token in the current state
+func (v *validator) syntaxErr() error {
+	f := v.stack[len(v.stack)-1]
+	if f.object {
+		switch f.state {
+		case sColon:
+			return fmt.Errorf(errObjectKeyRequiresColonMsg, f.key)
+		case sValue:
+			return fmt.Errorf(errObjectKeyRequiresValueMsg, f.key)
+		case sCommaOrClose:
+			return fmt.Errorf(errObjectKeyValueRequiresCommaOrBraceMsg, f.key)
+		}
+
+		return errObjectRequiresKeyOrBrace
+	}
+
+	switch f.state {
+	case sFirst:
+		return errArrayRequiresValueOrBracket
+	case sValue:
+		return errArrayRequiresValue
+	}
+
+	return errArrayRequiresCommaOrBracket
+}
+
+// checkString checks the length of a string
+func (v *validator) checkString(tok token, offset int) bool {
+	if n := uint(utf8.RuneCountInString(tok.value)); (v.limits.MaxStringLength > 0) && (n > v.limits.MaxStringLength) {
+		return v.violate(offset, errMaxStringLengthMsg, n, v.limits.MaxStringLength)
+	}
+
+	return false
+}
+
+// value handles a token that begins a value, returning true if the maximum number of violations is reached
+func (v *validator) value(tok token, offset int) bool {
+	// Count the element of an array
+	if len(v.stack) > 0 {
+		f := v.stack[len(v.stack)-1]
+		f.state = sCommaOrClose
+
+		if !f.object {
+			if f.count++; (v.limits.MaxArrayElements > 0) && (f.count == v.limits.MaxArrayElements+1) {
+				if v.violate(offset, errMaxArrayElementsMsg, v.limits.MaxArrayElements) {
+					return true
+				}
+			}
+		}
+	}
+
+	switch tok.typ {
+	case tOBrace, tOBracket:
+		v.stack = append(v.stack, &validateFrame{object: tok.typ == tOBrace, keys: map[string]bool{}})
+
+		// Only report the first level that is too deep
+		if depth := uint(len(v.stack)); (v.limits.MaxDepth > 0) && (depth == v.limits.MaxDepth+1) {
+			return v.violate(offset, errMaxDepthMsg, v.limits.MaxDepth)
+		}
+
+	case tString:
+		return v.checkString(tok, offset)
+
+	case tNumber:
+		if n := uint(len(tok.value)); (v.limits.MaxNumberLength > 0) && (n > v.limits.MaxNumberLength) {
+			return v.violate(offset, errMaxNumberLengthMsg, tok.value, v.limits.MaxNumberLength)
+		}
+	}
+
+	return false
+}
+
+// token validates the next token, which begins at the given offset.
+// Returns (true, nil) if the maximum number of violations is reached, or (false, error) for a syntax error.
+func (v *validator) token(tok token, offset int) (bool, error) {
+	isValue := (tok.typ == tOBrace) || (tok.typ == tOBracket) || (tok.typ == tString) || (tok.typ == tNumber) ||
+		(tok.typ == tBoolean) || (tok.typ == tNull)
+
+	// The document must begin with a brace or bracket
+	if !v.started {
+		if (tok.typ != tOBrace) && (tok.typ != tOBracket) {
+			return false, errObjectOrArrayRequired
+		}
+
+		v.started = true
+		return v.value(tok, offset), nil
+	}
+
+	f := v.stack[len(v.stack)-1]
+
+	switch {
+	// Close an empty object or array, or after a value
+	case ((tok.typ == tCBrace) && f.object) || ((tok.typ == tCBracket) && (!f.object)):
+		if (f.state != sFirst) && (f.state != sCommaOrClose) {
+			return false, v.syntaxErr()
+		}
+
+		v.stack = v.stack[:len(v.stack)-1]
+		v.done = len(v.stack) == 0
+
+	case tok.typ == tComma:
+		if f.state != sCommaOrClose {
+			return false, v.syntaxErr()
+		}
+
+		f.state = funcs.Ternary(f.object, sKey, sValue)
+
+	// Object key
+	case f.object && ((f.state == sFirst) || (f.state == sKey)):
+		if tok.typ != tString {
+			return false, v.syntaxErr()
+		}
+
+		if f.keys[tok.value] {
+			return false, fmt.Errorf(errObjectDuplicateKeyMsg, tok.value)
+		}
+
+		f.keys[tok.value], f.key, f.state = true, tok.value, sColon
+
+		if f.count++; (v.limits.MaxObjectKeys > 0) && (f.count == v.limits.MaxObjectKeys+1) {
+			if v.violate(offset, errMaxObjectKeysMsg, v.limits.MaxObjectKeys) {
+				return true, nil
+			}
+		}
+
+		return v.checkString(tok, offset), nil
+
+	case tok.typ == tColon:
+		if f.state != sColon {
+			return false, v.syntaxErr()
+		}
+
+		f.state = sValue
+
+	// Value of a key, or element of an array
+	case isValue && ((f.state == sValue) || ((!f.object) && (f.state == sFirst))):
+		return v.value(tok, offset), nil
+
+	default:
+		return false, v.syntaxErr()
+	}
+
+	return false, nil
+}
+
+// nextToken returns the next token and the offset it begins at
+func nextToken(oi *offsetIter) (token, int, error) {
+	var zv token
+
+	// Skip whitespace, so that the offset is the start of the token
+	for {
+		r, err := oi.Next()
+		if err != nil {
+			return zv, 0, err
+		}
+
+		if !((r == ' ') || (r == '\n') || (r == '\r') || (r == '\t')) {
+			oi.Unread(r)
+			break
+		}
+	}
+
+	offset := oi.offset
+	tok, err := lex(oi)
+	return tok, offset, err
+}
+
+// checkBytes returns true if the document has exceeded the maximum number of bytes, after recording a violation
+func (v *validator) checkBytes(oi *offsetIter) bool {
+	if (v.limits.MaxBytes > 0) && (uint(oi.offset) > v.limits.MaxBytes) {
+		// The whole document is in violation, so there is no path
+		v.violations = append(v.violations, Violation{int(v.limits.MaxBytes), "", fmt.Sprintf(errMaxBytesMsg, v.limits.MaxBytes)})
+		return true
+	}
+
+	return false
+}
+
+// Validate reads a JSON document, checking the JSON grammar and the given Limits, without materializing any
+// json.Values. Syntax errors use the same messages as Parse, but Validate is stricter: a trailing comma or a missing
+// value is always an error. Reading stops as soon as n violations are found (0 means no maximum), the document exceeds
+// Limits.MaxBytes, or a syntax error occurs.
+//
+// The result is one of:
+// - (nil, nil) if the document is valid and within the limits
+// - (violations, nil) if the document has no syntax errors before the last violation
+// - (violations found so far, error) if a syntax error or reading problem occurs
+func Validate(src io.Reader, limits Limits, n uint) ([]Violation, error) {
+	var (
+		v  = &validator{limits: limits, max: n}
+		oi = &offsetIter{Iter: iter.OfReaderAsRunes(src)}
+	)
+
+	for !v.done {
+		tok, offset, err := nextToken(oi)
+		if err != nil {
+			if err == iter.EOI {
+				err = funcs.TernaryResult(v.started, v.syntaxErr, func() error { return errEmptyDocument })
+			}
+
+			return v.violations, err
+		}
+
+		if v.checkBytes(oi) {
+			break
+		}
+
+		stop, err := v.token(tok, offset)
+		if err != nil {
+			return v.violations, err
+		}
+
+		if stop {
+			break
+		}
+	}
+
+	return v.violations, nil
+}
+
+// IterateLimited is the same as Iterate, except that the document is checked against the given Limits and the grammar
+// of Validate as it is read. The first Violation or syntax error is returned as the error of the iter, so that a document
+// that exceeds the limits is rejected as soon as possible, and only the values before the violation are materialized.
+func IterateLimited(src io.Reader, limits Limits) iter.Iter[json.Value] {
+	var (
+		v  = &validator{limits: limits, max: 1}
+		oi = &offsetIter{Iter: iter.OfReaderAsRunes(src)}
+	)
+
+	return iterate(iter.OfIter(func() (token, error) {
+		var zv token
+
+		tok, offset, err := nextToken(oi)
+		if err != nil {
+			// EOI is handled by the parser
+			return zv, err
+		}
+
+		if v.checkBytes(oi) {
+			return zv, v.violations[0]
+		}
+
+		stop, err := v.token(tok, offset)
+		if err != nil {
+			return zv, err
+		}
+
+		if stop {
+			return zv, v.violations[0]
+		}
+
+		return tok, nil
+	}))
+}
@@ -0,0 +1,219 @@
+package parse
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bantling/micro/encoding/json"
+	"github.com/bantling/micro/io"
+	"github.com/bantling/micro/iter"
+	"github.com/bantling/micro/stream"
+	"github.com/bantling/micro/tuple"
+	"github.com/bantling/micro/union"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOffsetIter_(t *testing.T) {
+	oi := &offsetIter{Iter: iter.OfStringAsRunes("aé€")}
+
+	r, _ := oi.Next()
+	assert.Equal(t, tuple.Of2('a', 1), tuple.Of2(r, oi.offset))
+
+	r, _ = oi.Next()
+	assert.Equal(t, tuple.Of2('é', 3), tuple.Of2(r, oi.offset))
+
+	oi.Unread(r)
+	assert.Equal(t, 1, oi.offset)
+
+	oi.Next()
+	r, _ = oi.Next()
+	assert.Equal(t, tuple.Of2('€', 6), tuple.Of2(r, oi.offset))
+
+	_, err := oi.Next()
+	assert.Equal(t, tuple.Of2(iter.EOI, 6), tuple.Of2(err, oi.offset))
+}
+
+func TestValidate_(t *testing.T) {
+	var (
+		doc      = `{"name": "abcdef", "tags": [1, 2, 3, 4], "deep": [[[]]], "n": 123456}`
+		validate = func(limits Limits, n uint) tuple.Two[[]Violation, error] {
+			return tuple.Of2(Validate(strings.NewReader(doc), limits, n))
+		}
+	)
+
+	// No limits
+	assert.Equal(t, tuple.Of2([]Violation(nil), error(nil)), validate(Limits{}, 0))
+
+	// Within limits
+	assert.Equal(
+		t,
+		tuple.Of2([]Violation(nil), error(nil)),
+		validate(Limits{MaxBytes: 70, MaxDepth: 4, MaxStringLength: 6, MaxNumberLength: 6, MaxArrayElements: 4, MaxObjectKeys: 4}, 0),
+	)
+
+	// Each limit
+	assert.Equal(
+		t,
+		tuple.Of2([]Violation{{9, ".name", fmt.Sprintf(errMaxStringLengthMsg, 6, 5)}}, error(nil)),
+		validate(Limits{MaxStringLength: 5}, 0),
+	)
+	assert.Equal(
+		t,
+		tuple.Of2([]Violation{{62, ".n", fmt.Sprintf(errMaxNumberLengthMsg, "123456", 5)}}, error(nil)),
+		validate(Limits{MaxNumberLength: 5}, 0),
+	)
+	assert.Equal(
+		t,
+		tuple.Of2([]Violation{{37, ".tags[3]", fmt.Sprintf(errMaxArrayElementsMsg, 3)}}, error(nil)),
+		validate(Limits{MaxArrayElements: 3}, 0),
+	)
+	assert.Equal(
+		t,
+		tuple.Of2([]Violation{{57, ".n", fmt.Sprintf(errMaxObjectKeysMsg, 3)}}, error(nil)),
+		validate(Limits{MaxObjectKeys: 3}, 0),
+	)
+	assert.Equal(
+		t,
+		tuple.Of2([]Violation{{51, ".deep[0][0]", fmt.Sprintf(errMaxDepthMsg, 3)}}, error(nil)),
+		validate(Limits{MaxDepth: 3}, 0),
+	)
+	assert.Equal(
+		t,
+		tuple.Of2([]Violation{{60, "", fmt.Sprintf(errMaxBytesMsg, 60)}}, error(nil)),
+		validate(Limits{MaxBytes: 60}, 0),
+	)
+
+	// Multiple violations, all of them or the first n
+	var (
+		limits = Limits{MaxStringLength: 3, MaxArrayElements: 2, MaxDepth: 2}
+		all    = []Violation{
+			{1, ".name", fmt.Sprintf(errMaxStringLengthMsg, 4, 3)},
+			{9, ".name", fmt.Sprintf(errMaxStringLengthMsg, 6, 3)},
+			{19, ".tags", fmt.Sprintf(errMaxStringLengthMsg, 4, 3)},
+			{34, ".tags[2]", fmt.Sprintf(errMaxArrayElementsMsg, 2)},
+			{41, ".deep", fmt.Sprintf(errMaxStringLengthMsg, 4, 3)},
+			{50, ".deep[0]", fmt.Sprintf(errMaxDepthMsg, 2)},
+		}
+	)
+
+	assert.Equal(t, tuple.Of2(all, error(nil)), validate(limits, 0))
+	assert.Equal(t, tuple.Of2(all[:2], error(nil)), validate(limits, 2))
+
+	// Reading stops at the nth violation, so a later read problem is not seen
+	anErr := fmt.Errorf("An err")
+	assert.Equal(
+		t,
+		tuple.Of2(all[:1], error(nil)),
+		tuple.Of2(Validate(io.NewErrorReader([]byte(`{"name": 1`), anErr), limits, 1)),
+	)
+	assert.Equal(
+		t,
+		tuple.Of2(all[:1], anErr),
+		tuple.Of2(Validate(io.NewErrorReader([]byte(`{"name": 1`), anErr), limits, 2)),
+	)
+
+	// Multibyte characters count as one character, offsets are in bytes
+	assert.Equal(
+		t,
+		tuple.Of2([]Violation{{10, "[1]", fmt.Sprintf(errMaxStringLengthMsg, 3, 2)}}, error(nil)),
+		tuple.Of2(Validate(strings.NewReader(`["é€", "aéb"]`), Limits{MaxStringLength: 2}, 0)),
+	)
+}
+
+func TestValidateSyntax_(t *testing.T) {
+	// Syntax errors are the same as Parse
+	for _, doc := range []string{
+		``,
+		` `,
+		`"a"`,
+		`{`,
+		`{1: 2}`,
+		`{"a" 1}`,
+		`{"a"`,
+		`{"a":`,
+		`{"a": 1 "b": 2}`,
+		`{"a": 1`,
+		`{"a": 1, "a": 2}`,
+		`{]`,
+		`[`,
+		`[1 2]`,
+		`[1`,
+		`[1, 2}`,
+		`[tru]`,
+		`["a`,
+		`[-]`,
+	} {
+		_, expected := Parse(strings.NewReader(doc))
+		assert.NotNil(t, expected, doc)
+
+		violations, err := Validate(strings.NewReader(doc), Limits{}, 0)
+		assert.Nil(t, violations, doc)
+		assert.Equal(t, fmt.Sprint(expected), fmt.Sprint(err), doc)
+	}
+
+	// Parse accepts a trailing comma or a missing value, or reports a later error
+	for _, ts := range []tuple.Two[string, error]{
+		tuple.Of2(`{"a": }`, fmt.Errorf(errObjectKeyRequiresValueMsg, "a")),
+		tuple.Of2(`{"a": ]`, fmt.Errorf(errObjectKeyRequiresValueMsg, "a")),
+		tuple.Of2(`{"a": 1, }`, errObjectRequiresKeyOrBrace),
+		tuple.Of2(`[,]`, errArrayRequiresValueOrBracket),
+		tuple.Of2(`[}`, errArrayRequiresValueOrBracket),
+		tuple.Of2(`[1, ]`, errArrayRequiresValue),
+		tuple.Of2(`[{"a": [1, {"b": }]}]`, fmt.Errorf(errObjectKeyRequiresValueMsg, "b")),
+	} {
+		assert.Equal(t, tuple.Of2([]Violation(nil), ts.U), tuple.Of2(Validate(strings.NewReader(ts.T), Limits{}, 0)), ts.T)
+	}
+
+	// Valid documents
+	for _, doc := range []string{`{}`, `[]`, ` [ {"a": [true, false, null, 1.5e3, "x"]}, {}, [[]] ] `} {
+		assert.Equal(t, tuple.Of2([]Violation(nil), error(nil)), tuple.Of2(Validate(strings.NewReader(doc), Limits{}, 0)), doc)
+	}
+}
+
+func TestIterateLimited_(t *testing.T) {
+	const doc = `[{"a": 1}, {"b": "toolong"}, {"c": 3}]`
+
+	// No limits is the same as Iterate
+	assert.Equal(
+		t,
+		iter.Maybe(stream.ReduceToSlice(Iterate(strings.NewReader(doc)))),
+		iter.Maybe(stream.ReduceToSlice(IterateLimited(strings.NewReader(doc), Limits{}))),
+	)
+
+	// Values before the violation are returned, the violation stops iteration
+	it := IterateLimited(strings.NewReader(doc), Limits{MaxStringLength: 6})
+	assert.Equal(t, union.OfResult(json.MustMapToValue(map[string]any{"a": json.NumberString("1")})), iter.Maybe(it))
+
+	violation := Violation{17, "[1].b", fmt.Sprintf(errMaxStringLengthMsg, 7, 6)}
+	assert.Equal(t, union.OfError[json.Value](violation), iter.Maybe(it))
+	assert.Equal(t, union.OfError[json.Value](violation), iter.Maybe(it))
+	assert.Equal(t, "JSON violation at offset 17, path [1].b: the string length of 7 exceeds the maximum of 6 characters", violation.Error())
+
+	// Size limit
+	it = IterateLimited(strings.NewReader(doc), Limits{MaxBytes: 12})
+	assert.Equal(t, union.OfResult(json.MustMapToValue(map[string]any{"a": json.NumberString("1")})), iter.Maybe(it))
+	assert.Equal(t, union.OfError[json.Value](Violation{12, "", fmt.Sprintf(errMaxBytesMsg, 12)}), iter.Maybe(it))
+
+	// Object document
+	it = IterateLimited(strings.NewReader(`{"a": [1, 2]}`), Limits{MaxArrayElements: 1})
+	assert.Equal(t, union.OfError[json.Value](Violation{10, ".a[1]", fmt.Sprintf(errMaxArrayElementsMsg, 1)}), iter.Maybe(it))
+
+	// Syntax errors are the same as Iterate
+	for _, doc := range []string{``, `"a"`, `[1 2]`, `{"a": 1, "a": 2}`, `[{"a"}]`} {
+		assert.Equal(
+			t,
+			fmt.Sprint(iter.Maybe(stream.ReduceToSlice(Iterate(strings.NewReader(doc))))),
+			fmt.Sprint(iter.Maybe(stream.ReduceToSlice(IterateLimited(strings.NewReader(doc), Limits{MaxDepth: 5})))),
+			doc,
+		)
+	}
+
+	// Except the grammar is stricter
+	it = IterateLimited(strings.NewReader(`[1, [], ]`), Limits{})
+	assert.Equal(t, union.OfResult(json.MustNumberToValue(json.NumberString("1"))), iter.Maybe(it))
+	assert.Equal(t, union.OfResult(json.MustSliceToValue([]json.Value{})), iter.Maybe(it))
+	assert.Equal(t, union.OfError[json.Value](errArrayRequiresValue), iter.Maybe(it))
+}
@@ -7,6 +7,7 @@ import (
 	"math/big"
 	goreflect "reflect"
 	"regexp"
+	"strings"
 
 	"github.com/bantling/micro/constraint"
 	"github.com/bantling/micro/conv"
@@ -303,6 +304,20 @@ func (jv Value) AsNumber() NumberString {
 	return NumberString(jv.val.V())
 }
 
+// IsInt returns true if the Value is a number that has no fractional part or exponent.
+// Numbers are stored as strings, so integers of any size (eg IDs that exceed 2^53) are preserved exactly, and are
+// written as they were parsed, without a trailing ".0" or exponent.
+// Returns false for all other types of Value.
+func (jv Value) IsInt() bool {
+	return (jv.typ == Number) && !strings.ContainsAny(jv.val.V(), ".eE")
+}
+
+// IsFloat returns true if the Value is a number that has a fractional part or exponent.
+// Returns false for all other types of Value.
+func (jv Value) IsFloat() bool {
+	return (jv.typ == Number) && strings.ContainsAny(jv.val.V(), ".eE")
+}
+
 // AsBoolean returns a bool representation of a Value.
 // Panics if the Value is not a boolean.
 func (jv Value) AsBool() bool {
@@ -278,6 +278,35 @@ func TestAsBool_(t *testing.T) {
 	)
 }
 
+func TestIsIntIsFloat_(t *testing.T) {
+	// Integers larger than 2^53 are not converted to float64
+	for _, v := range []Value{
+		MustToValue(int64(9_007_199_254_740_993)),
+		MustToValue(uint64(123_456_789_012_345_678)),
+		MustToValue(NumberString("-123456789012345678")),
+		MustToValue(0),
+	} {
+		assert.True(t, v.IsInt())
+		assert.False(t, v.IsFloat())
+	}
+	assert.Equal(t, NumberString("9007199254740993"), MustToValue(int64(9_007_199_254_740_993)).AsNumber())
+
+	for _, v := range []Value{
+		MustToValue(1.5),
+		MustToValue(NumberString("1.0")),
+		MustToValue(NumberString("1e5")),
+		MustToValue(NumberString("1E5")),
+	} {
+		assert.False(t, v.IsInt())
+		assert.True(t, v.IsFloat())
+	}
+
+	for _, v := range []Value{MustToValue("1"), TrueValue, NullValue, MustToValue([]any{}), MustToValue(map[string]any{})} {
+		assert.False(t, v.IsInt())
+		assert.False(t, v.IsFloat())
+	}
+}
+
 func TestIsNull_(t *testing.T) {
 	assert.True(t, NullValue.IsNull())
 	assert.False(t, TrueValue.IsNull())
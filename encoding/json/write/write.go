@@ -31,6 +31,22 @@ func MustWrite(jv json.Value, dst writer.Writer[rune]) {
 	funcs.Must(Write(jv, dst))
 }
 
+// WriteWithHooks writes any value to a writer, after applying the hooks to it as described in json.ApplyHooks, so that
+// values such as passwords can be redacted. Nothing is written if the hooks fail.
+func WriteWithHooks(jv json.Value, dst writer.Writer[rune], hooks json.Hooks) error {
+	hv, err := json.ApplyHooks(jv, hooks)
+	if err != nil {
+		return err
+	}
+
+	return Write(hv, dst)
+}
+
+// MustWriteWithHooks is a must version of WriteWithHooks
+func MustWriteWithHooks(jv json.Value, dst writer.Writer[rune], hooks json.Hooks) {
+	funcs.Must(WriteWithHooks(jv, dst, hooks))
+}
+
 // WriteObject writes an object to a writer
 func WriteObject(jv json.Value, dst writer.Writer[rune]) error {
 	if err := dst.Write('{'); err != nil {
@@ -10,6 +10,7 @@ import (
 
 	"github.com/bantling/micro/conv"
 	"github.com/bantling/micro/encoding/json"
+	"github.com/bantling/micro/encoding/json/parse"
 	"github.com/bantling/micro/funcs"
 	"github.com/bantling/micro/io"
 	"github.com/bantling/micro/io/writer"
@@ -138,3 +139,50 @@ func TestWriteArray_(t *testing.T) {
 		func(e any) { assert.Equal(t, err, e.(error)) },
 	)
 }
+
+func TestWriteNumberRoundTrip_(t *testing.T) {
+	// Integers beyond 2^53 and floats are written exactly as they were parsed
+	var (
+		src = `[9007199254740993,123456789012345678,-1,0,1.0,2.50,1.5e3]`
+		jv  = parse.MustParse(strings.NewReader(src))
+		str strings.Builder
+	)
+
+	var isInt []bool
+	for _, v := range jv.AsSlice() {
+		isInt = append(isInt, v.IsInt())
+	}
+	assert.Equal(t, []bool{true, true, true, true, false, false, false}, isInt)
+
+	assert.Nil(t, Write(jv, writer.OfIOWriterAsRunes(&str)))
+	assert.Equal(t, src, str.String())
+}
+
+func TestWriteWithHooks_(t *testing.T) {
+	var (
+		jv  = json.MustToValue(map[string]any{"user": map[string]any{"password": "p"}})
+		str strings.Builder
+	)
+
+	assert.Nil(t, WriteWithHooks(jv, writer.OfIOWriterAsRunes(&str), json.Hooks{Keys: map[string]json.Hook{"password": json.Redact}}))
+	assert.Equal(t, `{"user":{"password":"******"}}`, str.String())
+
+	// Nothing is written if the hooks fail
+	var (
+		err   = fmt.Errorf("fail")
+		fail  = func(string, json.Value) (json.Value, error) { return json.NullValue, err }
+		hooks = json.Hooks{Paths: map[string]json.Hook{".user.password": fail}}
+	)
+
+	str.Reset()
+	assert.Equal(t, err, WriteWithHooks(jv, writer.OfIOWriterAsRunes(&str), hooks))
+	assert.Equal(t, "", str.String())
+
+	funcs.TryTo(
+		func() {
+			MustWriteWithHooks(jv, writer.OfIOWriterAsRunes(&str), hooks)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, err, e.(error)) },
+	)
+}
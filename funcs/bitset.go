@@ -0,0 +1,171 @@
+package funcs
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"math/bits"
+)
+
+// BitSet is a growable set of non-negative integers, stored as one bit per integer.
+// The zero value is an empty set that is ready to use, and grows as needed when bits are set.
+//
+// A BitSet is not safe for concurrent use.
+type BitSet struct {
+	words []uint64
+}
+
+// wordOf returns the index of the word containing bit i, and the mask of bit i in that word
+func wordOf(i uint) (int, uint64) {
+	return int(i >> 6), 1 << (i & 63)
+}
+
+// NewBitSet constructs a BitSet with room for the given number of bits before it has to grow
+func NewBitSet(capacity uint) *BitSet {
+	return &BitSet{words: make([]uint64, 0, (capacity+63)>>6)}
+}
+
+// BitSetOf constructs a BitSet with the given bits set
+func BitSetOf(vals ...uint) *BitSet {
+	bs := &BitSet{}
+	for _, i := range vals {
+		bs.Set(i)
+	}
+
+	return bs
+}
+
+// Set sets bit i, growing the set if needed
+func (bs *BitSet) Set(i uint) {
+	w, mask := wordOf(i)
+	for len(bs.words) <= w {
+		bs.words = append(bs.words, 0)
+	}
+
+	bs.words[w] |= mask
+}
+
+// Clear clears bit i, which has no effect if i is beyond the end of the set
+func (bs *BitSet) Clear(i uint) {
+	if w, mask := wordOf(i); w < len(bs.words) {
+		bs.words[w] &^= mask
+	}
+}
+
+// Test returns true if bit i is set
+func (bs *BitSet) Test(i uint) bool {
+	w, mask := wordOf(i)
+	return (w < len(bs.words)) && (bs.words[w]&mask != 0)
+}
+
+// Count returns the number of bits that are set
+func (bs *BitSet) Count() uint {
+	var n int
+	for _, word := range bs.words {
+		n += bits.OnesCount64(word)
+	}
+
+	return uint(n)
+}
+
+// Union sets every bit in this set that is set in the other set, growing this set if needed
+func (bs *BitSet) Union(other *BitSet) {
+	for len(bs.words) < len(other.words) {
+		bs.words = append(bs.words, 0)
+	}
+
+	for w, word := range other.words {
+		bs.words[w] |= word
+	}
+}
+
+// Intersect clears every bit in this set that is not set in the other set
+func (bs *BitSet) Intersect(other *BitSet) {
+	if len(bs.words) > len(other.words) {
+		bs.words = bs.words[:len(other.words)]
+	}
+
+	for w := range bs.words {
+		bs.words[w] &= other.words[w]
+	}
+}
+
+// Equal returns true if both sets have the same bits set, regardless of how much each set has grown
+func (bs *BitSet) Equal(other *BitSet) bool {
+	long, short := bs.words, other.words
+	if len(long) < len(short) {
+		long, short = short, long
+	}
+
+	for w, word := range long {
+		var shortWord uint64
+		if w < len(short) {
+			shortWord = short[w]
+		}
+
+		if word != shortWord {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Clone returns a copy of the set
+func (bs *BitSet) Clone() *BitSet {
+	return &BitSet{words: append([]uint64(nil), bs.words...)}
+}
+
+// Iterate calls fn with each set bit in ascending order, until fn returns false or there are no more set bits
+func (bs *BitSet) Iterate(fn func(uint) bool) {
+	for w, word := range bs.words {
+		for word != 0 {
+			if !fn(uint(w<<6 + bits.TrailingZeros64(word))) {
+				return
+			}
+
+			// Clear lowest set bit
+			word &= word - 1
+		}
+	}
+}
+
+// Rank returns the number of set bits that are less than i
+func (bs *BitSet) Rank(i uint) uint {
+	var (
+		w, mask = wordOf(i)
+		n       int
+	)
+
+	for idx, word := range bs.words {
+		if idx == w {
+			n += bits.OnesCount64(word & (mask - 1))
+			break
+		}
+
+		n += bits.OnesCount64(word)
+	}
+
+	return uint(n)
+}
+
+// Select returns the position of the set bit with the given rank, and true, where rank 0 is the lowest set bit.
+// Returns (0, false) if there are not enough bits set.
+// For any set bit i, Select(Rank(i)) returns i.
+func (bs *BitSet) Select(rank uint) (uint, bool) {
+	for w, word := range bs.words {
+		n := uint(bits.OnesCount64(word))
+		if rank >= n {
+			rank -= n
+			continue
+		}
+
+		// Clear the lowest rank set bits, and the lowest remaining one is the result
+		for ; rank > 0; rank-- {
+			word &= word - 1
+		}
+
+		return uint(w<<6 + bits.TrailingZeros64(word)), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,115 @@
+package funcs
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"testing"
+
+	"github.com/bantling/micro/tuple"
+	"github.com/stretchr/testify/assert"
+)
+
+// bitsOf collects the set bits of a BitSet
+func bitsOf(bs *BitSet) []uint {
+	res := []uint{}
+	bs.Iterate(func(i uint) bool {
+		res = append(res, i)
+		return true
+	})
+
+	return res
+}
+
+func TestBitSetSetClearTest_(t *testing.T) {
+	var bs BitSet
+	assert.False(t, bs.Test(0))
+	assert.Equal(t, uint(0), bs.Count())
+
+	// Grows as needed
+	bs.Set(0)
+	bs.Set(63)
+	bs.Set(64)
+	bs.Set(200)
+	assert.True(t, bs.Test(0))
+	assert.True(t, bs.Test(63))
+	assert.True(t, bs.Test(64))
+	assert.True(t, bs.Test(200))
+	assert.False(t, bs.Test(1))
+	assert.False(t, bs.Test(1000))
+	assert.Equal(t, uint(4), bs.Count())
+
+	// Clearing beyond the end has no effect
+	bs.Clear(63)
+	bs.Clear(1000)
+	assert.False(t, bs.Test(63))
+	assert.Equal(t, []uint{0, 64, 200}, bitsOf(&bs))
+
+	// Capacity does not set bits
+	bs2 := NewBitSet(100)
+	assert.Equal(t, 0, len(bs2.words))
+	assert.Equal(t, 2, cap(bs2.words))
+	bs2.Set(99)
+	assert.Equal(t, []uint{99}, bitsOf(bs2))
+}
+
+func TestBitSetUnionIntersect_(t *testing.T) {
+	var (
+		a = BitSetOf(1, 3, 100)
+		b = BitSetOf(3, 4, 300)
+	)
+
+	u := a.Clone()
+	u.Union(b)
+	assert.Equal(t, []uint{1, 3, 4, 100, 300}, bitsOf(u))
+	assert.Equal(t, []uint{1, 3, 100}, bitsOf(a))
+
+	i := a.Clone()
+	i.Intersect(b)
+	assert.Equal(t, []uint{3}, bitsOf(i))
+
+	i = b.Clone()
+	i.Intersect(a)
+	assert.Equal(t, []uint{3}, bitsOf(i))
+
+	// Equal ignores growth
+	assert.True(t, BitSetOf(3).Equal(i))
+	assert.True(t, i.Equal(BitSetOf(3)))
+	assert.False(t, BitSetOf(3, 500).Equal(i))
+	assert.False(t, i.Equal(BitSetOf(2)))
+
+	i = BitSetOf(500)
+	i.Intersect(&BitSet{})
+	assert.True(t, (&BitSet{}).Equal(i))
+}
+
+func TestBitSetIterate_(t *testing.T) {
+	assert.Equal(t, []uint{}, bitsOf(&BitSet{}))
+
+	// Stops when fn returns false
+	var res []uint
+	BitSetOf(5, 70, 140).Iterate(func(i uint) bool {
+		res = append(res, i)
+		return i < 70
+	})
+	assert.Equal(t, []uint{5, 70}, res)
+}
+
+func TestBitSetRankSelect_(t *testing.T) {
+	bs := BitSetOf(2, 5, 64, 65, 130)
+
+	assert.Equal(t, uint(0), bs.Rank(0))
+	assert.Equal(t, uint(0), bs.Rank(2))
+	assert.Equal(t, uint(1), bs.Rank(3))
+	assert.Equal(t, uint(2), bs.Rank(64))
+	assert.Equal(t, uint(4), bs.Rank(130))
+	assert.Equal(t, uint(5), bs.Rank(131))
+	assert.Equal(t, uint(5), bs.Rank(10000))
+
+	for r, i := range []uint{2, 5, 64, 65, 130} {
+		assert.Equal(t, tuple.Of2(i, true), tuple.Of2(bs.Select(uint(r))))
+		assert.Equal(t, uint(r), bs.Rank(i))
+	}
+
+	assert.Equal(t, tuple.Of2(uint(0), false), tuple.Of2(bs.Select(5)))
+	assert.Equal(t, tuple.Of2(uint(0), false), tuple.Of2((&BitSet{}).Select(0)))
+}
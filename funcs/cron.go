@@ -0,0 +1,337 @@
+package funcs
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	cronFieldsMsg   = "The cron expression %q must have 5 or 6 fields, not %d"
+	cronFieldMsg    = "The cron %s field %q is invalid"
+	cronRangeMsg    = "The cron %s field %q is out of range: values must be %d-%d"
+	cronTimezoneMsg = "The cron timezone %q is invalid: %w"
+
+	// cronYearsToSearch is how many years NextAfter searches before deciding a schedule never occurs, eg February 30
+	cronYearsToSearch = 5
+)
+
+// cronField describes one field of a cron expression
+type cronField struct {
+	name     string
+	min, max uint
+	names    map[string]uint
+	question bool // question is true if ? may be used in place of *
+}
+
+var (
+	cronSecond   = cronField{name: "second", min: 0, max: 59}
+	cronMinute   = cronField{name: "minute", min: 0, max: 59}
+	cronHour     = cronField{name: "hour", min: 0, max: 23}
+	cronMonthDay = cronField{name: "day of month", min: 1, max: 31, question: true}
+	cronMonth    = cronField{
+		name: "month",
+		min:  1,
+		max:  12,
+		names: map[string]uint{
+			"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+			"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+		},
+	}
+	cronWeekDay = cronField{
+		name:     "day of week",
+		min:      0,
+		max:      7,
+		names:    map[string]uint{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6},
+		question: true,
+	}
+
+	// cronMacros are the predefined schedules
+	cronMacros = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// Cron is a parsed cron expression, that can calculate when the schedule next occurs.
+// The zero value is not useful, use ParseCron.
+type Cron struct {
+	expr                                             string
+	seconds, minutes, hours, monthDays, months, days uint64
+	monthDayStar, weekDayStar                        bool
+	loc                                              *time.Location
+}
+
+// value parses a single value of a field, which may be a name
+func (f cronField) value(str, field string) (uint, error) {
+	if val, haveIt := f.names[strings.ToLower(str)]; haveIt {
+		return val, nil
+	}
+
+	val, err := strconv.ParseUint(str, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf(cronFieldMsg, f.name, field)
+	}
+
+	if (uint(val) < f.min) || (uint(val) > f.max) {
+		return 0, fmt.Errorf(cronRangeMsg, f.name, field, f.min, f.max)
+	}
+
+	return uint(val), nil
+}
+
+// parse parses a field into a bit mask of the values it contains, and whether it is * or ?
+func (f cronField) parse(field string) (bits uint64, star bool, err error) {
+	star = (field == "*") || ((field == "?") && f.question)
+
+	for _, part := range strings.Split(field, ",") {
+		var (
+			rng, stepStr, haveStep = strings.Cut(part, "/")
+			lo, hi                 = f.min, f.max
+			step                   = uint64(1)
+		)
+
+		if haveStep {
+			if step, err = strconv.ParseUint(stepStr, 10, 8); (err != nil) || (step == 0) {
+				return 0, false, fmt.Errorf(cronFieldMsg, f.name, field)
+			}
+		}
+
+		switch loStr, hiStr, haveRange := strings.Cut(rng, "-"); {
+		case (rng == "*") || ((rng == "?") && f.question):
+		case haveRange:
+			if lo, err = f.value(loStr, field); err != nil {
+				return
+			}
+
+			if hi, err = f.value(hiStr, field); err != nil {
+				return
+			}
+
+			if lo > hi {
+				return 0, false, fmt.Errorf(cronFieldMsg, f.name, field)
+			}
+		default:
+			if lo, err = f.value(rng, field); err != nil {
+				return
+			}
+
+			// A single value with a step, such as 5/15, runs from the value to the maximum
+			if !haveStep {
+				hi = lo
+			}
+		}
+
+		for i := lo; i <= hi; i += uint(step) {
+			bits |= 1 << i
+		}
+	}
+
+	return
+}
+
+// ParseCron parses a cron expression of 5 fields: minute, hour, day of month, month, and day of week.
+// If there are 6 fields, the first field is the second.
+//
+// Each field is a comma separated list of:
+// - * for every value, or ? for the day of month or day of week
+// - a single value, such as 5
+// - a range, such as 1-5
+// - any of the above followed by a step, such as */15 or 0-30/10; a single value followed by a step, such as 5/15, runs
+// until the maximum
+//
+// Months may be given as jan-dec, and days of the week as sun-sat, in any case. Sunday is 0 or 7.
+// The predefined schedules @yearly, @annually, @monthly, @weekly, @daily, @midnight, and @hourly may be used instead.
+//
+// As in standard cron, if the day of month and day of week fields are both restricted (neither is * or ?), then a day
+// matches if either field matches. Otherwise a day must match both.
+//
+// The expression may be prefixed with CRON_TZ=zone or TZ=zone, such as CRON_TZ=America/Toronto, for the time zone the
+// schedule runs in. Without a time zone, the schedule runs in the time zone of the time passed to NextAfter.
+func ParseCron(expr string) (Cron, error) {
+	var (
+		res  = Cron{expr: expr}
+		spec = strings.TrimSpace(expr)
+	)
+
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		zone, rest, _ := strings.Cut(spec[strings.Index(spec, "=")+1:], " ")
+
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			return Cron{}, fmt.Errorf(cronTimezoneMsg, zone, err)
+		}
+
+		res.loc, spec = loc, strings.TrimSpace(rest)
+	}
+
+	if macro, haveIt := cronMacros[strings.ToLower(spec)]; haveIt {
+		spec = macro
+	}
+
+	fieldStrs := strings.Fields(spec)
+	switch len(fieldStrs) {
+	case 5:
+		fieldStrs = append([]string{"0"}, fieldStrs...)
+	case 6:
+	default:
+		return Cron{}, fmt.Errorf(cronFieldsMsg, expr, len(fieldStrs))
+	}
+
+	var err error
+	for i, dst := range []struct {
+		field *cronField
+		bits  *uint64
+		star  *bool
+	}{
+		{&cronSecond, &res.seconds, nil},
+		{&cronMinute, &res.minutes, nil},
+		{&cronHour, &res.hours, nil},
+		{&cronMonthDay, &res.monthDays, &res.monthDayStar},
+		{&cronMonth, &res.months, nil},
+		{&cronWeekDay, &res.days, &res.weekDayStar},
+	} {
+		var star bool
+		if *dst.bits, star, err = dst.field.parse(fieldStrs[i]); err != nil {
+			return Cron{}, err
+		}
+
+		if dst.star != nil {
+			*dst.star = star
+		}
+	}
+
+	// Sunday may be 7
+	if res.days&(1<<7) != 0 {
+		res.days = (res.days | 1) &^ (1 << 7)
+	}
+
+	return res, nil
+}
+
+// MustParseCron is a must version of ParseCron
+func MustParseCron(expr string) Cron {
+	return MustValue(ParseCron(expr))
+}
+
+// In returns a copy of the schedule that runs in the given time zone, overriding any zone in the expression
+func (c Cron) In(loc *time.Location) Cron {
+	c.loc = loc
+	return c
+}
+
+// String returns the expression the schedule was parsed from
+func (c Cron) String() string {
+	return c.expr
+}
+
+// dayMatches returns true if the day of t matches the day of month and day of week fields
+func (c Cron) dayMatches(t time.Time) bool {
+	var (
+		monthDay = c.monthDays&(1<<uint(t.Day())) != 0
+		weekDay  = c.days&(1<<uint(t.Weekday())) != 0
+	)
+
+	if c.monthDayStar || c.weekDayStar {
+		return monthDay && weekDay
+	}
+
+	return monthDay || weekDay
+}
+
+// cronFieldsChanged returns true if any of the first n fields of year, month, day, hour, and minute differ between a
+// and b
+func cronFieldsChanged(a, b time.Time, n int) bool {
+	var (
+		ay, am, ad = a.Date()
+		by, bm, bd = b.Date()
+		af         = [5]int{ay, int(am), ad, a.Hour(), a.Minute()}
+		bf         = [5]int{by, int(bm), bd, b.Hour(), b.Minute()}
+	)
+
+	for i := 0; i < n; i++ {
+		if af[i] != bf[i] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NextAfter returns the first time after t that the schedule occurs, in the time zone of the schedule if it has one,
+// otherwise in the time zone of t.
+//
+// A time that does not exist because the clocks go forward is skipped, and a time that occurs twice because the clocks
+// go back occurs both times. Returns the zero time if the schedule never occurs, such as February 30.
+func (c Cron) NextAfter(t time.Time) time.Time {
+	if c.loc != nil {
+		t = t.In(c.loc)
+	}
+
+	var (
+		loc   = t.Location()
+		limit = t.Year() + cronYearsToSearch
+	)
+
+	// Start at the next whole second
+	t = t.Truncate(time.Second).Add(time.Second)
+
+	// Each field is advanced until it matches, and if that wraps around into the next value of a larger field, start
+	// again from the largest field
+wrap:
+	if t.Year() > limit {
+		return time.Time{}
+	}
+
+	for c.months&(1<<uint(t.Month())) == 0 {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+
+	for !c.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+
+	// Hours, minutes, and seconds are advanced by durations rather than by time.Date, as time.Date does not guarantee
+	// which of two times that occur twice it returns, and could move backwards.
+	// A larger field may change without a smaller field reading 0, such as the day when the clocks go forward at
+	// midnight, so start again whenever any larger field changes.
+	for c.hours&(1<<uint(t.Hour())) == 0 {
+		prev := t
+		t = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second)
+		if cronFieldsChanged(prev, t, 3) {
+			goto wrap
+		}
+	}
+
+	for c.minutes&(1<<uint(t.Minute())) == 0 {
+		prev := t
+		t = t.Add(time.Minute - time.Duration(t.Second())*time.Second)
+		if cronFieldsChanged(prev, t, 4) {
+			goto wrap
+		}
+	}
+
+	for c.seconds&(1<<uint(t.Second())) == 0 {
+		prev := t
+		t = t.Add(time.Second)
+		if cronFieldsChanged(prev, t, 5) {
+			goto wrap
+		}
+	}
+
+	return t
+}
@@ -0,0 +1,185 @@
+package funcs
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron_(t *testing.T) {
+	c := MustParseCron("*/15 9-17 * jan,JUL mon-fri")
+	assert.Equal(t, "*/15 9-17 * jan,JUL mon-fri", c.String())
+	assert.Equal(t, uint64(1), c.seconds)
+	assert.Equal(t, uint64(1|1<<15|1<<30|1<<45), c.minutes)
+	assert.Equal(t, uint64(0x3fe00), c.hours)
+	assert.Equal(t, uint64(0xfffffffe), c.monthDays)
+	assert.Equal(t, uint64(1<<1|1<<7), c.months)
+	assert.Equal(t, uint64(0x3e), c.days)
+	assert.True(t, c.monthDayStar)
+	assert.False(t, c.weekDayStar)
+
+	// Seconds, single value with step, Sunday as 7, ?
+	c = MustParseCron(" 5/20 0 0 ? * 7 ")
+	assert.Equal(t, uint64(1<<5|1<<25|1<<45), c.seconds)
+	assert.Equal(t, uint64(1), c.days)
+	assert.True(t, c.monthDayStar)
+
+	// Range with step, lists
+	c = MustParseCron("0-30/10,59 * 1,15 * *")
+	assert.Equal(t, uint64(1|1<<10|1<<20|1<<30|1<<59), c.minutes)
+	assert.Equal(t, uint64(1<<1|1<<15), c.monthDays)
+	assert.False(t, c.monthDayStar)
+
+	// Macros
+	assert.Equal(t, MustParseCron("0 0 * * 0").days, MustParseCron("@weekly").days)
+	assert.Equal(t, MustParseCron("0 0 1 1 *").months, MustParseCron("@YEARLY").months)
+
+	// Time zone
+	c = MustParseCron("CRON_TZ=America/Toronto 0 9 * * *")
+	assert.Equal(t, "America/Toronto", c.loc.String())
+	assert.Equal(t, "UTC", MustParseCron("TZ=UTC @daily").loc.String())
+	assert.Equal(t, time.UTC, MustParseCron("* * * * *").In(time.UTC).loc)
+
+	// Errors
+	for _, tst := range []struct {
+		expr string
+		err  error
+	}{
+		{"* * * *", fmt.Errorf(cronFieldsMsg, "* * * *", 4)},
+		{"* * * * * * *", fmt.Errorf(cronFieldsMsg, "* * * * * * *", 7)},
+		{"", fmt.Errorf(cronFieldsMsg, "", 0)},
+		{"x * * * *", fmt.Errorf(cronFieldMsg, "minute", "x")},
+		{"60 * * * *", fmt.Errorf(cronRangeMsg, "minute", "60", 0, 59)},
+		{"* 24 * * *", fmt.Errorf(cronRangeMsg, "hour", "24", 0, 23)},
+		{"* * 0 * *", fmt.Errorf(cronRangeMsg, "day of month", "0", 1, 31)},
+		{"* * * 13 *", fmt.Errorf(cronRangeMsg, "month", "13", 1, 12)},
+		{"* * * ? *", fmt.Errorf(cronFieldMsg, "month", "?")},
+		{"* * * * 8", fmt.Errorf(cronRangeMsg, "day of week", "8", 0, 7)},
+		{"5-1 * * * *", fmt.Errorf(cronFieldMsg, "minute", "5-1")},
+		{"*/0 * * * *", fmt.Errorf(cronFieldMsg, "minute", "*/0")},
+		{"*/x * * * *", fmt.Errorf(cronFieldMsg, "minute", "*/x")},
+		{"1- * * * *", fmt.Errorf(cronFieldMsg, "minute", "1-")},
+		{"1,,2 * * * *", fmt.Errorf(cronFieldMsg, "minute", "1,,2")},
+		{"-1 * * * *", fmt.Errorf(cronFieldMsg, "minute", "-1")},
+	} {
+		_, err := ParseCron(tst.expr)
+		assert.Equal(t, tst.err, err, tst.expr)
+	}
+
+	_, err := ParseCron("CRON_TZ=Nowhere/Special * * * * *")
+	assert.Contains(t, err.Error(), `The cron timezone "Nowhere/Special" is invalid: `)
+
+	TryTo(
+		func() {
+			MustParseCron("@never")
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(cronFieldsMsg, "@never", 1), e)
+		},
+	)
+}
+
+func TestCronNextAfter_(t *testing.T) {
+	var (
+		utc = func(y int, m time.Month, d, h, min, s int) time.Time {
+			return time.Date(y, m, d, h, min, s, 0, time.UTC)
+		}
+		// Wednesday
+		start = utc(2025, 1, 15, 10, 7, 30)
+	)
+
+	for _, tst := range []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", utc(2025, 1, 15, 10, 8, 0)},
+		{"* * * * * *", utc(2025, 1, 15, 10, 7, 31)},
+		{"*/15 * * * *", utc(2025, 1, 15, 10, 15, 0)},
+		{"0 * * * *", utc(2025, 1, 15, 11, 0, 0)},
+		{"5 9 * * *", utc(2025, 1, 16, 9, 5, 0)},
+		{"0 0 1 * *", utc(2025, 2, 1, 0, 0, 0)},
+		{"0 0 1 1 *", utc(2026, 1, 1, 0, 0, 0)},
+		{"@yearly", utc(2026, 1, 1, 0, 0, 0)},
+		{"0 12 * * sat", utc(2025, 1, 18, 12, 0, 0)},
+		{"0 12 * * 0", utc(2025, 1, 19, 12, 0, 0)},
+		{"30 23 31 * *", utc(2025, 1, 31, 23, 30, 0)},
+		{"0 0 29 2 *", utc(2028, 2, 29, 0, 0, 0)},
+		{"0 0 31 4 *", time.Time{}},
+		{"0 0 30 2 *", time.Time{}},
+
+		// Day of month or day of week when both are restricted, otherwise both
+		{"0 0 13 * fri", utc(2025, 1, 17, 0, 0, 0)},
+		{"0 0 1 * fri", utc(2025, 1, 17, 0, 0, 0)},
+		{"0 0 * * fri", utc(2025, 1, 17, 0, 0, 0)},
+		{"0 0 13 * *", utc(2025, 2, 13, 0, 0, 0)},
+		{"0 0 13 * ?", utc(2025, 2, 13, 0, 0, 0)},
+		{"0 0 */7 * fri", utc(2025, 1, 17, 0, 0, 0)},
+	} {
+		assert.Equal(t, tst.next, MustParseCron(tst.expr).NextAfter(start), tst.expr)
+	}
+
+	// Starts after a time with fractional seconds
+	assert.Equal(t, utc(2025, 1, 15, 10, 7, 31), MustParseCron("* * * * * *").NextAfter(start.Add(time.Millisecond)))
+}
+
+func TestCronNextAfterTimezone_(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	if err != nil {
+		t.Skip("time zone database is not available")
+	}
+
+	var (
+		local = func(y int, m time.Month, d, h, min int) time.Time { return time.Date(y, m, d, h, min, 0, 0, toronto) }
+		start = time.Date(2025, 1, 15, 10, 7, 0, 0, time.UTC)
+	)
+
+	// The zone of the schedule, otherwise the zone of the time
+	next := MustParseCron("CRON_TZ=America/Toronto 0 9 * * *").NextAfter(start)
+	assert.Equal(t, local(2025, 1, 15, 9, 0), next)
+	assert.Equal(t, toronto, next.Location())
+	assert.Equal(t, time.Date(2025, 1, 15, 14, 0, 0, 0, time.UTC), next.UTC())
+
+	assert.Equal(t, time.Date(2025, 1, 16, 9, 0, 0, 0, time.UTC), MustParseCron("0 9 * * *").NextAfter(start))
+	assert.Equal(t, local(2025, 1, 15, 9, 0), MustParseCron("0 9 * * *").NextAfter(start.In(toronto)))
+	assert.Equal(t, local(2025, 1, 15, 9, 0), MustParseCron("0 9 * * *").In(toronto).NextAfter(start))
+
+	// Clocks go forward at 2:00 on March 9 2025, so 2:30 does not exist that day
+	c := MustParseCron("30 2 * * *").In(toronto)
+	assert.Equal(t, local(2025, 3, 10, 2, 30), c.NextAfter(local(2025, 3, 9, 0, 0)))
+
+	// Clocks go back at 2:00 on November 2 2025, so 1:30 occurs twice
+	c = MustParseCron("30 1 * * *").In(toronto)
+	first := c.NextAfter(local(2025, 11, 2, 0, 0))
+	assert.Equal(t, time.Date(2025, 11, 2, 5, 30, 0, 0, time.UTC), first.UTC())
+
+	second := c.NextAfter(first)
+	assert.Equal(t, time.Date(2025, 11, 2, 6, 30, 0, 0, time.UTC), second.UTC())
+	assert.Equal(t, local(2025, 11, 3, 1, 30), c.NextAfter(second))
+
+	// Hourly across both changes
+	c = MustParseCron("0 * * * *").In(toronto)
+	assert.Equal(t, local(2025, 3, 9, 3, 0), c.NextAfter(local(2025, 3, 9, 1, 30)))
+	assert.Equal(t, time.Date(2025, 11, 2, 6, 0, 0, 0, time.UTC), c.NextAfter(time.Date(2025, 11, 2, 5, 30, 0, 0, time.UTC)).UTC())
+}
+
+func TestCronNextAfterMidnightDST_(t *testing.T) {
+	santiago, err := time.LoadLocation("America/Santiago")
+	if err != nil {
+		t.Skip("time zone database is not available")
+	}
+
+	// Clocks go forward at midnight on September 6 2026, so there is no 00:00 and Sunday starts at 01:00
+	c := MustParseCron("0 1 * * sat").In(santiago)
+	next := c.NextAfter(time.Date(2026, 9, 5, 2, 0, 0, 0, santiago))
+	assert.Equal(t, time.Saturday, next.Weekday())
+	assert.Equal(t, time.Date(2026, 9, 12, 1, 0, 0, 0, santiago), next)
+
+	// A daily schedule runs on the day after the change at the first time that exists
+	c = MustParseCron("30 0 * * *").In(santiago)
+	assert.Equal(t, time.Date(2026, 9, 7, 0, 30, 0, 0, santiago), c.NextAfter(time.Date(2026, 9, 5, 23, 0, 0, 0, santiago)))
+}
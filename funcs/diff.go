@@ -0,0 +1,236 @@
+package funcs
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"github.com/bantling/micro/tuple"
+)
+
+// EditOp is the operation of an Edit
+type EditOp uint
+
+const (
+	EditKeep   EditOp = iota // EditKeep is a run of values that are in both slices
+	EditInsert               // EditInsert is a run of values that are only in the second slice
+	EditDelete               // EditDelete is a run of values that are only in the first slice
+)
+
+// Edit is a run of consecutive values that have the same EditOp
+type Edit[T any] struct {
+	Op     EditOp
+	Values []T
+}
+
+// MapDifference is the difference between two maps, where each field is empty if there are no such keys
+type MapDifference[K comparable, V any] struct {
+	// Added contains keys that are only in the second map, with their values
+	Added map[K]V
+
+	// Removed contains keys that are only in the first map, with their values
+	Removed map[K]V
+
+	// Changed contains keys that are in both maps with different values, as the pair of first and second values
+	Changed map[K]tuple.Two[V, V]
+}
+
+// SliceDiff returns an edit script that transforms slice a into slice b, using the Myers diff algorithm, such as
+// [EditKeep [1], EditDelete [2], EditInsert [3], EditKeep [4]] for [1, 2, 4] and [1, 3, 4].
+//
+// The script is as short as possible, with adjacent edits of the same op merged into one, and a delete preceding an
+// insert at the same position. Applying the script in order - copying kept values, skipping deleted values, and adding
+// inserted values - produces b. Kept values are those of a.
+//
+// If both slices are empty, the result is empty.
+func SliceDiff[T comparable](a, b []T) []Edit[T] {
+	return SliceDiffBy(a, b, func(x, y T) bool { return x == y })
+}
+
+// SliceDiffBy is a version of SliceDiff for any type of value, using the given func to compare them
+func SliceDiffBy[T any](a, b []T, eq func(T, T) bool) []Edit[T] {
+	var (
+		max = len(a) + len(b)
+		s   = diffState[T]{a: a, b: b, eq: eq, vf: make([]int, 2*max+3), vb: make([]int, 2*max+3), off: max + 1}
+	)
+
+	s.diff(0, len(a), 0, len(b))
+
+	// Merge edits into runs in forward order, where the deletes between two kept values precede the inserts
+	var (
+		res []Edit[T]
+		add = func(op EditOp, val T) {
+			if l := len(res) - 1; (l >= 0) && (res[l].Op == op) {
+				res[l].Values = append(res[l].Values, val)
+			} else {
+				res = append(res, Edit[T]{Op: op, Values: []T{val}})
+			}
+		}
+		inserts  []T
+		flushIns = func() {
+			for _, val := range inserts {
+				add(EditInsert, val)
+			}
+			inserts = inserts[:0]
+		}
+	)
+
+	for i, op := range s.ops {
+		switch op {
+		case EditKeep:
+			flushIns()
+			add(EditKeep, s.vals[i])
+		case EditDelete:
+			add(EditDelete, s.vals[i])
+		default:
+			inserts = append(inserts, s.vals[i])
+		}
+	}
+	flushIns()
+
+	return res
+}
+
+// diffState is the state of SliceDiffBy, which uses the linear space version of the Myers algorithm: the middle snake
+// of the shortest edit script is found by searching forwards from the start and backwards from the end at the same
+// time, and the parts before and after it are diffed recursively. The diagonal arrays are reused at every level.
+type diffState[T any] struct {
+	a, b   []T
+	eq     func(T, T) bool
+	vf, vb []int
+	off    int
+	ops    []EditOp
+	vals   []T
+}
+
+// emit appends a single value edit
+func (s *diffState[T]) emit(op EditOp, val T) {
+	s.ops, s.vals = append(s.ops, op), append(s.vals, val)
+}
+
+// diff emits the edits that transform a[aLo:aHi] into b[bLo:bHi] in forward order
+func (s *diffState[T]) diff(aLo, aHi, bLo, bHi int) {
+	// Keep the common prefix, and the common suffix after the rest
+	for (aLo < aHi) && (bLo < bHi) && s.eq(s.a[aLo], s.b[bLo]) {
+		s.emit(EditKeep, s.a[aLo])
+		aLo, bLo = aLo+1, bLo+1
+	}
+
+	suffix := aHi
+	for (aLo < aHi) && (bLo < bHi) && s.eq(s.a[aHi-1], s.b[bHi-1]) {
+		aHi, bHi = aHi-1, bHi-1
+	}
+
+	switch {
+	case aLo == aHi:
+		for ; bLo < bHi; bLo++ {
+			s.emit(EditInsert, s.b[bLo])
+		}
+	case bLo == bHi:
+		for ; aLo < aHi; aLo++ {
+			s.emit(EditDelete, s.a[aLo])
+		}
+	default:
+		// Both parts are non-empty and differ at both ends, so there are at least two edits, and each side of the middle
+		// snake has fewer edits
+		x, y, u, v := s.middleSnake(aLo, aHi, bLo, bHi)
+		s.diff(aLo, x, bLo, y)
+		for ; x < u; x++ {
+			s.emit(EditKeep, s.a[x])
+		}
+		s.diff(u, aHi, v, bHi)
+	}
+
+	for ; aHi < suffix; aHi++ {
+		s.emit(EditKeep, s.a[aHi])
+	}
+}
+
+// middleSnake returns the start (x, y) and end (u, v) of a run of equal values in the middle of a shortest edit script
+// that transforms a[aLo:aHi] into b[bLo:bHi], which may be empty
+func (s *diffState[T]) middleSnake(aLo, aHi, bLo, bHi int) (int, int, int, int) {
+	var (
+		n, m  = aHi - aLo, bHi - bLo
+		delta = n - m
+		odd   = delta&1 != 0
+		vf    = s.vf
+		vb    = s.vb
+		off   = s.off
+	)
+
+	// vf is the furthest x reached forwards on each diagonal k = x - y, and vb is the furthest distance reached backwards
+	// from the end on each diagonal, in reversed coordinates where diagonal k is diagonal delta - k forwards
+	vf[off+1], vb[off+1] = 0, 0
+
+	for d := 0; ; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if (k == -d) || ((k != d) && (vf[off+k-1] < vf[off+k+1])) {
+				x = vf[off+k+1]
+			} else {
+				x = vf[off+k-1] + 1
+			}
+
+			x0 := x
+			for y := x - k; (x < n) && (y < m) && s.eq(s.a[aLo+x], s.b[bLo+y]); y++ {
+				x++
+			}
+			vf[off+k] = x
+
+			// When delta is odd, the paths can only meet after a forward step, on a diagonal the backward search reached in
+			// d - 1 steps
+			if c := delta - k; odd && (c >= -(d - 1)) && (c <= d-1) && (x+vb[off+c] >= n) {
+				return aLo + x0, bLo + x0 - k, aLo + x, bLo + x - k
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if (k == -d) || ((k != d) && (vb[off+k-1] < vb[off+k+1])) {
+				x = vb[off+k+1]
+			} else {
+				x = vb[off+k-1] + 1
+			}
+
+			x0 := x
+			for y := x - k; (x < n) && (y < m) && s.eq(s.a[aHi-1-x], s.b[bHi-1-y]); y++ {
+				x++
+			}
+			vb[off+k] = x
+
+			// When delta is even, the paths can only meet after a backward step
+			if c := delta - k; !odd && (c >= -d) && (c <= d) && (x+vf[off+c] >= n) {
+				return aHi - x, bHi - (x - k), aHi - x0, bHi - (x0 - k)
+			}
+		}
+	}
+}
+
+// MapDiff returns the keys that were added, removed, and changed to transform map a into map b
+func MapDiff[K, V comparable](a, b map[K]V) MapDifference[K, V] {
+	return MapDiffBy(a, b, func(x, y V) bool { return x == y })
+}
+
+// MapDiffBy is a version of MapDiff for any type of value, using the given func to compare them
+func MapDiffBy[K comparable, V any](a, b map[K]V, eq func(V, V) bool) MapDifference[K, V] {
+	res := MapDifference[K, V]{Added: map[K]V{}, Removed: map[K]V{}, Changed: map[K]tuple.Two[V, V]{}}
+
+	for k, av := range a {
+		if bv, haveIt := b[k]; !haveIt {
+			res.Removed[k] = av
+		} else if !eq(av, bv) {
+			res.Changed[k] = tuple.Of2(av, bv)
+		}
+	}
+
+	for k, bv := range b {
+		if _, haveIt := a[k]; !haveIt {
+			res.Added[k] = bv
+		}
+	}
+
+	return res
+}
+
+// IsEmpty returns true if the maps are equal
+func (md MapDifference[K, V]) IsEmpty() bool {
+	return (len(md.Added) == 0) && (len(md.Removed) == 0) && (len(md.Changed) == 0)
+}
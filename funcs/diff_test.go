@@ -0,0 +1,177 @@
+package funcs
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/bantling/micro/tuple"
+	"github.com/stretchr/testify/assert"
+)
+
+// applyEdits applies an edit script to a, returning the result and the number of inserted and deleted values
+func applyEdits[T any](t *testing.T, a []T, edits []Edit[T]) ([]T, int) {
+	var (
+		res   = []T{}
+		i     int
+		count int
+	)
+
+	for _, e := range edits {
+		switch e.Op {
+		case EditKeep:
+			assert.Equal(t, a[i:i+len(e.Values)], e.Values)
+			res = append(res, e.Values...)
+			i += len(e.Values)
+		case EditDelete:
+			assert.Equal(t, a[i:i+len(e.Values)], e.Values)
+			i += len(e.Values)
+			count += len(e.Values)
+		case EditInsert:
+			res = append(res, e.Values...)
+			count += len(e.Values)
+		}
+	}
+
+	assert.Equal(t, len(a), i)
+	return res, count
+}
+
+// lcsLen returns the length of the longest common subsequence of a and b
+func lcsLen(a, b []int) int {
+	l := make([][]int, len(a)+1)
+	for i := range l {
+		l[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				l[i][j] = l[i+1][j+1] + 1
+			} else if l[i+1][j] > l[i][j+1] {
+				l[i][j] = l[i+1][j]
+			} else {
+				l[i][j] = l[i][j+1]
+			}
+		}
+	}
+
+	return l[0][0]
+}
+
+func TestSliceDiff_(t *testing.T) {
+	assert.Nil(t, SliceDiff[int](nil, nil))
+	assert.Equal(t, []Edit[int]{{EditKeep, []int{1, 2}}}, SliceDiff([]int{1, 2}, []int{1, 2}))
+	assert.Equal(t, []Edit[int]{{EditInsert, []int{1, 2}}}, SliceDiff(nil, []int{1, 2}))
+	assert.Equal(t, []Edit[int]{{EditDelete, []int{1, 2}}}, SliceDiff([]int{1, 2}, nil))
+	assert.Equal(
+		t,
+		[]Edit[int]{{EditKeep, []int{1}}, {EditDelete, []int{2}}, {EditInsert, []int{3}}, {EditKeep, []int{4}}},
+		SliceDiff([]int{1, 2, 4}, []int{1, 3, 4}),
+	)
+	assert.Equal(
+		t,
+		[]Edit[int]{{EditDelete, []int{1, 2}}, {EditInsert, []int{3, 4}}},
+		SliceDiff([]int{1, 2}, []int{3, 4}),
+	)
+
+	// The classic example from the Myers paper has 5 edits
+	var (
+		a     = strings.Split("ABCABBA", "")
+		b     = strings.Split("CBABAC", "")
+		edits = SliceDiff(a, b)
+	)
+
+	res, count := applyEdits(t, a, edits)
+	assert.Equal(t, b, res)
+	assert.Equal(t, 5, count)
+
+	// Lines of text
+	assert.Equal(
+		t,
+		[]Edit[string]{
+			{EditKeep, []string{"a", "b"}},
+			{EditDelete, []string{"c"}},
+			{EditKeep, []string{"d"}},
+			{EditInsert, []string{"e", "f"}},
+		},
+		SliceDiff([]string{"a", "b", "c", "d"}, []string{"a", "b", "d", "e", "f"}),
+	)
+
+	// Random slices always produce b with the fewest edits, and a delete never follows an insert
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		var a, b []int
+		for j := rnd.Intn(40); j > 0; j-- {
+			a = append(a, rnd.Intn(4))
+		}
+		for j := rnd.Intn(40); j > 0; j-- {
+			b = append(b, rnd.Intn(4))
+		}
+
+		edits := SliceDiff(a, b)
+		res, count := applyEdits(t, a, edits)
+		assert.Equal(t, append([]int{}, b...), res)
+		assert.Equal(t, len(a)+len(b)-2*lcsLen(a, b), count)
+
+		for j := 1; j < len(edits); j++ {
+			assert.False(t, (edits[j-1].Op == EditInsert) && (edits[j].Op == EditDelete))
+			assert.NotEqual(t, edits[j-1].Op, edits[j].Op)
+		}
+	}
+
+	// Large slices that have nothing in common only take space proportional to their lengths
+	var c, d []int
+	for i := 0; i < 3000; i++ {
+		c, d = append(c, i), append(d, -i-1)
+	}
+
+	assert.Equal(t, []Edit[int]{{EditDelete, c}, {EditInsert, d}}, SliceDiff(c, d))
+}
+
+func TestSliceDiffBy_(t *testing.T) {
+	type rec struct {
+		id   int
+		tags []string
+	}
+
+	var (
+		a  = []rec{{1, []string{"x"}}, {2, nil}}
+		b  = []rec{{1, []string{"y"}}, {2, nil}, {3, nil}}
+		eq = func(x, y rec) bool { return x.id == y.id }
+	)
+
+	assert.Equal(t, []Edit[rec]{{EditKeep, a}, {EditInsert, []rec{b[2]}}}, SliceDiffBy(a, b, eq))
+}
+
+func TestMapDiff_(t *testing.T) {
+	md := MapDiff(map[string]int{"a": 1, "b": 2, "c": 3}, map[string]int{"a": 1, "b": 4, "d": 5})
+	assert.Equal(
+		t,
+		MapDifference[string, int]{
+			Added:   map[string]int{"d": 5},
+			Removed: map[string]int{"c": 3},
+			Changed: map[string]tuple.Two[int, int]{"b": tuple.Of2(2, 4)},
+		},
+		md,
+	)
+	assert.False(t, md.IsEmpty())
+
+	md = MapDiff(map[string]int{"a": 1}, map[string]int{"a": 1})
+	assert.Equal(t, MapDifference[string, int]{Added: map[string]int{}, Removed: map[string]int{}, Changed: map[string]tuple.Two[int, int]{}}, md)
+	assert.True(t, md.IsEmpty())
+	assert.True(t, MapDiff[string, int](nil, nil).IsEmpty())
+
+	// Uncomparable values
+	assert.Equal(
+		t,
+		map[string]tuple.Two[[]int, []int]{"b": tuple.Of2([]int{2}, []int{3})},
+		MapDiffBy(
+			map[string][]int{"a": {1}, "b": {2}},
+			map[string][]int{"a": {1}, "b": {3}},
+			func(x, y []int) bool { return (len(x) == len(y)) && (x[0] == y[0]) },
+		).Changed,
+	)
+}
@@ -3,12 +3,14 @@ package funcs
 // SPDX-License-Identifier: Apache-2.0
 
 import (
+	"context"
 	"fmt"
 	"math/cmplx"
 	"reflect"
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/bantling/micro/constraint"
 	"github.com/bantling/micro/tuple"
@@ -29,6 +31,7 @@ const (
 	convertToSlice2ElemMsg     = "expected %s[%v][%v] to be %T, not %T"
 	assertMapTypeMsg           = "expected %s to be %T, not %T"
 	assertMapTypeValueMsg      = "expected %s[%v] to be %T, not %T"
+	raceNoFuncsMsg             = "Race requires at least one function"
 
 	// The ALL constant is for some remove funcs
 	ALL = true
@@ -70,6 +73,18 @@ func SliceCopy[T any](slc []T) (res []T) {
 	return
 }
 
+// SliceCountBy counts slice elements by the key returned by the provided func for each element.
+// If the slice is nil or empty, an empty map is returned.
+func SliceCountBy[T any, K comparable](slc []T, fn func(T) K) (res map[K]int) {
+	res = map[K]int{}
+
+	for _, val := range slc {
+		res[fn(val)]++
+	}
+
+	return
+}
+
 // SliceFlatten flattens a slice of any number of dimensions into a one dimensional slice.
 // The slice is received as type any, because there is no way to describe a slice of any number of dimensions
 // using generics.
@@ -136,6 +151,20 @@ func SliceFlatten[T any](value any) []T {
 	return rslc
 }
 
+// SliceGroupBy groups slice elements by the key returned by the provided func for each element.
+// The elements of each group are in the same order as the slice.
+// If the slice is nil or empty, an empty map is returned.
+func SliceGroupBy[T any, K comparable](slc []T, fn func(T) K) (res map[K][]T) {
+	res = map[K][]T{}
+
+	for _, val := range slc {
+		k := fn(val)
+		res[k] = append(res[k], val)
+	}
+
+	return
+}
+
 // SliceIndex returns the first of the following given a slice, index, and optional default value:
 // 1. slice[index] if the slice is non-nil and length > index
 // 2. slice[length + index] if the slice is non-nil and index < 0 and length + index >= 0
@@ -1392,3 +1421,91 @@ func CamelCaseToSnakeCase(camel string) string {
 
 	return sb.String()
 }
+
+// ==== Context
+
+// WithContext adapts a function that accepts a context, so that it returns as soon as the context is done, even if the
+// function does not check the context itself:
+// - If the context is already done, the function is not called, and the context error is returned
+// - If the function returns before the context is done, the function result is returned
+// - If the context is done before the function returns, the context error is returned
+//
+// In the last case, the function continues to run in a separate goroutine until it returns, and its result is discarded.
+func WithContext(fn func(context.Context) error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Buffered so the goroutine can always write the result, and exit, even if nothing reads it
+		done := make(chan error, 1)
+		go func() {
+			done <- fn(ctx)
+		}()
+
+		select {
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// WithTimeout adapts a function that accepts a context, so that it is given a child context that times out after d.
+// See WithContext for how the result is determined.
+func WithTimeout(fn func(context.Context) error, d time.Duration) func(context.Context) error {
+	withCtx := WithContext(fn)
+
+	return func(ctx context.Context) error {
+		tctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+
+		return withCtx(tctx)
+	}
+}
+
+// Race calls all the given functions concurrently with a child context of ctx, and returns the first successful result.
+// As soon as one function succeeds, the child context is cancelled, so the remaining functions can stop early.
+// If all functions fail, the error of the first function to fail is returned.
+// If ctx is done before any function succeeds, the context error is returned.
+//
+// Panics if no functions are provided.
+func Race[T any](ctx context.Context, fns ...func(context.Context) (T, error)) (T, error) {
+	if len(fns) == 0 {
+		panic(fmt.Errorf(raceNoFuncsMsg))
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Buffered so that every goroutine can write its result, and exit, even after Race has returned
+	results := make(chan tuple.Two[T, error], len(fns))
+	for _, fn := range fns {
+		go func(fn func(context.Context) (T, error)) {
+			results <- tuple.Of2(fn(cctx))
+		}(fn)
+	}
+
+	var (
+		zv       T
+		firstErr error
+	)
+
+	for range fns {
+		select {
+		case res := <-results:
+			if res.U == nil {
+				return res.T, nil
+			}
+
+			if firstErr == nil {
+				firstErr = res.U
+			}
+		case <-ctx.Done():
+			return zv, ctx.Err()
+		}
+	}
+
+	return zv, firstErr
+}
@@ -3,11 +3,13 @@ package funcs
 // SPDX-License-Identifier: Apache-2.0
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 	"reflect"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/bantling/micro/tuple"
 	"github.com/stretchr/testify/assert"
@@ -36,6 +38,15 @@ func TestSliceCopy_(t *testing.T) {
 	assert.Equal(t, slc, slc2)
 }
 
+func TestSliceCountBy_(t *testing.T) {
+	isEven := func(i int) bool { return i%2 == 0 }
+
+	assert.Equal(t, map[bool]int{}, SliceCountBy([]int(nil), isEven))
+	assert.Equal(t, map[bool]int{}, SliceCountBy([]int{}, isEven))
+	assert.Equal(t, map[bool]int{false: 3, true: 2}, SliceCountBy([]int{1, 2, 3, 4, 5}, isEven))
+	assert.Equal(t, map[int]int{1: 2, 3: 1}, SliceCountBy([]string{"a", "bcd", "e"}, func(s string) int { return len(s) }))
+}
+
 func TestSliceFlatten_(t *testing.T) {
 	assert.Equal(t, []int{}, SliceFlatten[int](nil))
 
@@ -70,6 +81,19 @@ func TestSliceFlatten_(t *testing.T) {
 	)
 }
 
+func TestSliceGroupBy_(t *testing.T) {
+	isEven := func(i int) bool { return i%2 == 0 }
+
+	assert.Equal(t, map[bool][]int{}, SliceGroupBy([]int(nil), isEven))
+	assert.Equal(t, map[bool][]int{}, SliceGroupBy([]int{}, isEven))
+	assert.Equal(t, map[bool][]int{false: {1, 3, 5}, true: {2, 4}}, SliceGroupBy([]int{1, 2, 3, 4, 5}, isEven))
+	assert.Equal(
+		t,
+		map[int][]string{1: {"a", "e"}, 3: {"bcd"}},
+		SliceGroupBy([]string{"a", "bcd", "e"}, func(s string) int { return len(s) }),
+	)
+}
+
 func TestSliceIndex_(t *testing.T) {
 	slc := []int{}
 	assert.Equal(t, 0, SliceIndex(slc, 0))
@@ -1432,3 +1456,102 @@ func TestCamelCaseToSnakeCase(t *testing.T) {
 	assert.Equal(t, "name", CamelCaseToSnakeCase("Name"))
 	assert.Equal(t, "name", CamelCaseToSnakeCase("name"))
 }
+
+// ==== Context
+
+func TestWithContext_(t *testing.T) {
+	// Function returns first
+	anErr := fmt.Errorf("An err")
+	assert.Nil(t, WithContext(func(context.Context) error { return nil })(context.Background()))
+	assert.Equal(t, anErr, WithContext(func(context.Context) error { return anErr })(context.Background()))
+
+	// Context already done, function is not called
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	assert.Equal(t, context.Canceled, WithContext(func(context.Context) error { called = true; return nil })(ctx))
+	assert.False(t, called)
+
+	// Context is done first, function ignores context
+	ctx, cancel = context.WithCancel(context.Background())
+	block := make(chan struct{})
+	defer close(block)
+	go cancel()
+	assert.Equal(t, context.Canceled, WithContext(func(context.Context) error { <-block; return nil })(ctx))
+}
+
+func TestWithTimeout_(t *testing.T) {
+	// Function returns first
+	assert.Nil(t, WithTimeout(func(context.Context) error { return nil }, time.Minute)(context.Background()))
+
+	// Function honours the context
+	assert.Equal(
+		t,
+		context.DeadlineExceeded,
+		WithTimeout(func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }, time.Millisecond)(context.Background()),
+	)
+
+	// Function ignores the context
+	block := make(chan struct{})
+	defer close(block)
+	assert.Equal(
+		t,
+		context.DeadlineExceeded,
+		WithTimeout(func(context.Context) error { <-block; return nil }, time.Millisecond)(context.Background()),
+	)
+}
+
+func TestRace_(t *testing.T) {
+	var (
+		anErr  = fmt.Errorf("An err")
+		anErr2 = fmt.Errorf("An err 2")
+		wait   = func(ctx context.Context) (int, error) { <-ctx.Done(); return 0, ctx.Err() }
+		fail   = func(context.Context) (int, error) { return 0, anErr }
+	)
+
+	// First success wins, others are cancelled
+	cancelled := make(chan bool, 1)
+	assert.Equal(
+		t,
+		tuple.Of2(1, error(nil)),
+		tuple.Of2(Race(
+			context.Background(),
+			func(ctx context.Context) (int, error) { <-ctx.Done(); cancelled <- true; return 0, ctx.Err() },
+			fail,
+			func(context.Context) (int, error) { return 1, nil },
+		)),
+	)
+	assert.True(t, <-cancelled)
+
+	// All fail, first error is returned
+	second := make(chan struct{})
+	assert.Equal(
+		t,
+		tuple.Of2(0, anErr),
+		tuple.Of2(Race(
+			context.Background(),
+			func(context.Context) (int, error) { defer close(second); return 0, anErr },
+			func(context.Context) (int, error) { <-second; return 0, anErr2 },
+		)),
+	)
+
+	// Parent context is done first
+	ctx, cancel := context.WithCancel(context.Background())
+	go cancel()
+	block := make(chan struct{})
+	defer close(block)
+	assert.Equal(
+		t,
+		tuple.Of2(0, context.Canceled),
+		tuple.Of2(Race(ctx, func(context.Context) (int, error) { <-block; return 0, nil }, wait)),
+	)
+
+	// No functions
+	TryTo(
+		func() {
+			Race[int](context.Background())
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf(raceNoFuncsMsg), e) },
+	)
+}
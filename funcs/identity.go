@@ -0,0 +1,134 @@
+package funcs
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// identityRemover is an IdentityCache of any type, that can remove the value of a collected object
+type identityRemover interface {
+	remove(addr uintptr)
+}
+
+var (
+	// identityMu guards identityRegistry
+	identityMu sync.Mutex
+
+	// identityRegistry maps the address of each object that has a finalizer to the caches that it has been cached in.
+	// One finalizer removes the object from all caches, as an object can only have one finalizer.
+	identityRegistry = map[uintptr]map[identityRemover]bool{}
+)
+
+// IdentityCache memoizes a value computed for each object, keyed by the identity (address) of the object rather than
+// its value, such as metadata computed once for each of a set of long lived objects. The cache does not keep the
+// objects alive: the value of an object is removed when the garbage collector finalizes it, so that a new object that
+// is later allocated at the same address does not see the value of the old object.
+//
+// An object must be allocated by new, a composite literal, or make, as required by runtime.SetFinalizer, must not have
+// a finalizer of its own, and must not be of a zero size type, as all zero size objects may have the same address. Any
+// number of caches can hold a value for the same object.
+//
+// Values of objects that change can be discarded with Invalidate, so that the next Get computes them again.
+//
+// Values are held strongly, so a value that refers to its object, directly or indirectly, keeps the object reachable:
+// the object is never finalized, and its value stays in the cache until it is removed with Invalidate or InvalidateAll.
+// Each cache is also held by the finalizer of every object it has a value for, so a cache that is no longer used is not
+// freed until those objects are collected.
+type IdentityCache[K, V any] struct {
+	mu      sync.Mutex
+	values  map[uintptr]V
+	compute func(*K) V
+}
+
+// NewIdentityCache constructs an IdentityCache that computes the value of an object with the given func
+func NewIdentityCache[K, V any](compute func(*K) V) *IdentityCache[K, V] {
+	return &IdentityCache[K, V]{values: map[uintptr]V{}, compute: compute}
+}
+
+// identityFinalize is the finalizer of a cached object, that removes it from every cache it is in
+func identityFinalize[K any](key *K) {
+	addr := uintptr(unsafe.Pointer(key))
+
+	identityMu.Lock()
+	caches := identityRegistry[addr]
+	delete(identityRegistry, addr)
+	identityMu.Unlock()
+
+	for cache := range caches {
+		cache.remove(addr)
+	}
+}
+
+// Get returns the value of the given object, computing it if it is not cached.
+// The value is computed without holding a lock, so that computing it can use the cache for other objects. If two
+// goroutines compute the value of the same object at the same time, the first value that is cached is returned by both.
+func (c *IdentityCache[K, V]) Get(key *K) V {
+	if val, haveIt := c.Lookup(key); haveIt {
+		return val
+	}
+
+	val := c.compute(key)
+	addr := uintptr(unsafe.Pointer(key))
+
+	c.mu.Lock()
+	if cached, haveIt := c.values[addr]; haveIt {
+		c.mu.Unlock()
+		return cached
+	}
+	c.values[addr] = val
+	c.mu.Unlock()
+
+	identityMu.Lock()
+	defer identityMu.Unlock()
+
+	caches, haveIt := identityRegistry[addr]
+	if !haveIt {
+		caches = map[identityRemover]bool{}
+		identityRegistry[addr] = caches
+		runtime.SetFinalizer(key, identityFinalize[K])
+	}
+	caches[c] = true
+
+	return val
+}
+
+// Lookup returns the cached value of the given object and true, or the zero value and false if it is not cached
+func (c *IdentityCache[K, V]) Lookup(key *K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	val, haveIt := c.values[uintptr(unsafe.Pointer(key))]
+	return val, haveIt
+}
+
+// Invalidate removes the cached value of the given object, if it has one
+func (c *IdentityCache[K, V]) Invalidate(key *K) {
+	c.remove(uintptr(unsafe.Pointer(key)))
+}
+
+// InvalidateAll removes all cached values
+func (c *IdentityCache[K, V]) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values = map[uintptr]V{}
+}
+
+// Len returns the number of cached values
+func (c *IdentityCache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.values)
+}
+
+// remove is the identityRemover interface
+func (c *IdentityCache[K, V]) remove(addr uintptr) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.values, addr)
+}
@@ -0,0 +1,171 @@
+package funcs
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/bantling/micro/tuple"
+	"github.com/stretchr/testify/assert"
+)
+
+type identityObj struct {
+	name string
+}
+
+func TestIdentityCache_(t *testing.T) {
+	var (
+		calls int
+		c     = NewIdentityCache(func(o *identityObj) int { calls++; return len(o.name) })
+		o1    = &identityObj{"one"}
+		o2    = &identityObj{"one"}
+	)
+
+	// Values are computed once per object, not per value
+	assert.Equal(t, tuple.Of2(0, false), tuple.Of2(c.Lookup(o1)))
+	assert.Equal(t, 3, c.Get(o1))
+	assert.Equal(t, 3, c.Get(o1))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 3, c.Get(o2))
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, tuple.Of2(3, true), tuple.Of2(c.Lookup(o1)))
+
+	// Invalidate
+	o1.name = "three"
+	assert.Equal(t, 3, c.Get(o1))
+	c.Invalidate(o1)
+	assert.Equal(t, tuple.Of2(0, false), tuple.Of2(c.Lookup(o1)))
+	assert.Equal(t, 5, c.Get(o1))
+	assert.Equal(t, 3, calls)
+
+	c.InvalidateAll()
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, 5, c.Get(o1))
+	assert.Equal(t, 4, calls)
+
+	// Another cache of the same object
+	c2 := NewIdentityCache(func(o *identityObj) string { return o.name + "!" })
+	assert.Equal(t, "three!", c2.Get(o1))
+	runtime.KeepAlive(o1)
+	runtime.KeepAlive(o2)
+}
+
+func TestIdentityCacheCollected_(t *testing.T) {
+	var (
+		c  = NewIdentityCache(func(o *identityObj) string { return o.name })
+		c2 = NewIdentityCache(func(o *identityObj) int { return len(o.name) })
+		o  = &identityObj{"kept"}
+	)
+
+	func() {
+		for i := 0; i < 10; i++ {
+			tmp := &identityObj{"temp"}
+			c.Get(tmp)
+			c2.Get(tmp)
+		}
+	}()
+	c.Get(o)
+	assert.Equal(t, 11, c.Len())
+
+	// Finalizers run in their own goroutine after a collection
+	for i := 0; (i < 100) && ((c.Len() > 1) || (c2.Len() > 0)); i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.Equal(t, 1, c.Len())
+	assert.Equal(t, 0, c2.Len())
+	assert.Equal(t, tuple.Of2("kept", true), tuple.Of2(c.Lookup(o)))
+	runtime.KeepAlive(o)
+}
+
+func TestIdentityCacheFreed_(t *testing.T) {
+	var (
+		c     = NewIdentityCache(func(o *identityObj) string { return o.name })
+		self  = NewIdentityCache(func(o *identityObj) *identityObj { return o })
+		addrs []uintptr
+	)
+
+	// registered returns how many of the addresses are in the registry
+	registered := func() int {
+		identityMu.Lock()
+		defer identityMu.Unlock()
+
+		n := 0
+		for _, addr := range addrs {
+			if _, haveIt := identityRegistry[addr]; haveIt {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	// collect runs collections until the cache and the registry have no more than the given number of entries
+	collect := func(cacheLen, registryLen int) {
+		for i := 0; (i < 100) && ((c.Len() > cacheLen) || (registered() > registryLen)); i++ {
+			runtime.GC()
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	func() {
+		for i := 0; i < 10; i++ {
+			tmp := &identityObj{"temp"}
+			c.Get(tmp)
+			addrs = append(addrs, uintptr(unsafe.Pointer(tmp)))
+		}
+	}()
+	assert.Equal(t, 10, registered())
+
+	// The cache entries and registry entries are freed once the keys are collected
+	collect(0, 0)
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, 0, registered())
+
+	// A value that refers to its key keeps the key alive, until the value is invalidated
+	func() {
+		tmp := &identityObj{"self"}
+		self.Get(tmp)
+		addrs = []uintptr{uintptr(unsafe.Pointer(tmp))}
+	}()
+
+	for i := 0; i < 5; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 1, self.Len())
+	assert.Equal(t, 1, registered())
+
+	self.InvalidateAll()
+	collect(0, 0)
+	assert.Equal(t, 0, self.Len())
+	assert.Equal(t, 0, registered())
+}
+
+func TestIdentityCacheConcurrent_(t *testing.T) {
+	var (
+		c    = NewIdentityCache(func(o *identityObj) string { return o.name })
+		objs = []*identityObj{{"a"}, {"b"}, {"c"}}
+		wg   sync.WaitGroup
+	)
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				o := objs[j%len(objs)]
+				assert.Equal(t, o.name, c.Get(o))
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, 3, c.Len())
+}
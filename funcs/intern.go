@@ -0,0 +1,137 @@
+package funcs
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"strings"
+	"sync"
+)
+
+const (
+	// internShards is the number of shards of an InternPool, which must be a power of 2
+	internShards = 64
+
+	// defaultInternLimit is the per shard limit of the pool used by Intern and InternBytes
+	defaultInternLimit = 4096
+)
+
+var (
+	// defaultInternPool is the pool used by Intern and InternBytes
+	defaultInternPool = NewInternPool(defaultInternLimit)
+)
+
+// internShard is one shard of an InternPool
+type internShard struct {
+	mu   sync.Mutex
+	strs map[string]string
+}
+
+// InternPool is a pool of strings, where interning a string returns the one copy of it held in the pool.
+// Decoding many documents that repeat the same strings, such as JSON object keys, allocates a new copy of each string
+// every time it is decoded. Interning the strings allows the duplicate copies to be collected.
+//
+// The pool is split into shards by a hash of the string, each with its own lock, so that concurrent goroutines
+// interning different strings rarely contend.
+//
+// An InternPool can have a limit on the number of strings per shard. When a shard reaches the limit, it is emptied
+// before the next new string is added, so that strings that are no longer used can be collected. This provides weak
+// reference behaviour, at the cost of occasionally interning a string again that was already in the pool.
+type InternPool struct {
+	shards [internShards]internShard
+	limit  uint
+}
+
+// NewInternPool constructs an InternPool with an optional limit on the number of strings per shard.
+// If the limit is not provided or 0, the pool is unlimited, and strings are only removed by calling Reset.
+func NewInternPool(limit ...uint) *InternPool {
+	p := &InternPool{limit: SliceIndex(limit, 0)}
+	for i := range p.shards {
+		p.shards[i].strs = map[string]string{}
+	}
+
+	return p
+}
+
+// shardOf returns the shard of a string or its bytes, using an FNV-1a hash
+func shardOf[S string | []byte](p *InternPool, s S) *internShard {
+	var h uint32 = 2166136261
+	for i := 0; i < len(s); i++ {
+		h = (h ^ uint32(s[i])) * 16777619
+	}
+
+	return &p.shards[h&(internShards-1)]
+}
+
+// lookup returns the pooled copy of s, adding the result of newStr if s is not in the pool
+func (p *InternPool) lookup(shard *internShard, s string, newStr func() string) string {
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if res, haveIt := shard.strs[s]; haveIt {
+		return res
+	}
+
+	if (p.limit > 0) && (uint(len(shard.strs)) >= p.limit) {
+		shard.strs = map[string]string{}
+	}
+
+	res := newStr()
+	shard.strs[res] = res
+	return res
+}
+
+// Intern returns the pooled copy of s, adding a copy of s to the pool if it is not already in it.
+// The copy ensures the pool does not keep a larger string alive that s is a substring of.
+func (p *InternPool) Intern(s string) string {
+	return p.lookup(shardOf(p, s), s, func() string { return strings.Clone(s) })
+}
+
+// InternBytes returns the pooled copy of the string of b, adding a copy to the pool if it is not already in it.
+// Unlike string(b), no string is allocated if the string is already in the pool.
+func (p *InternPool) InternBytes(b []byte) string {
+	// The compiler does not allocate for a string(b) conversion that is only used as a map key
+	shard := shardOf(p, b)
+	shard.mu.Lock()
+	res, haveIt := shard.strs[string(b)]
+	shard.mu.Unlock()
+
+	if haveIt {
+		return res
+	}
+
+	str := string(b)
+	return p.lookup(shard, str, func() string { return str })
+}
+
+// Len returns the number of strings in the pool
+func (p *InternPool) Len() int {
+	n := 0
+	for i := range p.shards {
+		shard := &p.shards[i]
+		shard.mu.Lock()
+		n += len(shard.strs)
+		shard.mu.Unlock()
+	}
+
+	return n
+}
+
+// Reset empties the pool
+func (p *InternPool) Reset() {
+	for i := range p.shards {
+		shard := &p.shards[i]
+		shard.mu.Lock()
+		shard.strs = map[string]string{}
+		shard.mu.Unlock()
+	}
+}
+
+// Intern returns the copy of s in a shared pool with a limit of 4096 strings per shard, see InternPool
+func Intern(s string) string {
+	return defaultInternPool.Intern(s)
+}
+
+// InternBytes returns the copy of the string of b in the same shared pool as Intern, see InternPool.InternBytes
+func InternBytes(b []byte) string {
+	return defaultInternPool.InternBytes(b)
+}
@@ -0,0 +1,108 @@
+package funcs
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sameString is true if both strings share the same bytes
+func sameString(a, b string) bool {
+	return (len(a) == len(b)) &&
+		((*reflect.StringHeader)(unsafe.Pointer(&a)).Data == (*reflect.StringHeader)(unsafe.Pointer(&b)).Data)
+}
+
+func TestInternPool_(t *testing.T) {
+	var (
+		p  = NewInternPool()
+		s1 = string([]byte("key"))
+		s2 = string([]byte("key"))
+	)
+
+	assert.False(t, sameString(s1, s2))
+
+	// A copy of the first string is pooled
+	pooled := p.Intern(s1)
+	assert.Equal(t, "key", pooled)
+	assert.False(t, sameString(s1, pooled))
+	assert.True(t, sameString(pooled, p.Intern(s1)))
+	assert.True(t, sameString(pooled, p.Intern(s2)))
+	assert.True(t, sameString(pooled, p.InternBytes([]byte("key"))))
+	assert.Equal(t, 1, p.Len())
+
+	// A substring does not keep the larger string in the pool
+	large := string([]byte("prefix-sub"))
+	assert.False(t, sameString(large[7:], p.Intern(large[7:])))
+
+	// Bytes are copied when added
+	b := []byte("other")
+	s3 := p.InternBytes(b)
+	b[0] = 'X'
+	assert.Equal(t, "other", s3)
+	assert.True(t, sameString(s3, p.Intern("other")))
+	assert.Equal(t, 3, p.Len())
+
+	// No allocation for a string already in the pool
+	assert.Equal(t, float64(0), testing.AllocsPerRun(100, func() { p.InternBytes([]byte("key")) }))
+
+	p.Reset()
+	assert.Equal(t, 0, p.Len())
+	assert.False(t, sameString(pooled, p.Intern(s2)))
+}
+
+func TestInternPoolLimit_(t *testing.T) {
+	p := NewInternPool(2)
+	for i := 0; i < 1000; i++ {
+		p.Intern(fmt.Sprint(i))
+	}
+
+	// Shards are emptied when they reach the limit
+	assert.True(t, p.Len() <= 2*internShards)
+	assert.True(t, p.Len() > 0)
+
+	// Unlimited
+	p = NewInternPool()
+	for i := 0; i < 1000; i++ {
+		p.Intern(fmt.Sprint(i))
+	}
+	assert.Equal(t, 1000, p.Len())
+}
+
+func TestInternPoolConcurrent_(t *testing.T) {
+	var (
+		p   = NewInternPool()
+		wg  sync.WaitGroup
+		res [8][]string
+	)
+
+	for g := range res {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				res[g] = append(res[g], p.Intern(fmt.Sprint(i)))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// Every goroutine got the same copy of each string
+	for g := range res {
+		for i, s := range res[g] {
+			assert.True(t, sameString(res[0][i], s))
+		}
+	}
+	assert.Equal(t, 100, p.Len())
+}
+
+func TestIntern_(t *testing.T) {
+	s1 := string([]byte("shared"))
+	assert.True(t, sameString(Intern(s1), Intern(string([]byte("shared")))))
+	assert.True(t, sameString(Intern(s1), InternBytes([]byte("shared"))))
+}
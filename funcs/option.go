@@ -0,0 +1,90 @@
+package funcs
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+)
+
+const (
+	requiredOptionMsg = "The %s option is required"
+)
+
+// Option sets an option of a configuration of type T, for functions that accept any number of options rather than a
+// parameter for every setting. Eg:
+//
+//	type config struct { retries int }
+//
+//	func WithRetries(n int) Option[config] {
+//	  return func(c *config) { c.retries = n }
+//	}
+type Option[T any] func(*T)
+
+// ValidatedOption is an Option that returns an error if it cannot be set, such as a value that is out of range
+type ValidatedOption[T any] func(*T) error
+
+// ApplyOptions applies options in the order given, so that a later option overrides an earlier one.
+// Nil options are ignored. Returns cfg, so that it can be used in a return statement.
+func ApplyOptions[T any](cfg *T, opts ...Option[T]) *T {
+	for _, opt := range opts {
+		if opt != nil {
+			opt(cfg)
+		}
+	}
+
+	return cfg
+}
+
+// OfOptions returns a copy of defaults with the options applied in the order given
+func OfOptions[T any](defaults T, opts ...Option[T]) T {
+	return *ApplyOptions(&defaults, opts...)
+}
+
+// ApplyValidatedOptions applies options in the order given, stopping at the first one that returns an error.
+// Nil options are ignored.
+func ApplyValidatedOptions[T any](cfg *T, opts ...ValidatedOption[T]) error {
+	for _, opt := range opts {
+		if opt != nil {
+			if err := opt(cfg); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// MustApplyValidatedOptions is a must version of ApplyValidatedOptions
+func MustApplyValidatedOptions[T any](cfg *T, opts ...ValidatedOption[T]) {
+	Must(ApplyValidatedOptions(cfg, opts...))
+}
+
+// Validate validates a configuration after the options have been applied, returning the first error returned by the
+// validators, which is useful for settings that are required, or depend on each other.
+func Validate[T any](cfg *T, validators ...func(*T) error) error {
+	for _, validator := range validators {
+		if err := validator(cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MustValidate is a must version of Validate
+func MustValidate[T any](cfg *T, validators ...func(*T) error) {
+	Must(Validate(cfg, validators...))
+}
+
+// Required returns a validator for Validate, that returns an error if the field returned by the given func is the zero
+// value. The name is the option name used in the error message.
+func Required[T any, V comparable](name string, field func(*T) V) func(*T) error {
+	return func(cfg *T) error {
+		var zv V
+		if field(cfg) == zv {
+			return fmt.Errorf(requiredOptionMsg, name)
+		}
+
+		return nil
+	}
+}
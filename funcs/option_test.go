@@ -0,0 +1,106 @@
+package funcs
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type optionConfig struct {
+	url     string
+	retries int
+	timeout time.Duration
+}
+
+func withRetries(n int) Option[optionConfig] {
+	return func(c *optionConfig) { c.retries = n }
+}
+
+func withTimeout(d time.Duration) ValidatedOption[optionConfig] {
+	return func(c *optionConfig) error {
+		if d <= 0 {
+			return fmt.Errorf("timeout must be positive")
+		}
+
+		c.timeout = d
+		return nil
+	}
+}
+
+func TestApplyOptions_(t *testing.T) {
+	// Later options override earlier ones, nil options are ignored
+	cfg := optionConfig{url: "a"}
+	assert.Equal(t, &cfg, ApplyOptions(&cfg, withRetries(1), nil, withRetries(3)))
+	assert.Equal(t, optionConfig{url: "a", retries: 3}, cfg)
+
+	// No options
+	assert.Equal(t, optionConfig{url: "a", retries: 3}, *ApplyOptions(&cfg))
+
+	// Defaults are copied
+	defaults := optionConfig{retries: 5}
+	assert.Equal(t, optionConfig{retries: 2}, OfOptions(defaults, withRetries(2)))
+	assert.Equal(t, optionConfig{retries: 5}, defaults)
+}
+
+func TestApplyValidatedOptions_(t *testing.T) {
+	var cfg optionConfig
+	assert.Nil(t, ApplyValidatedOptions(&cfg, withTimeout(time.Second), nil))
+	assert.Equal(t, time.Second, cfg.timeout)
+
+	// Stops at the first error
+	var applied bool
+	assert.Equal(
+		t,
+		fmt.Errorf("timeout must be positive"),
+		ApplyValidatedOptions(&cfg, withTimeout(0), func(*optionConfig) error { applied = true; return nil }),
+	)
+	assert.False(t, applied)
+	assert.Equal(t, time.Second, cfg.timeout)
+
+	MustApplyValidatedOptions(&cfg, withTimeout(time.Minute))
+	assert.Equal(t, time.Minute, cfg.timeout)
+
+	TryTo(
+		func() {
+			MustApplyValidatedOptions(&cfg, withTimeout(-1))
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf("timeout must be positive"), e)
+		},
+	)
+}
+
+func TestValidate_(t *testing.T) {
+	var (
+		url     = Required("url", func(c *optionConfig) string { return c.url })
+		retries = Required("retries", func(c *optionConfig) int { return c.retries })
+		cfg     = optionConfig{url: "a", retries: 1}
+	)
+
+	assert.Nil(t, Validate(&cfg))
+	assert.Nil(t, Validate(&cfg, url, retries))
+
+	// First error
+	cfg = optionConfig{}
+	assert.Equal(t, fmt.Errorf(requiredOptionMsg, "url"), Validate(&cfg, url, retries))
+
+	cfg.url = "a"
+	assert.Equal(t, fmt.Errorf(requiredOptionMsg, "retries"), Validate(&cfg, url, retries))
+
+	MustValidate(&optionConfig{url: "a"}, url)
+
+	TryTo(
+		func() {
+			MustValidate(&cfg, retries)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(requiredOptionMsg, "retries"), e)
+		},
+	)
+}
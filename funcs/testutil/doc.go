@@ -0,0 +1,5 @@
+// Package testutil is require-style test assertions built on the idioms of this module: Cmp values, panics with errors,
+// and iterators. Each assertion stops the test on failure.
+//
+// SPDX-License-Identifier: Apache-2.0
+package testutil
@@ -0,0 +1,123 @@
+package testutil
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/bantling/micro/constraint"
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/iter"
+)
+
+const (
+	errEqualCmpMsg         = "expected %v to compare equal to %v"
+	errPanicsNoPanicMsg    = "expected a panic with error %q, but there was no panic"
+	errPanicsNotErrorMsg   = "expected a panic with error %q, but the panic value is %T: %v"
+	errPanicsWrongErrorMsg = "expected a panic with error %q, not %q"
+	errIterErrorMsg        = "expected iterator to produce %v, but it failed after %v with error %q"
+	errIterNotEqualMsg     = "expected iterator to produce %v, not %v"
+	errIterNoErrorMsg      = "expected iterator to fail with error %q after %v, but it produced %v without error"
+	errIterWrongErrorMsg   = "expected iterator to fail with error %q, not %q"
+	errIterWrongPrefixMsg  = "expected iterator to produce %v before failing, not %v"
+)
+
+// EqualCmp requires that expected.Cmp(actual) == 0, which is useful for types like *big.Int where pointers differ
+func EqualCmp[T constraint.Cmp[T]](t testing.TB, expected, actual T) {
+	t.Helper()
+
+	if expected.Cmp(actual) != 0 {
+		t.Fatalf(errEqualCmpMsg, actual, expected)
+	}
+}
+
+// PanicsWithError requires that fn panics with an error that either errors.Is the expected error, or has the same message
+func PanicsWithError(t testing.TB, expected error, fn func()) {
+	t.Helper()
+
+	var (
+		panicked bool
+		val      any
+	)
+
+	funcs.TryTo(
+		fn,
+		func(e any) {
+			panicked = true
+			val = e
+		},
+	)
+
+	if !panicked {
+		t.Fatalf(errPanicsNoPanicMsg, expected)
+	}
+
+	err, isa := val.(error)
+	if !isa {
+		t.Fatalf(errPanicsNotErrorMsg, expected, val, val)
+	}
+
+	if !(errors.Is(err, expected) || (err.Error() == expected.Error())) {
+		t.Fatalf(errPanicsWrongErrorMsg, expected, err)
+	}
+}
+
+// IterEquals requires that the iterator produces exactly the expected values, followed by iter.EOI.
+// A nil or empty expected slice requires an empty iterator.
+func IterEquals[T any](t testing.TB, expected []T, it iter.Iter[T]) {
+	t.Helper()
+
+	actual, err := drain(it)
+	if err != nil {
+		t.Fatalf(errIterErrorMsg, expected, actual, err)
+	}
+
+	if !equalSlices(expected, actual) {
+		t.Fatalf(errIterNotEqualMsg, expected, actual)
+	}
+}
+
+// IterFails requires that the iterator produces exactly the expected values, followed by an error other than iter.EOI
+// that either errors.Is the expected error, or has the same message.
+func IterFails[T any](t testing.TB, expected []T, expectedErr error, it iter.Iter[T]) {
+	t.Helper()
+
+	actual, err := drain(it)
+	if err == nil {
+		t.Fatalf(errIterNoErrorMsg, expectedErr, expected, actual)
+	}
+
+	if !(errors.Is(err, expectedErr) || (err.Error() == expectedErr.Error())) {
+		t.Fatalf(errIterWrongErrorMsg, expectedErr, err)
+	}
+
+	if !equalSlices(expected, actual) {
+		t.Fatalf(errIterWrongPrefixMsg, expected, actual)
+	}
+}
+
+// equalSlices is true if both slices are empty, or are deeply equal
+func equalSlices[T any](expected, actual []T) bool {
+	return ((len(expected) == 0) && (len(actual) == 0)) || reflect.DeepEqual(expected, actual)
+}
+
+// drain reads all values of an iterator, returning a non-nil slice of values read before an error.
+// The returned error is nil if the iterator ended with iter.EOI.
+func drain[T any](it iter.Iter[T]) ([]T, error) {
+	res := []T{}
+
+	for {
+		val, err := it.Next()
+		if err != nil {
+			if err == iter.EOI {
+				err = nil
+			}
+
+			return res, err
+		}
+
+		res = append(res, val)
+	}
+}
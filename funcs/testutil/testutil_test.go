@@ -0,0 +1,140 @@
+package testutil
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/bantling/micro/iter"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTB records the failure message instead of failing the test.
+// Fatalf must not return, so it exits the goroutine, which is what testing.T does too.
+type fakeTB struct {
+	testing.TB
+	msg string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Fatalf(format string, args ...any) {
+	f.msg = fmt.Sprintf(format, args...)
+	panic(f)
+}
+
+// run executes fn with a fakeTB, and returns the failure message, which is empty if fn did not fail
+func run(fn func(testing.TB)) (msg string) {
+	tb := &fakeTB{}
+
+	defer func() {
+		if e := recover(); e != nil {
+			if e != tb {
+				panic(e)
+			}
+
+			msg = tb.msg
+		}
+	}()
+
+	fn(tb)
+	return
+}
+
+func TestEqualCmp_(t *testing.T) {
+	assert.Equal(t, "", run(func(tb testing.TB) { EqualCmp(tb, big.NewInt(1), big.NewInt(1)) }))
+	assert.Equal(t, "expected 2 to compare equal to 1", run(func(tb testing.TB) { EqualCmp(tb, big.NewInt(1), big.NewInt(2)) }))
+}
+
+func TestPanicsWithError_(t *testing.T) {
+	var (
+		anErr    = fmt.Errorf("an error")
+		wrapped  = fmt.Errorf("wrapped: %w", anErr)
+		sameText = fmt.Errorf("an error")
+	)
+
+	assert.Equal(t, "", run(func(tb testing.TB) { PanicsWithError(tb, anErr, func() { panic(anErr) }) }))
+	assert.Equal(t, "", run(func(tb testing.TB) { PanicsWithError(tb, anErr, func() { panic(wrapped) }) }))
+	assert.Equal(t, "", run(func(tb testing.TB) { PanicsWithError(tb, anErr, func() { panic(sameText) }) }))
+
+	assert.Equal(
+		t,
+		`expected a panic with error "an error", but there was no panic`,
+		run(func(tb testing.TB) { PanicsWithError(tb, anErr, func() {}) }),
+	)
+	assert.Equal(
+		t,
+		`expected a panic with error "an error", but the panic value is string: oops`,
+		run(func(tb testing.TB) { PanicsWithError(tb, anErr, func() { panic("oops") }) }),
+	)
+	assert.Equal(
+		t,
+		`expected a panic with error "an error", not "other"`,
+		run(func(tb testing.TB) { PanicsWithError(tb, anErr, func() { panic(fmt.Errorf("other")) }) }),
+	)
+}
+
+func TestIterEquals_(t *testing.T) {
+	assert.Equal(t, "", run(func(tb testing.TB) { IterEquals(tb, nil, iter.Of[int]()) }))
+	assert.Equal(t, "", run(func(tb testing.TB) { IterEquals(tb, []int{}, iter.Of[int]()) }))
+	assert.Equal(t, "", run(func(tb testing.TB) { IterEquals(tb, []int{1, 2}, iter.Of(1, 2)) }))
+
+	assert.Equal(
+		t,
+		"expected iterator to produce [1 2], not [1 3]",
+		run(func(tb testing.TB) { IterEquals(tb, []int{1, 2}, iter.Of(1, 3)) }),
+	)
+	assert.Equal(
+		t,
+		"expected iterator to produce [1], not []",
+		run(func(tb testing.TB) { IterEquals(tb, []int{1}, iter.Of[int]()) }),
+	)
+
+	anErr := fmt.Errorf("an error")
+	assert.Equal(
+		t,
+		`expected iterator to produce [1 2], but it failed after [1] with error "an error"`,
+		run(func(tb testing.TB) { IterEquals(tb, []int{1, 2}, failAfter(anErr, 1)) }),
+	)
+}
+
+func TestIterFails_(t *testing.T) {
+	anErr := fmt.Errorf("an error")
+
+	assert.Equal(t, "", run(func(tb testing.TB) { IterFails(tb, nil, anErr, failAfter[int](anErr)) }))
+	assert.Equal(t, "", run(func(tb testing.TB) { IterFails(tb, []int{1, 2}, anErr, failAfter(anErr, 1, 2)) }))
+	assert.Equal(t, "", run(func(tb testing.TB) { IterFails(tb, []int{1}, anErr, failAfter(fmt.Errorf("an error"), 1)) }))
+
+	assert.Equal(
+		t,
+		`expected iterator to fail with error "an error" after [1], but it produced [1] without error`,
+		run(func(tb testing.TB) { IterFails(tb, []int{1}, anErr, iter.Of(1)) }),
+	)
+	assert.Equal(
+		t,
+		`expected iterator to fail with error "an error", not "other"`,
+		run(func(tb testing.TB) { IterFails(tb, []int{1}, anErr, failAfter(fmt.Errorf("other"), 1)) }),
+	)
+	assert.Equal(
+		t,
+		"expected iterator to produce [1] before failing, not [2]",
+		run(func(tb testing.TB) { IterFails(tb, []int{1}, anErr, failAfter(anErr, 2)) }),
+	)
+}
+
+// failAfter returns an iterator of the given values, followed by the given error
+func failAfter[T any](err error, vals ...T) iter.Iter[T] {
+	i := 0
+
+	return iter.OfIter(func() (T, error) {
+		if i < len(vals) {
+			i++
+			return vals[i-1], nil
+		}
+
+		var zv T
+		return zv, err
+	})
+}
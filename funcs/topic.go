@@ -0,0 +1,190 @@
+package funcs
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"sync"
+)
+
+// Delivery is how a Topic delivers published values to a subscriber
+type Delivery uint
+
+const (
+	Sync  Delivery = iota // Sync calls the subscriber in the publishing goroutine, before Publish returns
+	Async                 // Async queues values for a goroutine dedicated to the subscriber, so Publish does not wait
+)
+
+// Subscription identifies a subscriber of a Topic, for unsubscribing
+type Subscription uint64
+
+// subscriber is a subscriber of a Topic.
+// For Async delivery, the queue is unbounded so that publishing never blocks, even if a subscriber unsubscribes itself.
+type subscriber[T any] struct {
+	id       Subscription
+	fn       func(T)
+	delivery Delivery
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queue    []T
+	closed   bool
+	done     chan struct{}
+}
+
+// Topic is an in-process publish/subscribe channel for values of type T, so that modules can communicate without
+// depending on each other. Subscribers are called in the order they subscribed.
+//
+// A subscriber that panics does not affect the publisher or other subscribers. The panic is recovered, and passed to
+// the optional handler given to NewTopic.
+//
+// A Topic is safe for concurrent use, and subscribers may subscribe and unsubscribe while a value is published.
+type Topic[T any] struct {
+	mu      sync.RWMutex
+	lastID  Subscription
+	subs    []*subscriber[T]
+	onPanic func(Subscription, any)
+}
+
+// NewTopic constructs a Topic with an optional handler for subscribers that panic
+func NewTopic[T any](onPanic ...func(Subscription, any)) *Topic[T] {
+	return &Topic[T]{onPanic: SliceIndex(onPanic, 0)}
+}
+
+// call calls the subscriber, recovering any panic
+func (t *Topic[T]) call(sub *subscriber[T], val T) {
+	defer func() {
+		if r := recover(); (r != nil) && (t.onPanic != nil) {
+			t.onPanic(sub.id, r)
+		}
+	}()
+
+	sub.fn(val)
+}
+
+// deliver is the goroutine of an Async subscriber, which delivers queued values until the subscriber is closed and the
+// queue is empty
+func (t *Topic[T]) deliver(sub *subscriber[T]) {
+	defer close(sub.done)
+
+	for {
+		sub.mu.Lock()
+		for (len(sub.queue) == 0) && (!sub.closed) {
+			sub.cond.Wait()
+		}
+
+		if len(sub.queue) == 0 {
+			sub.mu.Unlock()
+			return
+		}
+
+		val := sub.queue[0]
+		sub.queue = sub.queue[1:]
+		sub.mu.Unlock()
+
+		t.call(sub, val)
+	}
+}
+
+// Subscribe adds a subscriber, with an optional Delivery that defaults to Sync
+func (t *Topic[T]) Subscribe(fn func(T), delivery ...Delivery) Subscription {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lastID++
+	sub := &subscriber[T]{id: t.lastID, fn: fn, delivery: SliceIndex(delivery, 0, Sync)}
+
+	if sub.delivery == Async {
+		sub.cond = sync.NewCond(&sub.mu)
+		sub.done = make(chan struct{})
+		go t.deliver(sub)
+	}
+
+	t.subs = append(t.subs, sub)
+	return sub.id
+}
+
+// Unsubscribe removes a subscriber, returning false if it is not subscribed.
+// A Sync subscriber is not called by any Publish that begins after Unsubscribe returns. An Async subscriber is still
+// called with any values that were queued before Unsubscribe was called, by its goroutine, which then exits.
+//
+// A subscriber may unsubscribe itself.
+func (t *Topic[T]) Unsubscribe(id Subscription) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, sub := range t.subs {
+		if sub.id == id {
+			t.subs = append(t.subs[:i:i], t.subs[i+1:]...)
+			t.close(sub)
+			return true
+		}
+	}
+
+	return false
+}
+
+// close closes a subscriber, waking up the goroutine of an Async subscriber so it can exit
+func (t *Topic[T]) close(sub *subscriber[T]) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	sub.closed = true
+	if sub.cond != nil {
+		sub.cond.Broadcast()
+	}
+}
+
+// Publish publishes a value to all subscribers.
+// Sync subscribers have been called when Publish returns, Async subscribers may not have.
+func (t *Topic[T]) Publish(val T) {
+	// Copy the subscribers, so that a subscriber can subscribe and unsubscribe while being called
+	t.mu.RLock()
+	subs := append([]*subscriber[T]{}, t.subs...)
+	t.mu.RUnlock()
+
+	for _, sub := range subs {
+		sub.mu.Lock()
+		if sub.closed {
+			sub.mu.Unlock()
+			continue
+		}
+
+		if sub.delivery == Async {
+			sub.queue = append(sub.queue, val)
+			sub.cond.Signal()
+			sub.mu.Unlock()
+			continue
+		}
+
+		sub.mu.Unlock()
+		t.call(sub, val)
+	}
+}
+
+// Len returns the number of subscribers
+func (t *Topic[T]) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return len(t.subs)
+}
+
+// Close unsubscribes all subscribers, and waits for Async subscribers to finish delivering the values published before
+// Close was called. The Topic can still be used afterwards.
+//
+// Close must not be called by a subscriber, as it would wait for itself.
+func (t *Topic[T]) Close() {
+	t.mu.Lock()
+	subs := t.subs
+	t.subs = nil
+
+	for _, sub := range subs {
+		t.close(sub)
+	}
+	t.mu.Unlock()
+
+	for _, sub := range subs {
+		if sub.done != nil {
+			<-sub.done
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package funcs
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopicSync_(t *testing.T) {
+	var (
+		topic = NewTopic[int]()
+		got   []string
+	)
+
+	// Subscribers are called in order, before Publish returns
+	s1 := topic.Subscribe(func(i int) { got = append(got, fmt.Sprintf("a%d", i)) })
+	s2 := topic.Subscribe(func(i int) { got = append(got, fmt.Sprintf("b%d", i)) }, Sync)
+	assert.Equal(t, Subscription(1), s1)
+	assert.Equal(t, Subscription(2), s2)
+	assert.Equal(t, 2, topic.Len())
+
+	topic.Publish(1)
+	topic.Publish(2)
+	assert.Equal(t, []string{"a1", "b1", "a2", "b2"}, got)
+
+	// Unsubscribe
+	assert.True(t, topic.Unsubscribe(s1))
+	assert.False(t, topic.Unsubscribe(s1))
+	assert.False(t, topic.Unsubscribe(99))
+	assert.Equal(t, 1, topic.Len())
+
+	got = nil
+	topic.Publish(3)
+	assert.Equal(t, []string{"b3"}, got)
+
+	// A subscriber can unsubscribe itself, and subscribe another, while being called
+	var (
+		s3    Subscription
+		other []int
+	)
+
+	got = nil
+	s3 = topic.Subscribe(func(i int) {
+		got = append(got, fmt.Sprintf("c%d", i))
+		topic.Unsubscribe(s3)
+		topic.Subscribe(func(i int) { other = append(other, i) })
+	})
+
+	topic.Publish(4)
+	topic.Publish(5)
+	assert.Equal(t, []string{"b4", "c4", "b5"}, got)
+	assert.Equal(t, []int{5}, other)
+
+	// Close unsubscribes all
+	topic.Close()
+	assert.Equal(t, 0, topic.Len())
+
+	got = nil
+	topic.Publish(6)
+	assert.Nil(t, got)
+
+	// A topic with no subscribers
+	NewTopic[int]().Publish(1)
+}
+
+func TestTopicAsync_(t *testing.T) {
+	var (
+		topic   = NewTopic[int]()
+		mu      sync.Mutex
+		got     []int
+		release = make(chan struct{})
+	)
+
+	// The async subscriber blocks until released, which does not block Publish
+	topic.Subscribe(func(i int) {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, i)
+	}, Async)
+
+	var syncGot []int
+	topic.Subscribe(func(i int) { syncGot = append(syncGot, i) })
+
+	for i := 1; i <= 100; i++ {
+		topic.Publish(i)
+	}
+	assert.Equal(t, 100, len(syncGot))
+
+	// Values are delivered in order, Close waits for all of them
+	close(release)
+	topic.Close()
+
+	var expected []int
+	for i := 1; i <= 100; i++ {
+		expected = append(expected, i)
+	}
+	assert.Equal(t, expected, got)
+
+	// Unsubscribe delivers values already queued, and the subscriber can unsubscribe itself
+	var (
+		done  = make(chan struct{})
+		s     Subscription
+		count int
+	)
+
+	s = topic.Subscribe(func(i int) {
+		if count++; count == 2 {
+			topic.Unsubscribe(s)
+			close(done)
+		}
+	}, Async)
+
+	topic.Publish(1)
+	topic.Publish(2)
+	<-done
+	topic.Publish(3)
+	topic.Close()
+	assert.Equal(t, 2, count)
+}
+
+func TestTopicPanic_(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		panics = map[Subscription]any{}
+		topic  = NewTopic[int](func(s Subscription, r any) {
+			mu.Lock()
+			defer mu.Unlock()
+			panics[s] = r
+		})
+		got []int
+	)
+
+	// Panicking subscribers do not affect others or the publisher
+	s1 := topic.Subscribe(func(i int) { panic(fmt.Errorf("sync %d", i)) })
+	s2 := topic.Subscribe(func(i int) { panic(fmt.Errorf("async %d", i)) }, Async)
+	topic.Subscribe(func(i int) { got = append(got, i) })
+
+	topic.Publish(1)
+	topic.Close()
+	assert.Equal(t, []int{1}, got)
+	assert.Equal(t, map[Subscription]any{s1: fmt.Errorf("sync 1"), s2: fmt.Errorf("async 1")}, panics)
+
+	// Without a handler, panics are ignored
+	topic2 := NewTopic[int]()
+	topic2.Subscribe(func(int) { panic("ignored") })
+	topic2.Subscribe(func(i int) { got = append(got, i) })
+	topic2.Publish(2)
+	assert.Equal(t, []int{1, 2}, got)
+}
+
+func TestTopicConcurrent_(t *testing.T) {
+	var (
+		topic = NewTopic[int]()
+		mu    sync.Mutex
+		sum   int
+		wg    sync.WaitGroup
+	)
+
+	add := func(i int) {
+		mu.Lock()
+		defer mu.Unlock()
+		sum += i
+	}
+
+	topic.Subscribe(add)
+	topic.Subscribe(add, Async)
+
+	// Publish concurrently, while other subscribers come and go
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 1; i <= 100; i++ {
+				topic.Publish(i)
+				topic.Unsubscribe(topic.Subscribe(func(int) {}, Delivery(i%2)))
+			}
+		}()
+	}
+
+	wg.Wait()
+	topic.Close()
+	assert.Equal(t, 2*10*5050, sum)
+}
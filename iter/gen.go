@@ -7,7 +7,9 @@ import (
 	"fmt"
 	goio "io"
 	"reflect"
+	"sort"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/bantling/micro/funcs"
@@ -19,6 +21,8 @@ import (
 // Error constants
 var (
 	InvalidUTF8EncodingError = fmt.Errorf("Invalid UTF 8 encoding")
+
+	errZipLengthsMsg = "ZipIterGen requires slices of the same length, not %d and %d"
 )
 
 // ==== Iterating function generators
@@ -71,6 +75,66 @@ func MapIterGen[K comparable, V any](m map[K]V) func() (tuple.Two[K, V], error)
 	}
 }
 
+// ZipIterGen generates an iterating function for two slices whose elements correspond by index, such as names and
+// ages of the same people.
+// First min(len(slcA), len(slcB)) calls to iterating function return (tuple.Two[A, B]{slcA element, slcB element}, nil).
+// If the slices have the same length, all remaining calls return (tuple.Two[A, B] zero value, EOI).
+// Otherwise, the next call returns (tuple.Two[A, B] zero value, error), as the slices are not correlated.
+func ZipIterGen[A, B any](slcA []A, slcB []B) func() (tuple.Two[A, B], error) {
+	var (
+		idx  int
+		zv   tuple.Two[A, B]
+		done bool
+	)
+
+	return func() (tuple.Two[A, B], error) {
+		if done {
+			return zv, EOI
+		}
+
+		if (idx < len(slcA)) && (idx < len(slcB)) {
+			value := tuple.Of2(slcA[idx], slcB[idx])
+			idx++
+			return value, nil
+		}
+
+		done = true
+		if len(slcA) != len(slcB) {
+			return zv, fmt.Errorf(errZipLengthsMsg, len(slcA), len(slcB))
+		}
+
+		return zv, EOI
+	}
+}
+
+// MapSortedIterGen generates an iterating function for a map[K]V, in the order of the keys sorted by less, so that
+// the order is the same every time, unlike MapIterGen.
+// The keys are sorted when the function is generated, so later changes to the map are not reflected.
+// First len(m) calls to iterating function return (tuple.Two[K, V]{m key, m value}, nil)
+// All remaining calls return (tuple.Two[K, V] zero value, EOI)
+func MapSortedIterGen[K comparable, V any](m map[K]V, less func(K, K) bool) func() (tuple.Two[K, V], error) {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return less(keys[i], keys[j]) })
+
+	var (
+		idx int
+		zv  tuple.Two[K, V]
+	)
+
+	return func() (tuple.Two[K, V], error) {
+		if idx < len(keys) {
+			k := keys[idx]
+			idx++
+			return tuple.Of2(k, m[k]), nil
+		}
+
+		return zv, EOI
+	}
+}
+
 // NoValueIterGen generates an iterating function that has no values.
 // Always returns (zero value, EOI)
 func NoValueIterGen[T any]() func() (T, error) {
@@ -475,3 +539,241 @@ func ConcatIterGen[T any](src []Iter[T]) func() (T, error) {
 		}
 	}
 }
+
+// PermutationsIterGen generates an iterating function that iterates all permutations of the given slice.
+// The permutations are generated in lexicographic order of element positions, so the first permutation is the slice as
+// given, and the last is the slice reversed. Elements are not compared, so duplicate elements produce duplicate
+// permutations.
+// Each permutation is a new slice, so it may be retained or modified by the caller.
+// An empty slice has a single empty permutation.
+func PermutationsIterGen[T any](slc []T) func() ([]T, error) {
+	var (
+		idx   = make([]int, len(slc))
+		first = true
+		done  bool
+	)
+
+	for i := range idx {
+		idx[i] = i
+	}
+
+	return func() ([]T, error) {
+		if done {
+			return nil, EOI
+		}
+
+		if first {
+			first = false
+		} else {
+			// Find the rightmost position that is less than the position after it
+			i := len(idx) - 2
+			for (i >= 0) && (idx[i] > idx[i+1]) {
+				i--
+			}
+
+			if i < 0 {
+				// Positions are in descending order, which is the last permutation
+				done = true
+				return nil, EOI
+			}
+
+			// Swap it with the rightmost position greater than it, then reverse all positions after it
+			j := len(idx) - 1
+			for idx[j] < idx[i] {
+				j--
+			}
+			idx[i], idx[j] = idx[j], idx[i]
+
+			for l, r := i+1, len(idx)-1; l < r; l, r = l+1, r-1 {
+				idx[l], idx[r] = idx[r], idx[l]
+			}
+		}
+
+		perm := make([]T, len(idx))
+		for i, p := range idx {
+			perm[i] = slc[p]
+		}
+
+		return perm, nil
+	}
+}
+
+// CombinationsIterGen generates an iterating function that iterates all combinations of k elements of the given slice.
+// The elements of each combination are in the same order as the slice, and combinations are generated in
+// lexicographic order of element positions (eg, for [a, b, c] and k = 2: [a, b], [a, c], [b, c]).
+// Each combination is a new slice, so it may be retained or modified by the caller.
+// If k = 0 there is a single empty combination, and if k > len(slc) there are no combinations.
+func CombinationsIterGen[T any](slc []T, k uint) func() ([]T, error) {
+	var (
+		n     = len(slc)
+		idx   = make([]int, k)
+		first = true
+		done  = int(k) > n
+	)
+
+	for i := range idx {
+		idx[i] = i
+	}
+
+	return func() ([]T, error) {
+		if done {
+			return nil, EOI
+		}
+
+		if first {
+			first = false
+		} else {
+			// Find the rightmost position that has not reached its maximum value
+			i := len(idx) - 1
+			for (i >= 0) && (idx[i] == n-len(idx)+i) {
+				i--
+			}
+
+			if i < 0 {
+				// All positions are at their maximum, which is the last combination
+				done = true
+				return nil, EOI
+			}
+
+			// Increment it, and reset all positions after it to follow it consecutively
+			idx[i]++
+			for j := i + 1; j < len(idx); j++ {
+				idx[j] = idx[j-1] + 1
+			}
+		}
+
+		comb := make([]T, len(idx))
+		for i, p := range idx {
+			comb[i] = slc[p]
+		}
+
+		return comb, nil
+	}
+}
+
+// ScriptIterGen generates an iterating function that plays back the given steps in order.
+// Each call sleeps for the step Delay, if any, then returns (zero value, step Err) if Err is non-nil, else (step Value, nil).
+// After the first step with a non-nil Err, or after the last step, all further calls return (zero value, same error or EOI)
+// without sleeping, so any steps after an error step are never played back.
+func ScriptIterGen[T any](steps []Step[T]) func() (T, error) {
+	var (
+		i   int
+		zv  T
+		err = EOI
+	)
+
+	return func() (T, error) {
+		if i == len(steps) {
+			return zv, err
+		}
+
+		step := steps[i]
+		i++
+
+		if step.Delay > 0 {
+			time.Sleep(step.Delay)
+		}
+
+		if step.Err != nil {
+			i, err = len(steps), step.Err
+			return zv, err
+		}
+
+		return step.Value, nil
+	}
+}
+
+// PagesIterGen generates an iterating function that returns the items of successive pages, where each page is fetched
+// only when the items of the previous page have all been returned.
+// The first page is fetched with an empty token, and each following page is fetched with the next token returned by the
+// previous page. A page may have no items, in which case the next page is fetched immediately.
+// After a page with an empty next token has been returned, or after fetch returns an error, all further calls return
+// (zero value, EOI or the same error) without calling fetch again.
+func PagesIterGen[T any](fetch func(pageToken string) (items []T, next string, err error)) func() (T, error) {
+	var (
+		items   []T
+		token   string
+		fetched bool
+		zv      T
+		err     error
+	)
+
+	return func() (T, error) {
+		for len(items) == 0 {
+			if err != nil {
+				return zv, err
+			}
+
+			// The last page has been returned
+			if fetched && (token == "") {
+				err = EOI
+				return zv, err
+			}
+
+			if items, token, err = fetch(token); err != nil {
+				items = nil
+				return zv, err
+			}
+
+			fetched = true
+		}
+
+		val := items[0]
+		items = items[1:]
+
+		return val, nil
+	}
+}
+
+// FallbackIterGen generates an iterating function that returns the values of primary, unless primary has no values, or
+// its first call returns an error that fallbackOn returns true for, in which case the values of secondary are returned.
+// If fallbackOn is nil, only a primary with no values falls back.
+// Once primary has returned a value, any error it returns is returned as is, since falling back part way through would
+// mix values of both sources.
+// After returning (zero value, EOI or error), all further calls return (zero value, same EOI or error).
+func FallbackIterGen[T any](primary, secondary Iter[T], fallbackOn func(error) bool) func() (T, error) {
+	var (
+		src     = primary
+		started bool
+	)
+
+	return func() (T, error) {
+		val, err := src.Next()
+
+		if !started {
+			started = true
+
+			if (err == EOI) || ((err != nil) && (fallbackOn != nil) && fallbackOn(err)) {
+				src = secondary
+				val, err = src.Next()
+			}
+		}
+
+		return val, err
+	}
+}
+
+// DedupAdjacentIterGen generates an iterating function that returns the values of an Iter[T], except values that eq
+// reports are equal to the value returned before them, so that each run of equal values is returned only once.
+// Only the last value returned is kept, so memory use is constant regardless of the number of values.
+// After returning (zero value, EOI or error), all further calls return (zero value, same EOI or error).
+func DedupAdjacentIterGen[T any](it Iter[T], eq func(a, b T) bool) func() (T, error) {
+	var (
+		prev    T
+		started bool
+	)
+
+	return func() (T, error) {
+		for {
+			val, err := it.Next()
+			if err != nil {
+				return val, err
+			}
+
+			if !started || !eq(prev, val) {
+				prev, started = val, true
+				return val, nil
+			}
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bantling/micro/io"
 	"github.com/bantling/micro/tuple"
@@ -143,6 +144,57 @@ func TestMapIterGen_(t *testing.T) {
 	assert.Equal(t, EOI, err)
 }
 
+func TestZipIterGen_(t *testing.T) {
+	// nil
+	iter := ZipIterGen[int, string](nil, nil)
+	assert.Equal(t, tuple.Of2(tuple.Two[int, string]{}, EOI), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2(tuple.Two[int, string]{}, EOI), tuple.Of2(iter()))
+
+	// same length
+	iter = ZipIterGen([]int{1, 2}, []string{"a", "b"})
+	assert.Equal(t, tuple.Of2(tuple.Of2(1, "a"), error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2(tuple.Of2(2, "b"), error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2(tuple.Two[int, string]{}, EOI), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2(tuple.Two[int, string]{}, EOI), tuple.Of2(iter()))
+
+	// different lengths
+	iter = ZipIterGen([]int{1, 2}, []string{"a"})
+	assert.Equal(t, tuple.Of2(tuple.Of2(1, "a"), error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2(tuple.Two[int, string]{}, fmt.Errorf(errZipLengthsMsg, 2, 1)), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2(tuple.Two[int, string]{}, EOI), tuple.Of2(iter()))
+
+	iter = ZipIterGen([]int{}, []string{"a"})
+	assert.Equal(t, tuple.Of2(tuple.Two[int, string]{}, fmt.Errorf(errZipLengthsMsg, 0, 1)), tuple.Of2(iter()))
+}
+
+func TestMapSortedIterGen_(t *testing.T) {
+	less := func(a, b string) bool { return a < b }
+
+	// nil
+	iter := MapSortedIterGen[string, int](nil, less)
+	assert.Equal(t, tuple.Of2(tuple.Two[string, int]{}, EOI), tuple.Of2(iter()))
+
+	// sorted every time
+	src := map[string]int{"c": 3, "a": 1, "d": 4, "b": 2}
+	for i := 0; i < 10; i++ {
+		var keys []string
+		iter = MapSortedIterGen(src, less)
+		for kv, err := iter(); err == nil; kv, err = iter() {
+			assert.Equal(t, src[kv.T], kv.U)
+			keys = append(keys, kv.T)
+		}
+
+		assert.Equal(t, []string{"a", "b", "c", "d"}, keys)
+		assert.Equal(t, tuple.Of2(tuple.Two[string, int]{}, EOI), tuple.Of2(iter()))
+	}
+
+	// reverse order
+	iter2 := MapSortedIterGen(map[int]bool{1: true, 2: false}, func(a, b int) bool { return a > b })
+	assert.Equal(t, tuple.Of2(tuple.Of2(2, false), error(nil)), tuple.Of2(iter2()))
+	assert.Equal(t, tuple.Of2(tuple.Of2(1, true), error(nil)), tuple.Of2(iter2()))
+	assert.Equal(t, tuple.Of2(tuple.Two[int, bool]{}, EOI), tuple.Of2(iter2()))
+}
+
 func TestNoValueIterGen_(t *testing.T) {
 	iter := NoValueIterGen[int]()
 
@@ -724,3 +776,247 @@ func TestConcatIterGen_(t *testing.T) {
 	assert.Zero(t, val)
 	assert.Equal(t, anErr, err)
 }
+
+func TestPermutationsIterGen_(t *testing.T) {
+	collect := func(iter func() ([]int, error)) (res [][]int) {
+		for {
+			val, err := iter()
+			if err != nil {
+				assert.Nil(t, val)
+				assert.Equal(t, EOI, err)
+
+				// Further calls return EOI
+				val, err = iter()
+				assert.Nil(t, val)
+				assert.Equal(t, EOI, err)
+
+				return
+			}
+
+			res = append(res, val)
+		}
+	}
+
+	assert.Equal(t, [][]int{{}}, collect(PermutationsIterGen([]int{})))
+	assert.Equal(t, [][]int{{1}}, collect(PermutationsIterGen([]int{1})))
+	assert.Equal(t, [][]int{{1, 2}, {2, 1}}, collect(PermutationsIterGen([]int{1, 2})))
+	assert.Equal(
+		t,
+		[][]int{{3, 1, 2}, {3, 2, 1}, {1, 3, 2}, {1, 2, 3}, {2, 3, 1}, {2, 1, 3}},
+		collect(PermutationsIterGen([]int{3, 1, 2})),
+	)
+
+	// Duplicates are not removed
+	assert.Equal(t, [][]int{{1, 1}, {1, 1}}, collect(PermutationsIterGen([]int{1, 1})))
+
+	// 4! = 24
+	assert.Equal(t, 24, len(collect(PermutationsIterGen([]int{1, 2, 3, 4}))))
+
+	// Each permutation is a separate slice
+	iter := PermutationsIterGen([]int{1, 2})
+	p1, _ := iter()
+	p1[0] = 5
+	p2, _ := iter()
+	assert.Equal(t, []int{2, 1}, p2)
+}
+
+func TestCombinationsIterGen_(t *testing.T) {
+	collect := func(iter func() ([]string, error)) (res [][]string) {
+		for {
+			val, err := iter()
+			if err != nil {
+				assert.Nil(t, val)
+				assert.Equal(t, EOI, err)
+
+				// Further calls return EOI
+				val, err = iter()
+				assert.Nil(t, val)
+				assert.Equal(t, EOI, err)
+
+				return
+			}
+
+			res = append(res, val)
+		}
+	}
+
+	abcd := []string{"a", "b", "c", "d"}
+	assert.Equal(t, [][]string{{}}, collect(CombinationsIterGen(abcd, 0)))
+	assert.Equal(t, [][]string{{"a"}, {"b"}, {"c"}, {"d"}}, collect(CombinationsIterGen(abcd, 1)))
+	assert.Equal(
+		t,
+		[][]string{{"a", "b"}, {"a", "c"}, {"a", "d"}, {"b", "c"}, {"b", "d"}, {"c", "d"}},
+		collect(CombinationsIterGen(abcd, 2)),
+	)
+	assert.Equal(
+		t,
+		[][]string{{"a", "b", "c"}, {"a", "b", "d"}, {"a", "c", "d"}, {"b", "c", "d"}},
+		collect(CombinationsIterGen(abcd, 3)),
+	)
+	assert.Equal(t, [][]string{abcd}, collect(CombinationsIterGen(abcd, 4)))
+	assert.Nil(t, collect(CombinationsIterGen(abcd, 5)))
+
+	assert.Equal(t, [][]string{{}}, collect(CombinationsIterGen([]string{}, 0)))
+	assert.Nil(t, collect(CombinationsIterGen([]string{}, 1)))
+}
+
+func TestScriptIterGen_(t *testing.T) {
+	var (
+		anErr = fmt.Errorf("an error")
+		delay = 5 * time.Millisecond
+		steps = []Step[string]{{Value: "a"}, {Value: "b", Delay: delay}, {Err: anErr, Delay: delay}, {Value: "c"}}
+		iter  = ScriptIterGen(steps)
+		start = time.Now()
+	)
+
+	assert.Equal(t, tuple.Of2("a", error(nil)), tuple.Of2(iter()))
+
+	assert.Equal(t, tuple.Of2("b", error(nil)), tuple.Of2(iter()))
+	assert.True(t, time.Since(start) >= delay)
+
+	assert.Equal(t, tuple.Of2("", anErr), tuple.Of2(iter()))
+	assert.True(t, time.Since(start) >= 2*delay)
+
+	// Same error afterwards
+	assert.Equal(t, tuple.Of2("", anErr), tuple.Of2(iter()))
+
+	// Steps exhausted
+	iter = ScriptIterGen(ValueSteps("a"))
+	assert.Equal(t, tuple.Of2("a", error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2("", EOI), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2("", EOI), tuple.Of2(iter()))
+}
+
+func TestPagesIterGen_(t *testing.T) {
+	var (
+		anErr  = fmt.Errorf("an error")
+		tokens []string
+		pages  = map[string]tuple.Two[[]int, string]{
+			"":   tuple.Of2([]int{1, 2}, "p2"),
+			"p2": tuple.Of2([]int{}, "p3"),
+			"p3": tuple.Of2([]int{3}, ""),
+		}
+		fetch = func(token string) ([]int, string, error) {
+			tokens = append(tokens, token)
+			if page, haveIt := pages[token]; haveIt {
+				return page.T, page.U, nil
+			}
+
+			return []int{99}, "", anErr
+		}
+		iter = PagesIterGen(fetch)
+	)
+
+	// Pages are fetched lazily, an empty page is skipped
+	assert.Equal(t, tuple.Of2(1, error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, []string{""}, tokens)
+	assert.Equal(t, tuple.Of2(2, error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, []string{""}, tokens)
+	assert.Equal(t, tuple.Of2(3, error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, []string{"", "p2", "p3"}, tokens)
+
+	// No more pages
+	assert.Equal(t, tuple.Of2(0, EOI), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2(0, EOI), tuple.Of2(iter()))
+	assert.Equal(t, []string{"", "p2", "p3"}, tokens)
+
+	// Error, items returned with an error are ignored, and fetch is not called again
+	tokens, pages[""] = nil, tuple.Of2([]int{1}, "bad")
+	iter = PagesIterGen(fetch)
+	assert.Equal(t, tuple.Of2(1, error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2(0, anErr), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2(0, anErr), tuple.Of2(iter()))
+	assert.Equal(t, []string{"", "bad"}, tokens)
+
+	// Error on first page
+	iter = PagesIterGen(func(string) ([]int, string, error) { return nil, "", anErr })
+	assert.Equal(t, tuple.Of2(0, anErr), tuple.Of2(iter()))
+
+	// Only an empty page
+	iter = PagesIterGen(func(string) ([]int, string, error) { return nil, "", nil })
+	assert.Equal(t, tuple.Of2(0, EOI), tuple.Of2(iter()))
+}
+
+func TestFallbackIterGen_(t *testing.T) {
+	var (
+		anErr   = fmt.Errorf("an error")
+		missErr = fmt.Errorf("a miss")
+		isMiss  = func(err error) bool { return err == missErr }
+		reads   int
+		counted = func(it Iter[int]) Iter[int] {
+			return OfIter(func() (int, error) {
+				reads++
+				return it.Next()
+			})
+		}
+	)
+
+	// Primary has values, secondary is not read
+	iter := FallbackIterGen(Of(1, 2), counted(Of(3)), isMiss)
+	assert.Equal(t, tuple.Of2(1, error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2(2, error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2(0, EOI), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2(0, EOI), tuple.Of2(iter()))
+	assert.Equal(t, 0, reads)
+
+	// Primary is empty
+	iter = FallbackIterGen(OfEmpty[int](), Of(3, 4), nil)
+	assert.Equal(t, tuple.Of2(3, error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2(4, error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2(0, EOI), tuple.Of2(iter()))
+
+	// Primary fails with a fallback error
+	iter = FallbackIterGen(OfScript(ErrorStep[int](missErr)), Of(3), isMiss)
+	assert.Equal(t, tuple.Of2(3, error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2(0, EOI), tuple.Of2(iter()))
+
+	// Primary fails with another error, or no errors fall back
+	iter = FallbackIterGen(OfScript(ErrorStep[int](anErr)), counted(Of(3)), isMiss)
+	assert.Equal(t, tuple.Of2(0, anErr), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2(0, anErr), tuple.Of2(iter()))
+
+	iter = FallbackIterGen(OfScript(ErrorStep[int](missErr)), counted(Of(3)), nil)
+	assert.Equal(t, tuple.Of2(0, missErr), tuple.Of2(iter()))
+	assert.Equal(t, 0, reads)
+
+	// Primary fails after a value
+	iter = FallbackIterGen(OfScript(ValueStep(1), ErrorStep[int](missErr)), counted(Of(3)), isMiss)
+	assert.Equal(t, tuple.Of2(1, error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2(0, missErr), tuple.Of2(iter()))
+	assert.Equal(t, 0, reads)
+
+	// Secondary fails
+	iter = FallbackIterGen(OfEmpty[int](), OfScript(ValueStep(3), ErrorStep[int](anErr)), isMiss)
+	assert.Equal(t, tuple.Of2(3, error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2(0, anErr), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2(0, anErr), tuple.Of2(iter()))
+
+	// Both empty
+	iter = FallbackIterGen(OfEmpty[int](), OfEmpty[int](), isMiss)
+	assert.Equal(t, tuple.Of2(0, EOI), tuple.Of2(iter()))
+}
+
+func TestDedupAdjacentIterGen_(t *testing.T) {
+	var (
+		anErr = fmt.Errorf("An err")
+		eq    = func(a, b string) bool { return a == b }
+		iter  = DedupAdjacentIterGen(Of("a", "a", "b", "a"), eq)
+	)
+
+	assert.Equal(t, tuple.Of2("a", error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2("b", error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2("a", error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2("", EOI), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2("", EOI), tuple.Of2(iter()))
+
+	// The zero value is not treated as the previous value
+	iter = DedupAdjacentIterGen(Of("", ""), eq)
+	assert.Equal(t, tuple.Of2("", error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2("", EOI), tuple.Of2(iter()))
+
+	// Errors
+	iter = DedupAdjacentIterGen(OfScript(ValueStep("a"), ValueStep("a"), ErrorStep[string](anErr)), eq)
+	assert.Equal(t, tuple.Of2("a", error(nil)), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2("", anErr), tuple.Of2(iter()))
+	assert.Equal(t, tuple.Of2("", anErr), tuple.Of2(iter()))
+}
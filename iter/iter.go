@@ -6,7 +6,9 @@ import (
 	"fmt"
 	goio "io"
 	"strings"
+	"time"
 
+	"github.com/bantling/micro/funcs"
 	"github.com/bantling/micro/tuple"
 	"github.com/bantling/micro/union"
 )
@@ -37,6 +39,17 @@ type Iter[T any] interface {
 	Unread(T)
 }
 
+// ErrIter is an optional interface of an Iter, that returns the error that terminated iteration without calling Next
+// again, which IterImpl implements. See Err.
+// - Err returns nil if Next has not yet returned an error
+// - Err returns EOI if Next returned all values successfully
+// - Err returns some other error if Next returned a problem
+// - Unread does not affect the result of Err
+type ErrIter[T any] interface {
+	Iter[T]
+	Err() error
+}
+
 // IterImpl is the common implementation of Iter[T], based on an underlying iterating function.
 type IterImpl[T any] struct {
 	iterFn  func() (T, error)
@@ -44,8 +57,44 @@ type IterImpl[T any] struct {
 	lastErr error
 }
 
+// Step is a single step of a scripted iterator, see OfScript.
+// - Delay is how long to sleep before the step is returned, which may be zero
+// - If Err is nil, Value is returned
+// - If Err is non-nil, Err is returned instead of Value, and iteration ends with Err
+type Step[T any] struct {
+	Value T
+	Err   error
+	Delay time.Duration
+}
+
 // ==== Construct
 
+// ValueStep constructs a Step[T] that returns the given value
+func ValueStep[T any](value T) Step[T] {
+	return Step[T]{Value: value}
+}
+
+// ValueSteps constructs a []Step[T] that returns each of the given values, in order
+func ValueSteps[T any](values ...T) []Step[T] {
+	steps := make([]Step[T], len(values))
+	for i, value := range values {
+		steps[i] = Step[T]{Value: value}
+	}
+
+	return steps
+}
+
+// ErrorStep constructs a Step[T] that returns the given error
+func ErrorStep[T any](err error) Step[T] {
+	return Step[T]{Err: err}
+}
+
+// After returns a copy of this Step that sleeps for the given duration before it is returned
+func (s Step[T]) After(delay time.Duration) Step[T] {
+	s.Delay = delay
+	return s
+}
+
 // OfIter constructs an Iter[T] from an iterating function that returns (T, error).
 // The function must return (nextItem, nil) for every item available to iterate, then return (invalid, EOI) on the
 // next call after the last item, where invalid is any value of type T.
@@ -99,6 +148,22 @@ func OfMap[K comparable, V any](items map[K]V) Iter[tuple.Two[K, V]] {
 	return OfIter[tuple.Two[K, V]](MapIterGen[K, V](items))
 }
 
+// OfZip constructs an Iter[tuple.Two[A, B]] that iterates the elements of two slices pairwise by index.
+// Iteration fails if the slices have different lengths.
+//
+// See ZipIterGen.
+func OfZip[A, B any](slcA []A, slcB []B) Iter[tuple.Two[A, B]] {
+	return OfIter(ZipIterGen(slcA, slcB))
+}
+
+// OfMapEntriesSorted constructs an Iter[tuple.Two[K, V]] that iterates the entries of a map in key order.
+// Eg, OfMapEntriesSorted(mp, func(a, b string) bool { return a < b })
+//
+// See MapSortedIterGen.
+func OfMapEntriesSorted[K comparable, V any](mp map[K]V, less func(K, K) bool) Iter[tuple.Two[K, V]] {
+	return OfIter(MapSortedIterGen(mp, less))
+}
+
 // OfReader constructs an Iter[byte] that iterates the bytes of a Reader.
 //
 // See ReaderIterGen.
@@ -145,6 +210,62 @@ func Concat[T any](iters ...Iter[T]) Iter[T] {
 	return OfIter(ConcatIterGen(iters))
 }
 
+// OfPermutations constructs an Iter[[]T] that iterates all permutations of a slice.
+//
+// See PermutationsIterGen.
+func OfPermutations[T any](slc []T) Iter[[]T] {
+	return OfIter(PermutationsIterGen(slc))
+}
+
+// OfCombinations constructs an Iter[[]T] that iterates all combinations of k elements of a slice.
+//
+// See CombinationsIterGen.
+func OfCombinations[T any](slc []T, k uint) Iter[[]T] {
+	return OfIter(CombinationsIterGen(slc, k))
+}
+
+// OfScript constructs an Iter[T] that deterministically plays back the given steps, which is useful for testing how
+// code handles values that arrive slowly, and errors that occur partway through iteration.
+//
+// See ScriptIterGen.
+func OfScript[T any](steps ...Step[T]) Iter[T] {
+	return OfIter(ScriptIterGen(steps))
+}
+
+// OfPages constructs an Iter[T] that iterates the items of pages fetched lazily as the consumer advances, such as a
+// REST API that returns a page of results with a token for the next page.
+// Eg, OfPages(func(token string) ([]Customer, string, error) { return client.ListCustomers(token) })
+//
+// See PagesIterGen.
+func OfPages[T any](fetch func(pageToken string) (items []T, next string, err error)) Iter[T] {
+	return OfIter(PagesIterGen(fetch))
+}
+
+// Fallback constructs an Iter[T] that iterates primary, unless it has no elements, or it fails before returning any
+// element with an error that the optional fallbackOn func returns true for, in which case it iterates secondary.
+// Eg, a read from a cache that falls back to a database:
+//
+//	Fallback(cacheIter, dbIter, func(err error) bool { return errors.Is(err, ErrCacheMiss) })
+//
+// Secondary is not read from unless it is needed.
+//
+// See FallbackIterGen.
+func Fallback[T any](primary, secondary Iter[T], fallbackOn ...func(error) bool) Iter[T] {
+	return OfIter(FallbackIterGen(primary, secondary, funcs.SliceIndex(fallbackOn, 0)))
+}
+
+// DedupAdjacent constructs an Iter[T] that iterates the elements of an Iter[T], skipping any element that the eq func
+// reports is equal to the previous element. Eg, DedupAdjacent(Of(1, 1, 2, 1), func(a, b int) bool { return a == b })
+// iterates 1, 2, 1.
+//
+// Unlike stream.Distinct, it does not remember every element seen, so it only removes all duplicates if the elements
+// are sorted, such as the result of merging sorted sources.
+//
+// See DedupAdjacentIterGen.
+func DedupAdjacent[T any](it Iter[T], eq func(a, b T) bool) Iter[T] {
+	return OfIter(DedupAdjacentIterGen(it, eq))
+}
+
 // ==== IterImpl Methods
 
 // Next returns (value, nil) if there is another item to be read by Value.
@@ -184,6 +305,44 @@ func (it *IterImpl[T]) Next() (T, error) {
 	return zv, it.lastErr
 }
 
+// NextInto is the same as Next, except that it writes the next value into the given pointer, which is only written to
+// if the error is nil. It is useful for hot loops over large values, as one variable is reused for every value:
+//
+//	var val T
+//	for err := it.NextInto(&val); err == nil; err = it.NextInto(&val) {
+//	  ...
+//	}
+func (it *IterImpl[T]) NextInto(val *T) error {
+	// Check buffer for values placed by Unread
+	if len(it.buffer) > 0 {
+		*val = it.buffer[len(it.buffer)-1]
+		it.buffer = it.buffer[0 : len(it.buffer)-1]
+		return nil
+	}
+
+	// Check if we still have values to acquire via iterating function
+	if it.iterFn != nil {
+		v, err := it.iterFn()
+		if err == nil {
+			*val = v
+			return nil
+		}
+
+		// Don't try to call iterating function again
+		it.iterFn = nil
+		it.lastErr = err
+		return err
+	}
+
+	return it.lastErr
+}
+
+// Err returns nil if Next has not yet returned an error, or the error Next returned (EOI or a problem).
+// Values that are Unread after Next returns an error are still returned by Next, but do not change the result of Err.
+func (it *IterImpl[T]) Err() error {
+	return it.lastErr
+}
+
 // Unread adds the given value to an internal buffer, to be returned by Next in reverse order.
 func (it *IterImpl[T]) Unread(val T) {
 	it.buffer = append(it.buffer, val)
@@ -196,6 +355,31 @@ func Maybe[T any](it Iter[T]) union.Result[T] {
 	return union.OfResultError(it.Next())
 }
 
+// Err returns the error that terminated iteration of the given Iter without calling Next again, if it is an ErrIter.
+// Returns nil if it is not an ErrIter, or Next has not yet returned an error.
+func Err[T any](it Iter[T]) error {
+	if ei, isa := it.(ErrIter[T]); isa {
+		return ei.Err()
+	}
+
+	return nil
+}
+
+// NextInto writes the next value of the given Iter into val, using the NextInto method of IterImpl if the Iter is one,
+// else calling Next. The pointer is only written to if the error is nil.
+func NextInto[T any](it Iter[T], val *T) error {
+	if ii, isa := it.(*IterImpl[T]); isa {
+		return ii.NextInto(val)
+	}
+
+	v, err := it.Next()
+	if err == nil {
+		*val = v
+	}
+
+	return err
+}
+
 // SetError sets a particular error to occur instead of the first non-nil error the given iterator returns.
 func SetError[T any](it Iter[T], err error) Iter[T] {
 	return OfIter[T](func() (T, error) {
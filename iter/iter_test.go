@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bantling/micro/funcs"
 	"github.com/bantling/micro/tuple"
@@ -89,6 +90,24 @@ func TestOfMap_(t *testing.T) {
 	assert.Equal(t, src, dst)
 }
 
+func TestOfZip_(t *testing.T) {
+	it := OfZip([]string{"a", "b"}, []int{1, 2})
+	assert.Equal(t, union.OfResult(tuple.Of2("a", 1)), Maybe(it))
+	assert.Equal(t, union.OfResult(tuple.Of2("b", 2)), Maybe(it))
+	assert.Equal(t, union.OfError[tuple.Two[string, int]](EOI), Maybe(it))
+
+	it = OfZip([]string{"a"}, []int{1, 2})
+	assert.Equal(t, union.OfResult(tuple.Of2("a", 1)), Maybe(it))
+	assert.Equal(t, union.OfError[tuple.Two[string, int]](fmt.Errorf(errZipLengthsMsg, 1, 2)), Maybe(it))
+}
+
+func TestOfMapEntriesSorted_(t *testing.T) {
+	it := OfMapEntriesSorted(map[string]int{"b": 2, "a": 1}, func(a, b string) bool { return a < b })
+	assert.Equal(t, union.OfResult(tuple.Of2("a", 1)), Maybe(it))
+	assert.Equal(t, union.OfResult(tuple.Of2("b", 2)), Maybe(it))
+	assert.Equal(t, union.OfError[tuple.Two[string, int]](EOI), Maybe(it))
+}
+
 func TestOfReader_(t *testing.T) {
 	it := OfReader(strings.NewReader("ab"))
 	assert.Equal(t, union.OfResult(byte('a')), Maybe(it))
@@ -148,6 +167,163 @@ func TestConcat_(t *testing.T) {
 	assert.Equal(t, union.OfError[int](EOI), Maybe(it))
 }
 
+func TestOfPermutations_(t *testing.T) {
+	it := OfPermutations([]int{1, 2})
+	assert.Equal(t, union.OfResult([]int{1, 2}), Maybe(it))
+	assert.Equal(t, union.OfResult([]int{2, 1}), Maybe(it))
+	assert.Equal(t, union.OfError[[]int](EOI), Maybe(it))
+}
+
+func TestOfCombinations_(t *testing.T) {
+	it := OfCombinations([]int{1, 2, 3}, 2)
+	assert.Equal(t, union.OfResult([]int{1, 2}), Maybe(it))
+	assert.Equal(t, union.OfResult([]int{1, 3}), Maybe(it))
+	assert.Equal(t, union.OfResult([]int{2, 3}), Maybe(it))
+	assert.Equal(t, union.OfError[[]int](EOI), Maybe(it))
+}
+
+func TestOfScript_(t *testing.T) {
+	anErr := fmt.Errorf("an error")
+
+	// Values followed by an error, the step after the error is never reached
+	it := OfScript(append(ValueSteps(1, 2), ErrorStep[int](anErr), ValueStep(3))...)
+	assert.Equal(t, union.OfResult(1), Maybe(it))
+	assert.Equal(t, union.OfResult(2), Maybe(it))
+	assert.Equal(t, union.OfError[int](anErr), Maybe(it))
+	assert.Equal(t, union.OfError[int](anErr), Maybe(it))
+	assert.Equal(t, anErr, Err(it))
+
+	// Delays
+	var (
+		delay = 5 * time.Millisecond
+		start = time.Now()
+	)
+
+	it = OfScript(ValueStep(1), ValueStep(2).After(delay))
+	assert.Equal(t, union.OfResult(1), Maybe(it))
+	assert.Equal(t, union.OfResult(2), Maybe(it))
+	assert.True(t, time.Since(start) >= delay)
+	assert.Equal(t, union.OfError[int](EOI), Maybe(it))
+
+	// No steps
+	assert.Equal(t, union.OfError[int](EOI), Maybe(OfScript[int]()))
+}
+
+func TestOfPages_(t *testing.T) {
+	it := OfPages(func(token string) ([]string, string, error) {
+		if token == "" {
+			return []string{"a", "b"}, "next", nil
+		}
+
+		return []string{"c"}, "", nil
+	})
+
+	assert.Equal(t, union.OfResult("a"), Maybe(it))
+	assert.Equal(t, union.OfResult("b"), Maybe(it))
+	assert.Equal(t, union.OfResult("c"), Maybe(it))
+	assert.Equal(t, union.OfError[string](EOI), Maybe(it))
+}
+
+func TestFallback_(t *testing.T) {
+	var (
+		missErr = fmt.Errorf("a miss")
+		cache   = OfScript(ErrorStep[string](missErr))
+		db      = Of("a", "b")
+		it      = Fallback(cache, db, func(err error) bool { return err == missErr })
+	)
+
+	assert.Equal(t, union.OfResult("a"), Maybe(it))
+	assert.Equal(t, union.OfResult("b"), Maybe(it))
+	assert.Equal(t, union.OfError[string](EOI), Maybe(it))
+
+	// By default, only an empty primary falls back
+	assert.Equal(t, union.OfResult("c"), Maybe(Fallback(OfEmpty[string](), Of("c"))))
+	assert.Equal(t, union.OfError[string](missErr), Maybe(Fallback(OfScript(ErrorStep[string](missErr)), Of("c"))))
+}
+
+func TestDedupAdjacent_(t *testing.T) {
+	it := DedupAdjacent(Of(1, 1, 2, 2, 2, 1), func(a, b int) bool { return a == b })
+	assert.Equal(t, union.OfResult(1), Maybe(it))
+	assert.Equal(t, union.OfResult(2), Maybe(it))
+	assert.Equal(t, union.OfResult(1), Maybe(it))
+	assert.Equal(t, union.OfError[int](EOI), Maybe(it))
+
+	// Custom equality compares with the last element returned, not the last element read
+	it = DedupAdjacent(Of(1, 2, 3, 4, 5), func(a, b int) bool { return (b - a) <= 1 })
+	assert.Equal(t, union.OfResult(1), Maybe(it))
+	assert.Equal(t, union.OfResult(3), Maybe(it))
+	assert.Equal(t, union.OfResult(5), Maybe(it))
+	assert.Equal(t, union.OfError[int](EOI), Maybe(it))
+}
+
+func TestErr_(t *testing.T) {
+	// Not terminated
+	it := Of(1)
+	assert.Nil(t, Err(it))
+	assert.Equal(t, union.OfResult(1), Maybe(it))
+	assert.Nil(t, Err(it))
+
+	// Terminated by EOI
+	assert.Equal(t, union.OfError[int](EOI), Maybe(it))
+	assert.Equal(t, EOI, Err(it))
+
+	// Unread does not change the error
+	it.Unread(2)
+	assert.Equal(t, EOI, Err(it))
+	assert.Equal(t, union.OfResult(2), Maybe(it))
+	assert.Equal(t, EOI, Err(it))
+
+	// Terminated by a problem
+	anErr := fmt.Errorf("An err")
+	it = SetError(Of(1), anErr)
+	assert.Nil(t, Err(it))
+	assert.Equal(t, union.OfResult(1), Maybe(it))
+	assert.Equal(t, union.OfError[int](anErr), Maybe(it))
+	assert.Equal(t, anErr, Err(it))
+
+	// An Iter that is not an ErrIter
+	plain := struct{ Iter[int] }{OfEmpty[int]()}
+	assert.Equal(t, union.OfError[int](EOI), Maybe[int](plain))
+	assert.Nil(t, Err[int](plain))
+}
+
+func TestNextInto_(t *testing.T) {
+	var (
+		it  = Of(1, 2)
+		val int
+	)
+
+	assert.Nil(t, NextInto(it, &val))
+	assert.Equal(t, 1, val)
+	assert.Nil(t, NextInto(it, &val))
+	assert.Equal(t, 2, val)
+
+	// Value is not modified once there are no more values
+	assert.Equal(t, EOI, NextInto(it, &val))
+	assert.Equal(t, EOI, NextInto(it, &val))
+	assert.Equal(t, 2, val)
+
+	// Unread values are returned
+	it.Unread(3)
+	assert.Nil(t, NextInto(it, &val))
+	assert.Equal(t, 3, val)
+	assert.Equal(t, EOI, NextInto(it, &val))
+
+	// Value is not modified on a problem
+	anErr := fmt.Errorf("An err")
+	it = SetError(OfEmpty[int](), anErr)
+	assert.Equal(t, anErr, NextInto(it, &val))
+	assert.Equal(t, anErr, Err(it))
+	assert.Equal(t, 3, val)
+
+	// An Iter that is not an IterImpl
+	plain := struct{ Iter[int] }{Of(4)}
+	assert.Nil(t, NextInto[int](plain, &val))
+	assert.Equal(t, 4, val)
+	assert.Equal(t, EOI, NextInto[int](plain, &val))
+	assert.Equal(t, 4, val)
+}
+
 func TestUnread_(t *testing.T) {
 	// Unread before next
 	it := OfEmpty[int]()
@@ -188,3 +364,97 @@ func TestSetError_(t *testing.T) {
 	assert.Equal(t, union.OfError[int](anErr), Maybe(it))
 	assert.Equal(t, union.OfError[int](anErr), Maybe(it))
 }
+
+// ==== Benchmarks
+
+// benchSlice is a large input for benchmarks
+var benchSlice = make([]int, 1_000_000)
+
+// benchSum keeps the results of benchmarks, so that the loops are not optimized away
+var benchSum int
+
+func BenchmarkNext_(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		it := OfSlice(benchSlice)
+		for _, err := it.Next(); err == nil; _, err = it.Next() {
+		}
+	}
+}
+
+// benchValue is a large value, that is costly to copy
+type benchValue struct {
+	vals [32]int
+}
+
+// benchValues is a large input of large values for benchmarks
+var benchValues = make([]benchValue, 100_000)
+
+func BenchmarkNextLarge_(b *testing.B) {
+	b.ReportAllocs()
+
+	var sum int
+	for i := 0; i < b.N; i++ {
+		it := OfSlice(benchValues)
+		for val, err := it.Next(); err == nil; val, err = it.Next() {
+			sum += val.vals[0]
+		}
+	}
+
+	benchSum = sum
+}
+
+func BenchmarkNextIntoLarge_(b *testing.B) {
+	b.ReportAllocs()
+
+	var sum int
+	for i := 0; i < b.N; i++ {
+		var (
+			it  = OfSlice(benchValues)
+			val benchValue
+		)
+
+		for err := NextInto(it, &val); err == nil; err = NextInto(it, &val) {
+			sum += val.vals[0]
+		}
+	}
+
+	benchSum = sum
+}
+
+func BenchmarkUnreadNext_(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		it := OfSlice(benchSlice)
+		for val, err := it.Next(); err == nil; val, err = it.Next() {
+			it.Unread(val)
+			it.Next()
+		}
+	}
+}
+
+func BenchmarkConcat_(b *testing.B) {
+	b.ReportAllocs()
+
+	half := len(benchSlice) / 2
+	for i := 0; i < b.N; i++ {
+		it := Concat(OfSlice(benchSlice[:half]), OfSlice(benchSlice[half:]))
+		for _, err := it.Next(); err == nil; _, err = it.Next() {
+		}
+	}
+}
+
+func BenchmarkOfStringAsRunes_(b *testing.B) {
+	b.ReportAllocs()
+
+	str := strings.Repeat("abc", len(benchSlice)/3)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		it := OfStringAsRunes(str)
+		for _, err := it.Next(); err == nil; _, err = it.Next() {
+		}
+	}
+}
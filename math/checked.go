@@ -0,0 +1,189 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"unsafe"
+
+	"github.com/bantling/micro/constraint"
+)
+
+// The overflow checks of AddChecked, SubChecked, AddSat, and SubSat are branchless: the high bit of an expression of the
+// operands and result is set if and only if the result wrapped around, which is shifted into a mask of all one bits. The
+// checked versions only branch on whether to return an error, and the saturating versions use the mask to select either
+// the result or the limit. Tests of ^T(0) < 0 are constant for each type, and are not branches.
+
+// intLimits returns the smallest and largest values of type T
+func intLimits[T constraint.Integer]() (T, T) {
+	if max := ^T(0); max > 0 {
+		return 0, max
+	}
+
+	var zv T
+	min := T(1) << (unsafe.Sizeof(zv)*8 - 1)
+	return min, ^min
+}
+
+// addOverflow returns a + b, and a mask of all one bits if the sum wrapped around, else 0.
+func addOverflow[T constraint.Integer](a, b T) (T, T) {
+	var (
+		r     = a + b
+		shift = unsafe.Sizeof(a)*8 - 1
+	)
+
+	if ^T(0) < 0 {
+		// The sum wraps if a and b have the same sign, and the sum has a different sign
+		return r, ((a ^ r) & (b ^ r)) >> shift
+	}
+
+	// The sum wraps if the high bits of a and b are both set, or either is set and the high bit of the sum is clear
+	return r, -(((a & b) | ((a | b) &^ r)) >> shift)
+}
+
+// subOverflow returns a - b, and a mask of all one bits if the difference wrapped around, else 0.
+func subOverflow[T constraint.Integer](a, b T) (T, T) {
+	var (
+		r     = a - b
+		shift = unsafe.Sizeof(a)*8 - 1
+	)
+
+	if ^T(0) < 0 {
+		// The difference wraps if a and b have different signs, and the difference has a different sign than a
+		return r, ((a ^ b) & (a ^ r)) >> shift
+	}
+
+	// The difference wraps (borrows) if the high bit of a is clear and that of b is set, or they are the same and the
+	// high bit of the difference is set
+	return r, -(((^a & b) | (^(a ^ b) & r)) >> shift)
+}
+
+// AddChecked returns a + b for any integer type.
+//
+// Returns OverflowErr if the sum is too large for type T, or UnderflowErr if it is too small.
+func AddChecked[T constraint.Integer](a, b T) (T, error) {
+	r, mask := addOverflow(a, b)
+	if mask == 0 {
+		return r, nil
+	}
+
+	// Only two negative values can underflow
+	if a < 0 {
+		return 0, UnderflowErr
+	}
+
+	return 0, OverflowErr
+}
+
+// SubChecked returns a - b for any integer type.
+//
+// Returns OverflowErr if the difference is too large for type T, or UnderflowErr if it is too small.
+// Unlike SubInt, a - MinInt is correctly an overflow for any a >= 0.
+func SubChecked[T constraint.Integer](a, b T) (T, error) {
+	r, mask := subOverflow(a, b)
+	if mask == 0 {
+		return r, nil
+	}
+
+	// A signed difference overflows if a >= 0 > b, and underflows if a < 0 <= b, an unsigned difference only underflows
+	if a < b {
+		return 0, UnderflowErr
+	}
+
+	return 0, OverflowErr
+}
+
+// MulChecked returns a * b for any integer type.
+// Unlike Mul, the product is calculated without allocating a big.Int.
+//
+// Returns OverflowErr if the product is too large for type T, or UnderflowErr if it is too small.
+func MulChecked[T constraint.Integer](a, b T) (T, error) {
+	r, ok := mulChecked(a, b)
+	if !ok {
+		if (a < 0) != (b < 0) {
+			return 0, UnderflowErr
+		}
+
+		return 0, OverflowErr
+	}
+
+	return r, nil
+}
+
+// DivChecked returns a / b for any integer type, truncated towards zero like the / operator. See Div for a rounded
+// quotient.
+//
+// Returns DivByZeroErr if b is zero, or OverflowErr for MinInt / -1 of a signed type, which the / operator wraps to MinInt.
+func DivChecked[T constraint.Integer](a, b T) (T, error) {
+	if b == 0 {
+		return 0, DivByZeroErr
+	}
+
+	// The only quotient that wraps is MinInt / -1, where both are negative and the quotient is too
+	r := a / b
+	if (r < 0) && (b < 0) && (a < 0) {
+		return 0, OverflowErr
+	}
+
+	return r, nil
+}
+
+// AddSat returns a + b for any integer type, saturated to the largest value of type T if the sum is too large, or the
+// smallest value if the sum is too small.
+func AddSat[T constraint.Integer](a, b T) T {
+	var (
+		r, mask = addOverflow(a, b)
+		shift   = unsafe.Sizeof(a)*8 - 1
+		limit   = ^T(0)
+	)
+
+	// A signed sum saturates to MaxInt, or MaxInt ^ -1 = MinInt if a is negative, an unsigned sum only to ^0
+	if limit < 0 {
+		limit = ^(T(1) << shift) ^ (a >> shift)
+	}
+
+	return r ^ ((r ^ limit) & mask)
+}
+
+// SubSat returns a - b for any integer type, saturated to the largest value of type T if the difference is too large, or
+// the smallest value if the difference is too small. An unsigned difference saturates to 0.
+func SubSat[T constraint.Integer](a, b T) T {
+	var (
+		r, mask = subOverflow(a, b)
+		shift   = unsafe.Sizeof(a)*8 - 1
+		limit   T
+	)
+
+	// A signed difference saturates to MaxInt, or MaxInt ^ -1 = MinInt if a is negative, an unsigned difference only to 0
+	if ^limit < 0 {
+		limit = ^(T(1) << shift) ^ (a >> shift)
+	}
+
+	return r ^ ((r ^ limit) & mask)
+}
+
+// MulSat returns a * b for any integer type, saturated to the largest value of type T if the product is too large, or
+// the smallest value if the product is too small.
+func MulSat[T constraint.Integer](a, b T) T {
+	if r, ok := mulChecked(a, b); ok {
+		return r
+	}
+
+	min, max := intLimits[T]()
+	if (a < 0) != (b < 0) {
+		return min
+	}
+
+	return max
+}
+
+// DivSat returns a / b for any integer type, truncated towards zero like the / operator, where MinInt / -1 of a signed
+// type saturates to MaxInt. Panics if b is zero, like the / operator.
+func DivSat[T constraint.Integer](a, b T) T {
+	r := a / b
+	if (r < 0) && (b < 0) && (a < 0) {
+		_, max := intLimits[T]()
+		return max
+	}
+
+	return r
+}
@@ -0,0 +1,215 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	gomath "math"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/tuple"
+	"github.com/stretchr/testify/assert"
+)
+
+// checkedExpect returns the expected result of a checked operation on types of at most 16 bits, given the exact result
+func checkedExpect[T int8 | uint8 | int16 | uint16](exact int, min, max T) (T, error) {
+	switch {
+	case exact > int(max):
+		return 0, OverflowErr
+	case exact < int(min):
+		return 0, UnderflowErr
+	}
+
+	return T(exact), nil
+}
+
+// satExpect returns the expected result of a saturating operation on types of at most 16 bits, given the exact result
+func satExpect[T int8 | uint8 | int16 | uint16](exact int, min, max T) T {
+	return T(MinOrdered(MaxOrdered(exact, int(min)), int(max)))
+}
+
+func TestIntLimits_(t *testing.T) {
+	assert.Equal(t, tuple.Of2(int8(gomath.MinInt8), int8(gomath.MaxInt8)), tuple.Of2(intLimits[int8]()))
+	assert.Equal(t, tuple.Of2(uint8(0), uint8(gomath.MaxUint8)), tuple.Of2(intLimits[uint8]()))
+	assert.Equal(t, tuple.Of2(int32(gomath.MinInt32), int32(gomath.MaxInt32)), tuple.Of2(intLimits[int32]()))
+	assert.Equal(t, tuple.Of2(int(gomath.MinInt), int(gomath.MaxInt)), tuple.Of2(intLimits[int]()))
+	assert.Equal(t, tuple.Of2(uint64(0), uint64(gomath.MaxUint64)), tuple.Of2(intLimits[uint64]()))
+}
+
+func TestAddSubChecked_(t *testing.T) {
+	// Compare every pair of int8 and uint8 values against int arithmetic
+	for a := gomath.MinInt8; a <= gomath.MaxInt8; a++ {
+		for b := gomath.MinInt8; b <= gomath.MaxInt8; b++ {
+			assert.Equal(t, tuple.Of2(checkedExpect(a+b, int8(gomath.MinInt8), gomath.MaxInt8)), tuple.Of2(AddChecked(int8(a), int8(b))))
+			assert.Equal(t, tuple.Of2(checkedExpect(a-b, int8(gomath.MinInt8), gomath.MaxInt8)), tuple.Of2(SubChecked(int8(a), int8(b))))
+			assert.Equal(t, satExpect(a+b, int8(gomath.MinInt8), gomath.MaxInt8), AddSat(int8(a), int8(b)))
+			assert.Equal(t, satExpect(a-b, int8(gomath.MinInt8), gomath.MaxInt8), SubSat(int8(a), int8(b)))
+		}
+	}
+
+	for a := 0; a <= gomath.MaxUint8; a++ {
+		for b := 0; b <= gomath.MaxUint8; b++ {
+			assert.Equal(t, tuple.Of2(checkedExpect(a+b, uint8(0), gomath.MaxUint8)), tuple.Of2(AddChecked(uint8(a), uint8(b))))
+			assert.Equal(t, tuple.Of2(checkedExpect(a-b, uint8(0), gomath.MaxUint8)), tuple.Of2(SubChecked(uint8(a), uint8(b))))
+			assert.Equal(t, satExpect(a+b, uint8(0), gomath.MaxUint8), AddSat(uint8(a), uint8(b)))
+			assert.Equal(t, satExpect(a-b, uint8(0), gomath.MaxUint8), SubSat(uint8(a), uint8(b)))
+		}
+	}
+
+	// Boundaries of 64 bit types
+	assert.Equal(t, tuple.Of2(int64(gomath.MaxInt64), error(nil)), tuple.Of2(AddChecked(int64(gomath.MaxInt64-1), 1)))
+	assert.Equal(t, tuple.Of2(int64(0), OverflowErr), tuple.Of2(AddChecked(int64(gomath.MaxInt64), 1)))
+	assert.Equal(t, tuple.Of2(int64(0), UnderflowErr), tuple.Of2(AddChecked(int64(gomath.MinInt64), -1)))
+	assert.Equal(t, tuple.Of2(int64(-1), error(nil)), tuple.Of2(AddChecked(int64(gomath.MinInt64), gomath.MaxInt64)))
+	assert.Equal(t, tuple.Of2(uint64(0), OverflowErr), tuple.Of2(AddChecked(uint64(gomath.MaxUint64), 1)))
+	assert.Equal(t, tuple.Of2(uint64(gomath.MaxUint64), error(nil)), tuple.Of2(AddChecked(uint64(1<<63), 1<<63-1)))
+
+	assert.Equal(t, tuple.Of2(int64(gomath.MaxInt64), error(nil)), tuple.Of2(SubChecked(int64(-1), gomath.MinInt64)))
+	assert.Equal(t, tuple.Of2(int64(0), OverflowErr), tuple.Of2(SubChecked(int64(0), gomath.MinInt64)))
+	assert.Equal(t, tuple.Of2(int64(0), UnderflowErr), tuple.Of2(SubChecked(int64(gomath.MinInt64), 1)))
+	assert.Equal(t, tuple.Of2(uint64(0), UnderflowErr), tuple.Of2(SubChecked(uint64(0), 1)))
+	assert.Equal(t, tuple.Of2(uint64(0), UnderflowErr), tuple.Of2(SubChecked(uint64(1<<63-1), 1<<63)))
+
+	assert.Equal(t, int64(gomath.MaxInt64), AddSat(int64(gomath.MaxInt64), gomath.MaxInt64))
+	assert.Equal(t, int64(gomath.MinInt64), AddSat(int64(gomath.MinInt64), gomath.MinInt64))
+	assert.Equal(t, uint(gomath.MaxUint), AddSat(uint(gomath.MaxUint), 2))
+	assert.Equal(t, int64(gomath.MaxInt64), SubSat(int64(0), gomath.MinInt64))
+	assert.Equal(t, int64(gomath.MinInt64), SubSat(int64(-2), gomath.MaxInt64))
+	assert.Equal(t, uint(0), SubSat(uint(1), 2))
+	assert.Equal(t, 3, SubSat(5, 2))
+}
+
+func TestMulDivChecked_(t *testing.T) {
+	// Compare every pair of int8 and uint8 values against int arithmetic
+	for a := gomath.MinInt8; a <= gomath.MaxInt8; a++ {
+		for b := gomath.MinInt8; b <= gomath.MaxInt8; b++ {
+			assert.Equal(t, tuple.Of2(checkedExpect(a*b, int8(gomath.MinInt8), gomath.MaxInt8)), tuple.Of2(MulChecked(int8(a), int8(b))))
+			assert.Equal(t, satExpect(a*b, int8(gomath.MinInt8), gomath.MaxInt8), MulSat(int8(a), int8(b)))
+
+			if b != 0 {
+				assert.Equal(t, tuple.Of2(checkedExpect(a/b, int8(gomath.MinInt8), gomath.MaxInt8)), tuple.Of2(DivChecked(int8(a), int8(b))))
+				assert.Equal(t, satExpect(a/b, int8(gomath.MinInt8), gomath.MaxInt8), DivSat(int8(a), int8(b)))
+			}
+		}
+	}
+
+	for a := 0; a <= gomath.MaxUint8; a++ {
+		for b := 0; b <= gomath.MaxUint8; b++ {
+			assert.Equal(t, tuple.Of2(checkedExpect(a*b, uint8(0), gomath.MaxUint8)), tuple.Of2(MulChecked(uint8(a), uint8(b))))
+			assert.Equal(t, satExpect(a*b, uint8(0), gomath.MaxUint8), MulSat(uint8(a), uint8(b)))
+
+			if b != 0 {
+				assert.Equal(t, tuple.Of2(uint8(a/b), error(nil)), tuple.Of2(DivChecked(uint8(a), uint8(b))))
+				assert.Equal(t, uint8(a/b), DivSat(uint8(a), uint8(b)))
+			}
+		}
+	}
+
+	// Boundaries of 64 bit types
+	assert.Equal(t, tuple.Of2(int64(gomath.MinInt64), error(nil)), tuple.Of2(MulChecked(int64(1<<62), -2)))
+	assert.Equal(t, tuple.Of2(int64(0), OverflowErr), tuple.Of2(MulChecked(int64(1<<62), 2)))
+	assert.Equal(t, tuple.Of2(int64(0), OverflowErr), tuple.Of2(MulChecked(int64(gomath.MinInt64), -1)))
+	assert.Equal(t, tuple.Of2(int64(0), UnderflowErr), tuple.Of2(MulChecked(int64(gomath.MaxInt64), -2)))
+	assert.Equal(t, tuple.Of2(uint64(0), OverflowErr), tuple.Of2(MulChecked(uint64(1<<32), 1<<32)))
+	assert.Equal(t, int64(gomath.MaxInt64), MulSat(int64(gomath.MinInt64), -1))
+	assert.Equal(t, int64(gomath.MinInt64), MulSat(int64(gomath.MinInt64), 2))
+	assert.Equal(t, uint64(gomath.MaxUint64), MulSat(uint64(1<<32), 1<<32))
+
+	assert.Equal(t, tuple.Of2(int64(0), OverflowErr), tuple.Of2(DivChecked(int64(gomath.MinInt64), -1)))
+	assert.Equal(t, tuple.Of2(int64(-3), error(nil)), tuple.Of2(DivChecked(int64(7), -2)))
+	assert.Equal(t, tuple.Of2(0, DivByZeroErr), tuple.Of2(DivChecked(1, 0)))
+	assert.Equal(t, tuple.Of2(uint(0), DivByZeroErr), tuple.Of2(DivChecked(uint(1), 0)))
+	assert.Equal(t, int64(gomath.MaxInt64), DivSat(int64(gomath.MinInt64), -1))
+
+	funcs.TryTo(
+		func() {
+			DivSat(1, 0)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.ErrorContains(t, e.(error), "divide by zero")
+		},
+	)
+}
+
+// checkedBenchValues are pairs of operands that mostly do not overflow, as in typical arithmetic
+var checkedBenchValues = [][2]int64{
+	{1, 2},
+	{-1_000_000, 999},
+	{gomath.MaxInt64 - 5, 3},
+	{gomath.MinInt64 + 5, -3},
+	{123_456_789, -987_654_321},
+	{gomath.MaxInt64, 1},
+	{-7, gomath.MinInt64 + 7},
+	{42, 42},
+}
+
+// checkedBenchSum keeps the results of benchmarks, so that the calculations are not optimized away
+var checkedBenchSum int64
+
+// The branchless AddChecked compared with the branching AddInt, and MulChecked compared with the big.Int based Mul
+func BenchmarkAddChecked_(b *testing.B) {
+	var sum int64
+	for i := 0; i < b.N; i++ {
+		v := checkedBenchValues[i&7]
+		r, _ := AddChecked(v[0], v[1])
+		sum += r
+	}
+
+	checkedBenchSum = sum
+}
+
+func BenchmarkAddInt_(b *testing.B) {
+	var sum int64
+	for i := 0; i < b.N; i++ {
+		v := checkedBenchValues[i&7]
+		r := v[1]
+		AddInt(v[0], &r)
+		sum += r
+	}
+
+	checkedBenchSum = sum
+}
+
+func BenchmarkAddSat_(b *testing.B) {
+	var sum int64
+	for i := 0; i < b.N; i++ {
+		v := checkedBenchValues[i&7]
+		sum += AddSat(v[0], v[1])
+	}
+
+	checkedBenchSum = sum
+}
+
+func BenchmarkSubSat_(b *testing.B) {
+	var sum int64
+	for i := 0; i < b.N; i++ {
+		v := checkedBenchValues[i&7]
+		sum += SubSat(v[0], v[1])
+	}
+
+	checkedBenchSum = sum
+}
+
+func BenchmarkMulChecked_(b *testing.B) {
+	var sum int64
+	for i := 0; i < b.N; i++ {
+		v := checkedBenchValues[i&7]
+		r, _ := MulChecked(v[0], v[1])
+		sum += r
+	}
+
+	checkedBenchSum = sum
+}
+
+func BenchmarkMul_(b *testing.B) {
+	var sum int64
+	for i := 0; i < b.N; i++ {
+		v := checkedBenchValues[i&7]
+		r := v[1]
+		Mul(v[0], &r)
+		sum += r
+	}
+
+	checkedBenchSum = sum
+}
@@ -0,0 +1,61 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"math/big"
+	"math/bits"
+
+	"github.com/bantling/micro/constraint"
+)
+
+// ctIsZero returns 1 if v is 0, else 0, without branching on v
+func ctIsZero(v uint64) uint64 {
+	// For any non-zero v, the high bit of v or -v is set
+	return ((v | -v) >> 63) ^ 1
+}
+
+// ConstantTimeEqual returns true if a == b, taking the same time for any pair of values.
+// Useful for comparing integers that carry secrets such as tokens, where the time taken by == may reveal how much of
+// the values match.
+func ConstantTimeEqual[T constraint.Integer](a, b T) bool {
+	return ctIsZero(uint64(a^b)) == 1
+}
+
+// ConstantTimeEqual returns true if d and o are the same value, taking the same time for any pair of values.
+// Like Cmp, values with different scales are equal if they represent the same number (eg 1.5 is equal to 1.50).
+//
+// Both values are scaled to 18 decimal places as 128 bit magnitudes, which cannot overflow, so unlike AdjustDecimalScale
+// there is no failure case that would take less time.
+func (d Decimal) ConstantTimeEqual(o Decimal) bool {
+	// magnitude returns the absolute value scaled to 18 decimal places as 128 bits, and the sign mask
+	magnitude := func(dec Decimal) (hi, lo, mask uint64) {
+		m := dec.value >> 63
+		hi, lo = bits.Mul64(uint64((dec.value^m)-m), uint64(powersOf10[decimalMaxScale-dec.scale]))
+		return hi, lo, uint64(m)
+	}
+
+	var (
+		dhi, dlo, dm = magnitude(d)
+		ohi, olo, om = magnitude(o)
+	)
+
+	return ctIsZero((dhi^ohi)|(dlo^olo)|(dm^om)) == 1
+}
+
+// WipeBigInt overwrites the memory of a *big.Int that carries a secret with zeros, including any unused capacity, and
+// sets it to 0. Useful to limit how long a secret remains in memory once it is no longer needed, although any copies
+// made by earlier calculations are not affected.
+func WipeBigInt(b *big.Int) {
+	if b == nil {
+		return
+	}
+
+	words := b.Bits()
+	words = words[:cap(words)]
+	for i := range words {
+		words[i] = 0
+	}
+
+	b.SetInt64(0)
+}
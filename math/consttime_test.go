@@ -0,0 +1,81 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	gomath "math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCtIsZero_(t *testing.T) {
+	assert.Equal(t, uint64(1), ctIsZero(0))
+	assert.Equal(t, uint64(0), ctIsZero(1))
+	assert.Equal(t, uint64(0), ctIsZero(1<<63))
+	assert.Equal(t, uint64(0), ctIsZero(gomath.MaxUint64))
+}
+
+func TestConstantTimeEqual_(t *testing.T) {
+	assert.True(t, ConstantTimeEqual(0, 0))
+	assert.True(t, ConstantTimeEqual(-5, -5))
+	assert.False(t, ConstantTimeEqual(-5, 5))
+	assert.True(t, ConstantTimeEqual(int8(gomath.MinInt8), int8(gomath.MinInt8)))
+	assert.False(t, ConstantTimeEqual(int8(gomath.MinInt8), int8(gomath.MaxInt8)))
+	assert.True(t, ConstantTimeEqual(uint64(gomath.MaxUint64), uint64(gomath.MaxUint64)))
+	assert.False(t, ConstantTimeEqual(uint64(gomath.MaxUint64), 0))
+	assert.False(t, ConstantTimeEqual(int64(gomath.MinInt64), 0))
+}
+
+func TestDecimalConstantTimeEqual_(t *testing.T) {
+	for _, vals := range [][2]string{
+		{"0", "0"},
+		{"0", "0.00"},
+		{"-0.00", "0"},
+		{"1.5", "1.50"},
+		{"-1.5", "-1.500000"},
+		{"999999999999999999", "999999999999999999"},
+		{"-.999999999999999999", "-.999999999999999999"},
+	} {
+		d, o := MustStringToDecimal(vals[0]), MustStringToDecimal(vals[1])
+		assert.True(t, d.ConstantTimeEqual(o), "%s = %s", vals[0], vals[1])
+		assert.True(t, o.ConstantTimeEqual(d), "%s = %s", vals[1], vals[0])
+		assert.Equal(t, 0, d.Cmp(o))
+	}
+
+	for _, vals := range [][2]string{
+		{"0", "1"},
+		{"1.5", "-1.5"},
+		{"1.5", "1.51"},
+		{"1", ".000000000000000001"},
+		{"999999999999999999", "-999999999999999999"},
+		{".999999999999999999", ".999999999999999998"},
+	} {
+		d, o := MustStringToDecimal(vals[0]), MustStringToDecimal(vals[1])
+		assert.False(t, d.ConstantTimeEqual(o), "%s != %s", vals[0], vals[1])
+		assert.False(t, o.ConstantTimeEqual(d), "%s != %s", vals[1], vals[0])
+	}
+
+	// Denormalized values compare the same way
+	assert.True(t, MustDecimal(150, 2, false).ConstantTimeEqual(MustDecimal(15, 1)))
+}
+
+func TestWipeBigInt_(t *testing.T) {
+	b, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	words := b.Bits()
+	words = words[:cap(words)]
+
+	WipeBigInt(b)
+	assert.Equal(t, 0, b.Sign())
+	for _, w := range words {
+		assert.Equal(t, big.Word(0), w)
+	}
+
+	// Still usable
+	b.SetInt64(5)
+	assert.Equal(t, int64(5), b.Int64())
+
+	// Nil is ignored
+	WipeBigInt(nil)
+}
@@ -4,18 +4,16 @@ package math
 
 import (
 	"fmt"
-	"regexp"
-	"slices"
+	"math/bits"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/bantling/micro/conv"
 	"github.com/bantling/micro/funcs"
 )
 
 var (
-	// optional minus sign, zero or more digits, optional dot and zero or more digits.
-	decimalRegex = regexp.MustCompile("(-?)([0-9]*)[.]?([0-9]*)")
-
 	// Powers of 10 constants from 10^0 thru 10^18 (scale can be 0 - 18)
 	powersOf10 = []int64{
 		1,                         //  0
@@ -102,6 +100,9 @@ const (
 
 	// errDecimalDivisorTooLargeMsg is the error message for dividing by a divisor that is larger than the dividend
 	errDecimalDivisorTooLargeMsg = "The decimal calculation %s / %d is not allowed, the divisor is larger than the dividend"
+
+	// errDecimalMeanNoValuesMsg is the error message for the mean of an empty slice
+	errDecimalMeanNoValuesMsg = "The decimal mean of no values is not allowed"
 )
 
 // Decimal is like SQL Decimal(precision, scale):
@@ -115,6 +116,31 @@ type Decimal struct {
 	denormalized bool
 }
 
+// DecimalFormat contains the options for Decimal.Format. The zero value formats the same as Decimal.String.
+type DecimalFormat struct {
+	// ThousandsSeparator is inserted between each group of three integer digits, if it is non-empty (eg, ",")
+	ThousandsSeparator string
+
+	// DecimalPoint separates the integer and fractional digits, if it is empty then "." is used
+	DecimalPoint string
+
+	// Plus adds a leading + sign to a value that is not negative
+	Plus bool
+
+	// TrimZeros removes any trailing zeros after the decimal point, and the decimal point if no digits remain
+	TrimZeros bool
+
+	// MinScale adds trailing zeros as needed so there are at least MinScale digits after the decimal point.
+	// It is applied after TrimZeros, so that eg 1.500 can be formatted as 1.50.
+	MinScale uint
+
+	// Width is the minimum number of characters, the result is padded on the left as needed
+	Width uint
+
+	// ZeroPad pads to Width with zeros after any sign, rather than spaces before any sign
+	ZeroPad bool
+}
+
 // OfDecimal creates a Decimal with the given sign, digits, and scale
 // For clarity, there is no default scale
 func OfDecimal(value int64, scale uint, normalized ...bool) (d Decimal, err error) {
@@ -148,67 +174,224 @@ func MustDecimal(value int64, scale uint, normalized ...bool) Decimal {
 }
 
 // StringToDecimal creates a Decimal from the given string
-// The string must contain no more than 18 significant digits, and satisfy the following regex:
+// The string must contain at least one and no more than 18 digits, and satisfy the following regex:
 // (-?)([0-9]*)(.[0-9]*)?
+//
+// The string may be followed by an exponent of e or E and a signed integer, as in JSON numbers, so that 1.5e-3 is
+// 0.0015 and 1.5E3 is 1500. The result must not have more than 18 digits or a scale > 18, except that trailing zeros
+// are removed to reduce the scale, so 1.50e-17 is 0.000_000_000_000_000_015.
 func StringToDecimal(value string) (d Decimal, err error) {
-	parts := decimalRegex.FindStringSubmatch(value)
+	if i := strings.IndexAny(value, "eE"); i >= 0 {
+		return expStringToDecimal(value, value[:i], value[i+1:])
+	}
 
-	// Error if string doesn't match regex
-	// Error if total number of digits > 18
-	// indexes : 1 = optional leading minus sign, 2 = optional integer digits, 3 = optional decimal digits
-	if (parts == nil) || slices.Equal(parts, []string{"", "", "", ""}) || ((len(parts[2]) + len(parts[3])) > 18) {
-		err = fmt.Errorf(errInvalidStringMsg, value)
-		return
+	// Scan the digits by hand, accumulating the value as they are read, which is much faster than a regex.
+	// Digits are limited to 18, so the value cannot overflow.
+	var (
+		i      = 0
+		digits uint
+		point  bool
+	)
+
+	if (len(value) > 0) && (value[0] == '-') {
+		i = 1
 	}
 
-	// Set scale to number of digits after decimal, which may be zero
-	d.scale = uint(len(parts[3]))
+	for ; i < len(value); i++ {
+		switch c := value[i]; {
+		case ('0' <= c) && (c <= '9'):
+			if digits++; digits > decimalMaxScale {
+				return Decimal{}, fmt.Errorf(errInvalidStringMsg, value)
+			}
 
-	// Combine digits before and after decimal into a single string, and convert it to the int64 value
-	conv.StringToInt64(parts[2]+parts[3], &d.value)
+			d.value = d.value*10 + int64(c-'0')
+			if point {
+				d.scale++
+			}
+
+		case (c == '.') && !point:
+			point = true
+
+		default:
+			return Decimal{}, fmt.Errorf(errInvalidStringMsg, value)
+		}
+	}
+
+	// Error if there are no digits
+	if digits == 0 {
+		return Decimal{}, fmt.Errorf(errInvalidStringMsg, value)
+	}
 
 	// If there is a leading minus sign, negate the value
-	if len(parts[1]) > 0 {
+	if value[0] == '-' {
 		d.value = -d.value
 	}
 
 	return
 }
 
+// expStringToDecimal is StringToDecimal for a value with an exponent, split into the mantissa and exponent strings
+func expStringToDecimal(value, mantissa, exponent string) (Decimal, error) {
+	// The exponent is limited to 32 bits, so that adjusting the scale cannot overflow
+	var (
+		d, err      = StringToDecimal(mantissa)
+		exp, experr = strconv.ParseInt(exponent, 10, 32)
+	)
+
+	if (err != nil) || (experr != nil) {
+		return Decimal{}, fmt.Errorf(errInvalidStringMsg, value)
+	}
+
+	// A zero of any scale is just clamped to a valid scale
+	scale := int64(d.scale) - exp
+	if d.value == 0 {
+		d.scale = uint(MaxOrdered(0, MinOrdered(scale, decimalMaxScale)))
+		return d, nil
+	}
+
+	// A negative scale requires adding trailing zeros, and a scale > 18 requires removing them
+	for ; scale < 0; scale++ {
+		if (d.value > decimalMaxValue/10) || (d.value < decimalMinValue/10) {
+			return Decimal{}, fmt.Errorf(errInvalidStringMsg, value)
+		}
+
+		d.value *= 10
+	}
+
+	for ; (scale > decimalMaxScale) && (d.value%10 == 0); scale-- {
+		d.value /= 10
+	}
+
+	if scale > decimalMaxScale {
+		return Decimal{}, fmt.Errorf(errInvalidStringMsg, value)
+	}
+
+	d.scale = uint(scale)
+	return d, nil
+}
+
 // MustStringToDecimal is a must version of StringToDecimal
 func MustStringToDecimal(value string) Decimal {
 	return funcs.MustValue(StringToDecimal(value))
 }
 
 // String is the Stringer interface
-func (d Decimal) String() (str string) {
-	// Convert the abs value of the int to a string to start
-	str = conv.IntToString(funcs.Ternary(d.value < 0, -d.value, d.value))
+func (d Decimal) String() string {
+	// The longest string is a minus sign, a leading zero, a decimal point, and 18 digits
+	var (
+		buf [21]byte
+		i   = len(buf)
+		mag = uint64(funcs.Ternary(d.value < 0, -d.value, d.value))
+	)
+
+	// Write the digits from right to left, with the decimal point after scale digits. There is at least one digit before
+	// the decimal point, so a value with no more digits than the scale has a leading zero.
+	for n := uint(0); (mag > 0) || (n <= d.scale); n++ {
+		if (n == d.scale) && (n > 0) {
+			i--
+			buf[i] = '.'
+		}
+
+		i--
+		buf[i] = byte('0' + mag%10)
+		mag /= 10
+	}
+
+	// Add a leading minus if negative
+	if d.value < 0 {
+		i--
+		buf[i] = '-'
+	}
+
+	return string(buf[i:])
+}
 
-	// Get number of significant digits (length of string)
-	numSig := uint(len(str))
+// SciString returns the decimal in scientific notation, with one digit before the decimal point and no trailing zeros,
+// such as 1.5e-3 for 0.0015, -1.25e1 for -12.50, and 0e0 for zero. The result is a valid JSON number, and is parseable
+// by StringToDecimal, although trailing zeros of the scale are not preserved.
+func (d Decimal) SciString() string {
+	var (
+		digits  = conv.IntToString(funcs.Ternary(d.value < 0, -d.value, d.value))
+		trimmed = strings.TrimRight(digits, "0")
+		sign    = funcs.Ternary(d.value < 0, "-", "")
+	)
+
+	if trimmed == "" {
+		return "0e0"
+	}
+
+	str := trimmed[:1]
+	if len(trimmed) > 1 {
+		str += "." + trimmed[1:]
+	}
+
+	return sign + str + "e" + conv.IntToString(len(digits)-1-int(d.scale))
+}
+
+// Format formats the decimal according to the given options.
+// The resulting string is only parseable by StringToDecimal if the ThousandsSeparator, DecimalPoint, Plus, and Width
+// options are zero values.
+func (d Decimal) Format(opts DecimalFormat) string {
+	var (
+		// Split the abs value into integer and (possibly empty) fractional digits
+		parts  = strings.Split(strings.TrimPrefix(d.String(), "-"), ".")
+		intStr = parts[0]
+		frac   = funcs.SliceIndex(parts, 1)
+		sign   string
+		bld    strings.Builder
+	)
+
+	if opts.TrimZeros {
+		frac = strings.TrimRight(frac, "0")
+	}
+
+	if n := int(opts.MinScale) - len(frac); n > 0 {
+		frac += strings.Repeat("0", n)
+	}
 
 	switch {
-	// No digits after the decimal point, just an integer
-	case d.scale == 0:
-		break
+	case d.value < 0:
+		sign = "-"
+	case opts.Plus:
+		sign = "+"
+	}
 
-	// The number of significant digits is <= the number of decimals. Add leading "0." + (scale - digits) zeros.
-	case numSig <= d.scale:
-		str = "0." + strings.Repeat("0", int(d.scale-numSig)) + str
+	// Write integer digits, with a separator before each group of three except the first
+	if opts.ThousandsSeparator != "" {
+		first := len(intStr) % 3
+		if first == 0 {
+			first = 3
+		}
 
-	// At least one digit before and after decimal point, insert decimal at appropriate position
-	default:
-		numDigitsBeforeDecimal := numSig - d.scale
-		str = str[:numDigitsBeforeDecimal] + "." + str[numDigitsBeforeDecimal:]
+		bld.WriteString(intStr[:first])
+		for i := first; i < len(intStr); i += 3 {
+			bld.WriteString(opts.ThousandsSeparator)
+			bld.WriteString(intStr[i : i+3])
+		}
+	} else {
+		bld.WriteString(intStr)
 	}
 
-	// Add a leading minus if negative
-	if d.value < 0 {
-		str = "-" + str
+	if len(frac) > 0 {
+		bld.WriteString(funcs.Ternary(opts.DecimalPoint == "", ".", opts.DecimalPoint))
+		bld.WriteString(frac)
 	}
 
-	return
+	// Pad to width, which is a number of characters, not bytes, in case the separators are not ASCII
+	var (
+		digits = bld.String()
+		pad    string
+	)
+
+	if n := int(opts.Width) - utf8.RuneCountInString(sign) - utf8.RuneCountInString(digits); n > 0 {
+		pad = strings.Repeat(funcs.Ternary(opts.ZeroPad, "0", " "), n)
+	}
+
+	if opts.ZeroPad {
+		return sign + pad + digits
+	}
+
+	return pad + sign + digits
 }
 
 // Precison returns the total number of digits of a decimal, including trailing zeros.
@@ -249,14 +432,14 @@ func (d Decimal) Sign() (sgn int) {
 //   - If both numbers have the same scale, no adjustment is made
 //   - Otherwise, the number with the smaller scale is usually adjusted to the same scale as the other number
 //   - Increasing the scale can cause some most significant digits to be lost, in which case the other number is rounded
-//     down to match the scale
+//     to match the scale, using the optional mode, which defaults to HalfUp
 //
 // Examples:
 //
 // 1.5 and 1.25 -> 1.50 and 1.25
 // 1.5 and 18 digits with no decimals -> 18 digits cannot increase scale, so round 1.5 to 2
 // 99_999_999_999_999_999.5 and 1 -> the 18 digits round to a 19 digit value, an error occurs
-func AdjustDecimalScale(d1, d2 *Decimal) error {
+func AdjustDecimalScale(d1, d2 *Decimal, mode ...RoundingMode) error {
 	if d1.scale == d2.scale {
 		return nil
 	}
@@ -268,13 +451,10 @@ func AdjustDecimalScale(d1, d2 *Decimal) error {
 		d2 = t
 	}
 
-	// Convert d1 and d2 to strings of digits only, to see how many significant digits they possess
-	var str1, str2 string
-	str1 = conv.IntToString(funcs.Ternary(d1.value >= 0, d1.value, -d1.value))
-	str2 = conv.IntToString(funcs.Ternary(d2.value >= 0, d2.value, -d2.value))
+	// Convert d2 to a string of digits only, to see how many significant digits it possesses
+	str2 := conv.IntToString(funcs.Ternary(d2.value >= 0, d2.value, -d2.value))
 
 	var (
-		len1       = len(str1)
 		len2       = len(str2)
 		d2Capacity = decimalMaxScale - len2
 		scaleDiff  = int(d1.scale - d2.scale)
@@ -289,7 +469,7 @@ func AdjustDecimalScale(d1, d2 *Decimal) error {
 
 		d2.scale = d1.scale
 	} else {
-		// Harder solution - round d1 away from 0 to the same scale as d2, and set scale to match d2
+		// Harder solution - round d1 to the same scale as d2
 
 		// First check if d2 value can actually be rounded
 		if d2.value > decimalRoundMaxValue {
@@ -300,47 +480,16 @@ func AdjustDecimalScale(d1, d2 *Decimal) error {
 			return fmt.Errorf(errValueTooSmallToRoundMsg, d2.String())
 		}
 
-		// Round by manipulating digits directly in string as a []byte
-		dig1 := []byte(str1)
-
-		// Round by just examining first decimal place, if <= 4 round integer down, else round integer up
-		round := dig1[len1-scaleDiff] >= '5'
-
-		// throw away decimal digits
-		dig1 = dig1[:len1-scaleDiff]
-		len1 = len(dig1)
-
-		// Integer rounding affects digits of d1 we're keeping
-		// - as long as integer digit = 9, set to 0
-		// - if a digit < 9 is encountered, increment and stop
-		// - if all digits are 9, add additional 1 digit on left
-		var dig byte
-		for i := len1 - 1; round && (i >= 0); i-- {
-			dig = dig1[i]
-			round = dig == '9'
-			dig1[i] = funcs.Ternary(round, '0', dig+1)
-		}
-
-		// If final round is true, all integer digits are 9, add a leading 1
-		str1 = funcs.Ternary(round, "1"+string(dig1), string(dig1))
-
-		// Set d1 scale
-		d1.scale = d2.scale
-
-		// Set d1 value (preserving sign)
-		neg := d1.value < 0
-		conv.StringToInt64(str1, &d1.value)
-		if neg {
-			d1.value = -d1.value
-		}
+		// Round d1 to the scale of d2, which cannot overflow as d1 loses at least one digit
+		*d1 = d1.Round(d2.scale, funcs.SliceIndex(mode, 0))
 	}
 
 	return nil
 }
 
 // MustAdjustDecimalScale is a must version of AdjustDecimalScale
-func MustAdjustDecimalScale(d1, d2 *Decimal) {
-	funcs.Must(AdjustDecimalScale(d1, d2))
+func MustAdjustDecimalScale(d1, d2 *Decimal, mode ...RoundingMode) {
+	funcs.Must(AdjustDecimalScale(d1, d2, mode...))
 }
 
 // AdjustDecimalFormat adjusts the two decimals strings to have the same number of digits before the decimal,
@@ -569,57 +718,115 @@ func (d Decimal) MustSub(o Decimal) Decimal {
 	return funcs.MustValue(d.Sub(o))
 }
 
-// Mul calculates d * o using one of two methods:
-//
-// 1. r = d * o is tried first
-// If r = 0, then return 0 scale 0.
-// If r / d = o, then if r > max value, we have a valid result 19 digits in length.
-//
-//	Round it to 18 with single divide by 10, and add 1 if remainder >= 5.
-//	Given max int64 value starts with 92, divide by 10 cannot be max value.
-//
-// Otherwise, go to method 2 below.
-//
-// The resulting scale rs is d scale + o scale.
-// If rs <= 18, just return r with scale rs.
-// Otherwise, round r (rs - 18) times.
-// If the result is 0, return 0 scale 0, else return r scale rs.
-//
-// 2. If r / d != o, we have a case where d * o exceeds bounds of 64 bit integers.
-// Split d and o into 32-bit upper/lower pairs, and perform a series of shift and adds
-// that generate a 128-bit result.
-//
-// The 128 bit result is first rounded down to 18 digits, reducing rs by up to 18.
-// If rs = 0 and there are more than 18 digitds l
-// The result rs must be <= 18, return as is.
-func (d Decimal) Mul(o Decimal) (Decimal, error) {
-	// Start by just multiplying the two 64-bit values together, and adding their scales
-	r := d
-	r.value *= o.value
-	r.scale += o.scale
+// mul128 multiplies two signed 64-bit values, returning whether the product is negative, and the upper and lower 64
+// bits of the 128-bit magnitude of the product
+func mul128(a, b int64) (neg bool, hi, lo uint64) {
+	neg = (a < 0) != (b < 0)
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+
+	hi, lo = bits.Mul64(uint64(a), uint64(b))
+	return
+}
 
-	// There are two cases of over/under flow:
-	// - operation is not reversible: o != 0 and r / o != d
-	// - abs(value) > 18 9's
-	// It is an overflow if the signs are the same, underflow if they differ
-	// Note we must do checks in the order shown above:
-	// - The resulting value may be storable in a 64 bit int, but roll over/under, so that it has the opposite sign of what it should be
-	if (o.value != 0) && (r.value/o.value != d.value) {
-		return Decimal{}, fmt.Errorf(funcs.Ternary(d.Sign() == o.Sign(), errDecimalOverflowMsg, errDecimalUnderflowMsg), d, "*", o)
+// div128 divides the 128-bit value hi:lo by o, returning the 128-bit quotient and the remainder
+func div128(hi, lo, o uint64) (qhi, qlo, rem uint64) {
+	qhi = hi / o
+	qlo, rem = bits.Div64(hi%o, lo, o)
+	return
+}
+
+// digits36 returns the number of decimal digits in the 128-bit value hi:lo, which is at most 36 for the product of two
+// 18 digit values. Zero has one digit.
+func digits36(hi, lo uint64) uint {
+	var n uint
+	for hi != 0 {
+		hi, lo, _ = div128(hi, lo, uint64(powersOf10[decimalMaxScale]))
+		n += decimalMaxScale
 	}
-	if r.value > decimalMaxValue {
-		return Decimal{}, fmt.Errorf(errDecimalOverflowMsg, d, "*", o)
+
+	for n++; lo >= 10; lo /= 10 {
+		n++
 	}
-	if r.value < decimalMinValue {
-		return Decimal{}, fmt.Errorf(errDecimalUnderflowMsg, d, "*", o)
+
+	return n
+}
+
+// round128 rounds the 128-bit magnitude hi:lo with the given scale to at most 18 digits and scale 18, using the given
+// mode, where neg is true if the value is negative. Returns the rounded magnitude and scale.
+// Returns false if the integer part has more than 18 digits.
+func round128(hi, lo uint64, scale uint, neg bool, mode RoundingMode) (uint64, uint, bool) {
+	// The number of digits to remove is whichever is larger of the digits beyond 18, and the scale beyond 18
+	var excess uint
+	if digits := digits36(hi, lo); digits > decimalMaxScale {
+		excess = digits - decimalMaxScale
+	}
+	if (scale > decimalMaxScale) && (scale-decimalMaxScale > excess) {
+		excess = scale - decimalMaxScale
+	}
+
+	switch {
+	case excess > scale:
+		return 0, 0, false
+	case excess == 0:
+		return lo, scale, true
+	}
+
+	// Remove the excess digits, where at most 18 digits remain, which fit in the lower 64 bits.
+	// The remainder is < 10^18, so twice the remainder can be compared to 10^excess to see if it is more than halfway.
+	p := uint64(powersOf10[excess])
+	_, lo, rem := div128(hi, lo, p)
+	scale -= excess
+
+	if (rem != 0) && roundAway(mode, neg, (lo&1) == 1, CmpOrdered(2*rem, p)) {
+		lo++
+	}
+
+	// Rounding up 18 9s produces 19 digits, which are 1 followed by 18 zeros that can be removed if there is a scale
+	if lo > uint64(decimalMaxValue) {
+		if scale == 0 {
+			return 0, 0, false
+		}
+
+		lo /= 10
+		scale--
+	}
+
+	return lo, scale, true
+}
+
+// Mul calculates d * o as a 128-bit product, so that any two Decimals can be multiplied.
+//
+// The scale of the product is d scale + o scale, which can be up to 36 digits. If the product has more than 18 digits,
+// or a scale > 18, it is rounded to 18 digits and scale 18 by reducing the scale, so the result is as accurate as a
+// Decimal can be. The optional mode is the RoundingMode, which defaults to HalfUp. EG, 0.000_000_001 * 0.000_000_000_5 = 0.000_000_000_000_000_001 (rounded up from scale 19), and
+// 123_456_789.123_456_789 * 10.5 = 1_296_296_285.796_296_28 (rounded down from 20 digits).
+//
+// Returns an overflow error if the integer part has more than 18 digits and the result is positive, or an underflow
+// error if the result is negative.
+func (d Decimal) Mul(o Decimal, mode ...RoundingMode) (Decimal, error) {
+	neg, hi, lo := mul128(d.value, o.value)
+
+	value, scale, ok := round128(hi, lo, d.scale+o.scale, neg, funcs.SliceIndex(mode, 0))
+	if !ok {
+		return Decimal{}, fmt.Errorf(funcs.Ternary(neg, errDecimalUnderflowMsg, errDecimalOverflowMsg), d, "*", o)
+	}
+
+	r := Decimal{value: int64(value), scale: scale, denormalized: d.denormalized}
+	if neg {
+		r.value = -r.value
 	}
 
 	return r, nil
 }
 
 // MustMul is a must version of Mul
-func (d Decimal) MustMul(o Decimal) Decimal {
-	return funcs.MustValue(d.Mul(o))
+func (d Decimal) MustMul(o Decimal, mode ...RoundingMode) Decimal {
+	return funcs.MustValue(d.Mul(o, mode...))
 }
 
 // DivIntQuoRem divides d by unsigned integer o, and returns (quotient, remainder, error).
@@ -693,6 +900,14 @@ func (d Decimal) MustDivIntAdd(o uint) []Decimal {
 // Integer division is used to generate digits by taking remainders and multiplying them by 10 until they are >= divisor,
 // so that the remainder can then be divided further, generating more digits.
 //
+// Digits are generated one at a time, until the remainder is zero, or the quotient has 18 digits or scale 18. Since the
+// remainder is always less than the divisor, multiplying it by 10 never overflows, so large operands lose no precision.
+// The remaining fraction is used to round the quotient with the optional mode, which defaults to HalfUp.
+//
+// Returns a division by zero error if o is zero.
+// Returns an overflow error if the integer part of a positive result has more than 18 digits, or an underflow error if
+// the integer part of a negative result has more than 18 digits.
+//
 // Examples:
 //
 // 1. 5000 / 200
@@ -796,8 +1011,9 @@ func (d Decimal) MustDivIntAdd(o uint) []Decimal {
 //
 // 17. 1 / 200_000_000_000_000_000
 // 1 / 200_000_000_000_000_000
-// = 1 * 10^18 / 200_000_000_000_000_000, 1 * 10^18 is too large to store
-// = overflow
+// = 1 * 10^18 / 200_000_000_000_000_000, generating 18 digits from the remainder one at a time
+// = 5 scale 18
+// = 0.000_000_000_000_000_005
 //
 // 18. 100_000_000_000_000_000 / 0.1
 // = 100_000_000_000_000_000 / 1
@@ -805,102 +1021,156 @@ func (d Decimal) MustDivIntAdd(o uint) []Decimal {
 // Scale 0 - 1 = -1 = Multiply by 10^1
 // = 1 * 10^18
 // = overflow
-func (d Decimal) Div(o Decimal) (Decimal, error) {
-	// Check if d and o are positive (>= 0)
-	// 	fmt.Printf("%s / %s\n", d, o)
-	dpos, opos := d.value >= 0, o.value >= 0
+func (d Decimal) Div(o Decimal, mode ...RoundingMode) (Decimal, error) {
+	if o.value == 0 {
+		return Decimal{}, fmt.Errorf(errDecimalDivisionByZeroMsg, d)
+	}
 
-	// Make both values positive, for simplicity
-	dval, oval := d.value, o.value
-	if !dpos {
-		dval = -dval
+	// Make both values positive, for simplicity.
+	// The remainder is always < divisor <= 18 9s, so that remainder * 10 < 2^64, and never overflows as a uint64.
+	var (
+		neg        = (d.value < 0) != (o.value < 0)
+		dval, oval = uint64(d.value), uint64(o.value)
+	)
+	if d.value < 0 {
+		dval = uint64(-d.value)
 	}
-	if !opos {
-		oval = -oval
+	if o.value < 0 {
+		oval = uint64(-o.value)
 	}
 
 	// Start with plain old division
-	q := dval / oval
-	r := dval % oval
-
-	// Scale is dividend - divisor, could be negative
-	s := int(d.scale - o.scale)
-
-	// If scale is negative, multiply q,r by 10^(-scale), set scale = 0
-	// If the multiplications go beyond the limit of digits, we have to error out
-	for s < 0 {
-		q *= 10
-		r *= 10
-		s += 1
-
-		if q > decimalMaxValue {
-			// Return over/underflow
-			return Decimal{}, fmt.Errorf(funcs.Ternary(dpos == opos, errDecimalOverflowMsg, errDecimalUnderflowMsg), d, "/", o)
+	var (
+		q = dval / oval
+		r = dval % oval
+
+		// Scale is dividend - divisor, could be negative
+		s = int(d.scale) - int(o.scale)
+	)
+
+	// Generate digits until the remainder is zero, or there are no more digits, where a negative scale requires
+	// generating digits until the scale is zero.
+	for (r != 0) || (s < 0) {
+		digit := (r * 10) / oval
+
+		// If the quotient or scale cannot hold another digit, then the remainder is used to round the existing quotient
+		if nq := q*10 + digit; (nq > uint64(decimalMaxValue)) || (s == decimalMaxScale) {
+			if s < 0 {
+				return Decimal{}, fmt.Errorf(funcs.Ternary(neg, errDecimalUnderflowMsg, errDecimalOverflowMsg), d, "/", o)
+			}
+
+			break
+		} else {
+			q, r, s = nq, (r*10)%oval, s+1
 		}
 	}
 
-	// If r != 0, perform successive multiply/divides until r = 0
-main_loop:
-	for r != 0 {
-		// Multiply q,r by 10 until r >= o
-		for r < oval {
-			nq, nr, ns := q*10, r*10, s+1
-			// fmt.Printf("nq = %d, nr = %d, ns = %d\n", nq, nr, ns)
-
-			// If quotient exceeds max value, fall back on previous quotient, as most accurate result we can get
-			if nq > decimalMaxValue {
-				// fmt.Println("ran out of digits")
-
-				// Get next generated digit, so we know if we should round up the existing quotient
-				// Remainder may be < oval, multiply by 10 until it isn't
-				for nr < oval {
-					nr *= 10
-				}
-				nr /= oval
-				// fmt.Printf("next digit remainder = %d\n", nr)
-
-				// New remainder may be > 10
-				// If so, divide by 10 until remainder < 10, to get most significant digit of it
-				for nr > 10 {
-					nr /= 10
-				}
-				// fmt.Printf("next digit = %d\n", nr)
-
-				// Is next digit >= 5?
-				if nr >= 5 {
-					// fmt.Printf("Rounding up")
-					q++
-				}
-
-				break main_loop
+	// Round the fraction r / oval, where rounding up 18 9s requires removing a zero digit
+	if (r != 0) && roundAway(funcs.SliceIndex(mode, 0), neg, (q&1) == 1, CmpOrdered(2*r, oval)) {
+		if q++; q > uint64(decimalMaxValue) {
+			if s == 0 {
+				return Decimal{}, fmt.Errorf(funcs.Ternary(neg, errDecimalUnderflowMsg, errDecimalOverflowMsg), d, "/", o)
 			}
 
-			// If remainder exceeds max value, over/underflow occurs
-			if nr > decimalMaxValue {
-				// fmt.Println("exceeded max")
-				return Decimal{}, fmt.Errorf(funcs.Ternary(dpos == opos, errDecimalOverflowMsg, errDecimalUnderflowMsg), d, "/", o)
-			}
+			q /= 10
+			s--
+		}
+	}
 
-			// Copy new quotient, remainder, scale into current
-			q = nq
-			r = nr
-			s = ns
+	res := Decimal{value: int64(q), scale: uint(s), denormalized: d.denormalized}
+	if neg {
+		res.value = -res.value
+	}
+
+	return res, nil
+}
+
+// MustDiv is a must version of Div
+func (d Decimal) MustDiv(o Decimal, mode ...RoundingMode) Decimal {
+	return funcs.MustValue(d.Div(o, mode...))
+}
+
+// sumDecimal is common code for MeanDecimal and MeanDecimalSpread, returning the sum of the values.
+// Returns an error if there are no values, or the sum overflows or underflows.
+func sumDecimal(values []Decimal) (sum Decimal, err error) {
+	if len(values) == 0 {
+		return Decimal{}, fmt.Errorf(errDecimalMeanNoValuesMsg)
+	}
+
+	sum = values[0]
+	for _, value := range values[1:] {
+		if sum, err = sum.Add(value); err != nil {
+			return Decimal{}, err
 		}
+	}
 
-		// Add r / oval to q, set r to r mod oval
-		q, r = q+r/oval, r%oval
+	return
+}
+
+// MeanDecimal returns (mean, remainder, error) of the values, where the mean * len(values) + remainder = the sum of
+// the values. The mean and remainder have the scale of the sum, so nothing is lost other than what is in the remainder.
+// EG, the mean of [10.00, 20.00, 70.00] is 33.33 remainder 0.01.
+//
+// Unlike DivIntQuoRem, the number of values can be larger than the sum, to allow for the mean of small values, in which
+// case the mean may be zero. Denormalized values should be used if the scale of the mean matters, since the sum of the
+// normalized values 1.00 and 2.00 is 3, and the mean is 1 remainder 1.
+//
+// Returns an error if there are no values, or the sum overflows or underflows.
+func MeanDecimal(values []Decimal) (mean, remainder Decimal, err error) {
+	var sum Decimal
+	if sum, err = sumDecimal(values); err != nil {
+		return
+	}
+
+	n := int64(len(values))
+	mean = Decimal{scale: sum.scale, value: sum.value / n, denormalized: sum.denormalized}
+	remainder = Decimal{scale: sum.scale, value: sum.value % n, denormalized: sum.denormalized}
+	mean.applyNormalization()
+	remainder.applyNormalization()
+
+	return
+}
+
+// MustMeanDecimal is a must version of MeanDecimal
+func MustMeanDecimal(values []Decimal) (Decimal, Decimal) {
+	return funcs.MustValue2(MeanDecimal(values))
+}
+
+// MeanDecimalSpread is like MeanDecimal, except that like DivIntAdd it returns len(values) results that add up to the
+// sum of the values, where the remainder is spread across the first results, in the direction of the sum.
+// EG, the spread of [10.00, 20.00, 70.00] is [33.34, 33.33, 33.33], and of [-10.00, -20.00, -70.00] is
+// [-33.34, -33.33, -33.33].
+//
+// Returns an error if there are no values, or the sum overflows or underflows.
+func MeanDecimalSpread(values []Decimal) ([]Decimal, error) {
+	sum, err := sumDecimal(values)
+	if err != nil {
+		return nil, err
 	}
 
-	// If original signs differed, then result is negative
-	if dpos != opos {
-		q = -q
+	// The remainder has the same sign as the sum, and is a count of how many results need to be adjusted by 1
+	var (
+		n    = int64(len(values))
+		q    = sum.value / n
+		rc   = sum.value % n
+		step = int64(funcs.Ternary(rc < 0, -1, 1))
+		res  = make([]Decimal, n)
+	)
+
+	for i := range res {
+		res[i] = Decimal{scale: sum.scale, value: q, denormalized: sum.denormalized}
+		if rc != 0 {
+			res[i].value += step
+			rc -= step
+		}
+
+		res[i].applyNormalization()
 	}
 
-	// Return result
-	return Decimal{value: q, scale: uint(s), denormalized: d.denormalized}, nil
+	return res, nil
 }
 
-// MustDiv is a must version of Div
-func (d Decimal) MustDiv(o Decimal) Decimal {
-	return funcs.MustValue(d.Div(o))
+// MustMeanDecimalSpread is a must version of MeanDecimalSpread
+func MustMeanDecimalSpread(values []Decimal) []Decimal {
+	return funcs.MustValue(MeanDecimalSpread(values))
 }
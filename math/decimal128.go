@@ -0,0 +1,171 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/bantling/micro/funcs"
+)
+
+const (
+	// decimal128ExponentBias is the bias of the 14 bit decimal128 exponent, so that exponents range from -6176 to 6111
+	decimal128ExponentBias = 6176
+
+	// decimal128ExponentMask is the mask of the 14 exponent bits, after shifting out the coefficient bits
+	decimal128ExponentMask = 0x3fff
+
+	// decimal128CoefficientBits is the number of coefficient bits in the high 64 bits of the usual encoding
+	decimal128CoefficientBits = 49
+
+	// decimal128Bytes is the number of bytes of an encoded decimal128
+	decimal128Bytes = 16
+
+	// errDecimal128ConvertMsg is the error message for a decimal128 that cannot be converted to a Decimal
+	errDecimal128ConvertMsg = "The decimal128 value %s cannot be converted to a Decimal"
+
+	// errDecimal128LengthMsg is the error message for decoding a decimal128 from the wrong number of bytes
+	errDecimal128LengthMsg = "A decimal128 value must be 16 bytes, not %d"
+)
+
+var (
+	// decimal128MaxCoefficient is the largest canonical coefficient, 34 nines
+	decimal128MaxCoefficient = new(big.Int).Sub(bigPowerOf10(34), big.NewInt(1))
+
+	// bigDecimalMaxValue is decimalMaxValue as a *big.Int
+	bigDecimalMaxValue = big.NewInt(decimalMaxValue)
+)
+
+// EncodeDecimal128 encodes a Decimal as an IEEE 754-2008 decimal128, using the binary integer decimal (BID) encoding
+// used by systems such as MongoDB. The result is the high and low 64 bits.
+//
+// The encoding is always exact, as the value becomes the coefficient and the negative scale becomes the exponent, so
+// that 1.50 encodes as 150E-2 and decodes as 1.50.
+func EncodeDecimal128(d Decimal) (hi, lo uint64) {
+	if d.value < 0 {
+		hi, lo = 1<<63, uint64(-d.value)
+	} else {
+		lo = uint64(d.value)
+	}
+
+	hi |= uint64(decimal128ExponentBias-int(d.scale)) << decimal128CoefficientBits
+	return
+}
+
+// DecodeDecimal128 decodes the high and low 64 bits of an IEEE 754-2008 BID decimal128 into a Decimal.
+//
+// The Decimal has the same scale as the decimal128 has digits after the decimal point, except that trailing zeros are
+// removed as needed to fit the Decimal limits of 18 digits and a scale <= 18.
+// A positive exponent is multiplied out, so that 15E+1 decodes as 150.
+// -0 decodes as 0, and a non-canonical coefficient decodes as 0 as the standard requires.
+//
+// Returns an error if the decimal128 is NaN, infinite, or cannot be represented in 18 digits.
+func DecodeDecimal128(hi, lo uint64) (Decimal, error) {
+	var (
+		neg      = (hi >> 63) != 0
+		exponent int
+		coef     = new(big.Int)
+	)
+
+	switch {
+	case (hi>>58)&0x1f == 0x1f:
+		return Decimal{}, fmt.Errorf(errDecimal128ConvertMsg, "NaN")
+
+	case (hi>>58)&0x1f == 0x1e:
+		return Decimal{}, fmt.Errorf(errDecimal128ConvertMsg, funcs.Ternary(neg, "-Infinity", "Infinity"))
+
+	case (hi>>61)&0x3 == 0x3:
+		// The exponent is shifted 2 bits right, and the coefficient has an implied 100 prefix that makes it exceed
+		// 34 digits, so it is non-canonical and therefore zero
+		exponent = int((hi>>(decimal128CoefficientBits-2))&decimal128ExponentMask) - decimal128ExponentBias
+
+	default:
+		exponent = int((hi>>decimal128CoefficientBits)&decimal128ExponentMask) - decimal128ExponentBias
+		coef.SetUint64(hi & (1<<decimal128CoefficientBits - 1))
+		coef.Lsh(coef, 64)
+		coef.Or(coef, new(big.Int).SetUint64(lo))
+
+		if coef.Cmp(decimal128MaxCoefficient) > 0 {
+			coef.SetInt64(0)
+		}
+	}
+
+	// Zero has no sign, and any exponent
+	if coef.Sign() == 0 {
+		if exponent >= 0 {
+			return Decimal{}, nil
+		}
+
+		return Decimal{scale: uint(MinOrdered(-exponent, decimalMaxScale))}, nil
+	}
+
+	errFn := func() error {
+		return fmt.Errorf(errDecimal128ConvertMsg, fmt.Sprintf("%s%sE%+d", funcs.Ternary(neg, "-", ""), coef, exponent))
+	}
+
+	var (
+		scaled = new(big.Int).Set(coef)
+		scale  uint
+	)
+
+	if exponent > 0 {
+		if exponent > decimalMaxScale {
+			return Decimal{}, errFn()
+		}
+
+		scaled.Mul(scaled, bigPowerOf10(uint(exponent)))
+	} else {
+		// Remove trailing zeros until the scale and coefficient are small enough
+		q, r := new(big.Int), new(big.Int)
+
+		for scale = uint(-exponent); (scale > decimalMaxScale) || (scaled.Cmp(bigDecimalMaxValue) > 0); scale-- {
+			if q.QuoRem(scaled, bigTen, r); (scale == 0) || (r.Sign() != 0) {
+				return Decimal{}, errFn()
+			}
+
+			scaled.Set(q)
+		}
+	}
+
+	if scaled.Cmp(bigDecimalMaxValue) > 0 {
+		return Decimal{}, errFn()
+	}
+
+	value := scaled.Int64()
+	return Decimal{value: funcs.Ternary(neg, -value, value), scale: scale}, nil
+}
+
+// MustDecodeDecimal128 is a must version of DecodeDecimal128
+func MustDecodeDecimal128(hi, lo uint64) Decimal {
+	return funcs.MustValue(DecodeDecimal128(hi, lo))
+}
+
+// EncodeDecimal128Bytes encodes a Decimal as the 16 bytes of an IEEE 754-2008 BID decimal128 (see EncodeDecimal128).
+// The bytes are little endian, the order used by BSON and MongoDB.
+func EncodeDecimal128Bytes(d Decimal) []byte {
+	var (
+		hi, lo = EncodeDecimal128(d)
+		b      = make([]byte, decimal128Bytes)
+	)
+
+	binary.LittleEndian.PutUint64(b, lo)
+	binary.LittleEndian.PutUint64(b[8:], hi)
+	return b
+}
+
+// DecodeDecimal128Bytes decodes the 16 little endian bytes of an IEEE 754-2008 BID decimal128 (see DecodeDecimal128).
+// Returns an error if there are not exactly 16 bytes.
+func DecodeDecimal128Bytes(b []byte) (Decimal, error) {
+	if len(b) != decimal128Bytes {
+		return Decimal{}, fmt.Errorf(errDecimal128LengthMsg, len(b))
+	}
+
+	return DecodeDecimal128(binary.LittleEndian.Uint64(b[8:]), binary.LittleEndian.Uint64(b))
+}
+
+// MustDecodeDecimal128Bytes is a must version of DecodeDecimal128Bytes
+func MustDecodeDecimal128Bytes(b []byte) Decimal {
+	return funcs.MustValue(DecodeDecimal128Bytes(b))
+}
@@ -0,0 +1,128 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/tuple"
+	"github.com/stretchr/testify/assert"
+)
+
+// decimal128Of returns the high and low bits of a decimal128 with the given sign, coefficient, and exponent
+func decimal128Of(neg bool, coef *big.Int, exponent int) (hi, lo uint64) {
+	hi = new(big.Int).Rsh(coef, 64).Uint64() | uint64(exponent+decimal128ExponentBias)<<decimal128CoefficientBits
+	lo = new(big.Int).And(coef, new(big.Int).SetUint64(1<<64-1)).Uint64()
+	if neg {
+		hi |= 1 << 63
+	}
+
+	return
+}
+
+func TestEncodeDecimal128_(t *testing.T) {
+	assert.Equal(t, tuple.Of2(uint64(0x3040000000000000), uint64(0)), tuple.Of2(EncodeDecimal128(Decimal{})))
+	assert.Equal(t, tuple.Of2(uint64(0x3040000000000000), uint64(1)), tuple.Of2(EncodeDecimal128(MustDecimal(1, 0))))
+	assert.Equal(t, tuple.Of2(uint64(0xb03e000000000000), uint64(15)), tuple.Of2(EncodeDecimal128(MustDecimal(-15, 1))))
+	assert.Equal(t, tuple.Of2(uint64(0x303c000000000000), uint64(150)), tuple.Of2(EncodeDecimal128(MustDecimal(150, 2, false))))
+	assert.Equal(
+		t,
+		tuple.Of2(uint64(0xb01c000000000000), uint64(decimalMaxValue)),
+		tuple.Of2(EncodeDecimal128(MustDecimal(decimalMinValue, 18))),
+	)
+
+	assert.Equal(
+		t,
+		[]byte{0x0f, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x3e, 0xb0},
+		EncodeDecimal128Bytes(MustDecimal(-15, 1)),
+	)
+}
+
+func TestDecodeDecimal128_(t *testing.T) {
+	// Round trips
+	for _, d := range []Decimal{
+		{},
+		MustDecimal(1, 0),
+		MustDecimal(-15, 1),
+		MustDecimal(150, 2, false),
+		MustDecimal(decimalMaxValue, 0),
+		MustDecimal(decimalMinValue, 18),
+		MustDecimal(1, 18),
+	} {
+		assert.Equal(t, tuple.Of2(d.value, d.scale), tuple.Of2(MustDecodeDecimal128(EncodeDecimal128(d)).value, MustDecodeDecimal128(EncodeDecimal128(d)).scale))
+		assert.Equal(t, d.String(), MustDecodeDecimal128Bytes(EncodeDecimal128Bytes(d)).String())
+	}
+
+	// Positive exponent
+	assert.Equal(t, "150", MustDecodeDecimal128(decimal128Of(false, big.NewInt(15), 1)).String())
+	assert.Equal(t, "-100000000000000000", MustDecodeDecimal128(decimal128Of(true, big.NewInt(1), 17)).String())
+
+	// Trailing zeros are removed to fit
+	assert.Equal(t, "1.00000000000000000", MustDecodeDecimal128(decimal128Of(false, bigPowerOf10(30), -30)).String())
+	assert.Equal(t, "0.000000000000000001", MustDecodeDecimal128(decimal128Of(false, bigPowerOf10(10), -28)).String())
+
+	// Trailing zeros are otherwise kept
+	assert.Equal(t, "-1.500", MustDecodeDecimal128(decimal128Of(true, big.NewInt(1500), -3)).String())
+
+	// Zeros
+	assert.Equal(t, Decimal{}, MustDecodeDecimal128(decimal128Of(true, big.NewInt(0), 0)))
+	assert.Equal(t, Decimal{}, MustDecodeDecimal128(decimal128Of(false, big.NewInt(0), 6111)))
+	assert.Equal(t, Decimal{scale: 2}, MustDecodeDecimal128(decimal128Of(false, big.NewInt(0), -2)))
+	assert.Equal(t, Decimal{scale: 18}, MustDecodeDecimal128(decimal128Of(false, big.NewInt(0), -6176)))
+
+	// Non-canonical coefficients are zero
+	assert.Equal(t, Decimal{}, MustDecodeDecimal128(0x6c10000000000000, 0))
+	assert.Equal(t, Decimal{}, MustDecodeDecimal128(decimal128Of(false, bigPowerOf10(34), 0)))
+
+	// Not representable
+	for _, tst := range []struct {
+		str    string
+		hi, lo uint64
+	}{
+		{"NaN", 0x7c00000000000000, 0},
+		{"NaN", 0xfe00000000000000, 0},
+		{"Infinity", 0x7800000000000000, 0},
+		{"-Infinity", 0xf800000000000000, 0},
+	} {
+		assert.Equal(t, tuple.Of2(Decimal{}, fmt.Errorf(errDecimal128ConvertMsg, tst.str)), tuple.Of2(DecodeDecimal128(tst.hi, tst.lo)))
+	}
+
+	for _, tst := range []struct {
+		str      string
+		neg      bool
+		coef     *big.Int
+		exponent int
+	}{
+		{"1E+19", false, big.NewInt(1), 19},
+		{"-1E+18", true, big.NewInt(1), 18},
+		{"1E-19", false, big.NewInt(1), -19},
+		{"1000000000000000000E+0", false, bigPowerOf10(18), 0},
+		{"-12345678901234567891E-1", true, big.NewInt(0).SetUint64(12345678901234567891), -1},
+	} {
+		hi, lo := decimal128Of(tst.neg, tst.coef, tst.exponent)
+		assert.Equal(t, tuple.Of2(Decimal{}, fmt.Errorf(errDecimal128ConvertMsg, tst.str)), tuple.Of2(DecodeDecimal128(hi, lo)))
+	}
+
+	// Bytes
+	assert.Equal(t, MustDecimal(-15, 1), MustDecodeDecimal128Bytes([]byte{0x0f, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x3e, 0xb0}))
+	assert.Equal(t, tuple.Of2(Decimal{}, fmt.Errorf(errDecimal128LengthMsg, 15)), tuple.Of2(DecodeDecimal128Bytes(make([]byte, 15))))
+
+	funcs.TryTo(
+		func() {
+			MustDecodeDecimal128(0x7c00000000000000, 0)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf(errDecimal128ConvertMsg, "NaN"), e) },
+	)
+
+	funcs.TryTo(
+		func() {
+			MustDecodeDecimal128Bytes(nil)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf(errDecimal128LengthMsg, 0), e) },
+	)
+}
@@ -4,8 +4,10 @@ package math
 
 import (
 	"fmt"
+	"math/big"
 	"testing"
 
+	"github.com/bantling/micro/funcs"
 	"github.com/bantling/micro/tuple"
 	"github.com/bantling/micro/union"
 	"github.com/stretchr/testify/assert"
@@ -55,6 +57,59 @@ func TestStringToDecimal_(t *testing.T) {
 		tuple.Of2(Decimal{}, fmt.Errorf("The string value -1234567890123456789 is not a valid decimal string")),
 		tuple.Of2(StringToDecimal("-1234567890123456789")),
 	)
+
+	// Digits on either side of the decimal point are optional, but there must be at least one
+	assert.Equal(t, Decimal{value: 5, scale: 1}, MustStringToDecimal(".5"))
+	assert.Equal(t, Decimal{value: -5}, MustStringToDecimal("-5."))
+	assert.Equal(t, Decimal{value: 123_456_789_012_345_678, scale: 18}, MustStringToDecimal(".123456789012345678"))
+
+	// The whole string must be valid
+	for _, str := range []string{"", "-", ".", "-.", "1x", "1.2.3", "--1", " 1", "1 ", "+1", "1-"} {
+		assert.Equal(t, tuple.Of2(Decimal{}, fmt.Errorf(errInvalidStringMsg, str)), tuple.Of2(StringToDecimal(str)), str)
+	}
+}
+
+func TestStringToDecimalExponent_(t *testing.T) {
+	for str, expected := range map[string]Decimal{
+		"1.5e-3":       {value: 15, scale: 4},
+		"1.5E3":        {value: 1500},
+		"-2.5e+2":      {value: -250},
+		"15e-1":        {value: 15, scale: 1},
+		"1.50e-17":     {value: 15, scale: 18},
+		"1e-18":        {value: 1, scale: 18},
+		"1e17":         {value: 100_000_000_000_000_000},
+		"0.5e0":        {value: 5, scale: 1},
+		"0e-30":        {scale: 18},
+		"0.0e99999":    {},
+		"1.25e1":       {value: 12_5, scale: 1},
+		"999999999e9":  {value: 999_999_999_000_000_000},
+		"-999999999e9": {value: -999_999_999_000_000_000},
+	} {
+		assert.Equal(t, tuple.Of2(expected, error(nil)), tuple.Of2(StringToDecimal(str)), str)
+	}
+
+	for _, str := range []string{"1e", "e5", "1e1.5", "1e0x10", "1e1_0", "1e18", "1e-19", "1.5e-18", "-1e18", "1e99999999999", "x.5e1"} {
+		assert.Equal(t, tuple.Of2(Decimal{}, fmt.Errorf(errInvalidStringMsg, str)), tuple.Of2(StringToDecimal(str)), str)
+	}
+}
+
+func TestDecimalSciString_(t *testing.T) {
+	for expected, d := range map[string]Decimal{
+		"1.5e-3":                  MustDecimal(15, 4),
+		"1.5e3":                   MustDecimal(1500, 0),
+		"-1.25e1":                 MustDecimal(-12_50, 2, false),
+		"0e0":                     MustDecimal(0, 5, false),
+		"7e0":                     MustDecimal(7, 0),
+		"1e-18":                   MustDecimal(1, 18),
+		"9.9999999999999999e17":   MustDecimal(999_999_999_999_999_990, 0),
+		"-9.99999999999999999e-1": MustDecimal(-999_999_999_999_999_999, 18),
+	} {
+		assert.Equal(t, expected, d.SciString())
+
+		// Round trip
+		r := MustStringToDecimal(expected)
+		assert.Equal(t, 0, d.Cmp(r), expected)
+	}
 }
 
 func TestDecimalString_(t *testing.T) {
@@ -66,6 +121,69 @@ func TestDecimalString_(t *testing.T) {
 	assert.Equal(t, "0.0123", MustDecimal(123, 4).String())
 	assert.Equal(t, "0.00123", MustDecimal(123, 5).String())
 	assert.Equal(t, "-0.00123", MustDecimal(-123, 5).String())
+
+	// Zeros, and the longest strings
+	assert.Equal(t, "0", Decimal{}.String())
+	assert.Equal(t, "0.00", MustDecimal(0, 2, false).String())
+	assert.Equal(t, "100", MustDecimal(100, 0).String())
+	assert.Equal(t, "-0.000000000000000001", MustDecimal(-1, 18).String())
+	assert.Equal(t, "-0.999999999999999999", MustDecimal(decimalMinValue, 18).String())
+	assert.Equal(t, "-999999999999999999", MustDecimal(decimalMinValue, 0).String())
+
+	// Round trip
+	for _, str := range decimalBenchStrings {
+		assert.Equal(t, str, MustStringToDecimal(str).String())
+	}
+}
+
+func TestDecimalFormat_(t *testing.T) {
+	// Zero value is the same as String
+	for _, str := range []string{"0", "1", "-1", "1.25", "-0.05", "123456789.123456789"} {
+		assert.Equal(t, str, MustStringToDecimal(str).Format(DecimalFormat{}))
+	}
+
+	// Thousands separator
+	ts := DecimalFormat{ThousandsSeparator: ","}
+	assert.Equal(t, "0", MustStringToDecimal("0").Format(ts))
+	assert.Equal(t, "123", MustStringToDecimal("123").Format(ts))
+	assert.Equal(t, "1,234", MustStringToDecimal("1234").Format(ts))
+	assert.Equal(t, "-12,345.678", MustStringToDecimal("-12345.678").Format(ts))
+	assert.Equal(t, "123,456,789.123", MustStringToDecimal("123456789.123").Format(ts))
+
+	// Decimal point
+	assert.Equal(t, "1.234,5", MustStringToDecimal("1234.5").Format(DecimalFormat{ThousandsSeparator: ".", DecimalPoint: ","}))
+
+	// Plus sign
+	assert.Equal(t, "+1.5", MustStringToDecimal("1.5").Format(DecimalFormat{Plus: true}))
+	assert.Equal(t, "+0", MustStringToDecimal("0").Format(DecimalFormat{Plus: true}))
+	assert.Equal(t, "-1.5", MustStringToDecimal("-1.5").Format(DecimalFormat{Plus: true}))
+
+	// Trailing zeros
+	dn := MustDecimal(1_500, 3, false)
+	assert.Equal(t, "1.500", dn.Format(DecimalFormat{}))
+	assert.Equal(t, "1.5", dn.Format(DecimalFormat{TrimZeros: true}))
+	assert.Equal(t, "1.50", dn.Format(DecimalFormat{TrimZeros: true, MinScale: 2}))
+	assert.Equal(t, "1.500", dn.Format(DecimalFormat{MinScale: 2}))
+	assert.Equal(t, "2", MustDecimal(2_000, 3, false).Format(DecimalFormat{TrimZeros: true}))
+	assert.Equal(t, "2.00", MustStringToDecimal("2").Format(DecimalFormat{MinScale: 2}))
+	assert.Equal(t, "-0.10", MustStringToDecimal("-0.1").Format(DecimalFormat{MinScale: 2}))
+
+	// Width
+	assert.Equal(t, "   1.5", MustStringToDecimal("1.5").Format(DecimalFormat{Width: 6}))
+	assert.Equal(t, "  -1.5", MustStringToDecimal("-1.5").Format(DecimalFormat{Width: 6}))
+	assert.Equal(t, "-001.5", MustStringToDecimal("-1.5").Format(DecimalFormat{Width: 6, ZeroPad: true}))
+	assert.Equal(t, "+001.5", MustStringToDecimal("1.5").Format(DecimalFormat{Width: 6, ZeroPad: true, Plus: true}))
+	assert.Equal(t, "1234.5", MustStringToDecimal("1234.5").Format(DecimalFormat{Width: 3}))
+
+	// Width counts characters, not bytes
+	assert.Equal(t, " 1\u00a0234", MustStringToDecimal("1234").Format(DecimalFormat{ThousandsSeparator: "\u00a0", Width: 6}))
+
+	// All together
+	assert.Equal(
+		t,
+		"   +1,234,567.80",
+		MustStringToDecimal("1234567.8").Format(DecimalFormat{ThousandsSeparator: ",", Plus: true, MinScale: 2, Width: 16}),
+	)
 }
 
 func TestDecimalPrecision_(t *testing.T) {
@@ -447,10 +565,43 @@ func TestDecimalMul_(t *testing.T) {
 	d1, d2 = MustDecimal(1, 0), MustDecimal(0, 0)
 	assert.Equal(t, MustDecimal(0, 0), d1.MustMul(d2))
 
+	// 19 digits are rounded to 18 by reducing the scale
+	d1, d2 = MustDecimal(999_999_999_999_999_999, 0), MustDecimal(2, 1)
+	assert.Equal(t, tuple.Of2(MustDecimal(200_000_000_000_000_000, 0), error(nil)), tuple.Of2(d1.Mul(d2)))
+
+	d1, d2 = MustDecimal(-999_999_999_999_999_999, 0), MustDecimal(2, 1)
+	assert.Equal(t, tuple.Of2(MustDecimal(-200_000_000_000_000_000, 0), error(nil)), tuple.Of2(d1.Mul(d2)))
+
+	// 123_456_789.123_456_789 * 10.5 = 1_296_296_285.796_296_284_5, which has 20 digits
+	d1, d2 = MustDecimal(123_456_789_123_456_789, 9), MustDecimal(10_5, 1)
+	assert.Equal(t, MustDecimal(1_296_296_285_796_296_28, 8), d1.MustMul(d2))
+
+	// 0.000_000_001 * 0.000_000_000_5 = 0.000_000_000_000_000_000_5, which has scale 19
+	d1, d2 = MustDecimal(1, 9), MustDecimal(5, 10)
+	assert.Equal(t, MustDecimal(1, 18), d1.MustMul(d2))
+
+	// 0.000_000_001 * 0.000_000_000_4 rounds down to zero
+	d1, d2 = MustDecimal(1, 9), MustDecimal(4, 10)
+	assert.Equal(t, Decimal{scale: 18}, d1.MustMul(d2))
+
+	// 36 digits with scale 36: 0.999_999_999_999_999_999 * 0.999_999_999_999_999_999 = 0.999_999_999_999_999_998_000...001
+	d1 = MustDecimal(999_999_999_999_999_999, 18)
+	assert.Equal(t, MustDecimal(999_999_999_999_999_998, 18), d1.MustMul(d1))
+
+	// Rounding 18 9s up to 19 digits removes a trailing zero
+	// 8_333_333_333_333_333.33 * 12 = 99_999_999_999_999_999.96 -> 100_000_000_000_000_000.0 -> 100_000_000_000_000_000
+	d1, d2 = MustDecimal(833_333_333_333_333_333, 2), MustDecimal(12, 0)
+	assert.Equal(t, MustDecimal(100_000_000_000_000_000, 0), d1.MustMul(d2))
+
 	// Overflow
 	// - Within bounds of signed 64 bit int, but beyond bounds of 18 decimals
-	d1, d2 = MustDecimal(999_999_999_999_999_999, 0), MustDecimal(2, 1)
-	assert.Equal(t, tuple.Of2(Decimal{}, fmt.Errorf("The decimal calculation 999999999999999999 * 0.2 overflowed")), tuple.Of2(d1.Mul(d2)))
+	d1, d2 = MustDecimal(999_999_999_999_999_999, 0), MustDecimal(2, 0)
+	assert.Equal(t, tuple.Of2(Decimal{}, fmt.Errorf("The decimal calculation 999999999999999999 * 2 overflowed")), tuple.Of2(d1.Mul(d2)))
+
+	// - Rounding 18 9s up needs a 19th integer digit
+	// 83_333_333_333_333_333.3 * 12 = 999_999_999_999_999_999.6
+	d1, d2 = MustDecimal(833_333_333_333_333_333, 1), MustDecimal(12, 0)
+	assert.Equal(t, tuple.Of2(Decimal{}, fmt.Errorf("The decimal calculation 83333333333333333.3 * 12 overflowed")), tuple.Of2(d1.Mul(d2)))
 
 	// - Beyond bounds of signed 64 bit int, but only a little
 	d1, d2 = MustDecimal(999_999_999_999_999_999, 0), MustDecimal(16, 0)
@@ -462,8 +613,8 @@ func TestDecimalMul_(t *testing.T) {
 
 	// Underflow
 	// - Within bounds of signed 64 bit int, but beyond bounds of 18 decimals
-	d1, d2 = MustDecimal(-999_999_999_999_999_999, 0), MustDecimal(2, 1)
-	assert.Equal(t, tuple.Of2(Decimal{}, fmt.Errorf("The decimal calculation -999999999999999999 * 0.2 underflowed")), tuple.Of2(d1.Mul(d2)))
+	d1, d2 = MustDecimal(-999_999_999_999_999_999, 0), MustDecimal(2, 0)
+	assert.Equal(t, tuple.Of2(Decimal{}, fmt.Errorf("The decimal calculation -999999999999999999 * 2 underflowed")), tuple.Of2(d1.Mul(d2)))
 
 	// - Beyond bounds of signed 64 bit int, but only a little
 	d1, d2 = MustDecimal(999_999_999_999_999_999, 0), MustDecimal(-16, 0)
@@ -583,11 +734,194 @@ func TestDecimalDiv_(t *testing.T) {
 	de, dv = MustDecimal(1, 0), MustDecimal(100_000_000_000_000_000, 0)
 	assert.Equal(t, MustDecimal(1, 17), de.MustDiv(dv))
 
-	// 17. 1 / 200_000_000_000_000_000 = overflow
+	// 17. 1 / 200_000_000_000_000_000 = 0.000_000_000_000_000_005
 	de, dv = MustDecimal(1, 0), MustDecimal(200_000_000_000_000_000, 0)
-	assert.Equal(t, union.OfError[Decimal](fmt.Errorf(errDecimalOverflowMsg, de, "/", dv)), union.OfResultError(de.Div(dv)))
+	assert.Equal(t, MustDecimal(5, 18), de.MustDiv(dv))
 
 	// 18. 100_000_000_000_000_000 / 0.1 = overflow
 	de, dv = MustDecimal(100_000_000_000_000_000, 0), MustDecimal(1, 1)
 	assert.Equal(t, union.OfError[Decimal](fmt.Errorf(errDecimalOverflowMsg, de, "/", dv)), union.OfResultError(de.Div(dv)))
 }
+
+func TestDecimalMulDivBoundaries_(t *testing.T) {
+	// Compare every pair of boundary values to the exact result rounded with each mode to the scale of the result, where
+	// the scale is as large as possible
+	var values []Decimal
+	for _, v := range []int64{
+		0, 1, 5, 9, 10, 99, 1_000_000_000, 314_159_265_358_979_323, 123_456_789_012_345_678, 100_000_000_000_000_000,
+		500_000_000_000_000_000, 999_999_999_999_999_995, 999_999_999_999_999_999,
+	} {
+		for _, s := range []uint{0, 1, 9, 17, 18} {
+			values = append(values, Decimal{value: v, scale: s}, Decimal{value: -v, scale: s})
+		}
+	}
+
+	check := func(op string, mode RoundingMode, d, o, r Decimal, err error, num, den *big.Int, scale uint) {
+		if den.Sign() < 0 {
+			num, den = new(big.Int).Neg(num), new(big.Int).Neg(den)
+		}
+
+		if err != nil {
+			_, ok := roundQuo(num, den, 0, mode, true)
+			assert.False(t, ok, "%s %s %s mode %d", d, op, o, mode)
+			assert.Equal(t, fmt.Errorf(funcs.Ternary(num.Sign() < 0, errDecimalUnderflowMsg, errDecimalOverflowMsg), d, op, o), err)
+			return
+		}
+
+		expected, ok := roundQuo(num, den, r.scale, mode, true)
+		assert.True(t, ok, "%s %s %s mode %d", d, op, o, mode)
+		assert.Equal(t, expected.value, r.value, "%s %s %s mode %d", d, op, o, mode)
+
+		// A larger scale must not be possible, unless the result is exact
+		if (r.scale < scale) && (r.scale < decimalMaxScale) {
+			var (
+				scaled = new(big.Int).Mul(num, bigPowerOf10(r.scale))
+				exact  = new(big.Int).Rem(scaled, den).Sign() == 0
+			)
+
+			if _, ok = roundQuo(num, den, r.scale+1, mode, true); ok {
+				assert.True(t, exact, "%s %s %s mode %d", d, op, o, mode)
+			}
+		}
+	}
+
+	for _, mode := range []RoundingMode{HalfUp, HalfEven, Floor, Ceil, Truncate} {
+		for _, d := range values {
+			for _, o := range values {
+				r, err := d.Mul(o, mode)
+				check("*", mode, d, o, r, err, big.NewInt(0).Mul(big.NewInt(d.value), big.NewInt(o.value)), bigPowerOf10(d.scale+o.scale), d.scale+o.scale)
+
+				if o.value != 0 {
+					r, err = d.Div(o, mode)
+					check(
+						"/", mode, d, o, r, err,
+						new(big.Int).Mul(big.NewInt(d.value), bigPowerOf10(o.scale)),
+						new(big.Int).Mul(big.NewInt(o.value), bigPowerOf10(d.scale)),
+						decimalMaxScale,
+					)
+				}
+			}
+		}
+	}
+
+	// Division by zero
+	assert.Equal(t, union.OfError[Decimal](fmt.Errorf(errDecimalDivisionByZeroMsg, MustDecimal(1, 0))), union.OfResultError(MustDecimal(1, 0).Div(Decimal{})))
+
+	// Large divisors do not lose precision
+	// 999_999_999_999_999_998 / 999_999_999_999_999_999 = 0.999_999_999_999_999_998_999...
+	de, dv := MustDecimal(999_999_999_999_999_998, 0), MustDecimal(999_999_999_999_999_999, 0)
+	assert.Equal(t, MustDecimal(999_999_999_999_999_999, 18), de.MustDiv(dv))
+}
+
+func TestMeanDecimal_(t *testing.T) {
+	// 10.00 + 20.00 + 70.00 = 100.00 / 3 = 33.33 remainder 0.01
+	vals := []Decimal{MustDecimal(10_00, 2, false), MustDecimal(20_00, 2, false), MustDecimal(70_00, 2, false)}
+	assert.Equal(t, tuple.Of3(MustDecimal(33_33, 2, false), MustDecimal(1, 2, false), error(nil)), tuple.Of3(MeanDecimal(vals)))
+
+	// Negative sum has a negative remainder
+	vals = []Decimal{MustDecimal(-10_00, 2, false), MustDecimal(-20_00, 2, false), MustDecimal(-70_00, 2, false)}
+	assert.Equal(t, tuple.Of2(MustDecimal(-33_33, 2, false), MustDecimal(-1, 2, false)), tuple.Of2(MustMeanDecimal(vals)))
+
+	// Values with different scales
+	vals = []Decimal{MustDecimal(1_5, 1, false), MustDecimal(2_25, 2, false)}
+	assert.Equal(t, tuple.Of2(MustDecimal(1_87, 2, false), MustDecimal(1, 2, false)), tuple.Of2(MustMeanDecimal(vals)))
+
+	// More values than the sum
+	vals = []Decimal{MustDecimal(1, 2, false), MustDecimal(1, 2, false), MustDecimal(0, 2, false)}
+	assert.Equal(t, tuple.Of2(MustDecimal(0, 2, false), MustDecimal(2, 2, false)), tuple.Of2(MustMeanDecimal(vals)))
+
+	// Normalized values
+	vals = []Decimal{MustDecimal(1_00, 2), MustDecimal(2_00, 2)}
+	assert.Equal(t, tuple.Of2(MustDecimal(1, 0), MustDecimal(1, 0)), tuple.Of2(MustMeanDecimal(vals)))
+
+	vals = []Decimal{MustDecimal(1_00, 2), MustDecimal(2_20, 2)}
+	assert.Equal(t, tuple.Of2(MustDecimal(1_6, 1), MustDecimal(0, 0)), tuple.Of2(MustMeanDecimal(vals)))
+
+	// Single value
+	assert.Equal(t, tuple.Of2(MustDecimal(5, 1), MustDecimal(0, 0)), tuple.Of2(MustMeanDecimal([]Decimal{MustDecimal(5, 1)})))
+
+	// No values
+	assert.Equal(t, tuple.Of3(Decimal{}, Decimal{}, fmt.Errorf(errDecimalMeanNoValuesMsg)), tuple.Of3(MeanDecimal(nil)))
+
+	// Overflow
+	vals = []Decimal{MustDecimal(decimalMaxValue, 0), MustDecimal(1, 0)}
+	_, _, err := MeanDecimal(vals)
+	assert.NotNil(t, err)
+
+	funcs.TryTo(
+		func() {
+			MustMeanDecimal([]Decimal{})
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf(errDecimalMeanNoValuesMsg), e) },
+	)
+}
+
+func TestMeanDecimalSpread_(t *testing.T) {
+	// 10.00 + 20.00 + 70.00 = [33.34, 33.33, 33.33]
+	vals := []Decimal{MustDecimal(10_00, 2, false), MustDecimal(20_00, 2, false), MustDecimal(70_00, 2, false)}
+	assert.Equal(
+		t,
+		tuple.Of2([]Decimal{MustDecimal(33_34, 2, false), MustDecimal(33_33, 2, false), MustDecimal(33_33, 2, false)}, error(nil)),
+		tuple.Of2(MeanDecimalSpread(vals)),
+	)
+
+	// Negative sum spreads negative remainder
+	vals = []Decimal{MustDecimal(-10_00, 2, false), MustDecimal(-20_00, 2, false), MustDecimal(-70_00, 2, false)}
+	assert.Equal(
+		t,
+		[]Decimal{MustDecimal(-33_34, 2, false), MustDecimal(-33_33, 2, false), MustDecimal(-33_33, 2, false)},
+		MustMeanDecimalSpread(vals),
+	)
+
+	// More values than the sum
+	vals = []Decimal{MustDecimal(1, 2, false), MustDecimal(1, 2, false), MustDecimal(0, 2, false)}
+	assert.Equal(
+		t,
+		[]Decimal{MustDecimal(1, 2, false), MustDecimal(1, 2, false), MustDecimal(0, 2, false)},
+		MustMeanDecimalSpread(vals),
+	)
+
+	// The results always add up to the sum
+	vals = []Decimal{MustDecimal(1_01, 2, false), MustDecimal(-7, 2, false), MustDecimal(3_33, 2, false), MustDecimal(9, 2, false)}
+	var (
+		sum    = MustDecimal(0, 2, false)
+		spread = MustDecimal(0, 2, false)
+	)
+	for i, d := range MustMeanDecimalSpread(vals) {
+		sum, spread = sum.MustAdd(vals[i]), spread.MustAdd(d)
+	}
+	assert.Equal(t, sum, spread)
+
+	// No values
+	assert.Equal(t, tuple.Of2([]Decimal(nil), fmt.Errorf(errDecimalMeanNoValuesMsg)), tuple.Of2(MeanDecimalSpread(nil)))
+
+	funcs.TryTo(
+		func() {
+			MustMeanDecimalSpread(nil)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf(errDecimalMeanNoValuesMsg), e) },
+	)
+}
+
+// decimalBenchStrings are typical monetary values, as read from CSV files
+var decimalBenchStrings = []string{"12345.67", "-0.05", "1000000", "999999999999.999999", "0.000001", "-42.5"}
+
+func BenchmarkStringToDecimal_(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		StringToDecimal(decimalBenchStrings[n%len(decimalBenchStrings)])
+	}
+}
+
+func BenchmarkDecimalString_(b *testing.B) {
+	decs := make([]Decimal, len(decimalBenchStrings))
+	for i, str := range decimalBenchStrings {
+		decs[i] = MustStringToDecimal(str)
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		_ = decs[n%len(decs)].String()
+	}
+}
@@ -0,0 +1,377 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	goreflect "reflect"
+	"strconv"
+	"strings"
+
+	"github.com/bantling/micro/constraint"
+	"github.com/bantling/micro/conv"
+	"github.com/bantling/micro/funcs"
+)
+
+const (
+	// errDecimalToMsg is the error message for a Decimal that cannot be converted to another type
+	errDecimalToMsg = "The Decimal value %s cannot be converted to %s"
+
+	// errToDecimalMsg is the error message for a value of another type that cannot be converted to a Decimal
+	errToDecimalMsg = "The %s value %s cannot be converted to a Decimal"
+
+	// errDecimalScanMsg is the error message for a database value that cannot be scanned into a Decimal
+	errDecimalScanMsg = "The %T value %v cannot be scanned into a Decimal"
+)
+
+// Register conversions between Decimal and string, int64, float64, *big.Rat, and *big.Float with conv, so that the
+// conversions are available to conv.ReflectTo, conv.AnyTo, and conv.LookupConversion.
+//
+// Decimal does not satisfy the constraints of conv.To, or the output constraint of conv.AnyTo, so those functions
+// cannot convert to or from a Decimal, apart from conv.AnyTo of a Decimal source. Use DecimalTo and ToDecimal instead,
+// which convert to and from any Numeric type or string.
+//
+// A Decimal converts to a string, int64, or *big.Rat exactly, or fails if the value has a fractional part that an
+// int64 cannot hold. It converts to a float64 or *big.Float the same way as its string does, rounding to the nearest
+// float.
+//
+// A string, int64, or *big.Rat converts exactly, or fails if it requires more than 18 digits, or a *big.Rat has a
+// non-terminating decimal expansion like 1/3. A float64 or *big.Float converts as the shortest decimal string that
+// round trips to the same float, so that 0.1 converts to 0.1, failing if it is not finite or requires more than 18
+// digits.
+func init() {
+	var (
+		decTyp   = goreflect.TypeOf(Decimal{})
+		strTyp   = goreflect.TypeOf("")
+		int64Typ = goreflect.TypeOf(int64(0))
+		floatTyp = goreflect.TypeOf(float64(0))
+		ratTyp   = goreflect.TypeOf((*big.Rat)(nil))
+		bigFlTyp = goreflect.TypeOf((*big.Float)(nil))
+	)
+
+	// ==== From Decimal
+
+	conv.Register(decTyp, strTyp, func(i, o any) error {
+		*(o.(*string)) = i.(Decimal).String()
+		return nil
+	})
+
+	conv.Register(decTyp, int64Typ, func(i, o any) error {
+		return decimalToInt64(i.(Decimal), o.(*int64))
+	})
+
+	conv.Register(decTyp, floatTyp, func(i, o any) error {
+		return decimalToFloat64(i.(Decimal), o.(*float64))
+	})
+
+	conv.Register(decTyp, ratTyp, func(i, o any) error {
+		*(o.(**big.Rat)) = decimalToBigRat(i.(Decimal))
+		return nil
+	})
+
+	conv.Register(decTyp, bigFlTyp, func(i, o any) error {
+		return conv.StringToBigFloat(i.(Decimal).String(), o.(**big.Float))
+	})
+
+	// ==== To Decimal
+
+	conv.Register(strTyp, decTyp, func(i, o any) (err error) {
+		*(o.(*Decimal)), err = StringToDecimal(i.(string))
+		return
+	})
+
+	conv.Register(int64Typ, decTyp, func(i, o any) error {
+		return int64ToDecimal(i.(int64), o.(*Decimal))
+	})
+
+	conv.Register(floatTyp, decTyp, func(i, o any) error {
+		return float64ToDecimal(i.(float64), o.(*Decimal))
+	})
+
+	conv.Register(ratTyp, decTyp, func(i, o any) error {
+		return bigRatToDecimal(i.(*big.Rat), o.(*Decimal))
+	})
+
+	conv.Register(bigFlTyp, decTyp, func(i, o any) error {
+		return bigFloatToDecimal(i.(*big.Float), o.(*Decimal))
+	})
+}
+
+// DecimalTo converts a Decimal to any Numeric type or string with conv.ReflectTo, using the conversions registered with
+// conv. Other types than those registered convert via the string of the Decimal, such as 1.50 to an int8, which fails.
+func DecimalTo[O constraint.Numeric | string](d Decimal, o *O) error {
+	return conv.ReflectTo(goreflect.ValueOf(d), goreflect.ValueOf(o))
+}
+
+// MustDecimalTo is a must version of DecimalTo
+func MustDecimalTo[O constraint.Numeric | string](d Decimal, o *O) {
+	funcs.Must(DecimalTo(d, o))
+}
+
+// ToDecimal converts any Numeric type or string to a Decimal with conv.ReflectTo, using the conversions registered with
+// conv. Other types than those registered convert via their string, such as an int8 or *big.Int.
+func ToDecimal[I constraint.Numeric | string](i I, o *Decimal) error {
+	return conv.ReflectTo(goreflect.ValueOf(i), goreflect.ValueOf(o))
+}
+
+// MustToDecimal is a must version of ToDecimal
+func MustToDecimal[I constraint.Numeric | string](i I, o *Decimal) {
+	funcs.Must(ToDecimal(i, o))
+}
+
+// decimalToInt64 converts a Decimal to an int64, failing if it has a non-zero fractional part
+func decimalToInt64(d Decimal, o *int64) error {
+	if (d.value % powersOf10[d.scale]) != 0 {
+		return fmt.Errorf(errDecimalToMsg, d, "int64")
+	}
+
+	*o = d.value / powersOf10[d.scale]
+	return nil
+}
+
+// decimalToFloat64 converts a Decimal to the nearest float64
+func decimalToFloat64(d Decimal, o *float64) error {
+	if err := conv.StringToFloat64(d.String(), o); err != nil {
+		return fmt.Errorf(errDecimalToMsg, d, "float64")
+	}
+
+	return nil
+}
+
+// decimalToBigRat converts a Decimal to a *big.Rat, which is always exact
+func decimalToBigRat(d Decimal) *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(d.value), big.NewInt(powersOf10[d.scale]))
+}
+
+// int64ToDecimal converts an int64 to a Decimal with a scale of 0, failing if it has more than 18 digits
+func int64ToDecimal(i int64, o *Decimal) error {
+	d, err := OfDecimal(i, 0)
+	if err != nil {
+		return fmt.Errorf(errToDecimalMsg, "int64", strconv.FormatInt(i, 10))
+	}
+
+	*o = d
+	return nil
+}
+
+// float64ToDecimal converts a float64 to a Decimal of its shortest round trip decimal string
+func float64ToDecimal(f float64, o *Decimal) error {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return fmt.Errorf(errToDecimalMsg, "float64", strconv.FormatFloat(f, 'f', -1, 64))
+	}
+
+	return floatStringToDecimal("float64", strconv.FormatFloat(f, 'f', -1, 64), o)
+}
+
+// floatStringToDecimal converts the string of a float of the given type to a Decimal.
+// A zero integer part is removed, so that it does not count towards the 18 digits, eg 0.25 is parsed as .25.
+func floatStringToDecimal(typ, str string, o *Decimal) error {
+	parsed := str
+	if strings.HasPrefix(parsed, "0.") || strings.HasPrefix(parsed, "-0.") {
+		parsed = strings.Replace(parsed, "0.", ".", 1)
+	}
+
+	d, err := StringToDecimal(parsed)
+	if err != nil {
+		return fmt.Errorf(errToDecimalMsg, typ, str)
+	}
+
+	*o = d
+	return nil
+}
+
+// bigRatToDecimal converts a *big.Rat to a Decimal exactly, failing if the denominator does not divide a power of 10
+// up to 10 ^ 18, or the result has more than 18 digits
+func bigRatToDecimal(r *big.Rat, o *Decimal) error {
+	var (
+		num = new(big.Int)
+		rem = new(big.Int)
+	)
+
+	for scale := uint(0); scale <= decimalMaxScale; scale++ {
+		// r = n / d = (n * 10 ^ scale / d) / 10 ^ scale, if d divides n * 10 ^ scale
+		num.QuoRem(num.Mul(r.Num(), bigPowerOf10(scale)), r.Denom(), rem)
+		if rem.Sign() != 0 {
+			continue
+		}
+
+		if !num.IsInt64() {
+			break
+		}
+
+		d, err := OfDecimal(num.Int64(), scale)
+		if err != nil {
+			break
+		}
+
+		*o = d
+		return nil
+	}
+
+	return fmt.Errorf(errToDecimalMsg, "*big.Rat", r.RatString())
+}
+
+// bigFloatToDecimal converts a *big.Float to a Decimal of its shortest round trip decimal string
+func bigFloatToDecimal(f *big.Float, o *Decimal) error {
+	if f.IsInf() {
+		return fmt.Errorf(errToDecimalMsg, "*big.Float", f.Text('f', -1))
+	}
+
+	return floatStringToDecimal("*big.Float", f.Text('f', -1), o)
+}
+
+// ToFloat64 converts d to a float64 exactly, failing if it is not exactly representable, such as 0.1.
+// See FloatIsExactlyRepresentable.
+func (d Decimal) ToFloat64() (float64, error) {
+	var f float64
+	if !FloatIsExactlyRepresentable(d) {
+		return 0, fmt.Errorf(errDecimalToMsg, d, "float64 exactly")
+	}
+
+	// The float nearest to the string is the exact value
+	decimalToFloat64(d, &f)
+	return f, nil
+}
+
+// MustToFloat64 is a must version of ToFloat64
+func (d Decimal) MustToFloat64() float64 {
+	return funcs.MustValue(d.ToFloat64())
+}
+
+// Float64ToDecimal converts a float64 to a Decimal exactly, failing if it is not finite, or its exact decimal expansion
+// requires more than 18 digits or a scale > 18.
+// EG, 0.375 converts to 0.375, but 0.1 fails, as the float64 nearest to 0.1 is 0.1000000000000000055511151231257827...
+// The conv package conversion of a float64 to a Decimal is not exact, and converts 0.1 to 0.1.
+//
+// See DecomposeFloat.
+func Float64ToDecimal(f float64) (Decimal, error) {
+	ef, err := DecomposeFloat(f)
+	if (err != nil) || (len(ef.Digits)+MaxOrdered(ef.Exponent, 0) > decimalMaxScale) || (-ef.Exponent > decimalMaxScale) {
+		return Decimal{}, fmt.Errorf(errToDecimalMsg, "float64", strconv.FormatFloat(f, 'g', -1, 64))
+	}
+
+	var d Decimal
+	conv.StringToInt64(ef.Digits+strings.Repeat("0", MaxOrdered(ef.Exponent, 0)), &d.value)
+	d.scale = uint(MaxOrdered(-ef.Exponent, 0))
+	if ef.Negative {
+		d.value = -d.value
+	}
+
+	return d, nil
+}
+
+// MustFloat64ToDecimal is a must version of Float64ToDecimal
+func MustFloat64ToDecimal(f float64) Decimal {
+	return funcs.MustValue(Float64ToDecimal(f))
+}
+
+// ToBigRat converts d to a *big.Rat, which is always exact
+func (d Decimal) ToBigRat() *big.Rat {
+	return decimalToBigRat(d)
+}
+
+// BigRatToDecimal converts a *big.Rat to a Decimal exactly, failing if it has a non-terminating decimal expansion like
+// 1/3, or it requires more than 18 digits or a scale > 18
+func BigRatToDecimal(r *big.Rat) (Decimal, error) {
+	var d Decimal
+	err := bigRatToDecimal(r, &d)
+
+	return d, err
+}
+
+// MustBigRatToDecimal is a must version of BigRatToDecimal
+func MustBigRatToDecimal(r *big.Rat) Decimal {
+	return funcs.MustValue(BigRatToDecimal(r))
+}
+
+// MarshalJSON is the json.Marshaler interface, the Decimal is written as a JSON number of its string, eg 1.50
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON is the json.Unmarshaler interface, that accepts a JSON number, or a JSON string of a number, that
+// StringToDecimal can parse. A number can be given as a string to avoid other JSON decoders parsing it as a float.
+// A JSON null leaves the Decimal unchanged, like the encoding/json package does for other types.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	var (
+		text = bytes.TrimSpace(data)
+		str  = string(text)
+	)
+
+	if str == "null" {
+		return nil
+	}
+
+	if (len(text) > 0) && (text[0] == '"') {
+		if err := json.Unmarshal(text, &str); err != nil {
+			return fmt.Errorf(errToDecimalMsg, "JSON", string(data))
+		}
+	}
+
+	res, err := StringToDecimal(str)
+	if err != nil {
+		return fmt.Errorf(errToDecimalMsg, "JSON", string(data))
+	}
+
+	*d = res
+	return nil
+}
+
+// MarshalText is the encoding.TextMarshaler interface, the text is the same as String
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText is the encoding.TextUnmarshaler interface, the text is parsed by StringToDecimal
+func (d *Decimal) UnmarshalText(text []byte) error {
+	res, err := StringToDecimal(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = res
+	return nil
+}
+
+// Value is the database/sql/driver.Valuer interface.
+// The value is the string of the Decimal, which drivers pass to NUMERIC and DECIMAL columns without loss of precision.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan is the database/sql.Scanner interface, that accepts a string or []byte as parsed by StringToDecimal, an int64,
+// or a float64 converted as its shortest decimal string, so that a REAL value of 0.1 is 0.1.
+//
+// A NULL is an error, as there is no Decimal that represents it: scan a nullable column into a *Decimal field instead,
+// which database/sql sets to nil.
+func (d *Decimal) Scan(src any) error {
+	var (
+		res Decimal
+		err error
+	)
+
+	switch val := src.(type) {
+	case string:
+		res, err = StringToDecimal(val)
+	case []byte:
+		res, err = StringToDecimal(string(val))
+	case int64:
+		err = int64ToDecimal(val, &res)
+	case float64:
+		err = float64ToDecimal(val, &res)
+	default:
+		err = fmt.Errorf(errDecimalScanMsg, src, src)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	*d = res
+	return nil
+}
@@ -0,0 +1,325 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	goreflect "reflect"
+	"strconv"
+	"testing"
+
+	"github.com/bantling/micro/conv"
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/tuple"
+	"github.com/stretchr/testify/assert"
+)
+
+// reflectTo converts i to o with conv.ReflectTo
+func reflectTo[O any](i any, o *O) error {
+	return conv.ReflectTo(goreflect.ValueOf(i), goreflect.ValueOf(o))
+}
+
+func TestDecimalToConv_(t *testing.T) {
+	var (
+		d   = MustDecimal(-1525, 2)
+		str string
+		i64 int64
+		f64 float64
+		r   *big.Rat
+		bf  *big.Float
+	)
+
+	// string
+	assert.Nil(t, conv.AnyTo(d, &str))
+	assert.Equal(t, "-15.25", str)
+
+	// int64
+	assert.Nil(t, conv.AnyTo(MustDecimal(1500, 2, false), &i64))
+	assert.Equal(t, int64(15), i64)
+
+	assert.Equal(t, fmt.Errorf(errDecimalToMsg, "-15.25", "int64"), conv.AnyTo(d, &i64))
+	assert.Equal(t, int64(15), i64)
+
+	// float64
+	assert.Nil(t, conv.AnyTo(d, &f64))
+	assert.Equal(t, -15.25, f64)
+
+	assert.Nil(t, reflectTo(MustDecimal(1, 1), &f64))
+	assert.Equal(t, 0.1, f64)
+
+	// *big.Rat is exact
+	assert.Nil(t, conv.AnyTo(d, &r))
+	assert.Equal(t, big.NewRat(-61, 4), r)
+
+	// *big.Float
+	assert.Nil(t, reflectTo(d, &bf))
+	assert.Equal(t, "-15.25", bf.Text('f', -1))
+
+	// Registered conversions can be looked up
+	fn, err := conv.LookupConversion(goreflect.TypeOf(Decimal{}), goreflect.TypeOf(""))
+	assert.Nil(t, err)
+	assert.Nil(t, fn(d, &str))
+	assert.Equal(t, "-15.25", str)
+}
+
+func TestConvToDecimal_(t *testing.T) {
+	var d Decimal
+
+	// string
+	assert.Nil(t, reflectTo("1.50", &d))
+	assert.Equal(t, MustStringToDecimal("1.50"), d)
+
+	assert.Equal(t, fmt.Errorf(errInvalidStringMsg, "abc"), reflectTo("abc", &d))
+
+	// int64
+	assert.Nil(t, reflectTo(int64(-12), &d))
+	assert.Equal(t, MustDecimal(-12, 0), d)
+
+	assert.Equal(
+		t,
+		fmt.Errorf(errToDecimalMsg, "int64", "9223372036854775807"),
+		reflectTo(int64(math.MaxInt64), &d),
+	)
+
+	// float64 converts the shortest round trip string
+	assert.Nil(t, reflectTo(0.1, &d))
+	assert.Equal(t, MustDecimal(1, 1), d)
+
+	assert.Nil(t, reflectTo(-2.5e-17, &d))
+	assert.Equal(t, MustDecimal(-25, 18), d)
+
+	for f, str := range map[float64]string{
+		math.Inf(1): "+Inf",
+		1e18:        "1000000000000000000",
+		1e-19:       "0.0000000000000000001",
+	} {
+		assert.Equal(t, fmt.Errorf(errToDecimalMsg, "float64", str), reflectTo(f, &d))
+	}
+
+	assert.Equal(t, fmt.Errorf(errToDecimalMsg, "float64", "NaN"), reflectTo(math.NaN(), &d))
+
+	// *big.Rat must be exact
+	assert.Nil(t, reflectTo(big.NewRat(3, 8), &d))
+	assert.Equal(t, MustDecimal(375, 3), d)
+
+	assert.Nil(t, reflectTo(big.NewRat(-40, 1), &d))
+	assert.Equal(t, MustDecimal(-40, 0), d)
+
+	assert.Equal(t, fmt.Errorf(errToDecimalMsg, "*big.Rat", "1/3"), reflectTo(big.NewRat(1, 3), &d))
+	assert.Equal(t, fmt.Errorf(errToDecimalMsg, "*big.Rat", "1/524288"), reflectTo(big.NewRat(1, 1<<19), &d))
+	assert.Equal(
+		t,
+		fmt.Errorf(errToDecimalMsg, "*big.Rat", "1000000000000000000"),
+		reflectTo(new(big.Rat).SetInt(bigPowerOf10(18)), &d),
+	)
+
+	// *big.Float converts the shortest round trip string
+	assert.Nil(t, reflectTo(big.NewFloat(0.25), &d))
+	assert.Equal(t, MustDecimal(25, 2), d)
+
+	assert.Nil(t, reflectTo(big.NewFloat(0.1), &d))
+	assert.Equal(t, MustDecimal(1, 1), d)
+
+	assert.Equal(
+		t,
+		fmt.Errorf(errToDecimalMsg, "*big.Float", "+Inf"),
+		reflectTo(new(big.Float).SetInf(false), &d),
+	)
+}
+
+func TestDecimalToAndToDecimal_(t *testing.T) {
+	var (
+		d   = MustDecimal(-1525, 2)
+		str string
+		i32 int32
+		f32 float32
+		r   *big.Rat
+	)
+
+	// Registered types
+	assert.Nil(t, DecimalTo(d, &str))
+	assert.Equal(t, "-15.25", str)
+
+	assert.Nil(t, DecimalTo(d, &r))
+	assert.Equal(t, big.NewRat(-61, 4), r)
+
+	// Other types convert via the string
+	assert.Nil(t, DecimalTo(MustDecimal(-15, 0), &i32))
+	assert.Equal(t, int32(-15), i32)
+
+	assert.Nil(t, DecimalTo(d, &f32))
+	assert.Equal(t, float32(-15.25), f32)
+
+	MustDecimalTo(MustDecimal(7, 0), &i32)
+	assert.Equal(t, int32(7), i32)
+
+	funcs.TryTo(
+		func() {
+			MustDecimalTo(d, &i32)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf("The string value of -15.25 cannot be converted to int64"), e)
+		},
+	)
+
+	// To Decimal
+	var res Decimal
+	assert.Nil(t, ToDecimal("1.50", &res))
+	assert.Equal(t, MustStringToDecimal("1.50"), res)
+
+	assert.Nil(t, ToDecimal(int8(-5), &res))
+	assert.Equal(t, MustDecimal(-5, 0), res)
+
+	assert.Nil(t, ToDecimal(big.NewInt(12), &res))
+	assert.Equal(t, MustDecimal(12, 0), res)
+
+	MustToDecimal(0.25, &res)
+	assert.Equal(t, MustDecimal(25, 2), res)
+
+	assert.Equal(t, fmt.Errorf(errInvalidStringMsg, "abc"), ToDecimal("abc", &res))
+
+	funcs.TryTo(
+		func() {
+			MustToDecimal("abc", &res)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errInvalidStringMsg, "abc"), e)
+		},
+	)
+}
+
+func TestDecimalFloat64_(t *testing.T) {
+	// To float64 must be exact
+	for _, d := range []Decimal{MustDecimal(0, 0), MustDecimal(5, 1), MustDecimal(-3_75, 2), MustDecimal(1, 0), MustDecimal(9_007_199_254_740_992, 0)} {
+		f, err := d.ToFloat64()
+		assert.Nil(t, err)
+		assert.Equal(t, MustStringToDecimal(strconv.FormatFloat(f, 'f', -1, 64)), d)
+	}
+
+	assert.Equal(t, 0.375, MustDecimal(375, 3).MustToFloat64())
+	assert.Equal(t, tuple.Of2(0.0, fmt.Errorf(errDecimalToMsg, "0.1", "float64 exactly")), tuple.Of2(MustDecimal(1, 1).ToFloat64()))
+	assert.Equal(
+		t,
+		tuple.Of2(0.0, fmt.Errorf(errDecimalToMsg, "9007199254740993", "float64 exactly")),
+		tuple.Of2(MustDecimal(9_007_199_254_740_993, 0).ToFloat64()),
+	)
+
+	// From float64 must be exact
+	assert.Equal(t, tuple.Of2(MustDecimal(375, 3), error(nil)), tuple.Of2(Float64ToDecimal(0.375)))
+	assert.Equal(t, MustDecimal(-1_5, 1), MustFloat64ToDecimal(-1.5))
+	assert.Equal(t, MustDecimal(0, 0), MustFloat64ToDecimal(math.Copysign(0, -1)))
+	assert.Equal(t, MustDecimal(1_000_000_000_000_000_00, 0), MustFloat64ToDecimal(1e17))
+	assert.Equal(t, MustDecimal(3_814_697_265_625, 18), MustFloat64ToDecimal(1.0/(1<<18)))
+
+	for _, f := range []float64{0.1, 1e18, 1.0 / (1 << 19), math.Inf(1), math.NaN()} {
+		assert.Equal(t, tuple.Of2(Decimal{}, fmt.Errorf(errToDecimalMsg, "float64", strconv.FormatFloat(f, 'g', -1, 64))), tuple.Of2(Float64ToDecimal(f)))
+	}
+
+	funcs.TryTo(
+		func() {
+			MustDecimal(1, 1).MustToFloat64()
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errDecimalToMsg, "0.1", "float64 exactly"), e)
+		},
+	)
+}
+
+func TestDecimalBigRat_(t *testing.T) {
+	assert.Equal(t, big.NewRat(-3, 8), MustDecimal(-375, 3).ToBigRat())
+	assert.Equal(t, big.NewRat(0, 1), Decimal{}.ToBigRat())
+
+	assert.Equal(t, tuple.Of2(MustDecimal(375, 3), error(nil)), tuple.Of2(BigRatToDecimal(big.NewRat(3, 8))))
+	assert.Equal(t, MustDecimal(-2, 0), MustBigRatToDecimal(big.NewRat(-4, 2)))
+	assert.Equal(t, tuple.Of2(Decimal{}, fmt.Errorf(errToDecimalMsg, "*big.Rat", "1/3")), tuple.Of2(BigRatToDecimal(big.NewRat(1, 3))))
+
+	// Round trip
+	d := MustDecimal(123_456_789_012_345_678, 9)
+	assert.Equal(t, d, MustBigRatToDecimal(d.ToBigRat()))
+}
+
+func TestDecimalJSON_(t *testing.T) {
+	type order struct {
+		Price Decimal  `json:"price"`
+		Tax   *Decimal `json:"tax"`
+	}
+
+	var (
+		price = MustStringToDecimal("1.50")
+		tax   = MustStringToDecimal("-0.05")
+	)
+
+	// A JSON number of the string, preserving the scale
+	assert.Equal(t, tuple.Of2([]byte(`{"price":1.50,"tax":-0.05}`), error(nil)), tuple.Of2(json.Marshal(order{price, &tax})))
+	assert.Equal(t, tuple.Of2([]byte(`{"price":0,"tax":null}`), error(nil)), tuple.Of2(json.Marshal(order{})))
+
+	// A number, a string of a number, or an exponent
+	var o order
+	assert.Nil(t, json.Unmarshal([]byte(`{"price":1.50,"tax":"-0.05"}`), &o))
+	assert.Equal(t, order{price, &tax}, o)
+
+	assert.Nil(t, json.Unmarshal([]byte(`{"price":1.5e2}`), &o))
+	assert.Equal(t, MustStringToDecimal("150"), o.Price)
+
+	// Null leaves the value unchanged
+	assert.Nil(t, json.Unmarshal([]byte(`{"price":null,"tax":null}`), &o))
+	assert.Equal(t, order{Price: MustStringToDecimal("150")}, o)
+
+	// Errors
+	var d Decimal
+	assert.Equal(t, fmt.Errorf(errToDecimalMsg, "JSON", "true"), d.UnmarshalJSON([]byte("true")))
+	assert.Equal(t, fmt.Errorf(errToDecimalMsg, "JSON", `"1.5`), d.UnmarshalJSON([]byte(`"1.5`)))
+	assert.Equal(t, fmt.Errorf(errToDecimalMsg, "JSON", "1234567890123456789"), d.UnmarshalJSON([]byte("1234567890123456789")))
+	assert.Equal(t, Decimal{}, d)
+}
+
+func TestDecimalText_(t *testing.T) {
+	d := MustStringToDecimal("-12.340")
+	assert.Equal(t, tuple.Of2([]byte("-12.340"), error(nil)), tuple.Of2(d.MarshalText()))
+
+	var r Decimal
+	assert.Nil(t, r.UnmarshalText([]byte("-12.340")))
+	assert.Equal(t, d, r)
+
+	assert.Equal(t, fmt.Errorf(errInvalidStringMsg, "1234567890123456789"), r.UnmarshalText([]byte("1234567890123456789")))
+	assert.Equal(t, d, r)
+
+	// Map keys use the text interfaces
+	var m map[Decimal]int
+	assert.Nil(t, json.Unmarshal([]byte(`{"1.5":1}`), &m))
+	assert.Equal(t, map[Decimal]int{MustStringToDecimal("1.5"): 1}, m)
+}
+
+func TestDecimalSQL_(t *testing.T) {
+	assert.Equal(t, tuple.Of2(driver.Value("1.50"), error(nil)), tuple.Of2(MustStringToDecimal("1.50").Value()))
+
+	// The Valuer passes the driver checks
+	assert.Equal(t, tuple.Of2(driver.Value("-0.5"), error(nil)), tuple.Of2(driver.DefaultParameterConverter.ConvertValue(MustDecimal(-5, 1))))
+
+	var d Decimal
+	assert.Nil(t, d.Scan("1.50"))
+	assert.Equal(t, MustStringToDecimal("1.50"), d)
+
+	assert.Nil(t, d.Scan([]byte("-2.25")))
+	assert.Equal(t, MustStringToDecimal("-2.25"), d)
+
+	assert.Nil(t, d.Scan(int64(42)))
+	assert.Equal(t, MustDecimal(42, 0), d)
+
+	assert.Nil(t, d.Scan(0.1))
+	assert.Equal(t, MustDecimal(1, 1), d)
+
+	// Errors leave the value unchanged
+	assert.Equal(t, fmt.Errorf(errDecimalScanMsg, nil, nil), d.Scan(nil))
+	assert.Equal(t, fmt.Errorf(errDecimalScanMsg, true, true), d.Scan(true))
+	assert.Equal(t, fmt.Errorf(errInvalidStringMsg, "x"), d.Scan("x"))
+	assert.Equal(t, fmt.Errorf(errToDecimalMsg, "float64", "+Inf"), d.Scan(math.Inf(1)))
+	assert.Equal(t, MustDecimal(1, 1), d)
+}
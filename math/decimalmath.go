@@ -0,0 +1,250 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/bantling/micro/conv"
+	"github.com/bantling/micro/funcs"
+)
+
+const (
+	// errDecimalModByZeroMsg is the error message for the remainder of dividing by zero
+	errDecimalModByZeroMsg = "The decimal calculation %s %% 0 is not allowed"
+
+	// errDecimalSqrtNegativeMsg is the error message for the square root of a negative value
+	errDecimalSqrtNegativeMsg = "The decimal square root of %s is not allowed, the value is negative"
+
+	// errDecimalSqrtOverflowMsg is the error message for a square root that has more than 18 digits at the desired scale
+	errDecimalSqrtOverflowMsg = "The decimal square root of %s overflowed at scale %d"
+
+	// powGuardDigits is the number of significant digits that Pow keeps of intermediate powers, which is far more than
+	// the 18 digits of the result, so that truncating the remaining digits does not affect rounding the result
+	powGuardDigits = 50
+
+	// powMaxMagnitude is the power of 10 that Pow considers intermediate powers to be out of range at, as the result
+	// must either overflow, or be a reciprocal that rounds the same as any value < 10^-19
+	powMaxMagnitude = 20
+)
+
+// Abs returns the absolute value of d.
+// There is no error, as the range of a Decimal is symmetric.
+func (d Decimal) Abs() Decimal {
+	return Decimal{value: funcs.Ternary(d.value < 0, -d.value, d.value), scale: d.scale, denormalized: d.denormalized}
+}
+
+// Mod returns the remainder of d / o, where the quotient is truncated towards zero, like the Go % operator and SQL MOD.
+// The remainder has the same sign as d, regardless of the sign of o, and a magnitude less than that of o. The scale is
+// the larger of the scales of d and o. EG:
+//
+//	5.5 mod 2 = 1.5, -5.5 mod 2 = -1.5, 5.5 mod -2 = 1.5, -5.5 mod -2 = -1.5, 1 mod 0.3 = 0.1
+//
+// The remainder cannot overflow, so the only error is a division by zero error if o is zero.
+func (d Decimal) Mod(o Decimal) (Decimal, error) {
+	if o.value == 0 {
+		return Decimal{}, fmt.Errorf(errDecimalModByZeroMsg, d)
+	}
+
+	// Adjusting both values to the same scale can require up to 36 digits.
+	// The remainder is at most the magnitude of d and less than that of o, one of which is not adjusted, so it fits.
+	var (
+		scale = MaxOrdered(d.scale, o.scale)
+		dv    = new(big.Int).Mul(big.NewInt(d.value), bigPowerOf10(scale-d.scale))
+		ov    = new(big.Int).Mul(big.NewInt(o.value), bigPowerOf10(scale-o.scale))
+		r     = Decimal{value: dv.Rem(dv, ov).Int64(), scale: scale, denormalized: d.denormalized}
+	)
+
+	r.applyNormalization()
+	return r, nil
+}
+
+// MustMod is a must version of Mod
+func (d Decimal) MustMod(o Decimal) Decimal {
+	return funcs.MustValue(d.Mod(o))
+}
+
+// powMul returns the product of two magnitudes of the form m * 10^e, truncated to powGuardDigits significant digits.
+// Returns true if any non-zero digits were truncated.
+func powMul(am *big.Int, ae int, bm *big.Int, be int) (*big.Int, int, bool) {
+	var (
+		m   = new(big.Int).Mul(am, bm)
+		e   = ae + be
+		rem big.Int
+	)
+
+	if n := len(m.String()) - powGuardDigits; n > 0 {
+		m.QuoRem(m, bigPowerOf10(uint(n)), &rem)
+		e += n
+	}
+
+	return m, e, rem.Sign() != 0
+}
+
+// Pow returns d raised to the integer power n, where a negative n is the reciprocal 1 / d^-n, and d^0 = 1 for any d,
+// including 0. EG, compounding interest of 0.5% per month for 30 years is 1.005^360 = 6.02257521226321618.
+//
+// The result is rounded with the optional mode, which defaults to HalfUp. A positive n is rounded the same way as Mul,
+// to the scale of d * n, or fewer digits of scale if the result has more than 18 digits. A negative n is rounded the
+// same way as Div, to as many digits as a Decimal can hold.
+//
+// Large powers are calculated by repeated squaring, keeping far more digits of intermediate powers than the result
+// needs, so that even 1.000_000_001^1_000_000_000 = 2.71828182709990432 is fast and as accurate as a Decimal can be.
+//
+// Returns a division by zero error if d is zero and n is negative.
+// Returns an overflow error if the integer part of a positive result has more than 18 digits, or an underflow error if
+// the integer part of a negative result has more than 18 digits.
+func (d Decimal) Pow(n int, mode ...RoundingMode) (Decimal, error) {
+	// A MinInt exponent cannot be negated as an int, but can be as a uint
+	var (
+		un    = funcs.Ternary(n < 0, uint(-n), uint(n))
+		neg   = (d.value < 0) && (un&1 == 1)
+		rmode = funcs.SliceIndex(mode, 0)
+
+		// A positive power has a scale of at most d scale * n, for a negative power the scale is limited only by the digits
+		maxScale = uint(decimalMaxScale)
+	)
+
+	if (n > 0) && (un <= decimalMaxScale) {
+		maxScale = MinOrdered(d.scale*un, decimalMaxScale)
+	}
+
+	switch {
+	case (d.value == 0) && (n < 0):
+		return Decimal{}, fmt.Errorf(errDecimalDivisionByZeroMsg, "1")
+	case n == 0:
+		return Decimal{value: 1, denormalized: d.denormalized}, nil
+	case d.value == 0:
+		r := Decimal{scale: maxScale, denormalized: d.denormalized}
+		r.applyNormalization()
+		return r, nil
+	}
+
+	// Powers of a magnitude > 1 only increase, and powers of a magnitude < 1 only decrease, so the calculation can stop
+	// as soon as any intermediate power is out of range
+	var (
+		bm, be     = big.NewInt(funcs.Ternary(d.value < 0, -d.value, d.value)), -int(d.scale)
+		am, ae     = big.NewInt(1), 0
+		inexact    bool
+		trunc      bool
+		cmpOne     = bm.Cmp(bigPowerOf10(d.scale))
+		outOfRange = func(m *big.Int, e int) bool {
+			mag := len(m.String()) + e
+			return ((cmpOne > 0) && (mag > powMaxMagnitude)) || ((cmpOne < 0) && (mag <= -powMaxMagnitude))
+		}
+	)
+
+	for k := un; k > 0; k >>= 1 {
+		if k&1 == 1 {
+			am, ae, trunc = powMul(am, ae, bm, be)
+			inexact = inexact || trunc
+			if outOfRange(am, ae) {
+				break
+			}
+		}
+
+		if k > 1 {
+			bm, be, trunc = powMul(bm, be, bm, be)
+			inexact = inexact || trunc
+			if outOfRange(bm, be) {
+				am, ae = bm, be
+				break
+			}
+		}
+	}
+
+	// An out of range power > 1 overflows, and an out of range power < 1 is tiny, and the reverse for a reciprocal
+	var num, den *big.Int
+	switch {
+	case outOfRange(am, ae) && ((cmpOne > 0) == (n > 0)):
+		return Decimal{}, fmt.Errorf(funcs.Ternary(neg, errDecimalUnderflowMsg, errDecimalOverflowMsg), d, "^", conv.IntToString(n))
+	case outOfRange(am, ae):
+		// Any non-zero magnitude < 10^-19 rounds to scale 18 the same way
+		num, den = big.NewInt(1), bigPowerOf10(powMaxMagnitude)
+	default:
+		// Truncated digits are replaced with a half digit, which is close enough to the true value, and is never exact
+		if inexact {
+			am.Mul(am, bigTen).Add(am, big.NewInt(5))
+			ae--
+		}
+
+		num, den = am, big.NewInt(1)
+		if ae >= 0 {
+			num.Mul(num, bigPowerOf10(uint(ae)))
+		} else {
+			den = bigPowerOf10(uint(-ae))
+		}
+
+		if n < 0 {
+			num, den = den, num
+		}
+	}
+
+	if neg {
+		num.Neg(num)
+	}
+
+	r, ok := roundQuoDigits(num, den, maxScale, rmode, d.denormalized)
+	if !ok {
+		return Decimal{}, fmt.Errorf(funcs.Ternary(neg, errDecimalUnderflowMsg, errDecimalOverflowMsg), d, "^", conv.IntToString(n))
+	}
+
+	return r, nil
+}
+
+// MustPow is a must version of Pow
+func (d Decimal) MustPow(n int, mode ...RoundingMode) Decimal {
+	return funcs.MustValue(d.Pow(n, mode...))
+}
+
+// Sqrt returns the square root of d rounded to the given scale with the optional mode, which defaults to HalfUp.
+// EG, the square root of 2 to scale 10 is 1.4142135624.
+//
+// Returns an error if the scale is > 18, or d is negative.
+// Returns an overflow error if the result has more than 18 digits at the given scale, such as the square root of 2 to
+// scale 18, which is 1.414_213_562_373_095_049.
+func (d Decimal) Sqrt(scale uint, mode ...RoundingMode) (Decimal, error) {
+	if scale > decimalMaxScale {
+		return Decimal{}, fmt.Errorf(errScaleTooLargeMsg, scale)
+	}
+
+	if d.value < 0 {
+		return Decimal{}, fmt.Errorf(errDecimalSqrtNegativeMsg, d)
+	}
+
+	// The root of d at the given scale is the root of a / b, where a = value * 10^(2 * scale), and b = 10^(d scale).
+	// The integer root of the integer part of a / b is the truncated root.
+	var (
+		a = new(big.Int).Mul(big.NewInt(d.value), bigPowerOf10(2*scale))
+		b = bigPowerOf10(d.scale)
+		r = new(big.Int).Sqrt(new(big.Int).Quo(a, b))
+	)
+
+	// The root is exact if r^2 = a / b, otherwise compare a / b to (r + 1/2)^2 = (2r + 1)^2 / 4 to see if the discarded
+	// fraction is more or less than halfway. It can be exactly halfway, eg the root of 0.25 to scale 0 is 0.5.
+	if new(big.Int).Mul(new(big.Int).Mul(r, r), b).Cmp(a) != 0 {
+		var (
+			r2   = new(big.Int).Add(new(big.Int).Lsh(r, 1), big.NewInt(1))
+			half = new(big.Int).Lsh(a, 2).Cmp(r2.Mul(r2, r2).Mul(r2, b))
+		)
+
+		if roundAway(funcs.SliceIndex(mode, 0), false, r.Bit(0) == 1, half) {
+			r.Add(r, big.NewInt(1))
+		}
+	}
+
+	if !r.IsInt64() || (r.Int64() > decimalMaxValue) {
+		return Decimal{}, fmt.Errorf(errDecimalSqrtOverflowMsg, d, scale)
+	}
+
+	res := Decimal{value: r.Int64(), scale: scale, denormalized: d.denormalized}
+	res.applyNormalization()
+
+	return res, nil
+}
+
+// MustSqrt is a must version of Sqrt
+func (d Decimal) MustSqrt(scale uint, mode ...RoundingMode) Decimal {
+	return funcs.MustValue(d.Sqrt(scale, mode...))
+}
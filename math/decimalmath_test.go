@@ -0,0 +1,199 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	gomath "math"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/tuple"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecimalAbs_(t *testing.T) {
+	assert.Equal(t, MustDecimal(1_5, 1), MustDecimal(-1_5, 1).Abs())
+	assert.Equal(t, MustDecimal(1_5, 1), MustDecimal(1_5, 1).Abs())
+	assert.Equal(t, MustDecimal(0, 0), MustDecimal(0, 0).Abs())
+	assert.Equal(t, MustDecimal(decimalMaxValue, 0), MustDecimal(decimalMinValue, 0).Abs())
+	assert.Equal(t, MustDecimal(1_50, 2, false), MustDecimal(-1_50, 2, false).Abs())
+}
+
+func TestDecimalMod_(t *testing.T) {
+	// The sign of the remainder is the sign of the dividend
+	assert.Equal(t, tuple.Of2(MustDecimal(1_5, 1), error(nil)), tuple.Of2(MustDecimal(5_5, 1).Mod(MustDecimal(2, 0))))
+	assert.Equal(t, MustDecimal(-1_5, 1), MustDecimal(-5_5, 1).MustMod(MustDecimal(2, 0)))
+	assert.Equal(t, MustDecimal(1_5, 1), MustDecimal(5_5, 1).MustMod(MustDecimal(-2, 0)))
+	assert.Equal(t, MustDecimal(-1_5, 1), MustDecimal(-5_5, 1).MustMod(MustDecimal(-2, 0)))
+
+	// Larger scale of the two
+	assert.Equal(t, MustDecimal(1, 1), MustDecimal(1, 0).MustMod(MustDecimal(3, 1)))
+	assert.Equal(t, MustDecimal(10, 2, false), MustDecimal(1, 0, false).MustMod(MustDecimal(30, 2, false)))
+
+	// Exact multiple
+	assert.Equal(t, MustDecimal(0, 0), MustDecimal(6, 0).MustMod(MustDecimal(3, 0)))
+
+	// Divisor larger than dividend
+	assert.Equal(t, MustDecimal(1, 18), MustDecimal(1, 18).MustMod(MustDecimal(decimalMaxValue, 0)))
+
+	// Adjusting the scale of either value requires 36 digits
+	assert.Equal(t, MustDecimal(0, 0), MustDecimal(decimalMaxValue, 0).MustMod(MustDecimal(8, 18)))
+	assert.Equal(t, MustDecimal(4, 18), MustDecimal(123_456_789_123, 3).MustMod(MustDecimal(7, 18)))
+
+	// Division by zero
+	assert.Equal(
+		t,
+		tuple.Of2(Decimal{}, fmt.Errorf(errDecimalModByZeroMsg, "1.5")),
+		tuple.Of2(MustDecimal(1_5, 1).Mod(MustDecimal(0, 2))),
+	)
+
+	funcs.TryTo(
+		func() {
+			MustDecimal(1, 0).MustMod(MustDecimal(0, 0))
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errDecimalModByZeroMsg, "1"), e)
+		},
+	)
+}
+
+func TestDecimalPow_(t *testing.T) {
+	// Exact powers
+	assert.Equal(t, tuple.Of2(MustDecimal(1_21, 2), error(nil)), tuple.Of2(MustDecimal(1_1, 1).Pow(2)))
+	assert.Equal(t, MustDecimal(-8, 0), MustDecimal(-2, 0).MustPow(3))
+	assert.Equal(t, MustDecimal(16, 0), MustDecimal(-2, 0).MustPow(4))
+	assert.Equal(t, MustDecimal(576_460_752_303_423_488, 0), MustDecimal(2, 0).MustPow(59))
+	assert.Equal(t, MustDecimal(100_000_000_000_000_000, 0), MustDecimal(10, 0).MustPow(17))
+	assert.Equal(t, MustDecimal(1, 18), MustDecimal(1, 1).MustPow(18))
+
+	// The scale of a positive power is d scale * n, unless it is normalized
+	assert.Equal(t, MustDecimal(2_2500, 4, false), MustDecimal(1_50, 2, false).MustPow(2))
+	assert.Equal(t, MustDecimal(2_25, 2), MustDecimal(1_50, 2).MustPow(2))
+
+	// Inexact powers are rounded to fewer digits of scale: 1.5^20 = 3325.256_730_079_650_878_906_25
+	assert.Equal(t, MustDecimal(3325_256_730_079_650_88, 14), MustDecimal(1_5, 1).MustPow(20))
+	assert.Equal(t, MustDecimal(3325_256_730_079_650_87, 14), MustDecimal(1_5, 1).MustPow(20, Truncate))
+
+	// Powers with too many digits to calculate exactly
+	assert.Equal(t, MustDecimal(6_022_575_212_263_216_18, 17), MustDecimal(1_005, 3).MustPow(360))
+	assert.Equal(t, MustDecimal(2_718_281_827_099_904_32, 17), MustDecimal(1_000_000_001, 9).MustPow(1_000_000_000))
+
+	// Zero and one
+	assert.Equal(t, MustDecimal(1, 0), MustDecimal(0, 0).MustPow(0))
+	assert.Equal(t, MustDecimal(1, 0), MustDecimal(1_5, 1).MustPow(0))
+	assert.Equal(t, MustDecimal(0, 0), MustDecimal(0, 2).MustPow(5))
+	assert.Equal(t, Decimal{scale: 10, denormalized: true}, MustDecimal(0, 2, false).MustPow(5))
+	assert.Equal(t, MustDecimal(1, 0), MustDecimal(1, 0).MustPow(gomath.MaxInt))
+	assert.Equal(t, MustDecimal(-1, 0), MustDecimal(-1, 0).MustPow(gomath.MaxInt))
+	assert.Equal(t, MustDecimal(1, 0), MustDecimal(-1, 0).MustPow(gomath.MinInt))
+
+	// Negative powers
+	assert.Equal(t, MustDecimal(5, 1), MustDecimal(2, 0).MustPow(-1))
+	assert.Equal(t, MustDecimal(-125, 3), MustDecimal(-2, 0).MustPow(-3))
+	assert.Equal(t, MustDecimal(333_333_333_333_333_333, 18), MustDecimal(3, 0).MustPow(-1))
+	assert.Equal(t, MustDecimal(333_333_333_333_333_334, 18), MustDecimal(3, 0).MustPow(-1, Ceil))
+	assert.Equal(t, MustDecimal(576_460_752_303_423_488, 0), MustDecimal(5, 1).MustPow(-59))
+	assert.Equal(t, MustDecimal(2_493_721_628_481_410_3, 16), MustDecimal(97, 2).MustPow(-30))
+
+	// Results too small for scale 18 are rounded
+	assert.Equal(t, MustDecimal(0, 0), MustDecimal(1, 1).MustPow(19))
+	assert.Equal(t, MustDecimal(1, 18), MustDecimal(1, 1).MustPow(19, Ceil))
+	assert.Equal(t, MustDecimal(0, 0), MustDecimal(1, 1).MustPow(1_000))
+	assert.Equal(t, MustDecimal(1, 18), MustDecimal(1, 1).MustPow(1_000, Ceil))
+	assert.Equal(t, MustDecimal(-1, 18), MustDecimal(-1, 1).MustPow(1_001, Floor))
+	assert.Equal(t, MustDecimal(0, 0), MustDecimal(10, 0).MustPow(-19))
+	assert.Equal(t, MustDecimal(1, 18), MustDecimal(10, 0).MustPow(gomath.MinInt, Ceil))
+
+	// Overflow and underflow
+	assert.Equal(
+		t,
+		tuple.Of2(Decimal{}, fmt.Errorf(errDecimalOverflowMsg, "10", "^", "18")),
+		tuple.Of2(MustDecimal(10, 0).Pow(18)),
+	)
+	assert.Equal(
+		t,
+		tuple.Of2(Decimal{}, fmt.Errorf(errDecimalUnderflowMsg, "-10", "^", "19")),
+		tuple.Of2(MustDecimal(-10, 0).Pow(19)),
+	)
+	assert.Equal(
+		t,
+		tuple.Of2(Decimal{}, fmt.Errorf(errDecimalOverflowMsg, "2", "^", "1000000")),
+		tuple.Of2(MustDecimal(2, 0).Pow(1_000_000)),
+	)
+	assert.Equal(
+		t,
+		tuple.Of2(Decimal{}, fmt.Errorf(errDecimalOverflowMsg, "0.1", "^", "-18")),
+		tuple.Of2(MustDecimal(1, 1).Pow(-18)),
+	)
+	assert.Equal(
+		t,
+		tuple.Of2(Decimal{}, fmt.Errorf(errDecimalUnderflowMsg, "-0.5", "^", "-1001")),
+		tuple.Of2(MustDecimal(-5, 1).Pow(-1_001)),
+	)
+
+	// Division by zero
+	assert.Equal(
+		t,
+		tuple.Of2(Decimal{}, fmt.Errorf(errDecimalDivisionByZeroMsg, "1")),
+		tuple.Of2(MustDecimal(0, 0).Pow(-1)),
+	)
+
+	funcs.TryTo(
+		func() {
+			MustDecimal(10, 0).MustPow(18)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errDecimalOverflowMsg, "10", "^", "18"), e)
+		},
+	)
+}
+
+func TestDecimalSqrt_(t *testing.T) {
+	assert.Equal(t, tuple.Of2(MustDecimal(1_414_213_562_4, 10), error(nil)), tuple.Of2(MustDecimal(2, 0).Sqrt(10)))
+	assert.Equal(t, MustDecimal(1_414_213_562_373_095_05, 17), MustDecimal(2, 0).MustSqrt(17))
+	assert.Equal(t, MustDecimal(1_414_213_562_373_095_04, 17), MustDecimal(2, 0).MustSqrt(17, Floor))
+
+	// Exact roots
+	assert.Equal(t, MustDecimal(2, 0), MustDecimal(4, 0).MustSqrt(2))
+	assert.Equal(t, MustDecimal(2_00, 2, false), MustDecimal(4, 0, false).MustSqrt(2))
+	assert.Equal(t, MustDecimal(1_2, 1), MustDecimal(1_44, 2).MustSqrt(5))
+	assert.Equal(t, MustDecimal(0, 0), MustDecimal(0, 3).MustSqrt(18))
+
+	// Exactly halfway
+	assert.Equal(t, MustDecimal(5, 1), MustDecimal(25, 2).MustSqrt(1))
+	assert.Equal(t, MustDecimal(1, 0), MustDecimal(25, 2).MustSqrt(0))
+	assert.Equal(t, MustDecimal(0, 0), MustDecimal(25, 2).MustSqrt(0, HalfEven))
+
+	// The largest value has a root with 9 integer digits, so at most 9 digits of scale
+	assert.Equal(t, MustDecimal(999_999_999_999_999_999, 9), MustDecimal(decimalMaxValue, 0).MustSqrt(9))
+	assert.Equal(
+		t,
+		tuple.Of2(Decimal{}, fmt.Errorf(errDecimalSqrtOverflowMsg, "999999999999999999", 10)),
+		tuple.Of2(MustDecimal(decimalMaxValue, 0).Sqrt(10)),
+	)
+	assert.Equal(
+		t,
+		tuple.Of2(Decimal{}, fmt.Errorf(errDecimalSqrtOverflowMsg, "2", 18)),
+		tuple.Of2(MustDecimal(2, 0).Sqrt(18)),
+	)
+
+	// Errors
+	assert.Equal(
+		t,
+		tuple.Of2(Decimal{}, fmt.Errorf(errScaleTooLargeMsg, 19)),
+		tuple.Of2(MustDecimal(2, 0).Sqrt(19)),
+	)
+
+	funcs.TryTo(
+		func() {
+			MustDecimal(-2, 0).MustSqrt(2)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errDecimalSqrtNegativeMsg, "-2"), e)
+		},
+	)
+}
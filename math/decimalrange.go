@@ -0,0 +1,135 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+
+	"github.com/bantling/micro/funcs"
+)
+
+// Error messages
+var (
+	errDecimalRangeEmptyMsg = "The decimal range %s is not allowed, min must be < max, or min = max with both closed"
+)
+
+// DecimalRange is a range of Decimal values, where each bound may be open (exclusive) or closed (inclusive).
+// A range is never empty, it contains at least one value.
+//
+// Unlike Range, a DecimalRange has no current value, it is for testing and combining ranges, such as price bands or
+// validity windows, and translating them into SQL predicates.
+type DecimalRange struct {
+	min     Decimal
+	minMode RangeMode
+	max     Decimal
+	maxMode RangeMode
+}
+
+// nonEmpty returns true if a range from a lower bound to an upper bound contains at least one value
+func nonEmpty(min Decimal, minMode RangeMode, max Decimal, maxMode RangeMode) bool {
+	cmp := min.Cmp(max)
+	return (cmp < 0) || ((cmp == 0) && (minMode == Closed) && (maxMode == Closed))
+}
+
+// OfDecimalRange constructs a DecimalRange from a minimum value and mode, and a maximum value and mode.
+//
+// Returns an error if the range would be empty, which is when min > max, or min = max and either mode is Open.
+func OfDecimalRange(min Decimal, minMode RangeMode, max Decimal, maxMode RangeMode) (DecimalRange, error) {
+	r := DecimalRange{min, minMode, max, maxMode}
+	if !nonEmpty(min, minMode, max, maxMode) {
+		return DecimalRange{}, fmt.Errorf(errDecimalRangeEmptyMsg, r)
+	}
+
+	return r, nil
+}
+
+// MustDecimalRange is a must version of OfDecimalRange
+func MustDecimalRange(min Decimal, minMode RangeMode, max Decimal, maxMode RangeMode) DecimalRange {
+	return funcs.MustValue(OfDecimalRange(min, minMode, max, maxMode))
+}
+
+// GetMin returns the minimum value and mode
+func (r DecimalRange) GetMin() (Decimal, RangeMode) {
+	return r.min, r.minMode
+}
+
+// GetMax returns the maximum value and mode
+func (r DecimalRange) GetMax() (Decimal, RangeMode) {
+	return r.max, r.maxMode
+}
+
+// String returns the range in interval notation, where [ and ] are closed, and ( and ) are open (eg, [1.00, 2.00))
+func (r DecimalRange) String() string {
+	return fmt.Sprintf(
+		"%s%s, %s%s",
+		funcs.Ternary(r.minMode == Closed, "[", "("),
+		r.min,
+		r.max,
+		funcs.Ternary(r.maxMode == Closed, "]", ")"),
+	)
+}
+
+// Contains returns true if the value is in the range
+func (r DecimalRange) Contains(d Decimal) bool {
+	return nonEmpty(r.min, r.minMode, d, Closed) && nonEmpty(d, Closed, r.max, r.maxMode)
+}
+
+// Overlaps returns true if the ranges have at least one value in common
+func (r DecimalRange) Overlaps(o DecimalRange) bool {
+	return nonEmpty(r.min, r.minMode, o.max, o.maxMode) && nonEmpty(o.min, o.minMode, r.max, r.maxMode)
+}
+
+// Intersect returns the range of values that are in both ranges, and true.
+// If the ranges do not overlap, the result is (zero value, false).
+func (r DecimalRange) Intersect(o DecimalRange) (DecimalRange, bool) {
+	var res DecimalRange
+
+	// The larger minimum, where an open bound is larger than a closed bound of the same value
+	switch cmp := r.min.Cmp(o.min); {
+	case cmp > 0:
+		res.min, res.minMode = r.min, r.minMode
+	case cmp < 0:
+		res.min, res.minMode = o.min, o.minMode
+	case r.minMode == Open:
+		res.min, res.minMode = r.min, Open
+	default:
+		res.min, res.minMode = o.min, o.minMode
+	}
+
+	// The smaller maximum, where an open bound is smaller than a closed bound of the same value
+	switch cmp := r.max.Cmp(o.max); {
+	case cmp < 0:
+		res.max, res.maxMode = r.max, r.maxMode
+	case cmp > 0:
+		res.max, res.maxMode = o.max, o.maxMode
+	case r.maxMode == Open:
+		res.max, res.maxMode = r.max, Open
+	default:
+		res.max, res.maxMode = o.max, o.maxMode
+	}
+
+	if !nonEmpty(res.min, res.minMode, res.max, res.maxMode) {
+		return DecimalRange{}, false
+	}
+
+	return res, true
+}
+
+// SQL returns a SQL predicate that is true if the given column is in the range.
+// A closed range uses BETWEEN, otherwise a pair of comparisons is used (eg, price >= 1.00 AND price < 2.00).
+// The column is used as is, it must already be quoted if necessary.
+func (r DecimalRange) SQL(column string) string {
+	if (r.minMode == Closed) && (r.maxMode == Closed) {
+		return fmt.Sprintf("%s BETWEEN %s AND %s", column, r.min, r.max)
+	}
+
+	return fmt.Sprintf(
+		"%s %s %s AND %s %s %s",
+		column,
+		funcs.Ternary(r.minMode == Closed, ">=", ">"),
+		r.min,
+		column,
+		funcs.Ternary(r.maxMode == Closed, "<=", "<"),
+		r.max,
+	)
+}
@@ -0,0 +1,109 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/tuple"
+	"github.com/stretchr/testify/assert"
+)
+
+// dr is a shorthand for constructing a DecimalRange from strings
+func dr(min string, minMode RangeMode, max string, maxMode RangeMode) DecimalRange {
+	return MustDecimalRange(MustStringToDecimal(min), minMode, MustStringToDecimal(max), maxMode)
+}
+
+func TestOfDecimalRange_(t *testing.T) {
+	r := dr("1.00", Closed, "2.50", Open)
+	assert.Equal(t, tuple.Of2(MustStringToDecimal("1.00"), Closed), tuple.Of2(r.GetMin()))
+	assert.Equal(t, tuple.Of2(MustStringToDecimal("2.50"), Open), tuple.Of2(r.GetMax()))
+	assert.Equal(t, "[1.00, 2.50)", r.String())
+	assert.Equal(t, "(-1, 1]", dr("-1", Open, "1", Closed).String())
+
+	// Single value
+	assert.Equal(t, "[1, 1.0]", dr("1", Closed, "1.0", Closed).String())
+
+	// Empty
+	for _, modes := range [][2]RangeMode{{Open, Open}, {Open, Closed}, {Closed, Open}} {
+		one := MustStringToDecimal("1")
+		assert.Equal(
+			t,
+			tuple.Of2(DecimalRange{}, fmt.Errorf(errDecimalRangeEmptyMsg, DecimalRange{one, modes[0], one, modes[1]})),
+			tuple.Of2(OfDecimalRange(one, modes[0], one, modes[1])),
+		)
+	}
+
+	funcs.TryTo(
+		func() {
+			dr("2", Closed, "1", Closed)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf(errDecimalRangeEmptyMsg, "[2, 1]"), e) },
+	)
+}
+
+func TestDecimalRangeContains_(t *testing.T) {
+	var (
+		closed = dr("1", Closed, "2", Closed)
+		open   = dr("1", Open, "2", Open)
+	)
+
+	for _, val := range []string{"1", "1.00", "1.5", "2"} {
+		assert.True(t, closed.Contains(MustStringToDecimal(val)), val)
+	}
+
+	for _, val := range []string{"0.99", "2.01", "-1.5"} {
+		assert.False(t, closed.Contains(MustStringToDecimal(val)), val)
+	}
+
+	for _, val := range []string{"1.01", "1.99"} {
+		assert.True(t, open.Contains(MustStringToDecimal(val)), val)
+	}
+
+	for _, val := range []string{"1", "2.00"} {
+		assert.False(t, open.Contains(MustStringToDecimal(val)), val)
+	}
+}
+
+func TestDecimalRangeOverlapsIntersect_(t *testing.T) {
+	for _, test := range []struct {
+		r, o     DecimalRange
+		expected string
+	}{
+		// Disjoint
+		{dr("1", Closed, "2", Closed), dr("3", Closed, "4", Closed), ""},
+		// Touching bounds
+		{dr("1", Closed, "2", Closed), dr("2", Closed, "3", Closed), "[2, 2]"},
+		{dr("1", Closed, "2", Open), dr("2", Closed, "3", Closed), ""},
+		{dr("1", Closed, "2", Closed), dr("2", Open, "3", Closed), ""},
+		// Partial overlap
+		{dr("1", Closed, "3", Open), dr("2", Open, "4", Closed), "(2, 3)"},
+		// One inside the other
+		{dr("1", Closed, "4", Closed), dr("2", Open, "3", Closed), "(2, 3]"},
+		// Same values, open bound wins
+		{dr("1", Closed, "2", Open), dr("1.0", Open, "2.0", Closed), "(1.0, 2)"},
+		{dr("1", Closed, "2", Closed), dr("1", Closed, "2", Closed), "[1, 2]"},
+	} {
+		for _, pair := range [][2]DecimalRange{{test.r, test.o}, {test.o, test.r}} {
+			assert.Equal(t, test.expected != "", pair[0].Overlaps(pair[1]), "%s %s", pair[0], pair[1])
+
+			res, ok := pair[0].Intersect(pair[1])
+			assert.Equal(t, test.expected != "", ok, "%s %s", pair[0], pair[1])
+			if ok {
+				assert.Equal(t, test.expected, res.String())
+			} else {
+				assert.Equal(t, DecimalRange{}, res)
+			}
+		}
+	}
+}
+
+func TestDecimalRangeSQL_(t *testing.T) {
+	assert.Equal(t, "price BETWEEN 1.00 AND 2.50", dr("1.00", Closed, "2.50", Closed).SQL("price"))
+	assert.Equal(t, "price >= 1.00 AND price < 2.50", dr("1.00", Closed, "2.50", Open).SQL("price"))
+	assert.Equal(t, `"p" > -1 AND "p" <= 1`, dr("-1", Open, "1", Closed).SQL(`"p"`))
+	assert.Equal(t, "p > 0 AND p < 1", dr("0", Open, "1", Open).SQL("p"))
+}
@@ -0,0 +1,258 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/bantling/micro/constraint"
+	"github.com/bantling/micro/funcs"
+)
+
+// Constants
+var (
+	errFloatNotFiniteMsg = "The float value %v is not finite, it has no exact decimal expansion"
+)
+
+const (
+	// floatMantissaBits is the number of explicitly stored bits of a float64 mantissa
+	floatMantissaBits = 52
+
+	// floatExponentMask is the mask of the 11 float64 exponent bits, after shifting out the mantissa bits
+	floatExponentMask = 0x7ff
+
+	// floatExponentBias is the exponent bias of a float64, plus the number of mantissa bits, so that the value of a
+	// float64 is mantissa * 2 ^ (exponent - floatExponentBias) when the mantissa is treated as an integer
+	floatExponentBias = 1023 + floatMantissaBits
+
+	// floatMaxExactInt is the largest odd integer a float64 mantissa can hold, the implicit leading 1 bit plus 52 bits
+	floatMaxExactInt = 1<<(floatMantissaBits+1) - 1
+)
+
+// ExactFloat is the exact decimal expansion of a finite float64, such that the float64 value is precisely
+// (-1 if Negative) * Digits * 10 ^ Exponent.
+//
+// Digits has no leading or trailing zeros, except that zero is represented as Digits "0" and Exponent 0.
+// Every finite float64 has a finite decimal expansion, as 2 ^ -n = 5 ^ n * 10 ^ -n, but it may have many more digits
+// than strconv produces, as strconv only produces enough digits to distinguish adjacent float64 values.
+type ExactFloat struct {
+	Negative bool
+	Digits   string
+	Exponent int
+}
+
+// DecomposeFloat returns the exact decimal expansion of a float64.
+// Returns an error if the value is NaN or infinite.
+//
+// -0 is Negative with Digits "0".
+func DecomposeFloat(f float64) (ExactFloat, error) {
+	var (
+		bits     = math.Float64bits(f)
+		ef       = ExactFloat{Negative: (bits >> 63) != 0, Digits: "0"}
+		exponent = int((bits >> floatMantissaBits) & floatExponentMask)
+		mantissa = bits & (1<<floatMantissaBits - 1)
+	)
+
+	if exponent == floatExponentMask {
+		return ExactFloat{}, fmt.Errorf(errFloatNotFiniteMsg, f)
+	}
+
+	// A zero exponent is a subnormal number, that has no implicit leading 1 bit and the same exponent as the smallest
+	// normal number
+	if exponent == 0 {
+		exponent = 1
+	} else {
+		mantissa |= 1 << floatMantissaBits
+	}
+
+	if mantissa == 0 {
+		return ef, nil
+	}
+
+	// Remove trailing zero bits, so that the mantissa is odd
+	exponent -= floatExponentBias
+	for (mantissa & 1) == 0 {
+		mantissa >>= 1
+		exponent++
+	}
+
+	digits := new(big.Int).SetUint64(mantissa)
+	if exponent >= 0 {
+		// Integer mantissa * 2 ^ exponent, which may have decimal trailing zeros
+		ef.Digits = digits.Lsh(digits, uint(exponent)).String()
+		trimmed := strings.TrimRight(ef.Digits, "0")
+		ef.Exponent = len(ef.Digits) - len(trimmed)
+		ef.Digits = trimmed
+	} else {
+		// Odd mantissa * 2 ^ -n = odd mantissa * 5 ^ n * 10 ^ -n, which has no decimal trailing zeros
+		ef.Digits = digits.Mul(digits, new(big.Int).Exp(big.NewInt(5), big.NewInt(int64(-exponent)), nil)).String()
+		ef.Exponent = exponent
+	}
+
+	return ef, nil
+}
+
+// MustDecomposeFloat is a must version of DecomposeFloat
+func MustDecomposeFloat(f float64) ExactFloat {
+	return funcs.MustValue(DecomposeFloat(f))
+}
+
+// String returns the exact decimal expansion as a plain decimal string, without an exponent
+func (ef ExactFloat) String() string {
+	var str string
+
+	switch {
+	case ef.Exponent >= 0:
+		str = ef.Digits + strings.Repeat("0", ef.Exponent)
+	case -ef.Exponent < len(ef.Digits):
+		point := len(ef.Digits) + ef.Exponent
+		str = ef.Digits[:point] + "." + ef.Digits[point:]
+	default:
+		str = "0." + strings.Repeat("0", -ef.Exponent-len(ef.Digits)) + ef.Digits
+	}
+
+	if ef.Negative {
+		str = "-" + str
+	}
+
+	return str
+}
+
+// FloatIsExactlyRepresentable returns true if the given Decimal can be converted to a float64 without loss of precision.
+//
+// A Decimal is value / 10 ^ scale = value / (2 ^ scale * 5 ^ scale). After cancelling common factors of 5, the Decimal
+// is exactly representable if and only if no factors of 5 remain in the divisor, and the odd part of the remaining
+// value fits in the 53 bits of a float64 mantissa. The exponent range of a float64 is always sufficient for a Decimal.
+//
+// Eg, 0.5, 0.25, and 3.75 are exactly representable, but 0.1 and 0.3 are not.
+func FloatIsExactlyRepresentable(d Decimal) bool {
+	var (
+		value = d.value
+		scale = d.scale
+	)
+
+	if value == 0 {
+		return true
+	}
+
+	if value < 0 {
+		value = -value
+	}
+
+	// Cancel factors of 5
+	for (scale > 0) && (value%5 == 0) {
+		value /= 5
+		scale--
+	}
+
+	if scale > 0 {
+		return false
+	}
+
+	// Remove factors of 2, which only affect the float exponent
+	for (value & 1) == 0 {
+		value >>= 1
+	}
+
+	return value <= floatMaxExactInt
+}
+
+// floatOrdinal maps a float32 or float64 to an integer, such that adjacent floats have adjacent integers, and -0 and +0
+// are both 0. The difference of two ordinals is the number of representable floats between them.
+func floatOrdinal[T constraint.Float](f T) int64 {
+	if reflect.TypeOf(f).Kind() == reflect.Float32 {
+		bits := math.Float32bits(float32(f))
+		if (bits >> 31) != 0 {
+			return -int64(bits &^ (1 << 31))
+		}
+
+		return int64(bits)
+	}
+
+	bits := math.Float64bits(float64(f))
+	if (bits >> 63) != 0 {
+		return -int64(bits &^ (1 << 63))
+	}
+
+	return int64(bits)
+}
+
+// ULPDistance returns the number of units in the last place (ULPs) between two floats, which is the number of
+// representable values of type T that a must be incremented or decremented by to reach b.
+//
+// -0 and +0 are 0 ULPs apart, the largest finite value is 1 ULP from infinity, and any distance involving NaN is
+// math.MaxUint64.
+func ULPDistance[T constraint.Float](a, b T) uint64 {
+	if (a != a) || (b != b) {
+		return math.MaxUint64
+	}
+
+	oa, ob := floatOrdinal(a), floatOrdinal(b)
+	if oa < ob {
+		oa, ob = ob, oa
+	}
+
+	return uint64(oa) - uint64(ob)
+}
+
+// AlmostEqualULP returns true if a and b are at most maxULPs representable values apart (see ULPDistance).
+//
+// This is the most precise comparison of results that should be the same but were computed differently, as each ULP
+// scales with the magnitude of the values. It is not suitable for values of opposite sign near zero, as all the tiny
+// floats lie between them: 1e-300 and -1e-300 are over 10 ^ 17 ULPs apart, use AlmostEqualAbs for those.
+//
+// NaN is not equal to anything, and an infinity is only equal to the same infinity, even though the largest finite
+// value is 1 ULP from it.
+func AlmostEqualULP[T constraint.Float](a, b T, maxULPs uint64) bool {
+	if a == b {
+		return true
+	}
+
+	if (a != a) || (b != b) || math.IsInf(float64(a), 0) || math.IsInf(float64(b), 0) {
+		return false
+	}
+
+	return ULPDistance(a, b) <= maxULPs
+}
+
+// AlmostEqualAbs returns true if |a - b| <= tolerance.
+//
+// An absolute tolerance suits values whose scale is known in advance, such as comparing to zero, but is meaningless for
+// large values where the gap between adjacent floats exceeds the tolerance.
+//
+// NaN is not equal to anything, and an infinity is only equal to the same infinity.
+func AlmostEqualAbs[T constraint.Float](a, b, tolerance T) bool {
+	if a == b {
+		return true
+	}
+
+	return math.Abs(float64(a)-float64(b)) <= float64(tolerance)
+}
+
+// AlmostEqualRel returns true if |a - b| <= tolerance * max(|a|, |b|), so that a tolerance of 1e-9 means the values
+// agree to about 9 significant digits.
+//
+// A relative tolerance suits values of any magnitude, except near zero, where it is never satisfied unless the values
+// are exactly equal: combine with AlmostEqualAbs when values may be zero.
+//
+// NaN is not equal to anything, and an infinity is only equal to the same infinity.
+func AlmostEqualRel[T constraint.Float](a, b, tolerance T) bool {
+	if a == b {
+		return true
+	}
+
+	if math.IsInf(float64(a), 0) || math.IsInf(float64(b), 0) {
+		return false
+	}
+
+	var (
+		fa, fb = float64(a), float64(b)
+		diff   = math.Abs(fa - fb)
+	)
+
+	return diff <= float64(tolerance)*math.Max(math.Abs(fa), math.Abs(fb))
+}
@@ -0,0 +1,154 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/tuple"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecomposeFloat_(t *testing.T) {
+	assert.Equal(t, tuple.Of2(ExactFloat{Digits: "0"}, error(nil)), tuple.Of2(DecomposeFloat(0)))
+	assert.Equal(t, ExactFloat{Negative: true, Digits: "0"}, MustDecomposeFloat(math.Copysign(0, -1)))
+	assert.Equal(t, ExactFloat{Digits: "1"}, MustDecomposeFloat(1))
+	assert.Equal(t, ExactFloat{Negative: true, Digits: "5", Exponent: -1}, MustDecomposeFloat(-0.5))
+	assert.Equal(t, ExactFloat{Digits: "375", Exponent: -2}, MustDecomposeFloat(3.75))
+	assert.Equal(t, ExactFloat{Digits: "1", Exponent: 2}, MustDecomposeFloat(100))
+	assert.Equal(t, ExactFloat{Digits: "1024"}, MustDecomposeFloat(1024))
+
+	// 0.1 is not exact
+	assert.Equal(
+		t,
+		ExactFloat{Digits: "1000000000000000055511151231257827021181583404541015625", Exponent: -55},
+		MustDecomposeFloat(0.1),
+	)
+	assert.Equal(t, "0.1000000000000000055511151231257827021181583404541015625", MustDecomposeFloat(0.1).String())
+
+	// Largest and smallest values
+	assert.Equal(t, len(fmt.Sprintf("%.0f", math.MaxFloat64)), len(MustDecomposeFloat(math.MaxFloat64).Digits)+MustDecomposeFloat(math.MaxFloat64).Exponent)
+	assert.Equal(t, fmt.Sprintf("%.0f", math.MaxFloat64), MustDecomposeFloat(math.MaxFloat64).String())
+	ef := MustDecomposeFloat(math.SmallestNonzeroFloat64)
+	assert.Equal(t, -1074, ef.Exponent)
+	assert.Equal(t, 751, len(ef.Digits))
+	assert.Equal(t, "4940656458412465441765687928682213723650", ef.Digits[:40])
+
+	// Not finite
+	for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		assert.Equal(t, tuple.Of2(ExactFloat{}, fmt.Errorf(errFloatNotFiniteMsg, f)), tuple.Of2(DecomposeFloat(f)))
+	}
+
+	funcs.TryTo(
+		func() {
+			MustDecomposeFloat(math.Inf(1))
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf(errFloatNotFiniteMsg, math.Inf(1)), e) },
+	)
+}
+
+func TestExactFloatString_(t *testing.T) {
+	assert.Equal(t, "0", ExactFloat{Digits: "0"}.String())
+	assert.Equal(t, "-0", ExactFloat{Negative: true, Digits: "0"}.String())
+	assert.Equal(t, "12300", ExactFloat{Digits: "123", Exponent: 2}.String())
+	assert.Equal(t, "1.23", ExactFloat{Digits: "123", Exponent: -2}.String())
+	assert.Equal(t, "-0.123", ExactFloat{Negative: true, Digits: "123", Exponent: -3}.String())
+	assert.Equal(t, "0.00123", ExactFloat{Digits: "123", Exponent: -5}.String())
+}
+
+func TestFloatIsExactlyRepresentable_(t *testing.T) {
+	for _, str := range []string{"0", "1", "-1", "0.5", "0.25", "-3.75", "0.125", "1024", "9007199254740992", "9007199254740991", "288230376151711744"} {
+		assert.True(t, FloatIsExactlyRepresentable(MustStringToDecimal(str)), str)
+	}
+
+	for _, str := range []string{"0.1", "0.3", "-1.1", "0.2", "9007199254740993", "123456789012345678"} {
+		assert.False(t, FloatIsExactlyRepresentable(MustStringToDecimal(str)), str)
+	}
+
+	// Denormalized values with trailing zeros
+	assert.True(t, FloatIsExactlyRepresentable(MustDecimal(1_500, 3, false)))
+	assert.False(t, FloatIsExactlyRepresentable(MustDecimal(1_100, 3, false)))
+
+	// Consistent with DecomposeFloat
+	for _, f := range []float64{0.5, 0.1, 2.75, 1e-3} {
+		str := MustDecomposeFloat(f).String()
+		d, err := StringToDecimal(str)
+		if err == nil {
+			assert.True(t, FloatIsExactlyRepresentable(d), str)
+		}
+	}
+}
+
+func TestULPDistance_(t *testing.T) {
+	assert.Equal(t, uint64(0), ULPDistance(1.0, 1.0))
+	assert.Equal(t, uint64(0), ULPDistance(0, math.Copysign(0, -1)))
+	assert.Equal(t, uint64(1), ULPDistance(1, math.Nextafter(1, 2)))
+	assert.Equal(t, uint64(1), ULPDistance(math.Nextafter(1, 0), 1))
+	assert.Equal(t, uint64(2), ULPDistance(math.SmallestNonzeroFloat64, -math.SmallestNonzeroFloat64))
+	assert.Equal(t, uint64(1), ULPDistance(math.MaxFloat64, math.Inf(1)))
+	assert.True(t, ULPDistance(1e-300, -1e-300) > 1e17)
+
+	// Largest distance
+	assert.Equal(t, uint64(0xffe0000000000000), ULPDistance(math.Inf(-1), math.Inf(1)))
+
+	// float32 steps are float32 values
+	assert.Equal(t, uint64(1), ULPDistance(float32(1), math.Nextafter32(1, 2)))
+	assert.Equal(t, uint64(1), ULPDistance(math.MaxFloat32, float32(math.Inf(1))))
+
+	// NaN
+	assert.Equal(t, uint64(math.MaxUint64), ULPDistance(math.NaN(), 1))
+	assert.Equal(t, uint64(math.MaxUint64), ULPDistance(1, math.NaN()))
+}
+
+func TestAlmostEqualULP_(t *testing.T) {
+	// Variables, as constant arithmetic is exact
+	var (
+		a, b     = 0.1, 0.2
+		a32, b32 = float32(0.1), float32(0.7)
+	)
+
+	assert.True(t, AlmostEqualULP(a+b, 0.3, 1))
+	assert.False(t, AlmostEqualULP(a+b, 0.3, 0))
+	assert.True(t, AlmostEqualULP(0, math.Copysign(0, -1), 0))
+	assert.True(t, AlmostEqualULP(a32+b32, float32(0.8), 1))
+	assert.False(t, AlmostEqualULP(1e-300, -1e-300, 1<<32))
+
+	assert.True(t, AlmostEqualULP(math.Inf(1), math.Inf(1), 0))
+	assert.False(t, AlmostEqualULP(math.MaxFloat64, math.Inf(1), 1))
+	assert.False(t, AlmostEqualULP(math.NaN(), math.NaN(), math.MaxUint64))
+}
+
+func TestAlmostEqualAbs_(t *testing.T) {
+	assert.True(t, AlmostEqualAbs(1.0, 1.0, 0))
+	assert.True(t, AlmostEqualAbs(1e-300, -1e-300, 1e-12))
+	assert.True(t, AlmostEqualAbs(1.0, 1.1, 0.2))
+	assert.False(t, AlmostEqualAbs(1.0, 1.3, 0.2))
+	assert.True(t, AlmostEqualAbs(float32(1), float32(1.1), 0.2))
+
+	assert.True(t, AlmostEqualAbs(math.Inf(-1), math.Inf(-1), 0))
+	assert.False(t, AlmostEqualAbs(math.Inf(-1), math.Inf(1), math.MaxFloat64))
+	assert.False(t, AlmostEqualAbs(math.NaN(), 1, 1))
+}
+
+func TestAlmostEqualRel_(t *testing.T) {
+	a, b := 0.1, 0.2
+	assert.True(t, AlmostEqualRel(a+b, 0.3, 1e-15))
+	assert.False(t, AlmostEqualRel(a+b, 0.3, 1e-17))
+	assert.True(t, AlmostEqualRel(1e20, 1e20+1e10, 1e-9))
+	assert.False(t, AlmostEqualRel(1e20, 1e20+1e12, 1e-9))
+	assert.True(t, AlmostEqualRel(-100.0, -101.0, 0.01))
+	assert.False(t, AlmostEqualRel(-100.0, -102.0, 0.01))
+	assert.True(t, AlmostEqualRel(float32(100), float32(101), 0.01))
+
+	// Never near zero unless equal
+	assert.True(t, AlmostEqualRel(0.0, 0.0, 0))
+	assert.False(t, AlmostEqualRel(0, 1e-300, 0.5))
+
+	assert.True(t, AlmostEqualRel(math.Inf(1), math.Inf(1), 0))
+	assert.False(t, AlmostEqualRel(math.MaxFloat64, math.Inf(1), 1))
+	assert.False(t, AlmostEqualRel(math.NaN(), math.NaN(), 1))
+}
@@ -0,0 +1,330 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+
+	"github.com/bantling/micro/constraint"
+	"github.com/bantling/micro/funcs"
+)
+
+const (
+	errISqrtNegativeMsg   = "The integer square root of %d is not allowed, the value is negative"
+	errILogNotPositiveMsg = "The integer log base %d of %d is not allowed, the value is not positive"
+	errFactorialNegMsg    = "The factorial of %d is not allowed, the value is negative"
+)
+
+var (
+	bigThree = big.NewInt(3)
+)
+
+// squareAtMost returns true if r * r <= n
+func squareAtMost(r, n uint64) bool {
+	hi, lo := bits.Mul64(r, r)
+	return (hi == 0) && (lo <= n)
+}
+
+// cubeAtMost returns true if r * r * r <= n
+func cubeAtMost(r, n uint64) bool {
+	hi, lo := bits.Mul64(r, r)
+	if hi != 0 {
+		return false
+	}
+
+	hi, lo = bits.Mul64(lo, r)
+	return (hi == 0) && (lo <= n)
+}
+
+// isqrt64 returns the floor of the square root of n, correcting the float estimate, which can be off by one for large n
+func isqrt64(n uint64) uint64 {
+	r := uint64(math.Sqrt(float64(n)))
+	for !squareAtMost(r, n) {
+		r--
+	}
+
+	for squareAtMost(r+1, n) {
+		r++
+	}
+
+	return r
+}
+
+// icbrt64 returns the floor of the cube root of n, correcting the float estimate like isqrt64
+func icbrt64(n uint64) uint64 {
+	r := uint64(math.Cbrt(float64(n)))
+	for !cubeAtMost(r, n) {
+		r--
+	}
+
+	for cubeAtMost(r+1, n) {
+		r++
+	}
+
+	return r
+}
+
+// ISqrt returns the floor of the square root of n, and true if it is exact (n is a perfect square).
+// Unlike math.Sqrt, the result is correct for every value, including those over 2 ^ 53 that a float64 cannot hold.
+//
+// Returns an error if n is negative.
+func ISqrt[T constraint.Integer](n T) (T, bool, error) {
+	if n < 0 {
+		return 0, false, fmt.Errorf(errISqrtNegativeMsg, n)
+	}
+
+	r := isqrt64(uint64(n))
+	return T(r), r*r == uint64(n), nil
+}
+
+// MustISqrt is a must version of ISqrt
+func MustISqrt[T constraint.Integer](n T) (T, bool) {
+	r, exact, err := ISqrt(n)
+	funcs.Must(err)
+	return r, exact
+}
+
+// ISqrtBig is the *big.Int version of ISqrt
+func ISqrtBig(n *big.Int) (*big.Int, bool, error) {
+	if n.Sign() < 0 {
+		return nil, false, fmt.Errorf(errISqrtNegativeMsg, n)
+	}
+
+	r := new(big.Int).Sqrt(n)
+	return r, new(big.Int).Mul(r, r).Cmp(n) == 0, nil
+}
+
+// MustISqrtBig is a must version of ISqrtBig
+func MustISqrtBig(n *big.Int) (*big.Int, bool) {
+	r, exact, err := ISqrtBig(n)
+	funcs.Must(err)
+	return r, exact
+}
+
+// ICbrt returns the cube root of n rounded towards zero, and true if it is exact (n is a perfect cube).
+// A negative n has a negative root, eg the cube root of -9 is -2.
+func ICbrt[T constraint.Integer](n T) (T, bool) {
+	if n < 0 {
+		r := icbrt64(uint64(-int64(n)))
+		return -T(r), r*r*r == uint64(-int64(n))
+	}
+
+	r := icbrt64(uint64(n))
+	return T(r), r*r*r == uint64(n)
+}
+
+// ICbrtBig is the *big.Int version of ICbrt
+func ICbrtBig(n *big.Int) (*big.Int, bool) {
+	var (
+		abs = new(big.Int).Abs(n)
+		r   = new(big.Int)
+	)
+
+	if abs.Sign() > 0 {
+		// Newton's method, starting from a power of 2 that is at least the root, decreases until it reaches the floor
+		var (
+			next = new(big.Int)
+			sq   = new(big.Int)
+		)
+
+		r.Lsh(big.NewInt(1), uint(abs.BitLen()+2)/3)
+		for {
+			// next = (2r + abs / r ^ 2) / 3
+			next.Quo(abs, sq.Mul(r, r))
+			next.Add(next, sq.Lsh(r, 1))
+			next.Quo(next, bigThree)
+
+			if next.Cmp(r) >= 0 {
+				break
+			}
+
+			r.Set(next)
+		}
+	}
+
+	exact := new(big.Int).Mul(new(big.Int).Mul(r, r), r).Cmp(abs) == 0
+	if n.Sign() < 0 {
+		r.Neg(r)
+	}
+
+	return r, exact
+}
+
+// ILog2 returns the floor of the base 2 log of n, which is the index of the highest one bit, and true if it is exact
+// (n is a power of 2).
+//
+// Returns an error if n is not positive.
+func ILog2[T constraint.Integer](n T) (uint, bool, error) {
+	if n <= 0 {
+		return 0, false, fmt.Errorf(errILogNotPositiveMsg, 2, n)
+	}
+
+	u := uint64(n)
+	return uint(bits.Len64(u) - 1), (u & (u - 1)) == 0, nil
+}
+
+// MustILog2 is a must version of ILog2
+func MustILog2[T constraint.Integer](n T) (uint, bool) {
+	l, exact, err := ILog2(n)
+	funcs.Must(err)
+	return l, exact
+}
+
+// ILog2Big is the *big.Int version of ILog2
+func ILog2Big(n *big.Int) (uint, bool, error) {
+	if n.Sign() <= 0 {
+		return 0, false, fmt.Errorf(errILogNotPositiveMsg, 2, n)
+	}
+
+	l := uint(n.BitLen() - 1)
+	return l, n.TrailingZeroBits() == l, nil
+}
+
+// MustILog2Big is a must version of ILog2Big
+func MustILog2Big(n *big.Int) (uint, bool) {
+	l, exact, err := ILog2Big(n)
+	funcs.Must(err)
+	return l, exact
+}
+
+// ILog10 returns the floor of the base 10 log of n, which is one less than the number of decimal digits, and true if it
+// is exact (n is a power of 10).
+//
+// Returns an error if n is not positive.
+func ILog10[T constraint.Integer](n T) (uint, bool, error) {
+	if n <= 0 {
+		return 0, false, fmt.Errorf(errILogNotPositiveMsg, 10, n)
+	}
+
+	var (
+		u = uint64(n)
+		l uint
+		p = uint64(1)
+	)
+
+	// Compare with u / p rather than p * 10, so that p cannot overflow
+	for u/p >= 10 {
+		p *= 10
+		l++
+	}
+
+	return l, u == p, nil
+}
+
+// MustILog10 is a must version of ILog10
+func MustILog10[T constraint.Integer](n T) (uint, bool) {
+	l, exact, err := ILog10(n)
+	funcs.Must(err)
+	return l, exact
+}
+
+// ILog10Big is the *big.Int version of ILog10
+func ILog10Big(n *big.Int) (uint, bool, error) {
+	if n.Sign() <= 0 {
+		return 0, false, fmt.Errorf(errILogNotPositiveMsg, 10, n)
+	}
+
+	l := uint(len(n.Text(10)) - 1)
+	return l, bigPowerOf10(l).Cmp(n) == 0, nil
+}
+
+// MustILog10Big is a must version of ILog10Big
+func MustILog10Big(n *big.Int) (uint, bool) {
+	l, exact, err := ILog10Big(n)
+	funcs.Must(err)
+	return l, exact
+}
+
+// mulChecked returns a * b, and true if the product fits in type T.
+// Dividing the product by b detects every overflow except MinInt * -1, which is MinInt, detected by the wrong sign.
+func mulChecked[T constraint.Integer](a, b T) (T, bool) {
+	if (a == 0) || (b == 0) {
+		return 0, true
+	}
+
+	p := a * b
+	return p, (p/b == a) && ((p < 0) == ((a < 0) != (b < 0)))
+}
+
+// PowInt returns base ^ exp, computed by repeated squaring.
+//
+// Returns OverflowErr if the result is too large for type T, or UnderflowErr if it is too small.
+// 0 ^ 0 is 1.
+func PowInt[T constraint.Integer](base T, exp uint) (T, error) {
+	var (
+		r  = T(1)
+		ok = true
+
+		// The result is only negative if the base is negative, and the exponent is odd
+		neg = (base < 0) && ((exp & 1) == 1)
+	)
+
+	for {
+		if (exp & 1) == 1 {
+			if r, ok = mulChecked(r, base); !ok {
+				break
+			}
+		}
+
+		// Only square the base if a higher bit needs it, so that an unused square cannot overflow
+		if exp >>= 1; exp == 0 {
+			break
+		}
+
+		if base, ok = mulChecked(base, base); !ok {
+			break
+		}
+	}
+
+	if !ok {
+		return 0, funcs.Ternary(neg, UnderflowErr, OverflowErr)
+	}
+
+	return r, nil
+}
+
+// MustPowInt is a must version of PowInt
+func MustPowInt[T constraint.Integer](base T, exp uint) T {
+	return funcs.MustValue(PowInt(base, exp))
+}
+
+// PowIntBig is the *big.Int version of PowInt, which cannot overflow
+func PowIntBig(base *big.Int, exp uint) *big.Int {
+	return new(big.Int).Exp(base, new(big.Int).SetUint64(uint64(exp)), nil)
+}
+
+// Factorial returns n!, the product of the integers 1 thru n, where 0! is 1.
+// The largest factorial of an int64 or uint64 is 20!, and of an int8 is 5!.
+//
+// Returns an error if n is negative, or OverflowErr if the result is too large for type T.
+func Factorial[T constraint.Integer](n T) (T, error) {
+	if n < 0 {
+		return 0, fmt.Errorf(errFactorialNegMsg, n)
+	}
+
+	var (
+		r  = T(1)
+		ok bool
+	)
+
+	for i := T(2); i <= n; i++ {
+		if r, ok = mulChecked(r, i); !ok {
+			return 0, OverflowErr
+		}
+	}
+
+	return r, nil
+}
+
+// MustFactorial is a must version of Factorial
+func MustFactorial[T constraint.Integer](n T) T {
+	return funcs.MustValue(Factorial(n))
+}
+
+// FactorialBig is the *big.Int version of Factorial, which cannot overflow
+func FactorialBig(n uint) *big.Int {
+	return new(big.Int).MulRange(1, int64(n))
+}
@@ -0,0 +1,291 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/tuple"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestISqrt_(t *testing.T) {
+	assert.Equal(t, tuple.Of3(0, true, error(nil)), tuple.Of3(ISqrt(0)))
+	assert.Equal(t, tuple.Of3(1, true, error(nil)), tuple.Of3(ISqrt(1)))
+	assert.Equal(t, tuple.Of3(1, false, error(nil)), tuple.Of3(ISqrt(3)))
+	assert.Equal(t, tuple.Of3(int8(11), false, error(nil)), tuple.Of3(ISqrt(int8(127))))
+	assert.Equal(t, tuple.Of3(0, false, fmt.Errorf(errISqrtNegativeMsg, -4)), tuple.Of3(ISqrt(-4)))
+
+	// Values a float64 cannot hold exactly
+	assert.Equal(t, tuple.Of3(uint64(1<<32-1), false, error(nil)), tuple.Of3(ISqrt(uint64(math.MaxUint64))))
+	assert.Equal(t, tuple.Of3(uint64(1<<32-1), true, error(nil)), tuple.Of3(ISqrt(uint64(1<<32-1)*(1<<32-1))))
+	assert.Equal(t, tuple.Of3(uint64(1<<32-2), false, error(nil)), tuple.Of3(ISqrt(uint64(1<<32-1)*(1<<32-1)-1)))
+	assert.Equal(t, tuple.Of3(int64(3037000499), false, error(nil)), tuple.Of3(ISqrt(int64(math.MaxInt64))))
+
+	// Compare to big.Int
+	for _, n := range []uint64{2, 15, 16, 17, 1 << 53, 1<<53 + 1, 1<<62 - 1, 1 << 62, math.MaxUint64 - 1} {
+		r, exact := MustISqrt(n)
+		br, bexact := MustISqrtBig(new(big.Int).SetUint64(n))
+		assert.Equal(t, tuple.Of2(br.Uint64(), bexact), tuple.Of2(r, exact))
+	}
+
+	funcs.TryTo(
+		func() {
+			MustISqrt(-1)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errISqrtNegativeMsg, -1), e)
+		},
+	)
+}
+
+func TestISqrtBig_(t *testing.T) {
+	huge := new(big.Int).Lsh(big.NewInt(1), 200)
+	assert.Equal(t, tuple.Of3(new(big.Int).Lsh(big.NewInt(1), 100), true, error(nil)), tuple.Of3(ISqrtBig(huge)))
+	assert.Equal(
+		t,
+		tuple.Of3(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 100), big.NewInt(1)), false, error(nil)),
+		tuple.Of3(ISqrtBig(new(big.Int).Sub(huge, big.NewInt(1)))),
+	)
+	assert.Equal(t, tuple.Of3((*big.Int)(nil), false, fmt.Errorf(errISqrtNegativeMsg, -1)), tuple.Of3(ISqrtBig(big.NewInt(-1))))
+
+	funcs.TryTo(
+		func() {
+			MustISqrtBig(big.NewInt(-2))
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errISqrtNegativeMsg, -2), e)
+		},
+	)
+}
+
+func TestICbrt_(t *testing.T) {
+	assert.Equal(t, tuple.Of2(0, true), tuple.Of2(ICbrt(0)))
+	assert.Equal(t, tuple.Of2(2, true), tuple.Of2(ICbrt(8)))
+	assert.Equal(t, tuple.Of2(2, false), tuple.Of2(ICbrt(26)))
+	assert.Equal(t, tuple.Of2(3, true), tuple.Of2(ICbrt(27)))
+	assert.Equal(t, tuple.Of2(-2, false), tuple.Of2(ICbrt(-9)))
+	assert.Equal(t, tuple.Of2(-3, true), tuple.Of2(ICbrt(-27)))
+	assert.Equal(t, tuple.Of2(int8(-5), false), tuple.Of2(ICbrt(int8(math.MinInt8))))
+	assert.Equal(t, tuple.Of2(int64(-2097152), true), tuple.Of2(ICbrt(int64(math.MinInt64))))
+	assert.Equal(t, tuple.Of2(uint64(2642245), false), tuple.Of2(ICbrt(uint64(math.MaxUint64))))
+	assert.Equal(t, tuple.Of2(uint64(2642245), true), tuple.Of2(ICbrt(uint64(2642245)*2642245*2642245)))
+	assert.Equal(t, tuple.Of2(uint64(2642244), false), tuple.Of2(ICbrt(uint64(2642245)*2642245*2642245-1)))
+
+	// Compare to big.Int
+	for _, n := range []int64{1, 7, 63, 64, 65, -64, -65, 1 << 53, 1<<53 + 1, math.MaxInt64, math.MinInt64 + 1} {
+		r, exact := ICbrt(n)
+		br, bexact := ICbrtBig(big.NewInt(n))
+		assert.Equal(t, tuple.Of2(br.Int64(), bexact), tuple.Of2(r, exact))
+	}
+}
+
+func TestICbrtBig_(t *testing.T) {
+	assert.Equal(t, tuple.Of2(big.NewInt(0), true), tuple.Of2(ICbrtBig(big.NewInt(0))))
+	assert.Equal(t, tuple.Of2(big.NewInt(1), true), tuple.Of2(ICbrtBig(big.NewInt(1))))
+	assert.Equal(t, tuple.Of2(big.NewInt(-1), false), tuple.Of2(ICbrtBig(big.NewInt(-7))))
+
+	var (
+		root = new(big.Int).Exp(big.NewInt(10), big.NewInt(40), nil)
+		cube = new(big.Int).Exp(root, big.NewInt(3), nil)
+	)
+
+	assert.Equal(t, tuple.Of2(root, true), tuple.Of2(ICbrtBig(cube)))
+	assert.Equal(
+		t,
+		tuple.Of2(new(big.Int).Sub(root, big.NewInt(1)), false),
+		tuple.Of2(ICbrtBig(new(big.Int).Sub(cube, big.NewInt(1)))),
+	)
+	assert.Equal(t, tuple.Of2(new(big.Int).Neg(root), false), tuple.Of2(ICbrtBig(new(big.Int).Neg(new(big.Int).Add(cube, big.NewInt(1))))))
+}
+
+func TestILog2_(t *testing.T) {
+	assert.Equal(t, tuple.Of3(uint(0), true, error(nil)), tuple.Of3(ILog2(1)))
+	assert.Equal(t, tuple.Of3(uint(1), false, error(nil)), tuple.Of3(ILog2(3)))
+	assert.Equal(t, tuple.Of3(uint(10), true, error(nil)), tuple.Of3(ILog2(1024)))
+	assert.Equal(t, tuple.Of3(uint(6), false, error(nil)), tuple.Of3(ILog2(int8(math.MaxInt8))))
+	assert.Equal(t, tuple.Of3(uint(63), false, error(nil)), tuple.Of3(ILog2(uint64(math.MaxUint64))))
+	assert.Equal(t, tuple.Of3(uint(0), false, fmt.Errorf(errILogNotPositiveMsg, 2, 0)), tuple.Of3(ILog2(0)))
+	assert.Equal(t, tuple.Of3(uint(0), false, fmt.Errorf(errILogNotPositiveMsg, 2, -8)), tuple.Of3(ILog2(-8)))
+
+	assert.Equal(t, tuple.Of2(uint(2), true), tuple.Of2(MustILog2(uint8(4))))
+
+	funcs.TryTo(
+		func() {
+			MustILog2(0)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errILogNotPositiveMsg, 2, 0), e)
+		},
+	)
+}
+
+func TestILog2Big_(t *testing.T) {
+	huge := new(big.Int).Lsh(big.NewInt(1), 200)
+	assert.Equal(t, tuple.Of3(uint(200), true, error(nil)), tuple.Of3(ILog2Big(huge)))
+	assert.Equal(t, tuple.Of3(uint(199), false, error(nil)), tuple.Of3(ILog2Big(new(big.Int).Sub(huge, big.NewInt(1)))))
+	assert.Equal(t, tuple.Of3(uint(200), false, error(nil)), tuple.Of3(ILog2Big(new(big.Int).Add(huge, big.NewInt(1)))))
+	assert.Equal(t, tuple.Of3(uint(0), false, fmt.Errorf(errILogNotPositiveMsg, 2, -1)), tuple.Of3(ILog2Big(big.NewInt(-1))))
+
+	assert.Equal(t, tuple.Of2(uint(0), true), tuple.Of2(MustILog2Big(big.NewInt(1))))
+
+	funcs.TryTo(
+		func() {
+			MustILog2Big(big.NewInt(0))
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errILogNotPositiveMsg, 2, 0), e)
+		},
+	)
+}
+
+func TestILog10_(t *testing.T) {
+	assert.Equal(t, tuple.Of3(uint(0), true, error(nil)), tuple.Of3(ILog10(1)))
+	assert.Equal(t, tuple.Of3(uint(0), false, error(nil)), tuple.Of3(ILog10(9)))
+	assert.Equal(t, tuple.Of3(uint(1), true, error(nil)), tuple.Of3(ILog10(10)))
+	assert.Equal(t, tuple.Of3(uint(2), false, error(nil)), tuple.Of3(ILog10(999)))
+	assert.Equal(t, tuple.Of3(uint(2), false, error(nil)), tuple.Of3(ILog10(int8(math.MaxInt8))))
+	assert.Equal(t, tuple.Of3(uint(18), false, error(nil)), tuple.Of3(ILog10(int64(math.MaxInt64))))
+	assert.Equal(t, tuple.Of3(uint(19), true, error(nil)), tuple.Of3(ILog10(uint64(10_000_000_000_000_000_000))))
+	assert.Equal(t, tuple.Of3(uint(19), false, error(nil)), tuple.Of3(ILog10(uint64(math.MaxUint64))))
+	assert.Equal(t, tuple.Of3(uint(0), false, fmt.Errorf(errILogNotPositiveMsg, 10, 0)), tuple.Of3(ILog10(0)))
+
+	// Decimal digits
+	for i, p := range powersOf10 {
+		assert.Equal(t, tuple.Of2(uint(i), true), tuple.Of2(MustILog10(p)))
+		if p > 1 {
+			assert.Equal(t, tuple.Of2(uint(i-1), false), tuple.Of2(MustILog10(p-1)))
+		}
+	}
+
+	funcs.TryTo(
+		func() {
+			MustILog10(-10)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errILogNotPositiveMsg, 10, -10), e)
+		},
+	)
+}
+
+func TestILog10Big_(t *testing.T) {
+	huge := bigPowerOf10(50)
+	assert.Equal(t, tuple.Of3(uint(50), true, error(nil)), tuple.Of3(ILog10Big(huge)))
+	assert.Equal(t, tuple.Of3(uint(49), false, error(nil)), tuple.Of3(ILog10Big(new(big.Int).Sub(huge, big.NewInt(1)))))
+	assert.Equal(t, tuple.Of3(uint(0), false, fmt.Errorf(errILogNotPositiveMsg, 10, 0)), tuple.Of3(ILog10Big(big.NewInt(0))))
+
+	assert.Equal(t, tuple.Of2(uint(1), false), tuple.Of2(MustILog10Big(big.NewInt(11))))
+
+	funcs.TryTo(
+		func() {
+			MustILog10Big(big.NewInt(-1))
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errILogNotPositiveMsg, 10, -1), e)
+		},
+	)
+}
+
+func TestPowInt_(t *testing.T) {
+	assert.Equal(t, tuple.Of2(int64(1), error(nil)), tuple.Of2(PowInt(int64(0), 0)))
+	assert.Equal(t, tuple.Of2(int64(0), error(nil)), tuple.Of2(PowInt(int64(0), 5)))
+	assert.Equal(t, tuple.Of2(int64(1024), error(nil)), tuple.Of2(PowInt(int64(2), 10)))
+	assert.Equal(t, tuple.Of2(int64(-27), error(nil)), tuple.Of2(PowInt(int64(-3), 3)))
+	assert.Equal(t, tuple.Of2(int64(81), error(nil)), tuple.Of2(PowInt(int64(-3), 4)))
+	assert.Equal(t, int64(-1), MustPowInt(int64(-1), 1_000_001))
+	assert.Equal(t, int64(1_000_000_000_000_000_000), MustPowInt(int64(10), 18))
+
+	// Boundaries
+	assert.Equal(t, tuple.Of2(int64(1<<62), error(nil)), tuple.Of2(PowInt(int64(2), 62)))
+	assert.Equal(t, tuple.Of2(int64(math.MinInt64), error(nil)), tuple.Of2(PowInt(int64(-2), 63)))
+	assert.Equal(t, tuple.Of2(int64(0), OverflowErr), tuple.Of2(PowInt(int64(2), 63)))
+	assert.Equal(t, tuple.Of2(int64(0), OverflowErr), tuple.Of2(PowInt(int64(-2), 64)))
+	assert.Equal(t, tuple.Of2(int64(0), UnderflowErr), tuple.Of2(PowInt(int64(-2), 65)))
+	assert.Equal(t, tuple.Of2(int64(0), OverflowErr), tuple.Of2(PowInt(int64(10), 19)))
+	assert.Equal(t, tuple.Of2(int64(0), UnderflowErr), tuple.Of2(PowInt(int64(-10), 19)))
+	assert.Equal(t, tuple.Of2(uint64(1<<63), error(nil)), tuple.Of2(PowInt(uint64(2), 63)))
+	assert.Equal(t, tuple.Of2(uint64(0), OverflowErr), tuple.Of2(PowInt(uint64(2), 64)))
+	assert.Equal(t, tuple.Of2(uint64(12_157_665_459_056_928_801), error(nil)), tuple.Of2(PowInt(uint64(3), 40)))
+	assert.Equal(t, tuple.Of2(uint64(0), OverflowErr), tuple.Of2(PowInt(uint64(3), 41)))
+	assert.Equal(t, tuple.Of2(int8(-128), error(nil)), tuple.Of2(PowInt(int8(-2), 7)))
+	assert.Equal(t, tuple.Of2(int8(0), OverflowErr), tuple.Of2(PowInt(int8(2), 7)))
+	assert.Equal(t, tuple.Of2(uint8(243), error(nil)), tuple.Of2(PowInt(uint8(3), 5)))
+
+	// Compare every int16 result against big.Int
+	for base := int16(-40); base <= 40; base++ {
+		for exp := uint(0); exp <= 16; exp++ {
+			var (
+				p, err = PowInt(base, exp)
+				bp     = PowIntBig(big.NewInt(int64(base)), exp)
+			)
+
+			if bp.Cmp(big.NewInt(math.MaxInt16)) > 0 {
+				assert.Equal(t, OverflowErr, err, "%d ^ %d", base, exp)
+			} else if bp.Cmp(big.NewInt(math.MinInt16)) < 0 {
+				assert.Equal(t, UnderflowErr, err, "%d ^ %d", base, exp)
+			} else {
+				assert.Equal(t, tuple.Of2(bp.Int64(), error(nil)), tuple.Of2(int64(p), err), "%d ^ %d", base, exp)
+			}
+		}
+	}
+
+	funcs.TryTo(
+		func() {
+			MustPowInt(int32(2), 31)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, OverflowErr, e)
+		},
+	)
+}
+
+func TestPowIntBig_(t *testing.T) {
+	assert.Equal(t, bigPowerOf10(40), PowIntBig(big.NewInt(10), 40))
+	assert.Equal(t, big.NewInt(-8), PowIntBig(big.NewInt(-2), 3))
+	assert.Equal(t, big.NewInt(1), PowIntBig(big.NewInt(0), 0))
+}
+
+func TestFactorial_(t *testing.T) {
+	assert.Equal(t, tuple.Of2(int64(1), error(nil)), tuple.Of2(Factorial(int64(0))))
+	assert.Equal(t, tuple.Of2(int64(1), error(nil)), tuple.Of2(Factorial(int64(1))))
+	assert.Equal(t, tuple.Of2(int64(120), error(nil)), tuple.Of2(Factorial(int64(5))))
+	assert.Equal(t, tuple.Of2(int64(2_432_902_008_176_640_000), error(nil)), tuple.Of2(Factorial(int64(20))))
+	assert.Equal(t, tuple.Of2(int64(0), OverflowErr), tuple.Of2(Factorial(int64(21))))
+	assert.Equal(t, uint64(2_432_902_008_176_640_000), MustFactorial(uint64(20)))
+	assert.Equal(t, tuple.Of2(uint64(0), OverflowErr), tuple.Of2(Factorial(uint64(21))))
+	assert.Equal(t, tuple.Of2(int8(120), error(nil)), tuple.Of2(Factorial(int8(5))))
+	assert.Equal(t, tuple.Of2(int8(0), OverflowErr), tuple.Of2(Factorial(int8(127))))
+	assert.Equal(t, tuple.Of2(uint8(0), OverflowErr), tuple.Of2(Factorial(uint8(255))))
+	assert.Equal(t, tuple.Of2(0, fmt.Errorf(errFactorialNegMsg, -1)), tuple.Of2(Factorial(-1)))
+
+	for n := uint(0); n <= 20; n++ {
+		assert.Equal(t, FactorialBig(n).Uint64(), MustFactorial(uint64(n)))
+	}
+
+	funcs.TryTo(
+		func() {
+			MustFactorial(-3)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errFactorialNegMsg, -3), e)
+		},
+	)
+}
+
+func TestFactorialBig_(t *testing.T) {
+	assert.Equal(t, big.NewInt(1), FactorialBig(0))
+	assert.Equal(t, "51090942171709440000", FactorialBig(21).String())
+	assert.Equal(t, 0, new(big.Int).Quo(FactorialBig(30), FactorialBig(29)).Cmp(big.NewInt(30)))
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/bantling/micro/constraint"
 	"github.com/bantling/micro/conv"
+	"github.com/bantling/micro/funcs"
 )
 
 // Signed integer division, for toDiv map above
@@ -569,3 +570,73 @@ func MaxCmp[T constraint.Cmp[T]](val1, val2 T) T {
 
 	return val1
 }
+
+// MinOf returns the minimum of one or more ordered values.
+// Panics if no values are provided.
+func MinOf[T constraint.Ordered](values ...T) T {
+	res := funcs.MustNonEmptySlice(values)[0]
+	for _, val := range values[1:] {
+		res = MinOrdered(res, val)
+	}
+
+	return res
+}
+
+// MinOfCmp returns the minimum of one or more comparable values.
+// Panics if no values are provided.
+func MinOfCmp[T constraint.Cmp[T]](values ...T) T {
+	res := funcs.MustNonEmptySlice(values)[0]
+	for _, val := range values[1:] {
+		res = MinCmp(res, val)
+	}
+
+	return res
+}
+
+// MaxOf returns the maximum of one or more ordered values.
+// Panics if no values are provided.
+func MaxOf[T constraint.Ordered](values ...T) T {
+	res := funcs.MustNonEmptySlice(values)[0]
+	for _, val := range values[1:] {
+		res = MaxOrdered(res, val)
+	}
+
+	return res
+}
+
+// MaxOfCmp returns the maximum of one or more comparable values.
+// Panics if no values are provided.
+func MaxOfCmp[T constraint.Cmp[T]](values ...T) T {
+	res := funcs.MustNonEmptySlice(values)[0]
+	for _, val := range values[1:] {
+		res = MaxCmp(res, val)
+	}
+
+	return res
+}
+
+// MinMaxOf returns the minimum and maximum of one or more ordered values in a single pass.
+// Panics if no values are provided.
+func MinMaxOf[T constraint.Ordered](values ...T) (min, max T) {
+	min = funcs.MustNonEmptySlice(values)[0]
+	max = min
+
+	for _, val := range values[1:] {
+		min, max = MinOrdered(min, val), MaxOrdered(max, val)
+	}
+
+	return
+}
+
+// MinMaxOfCmp returns the minimum and maximum of one or more comparable values in a single pass.
+// Panics if no values are provided.
+func MinMaxOfCmp[T constraint.Cmp[T]](values ...T) (min, max T) {
+	min = funcs.MustNonEmptySlice(values)[0]
+	max = min
+
+	for _, val := range values[1:] {
+		min, max = MinCmp(min, val), MaxCmp(max, val)
+	}
+
+	return
+}
@@ -8,6 +8,8 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/tuple"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -1216,3 +1218,61 @@ func TestMinMax_(t *testing.T) {
 		assert.Equal(t, i, MaxCmp(i, j))
 	}()
 }
+
+func TestMinMaxOf_(t *testing.T) {
+	// Ordered
+	assert.Equal(t, 3, MinOf(3))
+	assert.Equal(t, 3, MaxOf(3))
+	assert.Equal(t, tuple.Of2(3, 3), tuple.Of2(MinMaxOf(3)))
+
+	assert.Equal(t, -2, MinOf(3, -2, 5, 0))
+	assert.Equal(t, 5, MaxOf(3, -2, 5, 0))
+	assert.Equal(t, tuple.Of2(-2, 5), tuple.Of2(MinMaxOf(3, -2, 5, 0)))
+
+	assert.Equal(t, "a", MinOf("c", "a", "b"))
+	assert.Equal(t, "c", MaxOf("c", "a", "b"))
+	assert.Equal(t, tuple.Of2("a", "c"), tuple.Of2(MinMaxOf("c", "a", "b")))
+
+	// Cmp
+	assert.Equal(t, cmp(2), MinOfCmp(cmp(2)))
+	assert.Equal(t, cmp(2), MaxOfCmp(cmp(2)))
+	assert.Equal(t, tuple.Of2(cmp(2), cmp(2)), tuple.Of2(MinMaxOfCmp(cmp(2))))
+
+	assert.Equal(t, cmp(1), MinOfCmp(cmp(2), cmp(1), cmp(4)))
+	assert.Equal(t, cmp(4), MaxOfCmp(cmp(2), cmp(1), cmp(4)))
+	assert.Equal(t, tuple.Of2(cmp(1), cmp(4)), tuple.Of2(MinMaxOfCmp(cmp(2), cmp(1), cmp(4))))
+
+	b1, b2, b3 := big.NewInt(5), big.NewInt(-7), big.NewInt(9)
+	assert.Equal(t, b2, MinOfCmp(b1, b2, b3))
+	assert.Equal(t, b3, MaxOfCmp(b1, b2, b3))
+	assert.Equal(t, tuple.Of2(b2, b3), tuple.Of2(MinMaxOfCmp(b1, b2, b3)))
+
+	// Empty
+	funcs.TryTo(
+		func() {
+			MinOf[int]()
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf("The value of type []int must have at least one element"), e) },
+	)
+
+	funcs.TryTo(
+		func() {
+			MaxOfCmp[*big.Int]()
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf("The value of type []*big.Int must have at least one element"), e)
+		},
+	)
+
+	funcs.TryTo(
+		func() {
+			MinMaxOf[string]()
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf("The value of type []string must have at least one element"), e)
+		},
+	)
+}
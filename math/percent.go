@@ -0,0 +1,189 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/bantling/micro/conv"
+	"github.com/bantling/micro/funcs"
+)
+
+const (
+	// errDecimalAllocateWeightsMsg is the error message for allocating by weights that are negative or all zero
+	errDecimalAllocateWeightsMsg = "The decimal allocation of %s requires weights that are not negative and not all zero"
+)
+
+var (
+	bigHundred     = big.NewInt(100)
+	bigTenThousand = big.NewInt(10_000)
+)
+
+// scaledQuo returns the Decimal value of (d * m) / (den * 10^(d.scale + mScale)) rounded to the given scale and mode,
+// or an overflow/underflow error for the calculation described by l op r if the result exceeds 18 digits.
+// Scales > 18 are an error.
+func scaledQuo(d Decimal, m *big.Int, mScale uint, den *big.Int, scale uint, mode RoundingMode, l, op, r any) (Decimal, error) {
+	if scale > decimalMaxScale {
+		return Decimal{}, fmt.Errorf(errScaleTooLargeMsg, scale)
+	}
+
+	var (
+		num = new(big.Int).Mul(big.NewInt(d.value), m)
+		dv  = new(big.Int).Mul(den, bigPowerOf10(d.scale+mScale))
+	)
+
+	// A negative divisor has the sign moved to the dividend, so that the divisor is positive
+	if dv.Sign() < 0 {
+		num.Neg(num)
+		dv.Neg(dv)
+	}
+
+	res, ok := roundQuo(num, dv, scale, mode, d.denormalized)
+	if !ok {
+		return Decimal{}, fmt.Errorf(funcs.Ternary(num.Sign() > 0, errDecimalOverflowMsg, errDecimalUnderflowMsg), l, op, r)
+	}
+
+	return res, nil
+}
+
+// PercentOf returns pct percent of d, rounded to the given scale with the given mode.
+// The result is normalized unless d is denormalized.
+// EG, PercentOf(19.99, 15, 2, HalfUp) = 3 (2.9985 rounded), or 3.00 if 19.99 is denormalized.
+//
+// Returns an error if the scale is > 18, or the result overflows or underflows.
+func PercentOf(d, pct Decimal, scale uint, mode RoundingMode) (Decimal, error) {
+	return scaledQuo(d, big.NewInt(pct.value), pct.scale, bigHundred, scale, mode, pct, "% of", d)
+}
+
+// MustPercentOf is a must version of PercentOf
+func MustPercentOf(d, pct Decimal, scale uint, mode RoundingMode) Decimal {
+	return funcs.MustValue(PercentOf(d, pct, scale, mode))
+}
+
+// BasisPoints returns bps basis points (hundredths of a percent) of d, rounded to the given scale with the given mode.
+// The result is normalized unless d is denormalized.
+// EG, BasisPoints(1_000_000.00, 25, 2, HalfEven) = 2500, or 2500.00 if 1_000_000.00 is denormalized.
+//
+// Returns an error if the scale is > 18, or the result overflows or underflows.
+func BasisPoints(d Decimal, bps int64, scale uint, mode RoundingMode) (Decimal, error) {
+	return scaledQuo(d, big.NewInt(bps), 0, bigTenThousand, scale, mode, conv.IntToString(bps), "bps of", d)
+}
+
+// MustBasisPoints is a must version of BasisPoints
+func MustBasisPoints(d Decimal, bps int64, scale uint, mode RoundingMode) Decimal {
+	return funcs.MustValue(BasisPoints(d, bps, scale, mode))
+}
+
+// ApplyPercent returns d adjusted by pct percent, where a positive pct is a surcharge such as a tax, and a negative pct
+// is a discount. The adjustment is rounded to the given scale with the given mode before it is added, so that the
+// result minus d is exactly the adjustment that PercentOf returns, as shown on an invoice line.
+// EG, ApplyPercent(19.99, 15, 2, HalfUp) = 22.99 (19.99 + 3), ApplyPercent(19.99, -10, 2, HalfUp) = 17.99 (19.99 - 2).
+//
+// Returns an error if the scale is > 18, or the adjustment or result overflows or underflows.
+func ApplyPercent(d, pct Decimal, scale uint, mode RoundingMode) (Decimal, error) {
+	adj, err := PercentOf(d, pct, scale, mode)
+	if err != nil {
+		return Decimal{}, err
+	}
+
+	return d.Add(adj)
+}
+
+// MustApplyPercent is a must version of ApplyPercent
+func MustApplyPercent(d, pct Decimal, scale uint, mode RoundingMode) Decimal {
+	return funcs.MustValue(ApplyPercent(d, pct, scale, mode))
+}
+
+// SplitPercent splits a gross amount that includes pct percent, such as a tax inclusive price, into (net, percentage)
+// amounts at the scale of gross. The percentage amount is rounded with the given mode, and the net amount is the rest,
+// so that net + percentage = gross exactly. The results are normalized unless gross is denormalized.
+// EG, for a denormalized gross, SplitPercent(23.00, 15, HalfUp) = (20.00, 3.00), SplitPercent(10.00, 13, HalfUp) =
+// (8.85, 1.15). A normalized 10.00 is 10, which has a scale of 0, so SplitPercent(10, 13, HalfUp) = (9, 1).
+//
+// Returns a division by zero error if pct = -100, or an error if the result overflows or underflows.
+func SplitPercent(gross, pct Decimal, mode RoundingMode) (net, amt Decimal, err error) {
+	// amt = gross * pct / (100 + pct), where 100 + pct is scaled to the same scale as pct
+	den := new(big.Int).Add(new(big.Int).Mul(bigHundred, bigPowerOf10(pct.scale)), big.NewInt(pct.value))
+	if den.Sign() == 0 {
+		err = fmt.Errorf(errDecimalDivisionByZeroMsg, gross)
+		return
+	}
+
+	// The 10^pct scale of the numerator and denominator cancel out, leaving gross * pct value / den
+	if amt, err = scaledQuo(gross, big.NewInt(pct.value), 0, den, gross.scale, mode, pct, "% included in", gross); err != nil {
+		return
+	}
+
+	net, err = gross.Sub(amt)
+	return
+}
+
+// MustSplitPercent is a must version of SplitPercent
+func MustSplitPercent(gross, pct Decimal, mode RoundingMode) (Decimal, Decimal) {
+	return funcs.MustValue2(SplitPercent(gross, pct, mode))
+}
+
+// AllocateDecimal allocates total into len(weights) parts in proportion to the weights, at the scale of total, such that
+// the parts add up to total exactly. Like DivIntAdd, each part is first truncated, and the remaining units of the last
+// digit are spread across the first parts that have a non-zero weight, in the direction of the total.
+// The parts are normalized unless total is denormalized.
+// EG, for a denormalized total, AllocateDecimal(100.00, 1, 1, 1) = [33.34, 33.33, 33.33], AllocateDecimal(10.00, 70, 20,
+// 10) = [7.00, 2.00, 1.00]. A normalized 100.00 is 100, so AllocateDecimal(100, 1, 1, 1) = [34, 33, 33].
+//
+// Returns an error if there are no weights, any weight is negative, or all weights are zero.
+func AllocateDecimal(total Decimal, weights ...Decimal) ([]Decimal, error) {
+	// Scale all weights to the largest scale, so they can be compared as integers
+	var maxScale uint
+	for _, w := range weights {
+		if w.value < 0 {
+			return nil, fmt.Errorf(errDecimalAllocateWeightsMsg, total)
+		}
+
+		maxScale = MaxOrdered(maxScale, w.scale)
+	}
+
+	var (
+		ws  = make([]*big.Int, len(weights))
+		sum = new(big.Int)
+	)
+
+	for i, w := range weights {
+		ws[i] = new(big.Int).Mul(big.NewInt(w.value), bigPowerOf10(maxScale-w.scale))
+		sum.Add(sum, ws[i])
+	}
+
+	if sum.Sign() == 0 {
+		return nil, fmt.Errorf(errDecimalAllocateWeightsMsg, total)
+	}
+
+	// Each part truncated is |part| <= |total|, so it fits in an int64
+	var (
+		tv   = big.NewInt(total.value)
+		res  = make([]Decimal, len(weights))
+		rc   = total.value
+		step = int64(funcs.Ternary(total.value < 0, -1, 1))
+	)
+
+	for i, w := range ws {
+		res[i] = Decimal{scale: total.scale, value: new(big.Int).Quo(new(big.Int).Mul(tv, w), sum).Int64(), denormalized: total.denormalized}
+		rc -= res[i].value
+	}
+
+	// The remaining count is less than the number of non-zero weights, as each truncation loses less than one unit
+	for i, w := range ws {
+		if (rc != 0) && (w.Sign() != 0) {
+			res[i].value += step
+			rc -= step
+		}
+
+		res[i].applyNormalization()
+	}
+
+	return res, nil
+}
+
+// MustAllocateDecimal is a must version of AllocateDecimal
+func MustAllocateDecimal(total Decimal, weights ...Decimal) []Decimal {
+	return funcs.MustValue(AllocateDecimal(total, weights...))
+}
@@ -0,0 +1,270 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/tuple"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundingModes_(t *testing.T) {
+	// 100% of a value rounds the value to the given scale
+	var (
+		hundred = MustDecimal(100, 0, false)
+		vals    = []Decimal{
+			MustDecimal(25, 1, false),
+			MustDecimal(35, 1, false),
+			MustDecimal(-25, 1, false),
+			MustDecimal(24, 1, false),
+			MustDecimal(-26, 1, false),
+			MustDecimal(26, 1, false),
+			MustDecimal(20, 1, false),
+		}
+	)
+
+	for mode, expected := range map[RoundingMode][]int64{
+		HalfUp:   {3, 4, -3, 2, -3, 3, 2},
+		HalfEven: {2, 4, -2, 2, -3, 3, 2},
+		Floor:    {2, 3, -3, 2, -3, 2, 2},
+		Ceil:     {3, 4, -2, 3, -2, 3, 2},
+		Truncate: {2, 3, -2, 2, -2, 2, 2},
+	} {
+		for i, val := range vals {
+			assert.Equal(t, MustDecimal(expected[i], 0, false), MustPercentOf(val, hundred, 0, mode), fmt.Sprintf("mode %d, val %s", mode, val))
+		}
+	}
+}
+
+func TestPercentOf_(t *testing.T) {
+	// 15% of 19.99 = 2.9985
+	var (
+		price = MustDecimal(19_99, 2, false)
+		pct   = MustDecimal(15, 0, false)
+	)
+
+	assert.Equal(t, tuple.Of2(MustDecimal(3_00, 2, false), error(nil)), tuple.Of2(PercentOf(price, pct, 2, HalfUp)))
+	assert.Equal(t, MustDecimal(2_99, 2, false), MustPercentOf(price, pct, 2, Floor))
+	assert.Equal(t, MustDecimal(2_9985, 4, false), MustPercentOf(price, pct, 4, HalfUp))
+	assert.Equal(t, MustDecimal(2_998_500, 6, false), MustPercentOf(price, pct, 6, HalfUp))
+
+	// Fractional percentages
+	assert.Equal(t, MustDecimal(1_25, 2, false), MustPercentOf(MustDecimal(100_00, 2, false), MustDecimal(1_25, 2, false), 2, HalfUp))
+	assert.Equal(t, MustDecimal(-1_25, 2, false), MustPercentOf(MustDecimal(-100_00, 2, false), MustDecimal(1_25, 2, false), 2, HalfUp))
+
+	// Normalized values have normalized results
+	assert.Equal(t, MustDecimal(3, 0), MustPercentOf(MustDecimal(19_99, 2), pct, 2, HalfUp))
+	assert.Equal(t, "3", MustPercentOf(MustDecimal(19_99, 2), pct, 2, HalfUp).String())
+	assert.Equal(t, "3.00", MustPercentOf(price, pct, 2, HalfUp).String())
+
+	// Errors
+	var (
+		max = MustDecimal(999_999_999_999_999_999, 0)
+		two = MustDecimal(200, 0)
+	)
+
+	assert.Equal(t, tuple.Of2(Decimal{}, fmt.Errorf(errScaleTooLargeMsg, 19)), tuple.Of2(PercentOf(price, pct, 19, HalfUp)))
+	assert.Equal(t, tuple.Of2(Decimal{}, fmt.Errorf(errDecimalOverflowMsg, two, "% of", max)), tuple.Of2(PercentOf(max, two, 0, HalfUp)))
+	assert.Equal(t, tuple.Of2(Decimal{}, fmt.Errorf(errDecimalUnderflowMsg, two, "% of", max.Negate())), tuple.Of2(PercentOf(max.Negate(), two, 0, HalfUp)))
+
+	funcs.TryTo(
+		func() {
+			MustPercentOf(max, two, 0, HalfUp)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf(errDecimalOverflowMsg, two, "% of", max), e) },
+	)
+}
+
+func TestBasisPoints_(t *testing.T) {
+	// 25 bps of 1,000,000.00 = 2,500.00
+	assert.Equal(
+		t,
+		tuple.Of2(MustDecimal(2_500_00, 2, false), error(nil)),
+		tuple.Of2(BasisPoints(MustDecimal(1_000_000_00, 2, false), 25, 2, HalfEven)),
+	)
+
+	// 1 bps of 12.34 = 0.001234, banker's rounding of a half
+	assert.Equal(t, MustDecimal(1234, 6, false), MustBasisPoints(MustDecimal(12_34, 2, false), 1, 6, HalfEven))
+	assert.Equal(t, MustDecimal(0, 3, false), MustBasisPoints(MustDecimal(5_00, 2, false), 1, 3, HalfEven))
+	assert.Equal(t, MustDecimal(1, 3, false), MustBasisPoints(MustDecimal(5_00, 2, false), 1, 3, HalfUp))
+	assert.Equal(t, MustDecimal(-1, 3, false), MustBasisPoints(MustDecimal(5_00, 2, false), -1, 3, HalfUp))
+
+	// Normalized values have normalized results
+	assert.Equal(t, "2500", MustBasisPoints(MustDecimal(1_000_000_00, 2), 25, 2, HalfEven).String())
+
+	// Errors
+	max := MustDecimal(999_999_999_999_999_999, 0)
+	assert.Equal(t, tuple.Of2(Decimal{}, fmt.Errorf(errScaleTooLargeMsg, 20)), tuple.Of2(BasisPoints(max, 1, 20, HalfUp)))
+	assert.Equal(t, tuple.Of2(Decimal{}, fmt.Errorf(errDecimalOverflowMsg, "20000", "bps of", max)), tuple.Of2(BasisPoints(max, 20_000, 0, HalfUp)))
+
+	funcs.TryTo(
+		func() {
+			MustBasisPoints(max, -20_000, 0, HalfUp)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf(errDecimalUnderflowMsg, "-20000", "bps of", max), e) },
+	)
+}
+
+func TestApplyPercent_(t *testing.T) {
+	var (
+		price = MustDecimal(19_99, 2, false)
+		tax   = MustDecimal(15, 0, false)
+		disc  = MustDecimal(-10, 0, false)
+	)
+
+	// The result is the price plus the rounded adjustment
+	assert.Equal(t, tuple.Of2(MustDecimal(22_99, 2, false), error(nil)), tuple.Of2(ApplyPercent(price, tax, 2, HalfUp)))
+	assert.Equal(t, MustDecimal(17_99, 2, false), MustApplyPercent(price, disc, 2, HalfUp))
+	assert.Equal(t, MustDecimal(18_00, 2, false), MustApplyPercent(price, disc, 2, Truncate))
+
+	// Discount then tax
+	assert.Equal(t, MustDecimal(20_69, 2, false), MustApplyPercent(MustApplyPercent(price, disc, 2, HalfUp), tax, 2, HalfUp))
+
+	// Errors
+	max := MustDecimal(999_999_999_999_999_999, 0)
+	assert.Equal(t, tuple.Of2(Decimal{}, fmt.Errorf(errScaleTooLargeMsg, 19)), tuple.Of2(ApplyPercent(price, tax, 19, HalfUp)))
+	assert.Equal(t, tuple.Of2(Decimal{}, fmt.Errorf(errDecimalOverflowMsg, max, "+", MustDecimal(150_000_000_000_000_000, 0))), tuple.Of2(ApplyPercent(max, tax, 0, HalfUp)))
+
+	funcs.TryTo(
+		func() {
+			MustApplyPercent(price, tax, 19, HalfUp)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf(errScaleTooLargeMsg, 19), e) },
+	)
+}
+
+func TestSplitPercent_(t *testing.T) {
+	// Tax inclusive prices
+	assert.Equal(
+		t,
+		tuple.Of3(MustDecimal(20_00, 2, false), MustDecimal(3_00, 2, false), error(nil)),
+		tuple.Of3(SplitPercent(MustDecimal(23_00, 2, false), MustDecimal(15, 0, false), HalfUp)),
+	)
+	assert.Equal(
+		t,
+		tuple.Of2(MustDecimal(8_85, 2, false), MustDecimal(1_15, 2, false)),
+		tuple.Of2(MustSplitPercent(MustDecimal(10_00, 2, false), MustDecimal(13, 0, false), HalfUp)),
+	)
+
+	// A normalized gross has a scale of 0
+	assert.Equal(
+		t,
+		tuple.Of2(MustDecimal(9, 0), MustDecimal(1, 0)),
+		tuple.Of2(MustSplitPercent(MustDecimal(10_00, 2), MustDecimal(13, 0), HalfUp)),
+	)
+
+	// Fractional percentage: 0.09975 / 1.09975 of 1.00 = 0.0907...
+	assert.Equal(
+		t,
+		tuple.Of2(MustDecimal(91, 2, false), MustDecimal(9, 2, false)),
+		tuple.Of2(MustSplitPercent(MustDecimal(1_00, 2, false), MustDecimal(9_975, 3, false), HalfUp)),
+	)
+
+	// Parts always add up to the gross amount
+	for cents := int64(-1000); cents <= 1000; cents += 7 {
+		gross := MustDecimal(cents, 2, false)
+		for _, mode := range []RoundingMode{HalfUp, HalfEven, Floor, Ceil, Truncate} {
+			net, amt := MustSplitPercent(gross, MustDecimal(13, 0, false), mode)
+			assert.Equal(t, gross, net.MustAdd(amt))
+		}
+	}
+
+	// Errors
+	gross := MustDecimal(1_00, 2, false)
+	assert.Equal(
+		t,
+		tuple.Of3(Decimal{}, Decimal{}, fmt.Errorf(errDecimalDivisionByZeroMsg, gross)),
+		tuple.Of3(SplitPercent(gross, MustDecimal(-100_0, 1, false), HalfUp)),
+	)
+
+	var (
+		max = MustDecimal(999_999_999_999_999_999, 0)
+		pct = MustDecimal(-99_99, 2)
+	)
+	assert.Equal(
+		t,
+		tuple.Of3(Decimal{}, Decimal{}, fmt.Errorf(errDecimalUnderflowMsg, pct, "% included in", max)),
+		tuple.Of3(SplitPercent(max, pct, HalfUp)),
+	)
+
+	funcs.TryTo(
+		func() {
+			MustSplitPercent(gross, MustDecimal(-100, 0), HalfUp)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf(errDecimalDivisionByZeroMsg, gross), e) },
+	)
+}
+
+func TestAllocateDecimal_(t *testing.T) {
+	var (
+		one   = MustDecimal(1, 0, false)
+		total = MustDecimal(100_00, 2, false)
+	)
+
+	// Equal weights are like DivIntAdd
+	assert.Equal(
+		t,
+		tuple.Of2([]Decimal{MustDecimal(33_34, 2, false), MustDecimal(33_33, 2, false), MustDecimal(33_33, 2, false)}, error(nil)),
+		tuple.Of2(AllocateDecimal(total, one, one, one)),
+	)
+
+	// Negative totals spread the remainder negatively
+	assert.Equal(
+		t,
+		[]Decimal{MustDecimal(-33_34, 2, false), MustDecimal(-33_33, 2, false), MustDecimal(-33_33, 2, false)},
+		MustAllocateDecimal(total.Negate(), one, one, one),
+	)
+
+	// Weights of different scales
+	assert.Equal(
+		t,
+		[]Decimal{MustDecimal(25_00, 2, false), MustDecimal(75_00, 2, false)},
+		MustAllocateDecimal(total, MustDecimal(5, 1, false), MustDecimal(1_50, 2, false)),
+	)
+
+	// Zero weights get nothing, not even a remainder
+	assert.Equal(
+		t,
+		[]Decimal{MustDecimal(0, 2, false), MustDecimal(34, 2, false), MustDecimal(33, 2, false), MustDecimal(33, 2, false)},
+		MustAllocateDecimal(MustDecimal(1_00, 2, false), MustDecimal(0, 0, false), one, one, one),
+	)
+
+	// Normalized total
+	assert.Equal(t, []Decimal{MustDecimal(7, 0), MustDecimal(2, 0), MustDecimal(1, 0)}, MustAllocateDecimal(MustDecimal(10_00, 2), MustDecimal(70, 0), MustDecimal(20, 0), MustDecimal(10, 0)))
+	assert.Equal(t, []Decimal{MustDecimal(34, 0), MustDecimal(33, 0), MustDecimal(33, 0)}, MustAllocateDecimal(MustDecimal(100_00, 2), MustDecimal(1, 0), MustDecimal(1, 0), MustDecimal(1, 0)))
+
+	// The parts always add up to the total
+	weights := []Decimal{MustDecimal(3, 0), MustDecimal(7, 1), MustDecimal(1_13, 2), MustDecimal(0, 0), MustDecimal(9, 0)}
+	for cents := int64(-1000); cents <= 1000; cents += 13 {
+		var (
+			total = MustDecimal(cents, 2, false)
+			sum   = MustDecimal(0, 2, false)
+		)
+
+		for _, part := range MustAllocateDecimal(total, weights...) {
+			sum = sum.MustAdd(part)
+		}
+
+		assert.Equal(t, total, sum)
+	}
+
+	// Errors
+	for _, weights := range [][]Decimal{nil, {MustDecimal(0, 0)}, {one, MustDecimal(-1, 0)}} {
+		assert.Equal(t, tuple.Of2([]Decimal(nil), fmt.Errorf(errDecimalAllocateWeightsMsg, total)), tuple.Of2(AllocateDecimal(total, weights...)))
+	}
+
+	funcs.TryTo(
+		func() {
+			MustAllocateDecimal(total)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf(errDecimalAllocateWeightsMsg, total), e) },
+	)
+}
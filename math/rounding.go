@@ -0,0 +1,130 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"math/big"
+)
+
+// RoundingMode is how a result is rounded when it has more digits than the desired scale
+type RoundingMode uint
+
+const (
+	HalfUp   RoundingMode = iota // HalfUp rounds to the nearest value, and away from zero if halfway
+	HalfEven                     // HalfEven rounds to the nearest value, and to an even last digit if halfway (banker's rounding)
+	Floor                        // Floor rounds towards negative infinity
+	Ceil                         // Ceil rounds towards positive infinity
+	Truncate                     // Truncate rounds towards zero
+)
+
+var (
+	bigTen = big.NewInt(10)
+)
+
+// bigPowerOf10 returns 10^n as a *big.Int
+func bigPowerOf10(n uint) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// roundAway returns true if a truncated magnitude has to be incremented to round it with the given mode, where some
+// non-zero fraction was discarded. The value is negative if neg is true, the truncated magnitude is odd if odd is true,
+// and half is -1, 0, or 1 if the discarded fraction is less than, equal to, or greater than one half, respectively.
+func roundAway(mode RoundingMode, neg, odd bool, half int) bool {
+	switch mode {
+	case HalfUp:
+		return half >= 0
+	case HalfEven:
+		return (half > 0) || ((half == 0) && odd)
+	case Floor:
+		return neg
+	case Ceil:
+		return !neg
+	}
+
+	return false
+}
+
+// Round returns d rounded to the given scale with the given mode, such as banker's rounding with HalfEven.
+// The result has the given scale, even if it ends in zeros. If the scale is >= the scale of d, d is returned as is.
+//
+// Rounding cannot overflow, as at least one digit is removed. EG:
+//
+//	2.345 rounded to scale 2: HalfUp = 2.35, HalfEven = 2.34, Floor = 2.34, Ceil = 2.35, Truncate = 2.34
+//	-2.345 rounded to scale 2: HalfUp = -2.35, HalfEven = -2.34, Floor = -2.35, Ceil = -2.34, Truncate = -2.34
+func (d Decimal) Round(scale uint, mode RoundingMode) Decimal {
+	if scale >= d.scale {
+		return d
+	}
+
+	var (
+		neg = d.value < 0
+		mag = uint64(d.value)
+		p   = uint64(powersOf10[d.scale-scale])
+	)
+	if neg {
+		mag = uint64(-d.value)
+	}
+
+	q, rem := mag/p, mag%p
+	if (rem != 0) && roundAway(mode, neg, (q&1) == 1, CmpOrdered(2*rem, p)) {
+		q++
+	}
+
+	r := Decimal{value: int64(q), scale: scale, denormalized: d.denormalized}
+	if neg {
+		r.value = -r.value
+	}
+
+	return r
+}
+
+// roundQuo returns num / den rounded to the given scale with the given mode, where den > 0.
+// Returns false if the result has more than 18 digits.
+func roundQuo(num, den *big.Int, scale uint, mode RoundingMode, denormalized bool) (Decimal, bool) {
+	var (
+		q, r = new(big.Int).QuoRem(new(big.Int).Mul(num, bigPowerOf10(scale)), den, new(big.Int))
+		sign = r.Sign()
+	)
+
+	// The truncated quotient only needs adjusting if there is a remainder.
+	// Compare twice the magnitude of the remainder to the divisor, to see if it is more or less than halfway.
+	if (sign != 0) && roundAway(mode, sign < 0, q.Bit(0) == 1, new(big.Int).Lsh(new(big.Int).Abs(r), 1).Cmp(den)) {
+		q.Add(q, big.NewInt(int64(sign)))
+	}
+
+	// The result must fit in 18 digits
+	if !q.IsInt64() || (q.Int64() > decimalMaxValue) || (q.Int64() < decimalMinValue) {
+		return Decimal{}, false
+	}
+
+	d := Decimal{value: q.Int64(), scale: scale, denormalized: denormalized}
+	d.applyNormalization()
+
+	return d, true
+}
+
+// roundQuoDigits returns num / den rounded with the given mode to the largest scale <= maxScale that the result fits in,
+// where den > 0, so that a result < 1 can have scale 18, but a result with 10 integer digits can only have scale 8.
+// Returns false if the integer part has more than 18 digits.
+func roundQuoDigits(num, den *big.Int, maxScale uint, mode RoundingMode, denormalized bool) (Decimal, bool) {
+	var (
+		q     = new(big.Int).Quo(new(big.Int).Abs(num), den)
+		scale = MinOrdered(maxScale, decimalMaxScale)
+	)
+
+	if q.Sign() != 0 {
+		digits := uint(len(q.String()))
+		if digits > decimalMaxScale {
+			return Decimal{}, false
+		}
+
+		scale = MinOrdered(scale, decimalMaxScale-digits)
+	}
+
+	// Rounding up can carry into another integer digit, such as 9.99 rounding to 10.0, which needs one less digit of scale
+	if d, ok := roundQuo(num, den, scale, mode, denormalized); ok || (scale == 0) {
+		return d, ok
+	}
+
+	return roundQuo(num, den, scale-1, mode, denormalized)
+}
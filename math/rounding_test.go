@@ -0,0 +1,83 @@
+package math
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecimalRound_(t *testing.T) {
+	vals := []Decimal{
+		MustDecimal(2_345, 3, false),
+		MustDecimal(-2_345, 3, false),
+		MustDecimal(2_355, 3, false),
+		MustDecimal(2_3451, 4, false),
+		MustDecimal(-2_3449, 4, false),
+		MustDecimal(2_340, 3, false),
+		MustDecimal(5, 3, false),
+	}
+
+	for mode, expected := range map[RoundingMode][]int64{
+		HalfUp:   {2_35, -2_35, 2_36, 2_35, -2_34, 2_34, 1},
+		HalfEven: {2_34, -2_34, 2_36, 2_35, -2_34, 2_34, 0},
+		Floor:    {2_34, -2_35, 2_35, 2_34, -2_35, 2_34, 0},
+		Ceil:     {2_35, -2_34, 2_36, 2_35, -2_34, 2_34, 1},
+		Truncate: {2_34, -2_34, 2_35, 2_34, -2_34, 2_34, 0},
+	} {
+		for i, val := range vals {
+			assert.Equal(t, Decimal{value: expected[i], scale: 2, denormalized: true}, val.Round(2, mode), fmt.Sprintf("mode %d, val %s", mode, val))
+		}
+	}
+
+	// A larger or equal scale returns the value as is
+	d := MustDecimal(1_5, 1)
+	assert.Equal(t, d, d.Round(1, HalfEven))
+	assert.Equal(t, d, d.Round(5, HalfEven))
+
+	// Carry into a new digit
+	assert.Equal(t, Decimal{value: 1_00, scale: 2}, MustDecimal(999_999_999_999_999_999, 18).Round(2, HalfUp))
+	assert.Equal(t, MustDecimal(100_000_000_000_000_000, 0), MustDecimal(99_999_999_999_999_999_9, 1).Round(0, Ceil))
+
+	// Zero scale
+	assert.Equal(t, MustDecimal(-2, 0), MustDecimal(-2_5, 1).Round(0, HalfEven))
+	assert.Equal(t, MustDecimal(-4, 0), MustDecimal(-3_5, 1).Round(0, HalfEven))
+}
+
+func TestDecimalRoundingModeOps_(t *testing.T) {
+	// 0.000_000_005 * 0.000_000_000_5 = 0.000_000_000_000_000_002_5
+	d1, d2 := MustDecimal(5, 9), MustDecimal(5, 10)
+	assert.Equal(t, MustDecimal(3, 18), d1.MustMul(d2))
+	assert.Equal(t, MustDecimal(2, 18), d1.MustMul(d2, HalfEven))
+	assert.Equal(t, MustDecimal(2, 18), d1.MustMul(d2, Truncate))
+	assert.Equal(t, MustDecimal(-3, 18), d1.Negate().MustMul(d2, Floor))
+	assert.Equal(t, MustDecimal(-2, 18), d1.Negate().MustMul(d2, Ceil))
+
+	// 2 / 3 = 0.666_666_666_666_666_666_6...
+	d1, d2 = MustDecimal(2, 0), MustDecimal(3, 0)
+	assert.Equal(t, MustDecimal(666_666_666_666_666_667, 18), d1.MustDiv(d2))
+	assert.Equal(t, MustDecimal(666_666_666_666_666_667, 18), d1.MustDiv(d2, HalfEven))
+	assert.Equal(t, MustDecimal(666_666_666_666_666_666, 18), d1.MustDiv(d2, Floor))
+	assert.Equal(t, MustDecimal(-666_666_666_666_666_667, 18), d1.Negate().MustDiv(d2, Floor))
+	assert.Equal(t, MustDecimal(-666_666_666_666_666_666, 18), d1.Negate().MustDiv(d2, Truncate))
+
+	// 0.1 / 200_000_000_000_000_000 = 0.000_000_000_000_000_000_5 is halfway at scale 18
+	d1, d2 = MustDecimal(1, 1), MustDecimal(200_000_000_000_000_000, 0)
+	assert.Equal(t, MustDecimal(1, 18), d1.MustDiv(d2))
+	assert.Equal(t, Decimal{scale: 18}, d1.MustDiv(d2, HalfEven))
+
+	// AdjustDecimalScale rounds with the mode
+	d1, d2 = MustDecimal(2_5, 1), MustDecimal(100_000_000_000_000_000, 0)
+	MustAdjustDecimalScale(&d1, &d2, HalfEven)
+	assert.Equal(t, MustDecimal(2, 0), d1)
+
+	d1, d2 = MustDecimal(2_5, 1), MustDecimal(100_000_000_000_000_000, 0)
+	MustAdjustDecimalScale(&d1, &d2)
+	assert.Equal(t, MustDecimal(3, 0), d1)
+
+	d1, d2 = MustDecimal(100_000_000_000_000_000, 0), MustDecimal(-2_9, 1)
+	MustAdjustDecimalScale(&d1, &d2, Truncate)
+	assert.Equal(t, MustDecimal(-2, 0), d2)
+}
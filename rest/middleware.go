@@ -0,0 +1,577 @@
+package rest
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bantling/micro/encoding/json"
+	"github.com/bantling/micro/encoding/json/write"
+	"github.com/bantling/micro/io/writer"
+)
+
+const (
+	// RequestIDHeader is the header that RequestID reads and writes
+	RequestIDHeader = "X-Request-ID"
+
+	// TraceParentHeader and TraceStateHeader are the W3C Trace Context headers that TraceContext reads
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+
+	problemJSONContent = "application/problem+json"
+)
+
+var (
+	contentEncoding = "Content-Encoding"
+	contentLength   = "Content-Length"
+	vary            = "Vary"
+
+	corsOrigin           = "Origin"
+	corsRequestMethod    = "Access-Control-Request-Method"
+	corsRequestHeaders   = "Access-Control-Request-Headers"
+	corsAllowOrigin      = "Access-Control-Allow-Origin"
+	corsAllowMethods     = "Access-Control-Allow-Methods"
+	corsAllowHeaders     = "Access-Control-Allow-Headers"
+	corsAllowCredentials = "Access-Control-Allow-Credentials"
+	corsExposeHeaders    = "Access-Control-Expose-Headers"
+	corsMaxAge           = "Access-Control-Max-Age"
+
+	errCORSAnyOriginCredentials = fmt.Errorf("CORS cannot allow credentials from any origin, the allowed origins must be listed")
+
+	// an incoming request id is only trusted if it is reasonably short and only has characters safe to log
+	requestIDRegex = regexp.MustCompile("^[A-Za-z0-9._:-]{1,128}$")
+
+	// version 00 of traceparent is version-traceid-parentid-flags, where version ff is invalid
+	traceParentRegex = regexp.MustCompile("^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})(-.*)?$")
+)
+
+// contextKey is the type of keys for values this package stores in a request context
+type contextKey uint
+
+const (
+	requestIDKey contextKey = iota
+	traceParentKey
+)
+
+// Middleware wraps an http.Handler with another http.Handler that does some work before and/or after it
+type Middleware func(http.Handler) http.Handler
+
+// Chain composes zero or more middlewares into a single Middleware.
+// The first middleware is the outermost, so it sees the request first and the response last.
+// EG, Chain(Recover(), RequestID())(mux) recovers from panics that occur in RequestID or mux.
+func Chain(middlewares ...Middleware) Middleware {
+	return func(handler http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			handler = middlewares[i](handler)
+		}
+
+		return handler
+	}
+}
+
+// statusWriter is an http.ResponseWriter that tracks the status code written
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader is http.ResponseWriter method.
+// An informational 1xx status is not the status of the response, which is written later.
+func (sw *statusWriter) WriteHeader(status int) {
+	if (sw.status == 0) && (status >= http.StatusOK) {
+		sw.status = status
+	}
+
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+// Write is http.ResponseWriter method
+func (sw *statusWriter) Write(p []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+
+	return sw.ResponseWriter.Write(p)
+}
+
+// Flush is http.Flusher method, which writes a 200 status if no status has been written yet, like net/http
+func (sw *statusWriter) Flush() {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+
+	if f, isa := sw.ResponseWriter.(http.Flusher); isa {
+		f.Flush()
+	}
+}
+
+// ==== Request ID
+
+// newRequestID generates a random version 4 UUID
+func newRequestID() string {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		panic(err)
+	}
+
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+
+	str := hex.EncodeToString(id[:])
+	return str[0:8] + "-" + str[8:12] + "-" + str[12:16] + "-" + str[16:20] + "-" + str[20:32]
+}
+
+// RequestID is a Middleware that ensures every request has an id:
+//   - If the request has an X-Request-ID header of up to 128 letters, digits, dots, underscores, colons, and dashes, it is
+//     used as is, so that ids propagate across services
+//   - Otherwise an id is generated, using the optional generator if provided, else a random UUID
+//
+// The id is set in the X-Request-ID response header, and stored in the request context for RequestIDFrom.
+func RequestID(generator ...func() string) Middleware {
+	gen := newRequestID
+	if len(generator) > 0 {
+		gen = generator[0]
+	}
+
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !requestIDRegex.MatchString(id) {
+				id = gen()
+			}
+
+			w.Header().Set(RequestIDHeader, id)
+			handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+		})
+	}
+}
+
+// RequestIDFrom returns the request id stored in a context by RequestID, or an empty string if there is none
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// ==== Recover
+
+// Recover is a Middleware that recovers from a panic in the wrapped handler, and responds with a 500 Internal Server
+// Error problem+json body as described by RFC 7807. The optional onPanic function is called with the request and
+// recovered value first, which is useful for logging.
+//
+// The body only contains generic details, the recovered value is not exposed to the client. If RequestID occurs before
+// Recover, the request id is included. If the handler already started writing a response, nothing more is written.
+//
+// As with net/http, a panic of http.ErrAbortHandler is not recovered.
+func Recover(onPanic ...func(*http.Request, any)) Middleware {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := &statusWriter{ResponseWriter: w}
+
+			defer func() {
+				val := recover()
+				if val == nil {
+					return
+				}
+
+				if val == http.ErrAbortHandler {
+					panic(val)
+				}
+
+				if len(onPanic) > 0 {
+					onPanic[0](r, val)
+				}
+
+				if sw.status == 0 {
+					WriteProblem(sw, r, http.StatusInternalServerError)
+				}
+			}()
+
+			handler.ServeHTTP(sw, r)
+		})
+	}
+}
+
+// WriteProblem writes an RFC 7807 problem+json response for the given status, with the standard status text as the
+// title, and the request path as the instance. If the request context has a request id, it is included.
+func WriteProblem(w http.ResponseWriter, r *http.Request, status int) {
+	problem := map[string]any{
+		"type":     "about:blank",
+		"title":    http.StatusText(status),
+		"status":   status,
+		"instance": r.URL.Path,
+	}
+
+	if id := RequestIDFrom(r.Context()); id != "" {
+		problem["requestId"] = id
+	}
+
+	w.Header().Set(contentType, problemJSONContent)
+	w.WriteHeader(status)
+	write.Write(json.MustMapToValue(problem), writer.OfIOWriterAsRunes(w))
+}
+
+// ==== Gzip
+
+// gzipWriter is an http.ResponseWriter that compresses any response that has a body
+type gzipWriter struct {
+	http.ResponseWriter
+	r        *http.Request
+	gz       *gzip.Writer
+	wroteHdr bool
+	pending  int
+}
+
+// WriteHeader is http.ResponseWriter method.
+// Only compresses responses that can have a body, and have not already been encoded by the handler.
+// An informational 1xx status is passed on without deciding whether to compress, as the final status comes later.
+func (gw *gzipWriter) WriteHeader(status int) {
+	if gw.wroteHdr {
+		return
+	}
+
+	if (status >= 100) && (status < http.StatusOK) {
+		gw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	gw.wroteHdr = true
+
+	hdr := gw.Header()
+	hdr.Add(vary, acceptEncoding)
+
+	if (gw.r.Method != http.MethodHead) &&
+		(status != http.StatusNoContent) &&
+		(status != http.StatusNotModified) &&
+		(status >= http.StatusOK) &&
+		(hdr.Get(contentEncoding) == "") {
+		hdr.Set(contentEncoding, gzipEncoding)
+		hdr.Del(contentLength)
+		gw.gz = gzip.NewWriter(gw.ResponseWriter)
+
+		// net/http does not detect the content type of an encoded body, so wait for the first data to detect it
+		if hdr.Get(contentType) == "" {
+			gw.pending = status
+			return
+		}
+	}
+
+	gw.ResponseWriter.WriteHeader(status)
+}
+
+// writePending writes a status that is waiting for the first data, setting the content type detected from the data
+func (gw *gzipWriter) writePending(p []byte) {
+	if gw.pending != 0 {
+		gw.Header().Set(contentType, http.DetectContentType(p))
+		gw.ResponseWriter.WriteHeader(gw.pending)
+		gw.pending = 0
+	}
+}
+
+// Write is http.ResponseWriter method
+func (gw *gzipWriter) Write(p []byte) (int, error) {
+	if !gw.wroteHdr {
+		gw.WriteHeader(http.StatusOK)
+	}
+	gw.writePending(p)
+
+	if gw.gz != nil {
+		return gw.gz.Write(p)
+	}
+
+	return gw.ResponseWriter.Write(p)
+}
+
+// Flush is http.Flusher method, which writes any compressed data buffered so far before flushing the underlying writer
+func (gw *gzipWriter) Flush() {
+	if !gw.wroteHdr {
+		gw.WriteHeader(http.StatusOK)
+	}
+	gw.writePending(nil)
+
+	if gw.gz != nil {
+		gw.gz.Flush()
+	}
+
+	if f, isa := gw.ResponseWriter.(http.Flusher); isa {
+		f.Flush()
+	}
+}
+
+// acceptsGzip is true if the Accept-Encoding header lists gzip without a zero quality
+func acceptsGzip(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get(acceptEncoding), ",") {
+		parts := strings.Split(accept, ";")
+		if strings.TrimSpace(parts[0]) != gzipEncoding {
+			continue
+		}
+
+		for _, param := range parts[1:] {
+			if q := strings.TrimSpace(param); strings.HasPrefix(q, "q=") {
+				f, err := strconv.ParseFloat(q[2:], 64)
+				return (err == nil) && (f > 0)
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// Gzip is a Middleware that compresses responses with gzip when the request Accept-Encoding header accepts it.
+// Responses without a body (HEAD, 204, 304) and responses the handler already encoded are not compressed.
+func Gzip() Middleware {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !acceptsGzip(r) {
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			gw := &gzipWriter{ResponseWriter: w, r: r}
+			defer func() {
+				gw.writePending(nil)
+				if gw.gz != nil {
+					gw.gz.Close()
+				}
+			}()
+
+			handler.ServeHTTP(gw, r)
+		})
+	}
+}
+
+// ==== CORS
+
+// CORSOptions configures the CORS Middleware
+type CORSOptions struct {
+	// AllowedOrigins is the origins that may make requests, where "*" allows any origin
+	AllowedOrigins []string
+	// AllowedMethods is the methods a preflight request allows, defaulting to GET, HEAD, and POST if empty
+	AllowedMethods []string
+	// AllowedHeaders is the headers a preflight request allows, defaulting to whatever headers are requested if empty
+	AllowedHeaders []string
+	// ExposedHeaders is the response headers the client may read, in addition to the CORS safelisted headers
+	ExposedHeaders []string
+	// AllowCredentials allows cookies and authorization headers, which requires echoing the origin rather than "*".
+	// Credentials can only be allowed for listed origins, not "*".
+	AllowCredentials bool
+	// MaxAge is how long a preflight response may be cached, where zero means the header is not sent
+	MaxAge time.Duration
+}
+
+// CORS is a Middleware that implements Cross-Origin Resource Sharing:
+// - Requests without an Origin header, or from an origin that is not allowed, are passed on without CORS headers
+// - Preflight requests (OPTIONS with an Access-Control-Request-Method header) are answered with 204 No Content
+// - Other requests have the allow origin, credentials, and expose headers added, and are passed on
+//
+// Panics if AllowedOrigins contains "*" and AllowCredentials is true, as that would allow any site to make requests
+// with the credentials of the user.
+func CORS(opts CORSOptions) Middleware {
+	var (
+		anyOrigin bool
+		origins   = map[string]bool{}
+		methods   = strings.Join(opts.AllowedMethods, ", ")
+		headers   = strings.Join(opts.AllowedHeaders, ", ")
+		exposed   = strings.Join(opts.ExposedHeaders, ", ")
+		maxAge    = strconv.Itoa(int(opts.MaxAge / time.Second))
+	)
+
+	for _, origin := range opts.AllowedOrigins {
+		if origin == "*" {
+			anyOrigin = true
+		}
+
+		origins[origin] = true
+	}
+
+	if anyOrigin && opts.AllowCredentials {
+		panic(errCORSAnyOriginCredentials)
+	}
+
+	if methods == "" {
+		methods = "GET, HEAD, POST"
+	}
+
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hdr := w.Header()
+			hdr.Add(vary, corsOrigin)
+
+			origin := r.Header.Get(corsOrigin)
+			if (origin == "") || !(anyOrigin || origins[origin]) {
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			if anyOrigin {
+				hdr.Set(corsAllowOrigin, "*")
+			} else {
+				hdr.Set(corsAllowOrigin, origin)
+			}
+
+			if opts.AllowCredentials {
+				hdr.Set(corsAllowCredentials, "true")
+			}
+
+			// Preflight
+			if (r.Method == http.MethodOptions) && (r.Header.Get(corsRequestMethod) != "") {
+				hdr.Set(corsAllowMethods, methods)
+
+				if headers != "" {
+					hdr.Set(corsAllowHeaders, headers)
+				} else if requested := r.Header.Get(corsRequestHeaders); requested != "" {
+					hdr.Set(corsAllowHeaders, requested)
+				}
+
+				if opts.MaxAge > 0 {
+					hdr.Set(corsMaxAge, maxAge)
+				}
+
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			if exposed != "" {
+				hdr.Set(corsExposeHeaders, exposed)
+			}
+
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ==== Trace context
+
+// TraceParent is a parsed W3C Trace Context traceparent header
+type TraceParent struct {
+	Version  string
+	TraceID  string
+	ParentID string
+	Flags    string
+	State    string
+}
+
+// Sampled is true if the sampled flag is set
+func (tp TraceParent) Sampled() bool {
+	b, _ := hex.DecodeString(tp.Flags)
+	return (len(b) == 1) && (b[0]&0x01 == 0x01)
+}
+
+// String is the traceparent header form, without the State
+func (tp TraceParent) String() string {
+	return fmt.Sprintf("%s-%s-%s-%s", tp.Version, tp.TraceID, tp.ParentID, tp.Flags)
+}
+
+// ParseTraceParent parses traceparent and tracestate header values.
+// Returns (TraceParent, true) if the traceparent is valid, else (zero value, false).
+// Versions higher than 00 are accepted as long as the first four fields are valid, as the spec requires.
+func ParseTraceParent(traceParent, traceState string) (TraceParent, bool) {
+	parts := traceParentRegex.FindStringSubmatch(traceParent)
+	if (parts == nil) ||
+		(parts[1] == "ff") ||
+		((parts[1] == "00") && (parts[5] != "")) ||
+		(parts[2] == strings.Repeat("0", 32)) ||
+		(parts[3] == strings.Repeat("0", 16)) {
+		return TraceParent{}, false
+	}
+
+	return TraceParent{Version: parts[1], TraceID: parts[2], ParentID: parts[3], Flags: parts[4], State: traceState}, true
+}
+
+// TraceParentFrom returns the TraceParent stored in a context by TraceContext, and true if there is one
+func TraceParentFrom(ctx context.Context) (TraceParent, bool) {
+	tp, haveIt := ctx.Value(traceParentKey).(TraceParent)
+	return tp, haveIt
+}
+
+// TextMapCarrier has the same method set as the OpenTelemetry propagation.TextMapCarrier interface, so that an
+// OpenTelemetry propagator can extract from or inject into it without this package depending on OpenTelemetry.
+type TextMapCarrier interface {
+	Get(key string) string
+	Set(key, value string)
+	Keys() []string
+}
+
+// HeaderCarrier adapts http.Header to TextMapCarrier
+type HeaderCarrier http.Header
+
+// Get returns the first value of a header
+func (hc HeaderCarrier) Get(key string) string {
+	return http.Header(hc).Get(key)
+}
+
+// Set sets a header
+func (hc HeaderCarrier) Set(key, value string) {
+	http.Header(hc).Set(key, value)
+}
+
+// Keys returns the header names
+func (hc HeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// TraceHook is called by TraceContext at the start of each request, with the request context and headers.
+// It returns the context to pass to the handler, and a function to call with the response status when the handler
+// returns. EG, an OpenTelemetry hook would extract the remote span context from the carrier using a propagator, start a
+// server span, and return a function that sets the span status and ends it.
+type TraceHook func(ctx context.Context, carrier TextMapCarrier, r *http.Request) (context.Context, func(status int))
+
+// TraceContext is a Middleware that propagates W3C Trace Context:
+// - A valid traceparent header (and any tracestate header) is parsed and stored in the context for TraceParentFrom
+// - If a hook is provided, it is called before the handler, and the function it returns is called after the handler
+//
+// If the handler does not write a status, it is reported to the hook as 200.
+func TraceContext(hook ...TraceHook) Middleware {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if tp, valid := ParseTraceParent(r.Header.Get(TraceParentHeader), r.Header.Get(TraceStateHeader)); valid {
+				ctx = context.WithValue(ctx, traceParentKey, tp)
+			}
+
+			if len(hook) == 0 {
+				handler.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			var (
+				sw           = &statusWriter{ResponseWriter: w}
+				hookCtx, end = hook[0](ctx, HeaderCarrier(r.Header), r)
+			)
+
+			defer func() {
+				if end == nil {
+					return
+				}
+
+				// A panic is reported as a 500, and continues to panic so that Recover or net/http can handle it
+				if val := recover(); val != nil {
+					end(http.StatusInternalServerError)
+					panic(val)
+				}
+
+				if sw.status == 0 {
+					sw.status = http.StatusOK
+				}
+
+				end(sw.status)
+			}()
+
+			handler.ServeHTTP(sw, r.WithContext(hookCtx))
+		})
+	}
+}
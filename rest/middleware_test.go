@@ -0,0 +1,504 @@
+package rest
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	goio "io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/bantling/micro/encoding/json"
+	"github.com/bantling/micro/encoding/json/parse"
+	"github.com/bantling/micro/funcs"
+	"github.com/stretchr/testify/assert"
+)
+
+// testKey is a context key type for tests
+type testKey string
+
+const spanKey testKey = "span"
+
+// informationalRecorder is an httptest.ResponseRecorder that records informational 1xx statuses separately, as net/http
+// does, rather than as the status of the response
+type informationalRecorder struct {
+	*httptest.ResponseRecorder
+	informational []int
+}
+
+// newInformationalRecorder constructs an informationalRecorder
+func newInformationalRecorder() *informationalRecorder {
+	return &informationalRecorder{ResponseRecorder: httptest.NewRecorder()}
+}
+
+// WriteHeader is http.ResponseWriter method
+func (ir *informationalRecorder) WriteHeader(status int) {
+	if status < http.StatusOK {
+		ir.informational = append(ir.informational, status)
+		return
+	}
+
+	ir.ResponseRecorder.WriteHeader(status)
+}
+
+// textHandler writes the given text with a 200
+func textHandler(text string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(text))
+	})
+}
+
+func TestChain_(t *testing.T) {
+	var order []string
+
+	mw := func(name string) Middleware {
+		return func(handler http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name+" before")
+				handler.ServeHTTP(w, r)
+				order = append(order, name+" after")
+			})
+		}
+	}
+
+	h := Chain(mw("a"), mw("b"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, []string{"a before", "b before", "handler", "b after", "a after"}, order)
+
+	// No middlewares is the handler as is
+	recorder := httptest.NewRecorder()
+	Chain()(textHandler("x")).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "x", recorder.Body.String())
+}
+
+func TestRequestID_(t *testing.T) {
+	var id string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = RequestIDFrom(r.Context())
+	})
+
+	// Generated UUID
+	recorder := httptest.NewRecorder()
+	RequestID()(handler).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Regexp(t, regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$"), id)
+	assert.Equal(t, id, recorder.Header().Get(RequestIDHeader))
+
+	// Custom generator
+	recorder = httptest.NewRecorder()
+	RequestID(func() string { return "gen" })(handler).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "gen", id)
+	assert.Equal(t, "gen", recorder.Header().Get(RequestIDHeader))
+
+	// Propagated id
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	recorder = httptest.NewRecorder()
+	RequestID()(handler).ServeHTTP(recorder, req)
+	assert.Equal(t, "abc-123", id)
+	assert.Equal(t, "abc-123", recorder.Header().Get(RequestIDHeader))
+
+	// Unsafe id is replaced
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "abc\n123")
+	RequestID(func() string { return "gen" })(handler).ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "gen", id)
+
+	// No id
+	assert.Equal(t, "", RequestIDFrom(context.Background()))
+}
+
+func TestRecover_(t *testing.T) {
+	var (
+		recovered any
+		onPanic   = func(r *http.Request, val any) { recovered = val }
+		panicker  = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+	)
+
+	// Panic with request id
+	recorder := httptest.NewRecorder()
+	Chain(RequestID(func() string { return "id1" }), Recover(onPanic))(panicker).
+		ServeHTTP(recorder, httptest.NewRequest("GET", "/foo", nil))
+	assert.Equal(t, "boom", recovered)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, problemJSONContent, recorder.Header().Get(contentType))
+	assert.Equal(
+		t,
+		json.MustMapToValue(map[string]any{
+			"type":      "about:blank",
+			"title":     "Internal Server Error",
+			"status":    500,
+			"instance":  "/foo",
+			"requestId": "id1",
+		}),
+		parse.MustParse(recorder.Body),
+	)
+
+	// Panic without request id or onPanic
+	recorder = httptest.NewRecorder()
+	Recover()(panicker).ServeHTTP(recorder, httptest.NewRequest("GET", "/foo", nil))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(
+		t,
+		json.MustMapToValue(map[string]any{"type": "about:blank", "title": "Internal Server Error", "status": 500, "instance": "/foo"}),
+		parse.MustParse(recorder.Body),
+	)
+
+	// Panic after response started is not written over
+	recorder = httptest.NewRecorder()
+	Recover()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("boom")
+	})).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "partial", recorder.Body.String())
+
+	// An informational status is not the start of the response
+	irecorder := newInformationalRecorder()
+	Recover()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusEarlyHints)
+		panic("boom")
+	})).ServeHTTP(irecorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, []int{http.StatusEarlyHints}, irecorder.informational)
+	assert.Equal(t, http.StatusInternalServerError, irecorder.Code)
+
+	// Flushing starts the response
+	recorder = httptest.NewRecorder()
+	Recover()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		panic("boom")
+	})).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.True(t, recorder.Flushed)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, 0, recorder.Body.Len())
+
+	// No panic
+	recorder = httptest.NewRecorder()
+	Recover()(textHandler("ok")).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "ok", recorder.Body.String())
+
+	// ErrAbortHandler is not recovered
+	funcs.TryTo(
+		func() {
+			Recover()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic(http.ErrAbortHandler) })).
+				ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, http.ErrAbortHandler, e) },
+	)
+}
+
+func TestGzip_(t *testing.T) {
+	gunzip := func(body goio.Reader) string {
+		return string(funcs.MustValue(goio.ReadAll(funcs.MustValue(gzip.NewReader(body)))))
+	}
+
+	for _, accept := range []string{"gzip", "deflate, gzip", "gzip;q=0.5"} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(acceptEncoding, accept)
+		recorder := httptest.NewRecorder()
+		Gzip()(textHandler("hello")).ServeHTTP(recorder, req)
+		assert.Equal(t, gzipEncoding, recorder.Header().Get(contentEncoding))
+		assert.Equal(t, acceptEncoding, recorder.Header().Get(vary))
+		assert.Equal(t, "hello", gunzip(recorder.Body))
+	}
+
+	// Not accepted
+	for _, accept := range []string{"", "deflate", "gzip;q=0"} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(acceptEncoding, accept)
+		recorder := httptest.NewRecorder()
+		Gzip()(textHandler("hello")).ServeHTTP(recorder, req)
+		assert.Equal(t, "", recorder.Header().Get(contentEncoding))
+		assert.Equal(t, "hello", recorder.Body.String())
+	}
+
+	// No body
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(acceptEncoding, gzipEncoding)
+	recorder := httptest.NewRecorder()
+	Gzip()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})).ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "", recorder.Header().Get(contentEncoding))
+	assert.Equal(t, 0, recorder.Body.Len())
+
+	// Already encoded by handler
+	recorder = httptest.NewRecorder()
+	Gzip()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentEncoding, "br")
+		w.Write([]byte("raw"))
+	})).ServeHTTP(recorder, req)
+	assert.Equal(t, "br", recorder.Header().Get(contentEncoding))
+	assert.Equal(t, "raw", recorder.Body.String())
+
+	// Explicit status with body
+	recorder = httptest.NewRecorder()
+	Gzip()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentLength, "7")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})).ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, "", recorder.Header().Get(contentLength))
+	assert.Equal(t, "created", gunzip(recorder.Body))
+
+	// The content type is detected from the uncompressed body, as net/http does not detect it for an encoded body
+	for _, status := range []int{0, http.StatusCreated} {
+		recorder = httptest.NewRecorder()
+		Gzip()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if status != 0 {
+				w.WriteHeader(status)
+			}
+			w.Write([]byte("<html><body>hi</body></html>"))
+		})).ServeHTTP(recorder, req)
+		assert.Equal(t, funcs.Ternary(status == 0, http.StatusOK, status), recorder.Code)
+		assert.Equal(t, "text/html; charset=utf-8", recorder.Header().Get(contentType))
+		assert.Equal(t, "<html><body>hi</body></html>", gunzip(recorder.Body))
+	}
+
+	// A content type set by the handler is kept
+	recorder = httptest.NewRecorder()
+	Gzip()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentType, jsonContent)
+		w.Write([]byte("<html>"))
+	})).ServeHTTP(recorder, req)
+	assert.Equal(t, jsonContent, recorder.Header().Get(contentType))
+	assert.Equal(t, "<html>", gunzip(recorder.Body))
+
+	// A status with no body is still written
+	recorder = httptest.NewRecorder()
+	Gzip()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})).ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	assert.Equal(t, "", gunzip(recorder.Body))
+
+	// An informational status does not decide the final status or encoding
+	irecorder := newInformationalRecorder()
+	Gzip()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusEarlyHints)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("accepted"))
+	})).ServeHTTP(irecorder, req)
+	assert.Equal(t, []int{http.StatusEarlyHints}, irecorder.informational)
+	assert.Equal(t, http.StatusAccepted, irecorder.Code)
+	assert.Equal(t, gzipEncoding, irecorder.Header().Get(contentEncoding))
+	assert.Equal(t, "accepted", gunzip(irecorder.Body))
+
+	// Flushing writes the compressed data so far
+	recorder = httptest.NewRecorder()
+	Gzip()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("part"))
+		w.(http.Flusher).Flush()
+		assert.True(t, recorder.Flushed)
+
+		part := make([]byte, 4)
+		funcs.MustValue(goio.ReadFull(funcs.MustValue(gzip.NewReader(bytes.NewReader(recorder.Body.Bytes()))), part))
+		assert.Equal(t, "part", string(part))
+	})).ServeHTTP(recorder, req)
+}
+
+func TestCORS_(t *testing.T) {
+	var (
+		called  bool
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+		serve   = func(opts CORSOptions, req *http.Request) *httptest.ResponseRecorder {
+			called = false
+			recorder := httptest.NewRecorder()
+			CORS(opts)(handler).ServeHTTP(recorder, req)
+			return recorder
+		}
+		request = func(method, origin string, hdrs ...string) *http.Request {
+			req := httptest.NewRequest(method, "/", nil)
+			if origin != "" {
+				req.Header.Set(corsOrigin, origin)
+			}
+
+			for i := 0; i < len(hdrs); i += 2 {
+				req.Header.Set(hdrs[i], hdrs[i+1])
+			}
+
+			return req
+		}
+		opts = CORSOptions{
+			AllowedOrigins: []string{"https://a.com"},
+			AllowedMethods: []string{"GET", "PUT"},
+			ExposedHeaders: []string{RequestIDHeader},
+			MaxAge:         time.Hour,
+		}
+	)
+
+	// No origin
+	recorder := serve(opts, request("GET", ""))
+	assert.True(t, called)
+	assert.Equal(t, "", recorder.Header().Get(corsAllowOrigin))
+	assert.Equal(t, corsOrigin, recorder.Header().Get(vary))
+
+	// Origin not allowed
+	recorder = serve(opts, request("GET", "https://b.com"))
+	assert.True(t, called)
+	assert.Equal(t, "", recorder.Header().Get(corsAllowOrigin))
+
+	// Allowed origin
+	recorder = serve(opts, request("GET", "https://a.com"))
+	assert.True(t, called)
+	assert.Equal(t, "https://a.com", recorder.Header().Get(corsAllowOrigin))
+	assert.Equal(t, RequestIDHeader, recorder.Header().Get(corsExposeHeaders))
+	assert.Equal(t, "", recorder.Header().Get(corsAllowCredentials))
+
+	// Preflight echoes requested headers
+	recorder = serve(opts, request("OPTIONS", "https://a.com", corsRequestMethod, "PUT", corsRequestHeaders, "X-Foo"))
+	assert.False(t, called)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "https://a.com", recorder.Header().Get(corsAllowOrigin))
+	assert.Equal(t, "GET, PUT", recorder.Header().Get(corsAllowMethods))
+	assert.Equal(t, "X-Foo", recorder.Header().Get(corsAllowHeaders))
+	assert.Equal(t, "3600", recorder.Header().Get(corsMaxAge))
+
+	// OPTIONS that is not a preflight
+	serve(opts, request("OPTIONS", "https://a.com"))
+	assert.True(t, called)
+
+	// Any origin, default methods, configured headers, no max age
+	opts = CORSOptions{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"X-Bar"}}
+	recorder = serve(opts, request("OPTIONS", "https://b.com", corsRequestMethod, "POST", corsRequestHeaders, "X-Foo"))
+	assert.Equal(t, "*", recorder.Header().Get(corsAllowOrigin))
+	assert.Equal(t, "GET, HEAD, POST", recorder.Header().Get(corsAllowMethods))
+	assert.Equal(t, "X-Bar", recorder.Header().Get(corsAllowHeaders))
+	assert.Equal(t, "", recorder.Header().Get(corsMaxAge))
+
+	// Listed origins with credentials echo the origin
+	opts = CORSOptions{AllowedOrigins: []string{"https://a.com", "https://b.com"}, AllowCredentials: true}
+	recorder = serve(opts, request("GET", "https://b.com"))
+	assert.True(t, called)
+	assert.Equal(t, "https://b.com", recorder.Header().Get(corsAllowOrigin))
+	assert.Equal(t, "true", recorder.Header().Get(corsAllowCredentials))
+
+	// Any origin with credentials is not allowed
+	funcs.TryTo(
+		func() {
+			CORS(CORSOptions{AllowedOrigins: []string{"https://a.com", "*"}, AllowCredentials: true})
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, errCORSAnyOriginCredentials, e)
+		},
+	)
+}
+
+func TestParseTraceParent_(t *testing.T) {
+	valid := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tp, ok := ParseTraceParent(valid, "congo=t61rcWkgMzE")
+	assert.True(t, ok)
+	assert.Equal(
+		t,
+		TraceParent{Version: "00", TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", ParentID: "00f067aa0ba902b7", Flags: "01", State: "congo=t61rcWkgMzE"},
+		tp,
+	)
+	assert.True(t, tp.Sampled())
+	assert.Equal(t, valid, tp.String())
+
+	tp, ok = ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "")
+	assert.True(t, ok)
+	assert.False(t, tp.Sampled())
+
+	// Future versions may have more fields
+	_, ok = ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-more", "")
+	assert.True(t, ok)
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-more",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+	} {
+		_, ok = ParseTraceParent(invalid, "")
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestTraceContext_(t *testing.T) {
+	var (
+		tp      TraceParent
+		haveTP  bool
+		hookVal any
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tp, haveTP = TraceParentFrom(r.Context())
+			hookVal = r.Context().Value(spanKey)
+		})
+		valid = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	)
+
+	// No hook
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(TraceParentHeader, valid)
+	req.Header.Set(TraceStateHeader, "a=b")
+	TraceContext()(handler).ServeHTTP(httptest.NewRecorder(), req)
+	assert.True(t, haveTP)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tp.TraceID)
+	assert.Equal(t, "a=b", tp.State)
+
+	// Invalid header
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(TraceParentHeader, "bad")
+	TraceContext()(handler).ServeHTTP(httptest.NewRecorder(), req)
+	assert.False(t, haveTP)
+
+	// Hook sees carrier, can replace context, and gets status
+	var (
+		carried string
+		keys    []string
+		status  int
+		hook    = func(ctx context.Context, carrier TextMapCarrier, r *http.Request) (context.Context, func(int)) {
+			carried, keys = carrier.Get(TraceParentHeader), carrier.Keys()
+			carrier.Set("X-Injected", "yes")
+			return context.WithValue(ctx, spanKey, "s1"), func(s int) { status = s }
+		}
+	)
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(TraceParentHeader, valid)
+	TraceContext(hook)(handler).ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, valid, carried)
+	assert.Equal(t, []string{"Traceparent"}, keys)
+	assert.Equal(t, "yes", req.Header.Get("X-Injected"))
+	assert.True(t, haveTP)
+	assert.Equal(t, "s1", hookVal)
+	assert.Equal(t, http.StatusOK, status)
+
+	// Status written by handler
+	TraceContext(hook)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusTeapot, status)
+
+	// Panic is reported as a 500, and can be recovered by an outer Recover
+	recorder := httptest.NewRecorder()
+	Chain(Recover(), TraceContext(hook))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+	// Hook that returns no end function
+	TraceContext(func(ctx context.Context, _ TextMapCarrier, _ *http.Request) (context.Context, func(int)) {
+		return ctx, nil
+	})(textHandler("ok")).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	// HeaderCarrier
+	hc := HeaderCarrier(http.Header{})
+	hc.Set("a", "1")
+	assert.Equal(t, "1", hc.Get("A"))
+	assert.Equal(t, []string{"A"}, hc.Keys())
+}
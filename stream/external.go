@@ -0,0 +1,620 @@
+package stream
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/gob"
+	gojson "encoding/json"
+	"fmt"
+	"hash/fnv"
+	goio "io"
+	"os"
+	"sort"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/iter"
+	"github.com/bantling/micro/tuple"
+)
+
+const (
+	// defaultSpillBudget is the default SpillInfo.Budget
+	defaultSpillBudget = 100_000
+
+	// spillFilePattern is the pattern for temporary file names
+	spillFilePattern = "micro-stream-spill-*"
+)
+
+// Codec serializes values of type T to and from temporary files, for transforms that spill to disk.
+// - Encoder is given a file to write, and returns a function that writes one value at a time
+// - Decoder is given a file to read, and returns an iterating function that returns (zero value, iter.EOI) at the end
+type Codec[T any] struct {
+	Encoder func(goio.Writer) func(T) error
+	Decoder func(goio.Reader) func() (T, error)
+}
+
+// JSONCodec returns a Codec that writes one JSON document per value with the standard library encoding/json, which
+// works for any type that round trips through json.Marshal and json.Unmarshal. This is the default Codec for
+// SpillInfo. The encoding/json package of this module cannot be used, as its parser depends on this package.
+func JSONCodec[T any]() Codec[T] {
+	return Codec[T]{
+		Encoder: func(w goio.Writer) func(T) error {
+			enc := gojson.NewEncoder(w)
+			return func(val T) error {
+				return enc.Encode(val)
+			}
+		},
+		Decoder: func(r goio.Reader) func() (T, error) {
+			dec := gojson.NewDecoder(r)
+			return func() (T, error) {
+				var val T
+				if err := dec.Decode(&val); err != nil {
+					var zv T
+					if err == goio.EOF {
+						return zv, iter.EOI
+					}
+
+					return zv, err
+				}
+
+				return val, nil
+			}
+		},
+	}
+}
+
+// GobCodec returns a Codec that uses encoding/gob, which works for any type gob can encode.
+// It is more compact than JSONCodec, and handles values JSON cannot, such as a NaN float.
+func GobCodec[T any]() Codec[T] {
+	return Codec[T]{
+		Encoder: func(w goio.Writer) func(T) error {
+			enc := gob.NewEncoder(w)
+			return func(val T) error {
+				return enc.Encode(val)
+			}
+		},
+		Decoder: func(r goio.Reader) func() (T, error) {
+			dec := gob.NewDecoder(r)
+			return func() (T, error) {
+				var val T
+				if err := dec.Decode(&val); err != nil {
+					var zv T
+					if err == goio.EOF {
+						return zv, iter.EOI
+					}
+
+					return zv, err
+				}
+
+				return val, nil
+			}
+		},
+	}
+}
+
+// SpillInfo configures the transforms that spill to disk when their input is too large to hold in memory.
+// The zero value is ready to use.
+type SpillInfo[T any] struct {
+	// Codec serializes values, defaulting to JSONCodec if both functions are nil
+	Codec Codec[T]
+	// Budget is how much memory may be used before spilling, in units of Size, defaulting to 100_000
+	Budget uint
+	// Size is the memory used by a value, in whatever unit Budget uses, defaulting to 1 per value
+	Size func(T) uint
+	// Dir is the directory for temporary files, defaulting to os.TempDir
+	Dir string
+}
+
+// spillInfo returns the optional SpillInfo with defaults applied
+func spillInfo[T any](info []SpillInfo[T]) SpillInfo[T] {
+	var inf SpillInfo[T]
+	if len(info) > 0 {
+		inf = info[0]
+	}
+
+	if (inf.Codec.Encoder == nil) && (inf.Codec.Decoder == nil) {
+		inf.Codec = JSONCodec[T]()
+	}
+
+	if inf.Budget == 0 {
+		inf.Budget = defaultSpillBudget
+	}
+
+	if inf.Size == nil {
+		inf.Size = func(T) uint { return 1 }
+	}
+
+	return inf
+}
+
+// spillFile is a temporary file of values
+type spillFile struct {
+	file *os.File
+	buf  *bufio.Writer
+}
+
+// writeSpillFile writes values into a new temporary file
+func writeSpillFile[T any](inf SpillInfo[T], vals []T) (sf spillFile, err error) {
+	if sf.file, err = os.CreateTemp(inf.Dir, spillFilePattern); err != nil {
+		return
+	}
+
+	sf.buf = bufio.NewWriter(sf.file)
+	enc := inf.Codec.Encoder(sf.buf)
+
+	for _, val := range vals {
+		if err = enc(val); err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		err = sf.buf.Flush()
+	}
+
+	if err != nil {
+		sf.remove()
+	}
+
+	return
+}
+
+// readSpillFile rewinds the file, and returns an iterating function that reads it
+func readSpillFile[T any](inf SpillInfo[T], sf spillFile) (func() (T, error), error) {
+	if _, err := sf.file.Seek(0, goio.SeekStart); err != nil {
+		return nil, err
+	}
+
+	return inf.Codec.Decoder(bufio.NewReader(sf.file)), nil
+}
+
+// remove closes and removes the file, returning the first error
+func (sf spillFile) remove() error {
+	err := sf.file.Close()
+	if e := os.Remove(sf.file.Name()); err == nil {
+		err = e
+	}
+
+	return err
+}
+
+// removeSpillFiles removes all the files, returning the first error
+func removeSpillFiles(files []spillFile) (err error) {
+	for _, sf := range files {
+		if e := sf.remove(); (e != nil) && (err == nil) {
+			err = e
+		}
+	}
+
+	return
+}
+
+// mergeHead is the next value of a sorted run being merged
+type mergeHead[T any] struct {
+	val  T
+	run  int
+	next func() (T, error)
+}
+
+// mergeHeap is a heap of the next value of each sorted run, ordered by value, then by run for stability
+type mergeHeap[T any] struct {
+	heads []mergeHead[T]
+	less  func(T, T) bool
+}
+
+func (h *mergeHeap[T]) Len() int { return len(h.heads) }
+
+func (h *mergeHeap[T]) Less(i, j int) bool {
+	a, b := h.heads[i], h.heads[j]
+	if h.less(a.val, b.val) {
+		return true
+	}
+
+	return (!h.less(b.val, a.val)) && (a.run < b.run)
+}
+
+func (h *mergeHeap[T]) Swap(i, j int) { h.heads[i], h.heads[j] = h.heads[j], h.heads[i] }
+
+func (h *mergeHeap[T]) Push(x any) { h.heads = append(h.heads, x.(mergeHead[T])) }
+
+func (h *mergeHeap[T]) Pop() any {
+	last := h.heads[len(h.heads)-1]
+	h.heads = h.heads[:len(h.heads)-1]
+	return last
+}
+
+// ExternalSort sorts an Iter[T] that may be too large to hold in memory, using the given less function.
+//
+// Values are read into memory until the SpillInfo budget is reached, then sorted and written to a temporary file as a
+// sorted run. Once all values are read, the runs are merged as the result is iterated, keeping only one value of each
+// run in memory. If all the values fit in the budget, no files are created, and the result is the same as SortBy.
+//
+// The sort is stable. The temporary files are removed when the result returns EOI or an error. If iteration of the
+// result is abandoned before that, the files remain until removed by the caller (see SpillInfo.Dir).
+//
+// See Distinct for an explanation of statefulness and the usage of Generator.
+func ExternalSort[T any](less func(T, T) bool, info ...SpillInfo[T]) func(iter.Iter[T]) iter.Iter[T] {
+	inf := spillInfo(info)
+
+	return Generator(func() func(iter.Iter[T]) iter.Iter[T] {
+		return func(it iter.Iter[T]) iter.Iter[T] {
+			var (
+				started bool
+				mem     iter.Iter[T]
+				files   []spillFile
+				merge   = &mergeHeap[T]{less: less}
+				readErr error
+				done    error
+			)
+
+			// fail removes all files, and returns the given error for this and all further calls.
+			// If the files cannot be removed at EOI, the removal error is returned instead.
+			fail := func(err error) (T, error) {
+				if e := removeSpillFiles(files); (e != nil) && (err == iter.EOI) {
+					err = e
+				}
+
+				files, done = nil, err
+				var zv T
+				return zv, err
+			}
+
+			// start reads all the input, spilling sorted runs as needed, and prepares for merging
+			start := func() error {
+				var (
+					buf  []T
+					used uint
+				)
+
+				for {
+					val, err := it.Next()
+					if err == iter.EOI {
+						break
+					}
+
+					if err != nil {
+						return err
+					}
+
+					if size := inf.Size(val); (len(buf) > 0) && (used+size > inf.Budget) {
+						sort.SliceStable(buf, func(i, j int) bool { return less(buf[i], buf[j]) })
+
+						sf, err := writeSpillFile(inf, buf)
+						if err != nil {
+							return err
+						}
+
+						files, buf, used = append(files, sf), buf[:0], 0
+					}
+
+					buf, used = append(buf, val), used+inf.Size(val)
+				}
+
+				sort.SliceStable(buf, func(i, j int) bool { return less(buf[i], buf[j]) })
+
+				// Everything fit in memory
+				if len(files) == 0 {
+					mem = iter.OfSlice(buf)
+					return nil
+				}
+
+				// Spill the last run too, so that all runs are merged the same way
+				if len(buf) > 0 {
+					sf, err := writeSpillFile(inf, buf)
+					if err != nil {
+						return err
+					}
+
+					files = append(files, sf)
+				}
+
+				for run, sf := range files {
+					next, err := readSpillFile(inf, sf)
+					if err != nil {
+						return err
+					}
+
+					val, err := next()
+					if err != nil {
+						if err == iter.EOI {
+							continue
+						}
+
+						return err
+					}
+
+					merge.heads = append(merge.heads, mergeHead[T]{val: val, run: run, next: next})
+				}
+
+				heap.Init(merge)
+				return nil
+			}
+
+			return iter.OfIter(func() (T, error) {
+				if done != nil {
+					var zv T
+					return zv, done
+				}
+
+				if !started {
+					started = true
+					if err := start(); err != nil {
+						return fail(err)
+					}
+				}
+
+				if mem != nil {
+					return mem.Next()
+				}
+
+				if merge.Len() == 0 {
+					return fail(funcs.Ternary(readErr == nil, iter.EOI, readErr))
+				}
+
+				// Return the smallest head, and replace it with the next value of the same run.
+				// If the next value cannot be read, stop merging, and return the error on the next call.
+				head := merge.heads[0]
+				val, err := head.next()
+
+				switch {
+				case err == nil:
+					merge.heads[0].val = val
+					heap.Fix(merge, 0)
+				case err == iter.EOI:
+					heap.Pop(merge)
+				default:
+					merge.heads, readErr = nil, err
+				}
+
+				return head.val, nil
+			})
+		}
+	})
+}
+
+const (
+	// defaultGroupPartitions is the default GroupInfo.Partitions
+	defaultGroupPartitions = 16
+
+	// maxGroupLevel is how many times a partition can be partitioned again before ignoring the budget
+	maxGroupLevel = 8
+)
+
+// GroupInfo configures ExternalGroupBy. The zero value is ready to use.
+type GroupInfo[T any, K comparable] struct {
+	// SpillInfo configures spilling, where Budget limits the groups in memory, and each group costs the Size of the
+	// first value with its key
+	SpillInfo[T]
+	// Partitions is how many files values are spilled into, defaulting to 16
+	Partitions uint
+	// Hash hashes keys to choose a partition, defaulting to a hash of the %#v formatting of the key
+	Hash func(K) uint64
+}
+
+// groupInfo returns the optional GroupInfo with defaults applied
+func groupInfo[T any, K comparable](info []GroupInfo[T, K]) GroupInfo[T, K] {
+	var inf GroupInfo[T, K]
+	if len(info) > 0 {
+		inf = info[0]
+	}
+
+	inf.SpillInfo = spillInfo([]SpillInfo[T]{inf.SpillInfo})
+
+	if inf.Partitions == 0 {
+		inf.Partitions = defaultGroupPartitions
+	}
+
+	if inf.Hash == nil {
+		inf.Hash = defaultHash[K]
+	}
+
+	return inf
+}
+
+// defaultHash is a hash of the %#v formatting of a value
+func defaultHash[K any](key K) uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%#v", key)
+	return h.Sum64()
+}
+
+// mix64 is the splitmix64 finalizer, which spreads the bits of a hash so that every output bit depends on every input bit
+func mix64(hash uint64) uint64 {
+	hash = (hash ^ (hash >> 30)) * 0xbf58476d1ce4e5b9
+	hash = (hash ^ (hash >> 27)) * 0x94d049bb133111eb
+	return hash ^ (hash >> 31)
+}
+
+// partitionOf returns the partition of a hash at a given level, remixing the hash for each level so that the values
+// of one partition are spread across all partitions of the next level
+func partitionOf(hash uint64, level, partitions uint) uint {
+	return uint(mix64(hash+uint64(level)*0x9e3779b97f4a7c15) % uint64(partitions))
+}
+
+// groupFile is a partition file to be grouped, and the level it was partitioned at
+type groupFile struct {
+	spillFile
+	level uint
+}
+
+// ExternalGroupBy groups an Iter[T] that may have too many groups to hold in memory, by the given key function, and
+// reduces the values of each group with the given reducer, which is first called with the zero value of U.
+//
+// Values are grouped in memory until the GroupInfo budget is reached. After that, values of groups already in memory
+// are still reduced, but values of other groups are hash partitioned by key into temporary files. Once all values are
+// read, the groups in memory are returned, then each partition file is grouped the same way, one at a time. A
+// partition with too many groups is partitioned again, up to a limit, after which its groups are all held in memory.
+//
+// The groups of each pass are returned in the order their keys first occur, but the order across passes depends on
+// the hash of the keys. If all the groups fit in the budget, no files are created, and all groups are returned in the
+// order their keys first occur.
+//
+// Spilled values are serialized with the SpillInfo Codec, which is JSONCodec by default, so T must round trip through
+// that Codec. Use GobCodec for types that JSON cannot encode.
+//
+// The temporary files are removed as they are grouped, or when the result returns an error. If iteration of the result
+// is abandoned before EOI, the remaining files remain until removed by the caller (see SpillInfo.Dir).
+//
+// See Distinct for an explanation of statefulness and the usage of Generator.
+func ExternalGroupBy[T any, K comparable, U any](
+	key func(T) K,
+	reducer func(U, T) U,
+	info ...GroupInfo[T, K],
+) func(iter.Iter[T]) iter.Iter[tuple.Two[K, U]] {
+	inf := groupInfo(info)
+
+	return Generator(func() func(iter.Iter[T]) iter.Iter[tuple.Two[K, U]] {
+		return func(it iter.Iter[T]) iter.Iter[tuple.Two[K, U]] {
+			var (
+				started bool
+				groups  []tuple.Two[K, U]
+				pending []groupFile
+				done    error
+			)
+
+			// fail removes all pending files, and returns the given error for this and all further calls.
+			// If the files cannot be removed at EOI, the removal error is returned instead.
+			fail := func(err error) (tuple.Two[K, U], error) {
+				for _, gf := range pending {
+					if e := gf.remove(); (e != nil) && (err == iter.EOI) {
+						err = e
+					}
+				}
+
+				pending, done = nil, err
+				var zv tuple.Two[K, U]
+				return zv, err
+			}
+
+			// group reduces all values of next into groups, spilling values of groups that do not fit into partitions
+			// at the next level, which are added to the front of pending
+			group := func(next func() (T, error), level uint) error {
+				var (
+					indexes = map[K]int{}
+					used    uint
+					spill   = level < maxGroupLevel
+					parts   = make([]*spillFile, inf.Partitions)
+					encs    = make([]func(T) error, inf.Partitions)
+					files   []groupFile
+				)
+
+				groups = groups[:0]
+
+				// Remove partitions of this level on failure
+				result := func(err error) error {
+					if err != nil {
+						for _, sf := range parts {
+							if sf != nil {
+								sf.remove()
+							}
+						}
+
+						return err
+					}
+
+					for _, sf := range parts {
+						if sf != nil {
+							files = append(files, groupFile{*sf, level + 1})
+						}
+					}
+
+					pending = append(files, pending...)
+					return nil
+				}
+
+				for {
+					val, err := next()
+					if err == iter.EOI {
+						break
+					}
+
+					if err != nil {
+						return result(err)
+					}
+
+					k := key(val)
+					if idx, haveIt := indexes[k]; haveIt {
+						groups[idx].U = reducer(groups[idx].U, val)
+						continue
+					}
+
+					// A new group that fits in memory
+					if size := inf.Size(val); (!spill) || (len(groups) == 0) || (used+size <= inf.Budget) {
+						var zv U
+						indexes[k], used = len(groups), used+size
+						groups = append(groups, tuple.Of2(k, reducer(zv, val)))
+						continue
+					}
+
+					// A new group that does not fit in memory is spilled into a partition
+					p := partitionOf(inf.Hash(k), level, inf.Partitions)
+					if parts[p] == nil {
+						sf, err := os.CreateTemp(inf.Dir, spillFilePattern)
+						if err != nil {
+							return result(err)
+						}
+
+						parts[p] = &spillFile{file: sf, buf: bufio.NewWriter(sf)}
+						encs[p] = inf.Codec.Encoder(parts[p].buf)
+					}
+
+					if err := encs[p](val); err != nil {
+						return result(err)
+					}
+				}
+
+				for _, sf := range parts {
+					if sf != nil {
+						if err := sf.buf.Flush(); err != nil {
+							return result(err)
+						}
+					}
+				}
+
+				return result(nil)
+			}
+
+			return iter.OfIter(func() (tuple.Two[K, U], error) {
+				if done != nil {
+					var zv tuple.Two[K, U]
+					return zv, done
+				}
+
+				if !started {
+					started = true
+					if err := group(it.Next, 0); err != nil {
+						return fail(err)
+					}
+				}
+
+				// Group pending files until one has at least one group
+				for len(groups) == 0 {
+					if len(pending) == 0 {
+						return fail(iter.EOI)
+					}
+
+					gf := pending[0]
+					pending = pending[1:]
+
+					next, err := readSpillFile(inf.SpillInfo, gf.spillFile)
+					if err == nil {
+						err = group(next, gf.level)
+					}
+
+					if e := gf.remove(); err == nil {
+						err = e
+					}
+
+					if err != nil {
+						return fail(err)
+					}
+				}
+
+				result := groups[0]
+				groups = groups[1:]
+				return result, nil
+			})
+		}
+	})
+}
@@ -0,0 +1,394 @@
+package stream
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	goio "io"
+	gomath "math"
+	"os"
+	"testing"
+
+	"github.com/bantling/micro/iter"
+	"github.com/bantling/micro/tuple"
+	"github.com/bantling/micro/union"
+	"github.com/stretchr/testify/assert"
+)
+
+// spillDir returns a new temporary directory, and a func that returns the number of files in it
+func spillDir(t *testing.T) (string, func() int) {
+	dir := t.TempDir()
+
+	return dir, func() int {
+		entries, err := os.ReadDir(dir)
+		assert.Nil(t, err)
+		return len(entries)
+	}
+}
+
+func TestJSONCodec_(t *testing.T) {
+	type pair struct {
+		Name  string
+		Count int
+	}
+
+	var (
+		codec = JSONCodec[pair]()
+		dir   = t.TempDir()
+		f, _  = os.CreateTemp(dir, "")
+	)
+	defer f.Close()
+
+	enc := codec.Encoder(f)
+	assert.Nil(t, enc(pair{"a", 1}))
+	assert.Nil(t, enc(pair{}))
+
+	f.Seek(0, goio.SeekStart)
+	dec := codec.Decoder(f)
+	assert.Equal(t, union.OfResult(pair{"a", 1}), union.OfResultError(dec()))
+	assert.Equal(t, union.OfResult(pair{}), union.OfResultError(dec()))
+	assert.Equal(t, union.OfError[pair](iter.EOI), union.OfResultError(dec()))
+
+	// Values JSON cannot encode are an error
+	assert.NotNil(t, JSONCodec[float64]().Encoder(f)(gomath.NaN()))
+}
+
+func TestGobCodec_(t *testing.T) {
+	var (
+		codec = GobCodec[string]()
+		dir   = t.TempDir()
+		f, _  = os.CreateTemp(dir, "")
+	)
+	defer f.Close()
+
+	enc := codec.Encoder(f)
+	assert.Nil(t, enc("a"))
+	assert.Nil(t, enc(""))
+
+	f.Seek(0, goio.SeekStart)
+	dec := codec.Decoder(f)
+	assert.Equal(t, union.OfResult("a"), union.OfResultError(dec()))
+	assert.Equal(t, union.OfResult(""), union.OfResultError(dec()))
+	assert.Equal(t, union.OfError[string](iter.EOI), union.OfResultError(dec()))
+}
+
+func TestExternalSort_(t *testing.T) {
+	var (
+		dir, numFiles = spillDir(t)
+		less          = func(i, j int) bool { return i < j }
+	)
+
+	// Fits in memory, no files are created
+	it := ExternalSort(less, SpillInfo[int]{Dir: dir})(iter.Of(3, 1, 2))
+	assert.Equal(t, union.OfResult([]int{1, 2, 3}), iter.Maybe(ReduceToSlice(it)))
+	assert.Equal(t, union.OfError[int](iter.EOI), iter.Maybe(it))
+	assert.Equal(t, 0, numFiles())
+
+	assert.Equal(t, iter.Maybe(ReduceToSlice(SortBy(less)(iter.OfEmpty[int]()))), iter.Maybe(ReduceToSlice(ExternalSort(less)(iter.OfEmpty[int]()))))
+
+	// Spills runs of 3 and merges them, removing the files at EOI
+	var (
+		fn      = ExternalSort(less, SpillInfo[int]{Budget: 3, Dir: dir})
+		seen    = 0
+		assert3 = func(i int) int {
+			if seen++; seen == 1 {
+				assert.Equal(t, 4, numFiles())
+			}
+			return i
+		}
+	)
+
+	it = Map(assert3)(fn(iter.Of(9, 4, 7, 1, 8, 2, 6, 3, 5, 0)))
+	assert.Equal(t, union.OfResult([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}), iter.Maybe(ReduceToSlice(it)))
+	assert.Equal(t, union.OfError[int](iter.EOI), iter.Maybe(it))
+	assert.Equal(t, 0, numFiles())
+
+	// Generator allows reuse
+	assert.Equal(t, union.OfResult([]int{1, 2, 3, 4}), iter.Maybe(ReduceToSlice(fn(iter.Of(4, 3, 2, 1)))))
+	assert.Equal(t, 0, numFiles())
+}
+
+func TestExternalSortStable_(t *testing.T) {
+	type pair struct {
+		Key, Order int
+	}
+
+	var (
+		dir, numFiles = spillDir(t)
+		less          = func(a, b pair) bool { return a.Key < b.Key }
+		src           = []pair{{2, 0}, {1, 1}, {2, 2}, {1, 3}, {2, 4}, {1, 5}, {2, 6}}
+		expected      = []pair{{1, 1}, {1, 3}, {1, 5}, {2, 0}, {2, 2}, {2, 4}, {2, 6}}
+	)
+
+	// Equal keys stay in source order, both within and across runs
+	for _, budget := range []uint{2, 3, 100} {
+		it := ExternalSort(less, SpillInfo[pair]{Budget: budget, Dir: dir})(iter.OfSlice(src))
+		assert.Equal(t, union.OfResult(expected), iter.Maybe(ReduceToSlice(it)))
+	}
+
+	assert.Equal(t, 0, numFiles())
+}
+
+func TestExternalSortSize_(t *testing.T) {
+	var (
+		dir, numFiles = spillDir(t)
+		less          = func(i, j string) bool { return i < j }
+		sizes         []int
+		codec         = GobCodec[string]()
+		countCodec    = Codec[string]{
+			Encoder: func(w goio.Writer) func(string) error {
+				enc, n := codec.Encoder(w), 0
+				sizes = append(sizes, 0)
+				return func(s string) error {
+					n++
+					sizes[len(sizes)-1] = n
+					return enc(s)
+				}
+			},
+			Decoder: codec.Decoder,
+		}
+		info = SpillInfo[string]{
+			Codec:  countCodec,
+			Budget: 4,
+			Size:   func(s string) uint { return uint(len(s)) },
+			Dir:    dir,
+		}
+	)
+
+	// Runs are "dddd", "cc" + "b" + "a", "eeeee", where a value larger than the budget is a run by itself
+	it := ExternalSort(less, info)(iter.Of("dddd", "cc", "b", "a", "eeeee"))
+	assert.Equal(t, union.OfResult([]string{"a", "b", "cc", "dddd", "eeeee"}), iter.Maybe(ReduceToSlice(it)))
+	assert.Equal(t, []int{1, 3, 1}, sizes)
+	assert.Equal(t, 0, numFiles())
+}
+
+func TestExternalSortErrors_(t *testing.T) {
+	var (
+		dir, numFiles = spillDir(t)
+		anErr         = fmt.Errorf("An err")
+		less          = func(i, j int) bool { return i < j }
+		info          = SpillInfo[int]{Budget: 2, Dir: dir}
+	)
+
+	// Source error before spilling
+	it := ExternalSort(less, info)(iter.OfScript(iter.ValueStep(1), iter.ErrorStep[int](anErr)))
+	assert.Equal(t, union.OfError[int](anErr), iter.Maybe(it))
+	assert.Equal(t, union.OfError[int](anErr), iter.Maybe(it))
+
+	// Source error after spilling removes the files
+	it = ExternalSort(less, info)(iter.OfScript(append(iter.ValueSteps(3, 2, 1), iter.ErrorStep[int](anErr))...))
+	assert.Equal(t, union.OfError[int](anErr), iter.Maybe(it))
+	assert.Equal(t, 0, numFiles())
+
+	// Encoder error
+	info.Codec = Codec[int]{
+		Encoder: func(goio.Writer) func(int) error { return func(int) error { return anErr } },
+		Decoder: GobCodec[int]().Decoder,
+	}
+	it = ExternalSort(less, info)(iter.Of(3, 2, 1))
+	assert.Equal(t, union.OfError[int](anErr), iter.Maybe(it))
+	assert.Equal(t, 0, numFiles())
+
+	// Decoder error on first value of a run
+	info.Codec = Codec[int]{
+		Encoder: GobCodec[int]().Encoder,
+		Decoder: func(goio.Reader) func() (int, error) { return func() (int, error) { return 0, anErr } },
+	}
+	it = ExternalSort(less, info)(iter.Of(3, 2, 1))
+	assert.Equal(t, union.OfError[int](anErr), iter.Maybe(it))
+	assert.Equal(t, 0, numFiles())
+
+	// Decoder error after first value of a run
+	gobDec := GobCodec[int]().Decoder
+	info.Codec = Codec[int]{
+		Encoder: GobCodec[int]().Encoder,
+		Decoder: func(r goio.Reader) func() (int, error) {
+			dec, n := gobDec(r), 0
+			return func() (int, error) {
+				if n++; n == 2 {
+					return 0, anErr
+				}
+				return dec()
+			}
+		},
+	}
+	it = ExternalSort(less, info)(iter.Of(4, 3, 2, 1))
+	assert.Equal(t, union.OfResult(1), iter.Maybe(it))
+	assert.Equal(t, union.OfError[int](anErr), iter.Maybe(it))
+	assert.Equal(t, union.OfError[int](anErr), iter.Maybe(it))
+	assert.Equal(t, 0, numFiles())
+
+	// Cannot create files
+	info = SpillInfo[int]{Budget: 1, Dir: dir + "/missing"}
+	it = ExternalSort(less, info)(iter.Of(2, 1))
+	assert.True(t, os.IsNotExist(iter.Maybe(it).Error()))
+}
+
+func TestPartitionOf_(t *testing.T) {
+	// Values of one partition spread across all partitions at the next level
+	var (
+		counts = map[uint]int{}
+		seen   = map[uint]bool{}
+	)
+
+	for h := uint64(0); h < 1000; h++ {
+		if partitionOf(h, 0, 4) == 0 {
+			counts[partitionOf(h, 1, 4)]++
+		}
+		seen[partitionOf(h, 0, 4)] = true
+	}
+
+	assert.Equal(t, 4, len(seen))
+	assert.Equal(t, 4, len(counts))
+}
+
+func TestExternalGroupBy_(t *testing.T) {
+	var (
+		dir, numFiles = spillDir(t)
+		key           = func(i int) int { return i % 10 }
+		sum           = func(s, i int) int { return s + i }
+		src           = []int{3, 13, 5, 1, 23, 15, 7, 11, 9, 0, 2, 4, 6, 8, 10}
+		expected      = map[int]int{0: 10, 1: 12, 2: 2, 3: 39, 4: 4, 5: 20, 6: 6, 7: 7, 8: 8, 9: 9}
+		toMap         = func(it iter.Iter[tuple.Two[int, int]]) map[int]int {
+			m := map[int]int{}
+			for _, g := range iter.Maybe(ReduceToSlice(it)).Get() {
+				m[g.T] = g.U
+			}
+			return m
+		}
+	)
+
+	// Fits in memory, no files are created, and groups are in the order keys occur
+	it := ExternalGroupBy(key, sum, GroupInfo[int, int]{SpillInfo: SpillInfo[int]{Dir: dir}})(iter.Of(3, 13, 5, 1, 23))
+	assert.Equal(
+		t,
+		union.OfResult([]tuple.Two[int, int]{tuple.Of2(3, 39), tuple.Of2(5, 5), tuple.Of2(1, 1)}),
+		iter.Maybe(ReduceToSlice(it)),
+	)
+	assert.Equal(t, union.OfError[tuple.Two[int, int]](iter.EOI), iter.Maybe(it))
+	assert.Equal(t, 0, numFiles())
+
+	assert.Equal(t, union.OfError[tuple.Two[int, int]](iter.EOI), iter.Maybe(ExternalGroupBy(key, sum)(iter.OfEmpty[int]())))
+
+	// Spills groups that do not fit, partitioning them again as needed, and removing the files as they are grouped
+	for _, budget := range []uint{1, 2, 3, 100} {
+		for _, partitions := range []uint{1, 2, 16} {
+			var (
+				maxFiles = 0
+				track    = func(g tuple.Two[int, int]) tuple.Two[int, int] {
+					if n := numFiles(); n > maxFiles {
+						maxFiles = n
+					}
+					return g
+				}
+				fn = ExternalGroupBy(key, sum, GroupInfo[int, int]{
+					SpillInfo:  SpillInfo[int]{Budget: budget, Dir: dir},
+					Partitions: partitions,
+				})
+			)
+
+			assert.Equal(t, expected, toMap(Map(track)(fn(iter.OfSlice(src)))))
+			assert.Equal(t, budget < 10, maxFiles > 0)
+			assert.Equal(t, 0, numFiles())
+
+			// Generator allows reuse
+			assert.Equal(t, expected, toMap(fn(iter.OfSlice(src))))
+		}
+	}
+
+	// Struct values are spilled with the default JSONCodec, or with GobCodec
+	type sale struct {
+		Region string
+		Amount float64
+	}
+
+	var (
+		sales   = []sale{{"east", 1.5}, {"west", 2}, {"east", 0.25}, {"north", 4}, {"west", 1}}
+		region  = func(s sale) string { return s.Region }
+		total   = func(t float64, s sale) float64 { return t + s.Amount }
+		byTotal = map[string]float64{"east": 1.75, "west": 3, "north": 4}
+	)
+
+	for _, codec := range []Codec[sale]{{}, GobCodec[sale]()} {
+		fn := ExternalGroupBy(region, total, GroupInfo[sale, string]{SpillInfo: SpillInfo[sale]{Codec: codec, Budget: 1, Dir: dir}})
+
+		m := map[string]float64{}
+		for _, g := range iter.Maybe(ReduceToSlice(fn(iter.OfSlice(sales)))).Get() {
+			m[g.T] = g.U
+		}
+
+		assert.Equal(t, byTotal, m)
+		assert.Equal(t, 0, numFiles())
+	}
+}
+
+func TestExternalGroupByHash_(t *testing.T) {
+	var (
+		dir, numFiles = spillDir(t)
+		key           = func(s string) string { return s }
+		count         = func(c int, _ string) int { return c + 1 }
+		levels        = 0
+		info          = GroupInfo[string, string]{
+			SpillInfo:  SpillInfo[string]{Budget: 1, Dir: dir},
+			Partitions: 2,
+			// All keys collide, so partitioning never separates them
+			Hash: func(string) uint64 { levels++; return 0 },
+		}
+	)
+
+	// Once the level limit is reached, the remaining groups are held in memory
+	it := ExternalGroupBy(key, count, info)(iter.Of("a", "b", "c", "b", "c", "c"))
+	assert.Equal(
+		t,
+		union.OfResult([]tuple.Two[string, int]{tuple.Of2("a", 1), tuple.Of2("b", 2), tuple.Of2("c", 3)}),
+		iter.Maybe(ReduceToSlice(it)),
+	)
+	assert.True(t, levels > 0)
+	assert.Equal(t, 0, numFiles())
+}
+
+func TestExternalGroupByErrors_(t *testing.T) {
+	var (
+		dir, numFiles = spillDir(t)
+		anErr         = fmt.Errorf("An err")
+		key           = func(i int) int { return i }
+		sum           = func(s, i int) int { return s + i }
+		info          = GroupInfo[int, int]{SpillInfo: SpillInfo[int]{Budget: 1, Dir: dir}}
+	)
+
+	// Source error before spilling
+	it := ExternalGroupBy(key, sum, info)(iter.OfScript(iter.ValueStep(1), iter.ErrorStep[int](anErr)))
+	assert.Equal(t, union.OfError[tuple.Two[int, int]](anErr), iter.Maybe(it))
+	assert.Equal(t, union.OfError[tuple.Two[int, int]](anErr), iter.Maybe(it))
+
+	// Source error after spilling removes the files
+	it = ExternalGroupBy(key, sum, info)(iter.OfScript(append(iter.ValueSteps(1, 2, 3), iter.ErrorStep[int](anErr))...))
+	assert.Equal(t, union.OfError[tuple.Two[int, int]](anErr), iter.Maybe(it))
+	assert.Equal(t, 0, numFiles())
+
+	// Encoder error
+	info.Codec = Codec[int]{
+		Encoder: func(goio.Writer) func(int) error { return func(int) error { return anErr } },
+		Decoder: GobCodec[int]().Decoder,
+	}
+	it = ExternalGroupBy(key, sum, info)(iter.Of(1, 2))
+	assert.Equal(t, union.OfError[tuple.Two[int, int]](anErr), iter.Maybe(it))
+	assert.Equal(t, 0, numFiles())
+
+	// Decoder error after the groups in memory are returned
+	info.Codec = Codec[int]{
+		Encoder: GobCodec[int]().Encoder,
+		Decoder: func(goio.Reader) func() (int, error) { return func() (int, error) { return 0, anErr } },
+	}
+	info.Partitions = 1
+	it = ExternalGroupBy(key, sum, info)(iter.Of(1, 2, 3))
+	assert.Equal(t, union.OfResult(tuple.Of2(1, 1)), iter.Maybe(it))
+	assert.Equal(t, union.OfError[tuple.Two[int, int]](anErr), iter.Maybe(it))
+	assert.Equal(t, union.OfError[tuple.Two[int, int]](anErr), iter.Maybe(it))
+	assert.Equal(t, 0, numFiles())
+
+	// Cannot create files
+	info = GroupInfo[int, int]{SpillInfo: SpillInfo[int]{Budget: 1, Dir: dir + "/missing"}}
+	it = ExternalGroupBy(key, sum, info)(iter.Of(1, 2))
+	assert.True(t, os.IsNotExist(iter.Maybe(it).Error()))
+}
@@ -0,0 +1,617 @@
+package stream
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	gomath "math"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bantling/micro/iter"
+	"github.com/bantling/micro/math"
+)
+
+// Constants
+var (
+	errFakeUnsupportedTypeMsg = "The type %s cannot be faked"
+	errFakeInvalidTagMsg      = "The fake tag %q of field %s.%s is not valid"
+	errFakeNoRefsMsg          = "The field %s.%s refers to %s, but no %s values with an id have been generated"
+	errFakeRefTypeMsg         = "The %s id of type %s cannot be assigned to the field %s.%s of type %s"
+
+	fakeDateLayout = "2006-01-02"
+	fakeMinTime    = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	fakeMaxTime    = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	fakeFirstNames = []string{
+		"Alice", "Bob", "Carlos", "Diana", "Ethan", "Fatima", "George", "Hana", "Ivan", "Julia",
+		"Kenji", "Laura", "Mohammed", "Nina", "Omar", "Priya", "Quinn", "Rosa", "Samuel", "Tara",
+	}
+	fakeLastNames = []string{
+		"Anderson", "Brown", "Chen", "Dubois", "Evans", "Fischer", "Garcia", "Hughes", "Ivanova", "Jones",
+		"Kim", "Lopez", "Martin", "Nguyen", "O'Brien", "Patel", "Rossi", "Smith", "Tanaka", "Wilson",
+	}
+	fakeStreets = []string{
+		"Main St", "Oak Ave", "Maple Dr", "Cedar Ln", "Elm St", "Pine Rd", "Lake Blvd", "Hill St", "Park Ave", "King St",
+	}
+	fakeCities = []string{
+		"Springfield", "Riverside", "Fairview", "Georgetown", "Franklin", "Clinton", "Madison", "Salem", "Oakville", "Kingston",
+	}
+	fakeCountries = []string{
+		"Australia", "Brazil", "Canada", "France", "Germany", "India", "Japan", "Mexico", "United Kingdom", "United States",
+	}
+	fakeCompanySuffixes = []string{"Inc", "LLC", "Ltd", "Group", "Systems", "Partners"}
+	fakeWords           = []string{
+		"alpha", "bright", "cloud", "delta", "ember", "forest", "granite", "harbor", "island", "jade",
+		"kernel", "lumen", "meadow", "nova", "orbit", "prairie", "quartz", "river", "summit", "tide",
+	}
+
+	// fakeNameKinds are the kinds inferred from the lower case name of a string field that has no kind in its tag
+	fakeNameKinds = map[string]string{
+		"firstname":  "firstName",
+		"lastname":   "lastName",
+		"surname":    "lastName",
+		"name":       "name",
+		"fullname":   "name",
+		"email":      "email",
+		"phone":      "phone",
+		"street":     "street",
+		"address":    "street",
+		"city":       "city",
+		"country":    "country",
+		"postalcode": "postalCode",
+		"zip":        "postalCode",
+		"zipcode":    "postalCode",
+		"company":    "company",
+	}
+
+	// fakeStringKinds are the kinds of generated strings
+	fakeStringKinds = map[string]bool{
+		"firstName":  true,
+		"lastName":   true,
+		"name":       true,
+		"email":      true,
+		"phone":      true,
+		"street":     true,
+		"city":       true,
+		"country":    true,
+		"postalCode": true,
+		"company":    true,
+		"word":       true,
+		"sentence":   true,
+	}
+
+	decimalType = reflect.TypeOf(math.Decimal{})
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// Faker generates deterministic fake values of struct types, for load tests and demo environments. The same seed
+// generates the same values, as long as the same types are generated in the same order.
+//
+// A field tagged `fake:"id"` is given sequential ids starting at 1 for each struct type, and a field tagged
+// `fake:"ref=Type"` is given the id of a random value of the struct type named Type that has already been generated by
+// the same Faker, so that generated children refer to generated parents. Type names a struct type in the same package
+// as the field, or in another package if qualified by the package path, eg `fake:"ref=example.com/model.Customer"`.
+// If several struct types of one package have the same name, such as types declared in functions, Type refers to the
+// one most recently generated.
+//
+// A Faker is not safe for concurrent use.
+type Faker struct {
+	rnd    *rand.Rand
+	lastID map[reflect.Type]int64
+	ids    map[reflect.Type][]reflect.Value
+	refs   map[string]reflect.Type
+}
+
+// fakeOpts are the options of a struct field given in a fake tag
+type fakeOpts struct {
+	kind      string
+	min       string
+	max       string
+	oneof     []string
+	ref       string
+	precision uint
+	scale     uint
+}
+
+// fakeGen sets a value to a fake value
+type fakeGen func(f *Faker, val reflect.Value) error
+
+// NewFaker constructs a Faker with the given seed
+func NewFaker(seed int64) *Faker {
+	return &Faker{
+		rnd:    rand.New(rand.NewSource(seed)),
+		lastID: map[reflect.Type]int64{},
+		ids:    map[reflect.Type][]reflect.Value{},
+		refs:   map[string]reflect.Type{},
+	}
+}
+
+// parseFakeTag parses a fake tag of the form `fake:"kind,min=1,max=10,oneof=a|b,ref=Type,precision=10,scale=2"`, where
+// every part is optional, and the parts after the kind may be in any order. The kind - skips the field.
+func parseFakeTag(typ reflect.Type, fld reflect.StructField) (opts fakeOpts, skip bool, err error) {
+	opts.precision, opts.scale = 10, 2
+
+	tag := fld.Tag.Get("fake")
+	if tag == "-" {
+		skip = true
+		return
+	}
+
+	parts := strings.Split(tag, ",")
+	if !strings.Contains(parts[0], "=") {
+		opts.kind = parts[0]
+		parts = parts[1:]
+	}
+
+	for _, part := range parts {
+		var (
+			kv  = strings.SplitN(part, "=", 2)
+			n   uint64
+			nok error
+		)
+
+		if len(kv) != 2 {
+			return opts, false, fmt.Errorf(errFakeInvalidTagMsg, tag, typ, fld.Name)
+		}
+
+		n, nok = strconv.ParseUint(kv[1], 10, 32)
+
+		switch {
+		case kv[0] == "min":
+			opts.min = kv[1]
+		case kv[0] == "max":
+			opts.max = kv[1]
+		case kv[0] == "oneof":
+			opts.oneof = strings.Split(kv[1], "|")
+		case kv[0] == "ref":
+			opts.kind, opts.ref = "ref", kv[1]
+		case (kv[0] == "precision") && (nok == nil) && (n > 0) && (n <= 18):
+			opts.precision = uint(n)
+		case (kv[0] == "scale") && (nok == nil):
+			opts.scale = uint(n)
+		default:
+			return opts, false, fmt.Errorf(errFakeInvalidTagMsg, tag, typ, fld.Name)
+		}
+	}
+
+	if (opts.scale > opts.precision) || ((opts.kind == "ref") && (opts.ref == "")) {
+		return opts, false, fmt.Errorf(errFakeInvalidTagMsg, tag, typ, fld.Name)
+	}
+
+	// A string field with no kind may have one inferred from the field name
+	if (opts.kind == "") && (fld.Type.Kind() == reflect.String) {
+		opts.kind = fakeNameKinds[strings.ToLower(fld.Name)]
+	}
+
+	return
+}
+
+// pick returns a random element of a slice
+func (f *Faker) pick(slc []string) string {
+	return slc[f.rnd.Intn(len(slc))]
+}
+
+// str generates a string of the given kind
+func (f *Faker) str(kind string) string {
+	switch kind {
+	case "firstName":
+		return f.pick(fakeFirstNames)
+	case "lastName":
+		return f.pick(fakeLastNames)
+	case "name":
+		return f.pick(fakeFirstNames) + " " + f.pick(fakeLastNames)
+	case "email":
+		return strings.ToLower(f.pick(fakeFirstNames)+"."+strings.ReplaceAll(f.pick(fakeLastNames), "'", "")) +
+			strconv.Itoa(f.rnd.Intn(100)) + "@example.com"
+	case "phone":
+		return fmt.Sprintf("+1-555-%03d-%04d", f.rnd.Intn(1000), f.rnd.Intn(10000))
+	case "street":
+		return strconv.Itoa(1+f.rnd.Intn(9999)) + " " + f.pick(fakeStreets)
+	case "city":
+		return f.pick(fakeCities)
+	case "country":
+		return f.pick(fakeCountries)
+	case "postalCode":
+		return fmt.Sprintf("%05d", f.rnd.Intn(100000))
+	case "company":
+		return f.pick(fakeLastNames) + " " + f.pick(fakeCompanySuffixes)
+	case "sentence":
+		var (
+			n     = 4 + f.rnd.Intn(5)
+			words = make([]string, n)
+		)
+
+		for i := range words {
+			words[i] = f.pick(fakeWords)
+		}
+
+		return strings.ToUpper(words[0][:1]) + strings.Join(words, " ")[1:] + "."
+	}
+
+	return f.pick(fakeWords)
+}
+
+// getID returns the next id of a struct type
+func (f *Faker) getID(typ reflect.Type) int64 {
+	f.lastID[typ]++
+	return f.lastID[typ]
+}
+
+// fakeRefName returns the package qualified name of a struct type, or of a ref to a type name in the package of owner
+func fakeRefName(owner reflect.Type, name string) string {
+	if strings.Contains(name, ".") {
+		return name
+	}
+
+	return owner.PkgPath() + "." + name
+}
+
+// compileFake returns a generator for a type, using the options of the field that has the type, if any.
+// Types that are being compiled are tracked, so that a recursive pointer or slice is left empty.
+func compileFake(typ reflect.Type, owner reflect.Type, fld reflect.StructField, opts fakeOpts, compiling map[reflect.Type]bool) (fakeGen, error) {
+	invalidTag := fmt.Errorf(errFakeInvalidTagMsg, fld.Tag.Get("fake"), owner, fld.Name)
+
+	// Only strings can be one of a list of values
+	if k := typ.Kind(); (len(opts.oneof) > 0) && (k != reflect.String) && (k != reflect.Pointer) && (k != reflect.Slice) {
+		return nil, invalidTag
+	}
+
+	// An id is a sequential integer or string of digits
+	if opts.kind == "id" {
+		switch typ.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return func(f *Faker, val reflect.Value) error {
+				val.SetInt(f.getID(owner))
+				return nil
+			}, nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return func(f *Faker, val reflect.Value) error {
+				val.SetUint(uint64(f.getID(owner)))
+				return nil
+			}, nil
+		case reflect.String:
+			return func(f *Faker, val reflect.Value) error {
+				val.SetString(strconv.FormatInt(f.getID(owner), 10))
+				return nil
+			}, nil
+		}
+
+		return nil, invalidTag
+	}
+
+	// A ref is the id of a random generated parent
+	if opts.kind == "ref" {
+		refName := fakeRefName(owner, opts.ref)
+
+		return func(f *Faker, val reflect.Value) error {
+			ids := f.ids[f.refs[refName]]
+			if len(ids) == 0 {
+				return fmt.Errorf(errFakeNoRefsMsg, owner, fld.Name, opts.ref, opts.ref)
+			}
+
+			// Converting an integer to a string would produce a rune, so integers are formatted
+			id := ids[f.rnd.Intn(len(ids))]
+			if (typ.Kind() == reflect.String) && (id.Kind() != reflect.String) {
+				val.SetString(fmt.Sprint(id.Interface()))
+				return nil
+			}
+
+			if !id.Type().ConvertibleTo(typ) {
+				return fmt.Errorf(errFakeRefTypeMsg, opts.ref, id.Type(), owner, fld.Name, typ)
+			}
+
+			val.Set(id.Convert(typ))
+			return nil
+		}, nil
+	}
+
+	// Any other kind is only valid for the type it describes
+	if k := typ.Kind(); (opts.kind != "") && (k != reflect.Pointer) && (k != reflect.Slice) &&
+		!((typ == timeType) && (opts.kind == "date")) && !((k == reflect.String) && fakeStringKinds[opts.kind]) {
+		return nil, invalidTag
+	}
+
+	switch {
+	case typ == decimalType:
+		return func(f *Faker, val reflect.Value) error {
+			max := int64(1)
+			for i := uint(0); i < opts.precision; i++ {
+				max *= 10
+			}
+
+			// Generate a decimal that is not normalized, so that it has the desired scale
+			d, err := math.OfDecimal(f.rnd.Int63n(max), opts.scale, false)
+			val.Set(reflect.ValueOf(d))
+			return err
+		}, nil
+
+	case typ == timeType:
+		var (
+			min, max = fakeMinTime, fakeMaxTime
+			err      error
+		)
+
+		if opts.min != "" {
+			min, err = time.Parse(fakeDateLayout, opts.min)
+		}
+
+		if (err == nil) && (opts.max != "") {
+			max, err = time.Parse(fakeDateLayout, opts.max)
+		}
+
+		if (err != nil) || !max.After(min) {
+			return nil, invalidTag
+		}
+
+		return func(f *Faker, val reflect.Value) error {
+			t := min.Add(time.Duration(f.rnd.Int63n(int64(max.Sub(min)/time.Second))) * time.Second)
+			if opts.kind == "date" {
+				t = t.Truncate(24 * time.Hour)
+			}
+
+			val.Set(reflect.ValueOf(t))
+			return nil
+		}, nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return func(f *Faker, val reflect.Value) error {
+			val.SetBool(f.rnd.Intn(2) == 1)
+			return nil
+		}, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		min, max, ok := fakeRange(opts, 0, 100, func(s string) (int64, error) { return strconv.ParseInt(s, 10, typ.Bits()) })
+		if !ok {
+			return nil, invalidTag
+		}
+
+		return func(f *Faker, val reflect.Value) error {
+			val.SetInt(min + int64(fakeUint64(f, uint64(max-min))))
+			return nil
+		}, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		min, max, ok := fakeRange(opts, 0, 100, func(s string) (uint64, error) { return strconv.ParseUint(s, 10, typ.Bits()) })
+		if !ok {
+			return nil, invalidTag
+		}
+
+		return func(f *Faker, val reflect.Value) error {
+			val.SetUint(min + fakeUint64(f, max-min))
+			return nil
+		}, nil
+
+	case reflect.Float32, reflect.Float64:
+		min, max, ok := fakeRange(opts, 0, 100, func(s string) (float64, error) { return strconv.ParseFloat(s, typ.Bits()) })
+		if !ok {
+			return nil, invalidTag
+		}
+
+		return func(f *Faker, val reflect.Value) error {
+			val.SetFloat(min + f.rnd.Float64()*(max-min))
+			return nil
+		}, nil
+
+	case reflect.String:
+		if len(opts.oneof) > 0 {
+			return func(f *Faker, val reflect.Value) error {
+				val.SetString(f.pick(opts.oneof))
+				return nil
+			}, nil
+		}
+
+		return func(f *Faker, val reflect.Value) error {
+			val.SetString(f.str(opts.kind))
+			return nil
+		}, nil
+
+	case reflect.Pointer:
+		if compiling[typ.Elem()] {
+			return func(*Faker, reflect.Value) error { return nil }, nil
+		}
+
+		elemGen, err := compileFake(typ.Elem(), owner, fld, opts, compiling)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(f *Faker, val reflect.Value) error {
+			ptr := reflect.New(typ.Elem())
+			val.Set(ptr)
+			return elemGen(f, ptr.Elem())
+		}, nil
+
+	case reflect.Slice:
+		if compiling[typ.Elem()] {
+			return func(*Faker, reflect.Value) error { return nil }, nil
+		}
+
+		elemGen, err := compileFake(typ.Elem(), owner, fld, opts, compiling)
+		if err != nil {
+			return nil, err
+		}
+
+		// Slices have 1 to 3 elements
+		return func(f *Faker, val reflect.Value) error {
+			slc := reflect.MakeSlice(typ, 1+f.rnd.Intn(3), 3)
+			for i := 0; i < slc.Len(); i++ {
+				if err := elemGen(f, slc.Index(i)); err != nil {
+					return err
+				}
+			}
+
+			val.Set(slc)
+			return nil
+		}, nil
+
+	case reflect.Struct:
+		return compileFakeStruct(typ, compiling)
+	}
+
+	return nil, fmt.Errorf(errFakeUnsupportedTypeMsg, typ)
+}
+
+// fakeRange returns the min and max options parsed by the given func, which default to the given values.
+// Returns false if either option cannot be parsed, or min > max.
+func fakeRange[T int64 | uint64 | float64](opts fakeOpts, defMin, defMax T, parse func(string) (T, error)) (min, max T, ok bool) {
+	var err error
+	min, max = defMin, defMax
+
+	if opts.min != "" {
+		if min, err = parse(opts.min); err != nil {
+			return
+		}
+	}
+
+	if opts.max != "" {
+		if max, err = parse(opts.max); err != nil {
+			return
+		}
+	}
+
+	ok = min <= max
+	return
+}
+
+// fakeUint64 returns a random value between 0 and span inclusive
+func fakeUint64(f *Faker, span uint64) uint64 {
+	// A span of every uint64 value would overflow to a modulus of 0
+	if span == gomath.MaxUint64 {
+		return f.rnd.Uint64()
+	}
+
+	return f.rnd.Uint64() % (span + 1)
+}
+
+// compileFakeStruct returns a generator for the exported fields of a struct, that records the id of each value
+// generated for refs to use
+func compileFakeStruct(typ reflect.Type, compiling map[reflect.Type]bool) (fakeGen, error) {
+	compiling[typ] = true
+	defer delete(compiling, typ)
+
+	var (
+		idx   []int
+		gens  []fakeGen
+		idIdx = -1
+	)
+
+	for i := 0; i < typ.NumField(); i++ {
+		fld := typ.Field(i)
+		if !fld.IsExported() {
+			continue
+		}
+
+		opts, skip, err := parseFakeTag(typ, fld)
+		if err != nil {
+			return nil, err
+		}
+
+		if skip {
+			continue
+		}
+
+		gen, err := compileFake(fld.Type, typ, fld, opts, compiling)
+		if err != nil {
+			return nil, err
+		}
+
+		if opts.kind == "id" {
+			idIdx = i
+		}
+
+		idx, gens = append(idx, i), append(gens, gen)
+	}
+
+	refName := fakeRefName(typ, typ.Name())
+
+	return func(f *Faker, val reflect.Value) error {
+		for i, gen := range gens {
+			if err := gen(f, val.Field(idx[i])); err != nil {
+				return err
+			}
+		}
+
+		if idIdx >= 0 {
+			// Record a copy of the id, rather than the field of the value
+			f.ids[typ], f.refs[refName] = append(f.ids[typ], reflect.ValueOf(val.Field(idIdx).Interface())), typ
+		}
+
+		return nil
+	}, nil
+}
+
+// Fake generates n fake values of struct type T, or of a pointer to a struct type.
+// Exported fields are generated according to their type, and an optional tag of the form
+// `fake:"kind,min=1,max=10,oneof=a|b,ref=Type,precision=10,scale=2"`, where every part is optional:
+//   - A kind of - skips the field
+//   - The kinds id and ref=Type are described in Faker
+//   - A string is the kind firstName, lastName, name, email, phone, street, city, country, postalCode, company, word, or
+//     sentence, or one of the values separated by |. If there is no kind, it is inferred from the field name (eg, a
+//     field named City is a city), or is a word.
+//   - bool is random
+//   - Integers and floats are between min and max inclusive, which default to 0 and 100
+//   - time.Time is between min and max dates in the form 2006-01-02, which default to 2000-01-01 and 2030-01-01. The
+//     kind date generates midnight UTC.
+//   - math.Decimal is a non-negative value with up to precision digits (default 10) and scale decimals (default 2), that
+//     is not normalized, so that it keeps the scale
+//   - A pointer points to a generated value, and a slice has 1 to 3 generated elements, except that a recursive pointer
+//     is nil, and a recursive slice is empty
+//   - A struct has its fields generated
+//
+// The resulting iter returns an error for unsupported types or invalid tags, a ref to a type that has no generated
+// values, or EOI after n values.
+func Fake[T any](f *Faker, n uint) iter.Iter[T] {
+	var (
+		typ   = reflect.TypeOf((*T)(nil)).Elem()
+		styp  = typ
+		gen   fakeGen
+		err   error
+		count uint
+	)
+
+	if typ.Kind() == reflect.Pointer {
+		styp = typ.Elem()
+	}
+
+	if styp.Kind() != reflect.Struct {
+		err = fmt.Errorf(errFakeUnsupportedTypeMsg, typ)
+	} else {
+		gen, err = compileFakeStruct(styp, map[reflect.Type]bool{})
+	}
+
+	return iter.OfIter(func() (T, error) {
+		var zv T
+
+		if err != nil {
+			return zv, err
+		}
+
+		if count == n {
+			return zv, iter.EOI
+		}
+
+		var (
+			ptr = reflect.New(styp)
+			res T
+		)
+
+		if err = gen(f, ptr.Elem()); err != nil {
+			return zv, err
+		}
+
+		count++
+		if typ.Kind() == reflect.Pointer {
+			res = ptr.Interface().(T)
+		} else {
+			res = ptr.Elem().Interface().(T)
+		}
+
+		return res, nil
+	})
+}
@@ -0,0 +1,365 @@
+package stream
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bantling/micro/iter"
+	"github.com/bantling/micro/math"
+	"github.com/bantling/micro/union"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCustomer struct {
+	ID      int64 `fake:"id"`
+	Name    string
+	Email   string
+	City    string
+	Tier    string       `fake:"oneof=gold|silver|bronze"`
+	Balance math.Decimal `fake:"precision=6,scale=2"`
+	Age     int          `fake:"min=18,max=65"`
+	Joined  time.Time    `fake:"date,min=2020-01-01,max=2021-01-01"`
+	Active  bool
+	Notes   string  `fake:"-"`
+	Bio     *string `fake:"sentence"`
+	notes   string
+}
+
+type fakeLine struct {
+	Product string `fake:"word"`
+	Qty     uint8  `fake:"min=1,max=5"`
+	Price   float64
+}
+
+type fakeOrder struct {
+	ID         string `fake:"id"`
+	CustomerID int64  `fake:"ref=fakeCustomer"`
+	CustRef    string `fake:"ref=fakeCustomer"`
+	Lines      []fakeLine
+	Previous   *fakeOrder
+	Related    []fakeOrder
+}
+
+func TestFakeFields_(t *testing.T) {
+	var (
+		f         = NewFaker(42)
+		customers = iter.Maybe(ReduceToSlice(Fake[fakeCustomer](f, 100))).Get()
+		firstSet  = map[string]bool{}
+		lastSet   = map[string]bool{}
+		tiers     = map[string]bool{}
+	)
+
+	for _, name := range fakeFirstNames {
+		firstSet[name] = true
+	}
+	for _, name := range fakeLastNames {
+		lastSet[name] = true
+	}
+
+	assert.Equal(t, 100, len(customers))
+	for i, c := range customers {
+		// Sequential ids
+		assert.Equal(t, int64(i+1), c.ID)
+
+		// Kinds inferred from field names
+		names := strings.Split(c.Name, " ")
+		assert.Equal(t, 2, len(names))
+		assert.True(t, firstSet[names[0]])
+		assert.True(t, lastSet[names[1]])
+		assert.True(t, strings.HasSuffix(c.Email, "@example.com"))
+		assert.Contains(t, fakeCities, c.City)
+
+		// Options
+		assert.Contains(t, []string{"gold", "silver", "bronze"}, c.Tier)
+		tiers[c.Tier] = true
+
+		assert.Equal(t, uint(2), c.Balance.Scale())
+		assert.False(t, c.Balance.Normalized())
+		assert.Equal(t, 1, math.MustDecimal(1_000_000, 0).Cmp(c.Balance))
+		assert.GreaterOrEqual(t, c.Balance.Sign(), 0)
+
+		assert.GreaterOrEqual(t, c.Age, 18)
+		assert.LessOrEqual(t, c.Age, 65)
+
+		assert.False(t, c.Joined.Before(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)))
+		assert.True(t, c.Joined.Before(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)))
+		assert.Equal(t, c.Joined, c.Joined.Truncate(24*time.Hour))
+
+		assert.Equal(t, "", c.Notes)
+		assert.Equal(t, "", c.notes)
+
+		assert.NotNil(t, c.Bio)
+		assert.True(t, strings.HasSuffix(*c.Bio, "."))
+		assert.GreaterOrEqual(t, len(strings.Split(*c.Bio, " ")), 4)
+	}
+
+	// Every option occurs
+	assert.Equal(t, 3, len(tiers))
+
+	// Children refer to generated parents, and recursive fields are empty
+	orders := iter.Maybe(ReduceToSlice(Fake[*fakeOrder](f, 50))).Get()
+	assert.Equal(t, 50, len(orders))
+
+	for i, o := range orders {
+		assert.Equal(t, fmt.Sprint(i+1), o.ID)
+		assert.GreaterOrEqual(t, o.CustomerID, int64(1))
+		assert.LessOrEqual(t, o.CustomerID, int64(100))
+		assert.Regexp(t, "^[0-9]+$", o.CustRef)
+
+		assert.GreaterOrEqual(t, len(o.Lines), 1)
+		assert.LessOrEqual(t, len(o.Lines), 3)
+		for _, l := range o.Lines {
+			assert.Contains(t, fakeWords, l.Product)
+			assert.GreaterOrEqual(t, l.Qty, uint8(1))
+			assert.LessOrEqual(t, l.Qty, uint8(5))
+			assert.GreaterOrEqual(t, l.Price, 0.0)
+			assert.Less(t, l.Price, 100.0)
+		}
+
+		assert.Nil(t, o.Previous)
+		assert.Nil(t, o.Related)
+	}
+}
+
+func TestFakeStringKinds_(t *testing.T) {
+	type s struct {
+		FirstName string
+		Surname   string
+		Phone     string
+		Address   string
+		Country   string
+		Zip       string
+		Company   string
+		Other     string
+		Email     string   `fake:"email"`
+		Status    *string  `fake:"oneof=new|done"`
+		Tags      []string `fake:"oneof=a|b"`
+	}
+
+	for _, v := range iter.Maybe(ReduceToSlice(Fake[s](NewFaker(7), 50))).Get() {
+		assert.Contains(t, fakeFirstNames, v.FirstName)
+		assert.Contains(t, fakeLastNames, v.Surname)
+		assert.Regexp(t, `^\+1-555-[0-9]{3}-[0-9]{4}$`, v.Phone)
+		assert.Regexp(t, `^[0-9]+ [A-Za-z ]+$`, v.Address)
+		assert.Contains(t, fakeCountries, v.Country)
+		assert.Regexp(t, `^[0-9]{5}$`, v.Zip)
+		assert.Regexp(t, `^[A-Za-z']+ [A-Za-z]+$`, v.Company)
+		assert.Contains(t, fakeWords, v.Other)
+		assert.Regexp(t, `^[a-z]+\.[a-z]+[0-9]*@example\.com$`, v.Email)
+		assert.Contains(t, []string{"new", "done"}, *v.Status)
+		for _, tag := range v.Tags {
+			assert.Contains(t, []string{"a", "b"}, tag)
+		}
+	}
+}
+
+func TestFakeDeterministic_(t *testing.T) {
+	gen := func(seed int64) ([]fakeCustomer, []*fakeOrder) {
+		f := NewFaker(seed)
+		return iter.Maybe(ReduceToSlice(Fake[fakeCustomer](f, 20))).Get(), iter.Maybe(ReduceToSlice(Fake[*fakeOrder](f, 20))).Get()
+	}
+
+	c1, o1 := gen(1)
+	c2, o2 := gen(1)
+	assert.Equal(t, c1, c2)
+	assert.Equal(t, o1, o2)
+
+	c3, _ := gen(2)
+	assert.NotEqual(t, c1, c3)
+
+	// Ids continue across iters of the same Faker
+	f := NewFaker(1)
+	assert.Equal(t, int64(1), iter.Maybe(Fake[fakeCustomer](f, 1)).Get().ID)
+	assert.Equal(t, int64(2), iter.Maybe(Fake[fakeCustomer](f, 1)).Get().ID)
+
+	// Only n values
+	it := Fake[fakeLine](f, 1)
+	assert.Nil(t, iter.Maybe(it).Error())
+	assert.Equal(t, union.OfError[fakeLine](iter.EOI), iter.Maybe(it))
+	assert.Equal(t, union.OfError[fakeLine](iter.EOI), iter.Maybe(Fake[fakeLine](f, 0)))
+}
+
+func TestFakeRefNames_(t *testing.T) {
+	f := NewFaker(1)
+
+	// Struct types with the same name have their own ids
+	{
+		type item struct {
+			ID int `fake:"id"`
+		}
+		assert.Equal(t, []item{{1}, {2}}, iter.Maybe(ReduceToSlice(Fake[item](f, 2))).Get())
+	}
+
+	type item struct {
+		ID int `fake:"id"`
+	}
+	assert.Equal(t, []item{{1}}, iter.Maybe(ReduceToSlice(Fake[item](f, 1))).Get())
+
+	// A ref refers to the most recently generated type of the name, in the package of the field
+	type child struct {
+		ItemID int `fake:"ref=item"`
+	}
+	assert.Equal(t, []child{{1}, {1}}, iter.Maybe(ReduceToSlice(Fake[child](f, 2))).Get())
+
+	// A ref may be qualified by the package path
+	type qualified struct {
+		CustomerID int64 `fake:"ref=github.com/bantling/micro/stream.fakeCustomer"`
+	}
+	assert.Equal(
+		t,
+		union.OfError[qualified](fmt.Errorf(errFakeNoRefsMsg, reflect.TypeOf(qualified{}), "CustomerID", "github.com/bantling/micro/stream.fakeCustomer", "github.com/bantling/micro/stream.fakeCustomer")),
+		iter.Maybe(Fake[qualified](f, 1)),
+	)
+
+	iter.Maybe(Fake[fakeCustomer](f, 1))
+	assert.Equal(t, union.OfResult(qualified{1}), iter.Maybe(Fake[qualified](f, 1)))
+
+	// A type of the same name in another package is not referred to
+	type other struct {
+		CustomerID int64 `fake:"ref=example.com/other.fakeCustomer"`
+	}
+	assert.NotNil(t, iter.Maybe(Fake[other](f, 1)).Error())
+}
+
+func TestFakeRange_(t *testing.T) {
+	type s struct {
+		I8  int8    `fake:"min=-128,max=127"`
+		I64 int64   `fake:"min=-9223372036854775808,max=9223372036854775807"`
+		U64 uint64  `fake:"max=18446744073709551615"`
+		F   float32 `fake:"min=-1,max=1"`
+		Eq  int     `fake:"min=7,max=7"`
+	}
+
+	for _, v := range iter.Maybe(ReduceToSlice(Fake[s](NewFaker(3), 100))).Get() {
+		assert.GreaterOrEqual(t, v.F, float32(-1))
+		assert.LessOrEqual(t, v.F, float32(1))
+		assert.Equal(t, 7, v.Eq)
+	}
+}
+
+func TestFakeErrors_(t *testing.T) {
+	// Refs to types that have no generated values
+	f := NewFaker(1)
+	assert.Equal(
+		t,
+		union.OfError[fakeOrder](fmt.Errorf(errFakeNoRefsMsg, reflect.TypeOf(fakeOrder{}), "CustomerID", "fakeCustomer", "fakeCustomer")),
+		iter.Maybe(Fake[fakeOrder](f, 1)),
+	)
+
+	// The error is returned again
+	it := Fake[fakeOrder](f, 1)
+	assert.NotNil(t, iter.Maybe(it).Error())
+	assert.NotNil(t, iter.Maybe(it).Error())
+
+	// Ids that cannot be assigned
+	type parent struct {
+		ID string `fake:"id"`
+	}
+	type child struct {
+		ParentID int `fake:"ref=parent"`
+	}
+
+	iter.Maybe(Fake[parent](f, 1))
+	assert.Equal(
+		t,
+		union.OfError[child](fmt.Errorf(errFakeRefTypeMsg, "parent", reflect.TypeOf(""), reflect.TypeOf(child{}), "ParentID", reflect.TypeOf(0))),
+		iter.Maybe(Fake[child](f, 1)),
+	)
+
+	// Unsupported types
+	type withMap struct {
+		M map[string]int
+	}
+	assert.Equal(t, union.OfError[int](fmt.Errorf(errFakeUnsupportedTypeMsg, reflect.TypeOf(0))), iter.Maybe(Fake[int](f, 1)))
+	assert.Equal(t, union.OfError[*int](fmt.Errorf(errFakeUnsupportedTypeMsg, reflect.TypeOf((*int)(nil)))), iter.Maybe(Fake[*int](f, 1)))
+	assert.Equal(t, union.OfError[withMap](fmt.Errorf(errFakeUnsupportedTypeMsg, reflect.TypeOf(map[string]int{}))), iter.Maybe(Fake[withMap](f, 1)))
+
+	// Invalid tags
+	type badOneof struct {
+		I int `fake:"oneof=1|2"`
+	}
+	type badID struct {
+		F float64 `fake:"id"`
+	}
+	type badKind struct {
+		S string `fake:"bogus"`
+	}
+	type badDate struct {
+		S string `fake:"date"`
+	}
+	type badIntKind struct {
+		I int `fake:"email"`
+	}
+	type badPart struct {
+		I int `fake:"min"`
+	}
+	type badKey struct {
+		I int `fake:"size=3"`
+	}
+	type badMin struct {
+		I int `fake:"min=x"`
+	}
+	type badMax struct {
+		I int8 `fake:"max=128"`
+	}
+	type badRange struct {
+		U uint `fake:"min=5,max=4"`
+	}
+	type badFloat struct {
+		F float64 `fake:"min=a"`
+	}
+	type badTimeMin struct {
+		T time.Time `fake:"min=2020"`
+	}
+	type badTimeMax struct {
+		T time.Time `fake:"max=2020"`
+	}
+	type badTimeRange struct {
+		T time.Time `fake:"min=2020-01-01,max=2020-01-01"`
+	}
+	type badPrecision struct {
+		D math.Decimal `fake:"precision=19"`
+	}
+	type badScale struct {
+		D math.Decimal `fake:"precision=2,scale=3"`
+	}
+	type badRef struct {
+		I int `fake:"ref="`
+	}
+	type badStruct struct {
+		L fakeLine `fake:"word"`
+	}
+
+	for _, tc := range []struct {
+		it    func() error
+		typ   reflect.Type
+		field string
+	}{
+		{func() error { return iter.Maybe(Fake[badOneof](f, 1)).Error() }, reflect.TypeOf(badOneof{}), "I"},
+		{func() error { return iter.Maybe(Fake[badID](f, 1)).Error() }, reflect.TypeOf(badID{}), "F"},
+		{func() error { return iter.Maybe(Fake[badKind](f, 1)).Error() }, reflect.TypeOf(badKind{}), "S"},
+		{func() error { return iter.Maybe(Fake[badDate](f, 1)).Error() }, reflect.TypeOf(badDate{}), "S"},
+		{func() error { return iter.Maybe(Fake[badIntKind](f, 1)).Error() }, reflect.TypeOf(badIntKind{}), "I"},
+		{func() error { return iter.Maybe(Fake[badPart](f, 1)).Error() }, reflect.TypeOf(badPart{}), "I"},
+		{func() error { return iter.Maybe(Fake[badKey](f, 1)).Error() }, reflect.TypeOf(badKey{}), "I"},
+		{func() error { return iter.Maybe(Fake[badMin](f, 1)).Error() }, reflect.TypeOf(badMin{}), "I"},
+		{func() error { return iter.Maybe(Fake[badMax](f, 1)).Error() }, reflect.TypeOf(badMax{}), "I"},
+		{func() error { return iter.Maybe(Fake[badRange](f, 1)).Error() }, reflect.TypeOf(badRange{}), "U"},
+		{func() error { return iter.Maybe(Fake[badFloat](f, 1)).Error() }, reflect.TypeOf(badFloat{}), "F"},
+		{func() error { return iter.Maybe(Fake[badTimeMin](f, 1)).Error() }, reflect.TypeOf(badTimeMin{}), "T"},
+		{func() error { return iter.Maybe(Fake[badTimeMax](f, 1)).Error() }, reflect.TypeOf(badTimeMax{}), "T"},
+		{func() error { return iter.Maybe(Fake[badTimeRange](f, 1)).Error() }, reflect.TypeOf(badTimeRange{}), "T"},
+		{func() error { return iter.Maybe(Fake[badPrecision](f, 1)).Error() }, reflect.TypeOf(badPrecision{}), "D"},
+		{func() error { return iter.Maybe(Fake[badScale](f, 1)).Error() }, reflect.TypeOf(badScale{}), "D"},
+		{func() error { return iter.Maybe(Fake[badRef](f, 1)).Error() }, reflect.TypeOf(badRef{}), "I"},
+		{func() error { return iter.Maybe(Fake[badStruct](f, 1)).Error() }, reflect.TypeOf(badStruct{}), "L"},
+	} {
+		fld, _ := tc.typ.FieldByName(tc.field)
+		assert.Equal(t, fmt.Errorf(errFakeInvalidTagMsg, fld.Tag.Get("fake"), tc.typ, tc.field), tc.it(), tc.typ.String())
+	}
+}
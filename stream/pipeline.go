@@ -0,0 +1,109 @@
+package stream
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bantling/micro/iter"
+)
+
+// Constants
+var (
+	errStageMsg = "Stage %s: %s"
+)
+
+// StageError is an error that occurred in a named stage of a stream, which identifies the stage by name
+type StageError struct {
+	Name string
+	Err  error
+}
+
+// Error is the error interface
+func (e StageError) Error() string {
+	return fmt.Sprintf(errStageMsg, e.Name, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e StageError) Unwrap() error {
+	return e.Err
+}
+
+// Pipeline is a composition of named transforms from Iter[T] to Iter[U].
+// The stage names are recorded in the order they are added, so they can be used in logs and error messages.
+// Stages are added with AddStage, as a method cannot introduce the additional type parameter required.
+type Pipeline[T, U any] struct {
+	stages    []string
+	transform func(iter.Iter[T]) iter.Iter[U]
+}
+
+// Named wraps a transform so that any non-EOI error it returns is a StageError with the given name.
+// If the error is already a StageError (eg, an error from an earlier named stage that passes through this stage),
+// it is returned as is, so that the error identifies the stage where it originated.
+func Named[T, U any](name string, transform func(iter.Iter[T]) iter.Iter[U]) func(iter.Iter[T]) iter.Iter[U] {
+	return func(it iter.Iter[T]) iter.Iter[U] {
+		res := transform(it)
+
+		return iter.OfIter(func() (U, error) {
+			val, err := res.Next()
+			if (err == nil) || (err == iter.EOI) {
+				return val, err
+			}
+
+			var se StageError
+			if errors.As(err, &se) {
+				return val, err
+			}
+
+			return val, StageError{Name: name, Err: err}
+		})
+	}
+}
+
+// NewPipeline constructs a Pipeline of no stages, that passes all elements through unchanged
+func NewPipeline[T any]() Pipeline[T, T] {
+	return Pipeline[T, T]{
+		transform: func(it iter.Iter[T]) iter.Iter[T] {
+			return it
+		},
+	}
+}
+
+// AddStage returns a new Pipeline that is the given Pipeline followed by the given transform, which is wrapped with
+// Named using the given name. The given Pipeline is not modified.
+func AddStage[T, U, V any](p Pipeline[T, U], name string, transform func(iter.Iter[U]) iter.Iter[V]) Pipeline[T, V] {
+	var (
+		stages = append(append(make([]string, 0, len(p.stages)+1), p.stages...), name)
+		first  = p.transform
+		second = Named(name, transform)
+	)
+
+	return Pipeline[T, V]{
+		stages: stages,
+		transform: func(it iter.Iter[T]) iter.Iter[V] {
+			return second(first(it))
+		},
+	}
+}
+
+// Stages returns a copy of the stage names, in the order they were added
+func (p Pipeline[T, U]) Stages() []string {
+	return append([]string{}, p.stages...)
+}
+
+// String returns the stage names separated by " -> "
+func (p Pipeline[T, U]) String() string {
+	return strings.Join(p.stages, " -> ")
+}
+
+// Transform returns the composition of all stages as a single transform
+func (p Pipeline[T, U]) Transform() func(iter.Iter[T]) iter.Iter[U] {
+	return p.transform
+}
+
+// Apply applies all stages to the given Iter
+func (p Pipeline[T, U]) Apply(it iter.Iter[T]) iter.Iter[U] {
+	return p.transform(it)
+}
@@ -0,0 +1,74 @@
+package stream
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/bantling/micro/iter"
+	"github.com/bantling/micro/union"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStageError_(t *testing.T) {
+	err := fmt.Errorf("An err")
+	se := StageError{Name: "foo", Err: err}
+	assert.Equal(t, "Stage foo: An err", se.Error())
+	assert.Equal(t, err, se.Unwrap())
+	assert.True(t, errors.Is(se, err))
+}
+
+func TestNamed_(t *testing.T) {
+	// Values and EOI pass through
+	it := Named("double", Map(func(i int) int { return i * 2 }))(iter.Of(1, 2))
+	assert.Equal(t, union.OfResult(2), iter.Maybe(it))
+	assert.Equal(t, union.OfResult(4), iter.Maybe(it))
+	assert.Equal(t, union.OfError[int](iter.EOI), iter.Maybe(it))
+
+	// Errors are wrapped
+	err := fmt.Errorf("An err")
+	it = Named("double", Map(func(i int) int { return i * 2 }))(iter.SetError(iter.Of(1), err))
+	assert.Equal(t, union.OfResult(2), iter.Maybe(it))
+	assert.Equal(t, union.OfError[int](StageError{Name: "double", Err: err}), iter.Maybe(it))
+
+	// Errors from an earlier named stage are not wrapped again
+	it = Named("second", Map(func(i int) int { return i }))(
+		Named("first", MapError(func(i int) (int, error) { return 0, err }))(iter.Of(1)),
+	)
+	assert.Equal(t, union.OfError[int](StageError{Name: "first", Err: err}), iter.Maybe(it))
+}
+
+func TestPipeline_(t *testing.T) {
+	p0 := NewPipeline[int]()
+	assert.Equal(t, []string{}, p0.Stages())
+	assert.Equal(t, "", p0.String())
+
+	it := p0.Apply(iter.Of(1))
+	assert.Equal(t, union.OfResult(1), iter.Maybe(it))
+	assert.Equal(t, union.OfError[int](iter.EOI), iter.Maybe(it))
+
+	p1 := AddStage(p0, "filter even", Filter(func(i int) bool { return i%2 == 0 }))
+	p2 := AddStage(p1, "to string", Map(strconv.Itoa))
+	assert.Equal(t, []string{"filter even"}, p1.Stages())
+	assert.Equal(t, []string{"filter even", "to string"}, p2.Stages())
+	assert.Equal(t, "filter even -> to string", p2.String())
+
+	its := p2.Transform()(iter.Of(1, 2, 3, 4))
+	assert.Equal(t, union.OfResult("2"), iter.Maybe(its))
+	assert.Equal(t, union.OfResult("4"), iter.Maybe(its))
+	assert.Equal(t, union.OfError[string](iter.EOI), iter.Maybe(its))
+
+	// Stages are independent
+	p3 := AddStage(p1, "square", Map(func(i int) int { return i * i }))
+	assert.Equal(t, []string{"filter even", "square"}, p3.Stages())
+	assert.Equal(t, []string{"filter even", "to string"}, p2.Stages())
+
+	// Error identifies the stage
+	err := fmt.Errorf("An err")
+	p4 := AddStage(p1, "fail", MapError(func(i int) (int, error) { return 0, err }))
+	it = p4.Apply(iter.Of(2))
+	assert.Equal(t, union.OfError[int](StageError{Name: "fail", Err: err}), iter.Maybe(it))
+}
@@ -0,0 +1,229 @@
+package stream
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	gomath "math"
+	"sort"
+
+	"github.com/bantling/micro/constraint"
+	"github.com/bantling/micro/iter"
+)
+
+// Constants
+var (
+	errQuantileEpsilonMsg = "A quantile epsilon must be greater than 0 and less than 1, not %v"
+	errQuantilePhiMsg     = "A quantile must be between 0 and 1 inclusive, not %v"
+	errHistogramBoundsMsg = "Histogram bounds must be in strictly ascending order, %v is not greater than %v"
+)
+
+// gkTuple is an entry of a QuantileSketch.
+// g is the rank of val minus the rank of the previous entry, and delta is the uncertainty of the rank of val.
+type gkTuple[T constraint.Ordered] struct {
+	val   T
+	g     uint64
+	delta uint64
+}
+
+// QuantileSketch is a Greenwald-Khanna summary of a set of values, that can answer any quantile query with a rank error
+// of at most epsilon * the number of values, using O((1 / epsilon) * log(epsilon * n)) memory.
+// The minimum and maximum are exact.
+//
+// A QuantileSketch is not safe for concurrent use.
+type QuantileSketch[T constraint.Ordered] struct {
+	epsilon float64
+	period  uint64
+	n       uint64
+	tuples  []gkTuple[T]
+}
+
+// NewQuantileSketch constructs a QuantileSketch with the given maximum rank error, such as 0.01 for 1%.
+//
+// Panics if epsilon is not greater than 0 and less than 1.
+func NewQuantileSketch[T constraint.Ordered](epsilon float64) *QuantileSketch[T] {
+	if !((epsilon > 0) && (epsilon < 1)) {
+		panic(fmt.Errorf(errQuantileEpsilonMsg, epsilon))
+	}
+
+	// Compress after every 1 / (2 * epsilon) values
+	return &QuantileSketch[T]{epsilon: epsilon, period: uint64(gomath.Max(1, 1/(2*epsilon)))}
+}
+
+// Add adds a value to the sketch
+func (s *QuantileSketch[T]) Add(val T) {
+	// A new minimum or maximum has an exact rank, any other value has the maximum uncertainty allowed
+	var (
+		i     = sort.Search(len(s.tuples), func(i int) bool { return s.tuples[i].val > val })
+		delta uint64
+	)
+
+	if max := uint64(2 * s.epsilon * float64(s.n)); (i > 0) && (i < len(s.tuples)) && (max > 0) {
+		// g + delta of the new entry must not exceed the maximum, where g is 1
+		delta = max - 1
+	}
+
+	s.tuples = append(s.tuples, gkTuple[T]{})
+	copy(s.tuples[i+1:], s.tuples[i:])
+	s.tuples[i] = gkTuple[T]{val, 1, delta}
+
+	if s.n++; s.n%s.period == 0 {
+		s.compress()
+	}
+}
+
+// compress merges each entry into the next one where the result is still within the maximum uncertainty.
+// The first and last entries are never merged away, so that the minimum and maximum are exact.
+func (s *QuantileSketch[T]) compress() {
+	var (
+		max = uint64(2 * s.epsilon * float64(s.n))
+		j   = len(s.tuples) - 1
+	)
+
+	// Walk backwards, keeping tuples[j:] as the compressed result
+	for i := len(s.tuples) - 2; i >= 1; i-- {
+		if next := s.tuples[j]; s.tuples[i].g+next.g+next.delta <= max {
+			s.tuples[j].g += s.tuples[i].g
+		} else {
+			j--
+			s.tuples[j] = s.tuples[i]
+		}
+	}
+
+	if j > 1 {
+		keep := copy(s.tuples[1:], s.tuples[j:])
+		s.tuples = s.tuples[:1+keep]
+	}
+}
+
+// Count returns the number of values added
+func (s *QuantileSketch[T]) Count() uint64 {
+	return s.n
+}
+
+// Quantile returns the value at the given quantile, where 0 is the minimum, 0.5 is the median, and 1 is the maximum.
+// The result is (zero value, false) if no values have been added.
+//
+// Panics if phi is not between 0 and 1 inclusive.
+func (s *QuantileSketch[T]) Quantile(phi float64) (T, bool) {
+	if !((phi >= 0) && (phi <= 1)) {
+		panic(fmt.Errorf(errQuantilePhiMsg, phi))
+	}
+
+	var zv T
+	if s.n == 0 {
+		return zv, false
+	}
+
+	if phi == 1 {
+		return s.tuples[len(s.tuples)-1].val, true
+	}
+
+	// Return the first value whose rank range is within the error of the desired rank, or failing that, the value whose
+	// rank range is closest to the desired rank
+	var (
+		rank    = gomath.Max(1, gomath.Ceil(phi*float64(s.n)))
+		maxErr  = s.epsilon * float64(s.n)
+		rmin    uint64
+		best    T
+		bestErr = gomath.Inf(1)
+	)
+
+	for _, t := range s.tuples {
+		rmin += t.g
+		tErr := gomath.Max(rank-float64(rmin), float64(rmin+t.delta)-rank)
+		if tErr <= maxErr {
+			return t.val, true
+		}
+
+		if tErr < bestErr {
+			best, bestErr = t.val, tErr
+		}
+	}
+
+	return best, true
+}
+
+// Quantiles reduces the elements to the values at each given quantile, using a QuantileSketch with the given epsilon.
+// Eg, Quantiles(0.001, 0.5, 0.95, 0.99) computes the median, 95th and 99th percentile of latencies with a rank error of
+// at most 0.1%. If the input set is empty, the result is empty.
+// For floats, the result is unspecified if the input contains NaN.
+//
+// Panics if epsilon is not greater than 0 and less than 1, or any quantile is not between 0 and 1 inclusive.
+func Quantiles[T constraint.Ordered](epsilon float64, phis ...float64) func(iter.Iter[T]) iter.Iter[[]T] {
+	if !((epsilon > 0) && (epsilon < 1)) {
+		panic(fmt.Errorf(errQuantileEpsilonMsg, epsilon))
+	}
+
+	for _, phi := range phis {
+		if !((phi >= 0) && (phi <= 1)) {
+			panic(fmt.Errorf(errQuantilePhiMsg, phi))
+		}
+	}
+
+	return func(it iter.Iter[T]) iter.Iter[[]T] {
+		return iter.OfIter(func() ([]T, error) {
+			var (
+				sketch = NewQuantileSketch[T](epsilon)
+				val    T
+				err    error
+			)
+
+			for {
+				if val, err = it.Next(); err != nil {
+					break
+				}
+
+				sketch.Add(val)
+			}
+
+			if (err != iter.EOI) || (sketch.n == 0) {
+				return nil, err
+			}
+
+			result := make([]T, len(phis))
+			for i, phi := range phis {
+				result[i], _ = sketch.Quantile(phi)
+			}
+
+			return result, nil
+		})
+	}
+}
+
+// Buckets is the result of Histogram.
+// Counts has one more element than Bounds, where each element is the number of values in a bucket:
+//   - Counts[0] is the number of values < Bounds[0]
+//   - Counts[i] is the number of values >= Bounds[i-1] and < Bounds[i]
+//   - Counts[len(Bounds)] is the number of values >= the last bound
+type Buckets[T constraint.Ordered] struct {
+	Bounds []T
+	Counts []uint
+}
+
+// Histogram reduces the elements to the number of elements in each bucket described by the given bounds, using memory
+// proportional to the number of bounds. See Buckets. If the input set is empty, every count is zero.
+// For floats, the bucket a NaN is counted in is unspecified.
+//
+// Panics if the bounds are not in strictly ascending order.
+func Histogram[T constraint.Ordered](bounds ...T) func(iter.Iter[T]) iter.Iter[Buckets[T]] {
+	// Copy the bounds, in case the caller modifies them
+	bounds = append([]T{}, bounds...)
+
+	for i := 1; i < len(bounds); i++ {
+		if !(bounds[i] > bounds[i-1]) {
+			panic(fmt.Errorf(errHistogramBoundsMsg, bounds[i], bounds[i-1]))
+		}
+	}
+
+	return func(it iter.Iter[T]) iter.Iter[Buckets[T]] {
+		// Each iter has its own result, with a copy of the bounds that cannot affect other results
+		return ReduceTo(
+			func(b Buckets[T], val T) Buckets[T] {
+				b.Counts[sort.Search(len(b.Bounds), func(i int) bool { return val < b.Bounds[i] })]++
+				return b
+			},
+			Buckets[T]{Bounds: append([]T{}, bounds...), Counts: make([]uint, len(bounds)+1)},
+		)(it)
+	}
+}
@@ -0,0 +1,266 @@
+package stream
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	gomath "math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/iter"
+	"github.com/bantling/micro/union"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuantileSketch_(t *testing.T) {
+	// Empty
+	s := NewQuantileSketch[int](0.01)
+	assert.Equal(t, uint64(0), s.Count())
+	_, ok := s.Quantile(0.5)
+	assert.False(t, ok)
+
+	// One value
+	s.Add(5)
+	for _, phi := range []float64{0, 0.5, 1} {
+		val, ok := s.Quantile(phi)
+		assert.Equal(t, 5, val)
+		assert.True(t, ok)
+	}
+
+	// 0 .. 99_999 in a scrambled order, so that the rank of each value is the value + 1
+	const n = 100_000
+	for _, epsilon := range []float64{0.1, 0.01, 0.001} {
+		s = NewQuantileSketch[int](epsilon)
+		for i := 0; i < n; i++ {
+			s.Add((i * 7919) % n)
+		}
+
+		assert.Equal(t, uint64(n), s.Count())
+
+		// The minimum and maximum are exact
+		assert.Equal(t, 0, funcs.FirstValue2(s.Quantile(0)))
+		assert.Equal(t, n-1, funcs.FirstValue2(s.Quantile(1)))
+
+		// Every other quantile is within the rank error
+		for phi := 0.0; phi <= 1; phi += 0.01 {
+			val, _ := s.Quantile(phi)
+			diff := val + 1 - int(phi*n)
+			assert.LessOrEqual(t, float64(funcs.Ternary(diff < 0, -diff, diff)), epsilon*n, fmt.Sprintf("epsilon %v phi %v", epsilon, phi))
+		}
+
+		// Memory is bounded
+		assert.Less(t, len(s.tuples), int(3/epsilon), epsilon)
+	}
+
+	// Epsilon > 0.5 compresses after every value
+	s = NewQuantileSketch[int](0.9)
+	for i := 0; i < 10; i++ {
+		s.Add(i)
+	}
+	assert.Equal(t, 0, funcs.FirstValue2(s.Quantile(0)))
+	assert.Equal(t, 9, funcs.FirstValue2(s.Quantile(1)))
+
+	// Invalid args
+	for _, epsilon := range []float64{0, -1, 1, 2} {
+		funcs.TryTo(
+			func() {
+				NewQuantileSketch[int](epsilon)
+				assert.Fail(t, "Must die")
+			},
+			func(e any) {
+				assert.Equal(t, fmt.Errorf(errQuantileEpsilonMsg, epsilon), e)
+			},
+		)
+	}
+
+	funcs.TryTo(
+		func() {
+			s.Quantile(1.5)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errQuantilePhiMsg, 1.5), e)
+		},
+	)
+}
+
+func TestQuantileSketchRandom_(t *testing.T) {
+	// A stream that once returned the maximum for the 25th percentile
+	s := NewQuantileSketch[int](0.1)
+	for _, val := range []int{984059, 902081, 941318, 954425, 122540, 240456, 203300} {
+		s.Add(val)
+	}
+	assert.Equal(t, 203300, funcs.FirstValue2(s.Quantile(0.25)))
+
+	// rankErr returns how far the rank of val in the sorted values is from the given rank, where duplicates of val have
+	// every rank from the first to the last
+	rankErr := func(sorted []int, val int, rank int) int {
+		var (
+			lo = sort.SearchInts(sorted, val) + 1
+			hi = sort.SearchInts(sorted, val+1)
+		)
+
+		switch {
+		case rank < lo:
+			return lo - rank
+		case rank > hi:
+			return rank - hi
+		}
+
+		return 0
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	for _, epsilon := range []float64{0.1, 0.05, 0.01} {
+		for i := 0; i < 500; i++ {
+			var (
+				n      = 1 + rnd.Intn(2000)
+				s      = NewQuantileSketch[int](epsilon)
+				sorted = make([]int, n)
+			)
+
+			for j := range sorted {
+				sorted[j] = rnd.Intn(funcs.Ternary(i%2 == 0, 1_000_000, 100))
+				s.Add(sorted[j])
+			}
+			sort.Ints(sorted)
+
+			for phi := 0.0; phi <= 1; phi += 0.05 {
+				var (
+					val, _ = s.Quantile(phi)
+					rank   = int(gomath.Max(1, gomath.Ceil(phi*float64(n))))
+				)
+
+				assert.LessOrEqual(t, float64(rankErr(sorted, val, rank)), epsilon*float64(n), fmt.Sprintf("epsilon %v n %d phi %v", epsilon, n, phi))
+			}
+		}
+	}
+}
+
+func TestQuantiles_(t *testing.T) {
+	// Latencies where most requests are fast, and a few are slow
+	var latencies []float64
+	for i := 0; i < 10_000; i++ {
+		latencies = append(latencies, float64(i%100)+funcs.Ternary(i%100 == 99, 1000.0, 0))
+	}
+
+	result := iter.Maybe(Quantiles[float64](0.001, 0, 0.5, 0.98, 1)(iter.OfSlice(latencies)))
+	assert.Nil(t, result.Error())
+
+	sorted := append([]float64{}, latencies...)
+	sort.Float64s(sorted)
+
+	qs := result.Get()
+	assert.Equal(t, 4, len(qs))
+	assert.Equal(t, 0.0, qs[0])
+	assert.InDelta(t, sorted[5000], qs[1], 1)
+	assert.InDelta(t, sorted[9800], qs[2], 1)
+	assert.Equal(t, 1099.0, qs[3])
+
+	// Strings are ordered
+	assert.Equal(t, union.OfResult([]string{"a", "c", "e"}), iter.Maybe(Quantiles[string](0.01, 0, 0.5, 1)(iter.Of("e", "b", "c", "a", "d"))))
+
+	// No quantiles
+	assert.Equal(t, union.OfResult([]int{}), iter.Maybe(Quantiles[int](0.01)(iter.Of(1))))
+
+	// Empty
+	assert.Equal(t, union.OfError[[]int](iter.EOI), iter.Maybe(Quantiles[int](0.01, 0.5)(iter.OfEmpty[int]())))
+
+	// Only one result
+	it := Quantiles[int](0.01, 0.5)(iter.Of(1, 2, 3))
+	assert.Equal(t, union.OfResult([]int{2}), iter.Maybe(it))
+	assert.Equal(t, union.OfError[[]int](iter.EOI), iter.Maybe(it))
+
+	// Error
+	anErr := fmt.Errorf("An err")
+	assert.Equal(
+		t,
+		union.OfError[[]int](anErr),
+		iter.Maybe(Quantiles[int](0.01, 0.5)(iter.OfScript(iter.ValueStep(1), iter.ErrorStep[int](anErr)))),
+	)
+
+	// Invalid args
+	funcs.TryTo(
+		func() {
+			Quantiles[int](0, 0.5)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errQuantileEpsilonMsg, 0.0), e)
+		},
+	)
+
+	funcs.TryTo(
+		func() {
+			Quantiles[int](0.01, 0.5, -0.1)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errQuantilePhiMsg, -0.1), e)
+		},
+	)
+}
+
+func TestHistogram_(t *testing.T) {
+	// Bucket boundaries are inclusive on the lower bound
+	assert.Equal(
+		t,
+		union.OfResult(Buckets[int]{Bounds: []int{10, 100, 1000}, Counts: []uint{2, 3, 1, 2}}),
+		iter.Maybe(Histogram(10, 100, 1000)(iter.Of(1, 9, 10, 50, 99, 100, 1000, 5000))),
+	)
+
+	// Floats
+	assert.Equal(
+		t,
+		union.OfResult(Buckets[float64]{Bounds: []float64{0.5}, Counts: []uint{1, 2}}),
+		iter.Maybe(Histogram(0.5)(iter.Of(0.25, 0.5, 0.75))),
+	)
+
+	// No bounds is one bucket
+	assert.Equal(
+		t,
+		union.OfResult(Buckets[int]{Bounds: []int{}, Counts: []uint{3}}),
+		iter.Maybe(Histogram[int]()(iter.Of(1, 2, 3))),
+	)
+
+	// Empty has zero counts
+	assert.Equal(
+		t,
+		union.OfResult(Buckets[int]{Bounds: []int{10}, Counts: []uint{0, 0}}),
+		iter.Maybe(Histogram(10)(iter.OfEmpty[int]())),
+	)
+
+	// Each iter has its own result, and the bounds are copied
+	var (
+		bounds = []int{10}
+		hist   = Histogram(bounds...)
+	)
+
+	bounds[0] = 20
+	assert.Equal(t, union.OfResult(Buckets[int]{Bounds: []int{10}, Counts: []uint{1, 0}}), iter.Maybe(hist(iter.Of(1))))
+	assert.Equal(t, union.OfResult(Buckets[int]{Bounds: []int{10}, Counts: []uint{0, 1}}), iter.Maybe(hist(iter.Of(11))))
+
+	// Error
+	anErr := fmt.Errorf("An err")
+	assert.Equal(
+		t,
+		union.OfError[Buckets[int]](anErr),
+		iter.Maybe(Histogram(10)(iter.OfScript(iter.ValueStep(1), iter.ErrorStep[int](anErr)))),
+	)
+
+	// Invalid bounds
+	for _, bounds := range [][]int{{1, 1}, {1, 3, 2}} {
+		funcs.TryTo(
+			func() {
+				Histogram(bounds...)
+				assert.Fail(t, "Must die")
+			},
+			func(e any) {
+				assert.Equal(t, fmt.Errorf(errHistogramBoundsMsg, bounds[len(bounds)-1], bounds[len(bounds)-2]), e)
+			},
+		)
+	}
+}
@@ -0,0 +1,298 @@
+package stream
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	gomath "math"
+	"math/bits"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/iter"
+)
+
+// Constants
+var (
+	errHyperLogLogPrecisionMsg = "A HyperLogLog precision must be between %d and %d, not %d"
+	errHyperLogLogMergeMsg     = "Cannot merge a HyperLogLog of precision %d into a HyperLogLog of precision %d"
+	errCountMinArgsMsg         = "A CountMinSketch epsilon and delta must be greater than 0 and less than 1, not %v and %v"
+	errCountMinMergeMsg        = "Cannot merge a CountMinSketch of %d x %d counters into a CountMinSketch of %d x %d counters"
+)
+
+const (
+	// hllMinPrecision and hllMaxPrecision are the range of HyperLogLog precisions
+	hllMinPrecision = 4
+	hllMaxPrecision = 18
+)
+
+// Sketch is a summary of a set of values that can be merged with another summary of the same kind, such that the
+// result is the same as a single summary of both sets of values.
+type Sketch[S any] interface {
+	Merge(S) error
+}
+
+// HyperLogLog estimates the number of distinct values in a set, using 2^precision bytes of memory.
+// The standard error of the estimate is 1.04 / sqrt(2^precision), eg 0.8% for a precision of 14.
+//
+// Values are hashed by a function that defaults to a hash of the %#v formatting of the value. Only sketches that use
+// the same hash function can be merged.
+//
+// A HyperLogLog is not safe for concurrent use.
+type HyperLogLog[T any] struct {
+	precision uint
+	hash      func(T) uint64
+	registers []uint8
+}
+
+// NewHyperLogLog constructs a HyperLogLog with the given precision, and an optional hash function.
+//
+// Panics if precision is not between 4 and 18.
+func NewHyperLogLog[T any](precision uint, hash ...func(T) uint64) *HyperLogLog[T] {
+	if (precision < hllMinPrecision) || (precision > hllMaxPrecision) {
+		panic(fmt.Errorf(errHyperLogLogPrecisionMsg, hllMinPrecision, hllMaxPrecision, precision))
+	}
+
+	return &HyperLogLog[T]{
+		precision: precision,
+		hash:      funcs.SliceIndex(hash, 0, defaultHash[T]),
+		registers: make([]uint8, 1<<precision),
+	}
+}
+
+// Add adds a value
+func (h *HyperLogLog[T]) Add(val T) {
+	// The first precision bits choose a register, the register keeps the maximum position of the first 1 bit of the rest
+	var (
+		x   = mix64(h.hash(val))
+		idx = x >> (64 - h.precision)
+		// Set the last bit, so there is always a 1 bit
+		rho = uint8(bits.LeadingZeros64((x<<h.precision)|(1<<(h.precision-1))) + 1)
+	)
+
+	if rho > h.registers[idx] {
+		h.registers[idx] = rho
+	}
+}
+
+// Merge merges another HyperLogLog into this one, so that it estimates the union of both sets of values.
+// It is an error if the precisions are different.
+func (h *HyperLogLog[T]) Merge(o *HyperLogLog[T]) error {
+	if h.precision != o.precision {
+		return fmt.Errorf(errHyperLogLogMergeMsg, o.precision, h.precision)
+	}
+
+	for i, r := range o.registers {
+		if r > h.registers[i] {
+			h.registers[i] = r
+		}
+	}
+
+	return nil
+}
+
+// Estimate returns the estimated number of distinct values
+func (h *HyperLogLog[T]) Estimate() uint64 {
+	var (
+		m     = float64(len(h.registers))
+		sum   float64
+		zeros int
+		alpha float64
+	)
+
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	switch h.precision {
+	case 4:
+		alpha = 0.673
+	case 5:
+		alpha = 0.697
+	case 6:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+
+	// Small cardinalities are better estimated by linear counting of the empty registers.
+	// A 64 bit hash does not need the large cardinality correction of the original algorithm.
+	est := alpha * m * m / sum
+	if (est <= 2.5*m) && (zeros > 0) {
+		est = m * gomath.Log(m/float64(zeros))
+	}
+
+	return uint64(gomath.Round(est))
+}
+
+// CountMinSketch estimates the number of times each value occurs in a set, using a table of depth x width counters.
+// An estimate is never less than the actual count, and exceeds it by at most epsilon * the total number of values, with
+// a probability of 1 - delta.
+//
+// Values are hashed by a function that defaults to a hash of the %#v formatting of the value. Only sketches that use
+// the same hash function can be merged.
+//
+// A CountMinSketch is not safe for concurrent use.
+type CountMinSketch[T any] struct {
+	width  uint
+	depth  uint
+	hash   func(T) uint64
+	counts []uint64
+	total  uint64
+}
+
+// NewCountMinSketch constructs a CountMinSketch with the given error rate and probability of exceeding it, and an
+// optional hash function. Eg, NewCountMinSketch(0.001, 0.01) has a 99% probability of each estimate exceeding the
+// actual count by at most 0.1% of the total, using 2719 x 5 counters.
+//
+// Panics if epsilon or delta are not greater than 0 and less than 1.
+func NewCountMinSketch[T any](epsilon, delta float64, hash ...func(T) uint64) *CountMinSketch[T] {
+	if !((epsilon > 0) && (epsilon < 1) && (delta > 0) && (delta < 1)) {
+		panic(fmt.Errorf(errCountMinArgsMsg, epsilon, delta))
+	}
+
+	var (
+		width = uint(gomath.Ceil(gomath.E / epsilon))
+		depth = uint(gomath.Ceil(gomath.Log(1 / delta)))
+	)
+
+	return &CountMinSketch[T]{
+		width:  width,
+		depth:  depth,
+		hash:   funcs.SliceIndex(hash, 0, defaultHash[T]),
+		counts: make([]uint64, width*depth),
+	}
+}
+
+// index returns the counter of a value in each row, where the hash of each row is derived from two halves of one hash
+func (c *CountMinSketch[T]) index(val T, fn func(int)) {
+	var (
+		x      = mix64(c.hash(val))
+		h1, h2 = x & 0xFFFFFFFF, x >> 32
+	)
+
+	for i := uint(0); i < c.depth; i++ {
+		fn(int(i*c.width + uint((h1+uint64(i)*h2)%uint64(c.width))))
+	}
+}
+
+// Add adds a value
+func (c *CountMinSketch[T]) Add(val T) {
+	c.total++
+	c.index(val, func(i int) { c.counts[i]++ })
+}
+
+// Count returns the estimated number of times a value has been added
+func (c *CountMinSketch[T]) Count(val T) uint64 {
+	var est uint64 = gomath.MaxUint64
+	c.index(val, func(i int) {
+		if c.counts[i] < est {
+			est = c.counts[i]
+		}
+	})
+
+	return est
+}
+
+// Total returns the number of values added
+func (c *CountMinSketch[T]) Total() uint64 {
+	return c.total
+}
+
+// Merge merges another CountMinSketch into this one, so that it estimates the counts of both sets of values.
+// It is an error if the sketches have a different number of counters.
+func (c *CountMinSketch[T]) Merge(o *CountMinSketch[T]) error {
+	if (c.width != o.width) || (c.depth != o.depth) {
+		return fmt.Errorf(errCountMinMergeMsg, o.depth, o.width, c.depth, c.width)
+	}
+
+	for i, n := range o.counts {
+		c.counts[i] += n
+	}
+
+	c.total += o.total
+	return nil
+}
+
+// CountDistinctApprox reduces the elements to a HyperLogLog of the given precision and optional hash function, whose
+// Estimate is the approximate number of distinct elements. If the input set is empty, the result is an empty
+// HyperLogLog.
+//
+// Each iter the result is applied to has its own HyperLogLog, so it can be used in Parallel to produce one sketch per
+// bucket, which are combined by MergeSketches.
+//
+// Panics if precision is not between 4 and 18.
+func CountDistinctApprox[T any](precision uint, hash ...func(T) uint64) func(iter.Iter[T]) iter.Iter[*HyperLogLog[T]] {
+	// Validate the args now
+	NewHyperLogLog(precision, hash...)
+
+	return func(it iter.Iter[T]) iter.Iter[*HyperLogLog[T]] {
+		return ReduceTo(
+			func(h *HyperLogLog[T], val T) *HyperLogLog[T] {
+				h.Add(val)
+				return h
+			},
+			NewHyperLogLog(precision, hash...),
+		)(it)
+	}
+}
+
+// FrequencyApprox reduces the elements to a CountMinSketch with the given epsilon, delta, and optional hash function,
+// whose Count is the approximate number of times an element occurs. If the input set is empty, the result is an empty
+// CountMinSketch.
+//
+// Each iter the result is applied to has its own CountMinSketch, so it can be used in Parallel to produce one sketch per
+// bucket, which are combined by MergeSketches.
+//
+// Panics if epsilon or delta are not greater than 0 and less than 1.
+func FrequencyApprox[T any](epsilon, delta float64, hash ...func(T) uint64) func(iter.Iter[T]) iter.Iter[*CountMinSketch[T]] {
+	// Validate the args now
+	NewCountMinSketch(epsilon, delta, hash...)
+
+	return func(it iter.Iter[T]) iter.Iter[*CountMinSketch[T]] {
+		return ReduceTo(
+			func(c *CountMinSketch[T], val T) *CountMinSketch[T] {
+				c.Add(val)
+				return c
+			},
+			NewCountMinSketch(epsilon, delta, hash...),
+		)(it)
+	}
+}
+
+// MergeSketches reduces sketches to the first sketch, after merging all the others into it.
+// If the input set is empty, the result is empty.
+// The resulting iter can return any kind of error from source iter, an error from Merge, or EOI.
+func MergeSketches[S Sketch[S]](it iter.Iter[S]) iter.Iter[S] {
+	var done bool
+
+	return iter.OfIter(func() (S, error) {
+		var zv S
+
+		if done {
+			return zv, iter.EOI
+		}
+
+		done = true
+
+		result, err := it.Next()
+		if err != nil {
+			return zv, err
+		}
+
+		for {
+			sketch, err := it.Next()
+			if err == iter.EOI {
+				return result, nil
+			} else if err != nil {
+				return zv, err
+			}
+
+			if err = result.Merge(sketch); err != nil {
+				return zv, err
+			}
+		}
+	})
+}
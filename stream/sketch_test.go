@@ -0,0 +1,277 @@
+package stream
+
+// SPDX-License-Identifier: Apache-2.0
+
+import (
+	"fmt"
+	gomath "math"
+	"testing"
+
+	"github.com/bantling/micro/funcs"
+	"github.com/bantling/micro/iter"
+	"github.com/bantling/micro/union"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHyperLogLog_(t *testing.T) {
+	// Empty
+	h := NewHyperLogLog[int](14)
+	assert.Equal(t, uint64(0), h.Estimate())
+
+	// Small cardinalities are close to exact
+	for i := 0; i < 1000; i++ {
+		h.Add(i % 10)
+	}
+	assert.Equal(t, uint64(10), h.Estimate())
+
+	// Large cardinalities are within a few standard errors, for each precision
+	for _, precision := range []uint{4, 5, 6, 10, 14, 18} {
+		var (
+			h      = NewHyperLogLog[string](precision)
+			n      = 200_000
+			stdErr = 1.04 / gomath.Sqrt(float64(uint(1)<<precision))
+		)
+
+		for i := 0; i < n; i++ {
+			h.Add(fmt.Sprintf("user%d", i))
+		}
+
+		assert.InEpsilon(t, n, h.Estimate(), 3*stdErr, precision)
+		assert.Equal(t, 1<<precision, len(h.registers))
+	}
+
+	// Custom hash
+	h = NewHyperLogLog(12, func(i int) uint64 { return uint64(i) })
+	for i := 0; i < 50_000; i++ {
+		h.Add(i)
+	}
+	assert.InEpsilon(t, 50_000, h.Estimate(), 0.05)
+
+	// Merging overlapping sets estimates the union
+	var (
+		h1 = NewHyperLogLog[int](14)
+		h2 = NewHyperLogLog[int](14)
+	)
+
+	for i := 0; i < 60_000; i++ {
+		h1.Add(i)
+		h2.Add(i + 40_000)
+	}
+
+	assert.Nil(t, h1.Merge(h2))
+	assert.InEpsilon(t, 100_000, h1.Estimate(), 0.03)
+
+	// Errors
+	assert.Equal(t, fmt.Errorf(errHyperLogLogMergeMsg, 10, 14), h1.Merge(NewHyperLogLog[int](10)))
+
+	for _, precision := range []uint{3, 19} {
+		funcs.TryTo(
+			func() {
+				NewHyperLogLog[int](precision)
+				assert.Fail(t, "Must die")
+			},
+			func(e any) {
+				assert.Equal(t, fmt.Errorf(errHyperLogLogPrecisionMsg, 4, 18, precision), e)
+			},
+		)
+	}
+}
+
+func TestCountMinSketch_(t *testing.T) {
+	const (
+		epsilon = 0.001
+		delta   = 0.01
+	)
+
+	c := NewCountMinSketch[int](epsilon, delta)
+	assert.Equal(t, uint(2719), c.width)
+	assert.Equal(t, uint(5), c.depth)
+	assert.Equal(t, uint64(0), c.Count(1))
+
+	// Value i occurs 10_000 / i times
+	actual := map[int]uint64{}
+	for i := 1; i <= 1000; i++ {
+		for j := 0; j < 10_000/i; j++ {
+			c.Add(i)
+			actual[i]++
+		}
+	}
+
+	var total uint64
+	for _, n := range actual {
+		total += n
+	}
+	assert.Equal(t, total, c.Total())
+
+	// Never an underestimate, and within the error
+	for i, n := range actual {
+		est := c.Count(i)
+		assert.GreaterOrEqual(t, est, n)
+		assert.LessOrEqual(t, float64(est-n), epsilon*float64(total))
+	}
+
+	assert.Equal(t, uint64(10_000), c.Count(1))
+	assert.LessOrEqual(t, c.Count(5000), uint64(epsilon*float64(total)))
+
+	// Merge
+	var (
+		c1 = NewCountMinSketch[string](0.01, 0.1)
+		c2 = NewCountMinSketch[string](0.01, 0.1)
+	)
+
+	c1.Add("a")
+	c1.Add("b")
+	c2.Add("a")
+
+	assert.Nil(t, c1.Merge(c2))
+	assert.Equal(t, uint64(2), c1.Count("a"))
+	assert.Equal(t, uint64(1), c1.Count("b"))
+	assert.Equal(t, uint64(3), c1.Total())
+
+	// Custom hash
+	c3 := NewCountMinSketch(0.01, 0.1, func(s string) uint64 { return uint64(len(s)) })
+	c3.Add("ab")
+	assert.Equal(t, uint64(1), c3.Count("cd"))
+
+	// Errors
+	assert.Equal(t, fmt.Errorf(errCountMinMergeMsg, 5, 2719, 3, 272), c1.Merge(NewCountMinSketch[string](epsilon, delta)))
+
+	for _, args := range [][]float64{{0, 0.5}, {1, 0.5}, {0.5, 0}, {0.5, 1}} {
+		funcs.TryTo(
+			func() {
+				NewCountMinSketch[int](args[0], args[1])
+				assert.Fail(t, "Must die")
+			},
+			func(e any) {
+				assert.Equal(t, fmt.Errorf(errCountMinArgsMsg, args[0], args[1]), e)
+			},
+		)
+	}
+}
+
+func TestCountDistinctApprox_(t *testing.T) {
+	var values []int
+	for i := 0; i < 100_000; i++ {
+		values = append(values, i%30_000)
+	}
+
+	// Sequential
+	h := iter.Maybe(CountDistinctApprox[int](14)(iter.OfSlice(values)))
+	assert.Nil(t, h.Error())
+	assert.InEpsilon(t, 30_000, h.Get().Estimate(), 0.03)
+
+	// Buckets are merged into the same estimate
+	fn := CountDistinctApprox[int](14)
+	merged := iter.Maybe(MergeSketches(iter.Concat(fn(iter.OfSlice(values[:40_000])), fn(iter.OfSlice(values[40_000:])))))
+	assert.Nil(t, merged.Error())
+	assert.Equal(t, h.Get().Estimate(), merged.Get().Estimate())
+
+	// Parallel buckets are merged into the same estimate
+	merged = iter.Maybe(MergeSketches(Parallel(CountDistinctApprox[int](14), PInfo{N: 8})(iter.OfSlice(values))))
+	assert.Nil(t, merged.Error())
+	assert.Equal(t, h.Get().Estimate(), merged.Get().Estimate())
+
+	// Empty
+	h = iter.Maybe(CountDistinctApprox[int](14)(iter.OfEmpty[int]()))
+	assert.Equal(t, uint64(0), h.Get().Estimate())
+
+	// Each iter has its own sketch
+	fn = CountDistinctApprox[int](10)
+	assert.Equal(t, uint64(1), iter.Maybe(fn(iter.Of(1))).Get().Estimate())
+	assert.Equal(t, uint64(1), iter.Maybe(fn(iter.Of(2))).Get().Estimate())
+
+	// Error
+	anErr := fmt.Errorf("An err")
+	assert.Equal(
+		t,
+		union.OfError[*HyperLogLog[int]](anErr),
+		iter.Maybe(CountDistinctApprox[int](14)(iter.OfScript(iter.ValueStep(1), iter.ErrorStep[int](anErr)))),
+	)
+
+	// Args are validated immediately
+	funcs.TryTo(
+		func() {
+			CountDistinctApprox[int](2)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errHyperLogLogPrecisionMsg, 4, 18, 2), e)
+		},
+	)
+}
+
+func TestFrequencyApprox_(t *testing.T) {
+	var values []string
+	for i := 0; i < 10_000; i++ {
+		values = append(values, funcs.Ternary(i%10 == 0, "hot", fmt.Sprintf("cold%d", i)))
+	}
+
+	// Sequential
+	c := iter.Maybe(FrequencyApprox[string](0.001, 0.01)(iter.OfSlice(values)))
+	assert.Nil(t, c.Error())
+	assert.InDelta(t, 1000, c.Get().Count("hot"), 10)
+	assert.Equal(t, uint64(10_000), c.Get().Total())
+
+	// Buckets are merged into the same counts
+	fn := FrequencyApprox[string](0.001, 0.01)
+	merged := iter.Maybe(MergeSketches(iter.Concat(fn(iter.OfSlice(values[:2_500])), fn(iter.OfSlice(values[2_500:])))))
+	assert.Nil(t, merged.Error())
+	assert.Equal(t, c.Get().counts, merged.Get().counts)
+	assert.Equal(t, uint64(10_000), merged.Get().Total())
+
+	// Parallel buckets are merged into the same counts
+	merged = iter.Maybe(MergeSketches(Parallel(FrequencyApprox[string](0.001, 0.01), PInfo{N: 4})(iter.OfSlice(values))))
+	assert.Nil(t, merged.Error())
+	assert.Equal(t, c.Get().counts, merged.Get().counts)
+	assert.Equal(t, uint64(10_000), merged.Get().Total())
+
+	// Empty
+	c = iter.Maybe(FrequencyApprox[string](0.001, 0.01)(iter.OfEmpty[string]()))
+	assert.Equal(t, uint64(0), c.Get().Total())
+
+	// Args are validated immediately
+	funcs.TryTo(
+		func() {
+			FrequencyApprox[int](0.1, 2)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) {
+			assert.Equal(t, fmt.Errorf(errCountMinArgsMsg, 0.1, 2.0), e)
+		},
+	)
+}
+
+func TestMergeSketches_(t *testing.T) {
+	var (
+		h1 = NewHyperLogLog[int](4)
+		h2 = NewHyperLogLog[int](4)
+	)
+
+	h1.Add(1)
+	h2.Add(2)
+
+	// Merged into first
+	it := MergeSketches(iter.Of(h1, h2))
+	assert.Equal(t, union.OfResult(h1), iter.Maybe(it))
+	assert.Equal(t, uint64(2), h1.Estimate())
+	assert.Equal(t, union.OfError[*HyperLogLog[int]](iter.EOI), iter.Maybe(it))
+
+	// Empty
+	assert.Equal(t, union.OfError[*HyperLogLog[int]](iter.EOI), iter.Maybe(MergeSketches(iter.OfEmpty[*HyperLogLog[int]]())))
+
+	// Merge error
+	assert.Equal(
+		t,
+		union.OfError[*HyperLogLog[int]](fmt.Errorf(errHyperLogLogMergeMsg, 5, 4)),
+		iter.Maybe(MergeSketches(iter.Of(h1, NewHyperLogLog[int](5)))),
+	)
+
+	// Iter errors
+	anErr := fmt.Errorf("An err")
+	for _, it := range []iter.Iter[*HyperLogLog[int]]{
+		iter.OfScript(iter.ErrorStep[*HyperLogLog[int]](anErr)),
+		iter.OfScript(iter.ValueStep(h1), iter.ErrorStep[*HyperLogLog[int]](anErr)),
+	} {
+		assert.Equal(t, union.OfError[*HyperLogLog[int]](anErr), iter.Maybe(MergeSketches(it)))
+	}
+}
@@ -7,7 +7,9 @@ import (
 	gomath "math"
 	"math/bits"
 	"reflect"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/bantling/micro/constraint"
 	"github.com/bantling/micro/conv"
@@ -33,7 +35,11 @@ type PInfo struct {
 
 // Constants
 var (
-	absErrMsg = "Absolute value error for %d: there is no corresponding positive value in type %T"
+	absErrMsg            = "Absolute value error for %d: there is no corresponding positive value in type %T"
+	errWindowDurationMsg = "WindowByTime size and slide must be positive, not %s and %s"
+	errBranchNoneMsg     = "Branch requires at least one branch"
+	errBranchUnknownMsg  = "Branch selector returned %q, which is not the name of a branch"
+	errParallelMoreMsg   = "Parallel transforms produced more than the %d elements they were given"
 )
 
 // ==== Functions that provide the foundation for all other functions
@@ -76,6 +82,179 @@ func MapError[T, U any](mapper func(T) (U, error)) func(iter.Iter[T]) iter.Iter[
 	}
 }
 
+// MapErrorPartition is similar to MapError, except that iteration is not cut short by a mapper error. Instead, it
+// returns two iters: one of the successfully mapped values, and one of the inputs that failed with their errors, in the
+// order they occur. Eg, good records can continue down a pipeline, while bad records are written to a dead letter sink.
+//
+// Both iters share the source, so that reading either one reads the source as needed, keeping any elements destined
+// for the other iter until it is read. Reading one iter fully before the other keeps all elements of the other iter in
+// memory, so for large sources the iters should be read concurrently, for which they are safe.
+//
+// The resulting iters can return any kind of error from the source iter, or EOI. An error from the source is returned
+// by both iters after any elements they already have.
+func MapErrorPartition[T, U any](mapper func(T) (U, error)) func(iter.Iter[T]) (iter.Iter[U], iter.Iter[tuple.Two[T, error]]) {
+	return func(it iter.Iter[T]) (iter.Iter[U], iter.Iter[tuple.Two[T, error]]) {
+		var (
+			mu        sync.Mutex
+			successes []U
+			failures  []tuple.Two[T, error]
+			srcErr    error
+		)
+
+		// fill reads the source until have returns true, returning the source error if it ends first
+		fill := func(have func() bool) error {
+			for !have() {
+				if srcErr != nil {
+					return srcErr
+				}
+
+				var val T
+				if val, srcErr = it.Next(); srcErr == nil {
+					if mval, err := mapper(val); err == nil {
+						successes = append(successes, mval)
+					} else {
+						failures = append(failures, tuple.Of2(val, err))
+					}
+				}
+			}
+
+			return nil
+		}
+
+		successIt := iter.OfIter(func() (U, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			var zv U
+			if err := fill(func() bool { return len(successes) > 0 }); err != nil {
+				return zv, err
+			}
+
+			val := successes[0]
+			successes[0], successes = zv, successes[1:]
+			return val, nil
+		})
+
+		failureIt := iter.OfIter(func() (tuple.Two[T, error], error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			var zv tuple.Two[T, error]
+			if err := fill(func() bool { return len(failures) > 0 }); err != nil {
+				return zv, err
+			}
+
+			val := failures[0]
+			failures[0], failures = zv, failures[1:]
+			return val, nil
+		})
+
+		return successIt, failureIt
+	}
+}
+
+// Branch constructs a new Iter[tuple.Two[string, U]] that routes each element of an Iter[T] to one of several named
+// sub-pipelines, according to the name the selector returns for it, and merges the results of the sub-pipelines, each
+// tagged with the name of the branch that produced it. Eg, orders can be routed to a different pricing pipeline per
+// region, without manually splitting the source into an iter per region.
+//
+// The results are merged by taking one element from each branch in turn, in order of branch name, skipping branches
+// that have ended. As with MapErrorPartition, the branches share the source, keeping elements destined for other
+// branches until they are read, so a branch that reduces its input keeps all elements of the other branches in memory.
+// A branch can be wrapped with Named, so that its errors identify it.
+//
+// The generated transform panics if there are no branches.
+//
+// The resulting iter can return any kind of error from the source iter or a branch, or EOI. A selector result that is
+// not the name of a branch is an error, which every branch receives as a source error after any elements it already has.
+func Branch[T, U any](
+	selector func(T) string,
+	branches map[string]func(iter.Iter[T]) iter.Iter[U],
+) func(iter.Iter[T]) iter.Iter[tuple.Two[string, U]] {
+	if len(branches) == 0 {
+		panic(fmt.Errorf(errBranchNoneMsg))
+	}
+
+	names := make([]string, 0, len(branches))
+	for name := range branches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return func(it iter.Iter[T]) iter.Iter[tuple.Two[string, U]] {
+		var (
+			pending = map[string][]T{}
+			srcErr  error
+			outputs = make([]iter.Iter[U], len(names))
+			active  = len(names)
+			next    int
+		)
+
+		// fill reads the source until the named branch has an element, returning the source error if it ends first
+		fill := func(name string) error {
+			for len(pending[name]) == 0 {
+				if srcErr != nil {
+					return srcErr
+				}
+
+				var val T
+				if val, srcErr = it.Next(); srcErr == nil {
+					if sel := selector(val); branches[sel] == nil {
+						srcErr = fmt.Errorf(errBranchUnknownMsg, sel)
+					} else {
+						pending[sel] = append(pending[sel], val)
+					}
+				}
+			}
+
+			return nil
+		}
+
+		for i, name := range names {
+			name := name
+			outputs[i] = branches[name](iter.OfIter(func() (T, error) {
+				var zv T
+				if err := fill(name); err != nil {
+					return zv, err
+				}
+
+				val := pending[name][0]
+				pending[name][0], pending[name] = zv, pending[name][1:]
+				return val, nil
+			}))
+		}
+
+		return iter.OfIter(func() (tuple.Two[string, U], error) {
+			var zv tuple.Two[string, U]
+
+			for active > 0 {
+				i := next
+				if next++; next == len(names) {
+					next = 0
+				}
+
+				if outputs[i] == nil {
+					continue
+				}
+
+				val, err := outputs[i].Next()
+				if err == nil {
+					return tuple.Of2(names[i], val), nil
+				}
+
+				if err != iter.EOI {
+					return zv, err
+				}
+
+				outputs[i] = nil
+				active--
+			}
+
+			return zv, iter.EOI
+		})
+	}
+}
+
 // Filter constructs a new Iter[T] from an Iter[T] and a func that returns true if a T passes the filter.
 //
 // The resulting iter can return any kind of error from source iter, or EOI.
@@ -746,6 +925,49 @@ func Count[T any](it iter.Iter[T]) iter.Iter[int] {
 	return ReduceTo[T, int](func(c int, _ T) int { return c + 1 }, 0)(it)
 }
 
+// Delta maps each pair of consecutive elements to subtract(current, previous).
+// There is one less result than the number of elements, so an Iter of zero or one elements has no results.
+// Eg, converting cumulative counters to per interval counts: for the input 1,3,6,10 and subtract(c, p) = c - p, the
+// result is 2,3,4.
+// See PairWise.
+func Delta[T, U any](subtract func(curr, prev T) U) func(iter.Iter[T]) iter.Iter[U] {
+	return func(it iter.Iter[T]) iter.Iter[U] {
+		return Map(func(pair tuple.Two[T, T]) U {
+			return subtract(pair.U, pair.T)
+		})(PairWise(it))
+	}
+}
+
+// DeltaByKey is similar to Delta, except that elements are grouped by a key, and each element is paired with the
+// previous element that has the same key. The first element of each key has no result.
+// Eg, for elements of (key, value) of (a, 1), (b, 5), (a, 3), (b, 6), (a, 7) the result is 2 (a), 1 (b), 4 (a).
+// See Distinct for an explanation of statefulness and the usage of Generator.
+func DeltaByKey[T any, K comparable, U any](key func(T) K, subtract func(curr, prev T) U) func(iter.Iter[T]) iter.Iter[U] {
+	return Generator(func() func(iter.Iter[T]) iter.Iter[U] {
+		prevs := map[K]T{}
+
+		return func(it iter.Iter[T]) iter.Iter[U] {
+			return iter.OfIter(func() (U, error) {
+				for {
+					curr, err := it.Next()
+					if err != nil {
+						var zv U
+						return zv, err
+					}
+
+					k := key(curr)
+					prev, havePrev := prevs[k]
+					prevs[k] = curr
+
+					if havePrev {
+						return subtract(curr, prev), nil
+					}
+				}
+			})
+		}
+	})
+}
+
 // Distinct reduces Iter[T] to an Iter[T] with distinct values.
 // Distinct is a stateful transform that has to track unique values across iterator Next and Value calls.
 //
@@ -789,6 +1011,91 @@ func Duplicate[T comparable](it iter.Iter[T]) iter.Iter[T] {
 	})(it)
 }
 
+// Find is a terminal that returns the first element that passes the filter, stopping as soon as it is found.
+// The result is one of:
+// - (element, true, nil) if an element passes the filter
+// - (zero value, false, nil) if no element passes the filter
+// - (zero value, false, error) if the Iter returns a problem before an element passes the filter
+//
+// Any elements after the one found are not read.
+func Find[T any](filter func(T) bool) func(iter.Iter[T]) (T, bool, error) {
+	return func(it iter.Iter[T]) (T, bool, error) {
+		for {
+			val, err := it.Next()
+			if err != nil {
+				var zv T
+				return zv, false, funcs.Ternary(err == iter.EOI, nil, err)
+			}
+
+			if filter(val) {
+				return val, true, nil
+			}
+		}
+	}
+}
+
+// FindLast is a terminal that returns the last element that passes the filter.
+// The results are the same as Find, except that all elements have to be read, and a problem results in
+// (zero value, false, error) even if some elements passed the filter before the problem occurred.
+func FindLast[T any](filter func(T) bool) func(iter.Iter[T]) (T, bool, error) {
+	return func(it iter.Iter[T]) (T, bool, error) {
+		var (
+			last  T
+			found bool
+		)
+
+		for {
+			val, err := it.Next()
+			if err != nil {
+				if err == iter.EOI {
+					return last, found, nil
+				}
+
+				var zv T
+				return zv, false, err
+			}
+
+			if filter(val) {
+				last, found = val, true
+			}
+		}
+	}
+}
+
+// PairWise maps each pair of consecutive elements to a tuple.Two of (previous, current).
+// There is one less result than the number of elements, so an Iter of zero or one elements has no results.
+// Eg, for the input 1,2,3 the result is (1,2),(2,3).
+func PairWise[T any](it iter.Iter[T]) iter.Iter[tuple.Two[T, T]] {
+	var (
+		prev    T
+		started bool
+	)
+
+	return iter.OfIter(func() (tuple.Two[T, T], error) {
+		var zv tuple.Two[T, T]
+
+		// The first element has no previous element to pair with
+		if !started {
+			val, err := it.Next()
+			if err != nil {
+				return zv, err
+			}
+
+			prev, started = val, true
+		}
+
+		curr, err := it.Next()
+		if err != nil {
+			return zv, err
+		}
+
+		pair := tuple.Of2(prev, curr)
+		prev = curr
+
+		return pair, nil
+	})
+}
+
 // Reverse reverses all the elements.
 // The input iter must have a finite size.
 func Reverse[T any](it iter.Iter[T]) iter.Iter[T] {
@@ -881,6 +1188,115 @@ func SortBy[T any](less func(T, T) bool) func(iter.Iter[T]) iter.Iter[T] {
 	}
 }
 
+// ==== Windows
+
+// WindowByTime groups elements into windows based on a timestamp of each element (event time), where each window
+// covers the half open time range [start, start + size), and each window starts slide after the previous window:
+// - if slide = size, the windows are consecutive (tumbling)
+// - if slide < size, the windows overlap (sliding), and an element can appear in multiple windows
+// - if slide > size, there are gaps between windows, and elements in a gap do not appear in any window
+//
+// The first window starts at the timestamp of the first element. Windows that contain no elements are skipped.
+// The elements of each window are in iteration order, and each window is a new slice.
+//
+// Elements are expected to be in ascending timestamp order. An element whose timestamp is earlier than the start of the
+// current window is discarded. A window is complete when an element at or after the end of the window is read, or when
+// there are no more elements, so at most one element is read beyond each window.
+//
+// Panics if size or slide are not positive.
+func WindowByTime[T any](extractTime func(T) time.Time, size, slide time.Duration) func(iter.Iter[T]) iter.Iter[[]T] {
+	if (size <= 0) || (slide <= 0) {
+		panic(fmt.Errorf(errWindowDurationMsg, size, slide))
+	}
+
+	return func(it iter.Iter[T]) iter.Iter[[]T] {
+		var (
+			// Elements read that are in the current window or later windows
+			buf     []T
+			start   time.Time
+			started bool
+			eoi     bool
+		)
+
+		// next reads the next element into buf, returning false when there are no more elements
+		next := func() (bool, error) {
+			if eoi {
+				return false, nil
+			}
+
+			val, err := it.Next()
+			if err == iter.EOI {
+				eoi = true
+				return false, nil
+			} else if err != nil {
+				return false, err
+			}
+
+			buf = append(buf, val)
+			return true, nil
+		}
+
+		return iter.OfIter(func() ([]T, error) {
+			if !started {
+				if ok, err := next(); !ok {
+					return nil, funcs.Ternary(err == nil, iter.EOI, err)
+				}
+
+				start, started = extractTime(buf[0]), true
+			} else {
+				start = start.Add(slide)
+			}
+
+			// Discard elements before the window start, and skip empty windows
+			for {
+				var kept []T
+				for _, val := range buf {
+					if !extractTime(val).Before(start) {
+						kept = append(kept, val)
+					}
+				}
+				buf = kept
+
+				if len(buf) == 0 {
+					if ok, err := next(); !ok {
+						return nil, funcs.Ternary(err == nil, iter.EOI, err)
+					}
+				}
+
+				// Advance the start by as many slides as needed for the earliest element to be before the end
+				earliest := extractTime(buf[0])
+				if end := start.Add(size); !earliest.Before(end) {
+					start = start.Add((earliest.Sub(end)/slide + 1) * slide)
+				}
+
+				// If the earliest element falls into a gap between windows, it is discarded on the next loop
+				if !earliest.Before(start) {
+					break
+				}
+			}
+
+			// Read until an element is at or after the end of the window, or there are no more elements
+			end := start.Add(size)
+			for extractTime(buf[len(buf)-1]).Before(end) {
+				if ok, err := next(); err != nil {
+					return nil, err
+				} else if !ok {
+					break
+				}
+			}
+
+			var window []T
+			for _, val := range buf {
+				if t := extractTime(val); !t.Before(start) && t.Before(end) {
+					window = append(window, val)
+				}
+			}
+
+			return window, nil
+		})
+	}
+}
+
 // ==== Math
 
 // Abs converts all elements into their absolute values.
@@ -907,7 +1323,7 @@ func AbsBigOps[T constraint.BigOps[T]](it iter.Iter[T]) iter.Iter[T] {
 
 // AvgInt reduces all signed integer elements in the input set to their average. If the input set is empty, the result is empty.
 // The average is rounded.
-// See math.Add, math.Div.
+// See math.AddChecked, math.Div.
 func AvgInt[T constraint.SignedInteger](it iter.Iter[T]) iter.Iter[T] {
 	return iter.OfIter(
 		func() (T, error) {
@@ -923,7 +1339,7 @@ func AvgInt[T constraint.SignedInteger](it iter.Iter[T]) iter.Iter[T] {
 			for {
 				if val, err = it.Next(); err == nil {
 					// Sum all values and count them, checking for over/underflow
-					if err = math.AddInt(val, &sum); err != nil {
+					if sum, err = math.AddChecked(sum, val); err != nil {
 						return zv, err
 					}
 					count++
@@ -951,7 +1367,7 @@ func AvgInt[T constraint.SignedInteger](it iter.Iter[T]) iter.Iter[T] {
 
 // AvgUint reduces all unsigned integer elements in the input set to their average. If the input set is empty, the result is empty.
 // The average is rounded.
-// See math.Add, math.Div.
+// See math.AddChecked, math.Div.
 func AvgUint[T constraint.UnsignedInteger](it iter.Iter[T]) iter.Iter[T] {
 	return iter.OfIter(
 		func() (T, error) {
@@ -967,7 +1383,7 @@ func AvgUint[T constraint.UnsignedInteger](it iter.Iter[T]) iter.Iter[T] {
 			for {
 				if val, err = it.Next(); err == nil {
 					// Sum all values and count them, checking for overflow
-					if err = math.AddUint(val, &sum); err != nil {
+					if sum, err = math.AddChecked(sum, val); err != nil {
 						return zv, err
 					}
 					count++
@@ -1036,6 +1452,9 @@ func AvgBigOps[T constraint.BigOps[T]](it iter.Iter[T]) iter.Iter[T] {
 	)
 }
 
+// Max, Min and Sum have no specialized []int64 or []float64 paths: collecting the input into a slice with
+// ReduceToSlice costs more than the generic Reduce loop it would replace.
+
 // Max calculates the maximum value of any primitive numeric type or string. If the input set is empty, the result is empty.
 func Max[T constraint.Ordered](it iter.Iter[T]) iter.Iter[T] {
 	return Reduce(
@@ -1104,8 +1523,11 @@ func generateRanges(numItems uint, info []PInfo) [][]uint {
 	var sliceRanges [][]uint
 
 	if len(info) == 0 {
-		// Use square root when no PInfo given
-		bucketSize := uint(gomath.Round(gomath.Sqrt(float64(numItems))))
+		// Use square root when no PInfo given, rounded to the nearest int: n is closer to (r + 1) ^ 2 if n - r ^ 2 > r
+		bucketSize, _ := math.MustISqrt(numItems)
+		if numItems-bucketSize*bucketSize > bucketSize {
+			bucketSize++
+		}
 		numThreads, remainder := bits.Div(0, numItems, bucketSize)
 
 		// Algorithm has int sqrt number of threads + additional thread if remainder > 0
@@ -1180,6 +1602,13 @@ func generateRanges(numItems uint, info []PInfo) [][]uint {
 //
 // If types T and U are the same, then a single slice is allocated to contain the input and modified in place to produce
 // the output. Otherwise, two slices are allocated, one for input and one for output.
+//
+// The transforms may produce fewer elements than they are given, such as a filter or reduction, in which case the
+// result is the elements produced by each thread, in thread order. Eg, a reduction produces one result per thread, that
+// can be combined into a final result by another reduction.
+//
+// If the input has two or more items, the resulting iter returns an error if the transforms of any thread produce more
+// elements than they are given.
 func Parallel[T, U any](transforms func(iter.Iter[T]) iter.Iter[U], info ...PInfo) func(iter.Iter[T]) iter.Iter[U] {
 	return func(source iter.Iter[T]) iter.Iter[U] {
 		// Get values into a slice
@@ -1221,8 +1650,9 @@ func Parallel[T, U any](transforms func(iter.Iter[T]) iter.Iter[U], info ...PInf
 
 		// Create a WaitGroup that can wait for all threads to complete
 		var (
-			wg   sync.WaitGroup
-			errs = make([]error, len(sliceRanges))
+			wg     sync.WaitGroup
+			errs   = make([]error, len(sliceRanges))
+			counts = make([]int, len(sliceRanges))
 		)
 
 		// The function to execute in each thread, accepting source and target subslices
@@ -1230,11 +1660,27 @@ func Parallel[T, U any](transforms func(iter.Iter[T]) iter.Iter[U], info ...PInf
 			// Decrement number of threads remaining once transforms are complete
 			defer wg.Done()
 
-			// Perform transforms and copy to output
-			_, threadErr := ReduceIntoSlice(out)(transforms(iter.OfSlice(in))).Next()
+			// Perform transforms and copy to output, counting the results in case there are fewer results than inputs
+			it := transforms(iter.OfSlice(in))
+			for {
+				val, threadErr := it.Next()
+				if threadErr != nil {
+					if threadErr != iter.EOI {
+						// In case an error occurs, populate the appropriate errs slot
+						errs[threadNum] = threadErr
+					}
+
+					break
+				}
 
-			// In case an error occurs, populate the appropriate errs slot
-			errs[threadNum] = threadErr
+				if counts[threadNum] == len(out) {
+					errs[threadNum] = fmt.Errorf(errParallelMoreMsg, len(in))
+					break
+				}
+
+				out[counts[threadNum]] = val
+				counts[threadNum]++
+			}
 		}
 
 		// Create the threads, passing a subslice of input to each thread for processing
@@ -1255,6 +1701,16 @@ func Parallel[T, U any](transforms func(iter.Iter[T]) iter.Iter[U], info ...PInf
 			}
 		}
 
-		return funcs.Ternary(err == nil, iter.OfSlice(output), iter.SetError(iter.OfEmpty[U](), err))
+		if err != nil {
+			return iter.SetError(iter.OfEmpty[U](), err)
+		}
+
+		// Move the results of each thread to follow the results of the previous thread
+		n := 0
+		for threadNum, sliceRange := range sliceRanges {
+			n += copy(output[n:], output[sliceRange[0]:sliceRange[0]+uint(counts[threadNum])])
+		}
+
+		return iter.OfSlice(output[:n])
 	}
 }
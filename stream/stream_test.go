@@ -14,7 +14,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"math/big"
 	"strconv"
+	"sync"
 	"testing"
+	"time"
 )
 
 // ==== Foundation funcs
@@ -37,6 +39,119 @@ func TestMapError_(t *testing.T) {
 	assert.Equal(t, union.OfError[int](&strconv.NumError{Func: "Atoi", Num: "3.25", Err: strconv.ErrSyntax}), iter.Maybe(it))
 }
 
+func TestMapErrorPartition_(t *testing.T) {
+	var (
+		errA   = &strconv.NumError{Func: "Atoi", Num: "a", Err: strconv.ErrSyntax}
+		errB   = &strconv.NumError{Func: "Atoi", Num: "b", Err: strconv.ErrSyntax}
+		ok, ko = MapErrorPartition(strconv.Atoi)(iter.Of("1", "a", "2", "b", "3"))
+	)
+
+	// Reading successes keeps the failures for later
+	assert.Equal(t, union.OfResult(1), iter.Maybe(ok))
+	assert.Equal(t, union.OfResult(2), iter.Maybe(ok))
+	assert.Equal(t, union.OfResult(tuple.Of2[string, error]("a", errA)), iter.Maybe(ko))
+	assert.Equal(t, union.OfResult(3), iter.Maybe(ok))
+	assert.Equal(t, union.OfError[int](iter.EOI), iter.Maybe(ok))
+	assert.Equal(t, union.OfResult(tuple.Of2[string, error]("b", errB)), iter.Maybe(ko))
+	assert.Equal(t, union.OfError[tuple.Two[string, error]](iter.EOI), iter.Maybe(ko))
+
+	// Reading failures first
+	ok, ko = MapErrorPartition(strconv.Atoi)(iter.Of("1", "a"))
+	assert.Equal(t, union.OfResult(tuple.Of2[string, error]("a", errA)), iter.Maybe(ko))
+	assert.Equal(t, union.OfError[tuple.Two[string, error]](iter.EOI), iter.Maybe(ko))
+	assert.Equal(t, union.OfResult(1), iter.Maybe(ok))
+	assert.Equal(t, union.OfError[int](iter.EOI), iter.Maybe(ok))
+
+	// A source error is returned by both iters after the elements they already have
+	anErr := fmt.Errorf("anErr")
+	ok, ko = MapErrorPartition(strconv.Atoi)(iter.OfScript(iter.ValueStep("a"), iter.ValueStep("1"), iter.ErrorStep[string](anErr)))
+	assert.Equal(t, union.OfResult(1), iter.Maybe(ok))
+	assert.Equal(t, union.OfError[int](anErr), iter.Maybe(ok))
+	assert.Equal(t, union.OfResult(tuple.Of2[string, error]("a", errA)), iter.Maybe(ko))
+	assert.Equal(t, union.OfError[tuple.Two[string, error]](anErr), iter.Maybe(ko))
+
+	// Concurrent readers
+	var (
+		src  = make([]string, 1000)
+		wg   sync.WaitGroup
+		sum  int
+		nbad int
+	)
+
+	for i := range src {
+		src[i] = funcs.Ternary(i%3 == 0, "x", strconv.Itoa(i))
+	}
+
+	ok, ko = MapErrorPartition(strconv.Atoi)(iter.OfSlice(src))
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for val, err := ok.Next(); err == nil; val, err = ok.Next() {
+			sum += val
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for _, err := ko.Next(); err == nil; _, err = ko.Next() {
+			nbad++
+		}
+	}()
+	wg.Wait()
+
+	assert.Equal(t, 334, nbad)
+	assert.Equal(t, 999*1000/2-3*333*334/2, sum)
+}
+
+func TestBranch_(t *testing.T) {
+	type result = tuple.Two[string, int]
+
+	var (
+		parity   = func(val int) string { return funcs.Ternary(val%2 == 0, "even", "odd") }
+		branches = map[string]func(iter.Iter[int]) iter.Iter[int]{
+			"even": Map(func(val int) int { return val * 10 }),
+			"odd":  Filter(func(val int) bool { return val > 1 }),
+		}
+		it = Branch(parity, branches)(iter.Of(1, 2, 3, 4, 5, 6))
+	)
+
+	// One element from each branch in turn, in order of name, until all branches end
+	assert.Equal(t, union.OfResult(tuple.Of2("even", 20)), iter.Maybe(it))
+	assert.Equal(t, union.OfResult(tuple.Of2("odd", 3)), iter.Maybe(it))
+	assert.Equal(t, union.OfResult(tuple.Of2("even", 40)), iter.Maybe(it))
+	assert.Equal(t, union.OfResult(tuple.Of2("odd", 5)), iter.Maybe(it))
+	assert.Equal(t, union.OfResult(tuple.Of2("even", 60)), iter.Maybe(it))
+	assert.Equal(t, union.OfError[result](iter.EOI), iter.Maybe(it))
+
+	// A branch that reduces its input, and a branch that receives no elements
+	branches["odd"] = Count[int]
+	branches["none"] = Map(func(val int) int { return -val })
+	it = Branch(parity, branches)(iter.Of(1, 2, 3, 4, 5))
+	assert.Equal(t, union.OfResult(tuple.Of2("even", 20)), iter.Maybe(it))
+	assert.Equal(t, union.OfResult(tuple.Of2("odd", 3)), iter.Maybe(it))
+	assert.Equal(t, union.OfResult(tuple.Of2("even", 40)), iter.Maybe(it))
+	assert.Equal(t, union.OfError[result](iter.EOI), iter.Maybe(it))
+
+	// A selector result that is not a branch
+	it = Branch(func(int) string { return "other" }, branches)(iter.Of(1))
+	assert.Equal(t, union.OfError[result](fmt.Errorf(errBranchUnknownMsg, "other")), iter.Maybe(it))
+	assert.Equal(t, union.OfError[result](fmt.Errorf(errBranchUnknownMsg, "other")), iter.Maybe(it))
+
+	// A source error is returned after the elements before it
+	anErr := fmt.Errorf("anErr")
+	it = Branch(parity, branches)(iter.OfScript(iter.ValueStep(2), iter.ErrorStep[int](anErr)))
+	assert.Equal(t, union.OfResult(tuple.Of2("even", 20)), iter.Maybe(it))
+	assert.Equal(t, union.OfError[result](anErr), iter.Maybe(it))
+
+	// No branches
+	funcs.TryTo(
+		func() {
+			Branch(parity, map[string]func(iter.Iter[int]) iter.Iter[int]{})
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf(errBranchNoneMsg), e) },
+	)
+}
+
 func TestFilter_(t *testing.T) {
 	it := Filter(func(val int) bool { return val > 1 })(iter.Of(1, 2))
 	assert.Equal(t, union.OfResult(2), iter.Maybe(it))
@@ -413,6 +528,55 @@ func TestCount_(t *testing.T) {
 	assert.Equal(t, union.OfError[int](iter.EOI), iter.Maybe(it))
 }
 
+func TestDelta_(t *testing.T) {
+	sub := Delta(func(c, p int) int { return c - p })
+
+	it := sub(iter.OfEmpty[int]())
+	assert.Equal(t, union.OfError[int](iter.EOI), iter.Maybe(it))
+
+	it = sub(iter.OfOne(1))
+	assert.Equal(t, union.OfError[int](iter.EOI), iter.Maybe(it))
+
+	it = sub(iter.Of(1, 3, 6, 10))
+	assert.Equal(t, union.OfResult([]int{2, 3, 4}), iter.Maybe(ReduceToSlice(it)))
+	assert.Equal(t, union.OfError[int](iter.EOI), iter.Maybe(it))
+
+	// Result type can differ
+	its := Delta(func(c, p int) string { return strconv.Itoa(c) + "-" + strconv.Itoa(p) })(iter.Of(1, 3))
+	assert.Equal(t, union.OfResult("3-1"), iter.Maybe(its))
+	assert.Equal(t, union.OfError[string](iter.EOI), iter.Maybe(its))
+
+	// Errors
+	err := fmt.Errorf("An err")
+	it = sub(iter.SetError(iter.Of(1, 2), err))
+	assert.Equal(t, union.OfResult(1), iter.Maybe(it))
+	assert.Equal(t, union.OfError[int](err), iter.Maybe(it))
+}
+
+func TestDeltaByKey_(t *testing.T) {
+	sub := DeltaByKey(
+		func(kv tuple.Two[string, int]) string { return kv.T },
+		func(c, p tuple.Two[string, int]) int { return c.U - p.U },
+	)
+
+	it := sub(iter.OfEmpty[tuple.Two[string, int]]())
+	assert.Equal(t, union.OfError[int](iter.EOI), iter.Maybe(it))
+
+	it = sub(iter.Of(tuple.Of2("a", 1), tuple.Of2("b", 5), tuple.Of2("a", 3), tuple.Of2("b", 6), tuple.Of2("a", 7)))
+	assert.Equal(t, union.OfResult([]int{2, 1, 4}), iter.Maybe(ReduceToSlice(it)))
+	assert.Equal(t, union.OfError[int](iter.EOI), iter.Maybe(it))
+
+	// State is not shared across iters
+	it = sub(iter.Of(tuple.Of2("a", 10)))
+	assert.Equal(t, union.OfError[int](iter.EOI), iter.Maybe(it))
+
+	// Errors
+	err := fmt.Errorf("An err")
+	it = sub(iter.SetError(iter.Of(tuple.Of2("a", 1), tuple.Of2("a", 2)), err))
+	assert.Equal(t, union.OfResult(1), iter.Maybe(it))
+	assert.Equal(t, union.OfError[int](err), iter.Maybe(it))
+}
+
 func TestDistinct_(t *testing.T) {
 	// Distinct
 	it := Distinct(iter.OfEmpty[int]())
@@ -450,6 +614,66 @@ func TestDuplicate_(t *testing.T) {
 	assert.Equal(t, union.OfError[int](iter.EOI), iter.Maybe(it))
 }
 
+func TestFind_(t *testing.T) {
+	var (
+		anErr = fmt.Errorf("an error")
+		even  = func(i int) bool { return i%2 == 0 }
+	)
+
+	assert.Equal(t, tuple.Of3(0, false, error(nil)), tuple.Of3(Find(even)(iter.OfEmpty[int]())))
+	assert.Equal(t, tuple.Of3(0, false, error(nil)), tuple.Of3(Find(even)(iter.Of(1, 3))))
+
+	// Short circuits on first match
+	it := iter.Of(1, 2, 3, 4)
+	assert.Equal(t, tuple.Of3(2, true, error(nil)), tuple.Of3(Find(even)(it)))
+	assert.Equal(t, union.OfResult(3), iter.Maybe(it))
+
+	// Problem before a match
+	it = iter.OfScript(iter.ValueStep(1), iter.ErrorStep[int](anErr), iter.ValueStep(2))
+	assert.Equal(t, tuple.Of3(0, false, anErr), tuple.Of3(Find(even)(it)))
+
+	// Problem after a match is not read
+	it = iter.OfScript(iter.ValueStep(2), iter.ErrorStep[int](anErr))
+	assert.Equal(t, tuple.Of3(2, true, error(nil)), tuple.Of3(Find(even)(it)))
+}
+
+func TestFindLast_(t *testing.T) {
+	var (
+		anErr = fmt.Errorf("an error")
+		even  = func(i int) bool { return i%2 == 0 }
+	)
+
+	assert.Equal(t, tuple.Of3(0, false, error(nil)), tuple.Of3(FindLast(even)(iter.OfEmpty[int]())))
+	assert.Equal(t, tuple.Of3(0, false, error(nil)), tuple.Of3(FindLast(even)(iter.Of(1, 3))))
+	assert.Equal(t, tuple.Of3(4, true, error(nil)), tuple.Of3(FindLast(even)(iter.Of(1, 2, 3, 4, 5))))
+
+	// Problem after a match
+	it := iter.OfScript(iter.ValueStep(2), iter.ErrorStep[int](anErr))
+	assert.Equal(t, tuple.Of3(0, false, anErr), tuple.Of3(FindLast(even)(it)))
+}
+
+func TestPairWise_(t *testing.T) {
+	it := PairWise(iter.OfEmpty[int]())
+	assert.Equal(t, union.OfError[tuple.Two[int, int]](iter.EOI), iter.Maybe(it))
+
+	it = PairWise(iter.OfOne(1))
+	assert.Equal(t, union.OfError[tuple.Two[int, int]](iter.EOI), iter.Maybe(it))
+
+	it = PairWise(iter.Of(1, 2, 3))
+	assert.Equal(t, union.OfResult(tuple.Of2(1, 2)), iter.Maybe(it))
+	assert.Equal(t, union.OfResult(tuple.Of2(2, 3)), iter.Maybe(it))
+	assert.Equal(t, union.OfError[tuple.Two[int, int]](iter.EOI), iter.Maybe(it))
+
+	// Errors
+	err := fmt.Errorf("An err")
+	it = PairWise(iter.SetError(iter.OfEmpty[int](), err))
+	assert.Equal(t, union.OfError[tuple.Two[int, int]](err), iter.Maybe(it))
+
+	it = PairWise(iter.SetError(iter.Of(1, 2), err))
+	assert.Equal(t, union.OfResult(tuple.Of2(1, 2)), iter.Maybe(it))
+	assert.Equal(t, union.OfError[tuple.Two[int, int]](err), iter.Maybe(it))
+}
+
 func TestReverse_(t *testing.T) {
 	it := Reverse(iter.OfEmpty[int]())
 	assert.Equal(t, union.OfResult([]int{}), iter.Maybe(ReduceToSlice(it)))
@@ -522,6 +746,97 @@ func TestSortBy_(t *testing.T) {
 
 // ==== Math
 
+func TestWindowByTime_(t *testing.T) {
+	// Elements are seconds after a base time
+	var (
+		base = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		ts   = func(secs int) time.Time { return base.Add(time.Duration(secs) * time.Second) }
+		secs = func(t time.Time) int { return int(t.Sub(base) / time.Second) }
+		of   = func(s ...int) iter.Iter[time.Time] {
+			var times []time.Time
+			for _, v := range s {
+				times = append(times, ts(v))
+			}
+			return iter.OfSlice(times)
+		}
+		collect = func(it iter.Iter[[]time.Time]) [][]int {
+			var res [][]int
+			for w, err := it.Next(); err == nil; w, err = it.Next() {
+				var ws []int
+				for _, v := range w {
+					ws = append(ws, secs(v))
+				}
+				res = append(res, ws)
+			}
+			assert.Equal(t, iter.EOI, iter.Err(it))
+			return res
+		}
+		id = func(t time.Time) time.Time { return t }
+	)
+
+	// Tumbling
+	tumble := WindowByTime(id, 10*time.Second, 10*time.Second)
+	assert.Nil(t, collect(tumble(of())))
+	assert.Equal(t, [][]int{{5}}, collect(tumble(of(5))))
+	assert.Equal(t, [][]int{{0, 3, 9}, {10, 19}, {20}}, collect(tumble(of(0, 3, 9, 10, 19, 20))))
+
+	// Empty windows are skipped
+	assert.Equal(t, [][]int{{0, 1}, {35}, {41}}, collect(tumble(of(0, 1, 35, 41))))
+
+	// Late elements are discarded
+	assert.Equal(t, [][]int{{0, 5}, {12, 15}}, collect(tumble(of(0, 5, 12, 3, 15))))
+
+	// Sliding
+	slide := WindowByTime(id, 10*time.Second, 5*time.Second)
+	assert.Equal(t, [][]int{{0, 4, 7}, {7, 12}, {12}}, collect(slide(of(0, 4, 7, 12))))
+	assert.Equal(t, [][]int{{0}, {30, 33}, {30, 33}}, collect(slide(of(0, 30, 33))))
+
+	// Gaps
+	gap := WindowByTime(id, 5*time.Second, 10*time.Second)
+	assert.Equal(t, [][]int{{0, 4}, {10, 11}, {31}}, collect(gap(of(0, 4, 7, 10, 11, 17, 25, 31))))
+
+	// Errors
+	err := fmt.Errorf("An err")
+	it := tumble(iter.SetError(iter.OfEmpty[time.Time](), err))
+	assert.Equal(t, union.OfError[[]time.Time](err), iter.Maybe(it))
+
+	it = tumble(iter.SetError(of(0, 1, 10), err))
+	assert.Equal(t, union.OfResult([]time.Time{ts(0), ts(1)}), iter.Maybe(it))
+	assert.Equal(t, union.OfError[[]time.Time](err), iter.Maybe(it))
+
+	it = tumble(iter.SetError(of(0, 1), err))
+	assert.Equal(t, union.OfError[[]time.Time](err), iter.Maybe(it))
+
+	// Elements of any type
+	type event struct {
+		at   time.Time
+		name string
+	}
+	ite := WindowByTime(func(e event) time.Time { return e.at }, time.Minute, time.Minute)(
+		iter.Of(event{ts(0), "a"}, event{ts(59), "b"}, event{ts(60), "c"}),
+	)
+	assert.Equal(t, union.OfResult([]event{{ts(0), "a"}, {ts(59), "b"}}), iter.Maybe(ite))
+	assert.Equal(t, union.OfResult([]event{{ts(60), "c"}}), iter.Maybe(ite))
+	assert.Equal(t, union.OfError[[]event](iter.EOI), iter.Maybe(ite))
+
+	// Invalid durations
+	funcs.TryTo(
+		func() {
+			WindowByTime(id, 0, time.Second)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf(errWindowDurationMsg, time.Duration(0), time.Second), e) },
+	)
+
+	funcs.TryTo(
+		func() {
+			WindowByTime(id, time.Second, -time.Second)
+			assert.Fail(t, "Must die")
+		},
+		func(e any) { assert.Equal(t, fmt.Errorf(errWindowDurationMsg, time.Second, -time.Second), e) },
+	)
+}
+
 func TestAbs_(t *testing.T) {
 	it := Abs(iter.Of(-1, 5, gomath.MinInt))
 	assert.Equal(t, union.OfResult(1), iter.Maybe(it))
@@ -728,6 +1043,43 @@ func TestParallel_(t *testing.T) {
 	}
 	it = ReduceToSlice(Parallel(fn)(iter.Of(1, 2)))
 	assert.Equal(t, union.OfError[[]int](anErr), iter.Maybe(it))
+
+	// Transforms that produce fewer elements only keep the elements produced, in thread order
+	for _, tc := range []struct {
+		fn       func(iter.Iter[int]) iter.Iter[int]
+		info     PInfo
+		n        int
+		expected []int
+	}{
+		{Count[int], PInfo{3, Threads}, 7, []int{3, 2, 2}},
+		{Count[int], PInfo{2, Items}, 5, []int{2, 2, 1}},
+		{Filter(func(i int) bool { return i%2 == 0 }), PInfo{2, Items}, 7, []int{2, 4, 6}},
+	} {
+		var in []int
+		for i := 1; i <= tc.n; i++ {
+			in = append(in, i)
+		}
+
+		assert.Equal(t, union.OfResult(tc.expected), iter.Maybe(ReduceToSlice(Parallel(tc.fn, tc.info)(iter.OfSlice(in)))))
+	}
+
+	countStr := funcs.Compose2(Count[int], Map(func(i int) string { return fmt.Sprint(i) }))
+	assert.Equal(
+		t,
+		union.OfResult([]string{"3", "2", "2"}),
+		iter.Maybe(ReduceToSlice(Parallel(countStr, PInfo{3, Threads})(iter.Of(1, 2, 3, 4, 5, 6, 7)))),
+	)
+
+	// Transforms that produce more elements are an error
+	twice := func(it iter.Iter[int]) iter.Iter[int] {
+		vals := funcs.MustValue(ReduceToSlice(it).Next())
+		return iter.OfSlice(append(vals, vals...))
+	}
+	assert.Equal(
+		t,
+		union.OfError[[]int](fmt.Errorf(errParallelMoreMsg, 3)),
+		iter.Maybe(ReduceToSlice(Parallel(twice, PInfo{3, Threads})(iter.Of(1, 2, 3, 4, 5, 6, 7)))),
+	)
 }
 
 // // ==== Composition